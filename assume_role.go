@@ -0,0 +1,90 @@
+package cocoa
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+)
+
+// AssumeRoleOptions are options to assume an AWS role for individual calls
+// rather than for the lifetime of a client. This makes it possible for a
+// single client to act on resources in other accounts (e.g. to create pods in
+// customer accounts from a multi-tenant control plane).
+type AssumeRoleOptions struct {
+	// RoleARN is the ARN of the role to assume. This is required.
+	RoleARN *string
+	// ExternalID is the external ID required by the role's trust policy, if
+	// any.
+	ExternalID *string
+}
+
+// NewAssumeRoleOptions returns new uninitialized options to assume a role.
+func NewAssumeRoleOptions() *AssumeRoleOptions {
+	return &AssumeRoleOptions{}
+}
+
+// SetRoleARN sets the ARN of the role to assume.
+func (o *AssumeRoleOptions) SetRoleARN(role string) *AssumeRoleOptions {
+	o.RoleARN = &role
+	return o
+}
+
+// SetExternalID sets the external ID required by the role's trust policy.
+func (o *AssumeRoleOptions) SetExternalID(id string) *AssumeRoleOptions {
+	o.ExternalID = &id
+	return o
+}
+
+// Validate checks that the role ARN is a valid IAM role ARN and that the
+// external ID, if given, is non-empty.
+func (o *AssumeRoleOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	role := utility.FromStringPtr(o.RoleARN)
+	if role == "" {
+		catcher.New("must specify a role ARN")
+	} else if parsed, err := arn.Parse(role); err != nil {
+		catcher.Wrapf(err, "parsing role ARN '%s'", role)
+	} else {
+		catcher.ErrorfWhen(parsed.Service != "iam", "role ARN '%s' must be an IAM ARN, but has service '%s'", role, parsed.Service)
+	}
+	catcher.NewWhen(o.ExternalID != nil && *o.ExternalID == "", "cannot specify an empty external ID")
+	return catcher.Resolve()
+}
+
+// hash returns the hash digest of the assume role options.
+func (o *AssumeRoleOptions) hash() string {
+	h := utility.NewSHA1Hash()
+
+	if o.RoleARN != nil {
+		h.Add("role_arn")
+		h.Add(*o.RoleARN)
+	}
+
+	if o.ExternalID != nil {
+		h.Add("external_id")
+		h.Add(*o.ExternalID)
+	}
+
+	return h.Sum()
+}
+
+type assumeRoleContextKey struct{}
+
+// ContextWithAssumeRole returns a copy of the context that makes the AWS
+// clients in cocoa assume the given role for any calls that use the returned
+// context.
+func ContextWithAssumeRole(ctx context.Context, opts AssumeRoleOptions) context.Context {
+	return context.WithValue(ctx, assumeRoleContextKey{}, opts)
+}
+
+// AssumeRoleFromContext returns the role to assume for calls that use the
+// context, if any.
+func AssumeRoleFromContext(ctx context.Context) *AssumeRoleOptions {
+	opts, ok := ctx.Value(assumeRoleContextKey{}).(AssumeRoleOptions)
+	if !ok {
+		return nil
+	}
+	return &opts
+}
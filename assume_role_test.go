@@ -0,0 +1,68 @@
+package cocoa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssumeRoleOptions(t *testing.T) {
+	const role = "arn:aws:iam::123456789012:role/role"
+
+	t.Run("NewAssumeRoleOptions", func(t *testing.T) {
+		opts := NewAssumeRoleOptions()
+		require.NotZero(t, opts)
+		assert.Zero(t, *opts)
+	})
+	t.Run("SetRoleARN", func(t *testing.T) {
+		opts := NewAssumeRoleOptions().SetRoleARN(role)
+		assert.Equal(t, role, utility.FromStringPtr(opts.RoleARN))
+	})
+	t.Run("SetExternalID", func(t *testing.T) {
+		opts := NewAssumeRoleOptions().SetExternalID("external_id")
+		assert.Equal(t, "external_id", utility.FromStringPtr(opts.ExternalID))
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("SucceedsWithRoleARN", func(t *testing.T) {
+			assert.NoError(t, NewAssumeRoleOptions().SetRoleARN(role).Validate())
+		})
+		t.Run("SucceedsWithRoleARNAndExternalID", func(t *testing.T) {
+			assert.NoError(t, NewAssumeRoleOptions().SetRoleARN(role).SetExternalID("external_id").Validate())
+		})
+		t.Run("FailsWithNoFieldsPopulated", func(t *testing.T) {
+			assert.Error(t, NewAssumeRoleOptions().Validate())
+		})
+		t.Run("FailsWithoutRoleARN", func(t *testing.T) {
+			assert.Error(t, NewAssumeRoleOptions().SetExternalID("external_id").Validate())
+		})
+		t.Run("FailsWithRoleNameInsteadOfARN", func(t *testing.T) {
+			assert.Error(t, NewAssumeRoleOptions().SetRoleARN("role").Validate())
+		})
+		t.Run("FailsWithNonIAMARN", func(t *testing.T) {
+			assert.Error(t, NewAssumeRoleOptions().SetRoleARN("arn:aws:ecs:us-east-1:123456789012:cluster/cluster").Validate())
+		})
+		t.Run("FailsWithEmptyExternalID", func(t *testing.T) {
+			assert.Error(t, NewAssumeRoleOptions().SetRoleARN(role).SetExternalID("").Validate())
+		})
+	})
+}
+
+func TestContextWithAssumeRole(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.Zero(t, AssumeRoleFromContext(ctx))
+
+	opts := NewAssumeRoleOptions().
+		SetRoleARN("arn:aws:iam::123456789012:role/role").
+		SetExternalID("external_id")
+	roleCtx := ContextWithAssumeRole(ctx, *opts)
+
+	fromCtx := AssumeRoleFromContext(roleCtx)
+	require.NotZero(t, fromCtx)
+	assert.Equal(t, *opts, *fromCtx)
+	assert.Zero(t, AssumeRoleFromContext(ctx), "original context should not be modified")
+}
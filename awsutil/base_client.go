@@ -2,8 +2,13 @@ package awsutil
 
 import (
 	"context"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/evergreen-ci/cocoa"
 	"github.com/evergreen-ci/utility"
 	"github.com/pkg/errors"
 )
@@ -13,11 +18,24 @@ import (
 type BaseClient struct {
 	opts   ClientOptions
 	config *aws.Config
+	// assumedRoles caches the credentials for roles assumed for individual
+	// calls so that they are reused until they expire.
+	assumedRoles *assumedRoleCredentials
+}
+
+// assumedRoleCredentials is a cache of credentials providers for assumed roles.
+type assumedRoleCredentials struct {
+	mu        sync.Mutex
+	stsClient *sts.Client
+	providers map[string]aws.CredentialsProvider
 }
 
 // NewBaseClient creates a new base AWS client from the client options.
 func NewBaseClient(opts ClientOptions) BaseClient {
-	return BaseClient{opts: opts}
+	return BaseClient{
+		opts:         opts,
+		assumedRoles: &assumedRoleCredentials{providers: map[string]aws.CredentialsProvider{}},
+	}
 }
 
 // GetConfig ensures that the config is initialized and returns it.
@@ -40,6 +58,20 @@ func (c *BaseClient) GetConfig(ctx context.Context) (*aws.Config, error) {
 	return c.config, nil
 }
 
+// Close releases the idle network connections held by the client's HTTP
+// client, if the HTTP client supports it. The client can still be used after
+// it is closed, in which case new connections are opened as needed.
+func (c *BaseClient) Close(ctx context.Context) error {
+	hc := c.opts.HTTPClient
+	if hc == nil && c.config != nil {
+		hc = c.config.HTTPClient
+	}
+	if closer, ok := hc.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+	return nil
+}
+
 // GetRetryOptions returns the retry options for the client.
 func (c *BaseClient) GetRetryOptions() utility.RetryOptions {
 	if c.opts.RetryOpts == nil {
@@ -47,3 +79,52 @@ func (c *BaseClient) GetRetryOptions() utility.RetryOptions {
 	}
 	return *c.opts.RetryOpts
 }
+
+// GetCircuitBreakerAPIOptions returns the API options that guard a single
+// operation with the client's circuit breaker. If the client does not have a
+// circuit breaker, it returns nil.
+func (c *BaseClient) GetCircuitBreakerAPIOptions() []func(*middleware.Stack) error {
+	if c.opts.CircuitBreaker == nil {
+		return nil
+	}
+	return []func(*middleware.Stack) error{c.opts.CircuitBreaker.addMiddleware}
+}
+
+// GetContextCredentials returns the credentials provider for the role to
+// assume from the context (see cocoa.ContextWithAssumeRole). If the context
+// does not specify a role to assume, it returns nil, in which case the client's
+// own credentials should be used. The role is assumed using the client's own
+// credentials, so the config must already be initialized.
+func (c *BaseClient) GetContextCredentials(ctx context.Context) (aws.CredentialsProvider, error) {
+	opts := cocoa.AssumeRoleFromContext(ctx)
+	if opts == nil {
+		return nil, nil
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid assume role options")
+	}
+	if c.config == nil {
+		return nil, errors.New("client config must be initialized before assuming a role")
+	}
+	if c.assumedRoles == nil {
+		c.assumedRoles = &assumedRoleCredentials{providers: map[string]aws.CredentialsProvider{}}
+	}
+
+	c.assumedRoles.mu.Lock()
+	defer c.assumedRoles.mu.Unlock()
+
+	key := utility.FromStringPtr(opts.RoleARN) + "|" + utility.FromStringPtr(opts.ExternalID)
+	if provider, ok := c.assumedRoles.providers[key]; ok {
+		return provider, nil
+	}
+
+	if c.assumedRoles.stsClient == nil {
+		c.assumedRoles.stsClient = sts.NewFromConfig(*c.config)
+	}
+	provider := aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(c.assumedRoles.stsClient, *opts.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.ExternalID = opts.ExternalID
+	}))
+	c.assumedRoles.providers[key] = provider
+
+	return provider, nil
+}
@@ -0,0 +1,65 @@
+package awsutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseClientGetContextCredentials(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newClient := func(t *testing.T) BaseClient {
+		c := NewBaseClient(*NewClientOptions().
+			SetRegion("us-east-1").
+			SetCredentialsProvider(credentials.NewStaticCredentialsProvider("access_key", "secret_key", "")))
+		_, err := c.GetConfig(ctx)
+		require.NoError(t, err)
+		return c
+	}
+	roleOpts := cocoa.NewAssumeRoleOptions().SetRoleARN("arn:aws:iam::123456789012:role/role")
+
+	t.Run("ReturnsNilWithoutRoleInContext", func(t *testing.T) {
+		c := newClient(t)
+		creds, err := c.GetContextCredentials(ctx)
+		assert.NoError(t, err)
+		assert.Zero(t, creds)
+	})
+	t.Run("ReturnsCredentialsForRoleInContext", func(t *testing.T) {
+		c := newClient(t)
+		creds, err := c.GetContextCredentials(cocoa.ContextWithAssumeRole(ctx, *roleOpts))
+		assert.NoError(t, err)
+		assert.NotZero(t, creds)
+	})
+	t.Run("ReusesCredentialsForSameRole", func(t *testing.T) {
+		c := newClient(t)
+		creds0, err := c.GetContextCredentials(cocoa.ContextWithAssumeRole(ctx, *roleOpts))
+		require.NoError(t, err)
+		creds1, err := c.GetContextCredentials(cocoa.ContextWithAssumeRole(ctx, *roleOpts))
+		require.NoError(t, err)
+		assert.True(t, creds0 == creds1, "should reuse credentials for the same role")
+
+		otherOpts := *roleOpts
+		otherOpts.SetExternalID("external_id")
+		creds2, err := c.GetContextCredentials(cocoa.ContextWithAssumeRole(ctx, otherOpts))
+		require.NoError(t, err)
+		assert.False(t, creds0 == creds2, "should not reuse credentials for a different external ID")
+	})
+	t.Run("FailsWithInvalidRoleInContext", func(t *testing.T) {
+		c := newClient(t)
+		creds, err := c.GetContextCredentials(cocoa.ContextWithAssumeRole(ctx, *cocoa.NewAssumeRoleOptions()))
+		assert.Error(t, err)
+		assert.Zero(t, creds)
+	})
+	t.Run("FailsWithUninitializedConfig", func(t *testing.T) {
+		c := NewBaseClient(*NewClientOptions().SetRegion("us-east-1"))
+		creds, err := c.GetContextCredentials(cocoa.ContextWithAssumeRole(ctx, *roleOpts))
+		assert.Error(t, err)
+		assert.Zero(t, creds)
+	})
+}
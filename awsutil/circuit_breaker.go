@@ -0,0 +1,334 @@
+package awsutil
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultCircuitBreakerErrorRateThreshold = 0.5
+	defaultCircuitBreakerMinRequests        = 10
+	defaultCircuitBreakerWindow             = time.Minute
+	defaultCircuitBreakerCooldown           = 30 * time.Second
+
+	circuitBreakerMiddlewareID = "CocoaCircuitBreaker"
+)
+
+// CircuitBreakerOptions configure when a circuit breaker stops sending
+// requests to an AWS service and when it starts sending them again.
+type CircuitBreakerOptions struct {
+	// ErrorRateThreshold is the fraction of failed requests within the window
+	// at which the circuit breaker opens. It must be greater than 0 and at most
+	// 1. Defaults to 0.5.
+	ErrorRateThreshold *float64
+	// MinRequests is the minimum number of requests that must be made within
+	// the window before the circuit breaker can open. Defaults to 10.
+	MinRequests *int
+	// Window is the length of time over which requests are counted to
+	// determine the error rate. Defaults to 1 minute.
+	Window *time.Duration
+	// Cooldown is how long the circuit breaker stays open before it allows a
+	// single trial request through to check whether the service has recovered.
+	// Defaults to 30 seconds.
+	Cooldown *time.Duration
+}
+
+// NewCircuitBreakerOptions returns new uninitialized circuit breaker options.
+func NewCircuitBreakerOptions() *CircuitBreakerOptions {
+	return &CircuitBreakerOptions{}
+}
+
+// SetErrorRateThreshold sets the fraction of failed requests at which the
+// circuit breaker opens.
+func (o *CircuitBreakerOptions) SetErrorRateThreshold(threshold float64) *CircuitBreakerOptions {
+	o.ErrorRateThreshold = &threshold
+	return o
+}
+
+// SetMinRequests sets the minimum number of requests within the window before
+// the circuit breaker can open.
+func (o *CircuitBreakerOptions) SetMinRequests(n int) *CircuitBreakerOptions {
+	o.MinRequests = &n
+	return o
+}
+
+// SetWindow sets the length of time over which requests are counted.
+func (o *CircuitBreakerOptions) SetWindow(window time.Duration) *CircuitBreakerOptions {
+	o.Window = &window
+	return o
+}
+
+// SetCooldown sets how long the circuit breaker stays open.
+func (o *CircuitBreakerOptions) SetCooldown(cooldown time.Duration) *CircuitBreakerOptions {
+	o.Cooldown = &cooldown
+	return o
+}
+
+// Validate checks that the circuit breaker options are valid and sets defaults
+// for unspecified options.
+func (o *CircuitBreakerOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.ErrorRateThreshold != nil && (*o.ErrorRateThreshold <= 0 || *o.ErrorRateThreshold > 1), "error rate threshold must be greater than 0 and at most 1")
+	catcher.NewWhen(o.MinRequests != nil && *o.MinRequests <= 0, "minimum number of requests must be positive")
+	catcher.NewWhen(o.Window != nil && *o.Window <= 0, "window must be positive")
+	catcher.NewWhen(o.Cooldown != nil && *o.Cooldown <= 0, "cooldown must be positive")
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	if o.ErrorRateThreshold == nil {
+		threshold := defaultCircuitBreakerErrorRateThreshold
+		o.ErrorRateThreshold = &threshold
+	}
+	if o.MinRequests == nil {
+		minRequests := defaultCircuitBreakerMinRequests
+		o.MinRequests = &minRequests
+	}
+	if o.Window == nil {
+		window := defaultCircuitBreakerWindow
+		o.Window = &window
+	}
+	if o.Cooldown == nil {
+		cooldown := defaultCircuitBreakerCooldown
+		o.Cooldown = &cooldown
+	}
+
+	return nil
+}
+
+// CircuitBreakerState is the state of a circuit breaker.
+type CircuitBreakerState string
+
+const (
+	// CircuitBreakerStateClosed indicates that requests are sent normally.
+	CircuitBreakerStateClosed CircuitBreakerState = "closed"
+	// CircuitBreakerStateOpen indicates that requests fail fast without being
+	// sent.
+	CircuitBreakerStateOpen CircuitBreakerState = "open"
+	// CircuitBreakerStateHalfOpen indicates that the cooldown has elapsed and
+	// a single trial request is allowed through to check whether the service
+	// has recovered.
+	CircuitBreakerStateHalfOpen CircuitBreakerState = "half-open"
+)
+
+// CircuitBreaker tracks the outcome of requests to an AWS service and fails
+// requests fast once too many of them have recently failed, so that callers
+// do not keep waiting on a service that is unavailable. A single circuit
+// breaker can be shared between multiple clients and is safe for concurrent
+// use.
+type CircuitBreaker struct {
+	opts CircuitBreakerOptions
+
+	mu            sync.Mutex
+	state         CircuitBreakerState
+	windowStart   time.Time
+	requests      int
+	failures      int
+	openedAt      time.Time
+	trialInFlight bool
+	// now returns the current time. It can be replaced in tests.
+	now func() time.Time
+}
+
+// NewCircuitBreaker returns a new closed circuit breaker with the given
+// options.
+func NewCircuitBreaker(opts CircuitBreakerOptions) (*CircuitBreaker, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid circuit breaker options")
+	}
+	return &CircuitBreaker{
+		opts:  opts,
+		state: CircuitBreakerStateClosed,
+		now:   time.Now,
+	}, nil
+}
+
+// State returns the current state of the circuit breaker.
+func (cb *CircuitBreaker) State() CircuitBreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.refreshState()
+
+	return cb.state
+}
+
+// Allow returns whether or not a request can be sent. If the request cannot be
+// sent, it also returns the time after which the circuit breaker will allow a
+// trial request through. Every allowed request must have its outcome recorded
+// with RecordSuccess or RecordFailure.
+func (cb *CircuitBreaker) Allow() (bool, time.Time) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.refreshState()
+
+	switch cb.state {
+	case CircuitBreakerStateOpen:
+		return false, cb.retryAfter()
+	case CircuitBreakerStateHalfOpen:
+		if cb.trialInFlight {
+			return false, cb.now()
+		}
+		cb.trialInFlight = true
+		return true, time.Time{}
+	default:
+		return true, time.Time{}
+	}
+}
+
+// RecordSuccess records that an allowed request succeeded.
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.record(circuitBreakerOutcomeSuccess)
+}
+
+// RecordFailure records that an allowed request failed.
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.record(circuitBreakerOutcomeFailure)
+}
+
+type circuitBreakerOutcome int
+
+const (
+	circuitBreakerOutcomeSuccess circuitBreakerOutcome = iota
+	circuitBreakerOutcomeFailure
+	// circuitBreakerOutcomeIgnored indicates that the request finished
+	// without saying anything about the health of the service (e.g. because
+	// the caller's context was cancelled).
+	circuitBreakerOutcomeIgnored
+)
+
+// record records the outcome of an allowed request and updates the state of
+// the circuit breaker.
+func (cb *CircuitBreaker) record(outcome circuitBreakerOutcome) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.refreshState()
+
+	if cb.state == CircuitBreakerStateHalfOpen {
+		cb.trialInFlight = false
+		switch outcome {
+		case circuitBreakerOutcomeSuccess:
+			cb.close()
+		case circuitBreakerOutcomeFailure:
+			cb.open()
+		}
+		return
+	}
+	if cb.state != CircuitBreakerStateClosed || outcome == circuitBreakerOutcomeIgnored {
+		return
+	}
+
+	cb.requests++
+	if outcome == circuitBreakerOutcomeFailure {
+		cb.failures++
+	}
+	if cb.requests >= *cb.opts.MinRequests && float64(cb.failures)/float64(cb.requests) >= *cb.opts.ErrorRateThreshold {
+		cb.open()
+	}
+}
+
+// refreshState moves the circuit breaker to half-open once the cooldown has
+// elapsed and starts a new window once the current window has elapsed. The
+// caller must hold the lock.
+func (cb *CircuitBreaker) refreshState() {
+	now := cb.now()
+	switch cb.state {
+	case CircuitBreakerStateOpen:
+		if !now.Before(cb.retryAfter()) {
+			cb.state = CircuitBreakerStateHalfOpen
+			cb.trialInFlight = false
+		}
+	case CircuitBreakerStateClosed:
+		if now.Sub(cb.windowStart) >= *cb.opts.Window {
+			cb.resetWindow(now)
+		}
+	}
+}
+
+func (cb *CircuitBreaker) retryAfter() time.Time {
+	return cb.openedAt.Add(*cb.opts.Cooldown)
+}
+
+func (cb *CircuitBreaker) open() {
+	cb.state = CircuitBreakerStateOpen
+	cb.openedAt = cb.now()
+}
+
+func (cb *CircuitBreaker) close() {
+	cb.state = CircuitBreakerStateClosed
+	cb.resetWindow(cb.now())
+}
+
+func (cb *CircuitBreaker) resetWindow(now time.Time) {
+	cb.windowStart = now
+	cb.requests = 0
+	cb.failures = 0
+}
+
+// middleware returns an AWS API middleware that fails requests fast with a
+// *cocoa.CircuitOpenError while the circuit breaker is open and records the
+// outcome of every request that is sent.
+func (cb *CircuitBreaker) middleware() middleware.InitializeMiddleware {
+	return middleware.InitializeMiddlewareFunc(circuitBreakerMiddlewareID, func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		if ok, retryAfter := cb.Allow(); !ok {
+			return middleware.InitializeOutput{}, middleware.Metadata{}, cocoa.NewCircuitOpenError(awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx), retryAfter)
+		}
+
+		out, md, err := next.HandleInitialize(ctx, in)
+		cb.record(classifyCircuitBreakerOutcome(ctx, err))
+
+		return out, md, err
+	})
+}
+
+// addMiddleware adds the circuit breaker middleware to the API stack.
+func (cb *CircuitBreaker) addMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(cb.middleware(), middleware.After)
+}
+
+// classifyCircuitBreakerOutcome determines whether a request's error indicates
+// that the service is unhealthy. Server errors, throttling, and errors that
+// prevented a response from being received count as failures. Other client
+// errors (e.g. invalid parameters or missing resources) indicate that the
+// service is responding normally.
+func classifyCircuitBreakerOutcome(ctx context.Context, err error) circuitBreakerOutcome {
+	if err == nil {
+		return circuitBreakerOutcomeSuccess
+	}
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return circuitBreakerOutcomeIgnored
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && cocoa.IsThrottlingErrorCode(apiErr.ErrorCode()) {
+		return circuitBreakerOutcomeFailure
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) && statusErr.HTTPStatusCode() != 0 {
+		code := statusErr.HTTPStatusCode()
+		if code >= http.StatusInternalServerError || code == http.StatusTooManyRequests {
+			return circuitBreakerOutcomeFailure
+		}
+		return circuitBreakerOutcomeSuccess
+	}
+
+	var invalidParamsErr *smithy.InvalidParamsError
+	var paramRequiredErr *smithy.ParamRequiredError
+	if errors.As(err, &invalidParamsErr) || errors.As(err, &paramRequiredErr) {
+		return circuitBreakerOutcomeIgnored
+	}
+
+	return circuitBreakerOutcomeFailure
+}
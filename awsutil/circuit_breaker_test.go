@@ -0,0 +1,274 @@
+package awsutil
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerOptions(t *testing.T) {
+	t.Run("ValidateSetsDefaults", func(t *testing.T) {
+		opts := NewCircuitBreakerOptions()
+		require.NoError(t, opts.Validate())
+		assert.Equal(t, defaultCircuitBreakerErrorRateThreshold, *opts.ErrorRateThreshold)
+		assert.Equal(t, defaultCircuitBreakerMinRequests, *opts.MinRequests)
+		assert.Equal(t, defaultCircuitBreakerWindow, *opts.Window)
+		assert.Equal(t, defaultCircuitBreakerCooldown, *opts.Cooldown)
+	})
+	t.Run("ValidateSucceedsWithAllFieldsSet", func(t *testing.T) {
+		opts := NewCircuitBreakerOptions().
+			SetErrorRateThreshold(1).
+			SetMinRequests(1).
+			SetWindow(time.Second).
+			SetCooldown(time.Second)
+		assert.NoError(t, opts.Validate())
+	})
+	t.Run("ValidateFailsWithZeroErrorRateThreshold", func(t *testing.T) {
+		assert.Error(t, NewCircuitBreakerOptions().SetErrorRateThreshold(0).Validate())
+	})
+	t.Run("ValidateFailsWithErrorRateThresholdAboveOne", func(t *testing.T) {
+		assert.Error(t, NewCircuitBreakerOptions().SetErrorRateThreshold(1.5).Validate())
+	})
+	t.Run("ValidateFailsWithNonpositiveMinRequests", func(t *testing.T) {
+		assert.Error(t, NewCircuitBreakerOptions().SetMinRequests(0).Validate())
+	})
+	t.Run("ValidateFailsWithNonpositiveWindow", func(t *testing.T) {
+		assert.Error(t, NewCircuitBreakerOptions().SetWindow(0).Validate())
+	})
+	t.Run("ValidateFailsWithNonpositiveCooldown", func(t *testing.T) {
+		assert.Error(t, NewCircuitBreakerOptions().SetCooldown(-time.Second).Validate())
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	const (
+		window   = time.Minute
+		cooldown = 10 * time.Second
+	)
+	newCircuitBreaker := func(t *testing.T) (*CircuitBreaker, *time.Time) {
+		cb, err := NewCircuitBreaker(*NewCircuitBreakerOptions().
+			SetErrorRateThreshold(0.5).
+			SetMinRequests(4).
+			SetWindow(window).
+			SetCooldown(cooldown))
+		require.NoError(t, err)
+		now := time.Now()
+		cb.now = func() time.Time { return now }
+		return cb, &now
+	}
+	trip := func(t *testing.T, cb *CircuitBreaker) {
+		for i := 0; i < 4; i++ {
+			ok, _ := cb.Allow()
+			require.True(t, ok)
+			cb.RecordFailure()
+		}
+		require.Equal(t, CircuitBreakerStateOpen, cb.State())
+	}
+
+	t.Run("NewCircuitBreakerFailsWithInvalidOptions", func(t *testing.T) {
+		cb, err := NewCircuitBreaker(*NewCircuitBreakerOptions().SetMinRequests(-1))
+		assert.Error(t, err)
+		assert.Zero(t, cb)
+	})
+	t.Run("StartsClosed", func(t *testing.T) {
+		cb, _ := newCircuitBreaker(t)
+		assert.Equal(t, CircuitBreakerStateClosed, cb.State())
+		ok, _ := cb.Allow()
+		assert.True(t, ok)
+	})
+	t.Run("StaysClosedBelowMinRequests", func(t *testing.T) {
+		cb, _ := newCircuitBreaker(t)
+		for i := 0; i < 3; i++ {
+			cb.RecordFailure()
+		}
+		assert.Equal(t, CircuitBreakerStateClosed, cb.State())
+	})
+	t.Run("StaysClosedBelowErrorRateThreshold", func(t *testing.T) {
+		cb, _ := newCircuitBreaker(t)
+		cb.RecordFailure()
+		for i := 0; i < 4; i++ {
+			cb.RecordSuccess()
+		}
+		assert.Equal(t, CircuitBreakerStateClosed, cb.State())
+	})
+	t.Run("OpensAtErrorRateThreshold", func(t *testing.T) {
+		cb, now := newCircuitBreaker(t)
+		cb.RecordSuccess()
+		cb.RecordSuccess()
+		cb.RecordFailure()
+		cb.RecordFailure()
+		assert.Equal(t, CircuitBreakerStateOpen, cb.State())
+
+		ok, retryAfter := cb.Allow()
+		assert.False(t, ok)
+		assert.True(t, now.Add(cooldown).Equal(retryAfter))
+	})
+	t.Run("ForgetsRequestsFromPreviousWindow", func(t *testing.T) {
+		cb, now := newCircuitBreaker(t)
+		for i := 0; i < 3; i++ {
+			cb.RecordFailure()
+		}
+		*now = now.Add(window)
+		cb.RecordFailure()
+		assert.Equal(t, CircuitBreakerStateClosed, cb.State())
+	})
+	t.Run("BecomesHalfOpenAfterCooldown", func(t *testing.T) {
+		cb, now := newCircuitBreaker(t)
+		trip(t, cb)
+
+		*now = now.Add(cooldown)
+		assert.Equal(t, CircuitBreakerStateHalfOpen, cb.State())
+	})
+	t.Run("HalfOpenAllowsOnlyOneTrialRequest", func(t *testing.T) {
+		cb, now := newCircuitBreaker(t)
+		trip(t, cb)
+		*now = now.Add(cooldown)
+
+		ok, _ := cb.Allow()
+		assert.True(t, ok)
+		ok, _ = cb.Allow()
+		assert.False(t, ok)
+	})
+	t.Run("ClosesAfterSuccessfulTrialRequest", func(t *testing.T) {
+		cb, now := newCircuitBreaker(t)
+		trip(t, cb)
+		*now = now.Add(cooldown)
+
+		ok, _ := cb.Allow()
+		require.True(t, ok)
+		cb.RecordSuccess()
+		assert.Equal(t, CircuitBreakerStateClosed, cb.State())
+
+		cb.RecordFailure()
+		assert.Equal(t, CircuitBreakerStateClosed, cb.State(), "counts should be reset after closing")
+	})
+	t.Run("ReopensAfterFailedTrialRequest", func(t *testing.T) {
+		cb, now := newCircuitBreaker(t)
+		trip(t, cb)
+		*now = now.Add(cooldown)
+
+		ok, _ := cb.Allow()
+		require.True(t, ok)
+		cb.RecordFailure()
+		assert.Equal(t, CircuitBreakerStateOpen, cb.State())
+
+		ok, retryAfter := cb.Allow()
+		assert.False(t, ok)
+		assert.True(t, now.Add(cooldown).Equal(retryAfter), "cooldown should restart")
+	})
+	t.Run("IgnoredTrialRequestAllowsAnotherTrial", func(t *testing.T) {
+		cb, now := newCircuitBreaker(t)
+		trip(t, cb)
+		*now = now.Add(cooldown)
+
+		ok, _ := cb.Allow()
+		require.True(t, ok)
+		cb.record(circuitBreakerOutcomeIgnored)
+		assert.Equal(t, CircuitBreakerStateHalfOpen, cb.State())
+		ok, _ = cb.Allow()
+		assert.True(t, ok)
+	})
+	t.Run("MiddlewareFailsFastWhileOpen", func(t *testing.T) {
+		cb, _ := newCircuitBreaker(t)
+		trip(t, cb)
+
+		var called bool
+		next := middleware.InitializeHandlerFunc(func(ctx context.Context, in middleware.InitializeInput) (middleware.InitializeOutput, middleware.Metadata, error) {
+			called = true
+			return middleware.InitializeOutput{}, middleware.Metadata{}, nil
+		})
+		_, _, err := cb.middleware().HandleInitialize(context.Background(), middleware.InitializeInput{}, next)
+		assert.True(t, cocoa.IsCircuitOpenError(err))
+		assert.False(t, called)
+	})
+	t.Run("MiddlewareRecordsOutcome", func(t *testing.T) {
+		cb, _ := newCircuitBreaker(t)
+
+		next := middleware.InitializeHandlerFunc(func(ctx context.Context, in middleware.InitializeInput) (middleware.InitializeOutput, middleware.Metadata, error) {
+			return middleware.InitializeOutput{}, middleware.Metadata{}, errors.New("connection reset")
+		})
+		for i := 0; i < 4; i++ {
+			_, _, err := cb.middleware().HandleInitialize(context.Background(), middleware.InitializeInput{}, next)
+			assert.Error(t, err)
+		}
+		assert.Equal(t, CircuitBreakerStateOpen, cb.State())
+	})
+}
+
+func TestClassifyCircuitBreakerOutcome(t *testing.T) {
+	newResponseError := func(statusCode int, err error) error {
+		return &smithy.OperationError{
+			ServiceID:     "ECS",
+			OperationName: "RunTask",
+			Err: &awshttp.ResponseError{
+				ResponseError: &smithyhttp.ResponseError{
+					Response: &smithyhttp.Response{Response: &http.Response{StatusCode: statusCode}},
+					Err:      err,
+				},
+			},
+		}
+	}
+	ctx := context.Background()
+
+	t.Run("SuccessWithoutError", func(t *testing.T) {
+		assert.Equal(t, circuitBreakerOutcomeSuccess, classifyCircuitBreakerOutcome(ctx, nil))
+	})
+	t.Run("SuccessWithClientError", func(t *testing.T) {
+		err := newResponseError(http.StatusBadRequest, &types.ClusterNotFoundException{Message: aws.String("cluster not found")})
+		assert.Equal(t, circuitBreakerOutcomeSuccess, classifyCircuitBreakerOutcome(ctx, err))
+	})
+	t.Run("FailureWithServerError", func(t *testing.T) {
+		err := newResponseError(http.StatusInternalServerError, &types.ServerException{Message: aws.String("internal error")})
+		assert.Equal(t, circuitBreakerOutcomeFailure, classifyCircuitBreakerOutcome(ctx, err))
+	})
+	t.Run("FailureWithTooManyRequests", func(t *testing.T) {
+		err := newResponseError(http.StatusTooManyRequests, errors.New("too many requests"))
+		assert.Equal(t, circuitBreakerOutcomeFailure, classifyCircuitBreakerOutcome(ctx, err))
+	})
+	t.Run("FailureWithThrottlingErrorCode", func(t *testing.T) {
+		err := newResponseError(http.StatusBadRequest, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "rate exceeded"})
+		assert.Equal(t, circuitBreakerOutcomeFailure, classifyCircuitBreakerOutcome(ctx, err))
+	})
+	t.Run("FailureWithoutResponse", func(t *testing.T) {
+		assert.Equal(t, circuitBreakerOutcomeFailure, classifyCircuitBreakerOutcome(ctx, errors.New("connection refused")))
+	})
+	t.Run("IgnoredWithInvalidParameters", func(t *testing.T) {
+		assert.Equal(t, circuitBreakerOutcomeIgnored, classifyCircuitBreakerOutcome(ctx, &smithy.InvalidParamsError{Context: "RunTaskInput"}))
+	})
+	t.Run("IgnoredWithCancelledContext", func(t *testing.T) {
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.Equal(t, circuitBreakerOutcomeIgnored, classifyCircuitBreakerOutcome(cctx, cctx.Err()))
+	})
+}
+
+func TestBaseClientGetCircuitBreakerAPIOptions(t *testing.T) {
+	t.Run("ReturnsNothingWithoutCircuitBreaker", func(t *testing.T) {
+		c := NewBaseClient(*NewClientOptions())
+		assert.Empty(t, c.GetCircuitBreakerAPIOptions())
+	})
+	t.Run("AddsCircuitBreakerMiddleware", func(t *testing.T) {
+		cb, err := NewCircuitBreaker(*NewCircuitBreakerOptions())
+		require.NoError(t, err)
+		c := NewBaseClient(*NewClientOptions().SetCircuitBreaker(cb))
+
+		apiOpts := c.GetCircuitBreakerAPIOptions()
+		require.Len(t, apiOpts, 1)
+		stack := middleware.NewStack("test", smithyhttp.NewStackRequest)
+		require.NoError(t, apiOpts[0](stack))
+		_, ok := stack.Initialize.Get(circuitBreakerMiddlewareID)
+		assert.True(t, ok)
+	})
+}
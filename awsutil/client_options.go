@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
 )
@@ -31,9 +32,19 @@ type ClientOptions struct {
 	// HTTPClient is the HTTP client to use to make requests.
 	// If not specified the AWS SDK's default client will be used.
 	HTTPClient config.HTTPClient
-
-	stsClient   *sts.Client
-	stsProvider *stscreds.AssumeRoleProvider
+	// HTTPOpts are options to configure the AWS SDK's default HTTP client
+	// (e.g. to route requests through a proxy). This cannot be specified
+	// together with HTTPClient.
+	HTTPOpts *HTTPOptions
+	// CircuitBreaker is an optional circuit breaker that makes requests fail
+	// fast with a *cocoa.CircuitOpenError once too many recent requests have
+	// failed. The same circuit breaker may be shared between multiple clients.
+	CircuitBreaker *CircuitBreaker
+
+	// builtHTTPClient is the HTTP client built from HTTPOpts, if any.
+	builtHTTPClient config.HTTPClient
+	stsClient       *sts.Client
+	stsProvider     *stscreds.AssumeRoleProvider
 }
 
 // NewClientOptions returns new unconfigured client options.
@@ -71,8 +82,30 @@ func (o *ClientOptions) SetHTTPClient(hc config.HTTPClient) *ClientOptions {
 	return o
 }
 
-// Validate sets defaults for unspecified options.
+// SetHTTPOptions sets the options to configure the default HTTP client.
+func (o *ClientOptions) SetHTTPOptions(opts HTTPOptions) *ClientOptions {
+	o.HTTPOpts = &opts
+	return o
+}
+
+// SetCircuitBreaker sets the circuit breaker that guards requests.
+func (o *ClientOptions) SetCircuitBreaker(cb *CircuitBreaker) *ClientOptions {
+	o.CircuitBreaker = cb
+	return o
+}
+
+// Validate checks that the HTTP client settings are valid and sets defaults for
+// unspecified options.
 func (o *ClientOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.HTTPClient != nil && o.HTTPOpts != nil, "cannot specify both an HTTP client and HTTP options")
+	if o.HTTPOpts != nil {
+		catcher.Wrap(o.HTTPOpts.Validate(), "invalid HTTP options")
+	}
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
 	if o.RetryOpts == nil {
 		o.RetryOpts = &utility.RetryOptions{}
 	}
@@ -81,6 +114,23 @@ func (o *ClientOptions) Validate() error {
 	return nil
 }
 
+// getHTTPClient returns the HTTP client to use to make requests. If HTTP
+// options are specified, the HTTP client is built from them once and reused
+// for subsequent requests.
+func (o *ClientOptions) getHTTPClient() (config.HTTPClient, error) {
+	if o.HTTPOpts == nil {
+		return o.HTTPClient, nil
+	}
+	if o.builtHTTPClient == nil {
+		hc, err := o.HTTPOpts.NewHTTPClient()
+		if err != nil {
+			return nil, errors.Wrap(err, "building HTTP client")
+		}
+		o.builtHTTPClient = hc
+	}
+	return o.builtHTTPClient, nil
+}
+
 var configCache = make(map[string]*aws.Config)
 
 // getAWSConfig fetches an aws.Config for the provided region, httpClient, and credsProvider. The config is cached since the AWS SDK will make a call
@@ -119,7 +169,11 @@ func (o *ClientOptions) GetCredentialsProvider(ctx context.Context) (aws.Credent
 	}
 
 	if o.stsClient == nil {
-		config, err := getAWSConfig(ctx, utility.FromStringPtr(o.Region), o.HTTPClient, o.CredsProvider)
+		hc, err := o.getHTTPClient()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting HTTP client")
+		}
+		config, err := getAWSConfig(ctx, utility.FromStringPtr(o.Region), hc, o.CredsProvider)
 		if err != nil {
 			return nil, errors.Wrap(err, "creating STS config")
 		}
@@ -139,7 +193,12 @@ func (o *ClientOptions) GetConfig(ctx context.Context) (*aws.Config, error) {
 		return nil, errors.Wrap(err, "getting credentials")
 	}
 
-	config, err := getAWSConfig(ctx, utility.FromStringPtr(o.Region), o.HTTPClient, creds)
+	hc, err := o.getHTTPClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting HTTP client")
+	}
+
+	config, err := getAWSConfig(ctx, utility.FromStringPtr(o.Region), hc, creds)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating config")
 	}
@@ -45,7 +45,27 @@ func TestClientOptions(t *testing.T) {
 		require.NotNil(t, opts.HTTPClient)
 		assert.Equal(t, hc, opts.HTTPClient)
 	})
+	t.Run("SetHTTPOptions", func(t *testing.T) {
+		httpOpts := NewHTTPOptions().SetTimeout(time.Minute)
+		opts := NewClientOptions().SetHTTPOptions(*httpOpts)
+		require.NotNil(t, opts.HTTPOpts)
+		assert.Equal(t, *httpOpts, *opts.HTTPOpts)
+	})
 	t.Run("Validate", func(t *testing.T) {
+		t.Run("SucceedsWithHTTPOptions", func(t *testing.T) {
+			opts := NewClientOptions().SetHTTPOptions(*NewHTTPOptions().SetProxyURL("http://proxy.example.com:3128"))
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithInvalidHTTPOptions", func(t *testing.T) {
+			opts := NewClientOptions().SetHTTPOptions(*NewHTTPOptions().SetTimeout(-time.Second))
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithBothHTTPClientAndHTTPOptions", func(t *testing.T) {
+			opts := NewClientOptions().
+				SetHTTPClient(http.DefaultClient).
+				SetHTTPOptions(*NewHTTPOptions().SetTimeout(time.Minute))
+			assert.Error(t, opts.Validate())
+		})
 		t.Run("SucceedsWithAllOptionSet", func(t *testing.T) {
 			role := "role"
 			region := "region"
@@ -104,3 +124,27 @@ func TestClientOptions(t *testing.T) {
 		})
 	})
 }
+
+func TestClientOptionsGetHTTPClient(t *testing.T) {
+	t.Run("ReturnsGivenHTTPClient", func(t *testing.T) {
+		opts := NewClientOptions().SetHTTPClient(http.DefaultClient)
+		hc, err := opts.getHTTPClient()
+		require.NoError(t, err)
+		assert.Equal(t, http.DefaultClient, hc)
+	})
+	t.Run("ReturnsNilWithoutHTTPClientOrOptions", func(t *testing.T) {
+		hc, err := NewClientOptions().getHTTPClient()
+		require.NoError(t, err)
+		assert.Nil(t, hc)
+	})
+	t.Run("BuildsAndReusesHTTPClientFromHTTPOptions", func(t *testing.T) {
+		opts := NewClientOptions().SetHTTPOptions(*NewHTTPOptions().SetTimeout(time.Minute))
+		hc, err := opts.getHTTPClient()
+		require.NoError(t, err)
+		require.NotNil(t, hc)
+
+		reused, err := opts.getHTTPClient()
+		require.NoError(t, err)
+		assert.Equal(t, hc, reused)
+	})
+}
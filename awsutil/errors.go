@@ -0,0 +1,48 @@
+package awsutil
+
+import (
+	"github.com/aws/smithy-go"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/pkg/errors"
+)
+
+// WrapAPIError wraps an error returned from an AWS API request in a
+// *cocoa.AWSError that includes the request ID, the operation, and the given
+// identifiers of the resources that the request was for. If the error is nil
+// or did not come from an AWS API request, it is returned unchanged.
+func WrapAPIError(err error, resources ...string) error {
+	if err == nil || cocoa.IsAWSError(err) {
+		return err
+	}
+
+	var opErr *smithy.OperationError
+	if !errors.As(err, &opErr) {
+		return err
+	}
+
+	awsErr := &cocoa.AWSError{
+		Service:   opErr.Service(),
+		Operation: opErr.Operation(),
+		Err:       err,
+	}
+	for _, r := range resources {
+		if r != "" {
+			awsErr.Resources = append(awsErr.Resources, r)
+		}
+	}
+
+	var reqIDErr interface{ ServiceRequestID() string }
+	if errors.As(err, &reqIDErr) {
+		awsErr.RequestID = reqIDErr.ServiceRequestID()
+	}
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		awsErr.StatusCode = statusErr.HTTPStatusCode()
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		awsErr.ErrorCode = apiErr.ErrorCode()
+	}
+
+	return awsErr
+}
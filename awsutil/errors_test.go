@@ -0,0 +1,62 @@
+package awsutil
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapAPIError(t *testing.T) {
+	newOperationError := func() *smithy.OperationError {
+		return &smithy.OperationError{
+			ServiceID:     "ECS",
+			OperationName: "RunTask",
+			Err: &awshttp.ResponseError{
+				ResponseError: &smithyhttp.ResponseError{
+					Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}},
+					Err:      &types.ClusterNotFoundException{Message: aws.String("cluster not found")},
+				},
+				RequestID: "request_id",
+			},
+		}
+	}
+
+	t.Run("IncludesRequestInformation", func(t *testing.T) {
+		err := WrapAPIError(newOperationError(), "cluster", "", "task_definition")
+		awsErr, ok := cocoa.AsAWSError(err)
+		require.True(t, ok)
+		assert.Equal(t, "ECS", awsErr.Service)
+		assert.Equal(t, "RunTask", awsErr.Operation)
+		assert.Equal(t, "request_id", awsErr.RequestID)
+		assert.Equal(t, http.StatusBadRequest, awsErr.StatusCode)
+		assert.Equal(t, "ClusterNotFoundException", awsErr.ErrorCode)
+		assert.Equal(t, []string{"cluster", "task_definition"}, awsErr.Resources, "empty resource identifiers should be omitted")
+	})
+	t.Run("PreservesUnderlyingErrorTypes", func(t *testing.T) {
+		err := WrapAPIError(errors.Wrap(newOperationError(), "retrying"))
+		require.True(t, cocoa.IsAWSError(err))
+		var notFound *types.ClusterNotFoundException
+		assert.True(t, errors.As(err, &notFound))
+	})
+	t.Run("DoesNotRewrapAWSError", func(t *testing.T) {
+		err := WrapAPIError(newOperationError(), "cluster")
+		assert.Equal(t, err, WrapAPIError(err, "other"))
+	})
+	t.Run("ReturnsNonAPIErrorUnchanged", func(t *testing.T) {
+		err := errors.New("some error")
+		assert.Equal(t, err, WrapAPIError(err, "resource"))
+		assert.False(t, cocoa.IsAWSError(WrapAPIError(err)))
+	})
+	t.Run("ReturnsNilForNilError", func(t *testing.T) {
+		assert.NoError(t, WrapAPIError(nil))
+	})
+}
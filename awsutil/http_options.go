@@ -0,0 +1,152 @@
+package awsutil
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// HTTPOptions configure the HTTP client used to make requests to AWS. Any
+// unspecified option uses the AWS SDK's default.
+type HTTPOptions struct {
+	// Timeout is the maximum amount of time that a single HTTP request can
+	// take, including reading the response body.
+	Timeout *time.Duration
+	// ProxyURL is the URL of the proxy to route requests through. If this is
+	// unspecified, the proxy is determined by the environment (i.e. the
+	// HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables).
+	ProxyURL *string
+	// TLSConfig is the TLS configuration to use for requests.
+	TLSConfig *tls.Config
+	// MaxIdleConns is the maximum number of idle connections across all hosts.
+	// Zero means there is no limit.
+	MaxIdleConns *int
+	// MaxIdleConnsPerHost is the maximum number of idle connections to keep
+	// per host.
+	MaxIdleConnsPerHost *int
+	// IdleConnTimeout is the maximum amount of time that an idle connection
+	// remains open before closing itself. Zero means there is no limit.
+	IdleConnTimeout *time.Duration
+}
+
+// NewHTTPOptions returns new unconfigured HTTP options.
+func NewHTTPOptions() *HTTPOptions {
+	return &HTTPOptions{}
+}
+
+// SetTimeout sets the maximum amount of time that a single request can take.
+func (o *HTTPOptions) SetTimeout(timeout time.Duration) *HTTPOptions {
+	o.Timeout = &timeout
+	return o
+}
+
+// SetProxyURL sets the URL of the proxy to route requests through.
+func (o *HTTPOptions) SetProxyURL(proxyURL string) *HTTPOptions {
+	o.ProxyURL = &proxyURL
+	return o
+}
+
+// SetTLSConfig sets the TLS configuration to use for requests.
+func (o *HTTPOptions) SetTLSConfig(tlsConf *tls.Config) *HTTPOptions {
+	o.TLSConfig = tlsConf
+	return o
+}
+
+// SetMaxIdleConns sets the maximum number of idle connections across all
+// hosts.
+func (o *HTTPOptions) SetMaxIdleConns(n int) *HTTPOptions {
+	o.MaxIdleConns = &n
+	return o
+}
+
+// SetMaxIdleConnsPerHost sets the maximum number of idle connections to keep
+// per host.
+func (o *HTTPOptions) SetMaxIdleConnsPerHost(n int) *HTTPOptions {
+	o.MaxIdleConnsPerHost = &n
+	return o
+}
+
+// SetIdleConnTimeout sets the maximum amount of time that an idle connection
+// remains open.
+func (o *HTTPOptions) SetIdleConnTimeout(timeout time.Duration) *HTTPOptions {
+	o.IdleConnTimeout = &timeout
+	return o
+}
+
+// Validate checks that the HTTP options are valid.
+func (o *HTTPOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.Timeout != nil && *o.Timeout < 0, "cannot specify a negative timeout")
+	catcher.NewWhen(o.IdleConnTimeout != nil && *o.IdleConnTimeout < 0, "cannot specify a negative idle connection timeout")
+	catcher.NewWhen(o.MaxIdleConns != nil && *o.MaxIdleConns < 0, "cannot specify a negative max number of idle connections")
+	catcher.NewWhen(o.MaxIdleConnsPerHost != nil && *o.MaxIdleConnsPerHost < 0, "cannot specify a negative max number of idle connections per host")
+	if o.ProxyURL != nil {
+		_, err := parseProxyURL(*o.ProxyURL)
+		catcher.Wrap(err, "invalid proxy URL")
+	}
+	return catcher.Resolve()
+}
+
+// parseProxyURL parses the proxy URL and checks that it has a supported scheme
+// and a host.
+func parseProxyURL(proxyURL string) (*url.URL, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, errors.Errorf("unsupported proxy scheme '%s'", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("must specify a proxy host")
+	}
+	return u, nil
+}
+
+// NewHTTPClient returns a new HTTP client for making requests to AWS that is
+// configured with the HTTP options.
+func (o *HTTPOptions) NewHTTPClient() (config.HTTPClient, error) {
+	if err := o.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid HTTP options")
+	}
+
+	var proxyURL *url.URL
+	if o.ProxyURL != nil {
+		var err error
+		proxyURL, err = parseProxyURL(*o.ProxyURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing proxy URL")
+		}
+	}
+
+	hc := awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+		if proxyURL != nil {
+			tr.Proxy = http.ProxyURL(proxyURL)
+		}
+		if o.TLSConfig != nil {
+			tr.TLSClientConfig = o.TLSConfig.Clone()
+		}
+		if o.MaxIdleConns != nil {
+			tr.MaxIdleConns = *o.MaxIdleConns
+		}
+		if o.MaxIdleConnsPerHost != nil {
+			tr.MaxIdleConnsPerHost = *o.MaxIdleConnsPerHost
+		}
+		if o.IdleConnTimeout != nil {
+			tr.IdleConnTimeout = *o.IdleConnTimeout
+		}
+	})
+	if o.Timeout != nil {
+		hc = hc.WithTimeout(*o.Timeout)
+	}
+
+	return hc, nil
+}
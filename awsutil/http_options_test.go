@@ -0,0 +1,120 @@
+package awsutil
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPOptions(t *testing.T) {
+	t.Run("NewHTTPOptions", func(t *testing.T) {
+		opts := NewHTTPOptions()
+		require.NotZero(t, opts)
+		assert.Zero(t, *opts)
+	})
+	t.Run("SetTimeout", func(t *testing.T) {
+		opts := NewHTTPOptions().SetTimeout(time.Minute)
+		require.NotZero(t, opts.Timeout)
+		assert.Equal(t, time.Minute, *opts.Timeout)
+	})
+	t.Run("SetProxyURL", func(t *testing.T) {
+		opts := NewHTTPOptions().SetProxyURL("http://proxy.example.com:3128")
+		require.NotZero(t, opts.ProxyURL)
+		assert.Equal(t, "http://proxy.example.com:3128", *opts.ProxyURL)
+	})
+	t.Run("SetTLSConfig", func(t *testing.T) {
+		tlsConf := &tls.Config{MinVersion: tls.VersionTLS12}
+		opts := NewHTTPOptions().SetTLSConfig(tlsConf)
+		assert.Equal(t, tlsConf, opts.TLSConfig)
+	})
+	t.Run("SetMaxIdleConns", func(t *testing.T) {
+		opts := NewHTTPOptions().SetMaxIdleConns(10)
+		require.NotZero(t, opts.MaxIdleConns)
+		assert.Equal(t, 10, *opts.MaxIdleConns)
+	})
+	t.Run("SetMaxIdleConnsPerHost", func(t *testing.T) {
+		opts := NewHTTPOptions().SetMaxIdleConnsPerHost(5)
+		require.NotZero(t, opts.MaxIdleConnsPerHost)
+		assert.Equal(t, 5, *opts.MaxIdleConnsPerHost)
+	})
+	t.Run("SetIdleConnTimeout", func(t *testing.T) {
+		opts := NewHTTPOptions().SetIdleConnTimeout(time.Second)
+		require.NotZero(t, opts.IdleConnTimeout)
+		assert.Equal(t, time.Second, *opts.IdleConnTimeout)
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("SucceedsWithEmpty", func(t *testing.T) {
+			assert.NoError(t, NewHTTPOptions().Validate())
+		})
+		t.Run("SucceedsWithAllFieldsPopulated", func(t *testing.T) {
+			opts := NewHTTPOptions().
+				SetTimeout(time.Minute).
+				SetProxyURL("https://proxy.example.com").
+				SetTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}).
+				SetMaxIdleConns(10).
+				SetMaxIdleConnsPerHost(5).
+				SetIdleConnTimeout(time.Second)
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithNegativeTimeout", func(t *testing.T) {
+			assert.Error(t, NewHTTPOptions().SetTimeout(-time.Second).Validate())
+		})
+		t.Run("FailsWithNegativeIdleConnTimeout", func(t *testing.T) {
+			assert.Error(t, NewHTTPOptions().SetIdleConnTimeout(-time.Second).Validate())
+		})
+		t.Run("FailsWithNegativeMaxIdleConns", func(t *testing.T) {
+			assert.Error(t, NewHTTPOptions().SetMaxIdleConns(-1).Validate())
+		})
+		t.Run("FailsWithNegativeMaxIdleConnsPerHost", func(t *testing.T) {
+			assert.Error(t, NewHTTPOptions().SetMaxIdleConnsPerHost(-1).Validate())
+		})
+		t.Run("FailsWithUnsupportedProxyScheme", func(t *testing.T) {
+			assert.Error(t, NewHTTPOptions().SetProxyURL("ftp://proxy.example.com").Validate())
+		})
+		t.Run("FailsWithProxyMissingHost", func(t *testing.T) {
+			assert.Error(t, NewHTTPOptions().SetProxyURL("http://").Validate())
+		})
+	})
+	t.Run("NewHTTPClient", func(t *testing.T) {
+		t.Run("ConfiguresTransport", func(t *testing.T) {
+			tlsConf := &tls.Config{MinVersion: tls.VersionTLS12}
+			opts := NewHTTPOptions().
+				SetTimeout(time.Minute).
+				SetProxyURL("http://proxy.example.com:3128").
+				SetTLSConfig(tlsConf).
+				SetMaxIdleConns(10).
+				SetMaxIdleConnsPerHost(5).
+				SetIdleConnTimeout(time.Second)
+			hc, err := opts.NewHTTPClient()
+			require.NoError(t, err)
+
+			bc, ok := hc.(*awshttp.BuildableClient)
+			require.True(t, ok)
+			assert.Equal(t, time.Minute, bc.GetTimeout())
+
+			tr := bc.GetTransport()
+			assert.Equal(t, 10, tr.MaxIdleConns)
+			assert.Equal(t, 5, tr.MaxIdleConnsPerHost)
+			assert.Equal(t, time.Second, tr.IdleConnTimeout)
+			require.NotZero(t, tr.TLSClientConfig)
+			assert.Equal(t, uint16(tls.VersionTLS12), tr.TLSClientConfig.MinVersion)
+
+			req, err := http.NewRequest(http.MethodGet, "https://ecs.us-east-1.amazonaws.com", nil)
+			require.NoError(t, err)
+			proxyURL, err := tr.Proxy(req)
+			require.NoError(t, err)
+			require.NotZero(t, proxyURL)
+			assert.Equal(t, "proxy.example.com:3128", proxyURL.Host)
+		})
+		t.Run("FailsWithInvalidOptions", func(t *testing.T) {
+			hc, err := NewHTTPOptions().SetTimeout(-time.Second).NewHTTPClient()
+			assert.Error(t, err)
+			assert.Zero(t, hc)
+		})
+	})
+}
@@ -0,0 +1,86 @@
+package cocoa
+
+import (
+	"sort"
+
+	"github.com/mongodb/grip"
+)
+
+// SetDeferred sets whether or not the secret's value is only known when the
+// pod is created rather than when its pod definition is created.
+func (s *SecretOptions) SetDeferred(deferred bool) *SecretOptions {
+	s.Deferred = &deferred
+	return s
+}
+
+// SetDeferredSecretValues sets the values of the pod definition's deferred
+// secrets, keyed by secret name. This overwrites any existing values.
+func (o *ECSPodExecutionOptions) SetDeferredSecretValues(values map[string]string) *ECSPodExecutionOptions {
+	o.DeferredSecretValues = values
+	return o
+}
+
+// AddDeferredSecretValue adds the value of the pod definition's deferred secret
+// with the given name.
+func (o *ECSPodExecutionOptions) AddDeferredSecretValue(name, value string) *ECSPodExecutionOptions {
+	if o.DeferredSecretValues == nil {
+		o.DeferredSecretValues = map[string]string{}
+	}
+	o.DeferredSecretValues[name] = value
+	return o
+}
+
+// validateDeferredSecretValues checks that every deferred secret value has a
+// secret name and a value.
+func (o *ECSPodExecutionOptions) validateDeferredSecretValues() error {
+	catcher := grip.NewBasicCatcher()
+	for name, val := range o.DeferredSecretValues {
+		catcher.NewWhen(name == "", "cannot specify a deferred secret value without a secret name")
+		catcher.ErrorfWhen(name != "" && val == "", "must specify a value for deferred secret '%s'", name)
+	}
+	return catcher.Resolve()
+}
+
+// DeferredSecretNames returns the sorted, unique names of the pod definition's
+// deferred secrets.
+func DeferredSecretNames(opts ECSPodDefinitionOptions) []string {
+	names := map[string]bool{}
+	for _, ref := range CollectSecretReferences(opts) {
+		if ref.Deferred {
+			names[ref.Name] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// ValidateDeferredSecretValues checks that the values resolve every one of the
+// pod definition's deferred secrets, so that a pod is never run while one of
+// its secrets still has its placeholder value. It also checks that there are
+// no values for secrets that are not deferred secrets in the pod definition.
+func ValidateDeferredSecretValues(opts ECSPodDefinitionOptions, values map[string]string) error {
+	deferred := map[string]bool{}
+	catcher := grip.NewBasicCatcher()
+	for _, name := range DeferredSecretNames(opts) {
+		deferred[name] = true
+		_, ok := values[name]
+		catcher.ErrorfWhen(!ok, "deferred secret '%s' must be given a value", name)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		catcher.ErrorfWhen(!deferred[name], "pod definition has no deferred secret '%s'", name)
+	}
+
+	return catcher.Resolve()
+}
@@ -0,0 +1,117 @@
+package cocoa
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeferredSecrets(t *testing.T) {
+	makeDefOpts := func() ECSPodDefinitionOptions {
+		deferred := NewSecretOptions().SetName("deferred").SetDeferred(true)
+		containerDef := NewECSContainerDefinition().
+			SetName("container").
+			SetImage("image").
+			AddEnvironmentVariables(
+				*NewEnvironmentVariable().SetName("first").SetSecretOptions(*deferred),
+				*NewEnvironmentVariable().SetName("second").SetSecretOptions(*deferred),
+				*NewEnvironmentVariable().SetName("existing").SetSecretOptions(*NewSecretOptions().SetID("id")),
+			).
+			SetLogConfiguration(*NewLogConfiguration().
+				SetLogDriver("splunk").
+				AddSecretOptions(*NewEnvironmentVariable().SetName("splunk-token").SetSecretOptions(*NewSecretOptions().
+					SetName("log_token").
+					SetDeferred(true))))
+		return *NewECSPodDefinitionOptions().AddContainerDefinitions(*containerDef)
+	}
+
+	t.Run("SecretOptions", func(t *testing.T) {
+		t.Run("ValidateSucceedsWithNamedDeferredSecret", func(t *testing.T) {
+			assert.NoError(t, NewSecretOptions().SetName("name").SetDeferred(true).Validate())
+		})
+		t.Run("ValidateSucceedsWithCreatedDeferredSecret", func(t *testing.T) {
+			assert.NoError(t, NewSecretOptions().SetName("name").SetID("id").SetDeferred(true).Validate())
+		})
+		t.Run("ValidateFailsWithDeferredSecretWithoutName", func(t *testing.T) {
+			assert.Error(t, NewSecretOptions().SetDeferred(true).Validate())
+		})
+		t.Run("ValidateFailsWithDeferredSecretWithNewValue", func(t *testing.T) {
+			assert.Error(t, NewSecretOptions().SetName("name").SetNewValue("value").SetDeferred(true).Validate())
+		})
+		t.Run("HashChangesWhenDeferred", func(t *testing.T) {
+			s := NewSecretOptions().SetName("name")
+			assert.NotEqual(t, s.hash(), NewSecretOptions().SetName("name").SetDeferred(true).hash())
+		})
+	})
+	t.Run("DeferredSecretNames", func(t *testing.T) {
+		assert.Equal(t, []string{"deferred", "log_token"}, DeferredSecretNames(makeDefOpts()))
+	})
+	t.Run("CollectSecretReferencesMarksDeferredSecretsAsNew", func(t *testing.T) {
+		refs := CollectSecretReferences(makeDefOpts())
+		require.Len(t, refs, 4)
+		assert.True(t, refs[0].Deferred)
+		assert.True(t, refs[0].New)
+		assert.False(t, refs[2].Deferred)
+		assert.False(t, refs[2].New)
+	})
+	t.Run("ValidateDeferredSecretValues", func(t *testing.T) {
+		t.Run("SucceedsWithAllValues", func(t *testing.T) {
+			assert.NoError(t, ValidateDeferredSecretValues(makeDefOpts(), map[string]string{
+				"deferred":  "value",
+				"log_token": "token",
+			}))
+		})
+		t.Run("SucceedsWithoutDeferredSecrets", func(t *testing.T) {
+			opts := NewECSPodDefinitionOptions().AddContainerDefinitions(*NewECSContainerDefinition().SetImage("image"))
+			assert.NoError(t, ValidateDeferredSecretValues(*opts, nil))
+		})
+		t.Run("FailsWithMissingValue", func(t *testing.T) {
+			assert.Error(t, ValidateDeferredSecretValues(makeDefOpts(), map[string]string{"deferred": "value"}))
+		})
+		t.Run("FailsWithValueForUnknownSecret", func(t *testing.T) {
+			assert.Error(t, ValidateDeferredSecretValues(makeDefOpts(), map[string]string{
+				"deferred":  "value",
+				"log_token": "token",
+				"unknown":   "value",
+			}))
+		})
+	})
+	t.Run("ExecutionOptions", func(t *testing.T) {
+		t.Run("SetDeferredSecretValues", func(t *testing.T) {
+			values := map[string]string{"name": "value"}
+			opts := NewECSPodExecutionOptions().SetDeferredSecretValues(values)
+			assert.Equal(t, values, opts.DeferredSecretValues)
+		})
+		t.Run("AddDeferredSecretValue", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().
+				AddDeferredSecretValue("name0", "value0").
+				AddDeferredSecretValue("name1", "value1")
+			assert.Equal(t, map[string]string{"name0": "value0", "name1": "value1"}, opts.DeferredSecretValues)
+		})
+		t.Run("ValidateFailsWithEmptyName", func(t *testing.T) {
+			assert.Error(t, NewECSPodExecutionOptions().AddDeferredSecretValue("", "value").Validate())
+		})
+		t.Run("ValidateFailsWithEmptyValue", func(t *testing.T) {
+			assert.Error(t, NewECSPodExecutionOptions().AddDeferredSecretValue("name", "").Validate())
+		})
+		t.Run("MergeOverwritesDeferredSecretValues", func(t *testing.T) {
+			merged := MergeECSPodExecutionOptions(
+				*NewECSPodExecutionOptions().AddDeferredSecretValue("name", "old"),
+				*NewECSPodExecutionOptions().AddDeferredSecretValue("name", "new"),
+			)
+			assert.Equal(t, map[string]string{"name": "new"}, merged.DeferredSecretValues)
+		})
+		t.Run("HashChangesWithDeferredSecretValues", func(t *testing.T) {
+			base := NewECSPodExecutionOptions().SetCluster("cluster")
+			withValues := NewECSPodExecutionOptions().SetCluster("cluster").AddDeferredSecretValue("name", "value")
+			assert.NotEqual(t, base.Hash(), withValues.Hash())
+		})
+		t.Run("DeferredSecretValuesAreNotSerialized", func(t *testing.T) {
+			b, err := json.Marshal(NewECSPodExecutionOptions().AddDeferredSecretValue("name", "super_secret"))
+			require.NoError(t, err)
+			assert.NotContains(t, string(b), "super_secret")
+		})
+	})
+}
@@ -0,0 +1,37 @@
+package cocoa
+
+import "context"
+
+// EC2Client provides a common interface to look up networking resources in AWS
+// EC2. Implementations must handle retrying and backoff.
+type EC2Client interface {
+	// DescribeSubnets returns information about the subnets among the given
+	// IDs that exist. Subnets that do not exist are omitted rather than
+	// returning an error.
+	DescribeSubnets(ctx context.Context, ids []string) ([]EC2Subnet, error)
+	// DescribeSecurityGroups returns information about the security groups
+	// among the given IDs that exist. Security groups that do not exist are
+	// omitted rather than returning an error.
+	DescribeSecurityGroups(ctx context.Context, ids []string) ([]EC2SecurityGroup, error)
+}
+
+// EC2Subnet represents information about an EC2 subnet.
+type EC2Subnet struct {
+	// ID is the unique identifier for the subnet.
+	ID string
+	// VPCID is the unique identifier for the VPC that the subnet is in.
+	VPCID string
+	// AvailableIPAddresses is the number of IP addresses in the subnet that
+	// are not in use. Each pod using AWSVPC networking uses one IP address in
+	// the subnet where it runs.
+	AvailableIPAddresses int
+}
+
+// EC2SecurityGroup represents information about an EC2 security group.
+type EC2SecurityGroup struct {
+	// ID is the unique identifier for the security group.
+	ID string
+	// VPCID is the unique identifier for the VPC that the security group
+	// belongs to.
+	VPCID string
+}
@@ -0,0 +1,226 @@
+package ecs
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/smithy-go"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// defaultCacheSyncRetryOpts are the retry options used to retry ECS requests
+// that are throttled while syncing the cache.
+var defaultCacheSyncRetryOpts = utility.RetryOptions{
+	MaxAttempts: 10,
+	MinDelay:    200 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// CacheSyncResult is the result of populating a pod definition cache from the
+// existing pod definitions in ECS.
+type CacheSyncResult struct {
+	// Synced are the pod definition items that were added to the cache.
+	Synced []cocoa.ECSPodDefinitionItem
+	// Skipped are the IDs of the pod definitions that were not synced because
+	// they are not tagged as created by the pod definition manager.
+	Skipped []string
+	// Failed maps the IDs of the pod definitions that could not be synced to
+	// the error that occurred.
+	Failed map[string]error
+}
+
+// SyncCacheFromECS populates the pod definition manager's cache from the
+// active pod definitions that already exist in ECS, so that a new, empty cache
+// can be warmed without registering every pod definition again. It pages
+// through the pod definitions in the family (or all families if familyPrefix is
+// empty), describes each one along with its tags, reconstructs its pod
+// definition options, and adds it to the cache. Each item's hash is recovered
+// from the tag that recorded it when the pod definition was created (see
+// cocoa.PodDefinitionHashTagKey), so it matches the hash from HashPodDefinition
+// of the options that the pod definition was originally created from.
+// Pod definitions created before the hash was recorded are synced without a
+// hash.
+//
+// Only pod definitions that have the manager's tracking tag are synced, since
+// those are the ones the manager created; other pod definitions can be cached
+// with ImportPodDefinition. Pod definitions that were created but never
+// successfully cached are re-tagged to indicate that they are now tracked.
+// Like ImportPodDefinition, secrets are reconstructed as references to
+// existing, unowned secrets.
+//
+// The pod definitions are synced one at a time, and requests that ECS
+// throttles are retried with backoff, so syncing many pod definitions does
+// not exhaust the account's request rate. All the pod definitions are
+// attempted even if some of them fail to sync. The result contains which pod
+// definitions were synced, skipped, or failed; if any failed, an error is also
+// returned along with the result.
+func SyncCacheFromECS(ctx context.Context, m *BasicPodDefinitionManager, familyPrefix string) (*CacheSyncResult, error) {
+	return syncCacheFromECS(ctx, m, familyPrefix, defaultCacheSyncRetryOpts)
+}
+
+func syncCacheFromECS(ctx context.Context, m *BasicPodDefinitionManager, familyPrefix string, retryOpts utility.RetryOptions) (*CacheSyncResult, error) {
+	if m == nil {
+		return nil, errors.New("must specify a pod definition manager")
+	}
+	if !m.usesCache() {
+		return nil, errors.New("pod definition manager must have a cache to sync")
+	}
+
+	ids, err := listActiveTaskDefinitionARNs(ctx, m.client, familyPrefix, retryOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing active task definitions")
+	}
+
+	res := CacheSyncResult{
+		Failed: map[string]error{},
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			res.Failed[id] = err
+			continue
+		}
+
+		item, err := m.syncCachedPodDefinition(ctx, id, retryOpts)
+		if err != nil {
+			res.Failed[id] = err
+			continue
+		}
+		if item == nil {
+			res.Skipped = append(res.Skipped, id)
+			continue
+		}
+		res.Synced = append(res.Synced, *item)
+	}
+
+	if len(res.Failed) != 0 {
+		failed := make([]string, 0, len(res.Failed))
+		for id := range res.Failed {
+			failed = append(failed, id)
+		}
+		sort.Strings(failed)
+
+		catcher := grip.NewBasicCatcher()
+		for _, id := range failed {
+			catcher.Wrapf(res.Failed[id], "syncing pod definition '%s'", id)
+		}
+		return &res, errors.Wrapf(catcher.Resolve(), "syncing %d of %d pod definitions", len(res.Failed), len(ids))
+	}
+
+	return &res, nil
+}
+
+// syncCachedPodDefinition describes the pod definition with the given ID and
+// adds it to the cache if it has the tracking tag. If the pod definition does
+// not have the tracking tag, it returns a nil item.
+func (m *BasicPodDefinitionManager) syncCachedPodDefinition(ctx context.Context, id string, retryOpts utility.RetryOptions) (*cocoa.ECSPodDefinitionItem, error) {
+	var out *ecs.DescribeTaskDefinitionOutput
+	if err := retryThrottled(ctx, retryOpts, func() error {
+		var err error
+		out, err = m.client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+			TaskDefinition: aws.String(id),
+			Include:        []types.TaskDefinitionField{types.TaskDefinitionFieldTags},
+		})
+		return err
+	}); err != nil {
+		return nil, errors.Wrapf(err, "describing task definition '%s'", id)
+	}
+	if out.TaskDefinition == nil {
+		return nil, errors.Errorf("expected task definition '%s' from ECS, but none was returned", id)
+	}
+
+	cacheTag := m.getCacheTag()
+	tags := importTags(out.Tags)
+	tracked, ok := tags[cacheTag]
+	if !ok {
+		return nil, nil
+	}
+
+	item := cocoa.ECSPodDefinitionItem{
+		ID:             utility.FromStringPtr(out.TaskDefinition.TaskDefinitionArn),
+		DefinitionOpts: translatePodDefinitionOptions(*out.TaskDefinition, out.Tags),
+		Hash:           tags[cocoa.PodDefinitionHashTagKey],
+	}
+	if item.ID == "" {
+		item.ID = id
+	}
+	// When the pod definition was created, its cached options had the
+	// tracking tag marking it as uncached but neither the checksum tag nor
+	// the hash tag, so the tags are restored to that state.
+	item.DefinitionOpts.Tags[cacheTag] = strconv.FormatBool(false)
+	delete(item.DefinitionOpts.Tags, cocoa.PodDefinitionHashTagKey)
+	if m.checksumTagName != "" {
+		delete(item.DefinitionOpts.Tags, m.checksumTagName)
+	}
+
+	if err := m.cache.Put(ctx, item); err != nil {
+		return nil, errors.Wrapf(err, "adding pod definition item '%s' to cache", item.ID)
+	}
+
+	if tracked != strconv.FormatBool(true) {
+		if err := retryThrottled(ctx, retryOpts, func() error {
+			_, err := m.client.TagResource(ctx, &ecs.TagResourceInput{
+				ResourceArn: aws.String(item.ID),
+				Tags:        ExportTags(map[string]string{cacheTag: strconv.FormatBool(true)}),
+			})
+			return err
+		}); err != nil {
+			return nil, errors.Wrapf(err, "re-tagging pod definition item '%s' to indicate that it is tracked", item.ID)
+		}
+	}
+
+	return &item, nil
+}
+
+// listActiveTaskDefinitionARNs lists the ARNs of all the active task
+// definitions in the family, or in all families if the family is empty,
+// retrying each page if it is throttled.
+func listActiveTaskDefinitionARNs(ctx context.Context, c cocoa.ECSClient, family string, retryOpts utility.RetryOptions) ([]string, error) {
+	in := &ecs.ListTaskDefinitionsInput{
+		Status: types.TaskDefinitionStatusActive,
+	}
+	if family != "" {
+		in.FamilyPrefix = aws.String(family)
+	}
+
+	var arns []string
+	for {
+		var out *ecs.ListTaskDefinitionsOutput
+		if err := retryThrottled(ctx, retryOpts, func() error {
+			var err error
+			out, err = c.ListTaskDefinitions(ctx, in)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+		arns = append(arns, out.TaskDefinitionArns...)
+
+		if out.NextToken == nil {
+			return arns, nil
+		}
+		in.NextToken = out.NextToken
+	}
+}
+
+// retryThrottled runs the request, retrying it with backoff only if the
+// request is throttled.
+func retryThrottled(ctx context.Context, opts utility.RetryOptions, request func() error) error {
+	return utility.Retry(ctx, func() (bool, error) {
+		err := request()
+		return isThrottlingError(err), err
+	}, opts)
+}
+
+// isThrottlingError returns whether or not the error indicates that the
+// request was throttled.
+func isThrottlingError(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && cocoa.IsThrottlingErrorCode(apiErr.ErrorCode())
+}
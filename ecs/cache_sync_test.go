@@ -0,0 +1,221 @@
+package ecs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/smithy-go"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// taskDefinitionListClient is an ECS client with a fixed set of task
+// definitions and their tags that lists one task definition per page and
+// throttles a limited number of requests.
+type taskDefinitionListClient struct {
+	cocoa.ECSClient
+
+	arns          []string
+	tags          map[string]map[string]string
+	listThrottles int
+	descThrottles int
+	failARN       string
+	listCalls     int
+	describeCalls int
+	tagged        map[string]map[string]string
+}
+
+func (c *taskDefinitionListClient) ListTaskDefinitions(ctx context.Context, in *ecs.ListTaskDefinitionsInput) (*ecs.ListTaskDefinitionsOutput, error) {
+	c.listCalls++
+	if c.listThrottles > 0 {
+		c.listThrottles--
+		return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	}
+
+	var i int
+	if in.NextToken != nil {
+		var err error
+		if i, err = strconv.Atoi(*in.NextToken); err != nil {
+			return nil, err
+		}
+	}
+	if i >= len(c.arns) {
+		return &ecs.ListTaskDefinitionsOutput{}, nil
+	}
+	out := &ecs.ListTaskDefinitionsOutput{TaskDefinitionArns: []string{c.arns[i]}}
+	if i+1 < len(c.arns) {
+		out.NextToken = utility.ToStringPtr(strconv.Itoa(i + 1))
+	}
+	return out, nil
+}
+
+func (c *taskDefinitionListClient) DescribeTaskDefinition(ctx context.Context, in *ecs.DescribeTaskDefinitionInput) (*ecs.DescribeTaskDefinitionOutput, error) {
+	c.describeCalls++
+	if c.descThrottles > 0 {
+		c.descThrottles--
+		return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	}
+
+	arn := utility.FromStringPtr(in.TaskDefinition)
+	if arn == c.failARN {
+		return nil, errors.New("fake error")
+	}
+	return &ecs.DescribeTaskDefinitionOutput{
+		TaskDefinition: &types.TaskDefinition{
+			TaskDefinitionArn: utility.ToStringPtr(arn),
+			Family:            utility.ToStringPtr("family"),
+			ContainerDefinitions: []types.ContainerDefinition{{
+				Name:  utility.ToStringPtr("container"),
+				Image: utility.ToStringPtr("image"),
+			}},
+		},
+		Tags: ExportTags(c.tags[arn]),
+	}, nil
+}
+
+func (c *taskDefinitionListClient) TagResource(ctx context.Context, in *ecs.TagResourceInput) (*ecs.TagResourceOutput, error) {
+	c.tagged[utility.FromStringPtr(in.ResourceArn)] = importTags(in.Tags)
+	return &ecs.TagResourceOutput{}, nil
+}
+
+// recordingPodDefinitionCache is a pod definition cache that records the
+// items that are put into it.
+type recordingPodDefinitionCache struct {
+	items map[string]cocoa.ECSPodDefinitionItem
+}
+
+func (c *recordingPodDefinitionCache) Put(ctx context.Context, item cocoa.ECSPodDefinitionItem) error {
+	c.items[item.ID] = item
+	return nil
+}
+
+func (c *recordingPodDefinitionCache) Delete(ctx context.Context, id string) error {
+	delete(c.items, id)
+	return nil
+}
+
+func (c *recordingPodDefinitionCache) GetTag() string {
+	return "cache-tag"
+}
+
+func TestSyncCacheFromECS(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	retryOpts := utility.RetryOptions{
+		MaxAttempts: 3,
+		MinDelay:    time.Millisecond,
+		MaxDelay:    time.Millisecond,
+	}
+	newClient := func() *taskDefinitionListClient {
+		return &taskDefinitionListClient{
+			arns: []string{"tracked", "uncached", "untracked"},
+			tags: map[string]map[string]string{
+				"tracked":   {"cache-tag": "true", "key": "value", cocoa.PodDefinitionHashTagKey: "hash"},
+				"uncached":  {"cache-tag": "false"},
+				"untracked": {"key": "value"},
+			},
+			tagged: map[string]map[string]string{},
+		}
+	}
+	newManager := func(t *testing.T, c cocoa.ECSClient, pdc cocoa.ECSPodDefinitionCache) *BasicPodDefinitionManager {
+		opts := NewBasicPodDefinitionManagerOptions().SetClient(c)
+		if pdc != nil {
+			opts.SetCache(pdc)
+		}
+		m, err := NewBasicPodDefinitionManager(*opts)
+		require.NoError(t, err)
+		return m
+	}
+
+	t.Run("SyncsTrackedPodDefinitions", func(t *testing.T) {
+		c := newClient()
+		pdc := &recordingPodDefinitionCache{items: map[string]cocoa.ECSPodDefinitionItem{}}
+		m := newManager(t, c, pdc)
+
+		res, err := syncCacheFromECS(ctx, m, "family", retryOpts)
+		require.NoError(t, err)
+		require.NotZero(t, res)
+
+		require.Len(t, res.Synced, 2)
+		assert.Equal(t, "tracked", res.Synced[0].ID)
+		assert.Equal(t, "uncached", res.Synced[1].ID)
+		assert.Equal(t, []string{"untracked"}, res.Skipped)
+		assert.Empty(t, res.Failed)
+
+		require.Len(t, pdc.items, 2)
+		for _, item := range res.Synced {
+			cached, ok := pdc.items[item.ID]
+			require.True(t, ok, "pod definition '%s' should be cached", item.ID)
+			assert.Equal(t, "false", cached.DefinitionOpts.Tags["cache-tag"], "cached tags should match the tags from when the pod definition was created")
+			assert.NotContains(t, cached.DefinitionOpts.Tags, cocoa.PodDefinitionHashTagKey)
+		}
+		assert.Equal(t, "value", pdc.items["tracked"].DefinitionOpts.Tags["key"])
+		assert.Equal(t, "hash", pdc.items["tracked"].Hash, "hash should be recovered from its tag")
+		assert.Zero(t, pdc.items["uncached"].Hash, "pod definition without a recorded hash should not have a hash")
+
+		assert.Equal(t, map[string]map[string]string{"uncached": {"cache-tag": "true"}}, c.tagged, "only the pod definition that was not cached should be re-tagged")
+	})
+	t.Run("RetriesThrottledRequests", func(t *testing.T) {
+		c := newClient()
+		c.listThrottles = 2
+		c.descThrottles = 2
+		pdc := &recordingPodDefinitionCache{items: map[string]cocoa.ECSPodDefinitionItem{}}
+		m := newManager(t, c, pdc)
+
+		res, err := syncCacheFromECS(ctx, m, "", retryOpts)
+		require.NoError(t, err)
+		require.NotZero(t, res)
+		assert.Len(t, res.Synced, 2)
+		assert.Equal(t, 5, c.listCalls, "should retry the throttled page requests")
+		assert.Equal(t, 5, c.describeCalls, "should retry the throttled describe requests")
+	})
+	t.Run("FailsWhenListingIsThrottledTooManyTimes", func(t *testing.T) {
+		c := newClient()
+		c.listThrottles = 10
+		pdc := &recordingPodDefinitionCache{items: map[string]cocoa.ECSPodDefinitionItem{}}
+		m := newManager(t, c, pdc)
+
+		res, err := syncCacheFromECS(ctx, m, "", retryOpts)
+		assert.Error(t, err)
+		assert.Zero(t, res)
+		assert.Equal(t, retryOpts.MaxAttempts, c.listCalls)
+		assert.Empty(t, pdc.items)
+	})
+	t.Run("ContinuesAfterIndividualFailures", func(t *testing.T) {
+		c := newClient()
+		c.failARN = "tracked"
+		pdc := &recordingPodDefinitionCache{items: map[string]cocoa.ECSPodDefinitionItem{}}
+		m := newManager(t, c, pdc)
+
+		res, err := syncCacheFromECS(ctx, m, "", retryOpts)
+		assert.Error(t, err)
+		require.NotZero(t, res)
+		require.Len(t, res.Synced, 1)
+		assert.Equal(t, "uncached", res.Synced[0].ID)
+		require.Len(t, res.Failed, 1)
+		assert.Contains(t, res.Failed, "tracked")
+		assert.Equal(t, 3, c.describeCalls, "non-throttling errors should not be retried")
+	})
+	t.Run("FailsWithoutCache", func(t *testing.T) {
+		c := newClient()
+		m := newManager(t, c, nil)
+
+		res, err := SyncCacheFromECS(ctx, m, "")
+		assert.Error(t, err)
+		assert.Zero(t, res)
+		assert.Zero(t, c.listCalls)
+	})
+	t.Run("FailsWithoutManager", func(t *testing.T) {
+		res, err := SyncCacheFromECS(ctx, nil, "")
+		assert.Error(t, err)
+		assert.Zero(t, res)
+	})
+}
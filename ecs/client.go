@@ -2,7 +2,6 @@ package ecs
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
@@ -35,6 +34,14 @@ func NewBasicClient(ctx context.Context, opts awsutil.ClientOptions) (*BasicClie
 	return c, nil
 }
 
+// newLazyBasicClient creates a new AWS ECS client from the given options that
+// is set up the first time it is used.
+func newLazyBasicClient(opts awsutil.ClientOptions) *BasicClient {
+	return &BasicClient{
+		BaseClient: awsutil.NewBaseClient(opts),
+	}
+}
+
 func (c *BasicClient) setup(ctx context.Context) error {
 	if c.ecs != nil {
 		return nil
@@ -50,24 +57,50 @@ func (c *BasicClient) setup(ctx context.Context) error {
 	return nil
 }
 
+// setupOperation sets up the client and returns the options for a single
+// operation. If the context specifies a role to assume, the operation uses the
+// credentials for that role. If the client has a circuit breaker, the
+// operation is guarded by it.
+func (c *BasicClient) setupOperation(ctx context.Context) ([]func(*ecs.Options), error) {
+	if err := c.setup(ctx); err != nil {
+		return nil, err
+	}
+
+	creds, err := c.GetContextCredentials(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting credentials for role to assume")
+	}
+	apiOpts := c.GetCircuitBreakerAPIOptions()
+	if creds == nil && len(apiOpts) == 0 {
+		return nil, nil
+	}
+
+	return []func(*ecs.Options){func(o *ecs.Options) {
+		if creds != nil {
+			o.Credentials = creds
+		}
+		o.APIOptions = append(o.APIOptions, apiOpts...)
+	}}, nil
+}
+
 // RegisterTaskDefinition registers a new task definition.
 func (c *BasicClient) RegisterTaskDefinition(ctx context.Context, in *ecs.RegisterTaskDefinitionInput) (*ecs.RegisterTaskDefinitionOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *ecs.RegisterTaskDefinitionOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("RegisterTaskDefinition", in)
-		out, err = c.ecs.RegisterTaskDefinition(ctx, in)
+		out, err = c.ecs.RegisterTaskDefinition(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.Family))
 	}
 
 	return out, nil
@@ -75,22 +108,22 @@ func (c *BasicClient) RegisterTaskDefinition(ctx context.Context, in *ecs.Regist
 
 // DescribeTaskDefinition describes an existing task definition.
 func (c *BasicClient) DescribeTaskDefinition(ctx context.Context, in *ecs.DescribeTaskDefinitionInput) (*ecs.DescribeTaskDefinitionOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *ecs.DescribeTaskDefinitionOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("DescribeTaskDefinition", in)
-		out, err = c.ecs.DescribeTaskDefinition(ctx, in)
+		out, err = c.ecs.DescribeTaskDefinition(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.TaskDefinition))
 	}
 	return out, nil
 }
@@ -98,44 +131,44 @@ func (c *BasicClient) DescribeTaskDefinition(ctx context.Context, in *ecs.Descri
 // ListTaskDefinitions returns the ARNs for the task definitions that match the
 // input filters.
 func (c *BasicClient) ListTaskDefinitions(ctx context.Context, in *ecs.ListTaskDefinitionsInput) (*ecs.ListTaskDefinitionsOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *ecs.ListTaskDefinitionsOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("ListTaskDefinitions", in)
-		out, err = c.ecs.ListTaskDefinitions(ctx, in)
+		out, err = c.ecs.ListTaskDefinitions(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err)
 	}
 	return out, nil
 }
 
 // DeregisterTaskDefinition deregisters an existing task definition.
 func (c *BasicClient) DeregisterTaskDefinition(ctx context.Context, in *ecs.DeregisterTaskDefinitionInput) (*ecs.DeregisterTaskDefinitionOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *ecs.DeregisterTaskDefinitionOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("DeregisterTaskDefinition", in)
-		out, err = c.ecs.DeregisterTaskDefinition(ctx, in)
+		out, err = c.ecs.DeregisterTaskDefinition(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.TaskDefinition))
 	}
 
 	return out, nil
@@ -143,15 +176,15 @@ func (c *BasicClient) DeregisterTaskDefinition(ctx context.Context, in *ecs.Dere
 
 // RunTask runs a new task.
 func (c *BasicClient) RunTask(ctx context.Context, in *ecs.RunTaskInput) (*ecs.RunTaskOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *ecs.RunTaskOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("RunTask", in)
-		out, err = c.ecs.RunTask(ctx, in)
+		out, err = c.ecs.RunTask(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		var apiErr smithy.APIError
 		if errors.As(err, &apiErr) {
@@ -179,7 +212,7 @@ func (c *BasicClient) RunTask(ctx context.Context, in *ecs.RunTaskInput) (*ecs.R
 			// may have failed for other reasons.
 			catcher := grip.NewBasicCatcher()
 			for _, f := range out.Failures {
-				if utility.StringSliceContains([]string{"RESOURCE:CPU", "RESOURCE:MEMORY"}, utility.FromStringPtr(f.Reason)) {
+				if utility.StringSliceContains([]string{ReasonResourceCPU, ReasonResourceMemory}, utility.FromStringPtr(f.Reason)) {
 					catcher.Add(ConvertFailureToError(f))
 				}
 			}
@@ -188,67 +221,135 @@ func (c *BasicClient) RunTask(ctx context.Context, in *ecs.RunTaskInput) (*ecs.R
 
 		return false, nil
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.Cluster), utility.FromStringPtr(in.TaskDefinition))
 	}
 
 	return out, nil
 }
 
+// StartTask runs a new task on each of the given container instances.
+func (c *BasicClient) StartTask(ctx context.Context, in *ecs.StartTaskInput) (*ecs.StartTaskOutput, error) {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up client")
+	}
+
+	var out *ecs.StartTaskOutput
+	if err := utility.Retry(ctx, func() (bool, error) {
+		msg := awsutil.MakeAPILogMessage("StartTask", in)
+		out, err = c.ecs.StartTask(ctx, in, optFns...)
+		grip.Debug(message.WrapError(err, msg))
+		if c.isNonRetryableError(err) {
+			return false, err
+		}
+		return true, err
+	}, c.GetRetryOptions()); err != nil {
+		return nil, awsutil.WrapAPIError(err, append([]string{utility.FromStringPtr(in.Cluster), utility.FromStringPtr(in.TaskDefinition)}, in.ContainerInstances...)...)
+	}
+	return out, nil
+}
+
 // DescribeTasks describes one or more existing tasks.
 func (c *BasicClient) DescribeTasks(ctx context.Context, in *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *ecs.DescribeTasksOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("DescribeTasks", in)
-		out, err = c.ecs.DescribeTasks(ctx, in)
+		out, err = c.ecs.DescribeTasks(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, append([]string{utility.FromStringPtr(in.Cluster)}, in.Tasks...)...)
 	}
 	return out, nil
 }
 
 // ListTasks returns the ARNs for the task that match the input filters.
 func (c *BasicClient) ListTasks(ctx context.Context, in *ecs.ListTasksInput) (*ecs.ListTasksOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *ecs.ListTasksOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("ListTasks", in)
-		out, err = c.ecs.ListTasks(ctx, in)
+		out, err = c.ecs.ListTasks(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.Cluster))
+	}
+	return out, nil
+}
+
+// ListContainerInstances returns the ARNs for the container instances that
+// match the input filters.
+func (c *BasicClient) ListContainerInstances(ctx context.Context, in *ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error) {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up client")
+	}
+
+	var out *ecs.ListContainerInstancesOutput
+	if err := utility.Retry(ctx, func() (bool, error) {
+		msg := awsutil.MakeAPILogMessage("ListContainerInstances", in)
+		out, err = c.ecs.ListContainerInstances(ctx, in, optFns...)
+		grip.Debug(message.WrapError(err, msg))
+		if c.isNonRetryableError(err) {
+			return false, err
+		}
+		return true, err
+	}, c.GetRetryOptions()); err != nil {
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.Cluster))
+	}
+	return out, nil
+}
+
+// DescribeContainerInstances describes the configuration, status, and
+// resources of the given container instances.
+func (c *BasicClient) DescribeContainerInstances(ctx context.Context, in *ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error) {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up client")
+	}
+
+	var out *ecs.DescribeContainerInstancesOutput
+	if err := utility.Retry(ctx, func() (bool, error) {
+		msg := awsutil.MakeAPILogMessage("DescribeContainerInstances", in)
+		out, err = c.ecs.DescribeContainerInstances(ctx, in, optFns...)
+		grip.Debug(message.WrapError(err, msg))
+		if c.isNonRetryableError(err) {
+			return false, err
+		}
+		return true, err
+	}, c.GetRetryOptions()); err != nil {
+		return nil, awsutil.WrapAPIError(err, append([]string{utility.FromStringPtr(in.Cluster)}, in.ContainerInstances...)...)
 	}
 	return out, nil
 }
 
 // StopTask stops a running task.
 func (c *BasicClient) StopTask(ctx context.Context, in *ecs.StopTaskInput) (*ecs.StopTaskOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *ecs.StopTaskOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("StopTask", in)
-		out, err = c.ecs.StopTask(ctx, in)
+		out, err = c.ecs.StopTask(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if isTaskNotFoundError(err) {
 			return false, cocoa.NewECSTaskNotFoundError(utility.FromStringPtr(in.Task))
@@ -258,29 +359,95 @@ func (c *BasicClient) StopTask(ctx context.Context, in *ecs.StopTaskInput) (*ecs
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.Cluster), utility.FromStringPtr(in.Task))
 	}
 	return out, nil
 }
 
 // TagResource adds tags to an existing resource in ECS.
 func (c *BasicClient) TagResource(ctx context.Context, in *ecs.TagResourceInput) (*ecs.TagResourceOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *ecs.TagResourceOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("TagResource", in)
-		out, err = c.ecs.TagResource(ctx, in)
+		out, err = c.ecs.TagResource(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.ResourceArn))
+	}
+	return out, nil
+}
+
+// DescribeClusters describes one or more existing clusters.
+func (c *BasicClient) DescribeClusters(ctx context.Context, in *ecs.DescribeClustersInput) (*ecs.DescribeClustersOutput, error) {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up client")
+	}
+
+	var out *ecs.DescribeClustersOutput
+	if err := utility.Retry(ctx, func() (bool, error) {
+		msg := awsutil.MakeAPILogMessage("DescribeClusters", in)
+		out, err = c.ecs.DescribeClusters(ctx, in, optFns...)
+		grip.Debug(message.WrapError(err, msg))
+		if c.isNonRetryableError(err) {
+			return false, err
+		}
+		return true, err
+	}, c.GetRetryOptions()); err != nil {
+		return nil, awsutil.WrapAPIError(err, in.Clusters...)
+	}
+	return out, nil
+}
+
+// ListServices lists all services in a cluster matching the input.
+func (c *BasicClient) ListServices(ctx context.Context, in *ecs.ListServicesInput) (*ecs.ListServicesOutput, error) {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up client")
+	}
+
+	var out *ecs.ListServicesOutput
+	if err := utility.Retry(ctx, func() (bool, error) {
+		msg := awsutil.MakeAPILogMessage("ListServices", in)
+		out, err = c.ecs.ListServices(ctx, in, optFns...)
+		grip.Debug(message.WrapError(err, msg))
+		if c.isNonRetryableError(err) {
+			return false, err
+		}
+		return true, err
+	}, c.GetRetryOptions()); err != nil {
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.Cluster))
+	}
+	return out, nil
+}
+
+// DescribeServices describes one or more existing services.
+func (c *BasicClient) DescribeServices(ctx context.Context, in *ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error) {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up client")
+	}
+
+	var out *ecs.DescribeServicesOutput
+	if err := utility.Retry(ctx, func() (bool, error) {
+		msg := awsutil.MakeAPILogMessage("DescribeServices", in)
+		out, err = c.ecs.DescribeServices(ctx, in, optFns...)
+		grip.Debug(message.WrapError(err, msg))
+		if c.isNonRetryableError(err) {
+			return false, err
+		}
+		return true, err
+	}, c.GetRetryOptions()); err != nil {
+		return nil, awsutil.WrapAPIError(err, in.Services...)
 	}
 	return out, nil
 }
@@ -293,7 +460,8 @@ func (c *BasicClient) isNonRetryableError(err error) bool {
 		utility.MatchesError[*types.InvalidParameterException](err) ||
 		utility.MatchesError[*types.ClusterNotFoundException](err) ||
 		utility.MatchesError[*smithy.InvalidParamsError](err) ||
-		utility.MatchesError[*smithy.ParamRequiredError](err)
+		utility.MatchesError[*smithy.ParamRequiredError](err) ||
+		cocoa.IsCircuitOpenError(err)
 }
 
 // isTaskNotFoundError returns whether or not the error returned from ECS is
@@ -304,28 +472,16 @@ func isTaskNotFoundError(err error) bool {
 		strings.Contains(invalidParameterErr.ErrorMessage(), "The referenced task was not found")
 }
 
-// ConvertFailureToError converts an ECS failure message into a formatted error.
-// If the failure is due to being unable to find the task, it will return a
-// cocoa.ECSTaskNotFound error.
+// ConvertFailureToError converts an ECS failure message into a
+// cocoa.ECSTaskFailureError, which can be classified with
+// cocoa.ClassifyRunTaskFailure. If the failure is due to being unable to find
+// the task, it will return a cocoa.ECSTaskNotFound error.
 // Docs: https://docs.aws.amazon.com/AmazonECS/latest/developerguide/api_failures_messages.html
 func ConvertFailureToError(f types.Failure) error {
 	if isTaskNotFoundFailure(f) {
 		return cocoa.NewECSTaskNotFoundError(utility.FromStringPtr(f.Arn))
 	}
-	var parts []string
-	if arn := utility.FromStringPtr(f.Arn); arn != "" {
-		parts = append(parts, fmt.Sprintf("task '%s'", arn))
-	}
-	if reason := utility.FromStringPtr(f.Reason); reason != "" {
-		parts = append(parts, fmt.Sprintf("(reason) %s", reason))
-	}
-	if detail := utility.FromStringPtr(f.Detail); detail != "" {
-		parts = append(parts, fmt.Sprintf("(detail) %s", detail))
-	}
-	if len(parts) == 0 {
-		return errors.New("ECS failure did not contain any additional failure information")
-	}
-	return errors.New(strings.Join(parts, ": "))
+	return cocoa.NewECSTaskFailureError(utility.FromStringPtr(f.Arn), utility.FromStringPtr(f.Reason), utility.FromStringPtr(f.Detail))
 }
 
 // isTaskNotFoundFailure returns whether or not the failure reason returned from
@@ -334,7 +490,18 @@ func isTaskNotFoundFailure(f types.Failure) bool {
 	return f.Arn != nil && utility.FromStringPtr(f.Reason) == ReasonTaskMissing
 }
 
-// ReasonTaskMissing indicates that a task cannot be found because it is
-// missing. This can happen for reasons such as the task never existed, or it
-// has been stopped for a long time.
-const ReasonTaskMissing = "MISSING"
+const (
+	// ReasonTaskMissing indicates that a task cannot be found because it is
+	// missing. This can happen for reasons such as the task never existed, or
+	// it has been stopped for a long time.
+	ReasonTaskMissing = "MISSING"
+	// ReasonResourceCPU indicates that a task could not be placed because no
+	// container instance in the cluster has enough CPU available.
+	ReasonResourceCPU = "RESOURCE:CPU"
+	// ReasonResourceMemory indicates that a task could not be placed because
+	// no container instance in the cluster has enough memory available.
+	ReasonResourceMemory = "RESOURCE:MEMORY"
+	// ReasonAgent indicates that a task could not be placed because the
+	// container instance's agent is disconnected.
+	ReasonAgent = "AGENT"
+)
@@ -86,6 +86,17 @@ func TestConvertFailureToError(t *testing.T) {
 		})
 		assert.True(t, cocoa.IsECSTaskNotFoundError(err))
 	})
+	t.Run("ConvertsToClassifiableFailure", func(t *testing.T) {
+		err := ConvertFailureToError(types.Failure{
+			Arn:    aws.String("arn"),
+			Reason: aws.String(ReasonResourceMemory),
+		})
+		tfe, ok := cocoa.AsECSTaskFailureError(err)
+		require.True(t, ok)
+		assert.Equal(t, ReasonResourceMemory, tfe.Reason)
+		assert.True(t, cocoa.IsRetryableRunTaskFailure(err))
+		assert.Equal(t, cocoa.RunTaskFailureClassCapacity, cocoa.ClassifyRunTaskFailure(err))
+	})
 }
 
 func TestIsNonRetryableError(t *testing.T) {
@@ -0,0 +1,175 @@
+package ecs
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// maxDescribeContainerInstances is the maximum number of container instances
+// that ECS can describe in a single request.
+const maxDescribeContainerInstances = 100
+
+// ContainerInstanceCapacity is the CPU and memory of a single container
+// instance.
+type ContainerInstanceCapacity struct {
+	// ARN is the ARN of the container instance.
+	ARN string
+	// RegisteredCPU is the total number of CPU units that the container
+	// instance registered with the cluster.
+	RegisteredCPU int
+	// RegisteredMemoryMB is the total amount of memory (in MB) that the
+	// container instance registered with the cluster.
+	RegisteredMemoryMB int
+	// RemainingCPU is the number of CPU units that are not yet used by tasks
+	// on the container instance.
+	RemainingCPU int
+	// RemainingMemoryMB is the amount of memory (in MB) that is not yet used
+	// by tasks on the container instance.
+	RemainingMemoryMB int
+}
+
+// ClusterCapacity is a snapshot of the CPU and memory across all the active
+// container instances in a cluster.
+type ClusterCapacity struct {
+	// Cluster is the name of the cluster.
+	Cluster string
+	// Instances are the capacities of each active container instance in the
+	// cluster.
+	Instances []ContainerInstanceCapacity
+	// RegisteredCPU is the total number of CPU units registered across all
+	// container instances.
+	RegisteredCPU int
+	// RegisteredMemoryMB is the total amount of memory (in MB) registered
+	// across all container instances.
+	RegisteredMemoryMB int
+	// RemainingCPU is the total number of CPU units remaining across all
+	// container instances.
+	RemainingCPU int
+	// RemainingMemoryMB is the total amount of memory (in MB) remaining across
+	// all container instances.
+	RemainingMemoryMB int
+}
+
+// GetClusterCapacity returns a snapshot of the CPU and memory of all the active
+// container instances in the cluster. Since tasks are constantly starting and
+// stopping, the snapshot is only accurate at the time it is taken.
+func GetClusterCapacity(ctx context.Context, c cocoa.ECSClient, cluster string) (*ClusterCapacity, error) {
+	if c == nil {
+		return nil, errors.New("must specify an ECS client")
+	}
+	if cluster == "" {
+		return nil, errors.New("must specify a cluster")
+	}
+
+	arns, err := listActiveContainerInstances(ctx, c, utility.ToStringPtr(cluster))
+	if err != nil {
+		return nil, errors.Wrap(err, "listing container instances")
+	}
+
+	capacity := ClusterCapacity{Cluster: cluster}
+	for start := 0; start < len(arns); start += maxDescribeContainerInstances {
+		end := start + maxDescribeContainerInstances
+		if end > len(arns) {
+			end = len(arns)
+		}
+
+		out, err := c.DescribeContainerInstances(ctx, &ecs.DescribeContainerInstancesInput{
+			Cluster:            utility.ToStringPtr(cluster),
+			ContainerInstances: arns[start:end],
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "describing container instances")
+		}
+		if len(out.Failures) != 0 {
+			catcher := grip.NewBasicCatcher()
+			for _, f := range out.Failures {
+				catcher.Add(ConvertFailureToError(f))
+			}
+			return nil, errors.Wrap(catcher.Resolve(), "describing container instances")
+		}
+
+		for _, instance := range out.ContainerInstances {
+			capacity.add(translateContainerInstanceCapacity(instance))
+		}
+	}
+
+	return &capacity, nil
+}
+
+// add adds the container instance's capacity to the cluster capacity.
+func (c *ClusterCapacity) add(instance ContainerInstanceCapacity) {
+	c.Instances = append(c.Instances, instance)
+	c.RegisteredCPU += instance.RegisteredCPU
+	c.RegisteredMemoryMB += instance.RegisteredMemoryMB
+	c.RemainingCPU += instance.RemainingCPU
+	c.RemainingMemoryMB += instance.RemainingMemoryMB
+}
+
+// CanFit returns whether or not a pod with the given definition can fit on at
+// least one of the cluster's container instances based on the remaining CPU
+// and memory. Since a pod cannot be split across multiple container
+// instances, a pod may not fit even if the cluster has enough remaining
+// resources in total.
+func (c *ClusterCapacity) CanFit(def cocoa.ECSPodDefinitionOptions) bool {
+	cpu, memMB := podDefinitionResourceRequirements(def)
+	for _, instance := range c.Instances {
+		if instance.RemainingCPU >= cpu && instance.RemainingMemoryMB >= memMB {
+			return true
+		}
+	}
+	return false
+}
+
+// podDefinitionResourceRequirements returns the CPU and memory that a pod
+// requires. If the pod-level limit is not set, the pod requires the sum of its
+// containers' resources.
+func podDefinitionResourceRequirements(def cocoa.ECSPodDefinitionOptions) (cpu int, memMB int) {
+	if def.CPU != nil {
+		cpu = *def.CPU
+	} else {
+		for _, containerDef := range def.ContainerDefinitions {
+			cpu += utility.FromIntPtr(containerDef.CPU)
+		}
+	}
+
+	if def.MemoryMB != nil {
+		memMB = *def.MemoryMB
+	} else {
+		for _, containerDef := range def.ContainerDefinitions {
+			memMB += utility.FromIntPtr(containerDef.MemoryMB)
+		}
+	}
+
+	return cpu, memMB
+}
+
+// translateContainerInstanceCapacity translates the ECS container instance's
+// resources into its CPU and memory capacity.
+func translateContainerInstanceCapacity(instance types.ContainerInstance) ContainerInstanceCapacity {
+	capacity := ContainerInstanceCapacity{
+		ARN: utility.FromStringPtr(instance.ContainerInstanceArn),
+	}
+	capacity.RegisteredCPU, capacity.RegisteredMemoryMB = translateCPUAndMemoryResources(instance.RegisteredResources)
+	capacity.RemainingCPU, capacity.RemainingMemoryMB = translateCPUAndMemoryResources(instance.RemainingResources)
+	return capacity
+}
+
+// translateCPUAndMemoryResources returns the CPU and memory from the ECS
+// resources.
+func translateCPUAndMemoryResources(resources []types.Resource) (cpu int, memMB int) {
+	for _, r := range resources {
+		switch utility.FromStringPtr(r.Name) {
+		case "CPU":
+			cpu = int(r.IntegerValue)
+		case "MEMORY":
+			memMB = int(r.IntegerValue)
+		}
+	}
+	return cpu, memMB
+}
@@ -0,0 +1,88 @@
+package ecs
+
+import (
+	"context"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+// deferredSecretPlaceholder is the value of a deferred secret from when it is
+// created with its pod definition until it is resolved.
+const deferredSecretPlaceholder = "cocoa-deferred-secret-placeholder"
+
+// mustCreateSecret returns whether or not the secret must be created along
+// with its pod definition, which is the case for new secrets and for deferred
+// secrets that have not been created yet.
+func mustCreateSecret(opts cocoa.SecretOptions) bool {
+	return opts.NewValue != nil || (utility.FromBoolPtr(opts.Deferred) && opts.ID == nil)
+}
+
+// withDeferredSecretPlaceholder returns a copy of the secret options in which a
+// deferred secret is given the placeholder value so that it can be created.
+func withDeferredSecretPlaceholder(opts cocoa.SecretOptions) cocoa.SecretOptions {
+	if utility.FromBoolPtr(opts.Deferred) && opts.NewValue == nil {
+		opts.SetNewValue(deferredSecretPlaceholder)
+	}
+	return opts
+}
+
+// ResolveDeferredSecrets sets the values of the pod definition's deferred
+// secrets (see cocoa.SecretOptions.Deferred). The pod definition options must
+// be the ones returned when the pod definition was created, since they contain
+// the IDs of the deferred secrets. The values must resolve every deferred
+// secret (see cocoa.ValidateDeferredSecretValues), otherwise none of them are
+// set.
+//
+// CreatePod resolves the deferred secrets itself before it runs each pod, so
+// this only has to be called before running a pod from a pod definition that
+// was created in advance without CreatePod (e.g. before
+// CreatePodFromExistingDefinition). Since the secret values are set in place,
+// every pod that runs from the pod definition afterwards uses the new values,
+// so pods that are created concurrently from the same pod definition should
+// not need different values.
+func ResolveDeferredSecrets(ctx context.Context, v cocoa.Vault, opts cocoa.ECSPodDefinitionOptions, values map[string]string) error {
+	if err := cocoa.ValidateDeferredSecretValues(opts, values); err != nil {
+		return errors.Wrap(err, "unresolved deferred secrets")
+	}
+
+	ids := map[string]string{}
+	for _, ref := range cocoa.CollectSecretReferences(opts) {
+		if !ref.Deferred {
+			continue
+		}
+		if ref.ID == "" {
+			return errors.Errorf("deferred secret '%s' has not been created", ref.Name)
+		}
+		ids[ref.Name] = ref.ID
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if v == nil {
+		return errors.New("no vault was specified")
+	}
+
+	for _, name := range cocoa.DeferredSecretNames(opts) {
+		secret := cocoa.NewNamedSecret().
+			SetName(ids[name]).
+			SetValue(values[name])
+		if err := v.UpdateValue(ctx, *secret); err != nil {
+			return errors.Wrapf(err, "resolving deferred secret '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+// validateExistingDefinitionDeferredSecrets checks that no deferred secret
+// values are given when running a task from an existing pod definition. The
+// secrets of an existing pod definition are not known, so its deferred secrets
+// must be resolved beforehand with ResolveDeferredSecrets.
+func validateExistingDefinitionDeferredSecrets(values map[string]string) error {
+	if len(values) != 0 {
+		return errors.New("cannot resolve deferred secrets for an existing pod definition; they must be resolved with ResolveDeferredSecrets before running the pod")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package ecs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updatingVault is a vault that records the secret values that are updated.
+type updatingVault struct {
+	cocoa.Vault
+
+	updated   map[string]string
+	updateErr error
+}
+
+func (v *updatingVault) UpdateValue(ctx context.Context, s cocoa.NamedSecret) error {
+	if v.updateErr != nil {
+		return v.updateErr
+	}
+	v.updated[utility.FromStringPtr(s.Name)] = utility.FromStringPtr(s.Value)
+	return nil
+}
+
+func TestResolveDeferredSecrets(t *testing.T) {
+	makeOpts := func(id string) cocoa.ECSPodDefinitionOptions {
+		secretOpts := cocoa.NewSecretOptions().SetName("deferred").SetDeferred(true)
+		if id != "" {
+			secretOpts.SetID(id)
+		}
+		containerDef := cocoa.NewECSContainerDefinition().
+			SetName("container").
+			SetImage("image").
+			AddEnvironmentVariables(
+				*cocoa.NewEnvironmentVariable().SetName("first").SetSecretOptions(*secretOpts),
+				*cocoa.NewEnvironmentVariable().SetName("second").SetSecretOptions(*secretOpts),
+				*cocoa.NewEnvironmentVariable().SetName("existing").SetSecretOptions(*cocoa.NewSecretOptions().SetID("existing_id")),
+			)
+		return *cocoa.NewECSPodDefinitionOptions().AddContainerDefinitions(*containerDef)
+	}
+
+	t.Run("UpdatesEachDeferredSecretOnce", func(t *testing.T) {
+		v := &updatingVault{updated: map[string]string{}}
+		require.NoError(t, ResolveDeferredSecrets(context.Background(), v, makeOpts("deferred_id"), map[string]string{"deferred": "value"}))
+		assert.Equal(t, map[string]string{"deferred_id": "value"}, v.updated)
+	})
+	t.Run("SucceedsWithoutDeferredSecrets", func(t *testing.T) {
+		opts := cocoa.NewECSPodDefinitionOptions().AddContainerDefinitions(*cocoa.NewECSContainerDefinition().SetImage("image"))
+		assert.NoError(t, ResolveDeferredSecrets(context.Background(), nil, *opts, nil))
+	})
+	t.Run("FailsWithMissingValue", func(t *testing.T) {
+		v := &updatingVault{updated: map[string]string{}}
+		assert.Error(t, ResolveDeferredSecrets(context.Background(), v, makeOpts("deferred_id"), nil))
+		assert.Empty(t, v.updated)
+	})
+	t.Run("FailsWithValueForUnknownSecret", func(t *testing.T) {
+		v := &updatingVault{updated: map[string]string{}}
+		assert.Error(t, ResolveDeferredSecrets(context.Background(), v, makeOpts("deferred_id"), map[string]string{
+			"deferred": "value",
+			"unknown":  "value",
+		}))
+		assert.Empty(t, v.updated)
+	})
+	t.Run("FailsWithUncreatedDeferredSecret", func(t *testing.T) {
+		v := &updatingVault{updated: map[string]string{}}
+		assert.Error(t, ResolveDeferredSecrets(context.Background(), v, makeOpts(""), map[string]string{"deferred": "value"}))
+		assert.Empty(t, v.updated)
+	})
+	t.Run("FailsWithoutVault", func(t *testing.T) {
+		assert.Error(t, ResolveDeferredSecrets(context.Background(), nil, makeOpts("deferred_id"), map[string]string{"deferred": "value"}))
+	})
+	t.Run("FailsWhenUpdatingValueFails", func(t *testing.T) {
+		v := &updatingVault{updateErr: errors.New("fake error")}
+		assert.Error(t, ResolveDeferredSecrets(context.Background(), v, makeOpts("deferred_id"), map[string]string{"deferred": "value"}))
+	})
+}
+
+func TestCreateSecretsWithDeferredSecrets(t *testing.T) {
+	t.Run("MustCreateSecret", func(t *testing.T) {
+		assert.True(t, mustCreateSecret(*cocoa.NewSecretOptions().SetName("name").SetNewValue("value")))
+		assert.True(t, mustCreateSecret(*cocoa.NewSecretOptions().SetName("name").SetDeferred(true)))
+		assert.False(t, mustCreateSecret(*cocoa.NewSecretOptions().SetName("name").SetDeferred(true).SetID("id")))
+		assert.False(t, mustCreateSecret(*cocoa.NewSecretOptions().SetID("id")))
+	})
+	t.Run("PlaceholderOnlyAppliesToDeferredSecrets", func(t *testing.T) {
+		deferred := withDeferredSecretPlaceholder(*cocoa.NewSecretOptions().SetName("name").SetDeferred(true))
+		assert.Equal(t, deferredSecretPlaceholder, utility.FromStringPtr(deferred.NewValue))
+
+		plain := withDeferredSecretPlaceholder(*cocoa.NewSecretOptions().SetName("name").SetNewValue("value"))
+		assert.Equal(t, "value", utility.FromStringPtr(plain.NewValue))
+	})
+	t.Run("ExistingDefinitionRejectsDeferredSecretValues", func(t *testing.T) {
+		assert.NoError(t, validateExistingDefinitionDeferredSecrets(nil))
+		assert.Error(t, validateExistingDefinitionDeferredSecrets(map[string]string{"deferred": "value"}))
+	})
+}
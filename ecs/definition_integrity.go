@@ -0,0 +1,244 @@
+package ecs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+// canonicalTaskDefinition is the part of a task definition that cocoa sets
+// when registering it. It can be built either from the input to register a
+// task definition or from a task definition that ECS describes, so the two can
+// be compared.
+type canonicalTaskDefinition struct {
+	Family               *string
+	ContainerDefinitions []types.ContainerDefinition
+	Volumes              []types.Volume
+	Cpu                  *string
+	Memory               *string
+	NetworkMode          types.NetworkMode
+	TaskRoleArn          *string
+	ExecutionRoleArn     *string
+}
+
+// TaskDefinitionChecksum returns a checksum of the canonical form of the task
+// definition that would be registered by the input. The checksum does not
+// depend on the input's tags or on the order of the containers' environment
+// variables and secrets, and fields that are unset are treated the same as
+// fields that are explicitly set to their zero value.
+func TaskDefinitionChecksum(in *ecs.RegisterTaskDefinitionInput) (string, error) {
+	if in == nil {
+		return "", errors.New("must specify a task definition input")
+	}
+	return canonicalTaskDefinition{
+		Family:               in.Family,
+		ContainerDefinitions: in.ContainerDefinitions,
+		Volumes:              in.Volumes,
+		Cpu:                  in.Cpu,
+		Memory:               in.Memory,
+		NetworkMode:          in.NetworkMode,
+		TaskRoleArn:          in.TaskRoleArn,
+		ExecutionRoleArn:     in.ExecutionRoleArn,
+	}.checksum()
+}
+
+// describedTaskDefinitionChecksum returns the checksum of a task definition
+// described by ECS, which is comparable to the checksum of the input that
+// registered it.
+func describedTaskDefinitionChecksum(def types.TaskDefinition) (string, error) {
+	return canonicalTaskDefinition{
+		Family:               def.Family,
+		ContainerDefinitions: def.ContainerDefinitions,
+		Volumes:              def.Volumes,
+		Cpu:                  def.Cpu,
+		Memory:               def.Memory,
+		NetworkMode:          def.NetworkMode,
+		TaskRoleArn:          def.TaskRoleArn,
+		ExecutionRoleArn:     def.ExecutionRoleArn,
+	}.checksum()
+}
+
+// checksum returns the SHA-256 checksum of the canonical JSON encoding of the
+// task definition.
+func (d canonicalTaskDefinition) checksum() (string, error) {
+	d.ContainerDefinitions = withECSContainerDefaults(sortContainerDefinitionVariables(d.ContainerDefinitions), d.NetworkMode)
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling task definition to JSON")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return "", errors.Wrap(err, "decoding task definition JSON")
+	}
+
+	// Maps are marshalled with their keys in sorted order, so the encoding is
+	// canonical.
+	canonical, err := json.Marshal(pruneZeroJSONValues(generic))
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling canonical task definition to JSON")
+	}
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// sortContainerDefinitionVariables returns copies of the container
+// definitions with their environment variables and secrets sorted by name,
+// since ECS does not preserve their order.
+func sortContainerDefinitionVariables(defs []types.ContainerDefinition) []types.ContainerDefinition {
+	sorted := make([]types.ContainerDefinition, 0, len(defs))
+	for _, def := range defs {
+		env := append([]types.KeyValuePair{}, def.Environment...)
+		sort.SliceStable(env, func(i, j int) bool {
+			return utility.FromStringPtr(env[i].Name) < utility.FromStringPtr(env[j].Name)
+		})
+		def.Environment = env
+
+		secrets := append([]types.Secret{}, def.Secrets...)
+		sort.SliceStable(secrets, func(i, j int) bool {
+			return utility.FromStringPtr(secrets[i].Name) < utility.FromStringPtr(secrets[j].Name)
+		})
+		def.Secrets = secrets
+
+		sorted = append(sorted, def)
+	}
+	return sorted
+}
+
+// withECSContainerDefaults sets the defaults that ECS fills in when it
+// registers the container definitions, so that a task definition described by
+// ECS compares equal to the input that registered it. Containers are essential
+// by default, port mappings use TCP by default, and in AWSVPC network mode, the
+// host port is always the same as the container port. The container
+// definitions must already be copies, since they are modified in place.
+func withECSContainerDefaults(defs []types.ContainerDefinition, mode types.NetworkMode) []types.ContainerDefinition {
+	for i := range defs {
+		if defs[i].Essential == nil {
+			defs[i].Essential = utility.ToBoolPtr(true)
+		}
+
+		mappings := make([]types.PortMapping, 0, len(defs[i].PortMappings))
+		for _, pm := range defs[i].PortMappings {
+			if pm.Protocol == "" {
+				pm.Protocol = types.TransportProtocolTcp
+			}
+			if mode == types.NetworkModeAwsvpc && (pm.HostPort == nil || *pm.HostPort == 0) {
+				pm.HostPort = pm.ContainerPort
+			}
+			mappings = append(mappings, pm)
+		}
+		defs[i].PortMappings = mappings
+	}
+	return defs
+}
+
+// pruneZeroJSONValues removes the null, empty, false, and zero values from the
+// decoded JSON so that fields that ECS reports with their zero value compare
+// equal to fields that were never set. Elements of arrays are pruned but not
+// removed so that the remaining elements keep their positions. It returns nil
+// if the value itself is a zero value.
+func pruneZeroJSONValues(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range val {
+			if pruned := pruneZeroJSONValues(elem); pruned != nil {
+				val[k] = pruned
+			} else {
+				delete(val, k)
+			}
+		}
+		if len(val) == 0 {
+			return nil
+		}
+		return val
+	case []interface{}:
+		if len(val) == 0 {
+			return nil
+		}
+		for i := range val {
+			val[i] = pruneZeroJSONValues(val[i])
+		}
+		return val
+	case string:
+		if val == "" {
+			return nil
+		}
+	case bool:
+		if !val {
+			return nil
+		}
+	case json.Number:
+		if f, err := val.Float64(); err == nil && f == 0 {
+			return nil
+		}
+	}
+	return v
+}
+
+// addChecksumTag adds a tag to the input whose value is the checksum of the
+// task definition that it registers.
+func addChecksumTag(in *ecs.RegisterTaskDefinitionInput, tagName string) error {
+	checksum, err := TaskDefinitionChecksum(in)
+	if err != nil {
+		return errors.Wrap(err, "computing task definition checksum")
+	}
+	in.Tags = append(in.Tags, types.Tag{
+		Key:   utility.ToStringPtr(tagName),
+		Value: utility.ToStringPtr(checksum),
+	})
+	return nil
+}
+
+// VerifyDefinitionIntegrity checks that the pod definition still matches the
+// checksum that was recorded in its checksum tag when it was registered. If
+// the pod definition was modified outside of cocoa (e.g. a new revision was
+// created from the console and kept the original's tags) or its checksum tag
+// is missing, this returns a cocoa.DefinitionDriftError. The pod definition
+// manager must have a checksum tag name to verify pod definitions.
+func (m *BasicPodDefinitionManager) VerifyDefinitionIntegrity(ctx context.Context, id string) error {
+	if m.checksumTagName == "" {
+		return errors.New("must specify a checksum tag name to verify pod definitions")
+	}
+
+	out, err := m.client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: utility.ToStringPtr(id),
+		Include:        []types.TaskDefinitionField{types.TaskDefinitionFieldTags},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "describing task definition '%s'", id)
+	}
+	if out.TaskDefinition == nil {
+		return errors.Errorf("expected task definition '%s' to exist, but none was returned", id)
+	}
+
+	actual, err := describedTaskDefinitionChecksum(*out.TaskDefinition)
+	if err != nil {
+		return errors.Wrapf(err, "computing checksum of task definition '%s'", id)
+	}
+
+	var expected string
+	for _, tag := range out.Tags {
+		if utility.FromStringPtr(tag.Key) == m.checksumTagName {
+			expected = utility.FromStringPtr(tag.Value)
+			break
+		}
+	}
+	if expected != actual {
+		return cocoa.NewDefinitionDriftError(id, expected, actual)
+	}
+
+	return nil
+}
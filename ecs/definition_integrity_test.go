@@ -0,0 +1,121 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskDefinitionChecksum(t *testing.T) {
+	newInput := func() *ecs.RegisterTaskDefinitionInput {
+		return &ecs.RegisterTaskDefinitionInput{
+			Family: utility.ToStringPtr("family"),
+			Cpu:    utility.ToStringPtr("512"),
+			Memory: utility.ToStringPtr("1024"),
+			ContainerDefinitions: []types.ContainerDefinition{{
+				Name:  utility.ToStringPtr("container"),
+				Image: utility.ToStringPtr("image"),
+				Environment: []types.KeyValuePair{
+					{Name: utility.ToStringPtr("b"), Value: utility.ToStringPtr("1")},
+					{Name: utility.ToStringPtr("a"), Value: utility.ToStringPtr("2")},
+				},
+			}},
+			Tags: []types.Tag{{Key: utility.ToStringPtr("key"), Value: utility.ToStringPtr("value")}},
+		}
+	}
+
+	t.Run("IsDeterministic", func(t *testing.T) {
+		checksum, err := TaskDefinitionChecksum(newInput())
+		require.NoError(t, err)
+		assert.NotZero(t, checksum)
+
+		again, err := TaskDefinitionChecksum(newInput())
+		require.NoError(t, err)
+		assert.Equal(t, checksum, again)
+	})
+	t.Run("IgnoresTagsAndEnvironmentVariableOrder", func(t *testing.T) {
+		checksum, err := TaskDefinitionChecksum(newInput())
+		require.NoError(t, err)
+
+		in := newInput()
+		in.Tags = nil
+		env := in.ContainerDefinitions[0].Environment
+		env[0], env[1] = env[1], env[0]
+		other, err := TaskDefinitionChecksum(in)
+		require.NoError(t, err)
+		assert.Equal(t, checksum, other)
+	})
+	t.Run("ChangesWithDefinition", func(t *testing.T) {
+		checksum, err := TaskDefinitionChecksum(newInput())
+		require.NoError(t, err)
+
+		in := newInput()
+		in.ContainerDefinitions[0].Image = utility.ToStringPtr("other_image")
+		other, err := TaskDefinitionChecksum(in)
+		require.NoError(t, err)
+		assert.NotEqual(t, checksum, other)
+	})
+	t.Run("MatchesDescribedTaskDefinitionWithZeroValuedFields", func(t *testing.T) {
+		in := newInput()
+		checksum, err := TaskDefinitionChecksum(in)
+		require.NoError(t, err)
+
+		containerDef := in.ContainerDefinitions[0]
+		containerDef.MountPoints = []types.MountPoint{}
+		described, err := describedTaskDefinitionChecksum(types.TaskDefinition{
+			TaskDefinitionArn:    utility.ToStringPtr("arn:aws:ecs:us-east-1:123456789012:task-definition/family:1"),
+			Family:               in.Family,
+			Revision:             1,
+			Cpu:                  in.Cpu,
+			Memory:               in.Memory,
+			ContainerDefinitions: []types.ContainerDefinition{containerDef},
+			Status:               types.TaskDefinitionStatusActive,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, checksum, described)
+	})
+	t.Run("MatchesDescribedTaskDefinitionWithECSDefaults", func(t *testing.T) {
+		in := newInput()
+		in.NetworkMode = types.NetworkModeAwsvpc
+		in.ContainerDefinitions[0].PortMappings = []types.PortMapping{{ContainerPort: utility.ToInt32Ptr(8080)}}
+		checksum, err := TaskDefinitionChecksum(in)
+		require.NoError(t, err)
+
+		containerDef := in.ContainerDefinitions[0]
+		containerDef.Essential = utility.ToBoolPtr(true)
+		containerDef.PortMappings = []types.PortMapping{{
+			ContainerPort: utility.ToInt32Ptr(8080),
+			HostPort:      utility.ToInt32Ptr(8080),
+			Protocol:      types.TransportProtocolTcp,
+		}}
+		described, err := describedTaskDefinitionChecksum(types.TaskDefinition{
+			Family:               in.Family,
+			Cpu:                  in.Cpu,
+			Memory:               in.Memory,
+			NetworkMode:          in.NetworkMode,
+			ContainerDefinitions: []types.ContainerDefinition{containerDef},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, checksum, described)
+	})
+	t.Run("ChangesWithNonDefaultPortMapping", func(t *testing.T) {
+		in := newInput()
+		in.ContainerDefinitions[0].PortMappings = []types.PortMapping{{ContainerPort: utility.ToInt32Ptr(8080)}}
+		checksum, err := TaskDefinitionChecksum(in)
+		require.NoError(t, err)
+
+		in.ContainerDefinitions[0].PortMappings[0].Protocol = types.TransportProtocolUdp
+		other, err := TaskDefinitionChecksum(in)
+		require.NoError(t, err)
+		assert.NotEqual(t, checksum, other)
+	})
+	t.Run("FailsWithoutInput", func(t *testing.T) {
+		checksum, err := TaskDefinitionChecksum(nil)
+		assert.Error(t, err)
+		assert.Zero(t, checksum)
+	})
+}
@@ -0,0 +1,91 @@
+package ecs
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/identity"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+// maxDescribeTasks is the maximum number of tasks that ECS can describe in a
+// single request.
+const maxDescribeTasks = 100
+
+// DescribeAllTasks describes all the given tasks in the cluster. Unlike
+// DescribeTasks, it can describe any number of tasks by splitting them into
+// multiple requests. The tasks and failures from all the requests are merged
+// and returned in the same order as the tasks were given.
+func (c *BasicClient) DescribeAllTasks(ctx context.Context, cluster string, arns []string) (*ecs.DescribeTasksOutput, error) {
+	return DescribeAllTasks(ctx, c, cluster, arns)
+}
+
+// DescribeAllTasks describes all the given tasks in the cluster using the
+// client. It splits the tasks into as many requests as needed to stay within
+// the ECS limit on the number of tasks per request. The tasks and failures from
+// all the requests are merged and returned in the same order as the tasks were
+// given. The tasks can be identified either by ARN or by ID.
+func DescribeAllTasks(ctx context.Context, c cocoa.ECSClient, cluster string, arns []string) (*ecs.DescribeTasksOutput, error) {
+	return describeAllTasks(ctx, c, cluster, arns, nil)
+}
+
+// describeAllTasks is the same as DescribeAllTasks, but also includes the
+// additional fields in the described tasks.
+func describeAllTasks(ctx context.Context, c cocoa.ECSClient, cluster string, arns []string, include []types.TaskField) (*ecs.DescribeTasksOutput, error) {
+	if c == nil {
+		return nil, errors.New("must specify a client")
+	}
+
+	var merged ecs.DescribeTasksOutput
+	for start := 0; start < len(arns); start += maxDescribeTasks {
+		end := start + maxDescribeTasks
+		if end > len(arns) {
+			end = len(arns)
+		}
+
+		out, err := c.DescribeTasks(ctx, &ecs.DescribeTasksInput{
+			Cluster: aws.String(cluster),
+			Tasks:   arns[start:end],
+			Include: include,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "describing tasks %d to %d", start, end-1)
+		}
+
+		merged.Tasks = append(merged.Tasks, out.Tasks...)
+		merged.Failures = append(merged.Failures, out.Failures...)
+	}
+
+	// ECS does not guarantee that the tasks are returned in the order that they
+	// were requested, so restore the original order.
+	order := make(map[string]int, len(arns))
+	for i, arn := range arns {
+		if _, ok := order[arn]; !ok {
+			order[arn] = i
+		}
+	}
+	indexOf := func(arn string) int {
+		if i, ok := order[arn]; ok {
+			return i
+		}
+		// The task may have been requested by its ID, which is the last part
+		// of the ARN.
+		if i, ok := order[identity.TaskIDFromARN(arn)]; ok {
+			return i
+		}
+		return len(arns)
+	}
+	sort.SliceStable(merged.Tasks, func(i, j int) bool {
+		return indexOf(utility.FromStringPtr(merged.Tasks[i].TaskArn)) < indexOf(utility.FromStringPtr(merged.Tasks[j].TaskArn))
+	})
+	sort.SliceStable(merged.Failures, func(i, j int) bool {
+		return indexOf(utility.FromStringPtr(merged.Failures[i].Arn)) < indexOf(utility.FromStringPtr(merged.Failures[j].Arn))
+	})
+
+	return &merged, nil
+}
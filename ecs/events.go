@@ -0,0 +1,20 @@
+package ecs
+
+import (
+	"context"
+	"time"
+
+	"github.com/evergreen-ci/cocoa"
+)
+
+// sendEvent sends the event to the event sink, if there is one. If the event
+// has no time, it is set to the current time.
+func sendEvent(ctx context.Context, sink cocoa.EventSink, e cocoa.Event) {
+	if sink == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	sink.SendEvent(ctx, e)
+}
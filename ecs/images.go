@@ -0,0 +1,133 @@
+package ecs
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// ImageValidationPolicy determines how image references that fail image
+// validation are handled.
+type ImageValidationPolicy string
+
+const (
+	// ImageValidationPolicyWarn logs a warning for invalid image references
+	// but still allows the pod definition to be created.
+	ImageValidationPolicyWarn ImageValidationPolicy = "warn"
+	// ImageValidationPolicyError rejects pod definitions that have invalid
+	// image references.
+	ImageValidationPolicyError ImageValidationPolicy = "error"
+)
+
+// Validate checks that the image validation policy is recognized.
+func (p ImageValidationPolicy) Validate() error {
+	switch p {
+	case ImageValidationPolicyWarn, ImageValidationPolicyError:
+		return nil
+	default:
+		return errors.Errorf("unrecognized image validation policy '%s'", p)
+	}
+}
+
+// ImageValidationOptions are options to check that the images used by pod
+// definitions are pinned so that pods are deployed reproducibly.
+type ImageValidationOptions struct {
+	// RequireDigest indicates whether or not images must be pinned by digest
+	// (e.g. "image@sha256:..."). If false, images only must not use the
+	// "latest" tag, either explicitly or implicitly by omitting the tag. By
+	// default, digests are not required.
+	RequireDigest *bool
+	// Policy determines how invalid image references are handled. By default,
+	// invalid image references are errors.
+	Policy *ImageValidationPolicy
+}
+
+// NewImageValidationOptions returns new uninitialized options to validate
+// images.
+func NewImageValidationOptions() *ImageValidationOptions {
+	return &ImageValidationOptions{}
+}
+
+// SetRequireDigest sets whether or not images must be pinned by digest.
+func (o *ImageValidationOptions) SetRequireDigest(require bool) *ImageValidationOptions {
+	o.RequireDigest = &require
+	return o
+}
+
+// SetPolicy sets how invalid image references are handled.
+func (o *ImageValidationOptions) SetPolicy(p ImageValidationPolicy) *ImageValidationOptions {
+	o.Policy = &p
+	return o
+}
+
+// Validate checks that the image validation options are valid and sets
+// defaults where possible.
+func (o *ImageValidationOptions) Validate() error {
+	if o.Policy == nil {
+		o.SetPolicy(ImageValidationPolicyError)
+	}
+	return errors.Wrap(o.Policy.Validate(), "invalid policy")
+}
+
+// imageDigestPattern matches the digest suffix of an image reference.
+var imageDigestPattern = regexp.MustCompile(`@[a-z0-9]+([+._-][a-z0-9]+)*:[a-fA-F0-9]{32,}$`)
+
+// ValidateImageReference checks that the image reference is pinned. If
+// requireDigest is true, the image must be pinned by digest; otherwise, the
+// image must either be pinned by digest or have a tag other than "latest".
+func ValidateImageReference(image string, requireDigest bool) error {
+	if image == "" {
+		return errors.New("image cannot be empty")
+	}
+	if imageDigestPattern.MatchString(image) {
+		return nil
+	}
+	if strings.Contains(image, "@") {
+		return errors.Errorf("image '%s' has an invalid digest", image)
+	}
+	if requireDigest {
+		return errors.Errorf("image '%s' must be pinned by digest", image)
+	}
+
+	// The tag can only appear in the last path component since a registry
+	// host can also contain a port separated by a colon.
+	name := image[strings.LastIndex(image, "/")+1:]
+	sep := strings.LastIndex(name, ":")
+	if sep == -1 {
+		return errors.Errorf("image '%s' has no tag, so it implicitly uses the 'latest' tag", image)
+	}
+	if tag := name[sep+1:]; tag == "latest" {
+		return errors.Errorf("image '%s' cannot use the 'latest' tag", image)
+	}
+
+	return nil
+}
+
+// ValidatePodDefinitionImages checks that all the container images in the pod
+// definition are pinned according to ValidateImageReference.
+func ValidatePodDefinitionImages(opts cocoa.ECSPodDefinitionOptions, requireDigest bool) error {
+	catcher := grip.NewBasicCatcher()
+	for _, def := range opts.ContainerDefinitions {
+		catcher.Wrapf(ValidateImageReference(utility.FromStringPtr(def.Image), requireDigest), "container '%s'", utility.FromStringPtr(def.Name))
+	}
+	return catcher.Resolve()
+}
+
+// ValidatePodDefinitionImagePullBehavior checks that the container images in
+// the pod definition can safely run on container instances that use the given
+// image pull behavior. If the behavior can run cached images, the images must
+// be pinned according to ValidateImageReference so that a stale cached image
+// is never run in place of the intended one.
+func ValidatePodDefinitionImagePullBehavior(opts cocoa.ECSPodDefinitionOptions, b cocoa.ImagePullBehavior) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	if !b.UsesCachedImages() {
+		return nil
+	}
+	return errors.Wrapf(ValidatePodDefinitionImages(opts, false), "image pull behavior '%s' requires pinned images", b)
+}
@@ -0,0 +1,107 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testImageDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestValidateImageReference(t *testing.T) {
+	t.Run("SucceedsWithDigest", func(t *testing.T) {
+		assert.NoError(t, ValidateImageReference("image@"+testImageDigest, true))
+		assert.NoError(t, ValidateImageReference("registry:5000/org/image:tag@"+testImageDigest, true))
+	})
+	t.Run("SucceedsWithNonLatestTagWithoutRequiringDigest", func(t *testing.T) {
+		assert.NoError(t, ValidateImageReference("image:1.2.3", false))
+		assert.NoError(t, ValidateImageReference("registry:5000/org/image:1.2.3", false))
+	})
+	t.Run("FailsWithTagWhenRequiringDigest", func(t *testing.T) {
+		assert.Error(t, ValidateImageReference("image:1.2.3", true))
+	})
+	t.Run("FailsWithLatestTag", func(t *testing.T) {
+		assert.Error(t, ValidateImageReference("image:latest", false))
+	})
+	t.Run("FailsWithImplicitLatestTag", func(t *testing.T) {
+		assert.Error(t, ValidateImageReference("image", false))
+		assert.Error(t, ValidateImageReference("registry:5000/org/image", false), "registry port should not be mistaken for a tag")
+	})
+	t.Run("FailsWithInvalidDigest", func(t *testing.T) {
+		assert.Error(t, ValidateImageReference("image@sha256:abc", false))
+	})
+	t.Run("FailsWithEmptyImage", func(t *testing.T) {
+		assert.Error(t, ValidateImageReference("", false))
+	})
+}
+
+func TestValidatePodDefinitionImages(t *testing.T) {
+	opts := *cocoa.NewECSPodDefinitionOptions().
+		AddContainerDefinitions(
+			*cocoa.NewECSContainerDefinition().SetName("pinned").SetImage("image@" + testImageDigest),
+			*cocoa.NewECSContainerDefinition().SetName("unpinned").SetImage("image:latest"),
+		)
+
+	err := ValidatePodDefinitionImages(opts, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "container 'unpinned'")
+	assert.NotContains(t, err.Error(), "container 'pinned'")
+
+	opts.ContainerDefinitions = opts.ContainerDefinitions[:1]
+	assert.NoError(t, ValidatePodDefinitionImages(opts, true))
+}
+
+func TestValidatePodDefinitionImagePullBehavior(t *testing.T) {
+	pinned := *cocoa.NewECSPodDefinitionOptions().
+		AddContainerDefinitions(*cocoa.NewECSContainerDefinition().SetName("pinned").SetImage("image:1.0"))
+	unpinned := *cocoa.NewECSPodDefinitionOptions().
+		AddContainerDefinitions(*cocoa.NewECSContainerDefinition().SetName("unpinned").SetImage("image:latest"))
+
+	t.Run("SucceedsWithUnpinnedImagesForUncachedBehaviors", func(t *testing.T) {
+		assert.NoError(t, ValidatePodDefinitionImagePullBehavior(unpinned, cocoa.ImagePullBehaviorDefault))
+		assert.NoError(t, ValidatePodDefinitionImagePullBehavior(unpinned, cocoa.ImagePullBehaviorAlways))
+	})
+	t.Run("SucceedsWithPinnedImagesForCachedBehaviors", func(t *testing.T) {
+		assert.NoError(t, ValidatePodDefinitionImagePullBehavior(pinned, cocoa.ImagePullBehaviorOnce))
+		assert.NoError(t, ValidatePodDefinitionImagePullBehavior(pinned, cocoa.ImagePullBehaviorPreferCached))
+	})
+	t.Run("FailsWithUnpinnedImagesForCachedBehaviors", func(t *testing.T) {
+		assert.Error(t, ValidatePodDefinitionImagePullBehavior(unpinned, cocoa.ImagePullBehaviorOnce))
+		assert.Error(t, ValidatePodDefinitionImagePullBehavior(unpinned, cocoa.ImagePullBehaviorPreferCached))
+	})
+	t.Run("FailsWithInvalidBehavior", func(t *testing.T) {
+		assert.Error(t, ValidatePodDefinitionImagePullBehavior(pinned, "foo"))
+	})
+}
+
+func TestImageValidationOptions(t *testing.T) {
+	t.Run("NewImageValidationOptions", func(t *testing.T) {
+		opts := NewImageValidationOptions()
+		require.NotZero(t, opts)
+		assert.Zero(t, *opts)
+	})
+	t.Run("SetRequireDigest", func(t *testing.T) {
+		opts := NewImageValidationOptions().SetRequireDigest(true)
+		require.NotZero(t, opts.RequireDigest)
+		assert.True(t, *opts.RequireDigest)
+	})
+	t.Run("SetPolicy", func(t *testing.T) {
+		opts := NewImageValidationOptions().SetPolicy(ImageValidationPolicyWarn)
+		require.NotZero(t, opts.Policy)
+		assert.Equal(t, ImageValidationPolicyWarn, *opts.Policy)
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("DefaultsToErrorPolicy", func(t *testing.T) {
+			opts := NewImageValidationOptions()
+			require.NoError(t, opts.Validate())
+			require.NotZero(t, opts.Policy)
+			assert.Equal(t, ImageValidationPolicyError, *opts.Policy)
+		})
+		t.Run("FailsWithInvalidPolicy", func(t *testing.T) {
+			opts := NewImageValidationOptions().SetPolicy("foo")
+			assert.Error(t, opts.Validate())
+		})
+	})
+}
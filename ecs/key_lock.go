@@ -0,0 +1,61 @@
+package ecs
+
+import (
+	"context"
+	"sync"
+)
+
+// keyLock serializes operations that share the same key, while operations for
+// different keys can run concurrently.
+type keyLock struct {
+	mu    sync.Mutex
+	locks map[string]*keyLockEntry
+}
+
+// keyLockEntry is the lock for a single key along with the number of
+// operations that are holding or waiting for it.
+type keyLockEntry struct {
+	sem  chan struct{}
+	refs int
+}
+
+func newKeyLock() *keyLock {
+	return &keyLock{locks: map[string]*keyLockEntry{}}
+}
+
+// lock waits until the lock for the key is available and acquires it. It
+// returns a function to release the lock. If the context is done before the
+// lock is acquired, it returns the context's error.
+func (l *keyLock) lock(ctx context.Context, key string) (func(), error) {
+	l.mu.Lock()
+	entry, ok := l.locks[key]
+	if !ok {
+		entry = &keyLockEntry{sem: make(chan struct{}, 1)}
+		l.locks[key] = entry
+	}
+	entry.refs++
+	l.mu.Unlock()
+
+	select {
+	case entry.sem <- struct{}{}:
+		return func() {
+			<-entry.sem
+			l.release(key, entry)
+		}, nil
+	case <-ctx.Done():
+		l.release(key, entry)
+		return nil, ctx.Err()
+	}
+}
+
+// release removes the caller's reference to the key's lock and removes the
+// lock once no operations are holding or waiting for it.
+func (l *keyLock) release(key string, entry *keyLockEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.refs--
+	if entry.refs == 0 {
+		delete(l.locks, key)
+	}
+}
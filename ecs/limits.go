@@ -0,0 +1,85 @@
+package ecs
+
+import (
+	"encoding/json"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxTaskDefinitionSize is the maximum size (in bytes) of a task
+	// definition's JSON that ECS accepts.
+	MaxTaskDefinitionSize = 64 * 1024
+	// MaxContainerCommandSize is the maximum total size (in bytes) of a
+	// container's command arguments allowed by strict validation.
+	MaxContainerCommandSize = 16 * 1024
+	// MaxContainerEnvVars is the maximum number of environment variables in a
+	// single container allowed by strict validation.
+	MaxContainerEnvVars = 200
+	// MaxContainerEnvVarsSize is the maximum total size (in bytes) of a
+	// container's environment variable names and values allowed by strict
+	// validation.
+	MaxContainerEnvVarsSize = 32 * 1024
+)
+
+// ValidatePodDefinitionSizes checks that the pod definition is small enough to
+// be registered in ECS. It checks each container's command and environment
+// variables as well as the size of the entire task definition, so that
+// oversized definitions are rejected with an error naming the offending
+// container rather than failing when the task definition is registered. Since
+// secret ARNs are not known until the secrets are created, the size of the
+// task definition only includes secrets that already have an ID.
+func ValidatePodDefinitionSizes(opts cocoa.ECSPodDefinitionOptions) error {
+	catcher := grip.NewBasicCatcher()
+
+	for _, def := range opts.ContainerDefinitions {
+		name := utility.FromStringPtr(def.Name)
+
+		var cmdSize int
+		for _, arg := range def.Command {
+			cmdSize += len(arg)
+		}
+		catcher.ErrorfWhen(cmdSize > MaxContainerCommandSize, "container '%s' has a command of %d bytes, which exceeds the maximum of %d bytes", name, cmdSize, MaxContainerCommandSize)
+
+		catcher.ErrorfWhen(len(def.EnvVars) > MaxContainerEnvVars, "container '%s' has %d environment variables, which exceeds the maximum of %d", name, len(def.EnvVars), MaxContainerEnvVars)
+
+		var envVarsSize int
+		for _, envVar := range def.EnvVars {
+			envVarsSize += len(utility.FromStringPtr(envVar.Name)) + len(utility.FromStringPtr(envVar.Value))
+		}
+		catcher.ErrorfWhen(envVarsSize > MaxContainerEnvVarsSize, "container '%s' has environment variables totaling %d bytes, which exceeds the maximum of %d bytes", name, envVarsSize, MaxContainerEnvVarsSize)
+	}
+
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	in := exportPodDefinitionOptions(opts)
+	b, err := json.Marshal(in)
+	if err != nil {
+		return errors.Wrap(err, "marshalling task definition to check its size")
+	}
+	if len(b) <= MaxTaskDefinitionSize {
+		return nil
+	}
+
+	// Report the largest container since it's most likely the one that needs
+	// to shrink.
+	var largestName string
+	var largestSize int
+	for _, containerDef := range in.ContainerDefinitions {
+		cb, err := json.Marshal(containerDef)
+		if err != nil {
+			return errors.Wrapf(err, "marshalling container definition '%s' to check its size", utility.FromStringPtr(containerDef.Name))
+		}
+		if len(cb) > largestSize {
+			largestName = utility.FromStringPtr(containerDef.Name)
+			largestSize = len(cb)
+		}
+	}
+
+	return errors.Errorf("task definition is %d bytes, which exceeds the maximum of %d bytes; the largest container is '%s' at %d bytes", len(b), MaxTaskDefinitionSize, largestName, largestSize)
+}
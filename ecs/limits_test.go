@@ -0,0 +1,75 @@
+package ecs
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePodDefinitionSizes(t *testing.T) {
+	makeOpts := func() cocoa.ECSPodDefinitionOptions {
+		containerDef := cocoa.NewECSContainerDefinition().
+			SetName("container").
+			SetImage("image").
+			SetCommand([]string{"echo", "foo"}).
+			AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().SetName("name").SetValue("value"))
+		return *cocoa.NewECSPodDefinitionOptions().
+			SetName("name").
+			SetMemoryMB(128).
+			SetCPU(128).
+			AddContainerDefinitions(*containerDef)
+	}
+
+	t.Run("SucceedsWithinLimits", func(t *testing.T) {
+		assert.NoError(t, ValidatePodDefinitionSizes(makeOpts()))
+	})
+	t.Run("FailsWithOversizedCommand", func(t *testing.T) {
+		opts := makeOpts()
+		opts.ContainerDefinitions[0].SetCommand([]string{"echo", strings.Repeat("a", MaxContainerCommandSize)})
+		err := ValidatePodDefinitionSizes(opts)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "container 'container'")
+		assert.Contains(t, err.Error(), "command")
+	})
+	t.Run("FailsWithTooManyEnvironmentVariables", func(t *testing.T) {
+		opts := makeOpts()
+		var envVars []cocoa.EnvironmentVariable
+		for i := 0; i <= MaxContainerEnvVars; i++ {
+			envVars = append(envVars, *cocoa.NewEnvironmentVariable().SetName("name" + strconv.Itoa(i)).SetValue("value"))
+		}
+		opts.ContainerDefinitions[0].SetEnvironmentVariables(envVars)
+		err := ValidatePodDefinitionSizes(opts)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "container 'container'")
+		assert.Contains(t, err.Error(), "environment variables")
+	})
+	t.Run("FailsWithOversizedEnvironmentVariables", func(t *testing.T) {
+		opts := makeOpts()
+		opts.ContainerDefinitions[0].SetEnvironmentVariables([]cocoa.EnvironmentVariable{
+			*cocoa.NewEnvironmentVariable().SetName("name").SetValue(strings.Repeat("a", MaxContainerEnvVarsSize)),
+		})
+		err := ValidatePodDefinitionSizes(opts)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "container 'container'")
+		assert.Contains(t, err.Error(), "bytes")
+	})
+	t.Run("FailsWithOversizedTaskDefinitionAndNamesLargestContainer", func(t *testing.T) {
+		opts := makeOpts()
+		for i := 0; i < 3; i++ {
+			opts.AddContainerDefinitions(*cocoa.NewECSContainerDefinition().
+				SetName("large" + strconv.Itoa(i)).
+				SetImage("image").
+				SetCommand([]string{strings.Repeat("a", MaxContainerCommandSize-i)}).
+				SetEnvironmentVariables([]cocoa.EnvironmentVariable{
+					*cocoa.NewEnvironmentVariable().SetName("name").SetValue(strings.Repeat("a", MaxContainerEnvVarsSize-16)),
+				}))
+		}
+		err := ValidatePodDefinitionSizes(opts)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "task definition")
+		assert.Contains(t, err.Error(), "'large0'")
+	})
+}
@@ -0,0 +1,251 @@
+package ecs
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// ListPodsFilters are filters to select which pods to list in a cluster.
+type ListPodsFilters struct {
+	// Family is the pod definition family that the pods must be running. If
+	// this is not specified, pods running any pod definition are listed.
+	Family *string
+	// DesiredStatus is the status that the pods are intended to have. By
+	// default, this is types.DesiredStatusRunning.
+	DesiredStatus *types.DesiredStatus
+	// Group is the name of the group that the pods must belong to. If this is
+	// not specified, pods in any group are listed. Since ECS cannot filter
+	// tasks by group, the pods are filtered after they are described. To find
+	// the pods placed using a group name template, use
+	// cocoa.ResolveGroupNameTemplate to resolve the same group name.
+	Group *string
+	// Purpose is the purpose that the pods must be tagged with. If this is not
+	// specified, pods with any purpose are listed. Since ECS cannot filter
+	// tasks by tag, the pods are filtered after they are described.
+	Purpose *string
+}
+
+// NewListPodsFilters returns new uninitialized filters to list pods.
+func NewListPodsFilters() *ListPodsFilters {
+	return &ListPodsFilters{}
+}
+
+// SetFamily sets the pod definition family that the pods must be running.
+func (f *ListPodsFilters) SetFamily(family string) *ListPodsFilters {
+	f.Family = &family
+	return f
+}
+
+// SetDesiredStatus sets the status that the pods are intended to have.
+func (f *ListPodsFilters) SetDesiredStatus(status types.DesiredStatus) *ListPodsFilters {
+	f.DesiredStatus = &status
+	return f
+}
+
+// SetGroup sets the name of the group that the pods must belong to.
+func (f *ListPodsFilters) SetGroup(group string) *ListPodsFilters {
+	f.Group = &group
+	return f
+}
+
+// SetPurpose sets the purpose that the pods must be tagged with.
+func (f *ListPodsFilters) SetPurpose(purpose string) *ListPodsFilters {
+	f.Purpose = &purpose
+	return f
+}
+
+// Validate checks that the filters are valid. It sets defaults where possible.
+func (f *ListPodsFilters) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(f.Family != nil && *f.Family == "", "cannot specify an empty family")
+	catcher.NewWhen(f.Group != nil && *f.Group == "", "cannot specify an empty group")
+	catcher.NewWhen(f.Purpose != nil && *f.Purpose == "", "cannot specify an empty purpose")
+	if f.DesiredStatus != nil {
+		switch *f.DesiredStatus {
+		case types.DesiredStatusRunning, types.DesiredStatusPending, types.DesiredStatusStopped:
+		default:
+			catcher.Errorf("unrecognized desired status '%s'", *f.DesiredStatus)
+		}
+	}
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	if f.DesiredStatus == nil {
+		f.SetDesiredStatus(types.DesiredStatusRunning)
+	}
+
+	return nil
+}
+
+// ListPods lists all the pods in the cluster that match the filters. The
+// returned pods have their resources and status populated, so they can be
+// managed as if they had been created directly. Since the ownership of
+// resources cannot be determined for existing pods, none of the pods'
+// resources are owned by the pods.
+func ListPods(ctx context.Context, c cocoa.ECSClient, v cocoa.Vault, cluster string, filters ListPodsFilters) ([]cocoa.ECSPod, error) {
+	if c == nil {
+		return nil, errors.New("must specify a client")
+	}
+	if err := filters.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid filters")
+	}
+
+	taskARNs, err := listTaskARNs(ctx, c, cluster, filters)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing tasks")
+	}
+
+	secretsByTaskDef := map[string]map[string][]cocoa.ContainerSecret{}
+	var include []types.TaskField
+	if filters.Purpose != nil {
+		include = append(include, types.TaskFieldTags)
+	}
+	out, err := describeAllTasks(ctx, c, cluster, taskARNs, include)
+	if err != nil {
+		return nil, errors.Wrap(err, "describing tasks")
+	}
+
+	var pods []cocoa.ECSPod
+	for _, task := range out.Tasks {
+		if filters.Group != nil && utility.FromStringPtr(task.Group) != *filters.Group {
+			continue
+		}
+		if filters.Purpose != nil && !importTags(task.Tags).HasPurpose(*filters.Purpose) {
+			continue
+		}
+
+		taskDefARN := utility.FromStringPtr(task.TaskDefinitionArn)
+		secrets, ok := secretsByTaskDef[taskDefARN]
+		if !ok {
+			secrets, err = getContainerSecrets(ctx, c, taskDefARN)
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting secrets for task definition '%s'", taskDefARN)
+			}
+			secretsByTaskDef[taskDefARN] = secrets
+		}
+
+		p, err := adoptPod(c, v, cluster, task, secrets)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating pod for task '%s'", utility.FromStringPtr(task.TaskArn))
+		}
+		pods = append(pods, p)
+	}
+
+	return pods, nil
+}
+
+// FindPodsByPurpose lists all the pods in the cluster that are tagged with the
+// given purpose and match the other filters. Any purpose already set in the
+// filters is replaced.
+func FindPodsByPurpose(ctx context.Context, c cocoa.ECSClient, v cocoa.Vault, cluster, purpose string, filters ListPodsFilters) ([]cocoa.ECSPod, error) {
+	return ListPods(ctx, c, v, cluster, *filters.SetPurpose(purpose))
+}
+
+// listTaskARNs lists the ARNs of all the tasks in the cluster that match the
+// filters.
+func listTaskARNs(ctx context.Context, c cocoa.ECSClient, cluster string, filters ListPodsFilters) ([]string, error) {
+	in := &ecs.ListTasksInput{
+		Cluster:       aws.String(cluster),
+		Family:        filters.Family,
+		DesiredStatus: *filters.DesiredStatus,
+	}
+
+	var arns []string
+	for {
+		out, err := c.ListTasks(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		arns = append(arns, out.TaskArns...)
+
+		if out.NextToken == nil {
+			return arns, nil
+		}
+		in.NextToken = out.NextToken
+	}
+}
+
+// getContainerSecrets gets the secrets used by each container in the task
+// definition, indexed by the container name.
+func getContainerSecrets(ctx context.Context, c cocoa.ECSClient, taskDefARN string) (map[string][]cocoa.ContainerSecret, error) {
+	out, err := c.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String(taskDefARN),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describing task definition")
+	}
+	if out.TaskDefinition == nil {
+		return nil, errors.New("expected a task definition from ECS, but none was returned")
+	}
+
+	secrets := map[string][]cocoa.ContainerSecret{}
+	for _, def := range out.TaskDefinition.ContainerDefinitions {
+		name := utility.FromStringPtr(def.Name)
+		for _, s := range def.Secrets {
+			if utility.FromStringPtr(s.ValueFrom) == "" {
+				continue
+			}
+			secrets[name] = append(secrets[name], *cocoa.NewContainerSecret().
+				SetID(utility.FromStringPtr(s.ValueFrom)).
+				SetOwned(false))
+		}
+		if def.LogConfiguration != nil {
+			for _, s := range def.LogConfiguration.SecretOptions {
+				if utility.FromStringPtr(s.ValueFrom) == "" {
+					continue
+				}
+				secrets[name] = append(secrets[name], *cocoa.NewContainerSecret().
+					SetID(utility.FromStringPtr(s.ValueFrom)).
+					SetOwned(false))
+			}
+		}
+		if def.RepositoryCredentials != nil && utility.FromStringPtr(def.RepositoryCredentials.CredentialsParameter) != "" {
+			secrets[name] = append(secrets[name], *cocoa.NewContainerSecret().
+				SetID(utility.FromStringPtr(def.RepositoryCredentials.CredentialsParameter)).
+				SetOwned(false))
+		}
+	}
+
+	return secrets, nil
+}
+
+// adoptPod creates a pod to manage an existing ECS task.
+func adoptPod(c cocoa.ECSClient, v cocoa.Vault, cluster string, task types.Task, secrets map[string][]cocoa.ContainerSecret) (*BasicPod, error) {
+	var containers []cocoa.ECSContainerResources
+	for _, container := range task.Containers {
+		name := utility.FromStringPtr(container.Name)
+		res := cocoa.NewECSContainerResources().
+			SetContainerID(utility.FromStringPtr(container.ContainerArn)).
+			SetName(name).
+			SetSecrets(secrets[name])
+		containers = append(containers, *res)
+	}
+
+	taskDef := cocoa.NewECSTaskDefinition().
+		SetID(utility.FromStringPtr(task.TaskDefinitionArn)).
+		SetOwned(false)
+	resources := cocoa.NewECSPodResources().
+		SetCluster(cluster).
+		SetContainers(containers).
+		SetTaskDefinition(*taskDef).
+		SetTaskID(utility.FromStringPtr(task.TaskArn))
+	if task.Group != nil {
+		resources.SetGroup(*task.Group)
+	}
+
+	podOpts := NewBasicPodOptions().
+		SetClient(c).
+		SetVault(v).
+		SetStatusInfo(translatePodStatusInfo(task)).
+		SetResources(*resources)
+
+	return NewBasicPod(podOpts)
+}
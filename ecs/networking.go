@@ -0,0 +1,149 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// defaultMinAvailableIPAddresses is the default minimum number of IP addresses
+// that must be available in a subnet for a pod to run in it.
+const defaultMinAvailableIPAddresses = 1
+
+// NetworkValidationOptions are options to check that the networking resources
+// for a pod using AWSVPC networking are usable before running the pod. This
+// catches misconfigured networking before the pod is run rather than when ECS
+// fails to provision the pod's network interface.
+type NetworkValidationOptions struct {
+	// Client is the client used to look up the networking resources. This is
+	// required.
+	Client cocoa.EC2Client
+	// MinAvailableIPAddresses is the minimum number of IP addresses that must
+	// be available in at least one of the pod's subnets. By default, this is
+	// 1.
+	MinAvailableIPAddresses *int
+}
+
+// NewNetworkValidationOptions returns new uninitialized options to check
+// networking resources.
+func NewNetworkValidationOptions() *NetworkValidationOptions {
+	return &NetworkValidationOptions{}
+}
+
+// SetClient sets the client used to look up the networking resources.
+func (o *NetworkValidationOptions) SetClient(c cocoa.EC2Client) *NetworkValidationOptions {
+	o.Client = c
+	return o
+}
+
+// SetMinAvailableIPAddresses sets the minimum number of IP addresses that must
+// be available in at least one of the pod's subnets.
+func (o *NetworkValidationOptions) SetMinAvailableIPAddresses(n int) *NetworkValidationOptions {
+	o.MinAvailableIPAddresses = &n
+	return o
+}
+
+// Validate checks that the client is given and that the minimum number of
+// available IP addresses, if given, is positive. It sets defaults where
+// possible.
+func (o *NetworkValidationOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.Client == nil, "must specify an EC2 client")
+	catcher.NewWhen(o.MinAvailableIPAddresses != nil && *o.MinAvailableIPAddresses <= 0, "must specify a positive minimum number of available IP addresses")
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	if o.MinAvailableIPAddresses == nil {
+		o.SetMinAvailableIPAddresses(defaultMinAvailableIPAddresses)
+	}
+
+	return nil
+}
+
+// ValidateAWSVPCNetworking checks that the pod's subnets and security groups
+// exist, that they all belong to the same VPC, and that at least one of the
+// subnets has enough available IP addresses for the pod. If the networking
+// resources are missing or misconfigured, it returns a
+// *cocoa.NetworkMisconfigurationError.
+func ValidateAWSVPCNetworking(ctx context.Context, awsvpcOpts cocoa.AWSVPCOptions, opts NetworkValidationOptions) error {
+	if err := opts.Validate(); err != nil {
+		return errors.Wrap(err, "invalid network validation options")
+	}
+	if err := awsvpcOpts.Validate(); err != nil {
+		return errors.Wrap(err, "invalid AWSVPC options")
+	}
+
+	subnets, err := opts.Client.DescribeSubnets(ctx, awsvpcOpts.Subnets)
+	if err != nil {
+		return errors.Wrap(err, "describing subnets")
+	}
+	vpcsByResource := map[string]string{}
+	for _, s := range subnets {
+		vpcsByResource[s.ID] = s.VPCID
+	}
+	if missing := missingNetworkResources(awsvpcOpts.Subnets, vpcsByResource); len(missing) != 0 {
+		return cocoa.NewNetworkMisconfigurationError(missing, "subnets do not exist")
+	}
+
+	if len(awsvpcOpts.SecurityGroups) != 0 {
+		securityGroups, err := opts.Client.DescribeSecurityGroups(ctx, awsvpcOpts.SecurityGroups)
+		if err != nil {
+			return errors.Wrap(err, "describing security groups")
+		}
+		found := map[string]string{}
+		for _, sg := range securityGroups {
+			found[sg.ID] = sg.VPCID
+			vpcsByResource[sg.ID] = sg.VPCID
+		}
+		if missing := missingNetworkResources(awsvpcOpts.SecurityGroups, found); len(missing) != 0 {
+			return cocoa.NewNetworkMisconfigurationError(missing, "security groups do not exist")
+		}
+	}
+
+	if vpcs := networkResourceVPCs(vpcsByResource); len(vpcs) > 1 {
+		resources := append(append([]string{}, awsvpcOpts.Subnets...), awsvpcOpts.SecurityGroups...)
+		return cocoa.NewNetworkMisconfigurationError(resources, fmt.Sprintf("subnets and security groups must all belong to the same VPC, but they belong to VPCs: %s", strings.Join(vpcs, ", ")))
+	}
+
+	minAvailable := utility.FromIntPtr(opts.MinAvailableIPAddresses)
+	for _, s := range subnets {
+		if s.AvailableIPAddresses >= minAvailable {
+			return nil
+		}
+	}
+
+	return cocoa.NewNetworkMisconfigurationError(awsvpcOpts.Subnets, fmt.Sprintf("no subnet has at least %d available IP address(es)", minAvailable))
+}
+
+// missingNetworkResources returns the IDs that were not found.
+func missingNetworkResources(ids []string, found map[string]string) []string {
+	var missing []string
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// networkResourceVPCs returns the sorted unique VPCs that the networking
+// resources belong to.
+func networkResourceVPCs(vpcsByResource map[string]string) []string {
+	unique := map[string]bool{}
+	for _, vpc := range vpcsByResource {
+		unique[vpc] = true
+	}
+	vpcs := make([]string, 0, len(unique))
+	for vpc := range unique {
+		vpcs = append(vpcs, vpc)
+	}
+	sort.Strings(vpcs)
+	return vpcs
+}
@@ -0,0 +1,168 @@
+package ecs
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// oneOffPodPollInterval is how often to check whether a one-off pod has
+// stopped.
+const oneOffPodPollInterval = 5 * time.Second
+
+// OneOffPodResult is the result of running a one-off pod to completion.
+type OneOffPodResult struct {
+	// TaskID is the resource identifier for the pod.
+	TaskID string
+	// ExitCodes are the exit codes of the pod's containers by container name.
+	// Containers that did not report an exit code are not included.
+	ExitCodes map[string]int
+	// TimedOut indicates that the pod did not stop on its own before the
+	// timeout, so it was stopped early.
+	TimedOut bool
+}
+
+// RunOneOffPod runs a pod from an existing definition to completion with the
+// given command, which is useful for tasks such as migrations or batch jobs
+// that run a single container until it exits. The command overrides the
+// command of the definition's container, so the definition must have exactly
+// one container. The pod is given up to the timeout to stop on its own before
+// it is stopped early. Once the pod has stopped, the exit codes of its
+// containers are collected and the pod is deleted.
+func (pc *BasicPodCreator) RunOneOffPod(ctx context.Context, def cocoa.ECSTaskDefinition, command []string, timeout time.Duration, opts ...cocoa.ECSPodExecutionOptions) (*OneOffPodResult, error) {
+	catcher := grip.NewBasicCatcher()
+	catcher.Wrap(def.Validate(), "invalid task definition")
+	catcher.NewWhen(len(command) == 0, "must specify a command")
+	catcher.NewWhen(timeout <= 0, "must specify a positive timeout")
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	containerName, err := pc.getOneOffContainerName(ctx, utility.FromStringPtr(def.ID))
+	if err != nil {
+		return nil, errors.Wrap(err, "getting container to run command")
+	}
+
+	execOpts := withCommandOverride(cocoa.MergeECSPodExecutionOptions(opts...), containerName, command)
+	p, err := pc.CreatePodFromExistingDefinition(ctx, def, execOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating pod")
+	}
+
+	res := &OneOffPodResult{TaskID: utility.FromStringPtr(p.Resources().TaskID)}
+
+	catcher.Wrap(pc.runOneOffPodToCompletion(ctx, p, timeout, res), "running pod to completion")
+	catcher.Wrap(p.Delete(ctx), "deleting pod")
+	if catcher.HasErrors() {
+		return res, catcher.Resolve()
+	}
+
+	return res, nil
+}
+
+// getOneOffContainerName returns the name of the only container in the pod
+// definition.
+func (pc *BasicPodCreator) getOneOffContainerName(ctx context.Context, id string) (string, error) {
+	out, err := pc.client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: utility.ToStringPtr(id),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "describing pod definition '%s'", id)
+	}
+	if out.TaskDefinition == nil {
+		return "", errors.Errorf("expected pod definition '%s' from ECS, but none was returned", id)
+	}
+	if n := len(out.TaskDefinition.ContainerDefinitions); n != 1 {
+		return "", errors.Errorf("pod definition '%s' must have exactly one container to override its command, but it has %d", id, n)
+	}
+
+	return utility.FromStringPtr(out.TaskDefinition.ContainerDefinitions[0].Name), nil
+}
+
+// withCommandOverride returns a copy of the execution options that overrides
+// the named container's command. Any existing overrides for the container are
+// preserved.
+func withCommandOverride(opts cocoa.ECSPodExecutionOptions, containerName string, command []string) cocoa.ECSPodExecutionOptions {
+	var overrideOpts cocoa.ECSOverridePodDefinitionOptions
+	if opts.OverrideOpts != nil {
+		overrideOpts = *opts.OverrideOpts
+	}
+
+	containerDefs := make([]cocoa.ECSOverrideContainerDefinition, 0, len(overrideOpts.ContainerDefinitions)+1)
+	var overridden bool
+	for _, containerDef := range overrideOpts.ContainerDefinitions {
+		if utility.FromStringPtr(containerDef.Name) == containerName {
+			containerDef.SetCommand(command)
+			overridden = true
+		}
+		containerDefs = append(containerDefs, containerDef)
+	}
+	if !overridden {
+		containerDefs = append(containerDefs, *cocoa.NewECSOverrideContainerDefinition().
+			SetName(containerName).
+			SetCommand(command))
+	}
+	overrideOpts.SetContainerDefinitions(containerDefs)
+
+	return *opts.SetOverrideOptions(overrideOpts)
+}
+
+// runOneOffPodToCompletion waits up to the timeout for the pod to stop. If it
+// does not stop in time, it stops the pod. Once the pod is stopped, it records
+// the exit codes of its containers in the result.
+func (pc *BasicPodCreator) runOneOffPodToCompletion(ctx context.Context, p cocoa.ECSPod, timeout time.Duration, res *OneOffPodResult) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	statusInfo, err := waitForPodStopped(waitCtx, p)
+	if err != nil {
+		if ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+			return errors.Wrap(err, "waiting for pod to stop")
+		}
+
+		res.TimedOut = true
+		if err := p.Stop(ctx); err != nil {
+			return errors.Wrap(err, "stopping pod after timeout")
+		}
+		if statusInfo, err = p.LatestStatusInfo(ctx); err != nil {
+			return errors.Wrap(err, "getting status of stopped pod")
+		}
+	}
+
+	res.ExitCodes = map[string]int{}
+	for _, container := range statusInfo.Containers {
+		if container.ExitCode != nil {
+			res.ExitCodes[utility.FromStringPtr(container.Name)] = *container.ExitCode
+		}
+	}
+
+	return nil
+}
+
+// waitForPodStopped polls the pod's status until it has stopped or the context
+// is done.
+func waitForPodStopped(ctx context.Context, p cocoa.ECSPod) (*cocoa.ECSPodStatusInfo, error) {
+	ticker := time.NewTicker(oneOffPodPollInterval)
+	defer ticker.Stop()
+
+	for {
+		statusInfo, err := p.LatestStatusInfo(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting pod status")
+		}
+		if statusInfo.Status == cocoa.StatusStopped {
+			return statusInfo, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// defaultRunTaskFailureHistorySize is the default maximum number of failures
+// that are kept for each cluster and group.
+const defaultRunTaskFailureHistorySize = 10
+
+// RunTaskFailureHistory records the most recent failures to run pods in each
+// cluster and group so that they can be used to diagnose why pods are stuck
+// pending. It is safe for concurrent use.
+type RunTaskFailureHistory struct {
+	mu   sync.Mutex
+	size int
+	// failures maps each cluster and group to its most recent failures in
+	// the order that they occurred.
+	failures map[runTaskFailureKey][]cocoa.RunTaskFailure
+}
+
+// runTaskFailureKey identifies the cluster and group that a failure occurred
+// in.
+type runTaskFailureKey struct {
+	cluster string
+	group   string
+}
+
+// NewRunTaskFailureHistory returns a new history that keeps at most the given
+// number of the most recent failures for each cluster and group. If the size is
+// not positive, it uses a default size.
+func NewRunTaskFailureHistory(size int) *RunTaskFailureHistory {
+	if size <= 0 {
+		size = defaultRunTaskFailureHistorySize
+	}
+	return &RunTaskFailureHistory{
+		size:     size,
+		failures: map[runTaskFailureKey][]cocoa.RunTaskFailure{},
+	}
+}
+
+// Record records a failure to run a pod in the given cluster and group. If
+// the history already has the maximum number of failures for the cluster and
+// group, the oldest one is discarded.
+func (h *RunTaskFailureHistory) Record(cluster, group string, f cocoa.RunTaskFailure) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := runTaskFailureKey{cluster: cluster, group: group}
+	failures := append(h.failures[key], f)
+	if len(failures) > h.size {
+		failures = failures[len(failures)-h.size:]
+	}
+	h.failures[key] = failures
+}
+
+// Recent returns the most recent failures to run a pod in the given cluster and
+// group that occurred no earlier than the given time, from oldest to newest.
+func (h *RunTaskFailureHistory) Recent(cluster, group string, since time.Time) []cocoa.RunTaskFailure {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var recent []cocoa.RunTaskFailure
+	for _, f := range h.failures[runTaskFailureKey{cluster: cluster, group: group}] {
+		if f.Time.Before(since) {
+			continue
+		}
+		recent = append(recent, f)
+	}
+	return recent
+}
+
+// recordRunTaskFailures records the failures from running a task, if there is a
+// history to record them in.
+func recordRunTaskFailures(h *RunTaskFailureHistory, in *ecs.RunTaskInput, out *ecs.RunTaskOutput) {
+	if h == nil || out == nil {
+		return
+	}
+	now := time.Now()
+	for _, f := range out.Failures {
+		h.Record(utility.FromStringPtr(in.Cluster), utility.FromStringPtr(in.Group), cocoa.RunTaskFailure{
+			Reason: utility.FromStringPtr(f.Reason),
+			Detail: utility.FromStringPtr(f.Detail),
+			Time:   now,
+		})
+	}
+}
+
+// pendingDiagnosisFailureWindow is how far back to look for failures to run
+// pods in the same cluster and group when diagnosing a pending pod.
+const pendingDiagnosisFailureWindow = 15 * time.Minute
+
+// PendingDiagnosis returns the most likely reason that the pod is stuck before
+// it starts running. It checks the reason that ECS gave for stopping the pod,
+// recent failures to run pods in the same cluster and group, and whether the
+// cluster has enough capacity to place the pod. Recent failures are only
+// checked if the pod has a failure history.
+func (p *BasicPod) PendingDiagnosis(ctx context.Context) (*cocoa.ECSPodPendingDiagnosis, error) {
+	out, err := p.client.DescribeTasks(ctx, &ecs.DescribeTasksInput{
+		Cluster: p.resources.Cluster,
+		Tasks:   []string{utility.FromStringPtr(p.resources.TaskID)},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describing task")
+	}
+	if len(out.Failures) != 0 {
+		catcher := grip.NewBasicCatcher()
+		for _, f := range out.Failures {
+			catcher.Add(ConvertFailureToError(f))
+		}
+		return nil, errors.Wrap(catcher.Resolve(), "describing task")
+	}
+	if len(out.Tasks) == 0 {
+		return nil, errors.New("expected a task to exist in ECS, but none was returned")
+	}
+
+	task := out.Tasks[0]
+	p.statusInfo = translatePodStatusInfo(task)
+
+	diagnosis := &cocoa.ECSPodPendingDiagnosis{
+		Status:        p.statusInfo.Status,
+		StopCode:      string(task.StopCode),
+		StoppedReason: utility.FromStringPtr(task.StoppedReason),
+	}
+	if p.failureHistory != nil {
+		diagnosis.RecentFailures = p.failureHistory.Recent(utility.FromStringPtr(p.resources.Cluster), utility.FromStringPtr(p.resources.Group), time.Now().Add(-pendingDiagnosisFailureWindow))
+	}
+
+	if cause, msg, ok := diagnoseStoppedTask(task); ok {
+		diagnosis.Cause = cause
+		diagnosis.Message = msg
+		return diagnosis, nil
+	}
+	if diagnosis.Status != cocoa.StatusStarting {
+		diagnosis.Cause = cocoa.PendingCauseNone
+		diagnosis.Message = fmt.Sprintf("pod is not pending because its status is '%s'", diagnosis.Status)
+		return diagnosis, nil
+	}
+	if cause, msg, ok := diagnoseRunTaskFailures(diagnosis.RecentFailures); ok {
+		diagnosis.Cause = cause
+		diagnosis.Message = msg
+		return diagnosis, nil
+	}
+
+	fits, err := p.fitsClusterCapacity(ctx, task)
+	if err != nil {
+		return nil, errors.Wrap(err, "checking cluster capacity")
+	}
+	if !fits {
+		diagnosis.Cause = cocoa.PendingCauseInsufficientCapacity
+		diagnosis.Message = "no container instance in the cluster has enough remaining CPU and memory to place the pod"
+		return diagnosis, nil
+	}
+
+	diagnosis.Cause = cocoa.PendingCauseUnknown
+	diagnosis.Message = "pod is pending, but the cause could not be determined"
+	return diagnosis, nil
+}
+
+// diagnoseStoppedTask returns the cause of the task failing to start based on
+// the reasons that ECS gave for stopping the task or its containers, if any.
+func diagnoseStoppedTask(task types.Task) (cause cocoa.PendingCause, msg string, ok bool) {
+	reasons := []string{utility.FromStringPtr(task.StoppedReason)}
+	for _, c := range task.Containers {
+		reasons = append(reasons, utility.FromStringPtr(c.Reason))
+	}
+
+	for _, reason := range reasons {
+		if reason == "" {
+			continue
+		}
+		if isImagePullReason(reason) {
+			return cocoa.PendingCauseImagePull, fmt.Sprintf("a container image could not be pulled: %s", reason), true
+		}
+		if isENILimitReason(reason) {
+			return cocoa.PendingCauseENILimit, fmt.Sprintf("a network interface could not be attached: %s", reason), true
+		}
+	}
+
+	return "", "", false
+}
+
+// diagnoseRunTaskFailures returns the cause of pods failing to start based on
+// the most recent failure to run a pod that has a recognized cause, if any.
+func diagnoseRunTaskFailures(failures []cocoa.RunTaskFailure) (cause cocoa.PendingCause, msg string, ok bool) {
+	for i := len(failures) - 1; i >= 0; i-- {
+		f := failures[i]
+		switch {
+		case f.Reason == ReasonResourceCPU || f.Reason == ReasonResourceMemory:
+			return cocoa.PendingCauseInsufficientCapacity, fmt.Sprintf("pods recently failed to run in the same cluster and group due to insufficient capacity (%s)", f.Reason), true
+		case isENILimitReason(f.Reason) || isENILimitReason(f.Detail):
+			return cocoa.PendingCauseENILimit, fmt.Sprintf("pods recently failed to run in the same cluster and group due to network interface limits (%s)", f.Reason), true
+		}
+	}
+	return "", "", false
+}
+
+// isImagePullReason returns whether or not the reason that ECS gave indicates
+// that a container image could not be pulled.
+func isImagePullReason(reason string) bool {
+	lower := strings.ToLower(reason)
+	return strings.Contains(lower, "cannotpullcontainer") || strings.Contains(lower, "pull image") || strings.Contains(lower, "imagepull")
+}
+
+// isENILimitReason returns whether or not the reason that ECS gave indicates
+// that an elastic network interface could not be attached.
+func isENILimitReason(reason string) bool {
+	return eniLimitReasonPattern.MatchString(reason)
+}
+
+// eniLimitReasonPattern matches reasons that ECS gives when an elastic network
+// interface cannot be attached.
+var eniLimitReasonPattern = regexp.MustCompile(`(?i)\beni\b|elastic network interface|networkinterface`)
+
+// fitsClusterCapacity returns whether or not the task can fit on at least one
+// container instance in the pod's cluster. If the cluster has no container
+// instances (e.g. it only uses Fargate) or the task's resource requirements are
+// unknown, it is assumed to fit.
+func (p *BasicPod) fitsClusterCapacity(ctx context.Context, task types.Task) (bool, error) {
+	cpu, cpuErr := strconv.Atoi(utility.FromStringPtr(task.Cpu))
+	memMB, memErr := strconv.Atoi(utility.FromStringPtr(task.Memory))
+	if cpuErr != nil && memErr != nil {
+		return true, nil
+	}
+	if p.resources.Cluster == nil {
+		return true, nil
+	}
+
+	capacity, err := GetClusterCapacity(ctx, p.client, utility.FromStringPtr(p.resources.Cluster))
+	if err != nil {
+		return false, err
+	}
+	if len(capacity.Instances) == 0 {
+		return true, nil
+	}
+
+	for _, instance := range capacity.Instances {
+		if instance.RemainingCPU >= cpu && instance.RemainingMemoryMB >= memMB {
+			return true, nil
+		}
+	}
+	return false, nil
+}
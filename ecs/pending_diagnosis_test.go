@@ -0,0 +1,96 @@
+package ecs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTaskFailureHistory(t *testing.T) {
+	t.Run("RecordsFailuresPerClusterAndGroup", func(t *testing.T) {
+		h := NewRunTaskFailureHistory(0)
+		now := time.Now()
+		h.Record("cluster", "group", cocoa.RunTaskFailure{Reason: ReasonResourceCPU, Time: now})
+		h.Record("cluster", "other_group", cocoa.RunTaskFailure{Reason: ReasonResourceMemory, Time: now})
+
+		recent := h.Recent("cluster", "group", now.Add(-time.Minute))
+		require.Len(t, recent, 1)
+		assert.Equal(t, ReasonResourceCPU, recent[0].Reason)
+		assert.Empty(t, h.Recent("other_cluster", "group", now.Add(-time.Minute)))
+	})
+	t.Run("DiscardsOldestFailuresBeyondSize", func(t *testing.T) {
+		h := NewRunTaskFailureHistory(2)
+		now := time.Now()
+		for _, reason := range []string{"reason0", "reason1", "reason2"} {
+			h.Record("cluster", "", cocoa.RunTaskFailure{Reason: reason, Time: now})
+		}
+
+		recent := h.Recent("cluster", "", now.Add(-time.Minute))
+		require.Len(t, recent, 2)
+		assert.Equal(t, "reason1", recent[0].Reason)
+		assert.Equal(t, "reason2", recent[1].Reason)
+	})
+	t.Run("OmitsFailuresBeforeTime", func(t *testing.T) {
+		h := NewRunTaskFailureHistory(0)
+		now := time.Now()
+		h.Record("cluster", "", cocoa.RunTaskFailure{Reason: "old", Time: now.Add(-time.Hour)})
+		h.Record("cluster", "", cocoa.RunTaskFailure{Reason: "new", Time: now})
+
+		recent := h.Recent("cluster", "", now.Add(-time.Minute))
+		require.Len(t, recent, 1)
+		assert.Equal(t, "new", recent[0].Reason)
+	})
+}
+
+func TestDiagnoseStoppedTask(t *testing.T) {
+	t.Run("DetectsImagePullFromStoppedReason", func(t *testing.T) {
+		cause, _, ok := diagnoseStoppedTask(types.Task{
+			StoppedReason: utility.ToStringPtr("CannotPullContainerError: pull access denied"),
+		})
+		assert.True(t, ok)
+		assert.Equal(t, cocoa.PendingCauseImagePull, cause)
+	})
+	t.Run("DetectsENILimitFromContainerReason", func(t *testing.T) {
+		cause, _, ok := diagnoseStoppedTask(types.Task{
+			Containers: []types.Container{{Reason: utility.ToStringPtr("ResourceInitializationError: unable to attach ENI")}},
+		})
+		assert.True(t, ok)
+		assert.Equal(t, cocoa.PendingCauseENILimit, cause)
+	})
+	t.Run("DoesNotMistakeOtherReasonsForENILimit", func(t *testing.T) {
+		_, _, ok := diagnoseStoppedTask(types.Task{
+			StoppedReason: utility.ToStringPtr("AccessDeniedException: not authorized"),
+		})
+		assert.False(t, ok)
+	})
+	t.Run("ReturnsNoCauseWithoutReasons", func(t *testing.T) {
+		_, _, ok := diagnoseStoppedTask(types.Task{})
+		assert.False(t, ok)
+	})
+}
+
+func TestDiagnoseRunTaskFailures(t *testing.T) {
+	t.Run("DetectsInsufficientCapacity", func(t *testing.T) {
+		cause, _, ok := diagnoseRunTaskFailures([]cocoa.RunTaskFailure{{Reason: ReasonResourceMemory}})
+		assert.True(t, ok)
+		assert.Equal(t, cocoa.PendingCauseInsufficientCapacity, cause)
+	})
+	t.Run("UsesMostRecentRecognizedFailure", func(t *testing.T) {
+		cause, _, ok := diagnoseRunTaskFailures([]cocoa.RunTaskFailure{
+			{Reason: ReasonResourceCPU},
+			{Reason: "ERROR", Detail: "elastic network interface limit reached"},
+			{Reason: ReasonAgent},
+		})
+		assert.True(t, ok)
+		assert.Equal(t, cocoa.PendingCauseENILimit, cause)
+	})
+	t.Run("ReturnsNoCauseForUnrecognizedFailures", func(t *testing.T) {
+		_, _, ok := diagnoseRunTaskFailures([]cocoa.RunTaskFailure{{Reason: ReasonAgent}})
+		assert.False(t, ok)
+	})
+}
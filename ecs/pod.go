@@ -16,6 +16,44 @@ type BasicPod struct {
 	vault      cocoa.Vault
 	resources  cocoa.ECSPodResources
 	statusInfo cocoa.ECSPodStatusInfo
+	protection cocoa.ECSPodProtectionPolicy
+	eventSink  cocoa.EventSink
+	// secretUsageTracker records the secrets that the pod references, if any.
+	secretUsageTracker cocoa.SecretUsageTracker
+	// deregistrationPolicy determines whether the pod deregisters its task
+	// definition when it's deleted.
+	deregistrationPolicy DeregistrationPolicy
+	// failureHistory records recent failures to run pods, which is used to
+	// diagnose why the pod is pending, if any.
+	failureHistory *RunTaskFailureHistory
+}
+
+// DeregistrationPolicy determines whether a pod deregisters its task
+// definition when it's deleted.
+type DeregistrationPolicy string
+
+const (
+	// DeregistrationPolicyRespectOwned indicates that the pod only
+	// deregisters its task definition if the pod owns it.
+	DeregistrationPolicyRespectOwned DeregistrationPolicy = "respect-owned"
+	// DeregistrationPolicyNever indicates that the pod never deregisters its
+	// task definition, even if the pod owns it. This prevents pods from
+	// deregistering task definitions that are shared with other pods.
+	DeregistrationPolicyNever DeregistrationPolicy = "never"
+	// DeregistrationPolicyAlways indicates that the pod always deregisters its
+	// task definition, even if the pod does not own it.
+	DeregistrationPolicyAlways DeregistrationPolicy = "always"
+)
+
+// Validate checks that the deregistration policy is one of the recognized
+// policies.
+func (p DeregistrationPolicy) Validate() error {
+	switch p {
+	case DeregistrationPolicyRespectOwned, DeregistrationPolicyNever, DeregistrationPolicyAlways:
+		return nil
+	default:
+		return errors.Errorf("unrecognized deregistration policy '%s'", p)
+	}
 }
 
 // BasicPodOptions are options to create a basic ECS pod.
@@ -24,6 +62,25 @@ type BasicPodOptions struct {
 	Vault      cocoa.Vault
 	Resources  *cocoa.ECSPodResources
 	StatusInfo *cocoa.ECSPodStatusInfo
+	// ProtectionPolicy specifies the pod's owned resources that should not be
+	// deleted when the pod is deleted. By default, all owned resources are
+	// deleted.
+	ProtectionPolicy *cocoa.ECSPodProtectionPolicy
+	// EventSink, if specified, receives events when the pod is stopped or
+	// deleted.
+	EventSink cocoa.EventSink
+	// SecretUsageTracker, if specified, is notified when the pod is deleted so
+	// that it no longer counts as referencing its secrets.
+	SecretUsageTracker cocoa.SecretUsageTracker
+	// DeregistrationPolicy determines whether the pod deregisters its task
+	// definition when it's deleted. This takes precedence over whether the
+	// task definition is owned by the pod, but the protection policy still
+	// applies. By default, this is DeregistrationPolicyRespectOwned.
+	DeregistrationPolicy *DeregistrationPolicy
+	// RunTaskFailureHistory, if specified, is used to check for recent
+	// failures to run pods in the same cluster and group when diagnosing why
+	// the pod is pending.
+	RunTaskFailureHistory *RunTaskFailureHistory
 }
 
 // NewBasicPodOptions returns new uninitialized options to create a basic ECS
@@ -56,6 +113,40 @@ func (o *BasicPodOptions) SetStatusInfo(s cocoa.ECSPodStatusInfo) *BasicPodOptio
 	return o
 }
 
+// SetProtectionPolicy sets the policy that protects the pod's owned resources
+// from deletion.
+func (o *BasicPodOptions) SetProtectionPolicy(p cocoa.ECSPodProtectionPolicy) *BasicPodOptions {
+	o.ProtectionPolicy = &p
+	return o
+}
+
+// SetEventSink sets the sink that receives the pod's lifecycle events.
+func (o *BasicPodOptions) SetEventSink(sink cocoa.EventSink) *BasicPodOptions {
+	o.EventSink = sink
+	return o
+}
+
+// SetSecretUsageTracker sets the tracker that records the secrets that the pod
+// references.
+func (o *BasicPodOptions) SetSecretUsageTracker(t cocoa.SecretUsageTracker) *BasicPodOptions {
+	o.SecretUsageTracker = t
+	return o
+}
+
+// SetDeregistrationPolicy sets the policy that determines whether the pod
+// deregisters its task definition when it's deleted.
+func (o *BasicPodOptions) SetDeregistrationPolicy(p DeregistrationPolicy) *BasicPodOptions {
+	o.DeregistrationPolicy = &p
+	return o
+}
+
+// SetRunTaskFailureHistory sets the history of recent failures to run pods,
+// which is used to diagnose why the pod is pending.
+func (o *BasicPodOptions) SetRunTaskFailureHistory(h *RunTaskFailureHistory) *BasicPodOptions {
+	o.RunTaskFailureHistory = h
+	return o
+}
+
 // Validate checks that the required parameters to initialize a pod are given.
 func (o *BasicPodOptions) Validate() error {
 	catcher := grip.NewBasicCatcher()
@@ -70,7 +161,21 @@ func (o *BasicPodOptions) Validate() error {
 	} else {
 		catcher.New("must specify status information")
 	}
-	return catcher.Resolve()
+	if o.ProtectionPolicy != nil {
+		catcher.Wrap(o.ProtectionPolicy.Validate(), "invalid protection policy")
+	}
+	if o.DeregistrationPolicy != nil {
+		catcher.Wrap(o.DeregistrationPolicy.Validate(), "invalid deregistration policy")
+	}
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	if o.DeregistrationPolicy == nil {
+		o.SetDeregistrationPolicy(DeregistrationPolicyRespectOwned)
+	}
+
+	return nil
 }
 
 // MergePodOptions merges all the given options describing an ECS pod.
@@ -99,6 +204,26 @@ func MergePodOptions(opts ...*BasicPodOptions) BasicPodOptions {
 		if opt.StatusInfo != nil {
 			merged.StatusInfo = opt.StatusInfo
 		}
+
+		if opt.ProtectionPolicy != nil {
+			merged.ProtectionPolicy = opt.ProtectionPolicy
+		}
+
+		if opt.EventSink != nil {
+			merged.EventSink = opt.EventSink
+		}
+
+		if opt.SecretUsageTracker != nil {
+			merged.SecretUsageTracker = opt.SecretUsageTracker
+		}
+
+		if opt.DeregistrationPolicy != nil {
+			merged.DeregistrationPolicy = opt.DeregistrationPolicy
+		}
+
+		if opt.RunTaskFailureHistory != nil {
+			merged.RunTaskFailureHistory = opt.RunTaskFailureHistory
+		}
 	}
 
 	return merged
@@ -110,12 +235,34 @@ func NewBasicPod(opts ...*BasicPodOptions) (*BasicPod, error) {
 	if err := merged.Validate(); err != nil {
 		return nil, errors.Wrap(err, "invalid options")
 	}
-	return &BasicPod{
-		client:     merged.Client,
-		vault:      merged.Vault,
-		resources:  *merged.Resources,
-		statusInfo: *merged.StatusInfo,
-	}, nil
+	p := &BasicPod{
+		client:               merged.Client,
+		vault:                merged.Vault,
+		resources:            *merged.Resources,
+		statusInfo:           *merged.StatusInfo,
+		eventSink:            merged.EventSink,
+		secretUsageTracker:   merged.SecretUsageTracker,
+		deregistrationPolicy: *merged.DeregistrationPolicy,
+		failureHistory:       merged.RunTaskFailureHistory,
+	}
+	if merged.ProtectionPolicy != nil {
+		p.protection = *merged.ProtectionPolicy
+	}
+	return p, nil
+}
+
+// NewBasicPodFromState reconstructs a pod that is backed by ECS from a
+// snapshot of its state previously returned by Export.
+func NewBasicPodFromState(c cocoa.ECSClient, v cocoa.Vault, state cocoa.ECSPodState) (*BasicPod, error) {
+	if err := state.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid pod state")
+	}
+	return NewBasicPod(NewBasicPodOptions().
+		SetClient(c).
+		SetVault(v).
+		SetResources(state.Resources()).
+		SetStatusInfo(state.StatusInfo()).
+		SetProtectionPolicy(state.ProtectionPolicy()))
 }
 
 // Resources returns information about the resources used by the pod.
@@ -128,6 +275,11 @@ func (p *BasicPod) StatusInfo() cocoa.ECSPodStatusInfo {
 	return p.statusInfo
 }
 
+// Export returns a serializable snapshot of the pod's state.
+func (p *BasicPod) Export() cocoa.ECSPodState {
+	return cocoa.NewECSPodState(p.resources, p.statusInfo, p.protection)
+}
+
 // LatestStatusInfo returns the most up-to-date status information for the pod.
 func (p *BasicPod) LatestStatusInfo(ctx context.Context) (*cocoa.ECSPodStatusInfo, error) {
 	out, err := p.client.DescribeTasks(ctx, &ecs.DescribeTasksInput{
@@ -180,16 +332,46 @@ func (p *BasicPod) Stop(ctx context.Context) error {
 		p.statusInfo.Containers[i].Status = cocoa.StatusStopped
 	}
 
+	sendEvent(ctx, p.eventSink, p.newEvent(cocoa.EventTypePodStopped))
+
 	return nil
 }
 
-// Delete deletes the pod and its owned resources.
+// shouldDeregisterTaskDefinition returns whether the pod's deregistration
+// policy allows it to deregister its task definition.
+func (p *BasicPod) shouldDeregisterTaskDefinition() bool {
+	if p.resources.TaskDefinition == nil {
+		return false
+	}
+	switch p.deregistrationPolicy {
+	case DeregistrationPolicyNever:
+		return false
+	case DeregistrationPolicyAlways:
+		return true
+	default:
+		return utility.FromBoolPtr(p.resources.TaskDefinition.Owned)
+	}
+}
+
+// Delete deletes the pod and its owned resources. Owned resources that are
+// protected by the pod's protection policy are skipped. Whether the task
+// definition is deregistered is determined by the pod's deregistration policy.
 func (p *BasicPod) Delete(ctx context.Context) error {
+	return p.DeleteWithOptions(ctx)
+}
+
+// DeleteWithOptions is the same as Delete, but owned resources that are
+// protected by the pod's protection policy are also deleted if the deletion
+// options explicitly override the protection.
+func (p *BasicPod) DeleteWithOptions(ctx context.Context, opts ...cocoa.ECSPodDeletionOptions) error {
+	mergedOpts := cocoa.MergeECSPodDeletionOptions(opts...)
+	overrideProtection := utility.FromBoolPtr(mergedOpts.OverrideProtection)
+
 	catcher := grip.NewBasicCatcher()
 
 	catcher.Wrap(p.Stop(ctx), "stopping pod")
 
-	if p.resources.TaskDefinition != nil && utility.FromBoolPtr(p.resources.TaskDefinition.Owned) {
+	if p.shouldDeregisterTaskDefinition() && (overrideProtection || !p.protection.ProtectsTaskDefinition()) {
 		var deregisterDef ecs.DeregisterTaskDefinitionInput
 		deregisterDef.TaskDefinition = p.resources.TaskDefinition.ID
 
@@ -203,6 +385,9 @@ func (p *BasicPod) Delete(ctx context.Context) error {
 			if !utility.FromBoolPtr(s.Owned) {
 				continue
 			}
+			if !overrideProtection && p.protection.ProtectsSecret(s) {
+				continue
+			}
 
 			id := utility.FromStringPtr(s.ID)
 
@@ -215,6 +400,10 @@ func (p *BasicPod) Delete(ctx context.Context) error {
 		}
 	}
 
+	if p.secretUsageTracker != nil {
+		catcher.Wrap(p.secretUsageTracker.UntrackPod(ctx, utility.FromStringPtr(p.resources.TaskID)), "untracking pod's secret usage")
+	}
+
 	if catcher.HasErrors() {
 		return catcher.Resolve()
 	}
@@ -224,5 +413,20 @@ func (p *BasicPod) Delete(ctx context.Context) error {
 		p.statusInfo.Containers[i].Status = cocoa.StatusDeleted
 	}
 
+	sendEvent(ctx, p.eventSink, p.newEvent(cocoa.EventTypePodDeleted))
+
 	return nil
 }
+
+// newEvent returns a new event of the given type for the pod.
+func (p *BasicPod) newEvent(t cocoa.EventType) cocoa.Event {
+	e := cocoa.Event{
+		Type:    t,
+		Cluster: utility.FromStringPtr(p.resources.Cluster),
+		TaskID:  utility.FromStringPtr(p.resources.TaskID),
+	}
+	if p.resources.TaskDefinition != nil {
+		e.PodDefinitionID = utility.FromStringPtr(p.resources.TaskDefinition.ID)
+	}
+	return e
+}
@@ -3,12 +3,17 @@ package ecs
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/awsutil"
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
@@ -20,14 +25,183 @@ type BasicPodCreator struct {
 	client cocoa.ECSClient
 	vault  cocoa.Vault
 	cache  cocoa.ECSPodDefinitionCache
+	strict bool
+	// activeWaitOpts are the retry options used to wait for newly-registered
+	// pod definitions to become active, if any.
+	activeWaitOpts *utility.RetryOptions
+	// imageValidationOpts are the options used to check that the images in
+	// new pod definitions are pinned, if any.
+	imageValidationOpts *ImageValidationOptions
+	// secretLocationOpts are the options used to check that the existing
+	// secrets in new pod definitions are in the expected account and region,
+	// if any.
+	secretLocationOpts *SecretLocationOptions
+	// secretTagPropagationOpts are the options used to apply the tags of new
+	// pod definitions to their new secrets, if any.
+	secretTagPropagationOpts *SecretTagPropagationOptions
+	// eventSink receives lifecycle events, if any.
+	eventSink cocoa.EventSink
+	// secretUsageTracker records which pods reference which secrets, if any.
+	secretUsageTracker cocoa.SecretUsageTracker
+	// prewarmConcurrency is the maximum number of pod definitions that can be
+	// registered at once when prewarming definitions.
+	prewarmConcurrency int
+	// warmPool contains the pod definitions that have been prewarmed.
+	warmPool *warmPool
+	// runKeys serializes idempotent pod creation for each external key.
+	runKeys *keyLock
+	// deregistrationPolicy determines whether the created pods deregister
+	// their task definitions when they're deleted, if any.
+	deregistrationPolicy *DeregistrationPolicy
+	// secretCreationConcurrency is the maximum number of secrets that can be
+	// created at once for a single pod definition, if any.
+	secretCreationConcurrency *int
+	// stageTimeouts limit how long each stage of creating a pod can take, if
+	// any.
+	stageTimeouts *PodCreationStageTimeouts
+	// podDefinitionTagName is the name of the tag that tracks whether a pod
+	// definition has been cached, if any.
+	podDefinitionTagName *string
+	// networkValidationOpts are the options used to check that the networking
+	// resources for pods using AWSVPC networking are usable before running
+	// them, if any.
+	networkValidationOpts *NetworkValidationOptions
+	// verifySecrets indicates whether or not to check that the secrets
+	// referenced by a pod definition exist before running a pod.
+	verifySecrets bool
+	// nameGenerator generates the names of pod definitions and containers
+	// that are not explicitly named, if any.
+	nameGenerator cocoa.NameGenerator
+	// podDefinitionChecksumTagName is the name of the tag that records the
+	// checksum of new pod definitions, if any.
+	podDefinitionChecksumTagName *string
+	// defaultAWSVPCOpts are the subnets and security groups for pods using
+	// AWSVPC networking that do not specify their own, if any.
+	defaultAWSVPCOpts *cocoa.AWSVPCOptions
+	// failureHistory records failures to run pods, if any.
+	failureHistory *RunTaskFailureHistory
+	// normalizers normalize pod definitions before they're hashed or
+	// registered.
+	normalizers []cocoa.DefinitionNormalizer
+	// hashOpts are the options used to customize the hash of pod
+	// definitions, if any.
+	hashOpts *cocoa.HashOptions
+	// ownedClient is the client that the pod creator constructed itself, if
+	// any. Only the owned client is closed when the pod creator is closed.
+	ownedClient *BasicClient
 }
 
 // BasicPodCreatorOptions are options to create a basic ECS pod
 // creator that's optionally backed by a cache.
 type BasicPodCreatorOptions struct {
+	// Client is the client used to communicate with ECS. The caller retains
+	// ownership of the client, so it is not closed when the pod creator is
+	// closed. Exactly one of Client or ClientOptions must be specified.
 	Client cocoa.ECSClient
-	Vault  cocoa.Vault
-	Cache  cocoa.ECSPodDefinitionCache
+	// ClientOptions are options to construct a new client used to communicate
+	// with ECS. The pod creator owns the constructed client, so it is closed
+	// when the pod creator is closed. Exactly one of Client or ClientOptions
+	// must be specified.
+	ClientOptions *awsutil.ClientOptions
+	Vault         cocoa.Vault
+	Cache         cocoa.ECSPodDefinitionCache
+	// StrictValidation indicates whether or not to check that pod definitions
+	// are within the ECS size limits before creating them. By default, the
+	// size limits are only enforced by ECS when the task definition is
+	// registered.
+	StrictValidation bool
+	// ActiveWaitOpts, if specified, makes the pod creator wait after
+	// registering a pod definition until ECS reports that it is active before
+	// running the pod, retrying with these options. By default, the pod
+	// creator does not wait.
+	ActiveWaitOpts *utility.RetryOptions
+	// ImageValidationOpts, if specified, checks that the images in new pod
+	// definitions are pinned before creating them. By default, images are not
+	// checked.
+	ImageValidationOpts *ImageValidationOptions
+	// SecretLocationOpts, if specified, checks that existing secrets referenced
+	// by ARN in new pod definitions are in the expected account and region
+	// before creating them. By default, secret locations are not checked.
+	SecretLocationOpts *SecretLocationOptions
+	// SecretTagPropagationOpts, if specified, applies the tags of new pod
+	// definitions to the new secrets that are created for them. By default,
+	// new secrets only have their own tags.
+	SecretTagPropagationOpts *SecretTagPropagationOptions
+	// EventSink, if specified, receives lifecycle events for the resources
+	// that the pod creator creates. By default, no events are sent.
+	EventSink cocoa.EventSink
+	// SecretUsageTracker, if specified, records the secrets referenced by
+	// each pod that the pod creator creates, and the pods untrack their
+	// secrets when they're deleted. If a new pod's secrets cannot be
+	// tracked, the pod is deleted so that its secrets are never in use
+	// without being tracked. By default, secret usage is not tracked.
+	SecretUsageTracker cocoa.SecretUsageTracker
+	// PrewarmConcurrency is the maximum number of pod definitions that can be
+	// registered at once when prewarming definitions. By default, this is
+	// defaultPrewarmConcurrency.
+	PrewarmConcurrency *int
+	// DeregistrationPolicy, if specified, determines whether the pods that the
+	// pod creator creates deregister their task definitions when they're
+	// deleted, regardless of whether the pods own them. By default, pods only
+	// deregister task definitions that they own.
+	DeregistrationPolicy *DeregistrationPolicy
+	// SecretCreationConcurrency, if specified, is the maximum number of new
+	// secrets that can be created at once for a single pod definition. By
+	// default, secrets are created one at a time.
+	SecretCreationConcurrency *int
+	// StageTimeouts, if specified, limit how long each stage of creating a
+	// pod can take. By default, the stages are only limited by the context
+	// used to create the pod.
+	StageTimeouts *PodCreationStageTimeouts
+	// PodDefinitionTagName, if specified, is the name of the tag that tracks
+	// whether a pod definition has been cached. This takes precedence over the
+	// cache's tag. By default, this is the cache's tag, or
+	// DefaultPodDefinitionTagName if the cache does not specify one.
+	PodDefinitionTagName *string
+	// NetworkValidationOpts, if specified, checks that the subnets and
+	// security groups for pods using AWSVPC networking exist, belong to the
+	// same VPC, and have available IP addresses before running the pods. By
+	// default, networking resources are not checked.
+	NetworkValidationOpts *NetworkValidationOptions
+	// VerifySecrets indicates whether or not to check that every Secrets
+	// Manager secret referenced by a pod definition exists and is not
+	// scheduled for deletion before running a pod from it. This requires a
+	// Vault that can describe secrets. By default, secrets are not checked, so
+	// a pod with a missing secret fails after it starts.
+	VerifySecrets bool
+	// NameGenerator, if specified, generates the names of new pod definitions
+	// and their containers if they are not explicitly named and the pod
+	// definition options do not specify their own name generator. By
+	// default, they are given random names.
+	NameGenerator cocoa.NameGenerator
+	// PodDefinitionChecksumTagName, if specified, is the name of the tag that
+	// records a checksum of each new pod definition when it is registered so
+	// that pod definitions modified outside of cocoa can be detected. By
+	// default, pod definitions are not tagged with a checksum.
+	PodDefinitionChecksumTagName *string
+	// DefaultAWSVPCOpts, if specified, are the default subnets and security
+	// groups for pods using AWSVPC networking. When a pod uses AWSVPC
+	// networking but its execution options omit the subnets or the security
+	// groups, they are set from these defaults. This keeps the options for
+	// each pod small in deployments that run all their pods in a single VPC.
+	// By default, each pod must specify its own AWSVPC options.
+	DefaultAWSVPCOpts *cocoa.AWSVPCOptions
+	// RunTaskFailureHistory, if specified, records the failures to run pods so
+	// that the pods that the pod creator creates can use recent failures in
+	// their cluster and group to diagnose why they are pending. By default,
+	// failures are not recorded.
+	RunTaskFailureHistory *RunTaskFailureHistory
+	// Normalizers, if specified, are applied in order to pod definitions before
+	// they're hashed or registered, so that logically identical pod
+	// definitions converge to the same pod definition, including the same
+	// prewarmed pod definition. By default, pod definitions are not
+	// normalized.
+	Normalizers []cocoa.DefinitionNormalizer
+	// HashOpts, if specified, customize which fields of pod definitions are
+	// included in their hashes (e.g. to exclude volatile tags). Pod
+	// definitions that only differ in the excluded fields share the same
+	// prewarmed pod definition. By default, every field is included.
+	HashOpts *cocoa.HashOptions
 }
 
 // NewBasicPodCreatorOptions returns new uninitialized options to
@@ -42,6 +216,13 @@ func (o *BasicPodCreatorOptions) SetClient(c cocoa.ECSClient) *BasicPodCreatorOp
 	return o
 }
 
+// SetClientOptions sets the options to construct a new client that the pod
+// creator owns and uses to communicate with ECS.
+func (o *BasicPodCreatorOptions) SetClientOptions(opts awsutil.ClientOptions) *BasicPodCreatorOptions {
+	o.ClientOptions = &opts
+	return o
+}
+
 // SetVault sets the vault that the pod creator uses to manage secrets.
 func (o *BasicPodCreatorOptions) SetVault(v cocoa.Vault) *BasicPodCreatorOptions {
 	o.Vault = v
@@ -54,14 +235,203 @@ func (o *BasicPodCreatorOptions) SetCache(pdc cocoa.ECSPodDefinitionCache) *Basi
 	return o
 }
 
+// SetStrictValidation sets whether or not the pod creator checks that pod definitions
+// are within the ECS size limits before creating them.
+func (o *BasicPodCreatorOptions) SetStrictValidation(strict bool) *BasicPodCreatorOptions {
+	o.StrictValidation = strict
+	return o
+}
+
+// SetActiveWaitOptions sets the retry options that the pod creator uses to wait
+// for newly-registered pod definitions to become active.
+func (o *BasicPodCreatorOptions) SetActiveWaitOptions(opts utility.RetryOptions) *BasicPodCreatorOptions {
+	o.ActiveWaitOpts = &opts
+	return o
+}
+
+// SetImageValidationOptions sets the options that the pod creator uses to check
+// that the images in new pod definitions are pinned.
+func (o *BasicPodCreatorOptions) SetImageValidationOptions(opts ImageValidationOptions) *BasicPodCreatorOptions {
+	o.ImageValidationOpts = &opts
+	return o
+}
+
+// SetSecretLocationOptions sets the options that the pod creator uses to check
+// that existing secrets in new pod definitions are in the expected account and
+// region.
+func (o *BasicPodCreatorOptions) SetSecretLocationOptions(opts SecretLocationOptions) *BasicPodCreatorOptions {
+	o.SecretLocationOpts = &opts
+	return o
+}
+
+// SetSecretTagPropagationOptions sets the options that the pod creator uses to
+// apply the tags of new pod definitions to their new secrets.
+func (o *BasicPodCreatorOptions) SetSecretTagPropagationOptions(opts SecretTagPropagationOptions) *BasicPodCreatorOptions {
+	o.SecretTagPropagationOpts = &opts
+	return o
+}
+
+// SetEventSink sets the sink that receives lifecycle events for the resources
+// that the pod creator creates.
+func (o *BasicPodCreatorOptions) SetEventSink(sink cocoa.EventSink) *BasicPodCreatorOptions {
+	o.EventSink = sink
+	return o
+}
+
+// SetSecretUsageTracker sets the tracker that records which pods reference
+// which secrets.
+func (o *BasicPodCreatorOptions) SetSecretUsageTracker(t cocoa.SecretUsageTracker) *BasicPodCreatorOptions {
+	o.SecretUsageTracker = t
+	return o
+}
+
+// SetPrewarmConcurrency sets the maximum number of pod definitions that can be
+// registered at once when prewarming definitions.
+func (o *BasicPodCreatorOptions) SetPrewarmConcurrency(n int) *BasicPodCreatorOptions {
+	o.PrewarmConcurrency = &n
+	return o
+}
+
+// SetDeregistrationPolicy sets the policy that determines whether the created
+// pods deregister their task definitions when they're deleted.
+func (o *BasicPodCreatorOptions) SetDeregistrationPolicy(p DeregistrationPolicy) *BasicPodCreatorOptions {
+	o.DeregistrationPolicy = &p
+	return o
+}
+
+// SetSecretCreationConcurrency sets the maximum number of new secrets that can
+// be created at once for a single pod definition.
+func (o *BasicPodCreatorOptions) SetSecretCreationConcurrency(n int) *BasicPodCreatorOptions {
+	o.SecretCreationConcurrency = &n
+	return o
+}
+
+// SetStageTimeouts sets the timeouts for each stage of creating a pod.
+func (o *BasicPodCreatorOptions) SetStageTimeouts(timeouts PodCreationStageTimeouts) *BasicPodCreatorOptions {
+	o.StageTimeouts = &timeouts
+	return o
+}
+
+// SetPodDefinitionTagName sets the name of the tag that tracks whether a pod
+// definition has been cached.
+func (o *BasicPodCreatorOptions) SetPodDefinitionTagName(name string) *BasicPodCreatorOptions {
+	o.PodDefinitionTagName = &name
+	return o
+}
+
+// SetNetworkValidationOptions sets the options that the pod creator uses to
+// check that the networking resources for pods using AWSVPC networking are
+// usable before running them.
+func (o *BasicPodCreatorOptions) SetNetworkValidationOptions(opts NetworkValidationOptions) *BasicPodCreatorOptions {
+	o.NetworkValidationOpts = &opts
+	return o
+}
+
+// SetVerifySecrets sets whether or not the pod creator checks that the secrets
+// referenced by a pod definition exist before running a pod from it.
+func (o *BasicPodCreatorOptions) SetVerifySecrets(verify bool) *BasicPodCreatorOptions {
+	o.VerifySecrets = verify
+	return o
+}
+
+// SetNameGenerator sets the generator for the names of new pod definitions and
+// their containers if they are not explicitly named.
+func (o *BasicPodCreatorOptions) SetNameGenerator(g cocoa.NameGenerator) *BasicPodCreatorOptions {
+	o.NameGenerator = g
+	return o
+}
+
+// SetPodDefinitionChecksumTagName sets the name of the tag that records a
+// checksum of each new pod definition.
+func (o *BasicPodCreatorOptions) SetPodDefinitionChecksumTagName(name string) *BasicPodCreatorOptions {
+	o.PodDefinitionChecksumTagName = &name
+	return o
+}
+
+// SetDefaultAWSVPCOptions sets the default subnets and security groups for
+// pods using AWSVPC networking that do not specify their own.
+func (o *BasicPodCreatorOptions) SetDefaultAWSVPCOptions(opts cocoa.AWSVPCOptions) *BasicPodCreatorOptions {
+	o.DefaultAWSVPCOpts = &opts
+	return o
+}
+
+// SetRunTaskFailureHistory sets the history that records failures to run pods.
+func (o *BasicPodCreatorOptions) SetRunTaskFailureHistory(h *RunTaskFailureHistory) *BasicPodCreatorOptions {
+	o.RunTaskFailureHistory = h
+	return o
+}
+
+// SetNormalizers sets the normalizers that are applied to pod definitions
+// before hashing or registering them. This overwrites any existing
+// normalizers.
+func (o *BasicPodCreatorOptions) SetNormalizers(normalizers []cocoa.DefinitionNormalizer) *BasicPodCreatorOptions {
+	o.Normalizers = normalizers
+	return o
+}
+
+// AddNormalizers adds new normalizers to the existing ones.
+func (o *BasicPodCreatorOptions) AddNormalizers(normalizers ...cocoa.DefinitionNormalizer) *BasicPodCreatorOptions {
+	o.Normalizers = append(o.Normalizers, normalizers...)
+	return o
+}
+
+// SetHashOptions sets the options to customize which fields of pod definitions
+// are included in their hashes.
+func (o *BasicPodCreatorOptions) SetHashOptions(opts cocoa.HashOptions) *BasicPodCreatorOptions {
+	o.HashOpts = &opts
+	return o
+}
+
 // Validate checks that the required parameters to initialize a pod creator are given.
 func (o *BasicPodCreatorOptions) Validate() error {
 	catcher := grip.NewBasicCatcher()
-	catcher.NewWhen(o.Client == nil, "must specify a client")
+	catcher.NewWhen(o.Client == nil && o.ClientOptions == nil, "must specify either a client or client options")
+	catcher.NewWhen(o.Client != nil && o.ClientOptions != nil, "cannot specify both a client and client options")
+	catcher.NewWhen(encryptsValues(o.Vault), "cannot use a vault that encrypts secret values because ECS cannot decrypt them for pods")
+	if o.ImageValidationOpts != nil {
+		catcher.Wrap(o.ImageValidationOpts.Validate(), "invalid image validation options")
+	}
+	if o.SecretLocationOpts != nil {
+		catcher.Wrap(o.SecretLocationOpts.Validate(), "invalid secret location options")
+	}
+	if o.SecretTagPropagationOpts != nil {
+		catcher.Wrap(o.SecretTagPropagationOpts.Validate(), "invalid secret tag propagation options")
+	}
+	catcher.NewWhen(o.PrewarmConcurrency != nil && *o.PrewarmConcurrency <= 0, "must specify a positive prewarm concurrency")
+	if o.DeregistrationPolicy != nil {
+		catcher.Wrap(o.DeregistrationPolicy.Validate(), "invalid deregistration policy")
+	}
+	catcher.NewWhen(o.SecretCreationConcurrency != nil && *o.SecretCreationConcurrency <= 0, "must specify a positive secret creation concurrency")
+	for i, n := range o.Normalizers {
+		catcher.ErrorfWhen(n == nil, "normalizer at index %d cannot be nil", i)
+	}
+	if o.StageTimeouts != nil {
+		catcher.Wrap(o.StageTimeouts.Validate(), "invalid stage timeouts")
+	}
+	if o.PodDefinitionTagName != nil {
+		catcher.Wrapf(validatePodDefinitionTagName(*o.PodDefinitionTagName), "invalid pod definition tag name '%s'", *o.PodDefinitionTagName)
+	}
+	if o.PodDefinitionChecksumTagName != nil {
+		catcher.Wrapf(validatePodDefinitionTagName(*o.PodDefinitionChecksumTagName), "invalid pod definition checksum tag name '%s'", *o.PodDefinitionChecksumTagName)
+	}
+	if o.NetworkValidationOpts != nil {
+		catcher.Wrap(o.NetworkValidationOpts.Validate(), "invalid network validation options")
+	}
+	if o.DefaultAWSVPCOpts != nil {
+		catcher.Wrap(o.DefaultAWSVPCOpts.Validate(), "invalid default AWSVPC options")
+	}
+	if o.VerifySecrets {
+		_, ok := o.Vault.(cocoa.SecretDescriber)
+		catcher.NewWhen(!ok, "must specify a vault that can describe secrets to verify secrets")
+	}
 	if catcher.HasErrors() {
 		return catcher.Resolve()
 	}
 
+	if o.PrewarmConcurrency == nil {
+		o.SetPrewarmConcurrency(defaultPrewarmConcurrency)
+	}
+
 	return nil
 }
 
@@ -70,40 +440,159 @@ func NewBasicPodCreator(opts BasicPodCreatorOptions) (*BasicPodCreator, error) {
 	if err := opts.Validate(); err != nil {
 		return nil, errors.Wrap(err, "invalid options")
 	}
-	return &BasicPodCreator{
-		client: opts.Client,
-		vault:  opts.Vault,
-		cache:  opts.Cache,
-	}, nil
+	pc := &BasicPodCreator{
+		client:                       opts.Client,
+		vault:                        opts.Vault,
+		cache:                        opts.Cache,
+		strict:                       opts.StrictValidation,
+		activeWaitOpts:               opts.ActiveWaitOpts,
+		imageValidationOpts:          opts.ImageValidationOpts,
+		secretLocationOpts:           opts.SecretLocationOpts,
+		secretTagPropagationOpts:     opts.SecretTagPropagationOpts,
+		eventSink:                    opts.EventSink,
+		secretUsageTracker:           opts.SecretUsageTracker,
+		prewarmConcurrency:           utility.FromIntPtr(opts.PrewarmConcurrency),
+		warmPool:                     newWarmPool(),
+		runKeys:                      newKeyLock(),
+		deregistrationPolicy:         opts.DeregistrationPolicy,
+		secretCreationConcurrency:    opts.SecretCreationConcurrency,
+		stageTimeouts:                opts.StageTimeouts,
+		podDefinitionTagName:         opts.PodDefinitionTagName,
+		networkValidationOpts:        opts.NetworkValidationOpts,
+		verifySecrets:                opts.VerifySecrets,
+		nameGenerator:                opts.NameGenerator,
+		podDefinitionChecksumTagName: opts.PodDefinitionChecksumTagName,
+		defaultAWSVPCOpts:            opts.DefaultAWSVPCOpts,
+		failureHistory:               opts.RunTaskFailureHistory,
+		normalizers:                  opts.Normalizers,
+		hashOpts:                     opts.HashOpts,
+	}
+	if opts.ClientOptions != nil {
+		pc.ownedClient = newLazyBasicClient(*opts.ClientOptions)
+		pc.client = pc.ownedClient
+	}
+	return pc, nil
 }
 
-// CreatePod creates a new pod backed by AWS ECS.
+// Close closes the client if the pod creator constructed it from client
+// options. Clients that were given to the pod creator are owned by the caller
+// and are not closed. The pod creator should not be used after it is closed.
+func (pc *BasicPodCreator) Close(ctx context.Context) error {
+	if pc.ownedClient == nil {
+		return nil
+	}
+	return errors.Wrap(pc.ownedClient.Close(ctx), "closing ECS client")
+}
+
+// CreatePod creates a new pod backed by AWS ECS. If it fails after some of the
+// pod's resources have already been created (e.g. because the context is
+// canceled), it returns a *cocoa.PartialCreationError containing the secrets
+// and task definition that were created so that the caller can clean them up.
 func (pc *BasicPodCreator) CreatePod(ctx context.Context, opts ...cocoa.ECSPodCreationOptions) (cocoa.ECSPod, error) {
-	mergedPodCreationOpts := cocoa.MergeECSPodCreationOptions(opts...)
+	p, _, err := pc.createPodWithDefinition(ctx, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// createPodWithDefinition creates a new pod backed by AWS ECS along with its
+// pod definition. It returns the pod and the pod definition that it runs. If
+// one of the pod creation stages fails, it returns a *cocoa.StagedError
+// identifying the stage that failed. If fallbacks are given, the pod is run
+// with the fallback execution options when there is not enough capacity to run
+// it.
+func (pc *BasicPodCreator) createPodWithDefinition(ctx context.Context, fallbacks *podExecutionFallbacks, opts ...cocoa.ECSPodCreationOptions) (*BasicPod, *cocoa.ECSPodDefinitionItem, error) {
+	stages := newPodCreationStages(pc.stageTimeouts)
+	p, pdi, err := pc.createPodInStages(ctx, stages, fallbacks, opts...)
+	if err != nil {
+		return nil, nil, stages.wrapError(err)
+	}
+	stages.logDurations(utility.FromStringPtr(p.resources.TaskID))
+	return p, pdi, nil
+}
+
+// createPodInStages creates a new pod backed by AWS ECS along with its pod
+// definition, running each step of creating the pod as a separate stage.
+func (pc *BasicPodCreator) createPodInStages(ctx context.Context, stages *podCreationStages, fallbacks *podExecutionFallbacks, opts ...cocoa.ECSPodCreationOptions) (*BasicPod, *cocoa.ECSPodDefinitionItem, error) {
+	mergedPodCreationOpts := withDefaultAWSVPCOptions(cocoa.MergeECSPodCreationOptions(opts...), pc.defaultAWSVPCOpts)
 	var mergedPodExecutionOpts cocoa.ECSPodExecutionOptions
 	if mergedPodCreationOpts.ExecutionOpts != nil {
 		mergedPodExecutionOpts = *mergedPodCreationOpts.ExecutionOpts
 	}
 
+	// The prewarmed pod definition has to be looked up before validating
+	// since validation can set defaults in the definition options. It is
+	// looked up by the normalized pod definition options, since prewarmed pod
+	// definitions are also normalized before they're registered. Overriding
+	// bind mounts or secrets requires a new pod definition, so a prewarmed one
+	// cannot be used. Deferred secrets are resolved separately for each pod,
+	// so they also require a new pod definition.
+	pdm, err := pc.newPodDefinitionManager()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "initializing pod definition manager")
+	}
+	var prewarmed *cocoa.ECSPodDefinitionItem
+	requiresNewDefinition := mergedPodExecutionOpts.OverrideOpts != nil && mergedPodExecutionOpts.OverrideOpts.RequiresNewDefinition()
+	if !requiresNewDefinition && len(cocoa.DeferredSecretNames(mergedPodCreationOpts.DefinitionOpts)) == 0 {
+		hash, hashed, err := pdm.hashedPodDefinition(mergedPodCreationOpts.DefinitionOpts)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "hashing pod definition")
+		}
+		prewarmed = pc.warmPool.get(hash, hashed)
+	}
+
+	if mergedPodCreationOpts.DefinitionOpts.NameGenerator == nil {
+		mergedPodCreationOpts.DefinitionOpts.NameGenerator = pc.nameGenerator
+	}
 	if err := mergedPodCreationOpts.Validate(); err != nil {
-		return nil, errors.Wrap(err, "invalid pod creation options")
+		return nil, nil, errors.Wrap(err, "invalid pod creation options")
 	}
 
 	if err := mergedPodExecutionOpts.Validate(); err != nil {
-		return nil, errors.Wrap(err, "invalid pod execution options")
+		return nil, nil, errors.Wrap(err, "invalid pod execution options")
+	}
+	if overrideOpts := mergedPodExecutionOpts.OverrideOpts; overrideOpts != nil {
+		if err := overrideOpts.ValidateAgainst(mergedPodCreationOpts.DefinitionOpts); err != nil {
+			return nil, nil, errors.Wrap(err, "overrides are incompatible with pod definition")
+		}
+	}
+	if placementOpts := mergedPodExecutionOpts.PlacementOpts; placementOpts != nil && placementOpts.ImagePullBehavior != nil {
+		if err := ValidatePodDefinitionImagePullBehavior(mergedPodCreationOpts.DefinitionOpts, *placementOpts.ImagePullBehavior); err != nil {
+			return nil, nil, errors.Wrap(err, "pod definition is incompatible with image pull behavior")
+		}
+	}
+	mergedPodExecutionOpts = withInheritedPurpose(mergedPodExecutionOpts, mergedPodCreationOpts.DefinitionOpts)
+	if fallbacks != nil {
+		defOpts := mergedPodCreationOpts.DefinitionOpts
+		// A fallback that sets its own tags replaces the original tags, so
+		// the pod definition's purpose has to be inherited again.
+		if err := fallbacks.merge(mergedPodExecutionOpts, func(execOpts cocoa.ECSPodExecutionOptions) cocoa.ECSPodExecutionOptions {
+			return withInheritedPurpose(pc.withDefaultExecutionAWSVPCOptions(execOpts, defOpts), defOpts)
+		}); err != nil {
+			return nil, nil, errors.Wrap(err, "invalid fallback execution options")
+		}
 	}
+	ctx = contextWithAssumeRole(ctx, mergedPodExecutionOpts.AssumeRoleOpts)
 
-	pdm, err := NewBasicPodDefinitionManager(*NewBasicPodDefinitionManagerOptions().
-		SetClient(pc.client).
-		SetVault(pc.vault).
-		SetCache(pc.cache))
-	if err != nil {
-		return nil, errors.Wrap(err, "initializing pod definition manager")
+	if prewarmed != nil {
+		return pc.createPodFromPrewarmedDefinition(ctx, stages, *prewarmed, mergedPodExecutionOpts, fallbacks)
+	}
+
+	// ECS does not support overriding mounts or secrets when running a task,
+	// so any overriding bind mounts and secrets have to be part of the new
+	// task definition.
+	mergedPodCreationOpts.DefinitionOpts = applyDefinitionOverrides(mergedPodCreationOpts.DefinitionOpts, mergedPodExecutionOpts.OverrideOpts)
+
+	// The deferred secrets are checked before creating anything so that a
+	// pod definition is not created for a pod that cannot run.
+	if err := cocoa.ValidateDeferredSecretValues(mergedPodCreationOpts.DefinitionOpts, mergedPodExecutionOpts.DeferredSecretValues); err != nil {
+		return nil, nil, errors.Wrap(err, "unresolved deferred secrets")
 	}
 
-	pdi, err := pdm.CreatePodDefinition(ctx, mergedPodCreationOpts.DefinitionOpts)
+	pdi, secretIDs, err := pdm.createPodDefinition(ctx, stages, mergedPodCreationOpts.DefinitionOpts)
 	if err != nil {
-		return nil, errors.Wrap(err, "creating pod definition")
+		return nil, nil, errors.Wrap(err, "creating pod definition")
 	}
 	mergedPodCreationOpts.DefinitionOpts = pdi.DefinitionOpts
 
@@ -111,27 +600,229 @@ func (pc *BasicPodCreator) CreatePod(ctx context.Context, opts ...cocoa.ECSPodCr
 		SetID(pdi.ID).
 		SetOwned(true)
 
-	task, err := pc.runTask(ctx, mergedPodExecutionOpts, *taskDef)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, newPartialCreationErrorIfCreated(errors.Wrap(err, "context is done before running task"), secretIDs, pdi.ID)
+	}
+
+	var task *types.Task
+	if err := stages.run(ctx, cocoa.ECSPodCreationStageRun, func(ctx context.Context) error {
+		if err := ResolveDeferredSecrets(ctx, pc.vault, pdi.DefinitionOpts, mergedPodExecutionOpts.DeferredSecretValues); err != nil {
+			return err
+		}
+		var err error
+		task, mergedPodExecutionOpts, err = pc.runTaskWithFallbacks(ctx, mergedPodExecutionOpts, *taskDef, fallbacks)
+		return err
+	}); err != nil {
+		return nil, nil, newPartialCreationErrorIfCreated(errors.Wrap(err, "running task"), secretIDs, pdi.ID)
+	}
+
+	p, err := pc.translatePod(ctx, stages, *task, *taskDef, mergedPodCreationOpts.DefinitionOpts.ContainerDefinitions, mergedPodExecutionOpts)
 	if err != nil {
-		return nil, errors.Wrap(err, "running task")
+		return nil, nil, err
+	}
+
+	sendEvent(ctx, pc.eventSink, p.newEvent(cocoa.EventTypePodCreated))
+
+	return p, pdi, nil
+}
+
+// translatePod translates the newly-running task into a pod and tracks the
+// pod's secret usage as the translate stage of creating the pod.
+func (pc *BasicPodCreator) translatePod(ctx context.Context, stages *podCreationStages, task types.Task, taskDef cocoa.ECSTaskDefinition, containerDefs []cocoa.ECSContainerDefinition, opts cocoa.ECSPodExecutionOptions) (*BasicPod, error) {
+	var p *BasicPod
+	if err := stages.run(ctx, cocoa.ECSPodCreationStageTranslate, func(stageCtx context.Context) error {
+		var err error
+		p, err = pc.createPod(utility.FromStringPtr(opts.Cluster), task, taskDef, containerDefs, opts.ProtectionPolicy)
+		if err != nil {
+			return errors.Wrap(err, "creating pod after requesting task")
+		}
+		// The pod is cleaned up with the original context so that it is still
+		// deleted if the stage times out.
+		return pc.trackSecretUsage(stageCtx, ctx, p)
+	}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newPodDefinitionManager returns a pod definition manager that creates pod
+// definitions with the same settings as the pod creator.
+func (pc *BasicPodCreator) newPodDefinitionManager() (*BasicPodDefinitionManager, error) {
+	pdmOpts := NewBasicPodDefinitionManagerOptions().
+		SetClient(pc.client).
+		SetVault(pc.vault).
+		SetCache(pc.cache).
+		SetStrictValidation(pc.strict)
+	if pc.activeWaitOpts != nil {
+		pdmOpts.SetActiveWaitOptions(*pc.activeWaitOpts)
+	}
+	if pc.imageValidationOpts != nil {
+		pdmOpts.SetImageValidationOptions(*pc.imageValidationOpts)
+	}
+	if pc.secretLocationOpts != nil {
+		pdmOpts.SetSecretLocationOptions(*pc.secretLocationOpts)
+	}
+	if pc.secretTagPropagationOpts != nil {
+		pdmOpts.SetSecretTagPropagationOptions(*pc.secretTagPropagationOpts)
+	}
+	if pc.eventSink != nil {
+		pdmOpts.SetEventSink(pc.eventSink)
+	}
+	if pc.secretCreationConcurrency != nil {
+		pdmOpts.SetSecretCreationConcurrency(*pc.secretCreationConcurrency)
 	}
+	if pc.podDefinitionTagName != nil {
+		pdmOpts.SetTagName(*pc.podDefinitionTagName)
+	}
+	if pc.nameGenerator != nil {
+		pdmOpts.SetNameGenerator(pc.nameGenerator)
+	}
+	if pc.podDefinitionChecksumTagName != nil {
+		pdmOpts.SetChecksumTagName(*pc.podDefinitionChecksumTagName)
+	}
+	if len(pc.normalizers) != 0 {
+		pdmOpts.SetNormalizers(pc.normalizers)
+	}
+	if pc.hashOpts != nil {
+		pdmOpts.SetHashOptions(*pc.hashOpts)
+	}
+	return NewBasicPodDefinitionManager(*pdmOpts)
+}
 
-	p, err := pc.createPod(utility.FromStringPtr(mergedPodExecutionOpts.Cluster), *task, *taskDef, mergedPodCreationOpts.DefinitionOpts.ContainerDefinitions)
+// CreatePodIdempotent creates a new pod backed by AWS ECS for the external key
+// unless the pod that was last created for the key is still running, in which
+// case it returns that pod without launching a duplicate. The pod creator must
+// have a cache that implements cocoa.ECSPodDefinitionRunCache to track the
+// last pod run for each key. If the new pod cannot be recorded in the cache,
+// the pod is deleted so that it cannot run untracked.
+//
+// Checking the last pod run and recording the new one are not a single atomic
+// operation in the cache, so concurrent calls for the same key are serialized
+// within the pod creator to ensure only one of them creates a pod. Callers that
+// create pods for the same key from multiple pod creators (e.g. in different
+// processes) must serialize those calls per key themselves.
+func (pc *BasicPodCreator) CreatePodIdempotent(ctx context.Context, key string, opts ...cocoa.ECSPodCreationOptions) (cocoa.ECSPod, error) {
+	if key == "" {
+		return nil, errors.New("must specify a non-empty key")
+	}
+	runCache, ok := pc.cache.(cocoa.ECSPodDefinitionRunCache)
+	if !ok {
+		return nil, errors.New("pod creator's cache does not support tracking pod runs")
+	}
+
+	var protection *cocoa.ECSPodProtectionPolicy
+	roleCtx := ctx
+	if execOpts := cocoa.MergeECSPodCreationOptions(opts...).ExecutionOpts; execOpts != nil {
+		protection = execOpts.ProtectionPolicy
+		roleCtx = contextWithAssumeRole(ctx, execOpts.AssumeRoleOpts)
+	}
+
+	unlock, err := pc.runKeys.lock(ctx, key)
 	if err != nil {
-		return nil, errors.Wrap(err, "creating pod after requesting task")
+		return nil, errors.Wrapf(err, "waiting for other pod creation for key '%s'", key)
+	}
+	defer unlock()
+
+	item, err := runCache.GetRun(ctx, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting last pod run for key '%s'", key)
+	}
+	if item != nil && item.LastRun != nil {
+		p, err := pc.getRunningPod(roleCtx, *item, protection)
+		if err != nil {
+			return nil, errors.Wrapf(err, "checking last pod run for key '%s'", key)
+		}
+		if p != nil {
+			return p, nil
+		}
+	}
+
+	p, pdi, err := pc.createPodWithDefinition(ctx, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := p.Resources()
+	pdi.LastRun = &cocoa.ECSPodRun{
+		Key:     key,
+		Cluster: utility.FromStringPtr(res.Cluster),
+		TaskID:  utility.FromStringPtr(res.TaskID),
+	}
+	if err := runCache.PutRun(ctx, *pdi); err != nil {
+		catcher := grip.NewBasicCatcher()
+		catcher.Wrapf(err, "recording pod run for key '%s'", key)
+		catcher.Wrap(p.Delete(roleCtx), "deleting untracked pod")
+		return nil, catcher.Resolve()
 	}
 
 	return p, nil
 }
 
+// getRunningPod returns the pod from the item's last run if it is still
+// running. If the pod has stopped or no longer exists, it returns nil.
+func (pc *BasicPodCreator) getRunningPod(ctx context.Context, item cocoa.ECSPodDefinitionItem, protection *cocoa.ECSPodProtectionPolicy) (*BasicPod, error) {
+	out, err := pc.client.DescribeTasks(ctx, &ecs.DescribeTasksInput{
+		Cluster: aws.String(item.LastRun.Cluster),
+		Tasks:   []string{item.LastRun.TaskID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describing task")
+	}
+	for _, f := range out.Failures {
+		if !isTaskNotFoundFailure(f) {
+			return nil, errors.Wrap(ConvertFailureToError(f), "describing task")
+		}
+	}
+	if len(out.Tasks) == 0 {
+		return nil, nil
+	}
+
+	task := out.Tasks[0]
+	if TaskStatus(utility.FromStringPtr(task.DesiredStatus)) == TaskStatusStopped {
+		return nil, nil
+	}
+	switch translatePodStatusInfo(task).Status {
+	case cocoa.StatusStopped, cocoa.StatusDeleted:
+		return nil, nil
+	}
+
+	// A pod that ran from a prewarmed pod definition shares the pod definition
+	// and its secrets with other pods, so it must not clean them up.
+	containerDefs := item.DefinitionOpts.ContainerDefinitions
+	if item.Prewarmed {
+		containerDefs = withUnownedSecrets(containerDefs)
+	}
+	taskDef := cocoa.NewECSTaskDefinition().
+		SetID(item.ID).
+		SetOwned(!item.Prewarmed)
+
+	return pc.createPod(item.LastRun.Cluster, task, *taskDef, containerDefs, protection)
+}
+
 // CreatePodFromExistingDefinition creates a new pod backed by AWS ECS from an
-// existing definition.
+// existing definition. The existing definition's secrets are not known, so if
+// it has deferred secrets, they must be resolved with ResolveDeferredSecrets
+// before creating the pod rather than with the execution options' deferred
+// secret values.
 func (pc *BasicPodCreator) CreatePodFromExistingDefinition(ctx context.Context, def cocoa.ECSTaskDefinition, opts ...cocoa.ECSPodExecutionOptions) (cocoa.ECSPod, error) {
 	if err := def.Validate(); err != nil {
 		return nil, errors.Wrap(err, "invalid task definition")
 	}
 
 	mergedPodExecutionOpts := cocoa.MergeECSPodExecutionOptions(opts...)
+	if err := validateExistingDefinitionOverrides(mergedPodExecutionOpts.OverrideOpts); err != nil {
+		return nil, err
+	}
+	if err := validateExistingDefinitionDeferredSecrets(mergedPodExecutionOpts.DeferredSecretValues); err != nil {
+		return nil, err
+	}
+	ctx = contextWithAssumeRole(ctx, mergedPodExecutionOpts.AssumeRoleOpts)
+	// The defaults have to be set before validating since the execution
+	// options may rely on them for required AWSVPC options.
+	mergedPodExecutionOpts, err := pc.withDefaultExistingDefinitionAWSVPCOptions(ctx, def, mergedPodExecutionOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting default AWSVPC options")
+	}
 	if err := mergedPodExecutionOpts.Validate(); err != nil {
 		return nil, errors.Wrap(err, "invalid pod execution options")
 	}
@@ -145,27 +836,197 @@ func (pc *BasicPodCreator) CreatePodFromExistingDefinition(ctx context.Context,
 		return nil, errors.Wrap(err, "running task")
 	}
 
-	p, err := pc.createPod(utility.FromStringPtr(mergedPodExecutionOpts.Cluster), *task, *taskDef, nil)
+	p, err := pc.createPod(utility.FromStringPtr(mergedPodExecutionOpts.Cluster), *task, *taskDef, nil, mergedPodExecutionOpts.ProtectionPolicy)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating pod after requesting task")
 	}
 
+	sendEvent(ctx, pc.eventSink, p.newEvent(cocoa.EventTypePodCreated))
+
 	return p, nil
 }
 
+// validateExistingDefinitionOverrides checks that the overrides can be applied
+// when running a task from an existing pod definition.
+func validateExistingDefinitionOverrides(overrideOpts *cocoa.ECSOverridePodDefinitionOptions) error {
+	if overrideOpts == nil {
+		return nil
+	}
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(overrideOpts.HasBindMounts(), "cannot override bind mounts for an existing pod definition because ECS does not support overriding mounts when running a task")
+	catcher.NewWhen(overrideOpts.HasSecretEnvironmentVariables(), "cannot override secret environment variables for an existing pod definition because ECS does not support overriding secrets when running a task")
+	return catcher.Resolve()
+}
+
+// withInheritedPurpose returns a copy of the execution options in which the pod
+// has the same purpose as its pod definition, so that pods can be found by the
+// purpose of their pod definition. A purpose explicitly set for the pod takes
+// precedence.
+func withInheritedPurpose(execOpts cocoa.ECSPodExecutionOptions, defOpts cocoa.ECSPodDefinitionOptions) cocoa.ECSPodExecutionOptions {
+	purpose, ok := defOpts.GetPurpose()
+	if !ok {
+		return execOpts
+	}
+	if _, ok := execOpts.GetPurpose(); ok {
+		return execOpts
+	}
+	execOpts.Tags = cocoa.NewTags().Add(execOpts.Tags).SetPurpose(purpose)
+	return execOpts
+}
+
+// withDefaultAWSVPCOptions returns a copy of the pod creation options in which
+// a pod using AWSVPC networking has the default subnets and security groups if
+// its execution options do not specify any.
+func withDefaultAWSVPCOptions(opts cocoa.ECSPodCreationOptions, defaults *cocoa.AWSVPCOptions) cocoa.ECSPodCreationOptions {
+	if defaults == nil || !usesAWSVPC(opts.DefinitionOpts) {
+		return opts
+	}
+
+	var execOpts cocoa.ECSPodExecutionOptions
+	if opts.ExecutionOpts != nil {
+		execOpts = *opts.ExecutionOpts
+	}
+	execOpts = applyDefaultAWSVPCOptions(execOpts, *defaults)
+	opts.ExecutionOpts = &execOpts
+
+	return opts
+}
+
+// withDefaultExecutionAWSVPCOptions returns a copy of the execution options in
+// which a pod from the pod definition has the pod creator's default subnets
+// and security groups if the pod definition uses AWSVPC networking and the
+// execution options do not specify any.
+func (pc *BasicPodCreator) withDefaultExecutionAWSVPCOptions(execOpts cocoa.ECSPodExecutionOptions, defOpts cocoa.ECSPodDefinitionOptions) cocoa.ECSPodExecutionOptions {
+	if pc.defaultAWSVPCOpts == nil || !usesAWSVPC(defOpts) {
+		return execOpts
+	}
+	return applyDefaultAWSVPCOptions(execOpts, *pc.defaultAWSVPCOpts)
+}
+
+// withDefaultExistingDefinitionAWSVPCOptions is the same as
+// withDefaultExecutionAWSVPCOptions for a pod from an existing pod definition.
+// The existing pod definition is only described to check its network mode if
+// the execution options are missing subnets or security groups.
+func (pc *BasicPodCreator) withDefaultExistingDefinitionAWSVPCOptions(ctx context.Context, def cocoa.ECSTaskDefinition, execOpts cocoa.ECSPodExecutionOptions) (cocoa.ECSPodExecutionOptions, error) {
+	if pc.defaultAWSVPCOpts == nil {
+		return execOpts, nil
+	}
+	if awsvpcOpts := execOpts.AWSVPCOpts; awsvpcOpts != nil && len(awsvpcOpts.Subnets) != 0 && len(awsvpcOpts.SecurityGroups) != 0 {
+		return execOpts, nil
+	}
+
+	id := utility.FromStringPtr(def.ID)
+	out, err := pc.client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String(id),
+	})
+	if err != nil {
+		return execOpts, errors.Wrapf(err, "describing pod definition '%s'", id)
+	}
+	if out.TaskDefinition == nil || out.TaskDefinition.NetworkMode != types.NetworkModeAwsvpc {
+		return execOpts, nil
+	}
+
+	return applyDefaultAWSVPCOptions(execOpts, *pc.defaultAWSVPCOpts), nil
+}
+
+// applyDefaultAWSVPCOptions returns a copy of the execution options with the
+// default subnets and security groups if they do not specify any.
+func applyDefaultAWSVPCOptions(execOpts cocoa.ECSPodExecutionOptions, defaults cocoa.AWSVPCOptions) cocoa.ECSPodExecutionOptions {
+	var awsvpcOpts cocoa.AWSVPCOptions
+	if execOpts.AWSVPCOpts != nil {
+		awsvpcOpts = *execOpts.AWSVPCOpts
+	}
+	if len(awsvpcOpts.Subnets) == 0 {
+		awsvpcOpts.Subnets = append([]string{}, defaults.Subnets...)
+	}
+	if len(awsvpcOpts.SecurityGroups) == 0 {
+		awsvpcOpts.SecurityGroups = append([]string{}, defaults.SecurityGroups...)
+	}
+	execOpts.AWSVPCOpts = &awsvpcOpts
+
+	return execOpts
+}
+
+// usesAWSVPC returns whether or not the pod definition uses AWSVPC networking.
+func usesAWSVPC(defOpts cocoa.ECSPodDefinitionOptions) bool {
+	return defOpts.NetworkMode != nil && *defOpts.NetworkMode == cocoa.NetworkModeAWSVPC
+}
+
+// applyDefinitionOverrides returns a copy of the pod definition options in
+// which each container definition includes the bind mounts and secret
+// environment variables from its corresponding container override.
+func applyDefinitionOverrides(defOpts cocoa.ECSPodDefinitionOptions, overrideOpts *cocoa.ECSOverridePodDefinitionOptions) cocoa.ECSPodDefinitionOptions {
+	if overrideOpts == nil || !overrideOpts.RequiresNewDefinition() {
+		return defOpts
+	}
+
+	containerDefs := make([]cocoa.ECSContainerDefinition, 0, len(defOpts.ContainerDefinitions))
+	for _, def := range defOpts.ContainerDefinitions {
+		def.BindMounts = append([]cocoa.BindMount{}, def.BindMounts...)
+		def.EnvVars = append([]cocoa.EnvironmentVariable{}, def.EnvVars...)
+		for _, override := range overrideOpts.ContainerDefinitions {
+			if utility.FromStringPtr(override.Name) == utility.FromStringPtr(def.Name) {
+				def.BindMounts = append(def.BindMounts, override.BindMounts...)
+				def.EnvVars = overrideEnvironmentVariables(def.EnvVars, override.SecretEnvVars)
+			}
+		}
+		containerDefs = append(containerDefs, def)
+	}
+	defOpts.ContainerDefinitions = containerDefs
+
+	return defOpts
+}
+
+// overrideEnvironmentVariables replaces the environment variables that have
+// the same name as an overriding one and appends the rest of the overriding
+// ones.
+func overrideEnvironmentVariables(envVars, overrides []cocoa.EnvironmentVariable) []cocoa.EnvironmentVariable {
+	for _, override := range overrides {
+		var replaced bool
+		for i := range envVars {
+			if utility.FromStringPtr(envVars[i].Name) == utility.FromStringPtr(override.Name) {
+				envVars[i] = override
+				replaced = true
+			}
+		}
+		if !replaced {
+			envVars = append(envVars, override)
+		}
+	}
+	return envVars
+}
+
 // createPod creates the basic ECS pod after its ECS task has been requested.
-func (pc *BasicPodCreator) createPod(cluster string, task types.Task, def cocoa.ECSTaskDefinition, containerDefs []cocoa.ECSContainerDefinition) (*BasicPod, error) {
+func (pc *BasicPodCreator) createPod(cluster string, task types.Task, def cocoa.ECSTaskDefinition, containerDefs []cocoa.ECSContainerDefinition, protection *cocoa.ECSPodProtectionPolicy) (*BasicPod, error) {
 	resources := cocoa.NewECSPodResources().
 		SetCluster(cluster).
 		SetContainers(pc.translateContainerResources(task.Containers, containerDefs)).
 		SetTaskDefinition(def).
 		SetTaskID(utility.FromStringPtr(task.TaskArn))
+	if task.Group != nil {
+		resources.SetGroup(*task.Group)
+	}
 
 	podOpts := NewBasicPodOptions().
 		SetClient(pc.client).
 		SetVault(pc.vault).
 		SetStatusInfo(translatePodStatusInfo(task)).
 		SetResources(*resources)
+	if protection != nil {
+		podOpts.SetProtectionPolicy(*protection)
+	}
+	if pc.eventSink != nil {
+		podOpts.SetEventSink(pc.eventSink)
+	}
+	if pc.secretUsageTracker != nil {
+		podOpts.SetSecretUsageTracker(pc.secretUsageTracker)
+	}
+	if pc.deregistrationPolicy != nil {
+		podOpts.SetDeregistrationPolicy(*pc.deregistrationPolicy)
+	}
+	if pc.failureHistory != nil {
+		podOpts.SetRunTaskFailureHistory(pc.failureHistory)
+	}
 
 	p, err := NewBasicPod(podOpts)
 	if err != nil {
@@ -175,10 +1036,36 @@ func (pc *BasicPodCreator) createPod(cluster string, task types.Task, def cocoa.
 	return p, nil
 }
 
-// registerTaskDefinition makes the request to register an ECS task definition
-// from the options and checks that it returns a valid task definition.
-func registerTaskDefinition(ctx context.Context, c cocoa.ECSClient, opts cocoa.ECSPodDefinitionOptions) (*types.TaskDefinition, error) {
-	in := exportPodDefinitionOptions(opts)
+// trackSecretUsage records the secrets referenced by the newly-created pod, if
+// the pod creator has a secret usage tracker. If the secrets cannot be tracked,
+// it deletes the pod using the cleanup context so that its secrets are never
+// in use without being tracked.
+func (pc *BasicPodCreator) trackSecretUsage(ctx, cleanupCtx context.Context, p *BasicPod) error {
+	if pc.secretUsageTracker == nil {
+		return nil
+	}
+
+	res := p.Resources()
+	secretIDs := res.SecretIDs()
+	if len(secretIDs) == 0 {
+		return nil
+	}
+
+	taskID := utility.FromStringPtr(res.TaskID)
+	if err := pc.secretUsageTracker.TrackPodSecrets(ctx, taskID, secretIDs); err != nil {
+		catcher := grip.NewBasicCatcher()
+		catcher.Wrapf(err, "tracking secret usage for pod '%s'", taskID)
+		catcher.Wrap(p.Delete(cleanupCtx), "deleting pod with untracked secrets")
+		return catcher.Resolve()
+	}
+
+	return nil
+}
+
+// registerTaskDefinitionInput makes the request to register an ECS task
+// definition from the input and checks that it returns a valid task
+// definition.
+func registerTaskDefinitionInput(ctx context.Context, c cocoa.ECSClient, in *ecs.RegisterTaskDefinitionInput) (*types.TaskDefinition, error) {
 	out, err := c.RegisterTaskDefinition(ctx, in)
 	if err != nil {
 		return nil, errors.Wrap(err, "registering task definition")
@@ -206,11 +1093,23 @@ func validateRegisterTaskDefinitionOutput(out *ecs.RegisterTaskDefinitionOutput)
 // runTask makes the request to run an ECS task from the execution options and
 // task definition and checks that it returns a valid task.
 func (pc *BasicPodCreator) runTask(ctx context.Context, opts cocoa.ECSPodExecutionOptions, def cocoa.ECSTaskDefinition) (*types.Task, error) {
+	if pc.networkValidationOpts != nil && opts.AWSVPCOpts != nil {
+		if err := ValidateAWSVPCNetworking(ctx, *opts.AWSVPCOpts, *pc.networkValidationOpts); err != nil {
+			return nil, errors.Wrap(err, "validating AWSVPC networking")
+		}
+	}
+	if pc.verifySecrets {
+		if err := pc.verifyPodDefinitionSecrets(ctx, def); err != nil {
+			return nil, errors.Wrap(err, "verifying secrets")
+		}
+	}
+
 	in := pc.exportTaskExecutionOptions(opts, def)
 	out, err := pc.client.RunTask(ctx, in)
 	if err != nil {
 		return nil, errors.Wrapf(err, "running task for definition '%s' in cluster '%s'", utility.FromStringPtr(in.TaskDefinition), utility.FromStringPtr(in.Cluster))
 	}
+	recordRunTaskFailures(pc.failureHistory, in, out)
 
 	if err := pc.validateRunTaskOutput(out); err != nil {
 		return nil, errors.Wrap(err, "validating response from running task")
@@ -219,9 +1118,23 @@ func (pc *BasicPodCreator) runTask(ctx context.Context, opts cocoa.ECSPodExecuti
 	return &out.Tasks[0], nil
 }
 
+// verifyPodDefinitionSecrets checks that the secrets referenced by the task
+// definition exist.
+func (pc *BasicPodCreator) verifyPodDefinitionSecrets(ctx context.Context, def cocoa.ECSTaskDefinition) error {
+	describer, ok := pc.vault.(cocoa.SecretDescriber)
+	if !ok {
+		return errors.New("vault cannot describe secrets")
+	}
+	return VerifyPodDefinitionSecrets(ctx, pc.client, describer, utility.FromStringPtr(def.ID))
+}
+
 // validateRunTaskOutput checks that the output from running a task contains no
 // errors and includes the necessary information for the expected tasks.
 func (pc *BasicPodCreator) validateRunTaskOutput(out *ecs.RunTaskOutput) error {
+	if len(out.Failures) == 1 {
+		// A single failure is returned as-is so that it can be classified.
+		return errors.Wrap(ConvertFailureToError(out.Failures[0]), "running task")
+	}
 	if len(out.Failures) > 0 {
 		catcher := grip.NewBasicCatcher()
 		for _, f := range out.Failures {
@@ -240,10 +1153,20 @@ func (pc *BasicPodCreator) validateRunTaskOutput(out *ecs.RunTaskOutput) error {
 	return nil
 }
 
+// defaultSecretCreationConcurrency is the default maximum number of secrets
+// that can be created at once for a single pod definition.
+const defaultSecretCreationConcurrency = 1
+
 // createSecrets creates any necessary secrets from the secret environment
 // variables for each container. Once the secrets are created, their IDs are
-// set.
-func createSecrets(ctx context.Context, v cocoa.Vault, opts *cocoa.ECSPodDefinitionOptions) error {
+// set. Deferred secrets are created with a placeholder value. If multiple
+// containers specify the same new named secret, it is only created once and
+// its ID is shared between them. Up to concurrency secrets are created at
+// once. Any propagated tags are applied to each new secret in addition to the
+// secret's own tags. It returns the IDs of all the secrets that were created,
+// even if it fails partway through creating them.
+func createSecrets(ctx context.Context, v cocoa.Vault, opts *cocoa.ECSPodDefinitionOptions, concurrency int, propagatedTags cocoa.Tags) ([]string, error) {
+	plan := newSecretCreationPlan()
 	var defs []cocoa.ECSContainerDefinition
 	for i, def := range opts.ContainerDefinitions {
 		defs = append(defs, def)
@@ -251,19 +1174,15 @@ func createSecrets(ctx context.Context, v cocoa.Vault, opts *cocoa.ECSPodDefinit
 
 		var envVars []cocoa.EnvironmentVariable
 		for _, envVar := range def.EnvVars {
-			if envVar.SecretOpts == nil || envVar.SecretOpts.NewValue == nil {
+			if envVar.SecretOpts == nil || !mustCreateSecret(*envVar.SecretOpts) {
 				envVars = append(envVars, envVar)
-				defs[i].EnvVars = append(defs[i].EnvVars, envVar)
 				continue
 			}
 
-			id, err := createSecret(ctx, v, *envVar.SecretOpts)
-			if err != nil {
-				return errors.Wrapf(err, "creating secret environment variable '%s' for container '%s'", utility.FromStringPtr(opts.Name), containerName)
+			updated := withPropagatedTags(*envVar.SecretOpts, propagatedTags)
+			if err := plan.add(withDeferredSecretPlaceholder(updated), func(id string) { updated.SetID(id) }, "creating secret environment variable '%s' for container '%s'", utility.FromStringPtr(envVar.Name), containerName); err != nil {
+				return nil, err
 			}
-
-			updated := *envVar.SecretOpts
-			updated.SetID(id)
 			envVar.SecretOpts = &updated
 			envVars = append(envVars, envVar)
 		}
@@ -272,24 +1191,56 @@ func createSecrets(ctx context.Context, v cocoa.Vault, opts *cocoa.ECSPodDefinit
 
 		repoCreds := def.RepoCreds
 		if def.RepoCreds != nil && def.RepoCreds.NewCreds != nil {
-			val, err := json.Marshal(def.RepoCreds.NewCreds)
+			existingID, err := findExistingRepoCreds(ctx, v, *def.RepoCreds)
 			if err != nil {
-				return errors.Wrap(err, "formatting new repository credentials to create")
+				return nil, errors.Wrapf(err, "finding existing repository credentials for container '%s'", containerName)
 			}
-			secretOpts := cocoa.NewSecretOptions().
-				SetName(utility.FromStringPtr(def.RepoCreds.Name)).
-				SetNewValue(string(val))
-			id, err := createSecret(ctx, v, *secretOpts)
-			if err != nil {
-				return errors.Wrapf(err, "creating repository credentials for container '%s'", utility.FromStringPtr(def.Name))
-			}
-
 			updated := *def.RepoCreds
-			updated.SetID(id)
+			if existingID != "" {
+				// The credentials already exist, so they were not created for
+				// this pod and must not be cleaned up with it.
+				updated.SetID(existingID).SetOwned(false)
+			} else {
+				val, err := json.Marshal(def.RepoCreds.NewCreds)
+				if err != nil {
+					return nil, errors.Wrap(err, "formatting new repository credentials to create")
+				}
+				secretOpts := cocoa.NewSecretOptions().
+					SetName(utility.FromStringPtr(def.RepoCreds.Name)).
+					SetNewValue(string(val))
+				if err := plan.add(withPropagatedTags(*secretOpts, propagatedTags), func(id string) { updated.SetID(id) }, "creating repository credentials for container '%s'", containerName); err != nil {
+					return nil, err
+				}
+			}
 			repoCreds = &updated
 		}
 
 		defs[i].RepoCreds = repoCreds
+
+		if def.LogConfiguration != nil && len(def.LogConfiguration.SecretOptions) != 0 {
+			logConfig := *def.LogConfiguration
+			logConfig.SecretOptions = nil
+			for _, opt := range def.LogConfiguration.SecretOptions {
+				if opt.SecretOpts == nil || !mustCreateSecret(*opt.SecretOpts) {
+					logConfig.SecretOptions = append(logConfig.SecretOptions, opt)
+					continue
+				}
+
+				updated := withPropagatedTags(*opt.SecretOpts, propagatedTags)
+				if err := plan.add(withDeferredSecretPlaceholder(updated), func(id string) { updated.SetID(id) }, "creating secret log option '%s' for container '%s'", utility.FromStringPtr(opt.Name), containerName); err != nil {
+					return nil, err
+				}
+				opt.SecretOpts = &updated
+				logConfig.SecretOptions = append(logConfig.SecretOptions, opt)
+			}
+
+			defs[i].LogConfiguration = &logConfig
+		}
+	}
+
+	secretIDs, err := plan.execute(ctx, v, concurrency)
+	if err != nil {
+		return secretIDs, err
 	}
 
 	// Since the options format makes extensive use of pointers and pointers may
@@ -299,18 +1250,188 @@ func createSecrets(ctx context.Context, v cocoa.Vault, opts *cocoa.ECSPodDefinit
 	// memory and avoid mutating the original input's container definitions.
 	opts.ContainerDefinitions = defs
 
+	return secretIDs, nil
+}
+
+// secretCreationPlan collects the new secrets that must be created for a pod
+// definition so that they can be created concurrently. Each new named secret
+// is only created once, and once it's created, its ID is assigned to every
+// place that uses it.
+type secretCreationPlan struct {
+	secrets []plannedSecret
+	byName  map[string]int
+}
+
+// plannedSecret is a new secret that must be created.
+type plannedSecret struct {
+	opts cocoa.SecretOptions
+	// assignments set the ID of the secret wherever it's used once it's
+	// created.
+	assignments []func(id string)
+	// errMsg describes the first use of the secret for error messages.
+	errMsg string
+}
+
+func newSecretCreationPlan() *secretCreationPlan {
+	return &secretCreationPlan{byName: map[string]int{}}
+}
+
+// add adds the secret to the plan along with the function that assigns its ID
+// once it's created. If a secret with the same name was already added, the
+// secret is reused rather than created again. It returns an error if the
+// secret was already added with a different value, ownership, or tags, since
+// only one of them could apply to the shared secret.
+func (p *secretCreationPlan) add(opts cocoa.SecretOptions, assign func(id string), errFormat string, args ...interface{}) error {
+	name := utility.FromStringPtr(opts.Name)
+	if i, ok := p.byName[name]; ok {
+		planned := p.secrets[i].opts
+		if utility.FromStringPtr(planned.NewValue) != utility.FromStringPtr(opts.NewValue) {
+			return errors.Wrapf(errors.Errorf("secret '%s' is specified multiple times with different values", name), errFormat, args...)
+		}
+		if utility.FromBoolPtr(planned.Owned) != utility.FromBoolPtr(opts.Owned) {
+			return errors.Wrapf(errors.Errorf("secret '%s' is specified multiple times with different ownership", name), errFormat, args...)
+		}
+		if !equalTags(planned.Tags, opts.Tags) {
+			return errors.Wrapf(errors.Errorf("secret '%s' is specified multiple times with different tags", name), errFormat, args...)
+		}
+		p.secrets[i].assignments = append(p.secrets[i].assignments, assign)
+		return nil
+	}
+
+	p.byName[name] = len(p.secrets)
+	p.secrets = append(p.secrets, plannedSecret{
+		opts:        opts,
+		assignments: []func(id string){assign},
+		errMsg:      fmt.Sprintf(errFormat, args...),
+	})
 	return nil
 }
 
+// equalTags returns whether or not the two sets of tags contain the same
+// key-value pairs.
+func equalTags(a, b cocoa.Tags) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, va := range a {
+		if vb, ok := b[k]; !ok || va != vb {
+			return false
+		}
+	}
+	return true
+}
+
+// execute creates all the planned secrets with up to the given number of
+// secrets being created at once. Once a secret fails to be created, no more
+// secrets are started. If all of them are created successfully, it assigns
+// their IDs. It returns the IDs of all the secrets that were created in the
+// order that they were added to the plan, even if some of them failed.
+func (p *secretCreationPlan) execute(ctx context.Context, v cocoa.Vault, concurrency int) ([]string, error) {
+	if concurrency <= 0 {
+		concurrency = defaultSecretCreationConcurrency
+	}
+
+	ids := make([]string, len(p.secrets))
+	errs := make([]error, len(p.secrets))
+	var failed int32
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := range p.secrets {
+		sem <- struct{}{}
+		if atomic.LoadInt32(&failed) != 0 {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			id, err := createSecret(ctx, v, p.secrets[i].opts)
+			if err != nil {
+				atomic.StoreInt32(&failed, 1)
+				errs[i] = errors.Wrap(err, p.secrets[i].errMsg)
+				return
+			}
+			ids[i] = id
+		}(i)
+	}
+	wg.Wait()
+
+	var secretIDs []string
+	catcher := grip.NewBasicCatcher()
+	for i := range p.secrets {
+		if ids[i] != "" {
+			secretIDs = append(secretIDs, ids[i])
+		}
+		catcher.Add(errs[i])
+	}
+	if catcher.HasErrors() {
+		return secretIDs, catcher.Resolve()
+	}
+
+	for i, secret := range p.secrets {
+		for _, assign := range secret.assignments {
+			assign(ids[i])
+		}
+	}
+
+	return secretIDs, nil
+}
+
+// contextWithAssumeRole returns a context that makes the AWS clients assume the
+// role for the calls that use it. If there is no role to assume, it returns the
+// original context.
+func contextWithAssumeRole(ctx context.Context, opts *cocoa.AssumeRoleOptions) context.Context {
+	if opts == nil {
+		return ctx
+	}
+	return cocoa.ContextWithAssumeRole(ctx, *opts)
+}
+
+// newPartialCreationErrorIfCreated returns a partial creation error wrapping
+// the given error if any resources were created. Otherwise, it returns the
+// original error.
+func newPartialCreationErrorIfCreated(err error, secretIDs []string, taskDefID string) error {
+	if len(secretIDs) == 0 && taskDefID == "" {
+		return err
+	}
+	return cocoa.NewPartialCreationError(err, secretIDs, taskDefID)
+}
+
+// findExistingRepoCreds returns the ID of the existing secret containing the
+// repository credentials if the credentials should only be created when
+// missing. If the credentials must be created, it returns an empty ID.
+func findExistingRepoCreds(ctx context.Context, v cocoa.Vault, creds cocoa.RepositoryCredentials) (id string, err error) {
+	if !utility.FromBoolPtr(creds.CreateIfMissing) {
+		return "", nil
+	}
+	if v == nil {
+		return "", errors.New("no vault was specified")
+	}
+	finder, ok := v.(cocoa.SecretFinder)
+	if !ok {
+		return "", errors.New("vault does not support finding existing secrets")
+	}
+	return finder.FindSecretID(ctx, utility.FromStringPtr(creds.Name))
+}
+
 // createSecret creates a single secret. It returns the newly-created secret's
 // ID.
 func createSecret(ctx context.Context, v cocoa.Vault, secret cocoa.SecretOptions) (id string, err error) {
 	if v == nil {
 		return "", errors.New("no vault was specified")
 	}
+	if err := ctx.Err(); err != nil {
+		return "", errors.Wrap(err, "context is done")
+	}
 	return v.CreateSecret(ctx, *cocoa.NewNamedSecret().
 		SetName(utility.FromStringPtr(secret.Name)).
-		SetValue(utility.FromStringPtr(secret.NewValue)))
+		SetValue(utility.FromStringPtr(secret.NewValue)).
+		SetTags(secret.Tags))
 }
 
 // ExportTags converts a mapping of tag names to values into ECS tags.
@@ -327,6 +1448,15 @@ func ExportTags(tags map[string]string) []types.Tag {
 	return ecsTags
 }
 
+// importTags converts ECS tags into a mapping of tag names to values.
+func importTags(ecsTags []types.Tag) cocoa.Tags {
+	tags := cocoa.NewTags()
+	for _, tag := range ecsTags {
+		tags.Set(utility.FromStringPtr(tag.Key), utility.FromStringPtr(tag.Value))
+	}
+	return tags
+}
+
 // exportOverrides converts options to override the pod definition into its
 // equivalent ECS task override options.
 func (pc *BasicPodCreator) exportOverrides(opts *cocoa.ECSOverridePodDefinitionOptions) *types.TaskOverride {
@@ -380,19 +1510,22 @@ func (pc *BasicPodCreator) exportOverrideContainerDefinitions(defs []cocoa.ECSOv
 }
 
 // exportStrategy converts the strategy and parameter into an ECS placement
-// strategy.
-func (pc *BasicPodCreator) exportStrategy(opts *cocoa.ECSPodPlacementOptions) []types.PlacementStrategy {
+// strategy. If no strategy should be used, it returns nil.
+func exportStrategy(opts cocoa.ECSPodExecutionOptions) []types.PlacementStrategy {
+	if !opts.UsesPlacementStrategy() {
+		return nil
+	}
 	return []types.PlacementStrategy{
 		{
-			Type:  types.PlacementStrategyType(*opts.Strategy),
-			Field: opts.StrategyParameter,
+			Type:  types.PlacementStrategyType(*opts.PlacementOpts.Strategy),
+			Field: opts.PlacementOpts.StrategyParameter,
 		},
 	}
 }
 
 // exportPlacementConstraints converts the placement options into ECS placement
 // constraints.
-func (pc *BasicPodCreator) exportPlacementConstraints(opts *cocoa.ECSPodPlacementOptions) []types.PlacementConstraint {
+func exportPlacementConstraints(opts *cocoa.ECSPodPlacementOptions) []types.PlacementConstraint {
 	var constraints []types.PlacementConstraint
 
 	for _, filter := range opts.InstanceFilters {
@@ -406,6 +1539,13 @@ func (pc *BasicPodCreator) exportPlacementConstraints(opts *cocoa.ECSPodPlacemen
 		constraints = append(constraints, constraint)
 	}
 
+	if opts.ImagePullBehavior != nil {
+		constraints = append(constraints, types.PlacementConstraint{
+			Type:       "memberOf",
+			Expression: aws.String("attribute:" + cocoa.ImagePullBehaviorAttribute + " == " + string(*opts.ImagePullBehavior)),
+		})
+	}
+
 	return constraints
 }
 
@@ -469,30 +1609,7 @@ func (pc *BasicPodCreator) translateContainerSecrets(defs []cocoa.ECSContainerDe
 	var translated []cocoa.ContainerSecret
 
 	for _, def := range defs {
-		for _, envVar := range def.EnvVars {
-			if envVar.SecretOpts == nil {
-				continue
-			}
-
-			cs := cocoa.NewContainerSecret().
-				SetID(utility.FromStringPtr(envVar.SecretOpts.ID)).
-				SetOwned(utility.FromBoolPtr(envVar.SecretOpts.Owned))
-			if name := utility.FromStringPtr(envVar.SecretOpts.Name); name != "" {
-				cs.SetName(name)
-			}
-			translated = append(translated, *cs)
-		}
-
-		if def.RepoCreds != nil {
-			cs := cocoa.NewContainerSecret().
-				SetID(utility.FromStringPtr(def.RepoCreds.ID)).
-				SetOwned(utility.FromBoolPtr(def.RepoCreds.Owned))
-			if name := utility.FromStringPtr(def.RepoCreds.Name); name != "" {
-				cs.SetName(name)
-			}
-			translated = append(translated, *cs)
-		}
-
+		translated = append(translated, def.ContainerSecrets()...)
 	}
 
 	return translated
@@ -502,9 +1619,16 @@ func (pc *BasicPodCreator) translateContainerSecrets(defs []cocoa.ECSContainerDe
 // status information.
 func translatePodStatusInfo(task types.Task) cocoa.ECSPodStatusInfo {
 	lastStatus := TaskStatus(utility.FromStringPtr(task.LastStatus)).ToCocoaStatus()
-	return *cocoa.NewECSPodStatusInfo().
+	statusInfo := cocoa.NewECSPodStatusInfo().
 		SetStatus(lastStatus).
 		SetContainers(translateContainerStatusInfo(task.Containers))
+	statusInfo.PullStartedAt = task.PullStartedAt
+	statusInfo.PullStoppedAt = task.PullStoppedAt
+	statusInfo.StartedAt = task.StartedAt
+	statusInfo.StoppingAt = task.StoppingAt
+	statusInfo.ExecutionStoppedAt = task.ExecutionStoppedAt
+	statusInfo.RetirementNotice = DetectTaskRetirement(task)
+	return *statusInfo
 }
 
 // translateContainerStatusInfo translates an ECS container to its equivalent
@@ -518,6 +1642,9 @@ func translateContainerStatusInfo(containers []types.Container) []cocoa.ECSConta
 			SetContainerID(utility.FromStringPtr(container.ContainerArn)).
 			SetName(utility.FromStringPtr(container.Name)).
 			SetStatus(lastStatus)
+		if container.ExitCode != nil {
+			status.SetExitCode(int(*container.ExitCode))
+		}
 		statuses = append(statuses, *status)
 	}
 
@@ -529,6 +1656,7 @@ func translateContainerStatusInfo(containers []types.Container) []cocoa.ECSConta
 func exportPodDefinitionOptions(opts cocoa.ECSPodDefinitionOptions) *ecs.RegisterTaskDefinitionInput {
 	taskDef := ecs.RegisterTaskDefinitionInput{
 		ContainerDefinitions: exportContainerDefinitions(opts.ContainerDefinitions),
+		Volumes:              exportBindMountVolumes(opts.ContainerDefinitions),
 		Family:               opts.Name,
 		Tags:                 ExportTags(opts.Tags),
 		TaskRoleArn:          opts.TaskRole,
@@ -565,6 +1693,7 @@ func exportContainerDefinitions(defs []cocoa.ECSContainerDefinition) []types.Con
 			LogConfiguration:      exportLogConfiguration(def.LogConfiguration),
 			RepositoryCredentials: exportRepoCreds(def.RepoCreds),
 			PortMappings:          exportPortMappings(def.PortMappings),
+			MountPoints:           exportMountPoints(def.BindMounts),
 		}
 		if mem := utility.FromIntPtr(def.MemoryMB); mem != 0 {
 			containerDef.Memory = aws.Int32(int32(mem))
@@ -575,6 +1704,31 @@ func exportContainerDefinitions(defs []cocoa.ECSContainerDefinition) []types.Con
 		if dir := utility.FromStringPtr(def.WorkingDir); dir != "" {
 			containerDef.WorkingDirectory = aws.String(dir)
 		}
+		if utility.FromBoolPtr(def.Interactive) {
+			containerDef.Interactive = aws.Bool(true)
+		}
+		if utility.FromBoolPtr(def.PseudoTerminal) {
+			containerDef.PseudoTerminal = aws.Bool(true)
+		}
+		if hostname := utility.FromStringPtr(def.Hostname); hostname != "" {
+			containerDef.Hostname = aws.String(hostname)
+		}
+		if user := utility.FromStringPtr(def.User); user != "" {
+			containerDef.User = aws.String(user)
+		}
+		for _, h := range def.ExtraHosts {
+			containerDef.ExtraHosts = append(containerDef.ExtraHosts, types.HostEntry{
+				Hostname:  h.Hostname,
+				IpAddress: h.IPAddress,
+			})
+		}
+		for _, vf := range def.VolumesFrom {
+			containerDef.VolumesFrom = append(containerDef.VolumesFrom, types.VolumeFrom{
+				SourceContainer: vf.SourceContainer,
+				ReadOnly:        aws.Bool(utility.FromBoolPtr(vf.ReadOnly)),
+			})
+		}
+		containerDef.LinuxParameters = exportLinuxParameters(def)
 
 		containerDefs = append(containerDefs, containerDef)
 	}
@@ -582,19 +1736,43 @@ func exportContainerDefinitions(defs []cocoa.ECSContainerDefinition) []types.Con
 	return containerDefs
 }
 
+// exportLinuxParameters exports the container definition's Linux-specific
+// settings into ECS Linux parameters. If the container definition has no
+// Linux-specific settings, this returns nil.
+func exportLinuxParameters(def cocoa.ECSContainerDefinition) *types.LinuxParameters {
+	if len(def.TmpfsMounts) == 0 && def.SharedMemorySizeMB == nil {
+		return nil
+	}
+
+	var params types.LinuxParameters
+	for _, m := range def.TmpfsMounts {
+		params.Tmpfs = append(params.Tmpfs, types.Tmpfs{
+			ContainerPath: m.ContainerPath,
+			Size:          int32(utility.FromIntPtr(m.SizeMB)),
+			MountOptions:  m.MountOptions,
+		})
+	}
+	if def.SharedMemorySizeMB != nil {
+		params.SharedMemorySize = aws.Int32(int32(*def.SharedMemorySizeMB))
+	}
+
+	return &params
+}
+
 // exportLogConfiguration exports the log configuration into ECS log configuration.
 func exportLogConfiguration(logConfiguration *cocoa.LogConfiguration) *types.LogConfiguration {
 	if logConfiguration == nil {
 		return nil
 	}
 
-	options := map[string]string{}
-	for k, v := range logConfiguration.Options {
-		options[k] = v
+	options := logConfiguration.GetOptions()
+	if options == nil {
+		options = map[string]string{}
 	}
 	return &types.LogConfiguration{
-		LogDriver: types.LogDriver(utility.FromStringPtr(logConfiguration.LogDriver)),
-		Options:   options,
+		LogDriver:     types.LogDriver(utility.FromStringPtr(logConfiguration.LogDriver)),
+		Options:       options,
+		SecretOptions: exportSecrets(logConfiguration.SecretOptions),
 	}
 }
 
@@ -612,14 +1790,14 @@ func exportRepoCreds(creds *cocoa.RepositoryCredentials) *types.RepositoryCreden
 func (pc *BasicPodCreator) exportTaskExecutionOptions(opts cocoa.ECSPodExecutionOptions, taskDef cocoa.ECSTaskDefinition) *ecs.RunTaskInput {
 	runTask := ecs.RunTaskInput{
 		Cluster:                  opts.Cluster,
-		CapacityProviderStrategy: pc.exportCapacityProvider(opts.CapacityProvider),
+		CapacityProviderStrategy: exportCapacityProvider(opts.CapacityProvider),
 		TaskDefinition:           taskDef.ID,
-		Tags:                     ExportTags(opts.Tags),
+		Tags:                     ExportTags(opts.RunTags(time.Now())),
 		EnableExecuteCommand:     utility.FromBoolPtr(opts.SupportsDebugMode),
 		Overrides:                pc.exportOverrides(opts.OverrideOpts),
-		PlacementStrategy:        pc.exportStrategy(opts.PlacementOpts),
-		PlacementConstraints:     pc.exportPlacementConstraints(opts.PlacementOpts),
-		NetworkConfiguration:     pc.exportAWSVPCOptions(opts.AWSVPCOpts),
+		PlacementStrategy:        exportStrategy(opts),
+		PlacementConstraints:     exportPlacementConstraints(opts.PlacementOpts),
+		NetworkConfiguration:     exportAWSVPCOptions(opts.AWSVPCOpts),
 	}
 	if opts.PlacementOpts != nil {
 		runTask.Group = opts.PlacementOpts.Group
@@ -629,7 +1807,7 @@ func (pc *BasicPodCreator) exportTaskExecutionOptions(opts cocoa.ECSPodExecution
 
 // exportCapacityProvider converts the capacity provider name into an ECS
 // capacity provider strategy.
-func (pc *BasicPodCreator) exportCapacityProvider(provider *string) []types.CapacityProviderStrategyItem {
+func exportCapacityProvider(provider *string) []types.CapacityProviderStrategyItem {
 	if provider == nil {
 		return nil
 	}
@@ -643,14 +1821,62 @@ func exportPortMappings(mappings []cocoa.PortMapping) []types.PortMapping {
 		mapping := types.PortMapping{
 			ContainerPort: aws.Int32(int32(utility.FromIntPtr(pm.ContainerPort))),
 			HostPort:      aws.Int32(int32(utility.FromIntPtr(pm.HostPort))),
+			Name:          pm.Name,
+		}
+		if pm.Protocol != nil {
+			mapping.Protocol = types.TransportProtocol(*pm.Protocol)
 		}
 		converted = append(converted, mapping)
 	}
 	return converted
 }
 
+// exportBindMountVolumes converts the bind mounts for all the container
+// definitions into ECS host volumes. Containers that mount the same source path
+// share a single volume.
+func exportBindMountVolumes(defs []cocoa.ECSContainerDefinition) []types.Volume {
+	var volumes []types.Volume
+	seen := map[string]bool{}
+	for _, def := range defs {
+		for _, bm := range def.BindMounts {
+			sourcePath := utility.FromStringPtr(bm.SourcePath)
+			if seen[sourcePath] {
+				continue
+			}
+			seen[sourcePath] = true
+			volumes = append(volumes, types.Volume{
+				Name: aws.String(bindMountVolumeName(sourcePath)),
+				Host: &types.HostVolumeProperties{SourcePath: aws.String(sourcePath)},
+			})
+		}
+	}
+	return volumes
+}
+
+// exportMountPoints converts bind mounts into ECS mount points.
+func exportMountPoints(mounts []cocoa.BindMount) []types.MountPoint {
+	var converted []types.MountPoint
+	for _, bm := range mounts {
+		converted = append(converted, types.MountPoint{
+			SourceVolume:  aws.String(bindMountVolumeName(utility.FromStringPtr(bm.SourcePath))),
+			ContainerPath: bm.ContainerPath,
+			ReadOnly:      aws.Bool(utility.FromBoolPtr(bm.ReadOnly)),
+		})
+	}
+	return converted
+}
+
+// bindMountVolumeName returns the deterministic name of the ECS volume for the
+// bind mount's source path. Source paths can contain characters that are not
+// allowed in volume names, so the name is derived from its hash.
+func bindMountVolumeName(sourcePath string) string {
+	h := utility.NewSHA1Hash()
+	h.Add(sourcePath)
+	return "bind-" + h.Sum()
+}
+
 // exportAWSVPCOptions converts AWSVPC options into ECS AWSVPC options.
-func (pc *BasicPodCreator) exportAWSVPCOptions(opts *cocoa.AWSVPCOptions) *types.NetworkConfiguration {
+func exportAWSVPCOptions(opts *cocoa.AWSVPCOptions) *types.NetworkConfiguration {
 	if opts == nil {
 		return nil
 	}
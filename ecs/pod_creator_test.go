@@ -2,7 +2,10 @@ package ecs
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/evergreen-ci/cocoa"
@@ -10,6 +13,7 @@ import (
 	"github.com/evergreen-ci/cocoa/internal/testutil"
 	"github.com/evergreen-ci/cocoa/secret"
 	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -53,6 +57,74 @@ func TestBasicPodCreator(t *testing.T) {
 			require.NoError(t, err)
 			require.NotZero(t, podCreator)
 		},
+		"NewPodCreatorSucceedsWithClientOptions": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).SetVault(v))
+			require.NoError(t, err)
+			require.NotZero(t, podCreator)
+			assert.NotZero(t, podCreator.client)
+			assert.Equal(t, podCreator.ownedClient, podCreator.client)
+		},
+		"NewPodCreatorFailsWithClientAndClientOptions": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClient(c).SetClientOptions(testutil.ValidNonIntegrationAWSOptions()))
+			require.Error(t, err)
+			require.Zero(t, podCreator)
+		},
+		"CloseClosesOwnedClient": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClientOptions(testutil.ValidNonIntegrationAWSOptions()))
+			require.NoError(t, err)
+			require.NotZero(t, podCreator.ownedClient)
+			assert.NoError(t, podCreator.Close(ctx))
+			assert.NoError(t, podCreator.Close(ctx), "closing should be idempotent")
+		},
+		"NewPodCreatorDefaultsPrewarmConcurrency": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+			assert.Equal(t, defaultPrewarmConcurrency, podCreator.prewarmConcurrency)
+		},
+		"NewPodCreatorSucceedsWithPrewarmConcurrency": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClient(c).SetPrewarmConcurrency(10))
+			require.NoError(t, err)
+			assert.Equal(t, 10, podCreator.prewarmConcurrency)
+		},
+		"NewPodCreatorFailsWithNonPositivePrewarmConcurrency": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClient(c).SetPrewarmConcurrency(0))
+			assert.Error(t, err)
+			assert.Zero(t, podCreator)
+		},
+		"NewPodCreatorSucceedsWithPodDefinitionTagName": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClient(c).SetPodDefinitionTagName("tag"))
+			require.NoError(t, err)
+			assert.Equal(t, "tag", utility.FromStringPtr(podCreator.podDefinitionTagName))
+		},
+		"NewPodCreatorFailsWithInvalidPodDefinitionTagName": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClient(c).SetPodDefinitionTagName("aws:tag"))
+			assert.Error(t, err)
+			assert.Zero(t, podCreator)
+		},
+		"NewPodCreatorFailsWithInvalidDeregistrationPolicy": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClient(c).SetDeregistrationPolicy("foo"))
+			assert.Error(t, err)
+			assert.Zero(t, podCreator)
+		},
+		"NewPodCreatorSucceedsWithStageTimeouts": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			timeouts := NewPodCreationStageTimeouts().SetRun(time.Minute).SetSecrets(time.Second)
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClient(c).SetStageTimeouts(*timeouts))
+			require.NoError(t, err)
+			require.NotZero(t, podCreator.stageTimeouts)
+			assert.Equal(t, *timeouts, *podCreator.stageTimeouts)
+		},
+		"NewPodCreatorFailsWithNonPositiveStageTimeout": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			timeouts := NewPodCreationStageTimeouts().SetDefinition(0)
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClient(c).SetStageTimeouts(*timeouts))
+			assert.Error(t, err)
+			assert.Zero(t, podCreator)
+		},
+		"CloseDoesNotCloseGivenClient": func(ctx context.Context, t *testing.T, c cocoa.ECSClient, v cocoa.Vault, pdc cocoa.ECSPodDefinitionCache) {
+			podCreator, err := NewBasicPodCreator(*NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+			assert.Zero(t, podCreator.ownedClient)
+			assert.NoError(t, podCreator.Close(ctx))
+		},
 	} {
 		t.Run(tName, func(t *testing.T) {
 			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
@@ -149,3 +221,148 @@ func TestECSPodCreator(t *testing.T) {
 		})
 	}
 }
+
+// concurrencyTrackingVault is a vault that records how many secrets are
+// created at once.
+type concurrencyTrackingVault struct {
+	cocoa.Vault
+
+	mu       sync.Mutex
+	inFlight int
+	maxSeen  int
+	created  map[string]string
+	failName string
+}
+
+func (v *concurrencyTrackingVault) CreateSecret(ctx context.Context, s cocoa.NamedSecret) (string, error) {
+	name := utility.FromStringPtr(s.Name)
+
+	v.mu.Lock()
+	v.inFlight++
+	if v.inFlight > v.maxSeen {
+		v.maxSeen = v.inFlight
+	}
+	v.mu.Unlock()
+
+	// Hold the secret creation open briefly so that concurrent creations
+	// overlap.
+	time.Sleep(10 * time.Millisecond)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.inFlight--
+	if name == v.failName {
+		return "", errors.New("fake error")
+	}
+	id := "id-" + name
+	v.created[id] = utility.FromStringPtr(s.Value)
+	return id, nil
+}
+
+func TestCreateSecrets(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	makeOpts := func() cocoa.ECSPodDefinitionOptions {
+		var envVars []cocoa.EnvironmentVariable
+		for i := 0; i < 6; i++ {
+			envVars = append(envVars, *cocoa.NewEnvironmentVariable().
+				SetName(fmt.Sprintf("env%d", i)).
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(fmt.Sprintf("secret%d", i)).
+					SetNewValue(fmt.Sprintf("value%d", i))))
+		}
+		shared := *cocoa.NewEnvironmentVariable().
+			SetName("shared").
+			SetSecretOptions(*cocoa.NewSecretOptions().
+				SetName("secret0").
+				SetNewValue("value0"))
+		return *cocoa.NewECSPodDefinitionOptions().AddContainerDefinitions(
+			*cocoa.NewECSContainerDefinition().SetName("c0").AddEnvironmentVariables(envVars[:3]...),
+			*cocoa.NewECSContainerDefinition().SetName("c1").AddEnvironmentVariables(append(envVars[3:], shared)...),
+		)
+	}
+
+	t.Run("CreatesSecretsConcurrentlyAndAssignsIDs", func(t *testing.T) {
+		v := &concurrencyTrackingVault{created: map[string]string{}}
+		opts := makeOpts()
+
+		ids, err := createSecrets(ctx, v, &opts, 3, nil)
+		require.NoError(t, err)
+		assert.Len(t, ids, 6, "shared secret should only be created once")
+		assert.LessOrEqual(t, v.maxSeen, 3)
+		assert.Greater(t, v.maxSeen, 1, "secrets should have been created concurrently")
+
+		for _, def := range opts.ContainerDefinitions {
+			for _, envVar := range def.EnvVars {
+				require.NotZero(t, envVar.SecretOpts)
+				id := utility.FromStringPtr(envVar.SecretOpts.ID)
+				assert.Equal(t, "id-"+utility.FromStringPtr(envVar.SecretOpts.Name), id)
+				assert.Equal(t, utility.FromStringPtr(envVar.SecretOpts.NewValue), v.created[id])
+			}
+		}
+	})
+	t.Run("CreatesSecretsOneAtATimeWithConcurrencyOfOne", func(t *testing.T) {
+		v := &concurrencyTrackingVault{created: map[string]string{}}
+		opts := makeOpts()
+
+		ids, err := createSecrets(ctx, v, &opts, 1, nil)
+		require.NoError(t, err)
+		assert.Len(t, ids, 6)
+		assert.Equal(t, 1, v.maxSeen)
+	})
+	t.Run("ReturnsCreatedSecretsOnFailureWithoutModifyingOptions", func(t *testing.T) {
+		v := &concurrencyTrackingVault{created: map[string]string{}, failName: "secret2"}
+		opts := makeOpts()
+		original := makeOpts()
+
+		ids, err := createSecrets(ctx, v, &opts, 1, nil)
+		assert.Error(t, err)
+		assert.Equal(t, []string{"id-secret0", "id-secret1"}, ids)
+		assert.Equal(t, original, opts)
+	})
+	t.Run("FailsWithConflictingSecretValuesBeforeCreatingAny", func(t *testing.T) {
+		v := &concurrencyTrackingVault{created: map[string]string{}}
+		opts := makeOpts()
+		opts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+			SetName("conflict").
+			SetSecretOptions(*cocoa.NewSecretOptions().
+				SetName("secret1").
+				SetNewValue("other")))
+
+		ids, err := createSecrets(ctx, v, &opts, 3, nil)
+		assert.Error(t, err)
+		assert.Empty(t, ids)
+		assert.Empty(t, v.created)
+	})
+	t.Run("FailsWithConflictingSecretOwnershipBeforeCreatingAny", func(t *testing.T) {
+		v := &concurrencyTrackingVault{created: map[string]string{}}
+		opts := makeOpts()
+		opts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+			SetName("conflict").
+			SetSecretOptions(*cocoa.NewSecretOptions().
+				SetName("secret1").
+				SetNewValue("value1").
+				SetOwned(true)))
+
+		ids, err := createSecrets(ctx, v, &opts, 3, nil)
+		assert.Error(t, err)
+		assert.Empty(t, ids)
+		assert.Empty(t, v.created)
+	})
+	t.Run("FailsWithConflictingSecretTagsBeforeCreatingAny", func(t *testing.T) {
+		v := &concurrencyTrackingVault{created: map[string]string{}}
+		opts := makeOpts()
+		opts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+			SetName("conflict").
+			SetSecretOptions(*cocoa.NewSecretOptions().
+				SetName("secret1").
+				SetNewValue("value1").
+				SetTags(map[string]string{"key": "value"})))
+
+		ids, err := createSecrets(ctx, v, &opts, 3, nil)
+		assert.Error(t, err)
+		assert.Empty(t, ids)
+		assert.Empty(t, v.created)
+	})
+}
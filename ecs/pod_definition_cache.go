@@ -0,0 +1,276 @@
+package ecs
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// defaultMemoryPodDefinitionCacheCapacity is the default maximum number of pod
+// definitions that an in-memory pod definition cache holds.
+const defaultMemoryPodDefinitionCacheCapacity = 1000
+
+// MemoryPodDefinitionCache provides a cocoa.ECSPodDefinitionRunCache
+// implementation that keeps pod definitions in memory. It holds up to a fixed
+// number of pod definitions and evicts the least recently used ones to make
+// room for new ones. Pod definitions can also expire after a fixed amount of
+// time. It is safe for concurrent use, but it is only shared within a single
+// process, so it is intended for single-process applications and tests.
+type MemoryPodDefinitionCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	tag      string
+	// items are the cached pod definitions, ordered from most to least
+	// recently used.
+	items *list.List
+	// itemsByID are the elements in items by pod definition ID.
+	itemsByID map[string]*list.Element
+	// runs are the pod definitions by the external key of their last run.
+	runs map[string]memoryPodDefinitionCacheEntry
+	// now returns the current time. This can be replaced for testing.
+	now func() time.Time
+}
+
+// memoryPodDefinitionCacheEntry is a pod definition in the in-memory cache.
+type memoryPodDefinitionCacheEntry struct {
+	item cocoa.ECSPodDefinitionItem
+	// expires is when the entry expires. This is zero if the entry never
+	// expires.
+	expires time.Time
+}
+
+// MemoryPodDefinitionCacheOptions are options to create an in-memory pod
+// definition cache.
+type MemoryPodDefinitionCacheOptions struct {
+	// Capacity is the maximum number of pod definitions that the cache can
+	// hold. When the cache is full, the least recently used pod definition is
+	// evicted. By default, this is defaultMemoryPodDefinitionCacheCapacity.
+	Capacity *int
+	// TTL, if specified, is how long a pod definition stays in the cache
+	// after it was last put in the cache. By default, pod definitions do not
+	// expire.
+	TTL *time.Duration
+	// Tag is the name of the tracking tag to use for pod definitions. By
+	// default, there is no tag.
+	Tag *string
+}
+
+// NewMemoryPodDefinitionCacheOptions returns new uninitialized options to
+// create an in-memory pod definition cache.
+func NewMemoryPodDefinitionCacheOptions() *MemoryPodDefinitionCacheOptions {
+	return &MemoryPodDefinitionCacheOptions{}
+}
+
+// SetCapacity sets the maximum number of pod definitions that the cache can
+// hold.
+func (o *MemoryPodDefinitionCacheOptions) SetCapacity(capacity int) *MemoryPodDefinitionCacheOptions {
+	o.Capacity = &capacity
+	return o
+}
+
+// SetTTL sets how long a pod definition stays in the cache after it was last
+// put in the cache.
+func (o *MemoryPodDefinitionCacheOptions) SetTTL(ttl time.Duration) *MemoryPodDefinitionCacheOptions {
+	o.TTL = &ttl
+	return o
+}
+
+// SetTag sets the name of the tracking tag to use for pod definitions.
+func (o *MemoryPodDefinitionCacheOptions) SetTag(tag string) *MemoryPodDefinitionCacheOptions {
+	o.Tag = &tag
+	return o
+}
+
+// Validate checks that the capacity and TTL are positive if they're given and
+// sets defaults where possible.
+func (o *MemoryPodDefinitionCacheOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.Capacity != nil && *o.Capacity <= 0, "must specify a positive capacity")
+	catcher.NewWhen(o.TTL != nil && *o.TTL <= 0, "must specify a positive TTL")
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	if o.Capacity == nil {
+		o.SetCapacity(defaultMemoryPodDefinitionCacheCapacity)
+	}
+
+	return nil
+}
+
+// NewMemoryPodDefinitionCache creates a new in-memory pod definition cache.
+func NewMemoryPodDefinitionCache(opts MemoryPodDefinitionCacheOptions) (*MemoryPodDefinitionCache, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid options")
+	}
+	var ttl time.Duration
+	if opts.TTL != nil {
+		ttl = *opts.TTL
+	}
+	return &MemoryPodDefinitionCache{
+		capacity:  utility.FromIntPtr(opts.Capacity),
+		ttl:       ttl,
+		tag:       utility.FromStringPtr(opts.Tag),
+		items:     list.New(),
+		itemsByID: map[string]*list.Element{},
+		runs:      map[string]memoryPodDefinitionCacheEntry{},
+		now:       time.Now,
+	}, nil
+}
+
+// Put adds a new pod definition item or updates an existing one. If the cache
+// is full, it evicts the least recently used pod definition.
+func (c *MemoryPodDefinitionCache) Put(_ context.Context, item cocoa.ECSPodDefinitionItem) error {
+	if item.ID == "" {
+		return errors.New("must specify a non-empty ID")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(item)
+
+	return nil
+}
+
+// put adds or updates the pod definition item as the most recently used one.
+func (c *MemoryPodDefinitionCache) put(item cocoa.ECSPodDefinitionItem) {
+	entry := memoryPodDefinitionCacheEntry{
+		item:    item,
+		expires: c.expiration(),
+	}
+	if elem, ok := c.itemsByID[item.ID]; ok {
+		elem.Value = entry
+		c.items.MoveToFront(elem)
+		return
+	}
+
+	c.itemsByID[item.ID] = c.items.PushFront(entry)
+	for c.items.Len() > c.capacity {
+		c.remove(c.items.Back())
+	}
+}
+
+// Get returns the pod definition item with the given ID and marks it as
+// recently used. If it is not in the cache or it has expired, it returns nil.
+func (c *MemoryPodDefinitionCache) Get(_ context.Context, id string) (*cocoa.ECSPodDefinitionItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.itemsByID[id]
+	if !ok {
+		return nil, nil
+	}
+	entry := elem.Value.(memoryPodDefinitionCacheEntry)
+	if c.isExpired(entry) {
+		c.remove(elem)
+		return nil, nil
+	}
+
+	c.items.MoveToFront(elem)
+	item := entry.item
+	return &item, nil
+}
+
+// Delete deletes the pod definition item by its unique identifier in ECS along
+// with any pod runs recorded for it. Deleting an item that is not in the cache
+// is a no-op.
+func (c *MemoryPodDefinitionCache) Delete(_ context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.itemsByID[id]; ok {
+		c.remove(elem)
+	}
+
+	return nil
+}
+
+// GetTag returns the name of the tracking tag to use for the pod definition.
+func (c *MemoryPodDefinitionCache) GetTag() string {
+	return c.tag
+}
+
+// PutRun records the item's last run for the external key, replacing any run
+// previously recorded for the same key. It also adds or updates the pod
+// definition item.
+func (c *MemoryPodDefinitionCache) PutRun(_ context.Context, item cocoa.ECSPodDefinitionItem) error {
+	if item.ID == "" {
+		return errors.New("must specify a non-empty ID")
+	}
+	if item.LastRun == nil || item.LastRun.Key == "" {
+		return errors.New("must specify a last run with a non-empty key")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.put(item)
+	c.runs[item.LastRun.Key] = memoryPodDefinitionCacheEntry{
+		item:    item,
+		expires: c.expiration(),
+	}
+
+	return nil
+}
+
+// GetRun returns the item whose last run was for the given external key. If no
+// run is recorded for the key, or the run's pod definition has been evicted or
+// has expired, it returns nil.
+func (c *MemoryPodDefinitionCache) GetRun(_ context.Context, key string) (*cocoa.ECSPodDefinitionItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.runs[key]
+	if !ok {
+		return nil, nil
+	}
+	if c.isExpired(entry) {
+		delete(c.runs, key)
+		return nil, nil
+	}
+
+	item := entry.item
+	return &item, nil
+}
+
+// Len returns the number of pod definitions in the cache, including any that
+// have expired but have not been removed yet.
+func (c *MemoryPodDefinitionCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.items.Len()
+}
+
+// remove removes the pod definition from the cache along with any pod runs
+// recorded for it.
+func (c *MemoryPodDefinitionCache) remove(elem *list.Element) {
+	entry := c.items.Remove(elem).(memoryPodDefinitionCacheEntry)
+	delete(c.itemsByID, entry.item.ID)
+	for key, run := range c.runs {
+		if run.item.ID == entry.item.ID {
+			delete(c.runs, key)
+		}
+	}
+}
+
+// expiration returns when a pod definition put in the cache now expires. If
+// the cache has no TTL, this is zero.
+func (c *MemoryPodDefinitionCache) expiration() time.Time {
+	if c.ttl == 0 {
+		return time.Time{}
+	}
+	return c.now().Add(c.ttl)
+}
+
+func (c *MemoryPodDefinitionCache) isExpired(entry memoryPodDefinitionCacheEntry) bool {
+	return !entry.expires.IsZero() && !c.now().Before(entry.expires)
+}
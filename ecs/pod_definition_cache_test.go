@@ -0,0 +1,279 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryPodDefinitionCacheOptions(t *testing.T) {
+	t.Run("NewMemoryPodDefinitionCacheOptions", func(t *testing.T) {
+		opts := NewMemoryPodDefinitionCacheOptions()
+		require.NotZero(t, opts)
+		assert.Zero(t, *opts)
+	})
+	t.Run("Setters", func(t *testing.T) {
+		opts := NewMemoryPodDefinitionCacheOptions().
+			SetCapacity(5).
+			SetTTL(time.Minute).
+			SetTag("tag")
+		assert.Equal(t, 5, utility.FromIntPtr(opts.Capacity))
+		assert.Equal(t, time.Minute, *opts.TTL)
+		assert.Equal(t, "tag", utility.FromStringPtr(opts.Tag))
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("EmptyIsValidAndSetsDefaultCapacity", func(t *testing.T) {
+			opts := NewMemoryPodDefinitionCacheOptions()
+			require.NoError(t, opts.Validate())
+			assert.Equal(t, defaultMemoryPodDefinitionCacheCapacity, utility.FromIntPtr(opts.Capacity))
+			assert.Zero(t, opts.TTL)
+		})
+		t.Run("AllFieldsIsValid", func(t *testing.T) {
+			opts := NewMemoryPodDefinitionCacheOptions().SetCapacity(1).SetTTL(time.Second).SetTag("tag")
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("ZeroCapacityIsInvalid", func(t *testing.T) {
+			assert.Error(t, NewMemoryPodDefinitionCacheOptions().SetCapacity(0).Validate())
+		})
+		t.Run("NegativeTTLIsInvalid", func(t *testing.T) {
+			assert.Error(t, NewMemoryPodDefinitionCacheOptions().SetTTL(-time.Second).Validate())
+		})
+	})
+}
+
+func TestMemoryPodDefinitionCache(t *testing.T) {
+	assert.Implements(t, (*cocoa.ECSPodDefinitionCache)(nil), &MemoryPodDefinitionCache{})
+	assert.Implements(t, (*cocoa.ECSPodDefinitionRunCache)(nil), &MemoryPodDefinitionCache{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newCache := func(t *testing.T, opts *MemoryPodDefinitionCacheOptions) *MemoryPodDefinitionCache {
+		c, err := NewMemoryPodDefinitionCache(*opts)
+		require.NoError(t, err)
+		return c
+	}
+	newItem := func(id string) cocoa.ECSPodDefinitionItem {
+		return cocoa.ECSPodDefinitionItem{
+			ID:             id,
+			DefinitionOpts: *cocoa.NewECSPodDefinitionOptions().SetName(id),
+		}
+	}
+
+	t.Run("FailsWithInvalidOptions", func(t *testing.T) {
+		c, err := NewMemoryPodDefinitionCache(*NewMemoryPodDefinitionCacheOptions().SetCapacity(-1))
+		assert.Error(t, err)
+		assert.Zero(t, c)
+	})
+	t.Run("PutAndGetSucceed", func(t *testing.T) {
+		c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+		item := newItem("id")
+		require.NoError(t, c.Put(ctx, item))
+
+		found, err := c.Get(ctx, item.ID)
+		require.NoError(t, err)
+		require.NotZero(t, found)
+		assert.Equal(t, item, *found)
+	})
+	t.Run("PutUpdatesExistingItem", func(t *testing.T) {
+		c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+		item := newItem("id")
+		require.NoError(t, c.Put(ctx, item))
+		item.DefinitionOpts.SetName("new_name")
+		require.NoError(t, c.Put(ctx, item))
+
+		found, err := c.Get(ctx, item.ID)
+		require.NoError(t, err)
+		require.NotZero(t, found)
+		assert.Equal(t, "new_name", utility.FromStringPtr(found.DefinitionOpts.Name))
+		assert.Equal(t, 1, c.Len())
+	})
+	t.Run("PutFailsWithoutID", func(t *testing.T) {
+		c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+		assert.Error(t, c.Put(ctx, newItem("")))
+		assert.Zero(t, c.Len())
+	})
+	t.Run("GetReturnsNilForNonexistentItem", func(t *testing.T) {
+		c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+		found, err := c.Get(ctx, "nonexistent")
+		assert.NoError(t, err)
+		assert.Zero(t, found)
+	})
+	t.Run("DeleteRemovesItem", func(t *testing.T) {
+		c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+		item := newItem("id")
+		require.NoError(t, c.Put(ctx, item))
+		require.NoError(t, c.Delete(ctx, item.ID))
+
+		found, err := c.Get(ctx, item.ID)
+		assert.NoError(t, err)
+		assert.Zero(t, found)
+	})
+	t.Run("DeleteIsNoopForNonexistentItem", func(t *testing.T) {
+		c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+		assert.NoError(t, c.Delete(ctx, "nonexistent"))
+	})
+	t.Run("EvictsLeastRecentlyUsedItemAtCapacity", func(t *testing.T) {
+		c := newCache(t, NewMemoryPodDefinitionCacheOptions().SetCapacity(2))
+		require.NoError(t, c.Put(ctx, newItem("first")))
+		require.NoError(t, c.Put(ctx, newItem("second")))
+
+		found, err := c.Get(ctx, "first")
+		require.NoError(t, err)
+		require.NotZero(t, found, "first item should still be cached")
+
+		require.NoError(t, c.Put(ctx, newItem("third")))
+		assert.Equal(t, 2, c.Len())
+
+		found, err = c.Get(ctx, "second")
+		require.NoError(t, err)
+		assert.Zero(t, found, "least recently used item should be evicted")
+		for _, id := range []string{"first", "third"} {
+			found, err = c.Get(ctx, id)
+			require.NoError(t, err)
+			assert.NotZero(t, found, "item '%s' should still be cached", id)
+		}
+	})
+	t.Run("ExpiresItemsAfterTTL", func(t *testing.T) {
+		c := newCache(t, NewMemoryPodDefinitionCacheOptions().SetTTL(time.Minute))
+		now := time.Now()
+		c.now = func() time.Time { return now }
+		require.NoError(t, c.Put(ctx, newItem("id")))
+
+		now = now.Add(30 * time.Second)
+		found, err := c.Get(ctx, "id")
+		require.NoError(t, err)
+		assert.NotZero(t, found, "item should not expire before the TTL")
+
+		now = now.Add(time.Minute)
+		found, err = c.Get(ctx, "id")
+		require.NoError(t, err)
+		assert.Zero(t, found, "item should expire after the TTL")
+		assert.Zero(t, c.Len())
+	})
+	t.Run("PutResetsTTL", func(t *testing.T) {
+		c := newCache(t, NewMemoryPodDefinitionCacheOptions().SetTTL(time.Minute))
+		now := time.Now()
+		c.now = func() time.Time { return now }
+		require.NoError(t, c.Put(ctx, newItem("id")))
+
+		now = now.Add(45 * time.Second)
+		require.NoError(t, c.Put(ctx, newItem("id")))
+
+		now = now.Add(45 * time.Second)
+		found, err := c.Get(ctx, "id")
+		require.NoError(t, err)
+		assert.NotZero(t, found)
+	})
+	t.Run("GetTag", func(t *testing.T) {
+		t.Run("ReturnsConfiguredTag", func(t *testing.T) {
+			c := newCache(t, NewMemoryPodDefinitionCacheOptions().SetTag("tag"))
+			assert.Equal(t, "tag", c.GetTag())
+		})
+		t.Run("ReturnsEmptyWithoutTag", func(t *testing.T) {
+			c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+			assert.Empty(t, c.GetTag())
+		})
+	})
+	t.Run("Runs", func(t *testing.T) {
+		newRunItem := func(id, key string) cocoa.ECSPodDefinitionItem {
+			item := newItem(id)
+			item.LastRun = &cocoa.ECSPodRun{
+				Key:     key,
+				Cluster: "cluster",
+				TaskID:  "task_id",
+			}
+			return item
+		}
+
+		t.Run("PutRunAndGetRunSucceed", func(t *testing.T) {
+			c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+			item := newRunItem("id", "key")
+			require.NoError(t, c.PutRun(ctx, item))
+
+			found, err := c.GetRun(ctx, "key")
+			require.NoError(t, err)
+			require.NotZero(t, found)
+			assert.Equal(t, item, *found)
+
+			found, err = c.Get(ctx, "id")
+			require.NoError(t, err)
+			assert.NotZero(t, found, "pod definition should also be cached")
+		})
+		t.Run("PutRunReplacesRunForSameKey", func(t *testing.T) {
+			c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+			require.NoError(t, c.PutRun(ctx, newRunItem("first", "key")))
+			require.NoError(t, c.PutRun(ctx, newRunItem("second", "key")))
+
+			found, err := c.GetRun(ctx, "key")
+			require.NoError(t, err)
+			require.NotZero(t, found)
+			assert.Equal(t, "second", found.ID)
+		})
+		t.Run("PutRunFailsWithoutLastRun", func(t *testing.T) {
+			c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+			assert.Error(t, c.PutRun(ctx, newItem("id")))
+			assert.Error(t, c.PutRun(ctx, newRunItem("id", "")))
+			assert.Zero(t, c.Len())
+		})
+		t.Run("GetRunReturnsNilForNonexistentKey", func(t *testing.T) {
+			c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+			found, err := c.GetRun(ctx, "nonexistent")
+			assert.NoError(t, err)
+			assert.Zero(t, found)
+		})
+		t.Run("DeleteRemovesRuns", func(t *testing.T) {
+			c := newCache(t, NewMemoryPodDefinitionCacheOptions())
+			require.NoError(t, c.PutRun(ctx, newRunItem("id", "key")))
+			require.NoError(t, c.Delete(ctx, "id"))
+
+			found, err := c.GetRun(ctx, "key")
+			assert.NoError(t, err)
+			assert.Zero(t, found)
+		})
+		t.Run("EvictionRemovesRuns", func(t *testing.T) {
+			c := newCache(t, NewMemoryPodDefinitionCacheOptions().SetCapacity(1))
+			require.NoError(t, c.PutRun(ctx, newRunItem("first", "key")))
+			require.NoError(t, c.Put(ctx, newItem("second")))
+
+			found, err := c.GetRun(ctx, "key")
+			assert.NoError(t, err)
+			assert.Zero(t, found)
+		})
+		t.Run("RunsExpireAfterTTL", func(t *testing.T) {
+			c := newCache(t, NewMemoryPodDefinitionCacheOptions().SetTTL(time.Minute))
+			now := time.Now()
+			c.now = func() time.Time { return now }
+			require.NoError(t, c.PutRun(ctx, newRunItem("id", "key")))
+
+			now = now.Add(2 * time.Minute)
+			found, err := c.GetRun(ctx, "key")
+			assert.NoError(t, err)
+			assert.Zero(t, found)
+		})
+	})
+	t.Run("IsSafeForConcurrentUse", func(t *testing.T) {
+		c := newCache(t, NewMemoryPodDefinitionCacheOptions().SetCapacity(10))
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				id := fmt.Sprintf("id%d", i)
+				assert.NoError(t, c.Put(ctx, newItem(id)))
+				_, err := c.Get(ctx, id)
+				assert.NoError(t, err)
+				assert.NoError(t, c.Delete(ctx, id))
+			}(i)
+		}
+		wg.Wait()
+		assert.Zero(t, c.Len())
+	})
+}
@@ -2,13 +2,20 @@ package ecs
 
 import (
 	"context"
+	"sort"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/awsutil"
+	"github.com/evergreen-ci/cocoa/identity"
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
 	"github.com/pkg/errors"
 )
 
@@ -19,14 +26,127 @@ type BasicPodDefinitionManager struct {
 	client cocoa.ECSClient
 	vault  cocoa.Vault
 	cache  cocoa.ECSPodDefinitionCache
+	strict bool
+	// activeWaitOpts are the retry options used to wait for newly-registered
+	// pod definitions to become active, if any.
+	activeWaitOpts *utility.RetryOptions
+	// imageValidationOpts are the options used to check that the images in
+	// new pod definitions are pinned, if any.
+	imageValidationOpts *ImageValidationOptions
+	// secretLocationOpts are the options used to check that the existing
+	// secrets in new pod definitions are in the expected account and region,
+	// if any.
+	secretLocationOpts *SecretLocationOptions
+	// secretTagPropagationOpts are the options used to apply the tags of new
+	// pod definitions to their new secrets, if any.
+	secretTagPropagationOpts *SecretTagPropagationOptions
+	// eventSink receives lifecycle events, if any.
+	eventSink cocoa.EventSink
+	// normalizers normalize pod definitions before they're hashed or
+	// registered, if any.
+	normalizers []cocoa.DefinitionNormalizer
+	// secretCreationConcurrency is the maximum number of secrets that can be
+	// created at once for a single pod definition.
+	secretCreationConcurrency int
+	// revisionDescribeConcurrency is the maximum number of pod definition
+	// revisions that can be described at once when listing revisions.
+	revisionDescribeConcurrency int
+	// tagName is the name of the tag that tracks whether a pod definition has
+	// been cached, if any.
+	tagName string
+	// nameGenerator generates the names of pod definitions and containers
+	// that are not explicitly named, if any.
+	nameGenerator cocoa.NameGenerator
+	// checksumTagName is the name of the tag that records the checksum of new
+	// pod definitions, if any.
+	checksumTagName string
+	// hashOpts are the options used to customize the hash of pod definitions,
+	// if any.
+	hashOpts *cocoa.HashOptions
+	// ownedClient is the client that the pod definition manager constructed
+	// itself, if any. Only the owned client is closed when the pod definition
+	// manager is closed.
+	ownedClient *BasicClient
 }
 
 // BasicPodDefinitionManagerOptions are options to create a basic ECS pod
 // definition manager that's optionally backed by a cache.
 type BasicPodDefinitionManagerOptions struct {
+	// Client is the client used to communicate with ECS. The caller retains
+	// ownership of the client, so it is not closed when the pod definition
+	// manager is closed. Exactly one of Client or ClientOptions must be
+	// specified.
 	Client cocoa.ECSClient
-	Vault  cocoa.Vault
-	Cache  cocoa.ECSPodDefinitionCache
+	// ClientOptions are options to construct a new client used to communicate
+	// with ECS. The pod definition manager owns the constructed client, so it
+	// is closed when the pod definition manager is closed. Exactly one of
+	// Client or ClientOptions must be specified.
+	ClientOptions *awsutil.ClientOptions
+	Vault         cocoa.Vault
+	Cache         cocoa.ECSPodDefinitionCache
+	// StrictValidation indicates whether or not to check that pod definitions
+	// are within the ECS size limits before creating them. By default, the
+	// size limits are only enforced by ECS when the task definition is
+	// registered.
+	StrictValidation bool
+	// ActiveWaitOpts, if specified, makes the pod definition manager wait after
+	// registering a pod definition until ECS reports that it is active,
+	// retrying with these options. This avoids races where a newly-registered
+	// pod definition cannot be used immediately. By default, the pod
+	// definition manager does not wait.
+	ActiveWaitOpts *utility.RetryOptions
+	// ImageValidationOpts, if specified, checks that the images in new pod
+	// definitions are pinned before creating them. By default, images are not
+	// checked.
+	ImageValidationOpts *ImageValidationOptions
+	// SecretLocationOpts, if specified, checks that existing secrets referenced
+	// by ARN in new pod definitions are in the expected account and region
+	// before creating them. By default, secret locations are not checked.
+	SecretLocationOpts *SecretLocationOptions
+	// SecretTagPropagationOpts, if specified, applies the tags of new pod
+	// definitions to the new secrets that are created for them. By default,
+	// new secrets only have their own tags.
+	SecretTagPropagationOpts *SecretTagPropagationOptions
+	// EventSink, if specified, receives lifecycle events for the resources
+	// that the pod definition manager creates. By default, no events are sent.
+	EventSink cocoa.EventSink
+	// Normalizers, if specified, are applied in order to pod definitions before
+	// they're hashed or registered, so that logically identical pod
+	// definitions converge to the same hash. By default, pod definitions are
+	// not normalized.
+	Normalizers []cocoa.DefinitionNormalizer
+	// SecretCreationConcurrency is the maximum number of new secrets that can
+	// be created at once for a single pod definition. Secrets that are
+	// specified multiple times in the same pod definition are still only
+	// created once. By default, secrets are created one at a time.
+	SecretCreationConcurrency *int
+	// RevisionDescribeConcurrency is the maximum number of pod definition
+	// revisions that can be described at once when listing the revisions of
+	// a pod definition family. By default, this is
+	// defaultRevisionDescribeConcurrency.
+	RevisionDescribeConcurrency *int
+	// TagName, if specified, is the name of the tag that tracks whether a pod
+	// definition has been cached. This takes precedence over the cache's tag.
+	// Independent deployments that share a cluster should use distinct tag
+	// names so that they do not track each other's pod definitions. By
+	// default, this is the cache's tag, or DefaultPodDefinitionTagName if the
+	// cache does not specify one.
+	TagName *string
+	// NameGenerator, if specified, generates the names of new pod definitions
+	// and their containers if they are not explicitly named and the pod
+	// definition options do not specify their own name generator. By
+	// default, they are given random names.
+	NameGenerator cocoa.NameGenerator
+	// ChecksumTagName, if specified, is the name of the tag that records a
+	// checksum of each new pod definition when it is registered. The checksum
+	// allows VerifyDefinitionIntegrity to detect pod definitions that were
+	// modified outside of cocoa. By default, pod definitions are not tagged
+	// with a checksum.
+	ChecksumTagName *string
+	// HashOpts, if specified, customize which fields of pod definitions are
+	// included in their hashes from HashPodDefinition (e.g. to exclude
+	// volatile tags). By default, every field is included.
+	HashOpts *cocoa.HashOptions
 }
 
 // NewBasicPodDefinitionManagerOptions returns new uninitialized options to
@@ -41,6 +161,13 @@ func (o *BasicPodDefinitionManagerOptions) SetClient(c cocoa.ECSClient) *BasicPo
 	return o
 }
 
+// SetClientOptions sets the options to construct a new client that the pod
+// manager owns and uses to communicate with ECS.
+func (o *BasicPodDefinitionManagerOptions) SetClientOptions(opts awsutil.ClientOptions) *BasicPodDefinitionManagerOptions {
+	o.ClientOptions = &opts
+	return o
+}
+
 // SetVault sets the vault that the pod manager uses to manage secrets.
 func (o *BasicPodDefinitionManagerOptions) SetVault(v cocoa.Vault) *BasicPodDefinitionManagerOptions {
 	o.Vault = v
@@ -53,19 +180,157 @@ func (o *BasicPodDefinitionManagerOptions) SetCache(pdc cocoa.ECSPodDefinitionCa
 	return o
 }
 
-var (
-	defaultCacheTrackingTag = "cocoa-tracked"
-)
+// SetStrictValidation sets whether or not the pod manager checks that pod definitions
+// are within the ECS size limits before creating them.
+func (o *BasicPodDefinitionManagerOptions) SetStrictValidation(strict bool) *BasicPodDefinitionManagerOptions {
+	o.StrictValidation = strict
+	return o
+}
+
+// SetActiveWaitOptions sets the retry options that the pod manager uses to wait
+// for newly-registered pod definitions to become active.
+func (o *BasicPodDefinitionManagerOptions) SetActiveWaitOptions(opts utility.RetryOptions) *BasicPodDefinitionManagerOptions {
+	o.ActiveWaitOpts = &opts
+	return o
+}
+
+// SetImageValidationOptions sets the options that the pod manager uses to check
+// that the images in new pod definitions are pinned.
+func (o *BasicPodDefinitionManagerOptions) SetImageValidationOptions(opts ImageValidationOptions) *BasicPodDefinitionManagerOptions {
+	o.ImageValidationOpts = &opts
+	return o
+}
+
+// SetSecretLocationOptions sets the options that the pod manager uses to check
+// that existing secrets in new pod definitions are in the expected account and
+// region.
+func (o *BasicPodDefinitionManagerOptions) SetSecretLocationOptions(opts SecretLocationOptions) *BasicPodDefinitionManagerOptions {
+	o.SecretLocationOpts = &opts
+	return o
+}
+
+// SetSecretTagPropagationOptions sets the options that the pod manager uses to
+// apply the tags of new pod definitions to their new secrets.
+func (o *BasicPodDefinitionManagerOptions) SetSecretTagPropagationOptions(opts SecretTagPropagationOptions) *BasicPodDefinitionManagerOptions {
+	o.SecretTagPropagationOpts = &opts
+	return o
+}
+
+// SetEventSink sets the sink that receives lifecycle events for the resources
+// that the pod manager creates.
+func (o *BasicPodDefinitionManagerOptions) SetEventSink(sink cocoa.EventSink) *BasicPodDefinitionManagerOptions {
+	o.EventSink = sink
+	return o
+}
+
+// SetNormalizers sets the normalizers that the pod manager applies to pod
+// definitions before hashing or registering them. This overwrites any existing
+// normalizers.
+func (o *BasicPodDefinitionManagerOptions) SetNormalizers(normalizers []cocoa.DefinitionNormalizer) *BasicPodDefinitionManagerOptions {
+	o.Normalizers = normalizers
+	return o
+}
+
+// AddNormalizers adds new normalizers to the existing ones.
+func (o *BasicPodDefinitionManagerOptions) AddNormalizers(normalizers ...cocoa.DefinitionNormalizer) *BasicPodDefinitionManagerOptions {
+	o.Normalizers = append(o.Normalizers, normalizers...)
+	return o
+}
+
+// SetSecretCreationConcurrency sets the maximum number of new secrets that can
+// be created at once for a single pod definition.
+func (o *BasicPodDefinitionManagerOptions) SetSecretCreationConcurrency(n int) *BasicPodDefinitionManagerOptions {
+	o.SecretCreationConcurrency = &n
+	return o
+}
+
+// SetRevisionDescribeConcurrency sets the maximum number of pod definition
+// revisions that can be described at once when listing revisions.
+func (o *BasicPodDefinitionManagerOptions) SetRevisionDescribeConcurrency(n int) *BasicPodDefinitionManagerOptions {
+	o.RevisionDescribeConcurrency = &n
+	return o
+}
+
+// SetTagName sets the name of the tag that tracks whether a pod definition has
+// been cached.
+func (o *BasicPodDefinitionManagerOptions) SetTagName(name string) *BasicPodDefinitionManagerOptions {
+	o.TagName = &name
+	return o
+}
+
+// SetNameGenerator sets the generator for the names of new pod definitions and
+// their containers if they are not explicitly named.
+func (o *BasicPodDefinitionManagerOptions) SetNameGenerator(g cocoa.NameGenerator) *BasicPodDefinitionManagerOptions {
+	o.NameGenerator = g
+	return o
+}
+
+// SetChecksumTagName sets the name of the tag that records a checksum of each
+// new pod definition.
+func (o *BasicPodDefinitionManagerOptions) SetChecksumTagName(name string) *BasicPodDefinitionManagerOptions {
+	o.ChecksumTagName = &name
+	return o
+}
+
+// SetHashOptions sets the options to customize which fields of pod definitions
+// are included in their hashes.
+func (o *BasicPodDefinitionManagerOptions) SetHashOptions(opts cocoa.HashOptions) *BasicPodDefinitionManagerOptions {
+	o.HashOpts = &opts
+	return o
+}
+
+// DefaultPodDefinitionTagName is the name of the tag that tracks whether a pod
+// definition has been cached if neither the pod definition manager nor its
+// cache specify one.
+const DefaultPodDefinitionTagName = "cocoa-tracked"
+
+// validatePodDefinitionTagName checks that the tag name is a valid ECS tag key.
+func validatePodDefinitionTagName(name string) error {
+	return cocoa.ValidateECSTags(cocoa.Tags{name: strconv.FormatBool(true)})
+}
 
 // Validate checks that the required parameters to initialize a pod definition
 // manager are given.
 func (o *BasicPodDefinitionManagerOptions) Validate() error {
 	catcher := grip.NewBasicCatcher()
-	catcher.NewWhen(o.Client == nil, "must specify a client")
+	catcher.NewWhen(o.Client == nil && o.ClientOptions == nil, "must specify either a client or client options")
+	catcher.NewWhen(o.Client != nil && o.ClientOptions != nil, "cannot specify both a client and client options")
+	catcher.NewWhen(encryptsValues(o.Vault), "cannot use a vault that encrypts secret values because ECS cannot decrypt them for pods")
+	if o.ImageValidationOpts != nil {
+		catcher.Wrap(o.ImageValidationOpts.Validate(), "invalid image validation options")
+	}
+	if o.SecretLocationOpts != nil {
+		catcher.Wrap(o.SecretLocationOpts.Validate(), "invalid secret location options")
+	}
+	if o.SecretTagPropagationOpts != nil {
+		catcher.Wrap(o.SecretTagPropagationOpts.Validate(), "invalid secret tag propagation options")
+	}
+	for i, n := range o.Normalizers {
+		catcher.ErrorfWhen(n == nil, "normalizer at index %d cannot be nil", i)
+	}
+	catcher.NewWhen(o.SecretCreationConcurrency != nil && *o.SecretCreationConcurrency <= 0, "must specify a positive secret creation concurrency")
+	catcher.NewWhen(o.RevisionDescribeConcurrency != nil && *o.RevisionDescribeConcurrency <= 0, "must specify a positive revision describe concurrency")
+	if o.TagName != nil {
+		catcher.Wrapf(validatePodDefinitionTagName(*o.TagName), "invalid tag name '%s'", *o.TagName)
+	} else if o.Cache != nil {
+		if name := o.Cache.GetTag(); name != "" {
+			catcher.Wrapf(validatePodDefinitionTagName(name), "invalid cache tag name '%s'", name)
+		}
+	}
+	if o.ChecksumTagName != nil {
+		catcher.Wrapf(validatePodDefinitionTagName(*o.ChecksumTagName), "invalid checksum tag name '%s'", *o.ChecksumTagName)
+	}
 	if catcher.HasErrors() {
 		return catcher.Resolve()
 	}
 
+	if o.SecretCreationConcurrency == nil {
+		o.SetSecretCreationConcurrency(defaultSecretCreationConcurrency)
+	}
+	if o.RevisionDescribeConcurrency == nil {
+		o.SetRevisionDescribeConcurrency(defaultRevisionDescribeConcurrency)
+	}
+
 	return nil
 }
 
@@ -75,19 +340,88 @@ func NewBasicPodDefinitionManager(opts BasicPodDefinitionManagerOptions) (*Basic
 	if err := opts.Validate(); err != nil {
 		return nil, errors.Wrap(err, "invalid options")
 	}
-	return &BasicPodDefinitionManager{
-		client: opts.Client,
-		vault:  opts.Vault,
-		cache:  opts.Cache,
-	}, nil
+	m := &BasicPodDefinitionManager{
+		client:                      opts.Client,
+		vault:                       opts.Vault,
+		cache:                       opts.Cache,
+		strict:                      opts.StrictValidation,
+		activeWaitOpts:              opts.ActiveWaitOpts,
+		imageValidationOpts:         opts.ImageValidationOpts,
+		secretLocationOpts:          opts.SecretLocationOpts,
+		secretTagPropagationOpts:    opts.SecretTagPropagationOpts,
+		eventSink:                   opts.EventSink,
+		normalizers:                 opts.Normalizers,
+		secretCreationConcurrency:   utility.FromIntPtr(opts.SecretCreationConcurrency),
+		revisionDescribeConcurrency: utility.FromIntPtr(opts.RevisionDescribeConcurrency),
+		tagName:                     utility.FromStringPtr(opts.TagName),
+		nameGenerator:               opts.NameGenerator,
+		checksumTagName:             utility.FromStringPtr(opts.ChecksumTagName),
+		hashOpts:                    opts.HashOpts,
+	}
+	if opts.ClientOptions != nil {
+		m.ownedClient = newLazyBasicClient(*opts.ClientOptions)
+		m.client = m.ownedClient
+	}
+	return m, nil
+}
+
+// Close closes the client if the pod definition manager constructed it from
+// client options. Clients that were given to the pod definition manager are
+// owned by the caller and are not closed. The pod definition manager should not
+// be used after it is closed.
+func (m *BasicPodDefinitionManager) Close(ctx context.Context) error {
+	if m.ownedClient == nil {
+		return nil
+	}
+	return errors.Wrap(m.ownedClient.Close(ctx), "closing ECS client")
 }
 
 // CreatePodDefinition creates a pod definition and caches it if it is using a
-// cache.
+// cache. If it fails after some of the pod definition's resources have already
+// been created, it returns a *cocoa.PartialCreationError containing the
+// resources that were created.
 func (m *BasicPodDefinitionManager) CreatePodDefinition(ctx context.Context, opts ...cocoa.ECSPodDefinitionOptions) (*cocoa.ECSPodDefinitionItem, error) {
-	mergedOpts := cocoa.MergeECSPodDefinitionOptions(opts...)
+	item, _, err := m.createPodDefinition(ctx, nil, opts...)
+	return item, err
+}
+
+// createPodDefinition creates a pod definition and caches it if it is using a
+// cache. It also returns the IDs of the secrets that were created for the pod
+// definition. If stages are given, creating the secrets and registering the
+// pod definition run as separate pod creation stages.
+func (m *BasicPodDefinitionManager) createPodDefinition(ctx context.Context, stages *podCreationStages, opts ...cocoa.ECSPodDefinitionOptions) (*cocoa.ECSPodDefinitionItem, []string, error) {
+	mergedOpts, err := m.normalize(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The hash is of the options as they were requested, before any defaults
+	// are set or any secrets are created.
+	hash, _ := m.hashNormalized(mergedOpts)
+	if mergedOpts.NameGenerator == nil {
+		mergedOpts.NameGenerator = m.nameGenerator
+	}
 	if err := mergedOpts.Validate(); err != nil {
-		return nil, errors.Wrap(err, "invalid pod definition options")
+		return nil, nil, errors.Wrap(err, "invalid pod definition options")
+	}
+	mergedOpts = removeSkippedRepoCreds(mergedOpts)
+	if m.strict {
+		if err := ValidatePodDefinitionSizes(mergedOpts); err != nil {
+			return nil, nil, errors.Wrap(err, "pod definition exceeds ECS size limits")
+		}
+	}
+	if err := m.validateImages(mergedOpts); err != nil {
+		return nil, nil, errors.Wrap(err, "pod definition has unpinned images")
+	}
+	if m.secretLocationOpts != nil {
+		if err := ValidatePodDefinitionSecretLocations(mergedOpts, *m.secretLocationOpts); err != nil {
+			return nil, nil, errors.Wrap(err, "pod definition has secrets in an unexpected location")
+		}
+	}
+	// The tags to propagate are selected before the cache tag is added since
+	// the cache tag only tracks the pod definition itself.
+	var propagatedTags cocoa.Tags
+	if m.secretTagPropagationOpts != nil {
+		propagatedTags = m.secretTagPropagationOpts.filter(mergedOpts.Tags)
 	}
 	if m.usesCache() {
 		// If the definition needs to be cached, we could successfully create a
@@ -99,18 +433,77 @@ func (m *BasicPodDefinitionManager) CreatePodDefinition(ctx context.Context, opt
 		mergedOpts.AddTags(map[string]string{m.getCacheTag(): strconv.FormatBool(false)})
 	}
 
-	if err := createSecrets(ctx, m.vault, &mergedOpts); err != nil {
-		return nil, errors.Wrap(err, "creating new secrets")
+	var secretIDs []string
+	if err := stages.run(ctx, cocoa.ECSPodCreationStageSecrets, func(ctx context.Context) error {
+		var err error
+		secretIDs, err = createSecrets(ctx, m.vault, &mergedOpts, m.secretCreationConcurrency, propagatedTags)
+		for _, id := range secretIDs {
+			sendEvent(ctx, m.eventSink, cocoa.Event{
+				Type:                cocoa.EventTypeSecretCreated,
+				PodDefinitionFamily: utility.FromStringPtr(mergedOpts.Name),
+				SecretID:            id,
+			})
+		}
+		if err != nil {
+			return newPartialCreationErrorIfCreated(errors.Wrap(err, "creating new secrets"), secretIDs, "")
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, newPartialCreationErrorIfCreated(errors.Wrap(err, "context is done before registering task definition"), secretIDs, "")
 	}
 
-	taskDef, err := registerTaskDefinition(ctx, m.client, mergedOpts)
+	var item *cocoa.ECSPodDefinitionItem
+	if err := stages.run(ctx, cocoa.ECSPodCreationStageDefinition, func(ctx context.Context) error {
+		var err error
+		item, err = m.registerPodDefinition(ctx, mergedOpts, hash, secretIDs)
+		return err
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	return item, secretIDs, nil
+}
+
+// registerPodDefinition registers the pod definition whose secrets have
+// already been created and caches it if it is using a cache. The hash is the
+// hash of the pod definition options that were originally requested.
+func (m *BasicPodDefinitionManager) registerPodDefinition(ctx context.Context, mergedOpts cocoa.ECSPodDefinitionOptions, hash string, secretIDs []string) (*cocoa.ECSPodDefinitionItem, error) {
+	in := exportPodDefinitionOptions(mergedOpts)
+	if m.usesCache() {
+		// The hash is recorded in a tag rather than in the pod definition
+		// options so that the cache can be repopulated from ECS with the same
+		// hash (see SyncCacheFromECS).
+		in.Tags = append(in.Tags, ExportTags(map[string]string{cocoa.PodDefinitionHashTagKey: hash})...)
+	}
+	if m.checksumTagName != "" {
+		if err := addChecksumTag(in, m.checksumTagName); err != nil {
+			return nil, newPartialCreationErrorIfCreated(err, secretIDs, "")
+		}
+	}
+	taskDef, err := registerTaskDefinitionInput(ctx, m.client, in)
 	if err != nil {
-		return nil, errors.Wrap(err, "registering task definition")
+		return nil, newPartialCreationErrorIfCreated(errors.Wrap(err, "registering task definition"), secretIDs, "")
+	}
+	sendEvent(ctx, m.eventSink, cocoa.Event{
+		Type:                cocoa.EventTypePodDefinitionRegistered,
+		PodDefinitionID:     utility.FromStringPtr(taskDef.TaskDefinitionArn),
+		PodDefinitionFamily: utility.FromStringPtr(taskDef.Family),
+	})
+
+	if m.activeWaitOpts != nil {
+		if err := m.waitForPodDefinitionActive(ctx, utility.FromStringPtr(taskDef.TaskDefinitionArn), *m.activeWaitOpts); err != nil {
+			return nil, newPartialCreationErrorIfCreated(err, secretIDs, utility.FromStringPtr(taskDef.TaskDefinitionArn))
+		}
 	}
 
 	item := cocoa.ECSPodDefinitionItem{
 		ID:             utility.FromStringPtr(taskDef.TaskDefinitionArn),
 		DefinitionOpts: mergedOpts,
+		Hash:           hash,
 	}
 
 	if !m.usesCache() {
@@ -118,7 +511,7 @@ func (m *BasicPodDefinitionManager) CreatePodDefinition(ctx context.Context, opt
 	}
 
 	if err := m.cache.Put(ctx, item); err != nil {
-		return nil, errors.Wrapf(err, "adding pod definition item '%s' named '%s' to cache", item.ID, utility.FromStringPtr(item.DefinitionOpts.Name))
+		return nil, newPartialCreationErrorIfCreated(errors.Wrapf(err, "adding pod definition item '%s' named '%s' to cache", item.ID, utility.FromStringPtr(item.DefinitionOpts.Name)), secretIDs, item.ID)
 	}
 
 	// Now that the cloud pod definition is being tracked in the cache, re-tag
@@ -127,12 +520,617 @@ func (m *BasicPodDefinitionManager) CreatePodDefinition(ctx context.Context, opt
 		ResourceArn: aws.String(item.ID),
 		Tags:        ExportTags(map[string]string{m.getCacheTag(): strconv.FormatBool(true)}),
 	}); err != nil {
-		return nil, errors.Wrapf(err, "re-tagging pod definition item '%s' named '%s' to indicate that it is tracked", item.ID, utility.FromStringPtr(item.DefinitionOpts.Name))
+		return nil, newPartialCreationErrorIfCreated(errors.Wrapf(err, "re-tagging pod definition item '%s' named '%s' to indicate that it is tracked", item.ID, utility.FromStringPtr(item.DefinitionOpts.Name)), secretIDs, item.ID)
 	}
 
 	return &item, nil
 }
 
+// HashPodDefinition returns the hash of the pod definition after applying the
+// pod definition manager's normalizers. Callers that look up existing pod
+// definitions by hash should use this instead of hashing the pod definition
+// directly so that the hash matches the normalized pod definition that is
+// registered. If the pod definition manager has hash options, the fields that
+// they exclude do not affect the hash.
+func (m *BasicPodDefinitionManager) HashPodDefinition(opts ...cocoa.ECSPodDefinitionOptions) (string, error) {
+	hash, _, err := m.hashedPodDefinition(opts...)
+	return hash, err
+}
+
+// hashedPodDefinition returns the hash of the pod definition along with the
+// normalized pod definition options without the fields that the hash options
+// exclude. Two pod definitions that have the same hash are only equivalent if
+// their returned options are also equal.
+func (m *BasicPodDefinitionManager) hashedPodDefinition(opts ...cocoa.ECSPodDefinitionOptions) (string, cocoa.ECSPodDefinitionOptions, error) {
+	normalized, err := m.normalize(opts...)
+	if err != nil {
+		return "", cocoa.ECSPodDefinitionOptions{}, err
+	}
+	hash, hashed := m.hashNormalized(normalized)
+	return hash, hashed, nil
+}
+
+// hashNormalized returns the hash of the pod definition options that have
+// already been normalized along with the options without the fields that the
+// hash options exclude.
+func (m *BasicPodDefinitionManager) hashNormalized(normalized cocoa.ECSPodDefinitionOptions) (string, cocoa.ECSPodDefinitionOptions) {
+	if m.hashOpts == nil {
+		return normalized.Hash(), normalized
+	}
+
+	if len(m.hashOpts.ExcludeTagKeys) != 0 {
+		normalized.Tags = cocoa.NewTags().Add(normalized.Tags).Remove(m.hashOpts.ExcludeTagKeys...)
+	}
+	if utility.FromBoolPtr(m.hashOpts.ExcludeName) {
+		normalized.Name = nil
+	}
+	return normalized.Hash(*m.hashOpts), normalized
+}
+
+// normalize merges the pod definition options and applies the normalizers to
+// the result.
+func (m *BasicPodDefinitionManager) normalize(opts ...cocoa.ECSPodDefinitionOptions) (cocoa.ECSPodDefinitionOptions, error) {
+	merged := cocoa.MergeECSPodDefinitionOptions(opts...)
+	normalized, err := cocoa.NormalizePodDefinition(merged, m.normalizers...)
+	if err != nil {
+		return cocoa.ECSPodDefinitionOptions{}, errors.Wrap(err, "normalizing pod definition")
+	}
+	return normalized, nil
+}
+
+// removeSkippedRepoCreds returns a copy of the pod definition options without
+// the repository credentials that the containers do not use, so that they are
+// neither created nor referenced by the pod definition.
+func removeSkippedRepoCreds(opts cocoa.ECSPodDefinitionOptions) cocoa.ECSPodDefinitionOptions {
+	containerDefs := make([]cocoa.ECSContainerDefinition, 0, len(opts.ContainerDefinitions))
+	for _, def := range opts.ContainerDefinitions {
+		if def.RepoCreds != nil && !def.UsesRepositoryCredentials() {
+			def.RepoCreds = nil
+		}
+		containerDefs = append(containerDefs, def)
+	}
+	opts.ContainerDefinitions = containerDefs
+	return opts
+}
+
+// validateImages checks the pod definition's images according to the image
+// validation options, if any. If the policy only warns about invalid images, it
+// logs the invalid images instead of returning an error.
+func (m *BasicPodDefinitionManager) validateImages(opts cocoa.ECSPodDefinitionOptions) error {
+	if m.imageValidationOpts == nil {
+		return nil
+	}
+
+	err := ValidatePodDefinitionImages(opts, utility.FromBoolPtr(m.imageValidationOpts.RequireDigest))
+	if err == nil {
+		return nil
+	}
+
+	if m.imageValidationOpts.Policy != nil && *m.imageValidationOpts.Policy == ImageValidationPolicyWarn {
+		grip.Warning(message.WrapError(err, message.Fields{
+			"message": "pod definition has unpinned images",
+			"family":  utility.FromStringPtr(opts.Name),
+		}))
+		return nil
+	}
+
+	return err
+}
+
+// ImportPodDefinition imports an existing pod definition that was not created
+// by the pod definition manager so that it can be managed like any other pod
+// definition. It describes the pod definition with the given ID and converts
+// it into pod definition options, then caches it if it is using a cache.
+// Secrets and repository credentials are imported as references to existing,
+// unowned secrets. Volumes other than bind mounts from the host are not
+// imported. If the pod definition has a hash tag (see
+// cocoa.PodDefinitionHashTagKey), the imported item's hash is recovered from
+// it; otherwise, the hash is computed from the imported pod definition options
+// so that the item can be found again by the options it is equivalent to.
+func (m *BasicPodDefinitionManager) ImportPodDefinition(ctx context.Context, id string) (*cocoa.ECSPodDefinitionItem, error) {
+	if id == "" {
+		return nil, errors.New("must specify a pod definition ID")
+	}
+
+	out, err := m.client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String(id),
+		Include:        []types.TaskDefinitionField{types.TaskDefinitionFieldTags},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "describing task definition '%s'", id)
+	}
+	if out.TaskDefinition == nil {
+		return nil, errors.Errorf("expected task definition '%s' from ECS, but none was returned", id)
+	}
+
+	item := cocoa.ECSPodDefinitionItem{
+		ID:             utility.FromStringPtr(out.TaskDefinition.TaskDefinitionArn),
+		DefinitionOpts: translatePodDefinitionOptions(*out.TaskDefinition, out.Tags),
+	}
+	if item.ID == "" {
+		item.ID = id
+	}
+
+	// The tags that the pod definition manager adds are not part of the
+	// requested pod definition options, so they're removed before hashing.
+	item.Hash = item.DefinitionOpts.Tags[cocoa.PodDefinitionHashTagKey]
+	delete(item.DefinitionOpts.Tags, cocoa.PodDefinitionHashTagKey)
+	if m.checksumTagName != "" {
+		delete(item.DefinitionOpts.Tags, m.checksumTagName)
+	}
+	if m.usesCache() {
+		delete(item.DefinitionOpts.Tags, m.getCacheTag())
+	}
+	if item.Hash == "" {
+		hash, _, err := m.hashedPodDefinition(item.DefinitionOpts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "hashing imported pod definition '%s'", item.ID)
+		}
+		item.Hash = hash
+	}
+
+	if !m.usesCache() {
+		return &item, nil
+	}
+
+	if err := m.cache.Put(ctx, item); err != nil {
+		return nil, errors.Wrapf(err, "adding imported pod definition item '%s' to cache", item.ID)
+	}
+
+	if _, err := m.client.TagResource(ctx, &ecs.TagResourceInput{
+		ResourceArn: aws.String(item.ID),
+		Tags:        ExportTags(map[string]string{m.getCacheTag(): strconv.FormatBool(true)}),
+	}); err != nil {
+		return nil, errors.Wrapf(err, "tagging imported pod definition item '%s' to indicate that it is tracked", item.ID)
+	}
+
+	return &item, nil
+}
+
+// UpdatePodDefinition creates a new revision in the same family as the
+// existing pod definition with the given ID. If it is using a cache, the new
+// revision is cached before the existing pod definition is removed from the
+// cache, so the family is always tracked by at least one cached item. If the
+// options specify it, the existing pod definition is deregistered before it is
+// removed from the cache.
+//
+// The cache is not updated atomically, so if the new revision is created but
+// the existing pod definition cannot be cleaned up, it returns the new
+// revision along with the error and both pod definitions remain in the cache.
+// If deregistering the existing pod definition fails, it is still active in
+// ECS; if only removing it from the cache fails, it may already be inactive in
+// ECS. In either case, the caller can finish the update by deleting the
+// existing pod definition (e.g. with DeletePodDefinition) rather than
+// retrying the update, which would create yet another revision.
+func (m *BasicPodDefinitionManager) UpdatePodDefinition(ctx context.Context, id string, opts cocoa.ECSPodDefinitionUpdateOptions) (*cocoa.ECSPodDefinitionItem, error) {
+	if id == "" {
+		return nil, errors.New("must specify a pod definition ID")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid update options")
+	}
+
+	out, err := m.client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String(id),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "describing existing task definition '%s'", id)
+	}
+	if out.TaskDefinition == nil {
+		return nil, errors.Errorf("expected task definition '%s' from ECS, but none was returned", id)
+	}
+	family := utility.FromStringPtr(out.TaskDefinition.Family)
+	if family == "" {
+		return nil, errors.Errorf("existing task definition '%s' has no family", id)
+	}
+	if name := utility.FromStringPtr(opts.DefinitionOpts.Name); opts.DefinitionOpts.Name != nil && name != family {
+		return nil, errors.Errorf("new revision name '%s' does not match the existing pod definition family '%s'", name, family)
+	}
+
+	defOpts := *opts.DefinitionOpts
+	defOpts.SetName(family)
+	item, _, err := m.createPodDefinition(ctx, nil, defOpts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating new revision of pod definition '%s'", id)
+	}
+
+	if utility.FromBoolPtr(opts.DeregisterPrevious) {
+		if _, err := m.client.DeregisterTaskDefinition(ctx, &ecs.DeregisterTaskDefinitionInput{
+			TaskDefinition: aws.String(id),
+		}); err != nil {
+			return item, errors.Wrapf(err, "deregistering previous task definition '%s'", id)
+		}
+	}
+
+	if m.usesCache() {
+		if err := m.cache.Delete(ctx, id); err != nil {
+			return item, errors.Wrapf(err, "deleting previous pod definition '%s' from cache", id)
+		}
+	}
+
+	return item, nil
+}
+
+// defaultActiveWaitOpts are the default retry options to wait for a pod
+// definition to become active.
+var defaultActiveWaitOpts = utility.RetryOptions{
+	MaxAttempts: 10,
+	MinDelay:    100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+}
+
+// WaitForPodDefinitionActive waits until ECS reports that the pod definition
+// with the given ID is active, retrying with a short backoff. If the manager
+// was configured with active wait options, those are used instead of the
+// default backoff. It returns an error if the pod definition is still not
+// active after all retries or if it becomes inactive.
+func (m *BasicPodDefinitionManager) WaitForPodDefinitionActive(ctx context.Context, id string) error {
+	opts := defaultActiveWaitOpts
+	if m.activeWaitOpts != nil {
+		opts = *m.activeWaitOpts
+	}
+	return m.waitForPodDefinitionActive(ctx, id, opts)
+}
+
+func (m *BasicPodDefinitionManager) waitForPodDefinitionActive(ctx context.Context, id string, opts utility.RetryOptions) error {
+	if id == "" {
+		return errors.New("must specify a pod definition ID")
+	}
+
+	return utility.Retry(ctx, func() (bool, error) {
+		out, err := m.client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+			TaskDefinition: aws.String(id),
+		})
+		if err != nil {
+			return true, errors.Wrapf(err, "describing pod definition '%s'", id)
+		}
+		if out.TaskDefinition == nil {
+			return true, errors.Errorf("expected pod definition '%s' from ECS, but none was returned", id)
+		}
+
+		switch status := out.TaskDefinition.Status; status {
+		case types.TaskDefinitionStatusActive:
+			return false, nil
+		case types.TaskDefinitionStatusInactive, types.TaskDefinitionStatusDeleteInProgress:
+			return false, errors.Errorf("pod definition '%s' has status '%s' and will not become active", id, status)
+		default:
+			return true, errors.Errorf("pod definition '%s' has status '%s' but is not active yet", id, status)
+		}
+	}, opts)
+}
+
+// defaultRevisionDescribeConcurrency is the default maximum number of pod
+// definition revisions that can be described at once when listing revisions.
+const defaultRevisionDescribeConcurrency = 4
+
+// ListPodDefinitionRevisions lists all the active and inactive revisions of the
+// pod definition family, ordered by revision number. Up to the revision
+// describe concurrency limit of revisions are described at once. If any of
+// them cannot be described, it returns an error.
+func (m *BasicPodDefinitionManager) ListPodDefinitionRevisions(ctx context.Context, family string) ([]cocoa.ECSPodDefinitionRevision, error) {
+	if family == "" {
+		return nil, errors.New("must specify a pod definition family")
+	}
+
+	var arns []string
+	for _, status := range []types.TaskDefinitionStatus{types.TaskDefinitionStatusActive, types.TaskDefinitionStatusInactive} {
+		statusARNs, err := m.listTaskDefinitionARNs(ctx, family, status)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing task definitions with status '%s'", status)
+		}
+		for _, arn := range statusARNs {
+			// The family filter matches on prefix, so it may include
+			// revisions from other families, which don't need to be
+			// described.
+			if parsed, err := identity.ParseTaskDefinitionARN(arn); err == nil && parsed.Family != family {
+				continue
+			}
+			arns = append(arns, arn)
+		}
+	}
+
+	defs, err := m.describeTaskDefinitions(ctx, arns)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []cocoa.ECSPodDefinitionRevision
+	for _, def := range defs {
+		// ARNs that could not be parsed are only filtered by family once
+		// they're described.
+		if utility.FromStringPtr(def.Family) != family {
+			continue
+		}
+
+		revisions = append(revisions, translatePodDefinitionRevision(def))
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+
+	return revisions, nil
+}
+
+// describeTaskDefinitions describes all the task definitions with up to the
+// revision describe concurrency limit of workers describing them at once. The
+// task definitions are returned in the same order as the given ARNs. If any of
+// them cannot be described, it returns an error.
+func (m *BasicPodDefinitionManager) describeTaskDefinitions(ctx context.Context, arns []string) ([]types.TaskDefinition, error) {
+	workers := m.revisionDescribeConcurrency
+	if workers <= 0 {
+		workers = defaultRevisionDescribeConcurrency
+	}
+	if workers > len(arns) {
+		workers = len(arns)
+	}
+
+	defs := make([]types.TaskDefinition, len(arns))
+	catcher := grip.NewBasicCatcher()
+	var catcherMu sync.Mutex
+	var wg sync.WaitGroup
+	indices := make(chan int, len(arns))
+	for i := range arns {
+		indices <- i
+	}
+	close(indices)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				arn := arns[i]
+				if err := ctx.Err(); err != nil {
+					catcherMu.Lock()
+					catcher.Wrapf(err, "describing task definition '%s'", arn)
+					catcherMu.Unlock()
+					return
+				}
+
+				out, err := m.client.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+					TaskDefinition: aws.String(arn),
+				})
+				if err == nil && out.TaskDefinition == nil {
+					err = errors.Errorf("expected task definition '%s' from ECS, but none was returned", arn)
+				}
+				if err != nil {
+					catcherMu.Lock()
+					catcher.Wrapf(err, "describing task definition '%s'", arn)
+					catcherMu.Unlock()
+					continue
+				}
+
+				defs[i] = *out.TaskDefinition
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	return defs, nil
+}
+
+// listTaskDefinitionARNs lists the ARNs of all the task definitions in the
+// family with the given status.
+func (m *BasicPodDefinitionManager) listTaskDefinitionARNs(ctx context.Context, family string, status types.TaskDefinitionStatus) ([]string, error) {
+	in := &ecs.ListTaskDefinitionsInput{
+		FamilyPrefix: aws.String(family),
+		Status:       status,
+	}
+
+	var arns []string
+	for {
+		out, err := m.client.ListTaskDefinitions(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		arns = append(arns, out.TaskDefinitionArns...)
+
+		if out.NextToken == nil {
+			return arns, nil
+		}
+		in.NextToken = out.NextToken
+	}
+}
+
+// translatePodDefinitionRevision translates an ECS task definition into its
+// equivalent cocoa pod definition revision.
+func translatePodDefinitionRevision(def types.TaskDefinition) cocoa.ECSPodDefinitionRevision {
+	rev := cocoa.ECSPodDefinitionRevision{
+		ID:             utility.FromStringPtr(def.TaskDefinitionArn),
+		Family:         utility.FromStringPtr(def.Family),
+		Revision:       int(def.Revision),
+		RegisteredAt:   def.RegisteredAt,
+		DeregisteredAt: def.DeregisteredAt,
+	}
+
+	switch def.Status {
+	case types.TaskDefinitionStatusActive:
+		rev.Status = cocoa.PodDefinitionStatusActive
+	case types.TaskDefinitionStatusInactive:
+		rev.Status = cocoa.PodDefinitionStatusInactive
+	default:
+		rev.Status = cocoa.PodDefinitionStatusUnknown
+	}
+
+	return rev
+}
+
+// translatePodDefinitionOptions translates an ECS task definition and its tags
+// into equivalent cocoa pod definition options.
+func translatePodDefinitionOptions(def types.TaskDefinition, tags []types.Tag) cocoa.ECSPodDefinitionOptions {
+	opts := cocoa.NewECSPodDefinitionOptions().
+		SetContainerDefinitions(translateContainerDefinitions(def.ContainerDefinitions, def.Volumes))
+	if family := utility.FromStringPtr(def.Family); family != "" {
+		opts.SetName(family)
+	}
+	if mem, err := strconv.Atoi(utility.FromStringPtr(def.Memory)); err == nil && mem > 0 {
+		opts.SetMemoryMB(mem)
+	}
+	if cpu, err := strconv.Atoi(utility.FromStringPtr(def.Cpu)); err == nil && cpu > 0 {
+		opts.SetCPU(cpu)
+	}
+	if def.NetworkMode != "" {
+		opts.SetNetworkMode(cocoa.ECSNetworkMode(def.NetworkMode))
+	}
+	if def.TaskRoleArn != nil {
+		opts.SetTaskRole(*def.TaskRoleArn)
+	}
+	if def.ExecutionRoleArn != nil {
+		opts.SetExecutionRole(*def.ExecutionRoleArn)
+	}
+	if len(tags) != 0 {
+		translated := map[string]string{}
+		for _, tag := range tags {
+			translated[utility.FromStringPtr(tag.Key)] = utility.FromStringPtr(tag.Value)
+		}
+		opts.SetTags(translated)
+	}
+
+	return *opts
+}
+
+// translateContainerDefinitions translates ECS container definitions into
+// equivalent cocoa container definitions. Mount points are only translated if
+// they refer to one of the volumes that is bind mounted from the host.
+func translateContainerDefinitions(defs []types.ContainerDefinition, volumes []types.Volume) []cocoa.ECSContainerDefinition {
+	hostSourcePaths := map[string]string{}
+	for _, v := range volumes {
+		if v.Host != nil && v.Host.SourcePath != nil {
+			hostSourcePaths[utility.FromStringPtr(v.Name)] = *v.Host.SourcePath
+		}
+	}
+
+	var translated []cocoa.ECSContainerDefinition
+	for _, def := range defs {
+		containerDef := cocoa.NewECSContainerDefinition().
+			SetCommand(def.Command)
+		if def.Name != nil {
+			containerDef.SetName(*def.Name)
+		}
+		if def.Image != nil {
+			containerDef.SetImage(*def.Image)
+		}
+		if def.WorkingDirectory != nil {
+			containerDef.SetWorkingDir(*def.WorkingDirectory)
+		}
+		if mem := utility.FromInt32Ptr(def.Memory); mem > 0 {
+			containerDef.SetMemoryMB(int(mem))
+		}
+		if def.Cpu > 0 {
+			containerDef.SetCPU(int(def.Cpu))
+		}
+		if utility.FromBoolPtr(def.Interactive) {
+			containerDef.SetInteractive(true)
+		}
+		if utility.FromBoolPtr(def.PseudoTerminal) {
+			containerDef.SetPseudoTerminal(true)
+		}
+		if def.Hostname != nil {
+			containerDef.SetHostname(*def.Hostname)
+		}
+		if def.User != nil {
+			containerDef.SetUser(*def.User)
+		}
+		for _, h := range def.ExtraHosts {
+			containerDef.AddExtraHosts(*cocoa.NewHostEntry().
+				SetHostname(utility.FromStringPtr(h.Hostname)).
+				SetIPAddress(utility.FromStringPtr(h.IpAddress)))
+		}
+		for _, vf := range def.VolumesFrom {
+			volumeFrom := cocoa.NewVolumeFrom().SetSourceContainer(utility.FromStringPtr(vf.SourceContainer))
+			if utility.FromBoolPtr(vf.ReadOnly) {
+				volumeFrom.SetReadOnly(true)
+			}
+			containerDef.AddVolumesFrom(*volumeFrom)
+		}
+		if def.LinuxParameters != nil {
+			for _, m := range def.LinuxParameters.Tmpfs {
+				containerDef.AddTmpfsMounts(*cocoa.NewTmpfsMount().
+					SetContainerPath(utility.FromStringPtr(m.ContainerPath)).
+					SetSizeMB(int(m.Size)).
+					SetMountOptions(m.MountOptions))
+			}
+			if def.LinuxParameters.SharedMemorySize != nil {
+				containerDef.SetSharedMemorySizeMB(int(*def.LinuxParameters.SharedMemorySize))
+			}
+		}
+
+		for _, envVar := range def.Environment {
+			containerDef.AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName(utility.FromStringPtr(envVar.Name)).
+				SetValue(utility.FromStringPtr(envVar.Value)))
+		}
+		containerDef.AddEnvironmentVariables(translateSecrets(def.Secrets)...)
+
+		if def.LogConfiguration != nil {
+			lc := cocoa.NewLogConfiguration().
+				SetLogDriver(string(def.LogConfiguration.LogDriver)).
+				SetSecretOptions(translateSecrets(def.LogConfiguration.SecretOptions))
+			if len(def.LogConfiguration.Options) != 0 {
+				lc.SetOptions(def.LogConfiguration.Options)
+			}
+			containerDef.SetLogConfiguration(*lc)
+		}
+
+		if def.RepositoryCredentials != nil && def.RepositoryCredentials.CredentialsParameter != nil {
+			containerDef.SetRepositoryCredentials(*cocoa.NewRepositoryCredentials().
+				SetID(*def.RepositoryCredentials.CredentialsParameter).
+				SetOwned(false))
+		}
+
+		for _, pm := range def.PortMappings {
+			mapping := cocoa.NewPortMapping().SetContainerPort(int(utility.FromInt32Ptr(pm.ContainerPort)))
+			if hostPort := utility.FromInt32Ptr(pm.HostPort); hostPort > 0 {
+				mapping.SetHostPort(int(hostPort))
+			}
+			if pm.Protocol != "" {
+				mapping.SetProtocol(cocoa.PortProtocol(pm.Protocol))
+			}
+			if pm.Name != nil {
+				mapping.SetName(*pm.Name)
+			}
+			containerDef.AddPortMappings(*mapping)
+		}
+
+		for _, mp := range def.MountPoints {
+			sourcePath, ok := hostSourcePaths[utility.FromStringPtr(mp.SourceVolume)]
+			if !ok {
+				continue
+			}
+			containerDef.AddBindMounts(*cocoa.NewBindMount().
+				SetSourcePath(sourcePath).
+				SetContainerPath(utility.FromStringPtr(mp.ContainerPath)).
+				SetReadOnly(utility.FromBoolPtr(mp.ReadOnly)))
+		}
+
+		translated = append(translated, *containerDef)
+	}
+
+	return translated
+}
+
+// translateSecrets translates ECS secrets into equivalent cocoa environment
+// variables that reference existing secrets. The secrets are not owned by
+// cocoa.
+func translateSecrets(secrets []types.Secret) []cocoa.EnvironmentVariable {
+	var translated []cocoa.EnvironmentVariable
+	for _, secret := range secrets {
+		translated = append(translated, *cocoa.NewEnvironmentVariable().
+			SetName(utility.FromStringPtr(secret.Name)).
+			SetSecretOptions(*cocoa.NewSecretOptions().
+				SetID(utility.FromStringPtr(secret.ValueFrom)).
+				SetOwned(false)))
+	}
+	return translated
+}
+
 // DeletePodDefinition deletes a pod definition and deletes it from the cache if
 // it is using a cache.
 func (m *BasicPodDefinitionManager) DeletePodDefinition(ctx context.Context, id string) error {
@@ -149,18 +1147,31 @@ func (m *BasicPodDefinitionManager) DeletePodDefinition(ctx context.Context, id
 	return nil
 }
 
+// encryptsValues returns whether or not the vault encrypts the secret values it
+// stores, in which case ECS would give the encrypted values to the pods that
+// reference them.
+func encryptsValues(v cocoa.Vault) bool {
+	encrypter, ok := v.(cocoa.ValueEncrypter)
+	return ok && encrypter.EncryptsValues()
+}
+
 func (m *BasicPodDefinitionManager) usesCache() bool {
 	return m.cache != nil
 }
 
-// getCacheTag returns the configured or default cache tracking tag if it is
-// using a cache. If it is not caching, this returns the empty string.
+// getCacheTag returns the cache tracking tag if it is using a cache. The tag
+// configured on the pod definition manager takes precedence over the cache's
+// tag, and if neither is configured, this returns the default tag. If it is not
+// caching, this returns the empty string.
 func (m *BasicPodDefinitionManager) getCacheTag() string {
 	if !m.usesCache() {
 		return ""
 	}
+	if m.tagName != "" {
+		return m.tagName
+	}
 	if t := m.cache.GetTag(); t != "" {
 		return t
 	}
-	return defaultCacheTrackingTag
+	return DefaultPodDefinitionTagName
 }
@@ -2,19 +2,30 @@ package ecs
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/identity"
 	"github.com/evergreen-ci/cocoa/internal/testcase"
 	"github.com/evergreen-ci/cocoa/internal/testutil"
 	"github.com/evergreen-ci/cocoa/secret"
 	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip/level"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestBasicPodDefinitionManager(t *testing.T) {
 	assert.Implements(t, (*cocoa.ECSPodDefinitionManager)(nil), &BasicPodDefinitionManager{})
+	assert.Implements(t, (*cocoa.ECSPodDefinitionRevisionLister)(nil), &BasicPodDefinitionManager{})
+	assert.Implements(t, (*cocoa.ECSPodDefinitionImporter)(nil), &BasicPodDefinitionManager{})
+	assert.Implements(t, (*cocoa.ECSPodDefinitionUpdater)(nil), &BasicPodDefinitionManager{})
+	assert.Implements(t, (*cocoa.ECSPodDefinitionIntegrityVerifier)(nil), &BasicPodDefinitionManager{})
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -35,6 +46,92 @@ func TestBasicPodDefinitionManager(t *testing.T) {
 			assert.NoError(t, err)
 			assert.NotZero(t, pdm)
 		})
+		t.Run("SucceedsWithClientOptions", func(t *testing.T) {
+			pdm, err := NewBasicPodDefinitionManager(*NewBasicPodDefinitionManagerOptions().SetClientOptions(testutil.ValidNonIntegrationAWSOptions()))
+			assert.NoError(t, err)
+			require.NotZero(t, pdm)
+			assert.NotZero(t, pdm.client)
+			assert.Equal(t, pdm.ownedClient, pdm.client)
+		})
+	})
+	t.Run("Close", func(t *testing.T) {
+		t.Run("ClosesOwnedClient", func(t *testing.T) {
+			pdm, err := NewBasicPodDefinitionManager(*NewBasicPodDefinitionManagerOptions().SetClientOptions(testutil.ValidNonIntegrationAWSOptions()))
+			require.NoError(t, err)
+			require.NotZero(t, pdm.ownedClient)
+			assert.NoError(t, pdm.Close(ctx))
+			assert.NoError(t, pdm.Close(ctx), "closing should be idempotent")
+		})
+		t.Run("DoesNotOwnGivenClient", func(t *testing.T) {
+			c, err := NewBasicClient(ctx, testutil.ValidNonIntegrationAWSOptions())
+			require.NoError(t, err)
+			pdm, err := NewBasicPodDefinitionManager(*NewBasicPodDefinitionManagerOptions().SetClient(c))
+			require.NoError(t, err)
+			assert.Zero(t, pdm.ownedClient)
+			assert.NoError(t, pdm.Close(ctx))
+		})
+	})
+	t.Run("HashPodDefinition", func(t *testing.T) {
+		makeOpts := func(image string, envVarNames ...string) cocoa.ECSPodDefinitionOptions {
+			def := cocoa.NewECSContainerDefinition().SetImage(image)
+			for _, name := range envVarNames {
+				def.AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().SetName(name).SetValue("value"))
+			}
+			return *cocoa.NewECSPodDefinitionOptions().AddContainerDefinitions(*def)
+		}
+		t.Run("ConvergesWithNormalizers", func(t *testing.T) {
+			pdm, err := NewBasicPodDefinitionManager(*NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				AddNormalizers(cocoa.SortEnvironmentVariablesNormalizer(), cocoa.LowercaseImageHostsNormalizer()))
+			require.NoError(t, err)
+
+			h0, err := pdm.HashPodDefinition(makeOpts("Registry.Example.com/image", "a", "b"))
+			require.NoError(t, err)
+			h1, err := pdm.HashPodDefinition(makeOpts("registry.example.com/image", "b", "a"))
+			require.NoError(t, err)
+			assert.Equal(t, h0, h1)
+		})
+		t.Run("DiffersWithoutNormalizers", func(t *testing.T) {
+			pdm, err := NewBasicPodDefinitionManager(*NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()))
+			require.NoError(t, err)
+
+			h0, err := pdm.HashPodDefinition(makeOpts("Registry.Example.com/image"))
+			require.NoError(t, err)
+			h1, err := pdm.HashPodDefinition(makeOpts("registry.example.com/image"))
+			require.NoError(t, err)
+			assert.NotEqual(t, h0, h1)
+		})
+		t.Run("ExcludesFieldsWithHashOptions", func(t *testing.T) {
+			pdm, err := NewBasicPodDefinitionManager(*NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetHashOptions(*cocoa.NewHashOptions().AddExcludeTagKeys("job_id")))
+			require.NoError(t, err)
+
+			opts0 := makeOpts("image")
+			opts0.SetTags(map[string]string{"job_id": "job0"})
+			opts1 := makeOpts("image")
+			opts1.SetTags(map[string]string{"job_id": "job1"})
+
+			h0, err := pdm.HashPodDefinition(opts0)
+			require.NoError(t, err)
+			h1, err := pdm.HashPodDefinition(opts1)
+			require.NoError(t, err)
+			assert.Equal(t, h0, h1)
+			assert.NotEqual(t, opts0.Hash(), opts1.Hash())
+		})
+		t.Run("FailsWithNormalizerError", func(t *testing.T) {
+			pdm, err := NewBasicPodDefinitionManager(*NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				AddNormalizers(cocoa.DefinitionNormalizerFunc(func(cocoa.ECSPodDefinitionOptions) (cocoa.ECSPodDefinitionOptions, error) {
+					return cocoa.ECSPodDefinitionOptions{}, errors.New("fake error")
+				})))
+			require.NoError(t, err)
+
+			h, err := pdm.HashPodDefinition(makeOpts("image"))
+			assert.Error(t, err)
+			assert.Zero(t, h)
+		})
 	})
 }
 
@@ -111,6 +208,12 @@ func TestBasicPodDefinitionManagerOptions(t *testing.T) {
 		opts := NewBasicPodDefinitionManagerOptions().SetClient(c)
 		assert.Equal(t, c, opts.Client)
 	})
+	t.Run("SetClientOptions", func(t *testing.T) {
+		clientOpts := testutil.ValidNonIntegrationAWSOptions()
+		opts := NewBasicPodDefinitionManagerOptions().SetClientOptions(clientOpts)
+		require.NotZero(t, opts.ClientOptions)
+		assert.Equal(t, clientOpts, *opts.ClientOptions)
+	})
 	t.Run("SetVault", func(t *testing.T) {
 		c, err := secret.NewBasicSecretsManagerClient(ctx, testutil.ValidNonIntegrationAWSOptions())
 		require.NoError(t, err)
@@ -125,11 +228,112 @@ func TestBasicPodDefinitionManagerOptions(t *testing.T) {
 		require.NotZero(t, opts.Cache)
 		assert.Equal(t, pdc, opts.Cache)
 	})
+	t.Run("SetStrictValidation", func(t *testing.T) {
+		opts := NewBasicPodDefinitionManagerOptions().SetStrictValidation(true)
+		assert.True(t, opts.StrictValidation)
+	})
+	t.Run("SetActiveWaitOptions", func(t *testing.T) {
+		retryOpts := utility.RetryOptions{MaxAttempts: 5}
+		opts := NewBasicPodDefinitionManagerOptions().SetActiveWaitOptions(retryOpts)
+		require.NotZero(t, opts.ActiveWaitOpts)
+		assert.Equal(t, retryOpts, *opts.ActiveWaitOpts)
+	})
+	t.Run("SetImageValidationOptions", func(t *testing.T) {
+		imageOpts := NewImageValidationOptions().SetRequireDigest(true)
+		opts := NewBasicPodDefinitionManagerOptions().SetImageValidationOptions(*imageOpts)
+		require.NotZero(t, opts.ImageValidationOpts)
+		assert.Equal(t, *imageOpts, *opts.ImageValidationOpts)
+	})
+	t.Run("SetSecretLocationOptions", func(t *testing.T) {
+		locOpts := NewSecretLocationOptions().SetAccountID("123456789012")
+		opts := NewBasicPodDefinitionManagerOptions().SetSecretLocationOptions(*locOpts)
+		require.NotZero(t, opts.SecretLocationOpts)
+		assert.Equal(t, *locOpts, *opts.SecretLocationOpts)
+	})
+	t.Run("SetSecretTagPropagationOptions", func(t *testing.T) {
+		tagOpts := NewSecretTagPropagationOptions().AddKeys("cost-center")
+		opts := NewBasicPodDefinitionManagerOptions().SetSecretTagPropagationOptions(*tagOpts)
+		require.NotZero(t, opts.SecretTagPropagationOpts)
+		assert.Equal(t, *tagOpts, *opts.SecretTagPropagationOpts)
+	})
+	t.Run("SetEventSink", func(t *testing.T) {
+		sink := cocoa.NewGripEventSink(level.Info)
+		opts := NewBasicPodDefinitionManagerOptions().SetEventSink(sink)
+		assert.Equal(t, sink, opts.EventSink)
+	})
+	t.Run("SetNormalizers", func(t *testing.T) {
+		normalizers := []cocoa.DefinitionNormalizer{cocoa.SortEnvironmentVariablesNormalizer()}
+		opts := NewBasicPodDefinitionManagerOptions().SetNormalizers(normalizers)
+		assert.Len(t, opts.Normalizers, 1)
+	})
+	t.Run("AddNormalizers", func(t *testing.T) {
+		opts := NewBasicPodDefinitionManagerOptions().
+			AddNormalizers(cocoa.SortEnvironmentVariablesNormalizer()).
+			AddNormalizers(cocoa.LowercaseImageHostsNormalizer())
+		assert.Len(t, opts.Normalizers, 2)
+	})
+	t.Run("SetTagName", func(t *testing.T) {
+		opts := NewBasicPodDefinitionManagerOptions().SetTagName("tag")
+		assert.Equal(t, "tag", utility.FromStringPtr(opts.TagName))
+	})
+	t.Run("SetRevisionDescribeConcurrency", func(t *testing.T) {
+		opts := NewBasicPodDefinitionManagerOptions().SetRevisionDescribeConcurrency(10)
+		assert.Equal(t, 10, utility.FromIntPtr(opts.RevisionDescribeConcurrency))
+	})
 	t.Run("Validate", func(t *testing.T) {
 		t.Run("FailsWithEmpty", func(t *testing.T) {
 			opts := NewBasicPodDefinitionManagerOptions()
 			assert.Error(t, opts.Validate())
 		})
+		t.Run("SucceedsWithTagName", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetTagName("deployment-tracked")
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithVaultThatEncryptsValues", func(t *testing.T) {
+			p, err := secret.NewAEADDataKeyProvider(make([]byte, 32))
+			require.NoError(t, err)
+			v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetDataKeyProvider(p))
+			require.NoError(t, err)
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetVault(v)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithEmptyTagName", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetTagName("")
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithTagNameWithInvalidCharacters", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetTagName("tag#name")
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithReservedTagName", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetTagName("aws:tracked")
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithInvalidCacheTagName", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetCache(&testutil.NoopECSPodDefinitionCache{Tag: "tag#name"})
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("SucceedsWithValidTagNameOverridingInvalidCacheTagName", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetCache(&testutil.NoopECSPodDefinitionCache{Tag: "tag#name"}).
+				SetTagName("tag")
+			assert.NoError(t, opts.Validate())
+		})
 		t.Run("SucceedsWithAllFieldsPopulated", func(t *testing.T) {
 			ecsClient, err := NewBasicClient(ctx, testutil.ValidNonIntegrationAWSOptions())
 			require.NoError(t, err)
@@ -153,5 +357,175 @@ func TestBasicPodDefinitionManagerOptions(t *testing.T) {
 				SetCache(&testutil.NoopECSPodDefinitionCache{Tag: "tag"})
 			assert.Error(t, opts.Validate())
 		})
+		t.Run("SucceedsWithClientOptionsInsteadOfClient", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().SetClientOptions(testutil.ValidNonIntegrationAWSOptions())
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("DefaultsRevisionDescribeConcurrency", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().SetClientOptions(testutil.ValidNonIntegrationAWSOptions())
+			require.NoError(t, opts.Validate())
+			assert.Equal(t, defaultRevisionDescribeConcurrency, utility.FromIntPtr(opts.RevisionDescribeConcurrency))
+		})
+		t.Run("FailsWithNonPositiveRevisionDescribeConcurrency", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetRevisionDescribeConcurrency(0)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithInvalidImageValidationOptions", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetImageValidationOptions(*NewImageValidationOptions().SetPolicy("foo"))
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithInvalidSecretLocationOptions", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetSecretLocationOptions(*NewSecretLocationOptions().SetAccountID(""))
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithInvalidSecretTagPropagationOptions", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetSecretTagPropagationOptions(*NewSecretTagPropagationOptions().AddKeys(""))
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithNilNormalizer", func(t *testing.T) {
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				AddNormalizers(cocoa.SortEnvironmentVariablesNormalizer(), nil)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithBothClientAndClientOptions", func(t *testing.T) {
+			ecsClient, err := NewBasicClient(ctx, testutil.ValidNonIntegrationAWSOptions())
+			require.NoError(t, err)
+			opts := NewBasicPodDefinitionManagerOptions().
+				SetClient(ecsClient).
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions())
+			assert.Error(t, opts.Validate())
+		})
+	})
+}
+
+// revisionDescribeClient is an ECS client with a fixed set of task definition
+// revisions in a family and in another family that shares its prefix. It
+// records which revisions are described and how many are described at once.
+type revisionDescribeClient struct {
+	cocoa.ECSClient
+
+	mu        sync.Mutex
+	family    string
+	revs      int
+	otherRevs int
+	failARN   string
+	inFlight  int
+	maxSeen   int
+	described []string
+}
+
+func (c *revisionDescribeClient) arn(family string, rev int) string {
+	return identity.TaskDefinitionARN{
+		Location: identity.Location{Partition: "aws", Region: "us-east-1", AccountID: "123456789012"},
+		Family:   family,
+		Revision: rev,
+	}.String()
+}
+
+func (c *revisionDescribeClient) otherFamily() string {
+	return c.family + "-other"
+}
+
+func (c *revisionDescribeClient) ListTaskDefinitions(ctx context.Context, in *ecs.ListTaskDefinitionsInput) (*ecs.ListTaskDefinitionsOutput, error) {
+	if in.Status != types.TaskDefinitionStatusActive {
+		return &ecs.ListTaskDefinitionsOutput{}, nil
+	}
+	out := &ecs.ListTaskDefinitionsOutput{}
+	for rev := c.revs; rev > 0; rev-- {
+		out.TaskDefinitionArns = append(out.TaskDefinitionArns, c.arn(c.family, rev))
+	}
+	for rev := c.otherRevs; rev > 0; rev-- {
+		out.TaskDefinitionArns = append(out.TaskDefinitionArns, c.arn(c.otherFamily(), rev))
+	}
+	return out, nil
+}
+
+func (c *revisionDescribeClient) DescribeTaskDefinition(ctx context.Context, in *ecs.DescribeTaskDefinitionInput) (*ecs.DescribeTaskDefinitionOutput, error) {
+	id := utility.FromStringPtr(in.TaskDefinition)
+
+	c.mu.Lock()
+	c.described = append(c.described, id)
+	c.inFlight++
+	if c.inFlight > c.maxSeen {
+		c.maxSeen = c.inFlight
+	}
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+
+	if id == c.failARN {
+		return nil, errors.New("fake error")
+	}
+
+	parsed, err := identity.ParseTaskDefinitionARN(id)
+	if err != nil {
+		return nil, err
+	}
+	return &ecs.DescribeTaskDefinitionOutput{
+		TaskDefinition: &types.TaskDefinition{
+			TaskDefinitionArn: utility.ToStringPtr(id),
+			Family:            utility.ToStringPtr(parsed.Family),
+			Revision:          int32(parsed.Revision),
+			Status:            types.TaskDefinitionStatusActive,
+		},
+	}, nil
+}
+
+func TestListPodDefinitionRevisions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newManager := func(t *testing.T, c cocoa.ECSClient, concurrency int) *BasicPodDefinitionManager {
+		pdm, err := NewBasicPodDefinitionManager(*NewBasicPodDefinitionManagerOptions().
+			SetClient(c).
+			SetRevisionDescribeConcurrency(concurrency))
+		require.NoError(t, err)
+		return pdm
+	}
+
+	t.Run("ReturnsRevisionsInOrder", func(t *testing.T) {
+		c := &revisionDescribeClient{family: "family", revs: 5}
+		revisions, err := newManager(t, c, 2).ListPodDefinitionRevisions(ctx, "family")
+		require.NoError(t, err)
+		require.Len(t, revisions, 5)
+		for i, rev := range revisions {
+			assert.Equal(t, i+1, rev.Revision)
+			assert.Equal(t, c.arn("family", i+1), rev.ID)
+		}
+	})
+	t.Run("DoesNotDescribeRevisionsFromOtherFamilies", func(t *testing.T) {
+		c := &revisionDescribeClient{family: "family", revs: 2, otherRevs: 3}
+		revisions, err := newManager(t, c, 2).ListPodDefinitionRevisions(ctx, "family")
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		assert.ElementsMatch(t, []string{c.arn("family", 1), c.arn("family", 2)}, c.described)
+	})
+	t.Run("LimitsConcurrency", func(t *testing.T) {
+		c := &revisionDescribeClient{family: "family", revs: 10}
+		_, err := newManager(t, c, 3).ListPodDefinitionRevisions(ctx, "family")
+		require.NoError(t, err)
+		assert.LessOrEqual(t, c.maxSeen, 3)
+		assert.Greater(t, c.maxSeen, 1, "revisions should be described concurrently")
+	})
+	t.Run("FailsWhenDescribingAnyRevisionFails", func(t *testing.T) {
+		c := &revisionDescribeClient{family: "family", revs: 5}
+		c.failARN = c.arn("family", 3)
+		revisions, err := newManager(t, c, 2).ListPodDefinitionRevisions(ctx, "family")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), c.failARN)
+		assert.Empty(t, revisions)
 	})
 }
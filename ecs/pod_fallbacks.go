@@ -0,0 +1,142 @@
+package ecs
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+// FallbackPodResult is the result of creating a pod with fallback execution
+// options.
+type FallbackPodResult struct {
+	// Pod is the pod that was created.
+	Pod cocoa.ECSPod
+	// FallbackIndex is the index of the fallback execution options that the
+	// pod was run with. If the pod was run with the original execution
+	// options, this is -1.
+	FallbackIndex int
+}
+
+// CreatePodWithFallbacks creates a new pod backed by AWS ECS. If the pod cannot
+// run because there is not enough capacity (e.g. the cluster has insufficient
+// CPU or memory), it tries to run it again with each of the fallback execution
+// options in order until one succeeds. Each fallback is applied on top of the
+// pod's original execution options, so it only needs to specify what should
+// change (e.g. a different cluster, capacity provider, or placement). The pod
+// creator's defaults (e.g. the default AWSVPC options) apply to each fallback
+// the same way that they apply to the original execution options. The pod
+// definition is only created once, so fallbacks cannot override bind mounts or
+// secrets. Failures that are not due to capacity are returned immediately
+// without trying the remaining fallbacks.
+func (pc *BasicPodCreator) CreatePodWithFallbacks(ctx context.Context, opts cocoa.ECSPodCreationOptions, fallbacks []cocoa.ECSPodExecutionOptions) (*FallbackPodResult, error) {
+	f, err := newPodExecutionFallbacks(fallbacks)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid fallback execution options")
+	}
+
+	p, _, err := pc.createPodWithDefinition(ctx, f, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FallbackPodResult{
+		Pod:           p,
+		FallbackIndex: f.used,
+	}, nil
+}
+
+// podExecutionFallbacks are alternative execution options to run a pod with
+// when there is not enough capacity to run it with its original execution
+// options.
+type podExecutionFallbacks struct {
+	// fallbacks are the fallback execution options as they were given.
+	fallbacks []cocoa.ECSPodExecutionOptions
+	// opts are the fully-merged fallback execution options in the order that
+	// they should be tried. They are only set once the fallbacks are merged
+	// with the pod's original execution options.
+	opts []cocoa.ECSPodExecutionOptions
+	// used is the index of the fallback execution options that the pod was
+	// run with, or -1 if it was run with its original execution options.
+	used int
+}
+
+// newPodExecutionFallbacks checks that the fallbacks can share the pod
+// definition with the original execution options.
+func newPodExecutionFallbacks(fallbacks []cocoa.ECSPodExecutionOptions) (*podExecutionFallbacks, error) {
+	catcher := grip.NewBasicCatcher()
+	for i, fallback := range fallbacks {
+		catcher.ErrorfWhen(fallback.OverrideOpts != nil && fallback.OverrideOpts.RequiresNewDefinition(), "fallback %d cannot override bind mounts or secrets because the pod definition is shared with the original execution options", i)
+	}
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	return &podExecutionFallbacks{
+		fallbacks: fallbacks,
+		used:      -1,
+	}, nil
+}
+
+// merge merges each of the fallbacks on top of the pod's original execution
+// options and checks that they are valid. The original execution options must
+// already be fully resolved (e.g. with the default AWSVPC options), and the
+// same resolution is applied to each merged fallback with the resolve
+// function, since a fallback can replace the options that it depends on.
+func (f *podExecutionFallbacks) merge(original cocoa.ECSPodExecutionOptions, resolve func(cocoa.ECSPodExecutionOptions) cocoa.ECSPodExecutionOptions) error {
+	catcher := grip.NewBasicCatcher()
+	merged := make([]cocoa.ECSPodExecutionOptions, 0, len(f.fallbacks))
+	for i, fallback := range f.fallbacks {
+		execOpts := resolve(cocoa.MergeECSPodExecutionOptions(original, fallback))
+		if err := execOpts.Validate(); err != nil {
+			catcher.Wrapf(err, "fallback %d", i)
+			continue
+		}
+		merged = append(merged, execOpts)
+	}
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	f.opts = merged
+	return nil
+}
+
+// runTaskWithFallbacks runs the task with the execution options. If the task
+// cannot run due to insufficient capacity, it tries each of the fallback
+// execution options in order. It returns the task along with the execution
+// options that it was run with.
+func (pc *BasicPodCreator) runTaskWithFallbacks(ctx context.Context, opts cocoa.ECSPodExecutionOptions, def cocoa.ECSTaskDefinition, fallbacks *podExecutionFallbacks) (*types.Task, cocoa.ECSPodExecutionOptions, error) {
+	task, err := pc.runTask(ctx, opts, def)
+	if err == nil || fallbacks == nil {
+		return task, opts, err
+	}
+
+	for i, fallbackOpts := range fallbacks.opts {
+		if !cocoa.IsCapacityRunTaskFailure(err) {
+			return nil, opts, err
+		}
+
+		grip.Debug(message.WrapError(err, message.Fields{
+			"message":          "insufficient capacity to run task, trying fallback execution options",
+			"task_definition":  utility.FromStringPtr(def.ID),
+			"failed_cluster":   utility.FromStringPtr(opts.Cluster),
+			"fallback":         i,
+			"fallback_cluster": utility.FromStringPtr(fallbackOpts.Cluster),
+		}))
+
+		opts = fallbackOpts
+		task, err = pc.runTask(ctx, opts, def)
+		if err == nil {
+			fallbacks.used = i
+			return task, opts, nil
+		}
+		err = errors.Wrapf(err, "running task with fallback %d", i)
+	}
+
+	return nil, opts, err
+}
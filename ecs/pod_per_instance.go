@@ -0,0 +1,131 @@
+package ecs
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// maxStartTaskContainerInstances is the maximum number of container instances
+// that ECS can start tasks on in a single request.
+const maxStartTaskContainerInstances = 10
+
+// CreatePodPerInstance creates one pod from an existing definition on every
+// active container instance in the cluster, which is useful for running
+// node-level agents (e.g. log shippers or monitors). It returns a mapping of
+// each container instance ARN to the pod created on it.
+//
+// Since the pods are started on specific container instances, the execution
+// options cannot specify a capacity provider or placement options. If some of
+// the pods cannot be created, it returns the pods that were created along with
+// the error so that the caller can clean them up. Like
+// CreatePodFromExistingDefinition, any deferred secrets in the definition must
+// be resolved with ResolveDeferredSecrets beforehand.
+func (pc *BasicPodCreator) CreatePodPerInstance(ctx context.Context, def cocoa.ECSTaskDefinition, opts ...cocoa.ECSPodExecutionOptions) (map[string]cocoa.ECSPod, error) {
+	if err := def.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid task definition")
+	}
+
+	mergedPodExecutionOpts := cocoa.MergeECSPodExecutionOptions(opts...)
+	// The placement options must be checked before validating since
+	// validation sets default placement options.
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(mergedPodExecutionOpts.CapacityProvider != nil, "cannot specify a capacity provider when creating a pod on every container instance")
+	catcher.NewWhen(mergedPodExecutionOpts.PlacementOpts != nil, "cannot specify placement options when creating a pod on every container instance")
+	catcher.Add(validateExistingDefinitionOverrides(mergedPodExecutionOpts.OverrideOpts))
+	catcher.Add(validateExistingDefinitionDeferredSecrets(mergedPodExecutionOpts.DeferredSecretValues))
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+	ctx = contextWithAssumeRole(ctx, mergedPodExecutionOpts.AssumeRoleOpts)
+	mergedPodExecutionOpts, err := pc.withDefaultExistingDefinitionAWSVPCOptions(ctx, def, mergedPodExecutionOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting default AWSVPC options")
+	}
+	if err := mergedPodExecutionOpts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid pod execution options")
+	}
+
+	instances, err := listActiveContainerInstances(ctx, pc.client, mergedPodExecutionOpts.Cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing container instances")
+	}
+
+	taskDef := cocoa.NewECSTaskDefinition().
+		SetID(utility.FromStringPtr(def.ID)).
+		SetOwned(utility.FromBoolPtr(def.Owned))
+
+	pods := map[string]cocoa.ECSPod{}
+	for start := 0; start < len(instances); start += maxStartTaskContainerInstances {
+		end := start + maxStartTaskContainerInstances
+		if end > len(instances) {
+			end = len(instances)
+		}
+
+		out, err := pc.client.StartTask(ctx, pc.exportStartTaskInput(mergedPodExecutionOpts, *taskDef, instances[start:end]))
+		if err != nil {
+			catcher.Wrap(err, "starting tasks")
+			break
+		}
+		for _, f := range out.Failures {
+			catcher.Wrapf(ConvertFailureToError(f), "starting task on container instance '%s'", utility.FromStringPtr(f.Arn))
+		}
+
+		for _, task := range out.Tasks {
+			instance := utility.FromStringPtr(task.ContainerInstanceArn)
+			p, err := pc.createPod(utility.FromStringPtr(mergedPodExecutionOpts.Cluster), task, *taskDef, nil, mergedPodExecutionOpts.ProtectionPolicy)
+			if err != nil {
+				catcher.Wrapf(err, "creating pod on container instance '%s' after requesting task", instance)
+				continue
+			}
+
+			sendEvent(ctx, pc.eventSink, p.newEvent(cocoa.EventTypePodCreated))
+
+			pods[instance] = p
+		}
+	}
+
+	return pods, catcher.Resolve()
+}
+
+// listActiveContainerInstances lists the ARNs of all the active container
+// instances in the cluster.
+func listActiveContainerInstances(ctx context.Context, c cocoa.ECSClient, cluster *string) ([]string, error) {
+	var instances []string
+	in := &ecs.ListContainerInstancesInput{
+		Cluster: cluster,
+		Status:  types.ContainerInstanceStatusActive,
+	}
+	for {
+		out, err := c.ListContainerInstances(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, out.ContainerInstanceArns...)
+
+		if out.NextToken == nil {
+			return instances, nil
+		}
+		in.NextToken = out.NextToken
+	}
+}
+
+// exportStartTaskInput converts execution options and a task definition into an
+// ECS input to start tasks on the given container instances.
+func (pc *BasicPodCreator) exportStartTaskInput(opts cocoa.ECSPodExecutionOptions, taskDef cocoa.ECSTaskDefinition, instances []string) *ecs.StartTaskInput {
+	return &ecs.StartTaskInput{
+		Cluster:              opts.Cluster,
+		ContainerInstances:   instances,
+		TaskDefinition:       taskDef.ID,
+		Tags:                 ExportTags(opts.RunTags(time.Now())),
+		EnableExecuteCommand: utility.FromBoolPtr(opts.SupportsDebugMode),
+		Overrides:            pc.exportOverrides(opts.OverrideOpts),
+		NetworkConfiguration: exportAWSVPCOptions(opts.AWSVPCOpts),
+	}
+}
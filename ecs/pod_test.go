@@ -9,12 +9,16 @@ import (
 	"github.com/evergreen-ci/cocoa/internal/testutil"
 	"github.com/evergreen-ci/cocoa/secret"
 	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip/level"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestBasicPod(t *testing.T) {
 	assert.Implements(t, (*cocoa.ECSPod)(nil), &BasicPod{})
+	assert.Implements(t, (*cocoa.ECSPodPendingDiagnoser)(nil), &BasicPod{})
+	assert.Implements(t, (*cocoa.ECSPodExporter)(nil), &BasicPod{})
+	assert.Implements(t, (*cocoa.ECSPodProtectedDeleter)(nil), &BasicPod{})
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -43,6 +47,33 @@ func TestBasicPod(t *testing.T) {
 			assert.Equal(t, *res, podRes)
 			assert.Equal(t, *ps, p.StatusInfo())
 		},
+		"RestoresFromExportedState": func(ctx context.Context, t *testing.T, c cocoa.ECSClient) {
+			res := cocoa.NewECSPodResources().
+				SetTaskID("task_id").
+				SetCluster("cluster").
+				AddContainers(*cocoa.NewECSContainerResources().
+					SetContainerID("container_id").
+					SetName("name"))
+			ps := cocoa.NewECSPodStatusInfo().
+				SetStatus(cocoa.StatusRunning).
+				AddContainers(*cocoa.NewECSContainerStatusInfo().
+					SetContainerID("container_id").
+					SetName("name").
+					SetStatus(cocoa.StatusRunning))
+			p, err := NewBasicPod(NewBasicPodOptions().SetClient(c).SetResources(*res).SetStatusInfo(*ps))
+			require.NoError(t, err)
+
+			restored, err := NewBasicPodFromState(c, nil, p.Export())
+			require.NoError(t, err)
+			assert.Equal(t, *res, restored.Resources())
+			assert.Equal(t, *ps, restored.StatusInfo())
+			assert.Equal(t, p.Export(), restored.Export())
+		},
+		"RestoreFailsWithInvalidState": func(ctx context.Context, t *testing.T, c cocoa.ECSClient) {
+			p, err := NewBasicPodFromState(c, nil, cocoa.ECSPodState{Status: cocoa.StatusRunning})
+			assert.Error(t, err)
+			assert.Zero(t, p)
+		},
 	} {
 		t.Run(tName, func(t *testing.T) {
 			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
@@ -132,6 +163,27 @@ func TestBasicPodOptions(t *testing.T) {
 		require.NotNil(t, opts.StatusInfo)
 		assert.Equal(t, *ps, *opts.StatusInfo)
 	})
+	t.Run("SetProtectionPolicy", func(t *testing.T) {
+		pp := cocoa.NewECSPodProtectionPolicy().SetTaskDefinition(true)
+		opts := NewBasicPodOptions().SetProtectionPolicy(*pp)
+		require.NotZero(t, opts.ProtectionPolicy)
+		assert.Equal(t, *pp, *opts.ProtectionPolicy)
+	})
+	t.Run("SetEventSink", func(t *testing.T) {
+		sink := cocoa.NewGripEventSink(level.Info)
+		opts := NewBasicPodOptions().SetEventSink(sink)
+		assert.Equal(t, sink, opts.EventSink)
+	})
+	t.Run("SetSecretUsageTracker", func(t *testing.T) {
+		tracker := cocoa.NewMemorySecretUsageTracker()
+		opts := NewBasicPodOptions().SetSecretUsageTracker(tracker)
+		assert.Equal(t, tracker, opts.SecretUsageTracker)
+	})
+	t.Run("SetDeregistrationPolicy", func(t *testing.T) {
+		opts := NewBasicPodOptions().SetDeregistrationPolicy(DeregistrationPolicyNever)
+		require.NotZero(t, opts.DeregistrationPolicy)
+		assert.Equal(t, DeregistrationPolicyNever, *opts.DeregistrationPolicy)
+	})
 	t.Run("Validate", func(t *testing.T) {
 		validResources := func() cocoa.ECSPodResources {
 			return *cocoa.NewECSPodResources().
@@ -204,6 +256,27 @@ func TestBasicPodOptions(t *testing.T) {
 				SetStatusInfo(validStatusInfo())
 			assert.Error(t, opts.Validate())
 		})
+		t.Run("DefaultsDeregistrationPolicy", func(t *testing.T) {
+			ecsClient, err := NewBasicClient(ctx, testutil.ValidNonIntegrationAWSOptions())
+			require.NoError(t, err)
+			opts := NewBasicPodOptions().
+				SetClient(ecsClient).
+				SetResources(validResources()).
+				SetStatusInfo(validStatusInfo())
+			require.NoError(t, opts.Validate())
+			require.NotZero(t, opts.DeregistrationPolicy)
+			assert.Equal(t, DeregistrationPolicyRespectOwned, *opts.DeregistrationPolicy)
+		})
+		t.Run("FailsWithInvalidDeregistrationPolicy", func(t *testing.T) {
+			ecsClient, err := NewBasicClient(ctx, testutil.ValidNonIntegrationAWSOptions())
+			require.NoError(t, err)
+			opts := NewBasicPodOptions().
+				SetClient(ecsClient).
+				SetResources(validResources()).
+				SetStatusInfo(validStatusInfo()).
+				SetDeregistrationPolicy("foo")
+			assert.Error(t, opts.Validate())
+		})
 		t.Run("FailsWithoutStatus", func(t *testing.T) {
 			ecsClient, err := NewBasicClient(ctx, testutil.ValidNonIntegrationAWSOptions())
 			require.NoError(t, err)
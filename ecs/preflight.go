@@ -0,0 +1,358 @@
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// PreflightCheckName is the name of a single preflight check.
+type PreflightCheckName string
+
+const (
+	// PreflightCheckCluster checks that the cluster exists and is active.
+	PreflightCheckCluster PreflightCheckName = "cluster"
+	// PreflightCheckTaskRole checks that the pod's task role exists and can be
+	// assumed by ECS tasks.
+	PreflightCheckTaskRole PreflightCheckName = "task-role"
+	// PreflightCheckExecutionRole checks that the pod's execution role exists
+	// and can be assumed by ECS tasks.
+	PreflightCheckExecutionRole PreflightCheckName = "execution-role"
+	// PreflightCheckSubnets checks that the pod's subnets exist.
+	PreflightCheckSubnets PreflightCheckName = "subnets"
+	// PreflightCheckSecurityGroups checks that the pod's security groups
+	// exist.
+	PreflightCheckSecurityGroups PreflightCheckName = "security-groups"
+	// PreflightCheckNetworkConfiguration checks that the pod's subnets and
+	// security groups belong to the same VPC and that the subnets have
+	// available IP addresses for the pod.
+	PreflightCheckNetworkConfiguration PreflightCheckName = "network-configuration"
+)
+
+// clusterStatusActive is the status of an ECS cluster that can run tasks.
+const clusterStatusActive = "ACTIVE"
+
+// PreflightCheckResult is the result of a single preflight check.
+type PreflightCheckResult struct {
+	// Name is the name of the check.
+	Name PreflightCheckName
+	// Passed indicates whether or not the check succeeded.
+	Passed bool
+	// Skipped indicates that the check was not performed, either because
+	// there was nothing to check or because the client needed to perform the
+	// check was not given.
+	Skipped bool
+	// Message describes the outcome of the check.
+	Message string
+}
+
+// PreflightReport is the structured result of running all the preflight checks
+// for a pod.
+type PreflightReport struct {
+	// Results are the results of each individual check.
+	Results []PreflightCheckResult
+}
+
+// Passed returns whether or not all the preflight checks passed or were
+// skipped.
+func (r *PreflightReport) Passed() bool {
+	return len(r.Failures()) == 0
+}
+
+// Failures returns the results of the checks that failed.
+func (r *PreflightReport) Failures() []PreflightCheckResult {
+	var failures []PreflightCheckResult
+	for _, res := range r.Results {
+		if !res.Passed && !res.Skipped {
+			failures = append(failures, res)
+		}
+	}
+	return failures
+}
+
+// Error returns an error describing all the failed checks. If all the checks
+// passed, this returns nil.
+func (r *PreflightReport) Error() error {
+	catcher := grip.NewBasicCatcher()
+	for _, res := range r.Failures() {
+		catcher.Errorf("preflight check '%s' failed: %s", res.Name, res.Message)
+	}
+	return catcher.Resolve()
+}
+
+func (r *PreflightReport) pass(name PreflightCheckName, msg string) {
+	r.Results = append(r.Results, PreflightCheckResult{Name: name, Passed: true, Message: msg})
+}
+
+func (r *PreflightReport) fail(name PreflightCheckName, msg string) {
+	r.Results = append(r.Results, PreflightCheckResult{Name: name, Message: msg})
+}
+
+func (r *PreflightReport) skip(name PreflightCheckName, msg string) {
+	r.Results = append(r.Results, PreflightCheckResult{Name: name, Skipped: true, Message: msg})
+}
+
+// PreflightCheckerOptions are options to create a preflight checker.
+type PreflightCheckerOptions struct {
+	// ECSClient is the client used to check the cluster. This is required.
+	ECSClient cocoa.ECSClient
+	// IAMClient is the client used to check the pod's roles. If this is not
+	// specified, the role checks are skipped.
+	IAMClient cocoa.IAMClient
+	// EC2Client is the client used to check the pod's networking
+	// configuration. If this is not specified, the networking checks are
+	// skipped.
+	EC2Client cocoa.EC2Client
+}
+
+// NewPreflightCheckerOptions returns new uninitialized options to create a
+// preflight checker.
+func NewPreflightCheckerOptions() *PreflightCheckerOptions {
+	return &PreflightCheckerOptions{}
+}
+
+// SetECSClient sets the client used to check the cluster.
+func (o *PreflightCheckerOptions) SetECSClient(c cocoa.ECSClient) *PreflightCheckerOptions {
+	o.ECSClient = c
+	return o
+}
+
+// SetIAMClient sets the client used to check the pod's roles.
+func (o *PreflightCheckerOptions) SetIAMClient(c cocoa.IAMClient) *PreflightCheckerOptions {
+	o.IAMClient = c
+	return o
+}
+
+// SetEC2Client sets the client used to check the pod's networking
+// configuration.
+func (o *PreflightCheckerOptions) SetEC2Client(c cocoa.EC2Client) *PreflightCheckerOptions {
+	o.EC2Client = c
+	return o
+}
+
+// Validate checks that the required parameters to initialize a preflight
+// checker are given.
+func (o *PreflightCheckerOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.ECSClient == nil, "must specify an ECS client")
+	return catcher.Resolve()
+}
+
+// PreflightChecker checks that the AWS resources that a pod depends on exist
+// before attempting to create the pod.
+type PreflightChecker struct {
+	ecs cocoa.ECSClient
+	iam cocoa.IAMClient
+	ec2 cocoa.EC2Client
+}
+
+// NewPreflightChecker creates a new preflight checker.
+func NewPreflightChecker(opts PreflightCheckerOptions) (*PreflightChecker, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid options")
+	}
+	return &PreflightChecker{
+		ecs: opts.ECSClient,
+		iam: opts.IAMClient,
+		ec2: opts.EC2Client,
+	}, nil
+}
+
+// Check runs all the preflight checks for the pod that would be created from
+// the given options and returns a report of the results. Checks that fail
+// because a resource is missing or unusable are recorded in the report; an
+// error is only returned if the options are invalid or a check could not be
+// performed.
+func (c *PreflightChecker) Check(ctx context.Context, opts ...cocoa.ECSPodCreationOptions) (*PreflightReport, error) {
+	mergedOpts := cocoa.MergeECSPodCreationOptions(opts...)
+	if err := mergedOpts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid pod creation options")
+	}
+	execOpts := mergedOpts.ExecutionOpts
+	if execOpts == nil {
+		execOpts = cocoa.NewECSPodExecutionOptions()
+	}
+
+	report := &PreflightReport{}
+
+	if err := c.checkCluster(ctx, report, execOpts.Cluster); err != nil {
+		return nil, errors.Wrap(err, "checking cluster")
+	}
+	if err := c.checkRole(ctx, report, PreflightCheckTaskRole, mergedOpts.DefinitionOpts.TaskRole); err != nil {
+		return nil, errors.Wrap(err, "checking task role")
+	}
+	if err := c.checkRole(ctx, report, PreflightCheckExecutionRole, mergedOpts.DefinitionOpts.ExecutionRole); err != nil {
+		return nil, errors.Wrap(err, "checking execution role")
+	}
+
+	var subnets, securityGroups []string
+	if execOpts.AWSVPCOpts != nil {
+		subnets = execOpts.AWSVPCOpts.Subnets
+		securityGroups = execOpts.AWSVPCOpts.SecurityGroups
+	}
+	if err := c.checkNetworkResources(ctx, report, PreflightCheckSubnets, subnets, c.describeSubnets); err != nil {
+		return nil, errors.Wrap(err, "checking subnets")
+	}
+	if err := c.checkNetworkResources(ctx, report, PreflightCheckSecurityGroups, securityGroups, c.describeSecurityGroups); err != nil {
+		return nil, errors.Wrap(err, "checking security groups")
+	}
+	if err := c.checkNetworkConfiguration(ctx, report, execOpts.AWSVPCOpts); err != nil {
+		return nil, errors.Wrap(err, "checking network configuration")
+	}
+
+	return report, nil
+}
+
+// checkCluster checks that the cluster exists and is active. If no cluster is
+// given, it checks the default cluster. The number of tasks already in the
+// cluster is included in the result so that callers can tell how close the
+// cluster is to its limits.
+func (c *PreflightChecker) checkCluster(ctx context.Context, report *PreflightReport, cluster *string) error {
+	in := &ecs.DescribeClustersInput{}
+	name := "default"
+	if cluster != nil {
+		in.Clusters = []string{*cluster}
+		name = *cluster
+	}
+
+	out, err := c.ecs.DescribeClusters(ctx, in)
+	if err != nil {
+		return err
+	}
+	if len(out.Clusters) == 0 {
+		report.fail(PreflightCheckCluster, fmt.Sprintf("cluster '%s' does not exist", name))
+		return nil
+	}
+
+	info := out.Clusters[0]
+	if status := utility.FromStringPtr(info.Status); status != clusterStatusActive {
+		report.fail(PreflightCheckCluster, fmt.Sprintf("cluster '%s' has status '%s' but must be active", name, status))
+		return nil
+	}
+
+	report.pass(PreflightCheckCluster, fmt.Sprintf("cluster '%s' is active with %d running and %d pending tasks", name, info.RunningTasksCount, info.PendingTasksCount))
+
+	return nil
+}
+
+// checkRole checks that the role exists and can be assumed by ECS tasks if one
+// is given.
+func (c *PreflightChecker) checkRole(ctx context.Context, report *PreflightReport, name PreflightCheckName, role *string) error {
+	if role == nil {
+		report.skip(name, "no role specified")
+		return nil
+	}
+	if c.iam == nil {
+		report.skip(name, "no IAM client specified")
+		return nil
+	}
+
+	err := ValidateRole(ctx, c.iam, *role)
+	if cocoa.IsRoleMisconfigurationError(err) {
+		report.fail(name, err.Error())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	report.pass(name, fmt.Sprintf("role '%s' exists and can be assumed by ECS tasks", *role))
+
+	return nil
+}
+
+// checkNetworkResources checks that all the given networking resources exist
+// using the describe function.
+func (c *PreflightChecker) checkNetworkResources(ctx context.Context, report *PreflightReport, name PreflightCheckName, ids []string, describe func(context.Context, []string) ([]string, error)) error {
+	if len(ids) == 0 {
+		report.skip(name, "no resources specified")
+		return nil
+	}
+	if c.ec2 == nil {
+		report.skip(name, "no EC2 client specified")
+		return nil
+	}
+
+	found, err := describe(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if !utility.StringSliceContains(found, id) {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) != 0 {
+		report.fail(name, fmt.Sprintf("resources do not exist: %s", strings.Join(missing, ", ")))
+		return nil
+	}
+
+	report.pass(name, fmt.Sprintf("resources exist: %s", strings.Join(ids, ", ")))
+
+	return nil
+}
+
+// checkNetworkConfiguration checks that the networking resources can be used
+// together to run the pod. This is only checked once all the networking
+// resources are known to exist.
+func (c *PreflightChecker) checkNetworkConfiguration(ctx context.Context, report *PreflightReport, awsvpcOpts *cocoa.AWSVPCOptions) error {
+	if awsvpcOpts == nil {
+		report.skip(PreflightCheckNetworkConfiguration, "no AWSVPC configuration specified")
+		return nil
+	}
+	if c.ec2 == nil {
+		report.skip(PreflightCheckNetworkConfiguration, "no EC2 client specified")
+		return nil
+	}
+	for _, res := range report.Failures() {
+		if res.Name == PreflightCheckSubnets || res.Name == PreflightCheckSecurityGroups {
+			report.skip(PreflightCheckNetworkConfiguration, "networking resources do not exist")
+			return nil
+		}
+	}
+
+	err := ValidateAWSVPCNetworking(ctx, *awsvpcOpts, *NewNetworkValidationOptions().SetClient(c.ec2))
+	if cocoa.IsNetworkMisconfigurationError(err) {
+		report.fail(PreflightCheckNetworkConfiguration, err.Error())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	report.pass(PreflightCheckNetworkConfiguration, "subnets and security groups belong to the same VPC and have available IP addresses")
+
+	return nil
+}
+
+func (c *PreflightChecker) describeSubnets(ctx context.Context, ids []string) ([]string, error) {
+	subnets, err := c.ec2.DescribeSubnets(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	found := make([]string, 0, len(subnets))
+	for _, s := range subnets {
+		found = append(found, s.ID)
+	}
+	return found, nil
+}
+
+func (c *PreflightChecker) describeSecurityGroups(ctx context.Context, ids []string) ([]string, error) {
+	securityGroups, err := c.ec2.DescribeSecurityGroups(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	found := make([]string, 0, len(securityGroups))
+	for _, sg := range securityGroups {
+		found = append(found, sg.ID)
+	}
+	return found, nil
+}
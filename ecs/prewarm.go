@@ -0,0 +1,242 @@
+package ecs
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// defaultPrewarmConcurrency is the default maximum number of pod definitions
+// that can be registered at once when prewarming definitions.
+const defaultPrewarmConcurrency = 4
+
+// warmPool contains pod definitions that were registered ahead of time so that
+// pods can be created from them without registering a new pod definition.
+type warmPool struct {
+	mu    sync.RWMutex
+	items map[string][]prewarmedDefinition
+}
+
+// prewarmedDefinition is a pod definition that was registered ahead of time
+// along with the options that were originally requested for it.
+type prewarmedDefinition struct {
+	// requested are the pod definition options as they were requested, before
+	// any defaults were set or any secrets were created. They are the options
+	// as the pod definition manager hashes them, so they exclude any fields
+	// that are excluded from the hash.
+	requested cocoa.ECSPodDefinitionOptions
+	item      cocoa.ECSPodDefinitionItem
+}
+
+func newWarmPool() *warmPool {
+	return &warmPool{items: map[string][]prewarmedDefinition{}}
+}
+
+// get returns the prewarmed pod definition item matching the requested pod
+// definition options with the given hash. The options must be the ones
+// returned by the pod definition manager along with the hash, so that pod
+// definitions that only differ in the fields excluded from the hash match. If
+// there is no match, it returns nil.
+func (p *warmPool) get(hash string, opts cocoa.ECSPodDefinitionOptions) *cocoa.ECSPodDefinitionItem {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, def := range p.items[hash] {
+		if def.requested.Equals(opts) {
+			item := def.item
+			return &item
+		}
+	}
+	return nil
+}
+
+// put adds the pod definition item to the warm pool for the requested pod
+// definition options with the given hash.
+func (p *warmPool) put(hash string, opts cocoa.ECSPodDefinitionOptions, item cocoa.ECSPodDefinitionItem) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, def := range p.items[hash] {
+		if def.requested.Equals(opts) {
+			return
+		}
+	}
+	p.items[hash] = append(p.items[hash], prewarmedDefinition{requested: opts, item: item})
+}
+
+// PrewarmDefinitions registers the given pod definitions ahead of time so that
+// pods created later with the same pod definition options can skip
+// registering a pod definition. Pod definitions that were already prewarmed
+// are reused rather than registered again. Up to the prewarm concurrency limit
+// of pod definitions are registered at once. The returned items are in the
+// same order as the given pod definition options; if any of them fails to
+// register, its item is left empty and the error is returned along with the
+// items that succeeded.
+//
+// Pods created from a prewarmed pod definition do not own the pod definition
+// or its secrets, since they may be shared between many pods. For the same
+// reason, pod definitions with deferred secrets cannot be prewarmed, since
+// resolving them for one pod would change them for every other pod.
+func (pc *BasicPodCreator) PrewarmDefinitions(ctx context.Context, defs []cocoa.ECSPodDefinitionOptions) ([]cocoa.ECSPodDefinitionItem, error) {
+	pdm, err := pc.newPodDefinitionManager()
+	if err != nil {
+		return nil, errors.Wrap(err, "initializing pod definition manager")
+	}
+
+	items := make([]cocoa.ECSPodDefinitionItem, len(defs))
+	catcher := grip.NewBasicCatcher()
+	var catcherMu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, pc.prewarmConcurrency)
+
+	// Equivalent pod definitions in the same batch are only registered once.
+	hashes := make([]string, len(defs))
+	hashed := make([]cocoa.ECSPodDefinitionOptions, len(defs))
+	indicesByDef := map[int][]int{}
+	var unique []int
+	for i, def := range defs {
+		if hashes[i], hashed[i], err = pdm.hashedPodDefinition(def); err != nil {
+			catcher.Wrapf(err, "hashing pod definition at index %d named '%s'", i, utility.FromStringPtr(def.Name))
+			continue
+		}
+		dupe := false
+		for _, j := range unique {
+			if hashes[j] == hashes[i] && hashed[j].Equals(hashed[i]) {
+				indicesByDef[j] = append(indicesByDef[j], i)
+				dupe = true
+				break
+			}
+		}
+		if !dupe {
+			unique = append(unique, i)
+			indicesByDef[i] = []int{i}
+		}
+	}
+
+	for _, i := range unique {
+		def := defs[i]
+		if len(cocoa.DeferredSecretNames(def)) != 0 {
+			catcherMu.Lock()
+			catcher.Errorf("cannot prewarm pod definition at index %d named '%s' because it has deferred secrets", i, utility.FromStringPtr(def.Name))
+			catcherMu.Unlock()
+			continue
+		}
+		if item := pc.warmPool.get(hashes[i], hashed[i]); item != nil {
+			for _, j := range indicesByDef[i] {
+				items[j] = *item
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, def cocoa.ECSPodDefinitionOptions) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				catcherMu.Lock()
+				catcher.Wrapf(ctx.Err(), "prewarming pod definition at index %d", i)
+				catcherMu.Unlock()
+				return
+			}
+
+			// Creating the pod definition can modify the options (e.g. by
+			// setting defaults), so the originally requested options are
+			// matched against later requests.
+			item, _, err := pdm.createPodDefinition(ctx, nil, def)
+			if err != nil {
+				catcherMu.Lock()
+				catcher.Wrapf(err, "prewarming pod definition at index %d named '%s'", i, utility.FromStringPtr(def.Name))
+				catcherMu.Unlock()
+				return
+			}
+
+			item.Prewarmed = true
+			pc.warmPool.put(hashes[i], hashed[i], *item)
+			for _, j := range indicesByDef[i] {
+				items[j] = *item
+			}
+		}(i, def)
+	}
+
+	wg.Wait()
+
+	return items, catcher.Resolve()
+}
+
+// createPodFromPrewarmedDefinition creates a new pod backed by AWS ECS from a
+// pod definition that was already prewarmed. The pod does not own the pod
+// definition or its secrets because they may be shared with other pods.
+func (pc *BasicPodCreator) createPodFromPrewarmedDefinition(ctx context.Context, stages *podCreationStages, item cocoa.ECSPodDefinitionItem, opts cocoa.ECSPodExecutionOptions, fallbacks *podExecutionFallbacks) (*BasicPod, *cocoa.ECSPodDefinitionItem, error) {
+	taskDef := cocoa.NewECSTaskDefinition().
+		SetID(item.ID).
+		SetOwned(false)
+
+	// The prewarmed pod definition is shared between pods, so it has no
+	// deferred secrets that could be resolved for this pod.
+	if err := cocoa.ValidateDeferredSecretValues(item.DefinitionOpts, opts.DeferredSecretValues); err != nil {
+		return nil, nil, errors.Wrap(err, "unresolved deferred secrets")
+	}
+
+	var task *types.Task
+	if err := stages.run(ctx, cocoa.ECSPodCreationStageRun, func(ctx context.Context) error {
+		var err error
+		task, opts, err = pc.runTaskWithFallbacks(ctx, opts, *taskDef, fallbacks)
+		return err
+	}); err != nil {
+		return nil, nil, errors.Wrap(err, "running task")
+	}
+
+	p, err := pc.translatePod(ctx, stages, *task, *taskDef, withUnownedSecrets(item.DefinitionOpts.ContainerDefinitions), opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sendEvent(ctx, pc.eventSink, p.newEvent(cocoa.EventTypePodCreated))
+
+	return p, &item, nil
+}
+
+// withUnownedSecrets returns a copy of the container definitions in which
+// none of the secrets are owned.
+func withUnownedSecrets(defs []cocoa.ECSContainerDefinition) []cocoa.ECSContainerDefinition {
+	unowned := make([]cocoa.ECSContainerDefinition, 0, len(defs))
+	for _, def := range defs {
+		def.EnvVars = withUnownedSecretEnvVars(def.EnvVars)
+		if def.LogConfiguration != nil {
+			logConf := *def.LogConfiguration
+			logConf.SecretOptions = withUnownedSecretEnvVars(logConf.SecretOptions)
+			def.LogConfiguration = &logConf
+		}
+		if def.RepoCreds != nil {
+			repoCreds := *def.RepoCreds
+			def.RepoCreds = repoCreds.SetOwned(false)
+		}
+		unowned = append(unowned, def)
+	}
+	return unowned
+}
+
+// withUnownedSecretEnvVars returns a copy of the environment variables in
+// which none of the secrets are owned.
+func withUnownedSecretEnvVars(envVars []cocoa.EnvironmentVariable) []cocoa.EnvironmentVariable {
+	if envVars == nil {
+		return nil
+	}
+	unowned := make([]cocoa.EnvironmentVariable, 0, len(envVars))
+	for _, envVar := range envVars {
+		if envVar.SecretOpts != nil {
+			secretOpts := *envVar.SecretOpts
+			envVar.SecretOpts = secretOpts.SetOwned(false)
+		}
+		unowned = append(unowned, envVar)
+	}
+	return unowned
+}
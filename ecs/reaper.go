@@ -0,0 +1,102 @@
+package ecs
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// defaultReapConcurrency is the maximum number of expired tasks that are
+// stopped at once when reaping.
+const defaultReapConcurrency = 10
+
+// ReapResult is the result of stopping the pods that have passed their stop
+// deadline.
+type ReapResult struct {
+	// Stopped are the ARNs of the tasks that were stopped because they passed
+	// their stop deadline. Tasks that ECS no longer has information about are
+	// considered stopped.
+	Stopped []string
+	// Failed maps the ARNs of the expired tasks that could not be stopped, or
+	// whose stop deadline could not be read, to the error that occurred.
+	Failed map[string]error
+}
+
+// ReapExpiredPods stops all the running pods in the cluster whose stop
+// deadline (see cocoa.ECSPodExecutionOptions.SetMaxLifetime) has passed. The
+// deadlines are read from the pods' tags rather than from any state kept by
+// the caller, so reaping can resume after the caller restarts and multiple
+// callers can reap the same cluster. Pods without a stop deadline are never
+// stopped. The expired tasks are stopped concurrently, but only up to a limited
+// number at once to avoid ECS throttling. Stopping a task only stops the pod
+// without cleaning up any of its underlying resources.
+//
+// All the expired tasks are attempted even if some of them fail to stop. The
+// result contains which tasks were stopped and which ones failed; if any
+// failed, an error is also returned along with the result.
+func ReapExpiredPods(ctx context.Context, c cocoa.ECSClient, cluster, reason string) (*ReapResult, error) {
+	return reapExpiredPods(ctx, c, cluster, reason, time.Now(), defaultReapConcurrency)
+}
+
+func reapExpiredPods(ctx context.Context, c cocoa.ECSClient, cluster, reason string, now time.Time, concurrency int) (*ReapResult, error) {
+	if c == nil {
+		return nil, errors.New("must specify a client")
+	}
+	if cluster == "" {
+		return nil, errors.New("must specify a cluster")
+	}
+
+	filters := NewListPodsFilters().SetDesiredStatus(types.DesiredStatusRunning)
+	arns, err := listTaskARNs(ctx, c, cluster, *filters)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing tasks")
+	}
+
+	res := ReapResult{Failed: map[string]error{}}
+	var expired []string
+	if len(arns) != 0 {
+		out, err := describeAllTasks(ctx, c, cluster, arns, []types.TaskField{types.TaskFieldTags})
+		if err != nil {
+			return nil, errors.Wrap(err, "describing tasks")
+		}
+		for _, task := range out.Tasks {
+			arn := utility.FromStringPtr(task.TaskArn)
+			deadline, ok, err := importTags(task.Tags).GetStopDeadline()
+			if err != nil {
+				res.Failed[arn] = err
+				continue
+			}
+			if ok && !deadline.After(now) {
+				expired = append(expired, arn)
+			}
+		}
+	}
+
+	stopped, failed := stopTasks(ctx, c, cluster, expired, reason, concurrency)
+	res.Stopped = stopped
+	for arn, err := range failed {
+		res.Failed[arn] = err
+	}
+
+	if len(res.Failed) != 0 {
+		failedARNs := make([]string, 0, len(res.Failed))
+		for arn := range res.Failed {
+			failedARNs = append(failedARNs, arn)
+		}
+		sort.Strings(failedARNs)
+
+		catcher := grip.NewBasicCatcher()
+		for _, arn := range failedARNs {
+			catcher.Wrapf(res.Failed[arn], "reaping task '%s'", arn)
+		}
+		return &res, errors.Wrapf(catcher.Resolve(), "reaping %d tasks", len(res.Failed))
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,129 @@
+package ecs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// taggedTaskClient is an ECS client with a fixed set of running tasks and
+// their tags that records which tasks are stopped.
+type taggedTaskClient struct {
+	cocoa.ECSClient
+
+	mu      sync.Mutex
+	tags    map[string]map[string]string
+	stopped map[string]string
+	failARN string
+}
+
+func (c *taggedTaskClient) ListTasks(ctx context.Context, in *ecs.ListTasksInput) (*ecs.ListTasksOutput, error) {
+	var arns []string
+	for arn := range c.tags {
+		arns = append(arns, arn)
+	}
+	return &ecs.ListTasksOutput{TaskArns: arns}, nil
+}
+
+func (c *taggedTaskClient) DescribeTasks(ctx context.Context, in *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
+	var includeTags bool
+	for _, field := range in.Include {
+		if field == types.TaskFieldTags {
+			includeTags = true
+		}
+	}
+
+	var out ecs.DescribeTasksOutput
+	for _, arn := range in.Tasks {
+		task := types.Task{TaskArn: utility.ToStringPtr(arn)}
+		if includeTags {
+			task.Tags = ExportTags(c.tags[arn])
+		}
+		out.Tasks = append(out.Tasks, task)
+	}
+	return &out, nil
+}
+
+func (c *taggedTaskClient) StopTask(ctx context.Context, in *ecs.StopTaskInput) (*ecs.StopTaskOutput, error) {
+	arn := utility.FromStringPtr(in.Task)
+	if arn == c.failARN {
+		return nil, errors.New("fake error")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped[arn] = utility.FromStringPtr(in.Reason)
+	return &ecs.StopTaskOutput{}, nil
+}
+
+func TestReapExpiredPods(t *testing.T) {
+	now := time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC)
+	newClient := func() *taggedTaskClient {
+		return &taggedTaskClient{
+			tags: map[string]map[string]string{
+				"expired0":  cocoa.NewTags().SetStopDeadline(now.Add(-time.Hour)),
+				"expired1":  cocoa.NewTags().SetStopDeadline(now).SetPurpose("agent"),
+				"unexpired": cocoa.NewTags().SetStopDeadline(now.Add(time.Minute)),
+				"untagged":  cocoa.NewTags().Set("key", "value"),
+			},
+			stopped: map[string]string{},
+		}
+	}
+
+	t.Run("StopsOnlyExpiredPods", func(t *testing.T) {
+		c := newClient()
+		res, err := reapExpiredPods(context.Background(), c, "cluster", "reason", now, 2)
+		require.NoError(t, err)
+		require.NotZero(t, res)
+		assert.Equal(t, []string{"expired0", "expired1"}, res.Stopped)
+		assert.Empty(t, res.Failed)
+		assert.Equal(t, map[string]string{"expired0": "reason", "expired1": "reason"}, c.stopped)
+	})
+	t.Run("StopsNothingWithoutExpiredPods", func(t *testing.T) {
+		c := newClient()
+		res, err := reapExpiredPods(context.Background(), c, "cluster", "reason", now.Add(-2*time.Hour), 2)
+		require.NoError(t, err)
+		assert.Empty(t, res.Stopped)
+		assert.Empty(t, c.stopped)
+	})
+	t.Run("ReportsPodsThatFailToStop", func(t *testing.T) {
+		c := newClient()
+		c.failARN = "expired0"
+		res, err := reapExpiredPods(context.Background(), c, "cluster", "reason", now, 2)
+		assert.Error(t, err)
+		require.NotZero(t, res)
+		assert.Equal(t, []string{"expired1"}, res.Stopped)
+		require.Len(t, res.Failed, 1)
+		assert.Error(t, res.Failed["expired0"])
+	})
+	t.Run("ReportsPodsWithInvalidDeadline", func(t *testing.T) {
+		c := newClient()
+		c.tags["invalid"] = cocoa.NewTags().Set(cocoa.StopDeadlineTagKey, "tomorrow")
+		res, err := reapExpiredPods(context.Background(), c, "cluster", "reason", now, 2)
+		assert.Error(t, err)
+		require.NotZero(t, res)
+		assert.Equal(t, []string{"expired0", "expired1"}, res.Stopped)
+		require.Len(t, res.Failed, 1)
+		assert.Error(t, res.Failed["invalid"])
+		assert.NotContains(t, c.stopped, "invalid")
+	})
+	t.Run("FailsWithoutClient", func(t *testing.T) {
+		res, err := ReapExpiredPods(context.Background(), nil, "cluster", "reason")
+		assert.Error(t, err)
+		assert.Zero(t, res)
+	})
+	t.Run("FailsWithoutCluster", func(t *testing.T) {
+		res, err := ReapExpiredPods(context.Background(), newClient(), "", "reason")
+		assert.Error(t, err)
+		assert.Zero(t, res)
+	})
+}
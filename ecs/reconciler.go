@@ -0,0 +1,316 @@
+package ecs
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// DesiredPod describes the intended state of a single pod. Setting the desired
+// status to stopped acts as a kill switch for the pod.
+type DesiredPod struct {
+	// ARN is the ARN of the ECS task backing the pod.
+	ARN *string
+	// DesiredStatus is the status that the pod is intended to have. This can
+	// either be types.DesiredStatusRunning or types.DesiredStatusStopped. By
+	// default, this is types.DesiredStatusRunning.
+	DesiredStatus *types.DesiredStatus
+	// CreationOpts are the options to create a replacement pod if the pod is
+	// intended to be running but is not. If this is not specified, a pod that
+	// is not running is reported as missing but is not replaced.
+	CreationOpts *cocoa.ECSPodCreationOptions
+}
+
+// NewDesiredPod returns a new uninitialized desired pod.
+func NewDesiredPod() *DesiredPod {
+	return &DesiredPod{}
+}
+
+// SetARN sets the ARN of the ECS task backing the pod.
+func (d *DesiredPod) SetARN(arn string) *DesiredPod {
+	d.ARN = &arn
+	return d
+}
+
+// SetDesiredStatus sets the status that the pod is intended to have.
+func (d *DesiredPod) SetDesiredStatus(status types.DesiredStatus) *DesiredPod {
+	d.DesiredStatus = &status
+	return d
+}
+
+// SetCreationOptions sets the options to create a replacement pod.
+func (d *DesiredPod) SetCreationOptions(opts cocoa.ECSPodCreationOptions) *DesiredPod {
+	d.CreationOpts = &opts
+	return d
+}
+
+// Validate checks that the ARN is given and that the desired status is valid.
+// It sets defaults where possible.
+func (d *DesiredPod) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(utility.FromStringPtr(d.ARN) == "", "must specify an ARN")
+	if d.DesiredStatus != nil {
+		switch *d.DesiredStatus {
+		case types.DesiredStatusRunning, types.DesiredStatusStopped:
+		default:
+			catcher.Errorf("unsupported desired status '%s'", *d.DesiredStatus)
+		}
+	}
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	if d.DesiredStatus == nil {
+		d.SetDesiredStatus(types.DesiredStatusRunning)
+	}
+
+	return nil
+}
+
+// ReconcilerOptions are options to create a reconciler.
+type ReconcilerOptions struct {
+	// Client is the client used to stop pods.
+	Client cocoa.ECSClient
+	// Creator is the creator used to create replacement pods. If this is not
+	// specified, the reconciler cannot create pods.
+	Creator cocoa.ECSPodCreator
+	// Cluster is the cluster in which the pods run.
+	Cluster *string
+	// StopReason is the reason recorded in ECS for pods that are stopped by
+	// the reconciler.
+	StopReason *string
+	// DryRun indicates whether the reconciler should only compute the actions
+	// needed to converge without executing them. By default, the actions are
+	// executed.
+	DryRun *bool
+}
+
+// NewReconcilerOptions returns new uninitialized options to create a
+// reconciler.
+func NewReconcilerOptions() *ReconcilerOptions {
+	return &ReconcilerOptions{}
+}
+
+// SetClient sets the client used to stop pods.
+func (o *ReconcilerOptions) SetClient(c cocoa.ECSClient) *ReconcilerOptions {
+	o.Client = c
+	return o
+}
+
+// SetCreator sets the creator used to create replacement pods.
+func (o *ReconcilerOptions) SetCreator(pc cocoa.ECSPodCreator) *ReconcilerOptions {
+	o.Creator = pc
+	return o
+}
+
+// SetCluster sets the cluster in which the pods run.
+func (o *ReconcilerOptions) SetCluster(cluster string) *ReconcilerOptions {
+	o.Cluster = &cluster
+	return o
+}
+
+// SetStopReason sets the reason recorded in ECS for stopped pods.
+func (o *ReconcilerOptions) SetStopReason(reason string) *ReconcilerOptions {
+	o.StopReason = &reason
+	return o
+}
+
+// SetDryRun sets whether the reconciler should only compute the actions
+// without executing them.
+func (o *ReconcilerOptions) SetDryRun(dryRun bool) *ReconcilerOptions {
+	o.DryRun = &dryRun
+	return o
+}
+
+// Validate checks that the required options are given.
+func (o *ReconcilerOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.Client == nil, "must specify a client")
+	catcher.NewWhen(utility.FromStringPtr(o.Cluster) == "", "must specify a cluster")
+	catcher.NewWhen(o.StopReason != nil && *o.StopReason == "", "cannot specify an empty stop reason")
+	return catcher.Resolve()
+}
+
+// ReconcilePlan describes the actions needed to converge the actual pods
+// toward the desired pods.
+type ReconcilePlan struct {
+	// ToStop are the ARNs of the running pods that must be stopped, either
+	// because they are not desired at all or because they are desired to be
+	// stopped.
+	ToStop []string
+	// ToCreate are the desired pods that are not running and must be
+	// replaced.
+	ToCreate []DesiredPod
+	// Missing are the ARNs of the desired pods that are not running but cannot
+	// be replaced because they have no creation options.
+	Missing []string
+}
+
+// IsEmpty returns whether the plan has no actions to take.
+func (p *ReconcilePlan) IsEmpty() bool {
+	return len(p.ToStop) == 0 && len(p.ToCreate) == 0
+}
+
+// ReconcileResult is the result of reconciling the pods.
+type ReconcileResult struct {
+	// Plan is the computed plan of actions.
+	Plan ReconcilePlan
+	// Stopped are the ARNs of the pods that were successfully stopped.
+	Stopped []string
+	// Created maps the ARN of each replaced pod to the pod created to replace
+	// it.
+	Created map[string]cocoa.ECSPod
+}
+
+// Reconciler converges the pods running in a cluster toward a desired set of
+// pods. It forms the basis of a simple controller loop: take a snapshot of the
+// running pods, reconcile them against the desired pods, and repeat.
+type Reconciler struct {
+	client     cocoa.ECSClient
+	creator    cocoa.ECSPodCreator
+	cluster    string
+	stopReason *string
+	dryRun     bool
+}
+
+// NewReconciler returns a new reconciler initialized with the given options.
+func NewReconciler(opts ReconcilerOptions) (*Reconciler, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid reconciler options")
+	}
+	return &Reconciler{
+		client:     opts.Client,
+		creator:    opts.Creator,
+		cluster:    utility.FromStringPtr(opts.Cluster),
+		stopReason: opts.StopReason,
+		dryRun:     utility.FromBoolPtr(opts.DryRun),
+	}, nil
+}
+
+// Snapshot returns the ARNs of all the pods in the cluster that match the
+// filters.
+func (r *Reconciler) Snapshot(ctx context.Context, filters ListPodsFilters) ([]string, error) {
+	if err := filters.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid filters")
+	}
+	arns, err := listTaskARNs(ctx, r.client, r.cluster, filters)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing tasks")
+	}
+	return arns, nil
+}
+
+// Plan computes the actions needed to converge the actual running pods toward
+// the desired pods without executing them. Any running pod that is not
+// desired is stopped.
+func (r *Reconciler) Plan(desired []DesiredPod, actual []string) (*ReconcilePlan, error) {
+	catcher := grip.NewBasicCatcher()
+	desiredByARN := map[string]DesiredPod{}
+	for i, d := range desired {
+		if err := d.Validate(); err != nil {
+			catcher.Wrapf(err, "invalid desired pod at index %d", i)
+			continue
+		}
+		arn := utility.FromStringPtr(d.ARN)
+		catcher.ErrorfWhen(desiredByARN[arn].ARN != nil, "cannot specify duplicate desired pod '%s'", arn)
+		desiredByARN[arn] = d
+	}
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	running := map[string]bool{}
+	for _, arn := range actual {
+		running[arn] = true
+	}
+
+	var plan ReconcilePlan
+	for arn := range running {
+		d, ok := desiredByARN[arn]
+		if !ok || *d.DesiredStatus == types.DesiredStatusStopped {
+			plan.ToStop = append(plan.ToStop, arn)
+		}
+	}
+	for arn, d := range desiredByARN {
+		if running[arn] || *d.DesiredStatus != types.DesiredStatusRunning {
+			continue
+		}
+		if d.CreationOpts == nil {
+			plan.Missing = append(plan.Missing, arn)
+			continue
+		}
+		plan.ToCreate = append(plan.ToCreate, d)
+	}
+
+	// Sort the actions so that the plan is deterministic.
+	sort.Strings(plan.ToStop)
+	sort.Strings(plan.Missing)
+	sort.Slice(plan.ToCreate, func(i, j int) bool {
+		return utility.FromStringPtr(plan.ToCreate[i].ARN) < utility.FromStringPtr(plan.ToCreate[j].ARN)
+	})
+
+	return &plan, nil
+}
+
+// Reconcile computes the actions needed to converge the actual running pods
+// toward the desired pods and, unless the reconciler is a dry run, executes
+// them. If some of the actions fail, it returns the result of the actions
+// that succeeded along with the error.
+func (r *Reconciler) Reconcile(ctx context.Context, desired []DesiredPod, actual []string) (*ReconcileResult, error) {
+	plan, err := r.Plan(desired, actual)
+	if err != nil {
+		return nil, errors.Wrap(err, "planning reconciliation")
+	}
+
+	res := &ReconcileResult{
+		Plan:    *plan,
+		Created: map[string]cocoa.ECSPod{},
+	}
+	if r.dryRun || plan.IsEmpty() {
+		return res, nil
+	}
+	if len(plan.ToCreate) != 0 && r.creator == nil {
+		return res, errors.New("must specify a creator to replace pods")
+	}
+
+	catcher := grip.NewBasicCatcher()
+	for _, arn := range plan.ToStop {
+		if err := r.stop(ctx, arn); err != nil {
+			catcher.Wrapf(err, "stopping pod '%s'", arn)
+			continue
+		}
+		res.Stopped = append(res.Stopped, arn)
+	}
+
+	for _, d := range plan.ToCreate {
+		arn := utility.FromStringPtr(d.ARN)
+		p, err := r.creator.CreatePod(ctx, *d.CreationOpts)
+		if err != nil {
+			catcher.Wrapf(err, "creating pod to replace pod '%s'", arn)
+			continue
+		}
+		res.Created[arn] = p
+	}
+
+	return res, catcher.Resolve()
+}
+
+// stop stops the ECS task with the given ARN. If the task cannot be found, it
+// is considered already stopped.
+func (r *Reconciler) stop(ctx context.Context, arn string) error {
+	_, err := r.client.StopTask(ctx, &ecs.StopTaskInput{
+		Cluster: utility.ToStringPtr(r.cluster),
+		Task:    utility.ToStringPtr(arn),
+		Reason:  r.stopReason,
+	})
+	if err != nil && !cocoa.IsECSTaskNotFoundError(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDesiredPod(t *testing.T) {
+	t.Run("NewDesiredPod", func(t *testing.T) {
+		d := NewDesiredPod()
+		require.NotZero(t, d)
+		assert.Zero(t, *d)
+	})
+	t.Run("SetARN", func(t *testing.T) {
+		d := NewDesiredPod().SetARN("arn")
+		assert.Equal(t, "arn", utility.FromStringPtr(d.ARN))
+	})
+	t.Run("SetDesiredStatus", func(t *testing.T) {
+		d := NewDesiredPod().SetDesiredStatus(types.DesiredStatusStopped)
+		require.NotZero(t, d.DesiredStatus)
+		assert.Equal(t, types.DesiredStatusStopped, *d.DesiredStatus)
+	})
+	t.Run("SetCreationOptions", func(t *testing.T) {
+		opts := cocoa.NewECSPodCreationOptions().SetExecutionOptions(*cocoa.NewECSPodExecutionOptions().SetCluster("cluster"))
+		d := NewDesiredPod().SetCreationOptions(*opts)
+		require.NotZero(t, d.CreationOpts)
+		assert.Equal(t, *opts, *d.CreationOpts)
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("DefaultsToRunning", func(t *testing.T) {
+			d := NewDesiredPod().SetARN("arn")
+			require.NoError(t, d.Validate())
+			require.NotZero(t, d.DesiredStatus)
+			assert.Equal(t, types.DesiredStatusRunning, *d.DesiredStatus)
+		})
+		t.Run("SucceedsWithStopped", func(t *testing.T) {
+			d := NewDesiredPod().SetARN("arn").SetDesiredStatus(types.DesiredStatusStopped)
+			require.NoError(t, d.Validate())
+			assert.Equal(t, types.DesiredStatusStopped, *d.DesiredStatus)
+		})
+		t.Run("FailsWithoutARN", func(t *testing.T) {
+			assert.Error(t, NewDesiredPod().Validate())
+		})
+		t.Run("FailsWithPendingStatus", func(t *testing.T) {
+			d := NewDesiredPod().SetARN("arn").SetDesiredStatus(types.DesiredStatusPending)
+			assert.Error(t, d.Validate())
+		})
+	})
+}
+
+func TestReconcilerOptions(t *testing.T) {
+	t.Run("SetClient", func(t *testing.T) {
+		c := &BasicClient{}
+		opts := NewReconcilerOptions().SetClient(c)
+		assert.Equal(t, c, opts.Client)
+	})
+	t.Run("SetCreator", func(t *testing.T) {
+		pc := &BasicPodCreator{}
+		opts := NewReconcilerOptions().SetCreator(pc)
+		assert.Equal(t, pc, opts.Creator)
+	})
+	t.Run("SetCluster", func(t *testing.T) {
+		opts := NewReconcilerOptions().SetCluster("cluster")
+		assert.Equal(t, "cluster", utility.FromStringPtr(opts.Cluster))
+	})
+	t.Run("SetStopReason", func(t *testing.T) {
+		opts := NewReconcilerOptions().SetStopReason("reason")
+		assert.Equal(t, "reason", utility.FromStringPtr(opts.StopReason))
+	})
+	t.Run("SetDryRun", func(t *testing.T) {
+		opts := NewReconcilerOptions().SetDryRun(true)
+		assert.True(t, utility.FromBoolPtr(opts.DryRun))
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("SucceedsWithRequiredOptions", func(t *testing.T) {
+			opts := NewReconcilerOptions().SetClient(&BasicClient{}).SetCluster("cluster")
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithoutClient", func(t *testing.T) {
+			opts := NewReconcilerOptions().SetCluster("cluster")
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithoutCluster", func(t *testing.T) {
+			opts := NewReconcilerOptions().SetClient(&BasicClient{})
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithEmptyStopReason", func(t *testing.T) {
+			opts := NewReconcilerOptions().SetClient(&BasicClient{}).SetCluster("cluster").SetStopReason("")
+			assert.Error(t, opts.Validate())
+		})
+	})
+}
+
+func TestReconcilerPlan(t *testing.T) {
+	r := &Reconciler{cluster: "cluster"}
+	creationOpts := cocoa.NewECSPodCreationOptions().SetExecutionOptions(*cocoa.NewECSPodExecutionOptions().SetCluster("cluster"))
+
+	t.Run("StopsUndesiredPods", func(t *testing.T) {
+		plan, err := r.Plan([]DesiredPod{*NewDesiredPod().SetARN("arn0")}, []string{"arn0", "arn2", "arn1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"arn1", "arn2"}, plan.ToStop)
+		assert.Empty(t, plan.ToCreate)
+		assert.Empty(t, plan.Missing)
+	})
+	t.Run("StopsPodsDesiredToBeStopped", func(t *testing.T) {
+		desired := []DesiredPod{*NewDesiredPod().SetARN("arn").SetDesiredStatus(types.DesiredStatusStopped)}
+		plan, err := r.Plan(desired, []string{"arn"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"arn"}, plan.ToStop)
+		assert.Empty(t, plan.ToCreate)
+	})
+	t.Run("IgnoresNonRunningPodsDesiredToBeStopped", func(t *testing.T) {
+		desired := []DesiredPod{*NewDesiredPod().SetARN("arn").SetDesiredStatus(types.DesiredStatusStopped).SetCreationOptions(*creationOpts)}
+		plan, err := r.Plan(desired, nil)
+		require.NoError(t, err)
+		assert.True(t, plan.IsEmpty())
+		assert.Empty(t, plan.Missing)
+	})
+	t.Run("CreatesMissingPodsWithCreationOptions", func(t *testing.T) {
+		desired := []DesiredPod{
+			*NewDesiredPod().SetARN("arn1").SetCreationOptions(*creationOpts),
+			*NewDesiredPod().SetARN("arn0").SetCreationOptions(*creationOpts),
+			*NewDesiredPod().SetARN("arn2"),
+		}
+		plan, err := r.Plan(desired, nil)
+		require.NoError(t, err)
+		require.Len(t, plan.ToCreate, 2)
+		assert.Equal(t, "arn0", utility.FromStringPtr(plan.ToCreate[0].ARN))
+		assert.Equal(t, "arn1", utility.FromStringPtr(plan.ToCreate[1].ARN))
+		assert.Equal(t, []string{"arn2"}, plan.Missing)
+		assert.Empty(t, plan.ToStop)
+	})
+	t.Run("ReturnsEmptyPlanWhenConverged", func(t *testing.T) {
+		plan, err := r.Plan([]DesiredPod{*NewDesiredPod().SetARN("arn")}, []string{"arn"})
+		require.NoError(t, err)
+		assert.True(t, plan.IsEmpty())
+	})
+	t.Run("FailsWithInvalidDesiredPod", func(t *testing.T) {
+		plan, err := r.Plan([]DesiredPod{*NewDesiredPod()}, nil)
+		assert.Error(t, err)
+		assert.Zero(t, plan)
+	})
+	t.Run("FailsWithDuplicateDesiredPods", func(t *testing.T) {
+		plan, err := r.Plan([]DesiredPod{*NewDesiredPod().SetARN("arn"), *NewDesiredPod().SetARN("arn")}, nil)
+		assert.Error(t, err)
+		assert.Zero(t, plan)
+	})
+}
@@ -0,0 +1,83 @@
+package ecs
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+)
+
+// retirementReasonKeywords are the keywords in a task's stopped reason or a
+// service event that indicate that AWS is retiring a task for maintenance.
+var retirementReasonKeywords = []string{"retire", "retiring", "maintenance"}
+
+// serviceEventTaskRegexp matches the task IDs in a service event message (e.g.
+// "(task 0123456789abcdef)").
+var serviceEventTaskRegexp = regexp.MustCompile(`\(task ([^)\s]+)\)`)
+
+// DetectTaskRetirement returns a notice if AWS has flagged the task to be
+// retired, based on its stop code and stopped reason. It returns nil if the
+// task is not being retired (e.g. if it was stopped by a user).
+func DetectTaskRetirement(task types.Task) *cocoa.PodRetirementNotice {
+	notice := cocoa.PodRetirementNotice{
+		StopCode: string(task.StopCode),
+		Message:  utility.FromStringPtr(task.StoppedReason),
+		NoticeAt: task.StoppingAt,
+	}
+	switch task.StopCode {
+	case types.TaskStopCodeSpotInterruption:
+		notice.Cause = cocoa.RetirementCauseSpotInterruption
+	case types.TaskStopCodeTerminationNotice:
+		notice.Cause = cocoa.RetirementCauseTerminationNotice
+	case types.TaskStopCodeUserInitiated:
+		return nil
+	default:
+		if !isRetirementReason(notice.Message) {
+			return nil
+		}
+		notice.Cause = cocoa.RetirementCauseMaintenance
+	}
+	return &notice
+}
+
+// DetectServiceRetirementNotices returns a notice for each task that a service
+// event reports is being retired, indexed by the task ID. ECS reports when it
+// replaces a service's tasks for infrastructure maintenance in the service's
+// events before the tasks are stopped, so this can give an earlier notice than
+// DetectTaskRetirement. The events are expected in the order that ECS returns
+// them (i.e. newest first), so each task's notice is from its newest event.
+func DetectServiceRetirementNotices(events []types.ServiceEvent) map[string]cocoa.PodRetirementNotice {
+	notices := map[string]cocoa.PodRetirementNotice{}
+	for _, event := range events {
+		msg := utility.FromStringPtr(event.Message)
+		if !isRetirementReason(msg) {
+			continue
+		}
+		for _, match := range serviceEventTaskRegexp.FindAllStringSubmatch(msg, -1) {
+			taskID := match[1]
+			if _, ok := notices[taskID]; ok {
+				continue
+			}
+			notices[taskID] = cocoa.PodRetirementNotice{
+				Cause:    cocoa.RetirementCauseMaintenance,
+				Message:  msg,
+				NoticeAt: event.CreatedAt,
+			}
+		}
+	}
+	return notices
+}
+
+// isRetirementReason returns whether or not the reason indicates that AWS is
+// retiring a task.
+func isRetirementReason(reason string) bool {
+	reason = strings.ToLower(reason)
+	for _, keyword := range retirementReasonKeywords {
+		if strings.Contains(reason, keyword) {
+			return true
+		}
+	}
+	return false
+}
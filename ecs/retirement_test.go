@@ -0,0 +1,100 @@
+package ecs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTaskRetirement(t *testing.T) {
+	stopping := time.Now().Round(time.Millisecond)
+
+	t.Run("DetectsSpotInterruption", func(t *testing.T) {
+		notice := DetectTaskRetirement(types.Task{
+			StopCode:      types.TaskStopCodeSpotInterruption,
+			StoppedReason: utility.ToStringPtr("Spot capacity is being reclaimed"),
+			StoppingAt:    utility.ToTimePtr(stopping),
+		})
+		require.NotZero(t, notice)
+		assert.Equal(t, cocoa.RetirementCauseSpotInterruption, notice.Cause)
+		assert.Equal(t, string(types.TaskStopCodeSpotInterruption), notice.StopCode)
+		assert.Equal(t, "Spot capacity is being reclaimed", notice.Message)
+		require.NotZero(t, notice.NoticeAt)
+		assert.True(t, stopping.Equal(*notice.NoticeAt))
+	})
+	t.Run("DetectsTerminationNotice", func(t *testing.T) {
+		notice := DetectTaskRetirement(types.Task{StopCode: types.TaskStopCodeTerminationNotice})
+		require.NotZero(t, notice)
+		assert.Equal(t, cocoa.RetirementCauseTerminationNotice, notice.Cause)
+	})
+	t.Run("DetectsMaintenanceFromStoppedReason", func(t *testing.T) {
+		notice := DetectTaskRetirement(types.Task{
+			StopCode:      types.TaskStopCodeServiceSchedulerInitiated,
+			StoppedReason: utility.ToStringPtr("ECS is performing maintenance on the underlying infrastructure hosting the task"),
+		})
+		require.NotZero(t, notice)
+		assert.Equal(t, cocoa.RetirementCauseMaintenance, notice.Cause)
+	})
+	t.Run("IgnoresUserInitiatedStop", func(t *testing.T) {
+		assert.Zero(t, DetectTaskRetirement(types.Task{
+			StopCode:      types.TaskStopCodeUserInitiated,
+			StoppedReason: utility.ToStringPtr("retiring old pods"),
+		}))
+	})
+	t.Run("IgnoresOtherStops", func(t *testing.T) {
+		assert.Zero(t, DetectTaskRetirement(types.Task{
+			StopCode:      types.TaskStopCodeEssentialContainerExited,
+			StoppedReason: utility.ToStringPtr("Essential container in task exited"),
+		}))
+	})
+	t.Run("IgnoresRunningTask", func(t *testing.T) {
+		assert.Zero(t, DetectTaskRetirement(types.Task{LastStatus: utility.ToStringPtr(string(TaskStatusRunning))}))
+	})
+	t.Run("IsIncludedInStatusInfo", func(t *testing.T) {
+		statusInfo := translatePodStatusInfo(types.Task{
+			LastStatus: utility.ToStringPtr(string(TaskStatusStopping)),
+			StopCode:   types.TaskStopCodeSpotInterruption,
+		})
+		assert.True(t, statusInfo.IsRetiring())
+		require.NotZero(t, statusInfo.RetirementNotice)
+		assert.Equal(t, cocoa.RetirementCauseSpotInterruption, statusInfo.RetirementNotice.Cause)
+	})
+}
+
+func TestDetectServiceRetirementNotices(t *testing.T) {
+	newer := time.Now().Round(time.Millisecond)
+	older := newer.Add(-time.Hour)
+	events := []types.ServiceEvent{
+		{
+			Message:   utility.ToStringPtr("(service svc) is retiring 2 tasks for infrastructure maintenance: (task abc) (task def)."),
+			CreatedAt: utility.ToTimePtr(newer),
+		},
+		{
+			Message:   utility.ToStringPtr("(service svc) has started 1 tasks: (task ghi)."),
+			CreatedAt: utility.ToTimePtr(newer),
+		},
+		{
+			Message:   utility.ToStringPtr("(service svc) task (task abc) is scheduled for retirement."),
+			CreatedAt: utility.ToTimePtr(older),
+		},
+	}
+
+	notices := DetectServiceRetirementNotices(events)
+	require.Len(t, notices, 2)
+	for _, taskID := range []string{"abc", "def"} {
+		notice, ok := notices[taskID]
+		require.True(t, ok, "task '%s' should have a notice", taskID)
+		assert.Equal(t, cocoa.RetirementCauseMaintenance, notice.Cause)
+		assert.Equal(t, utility.FromStringPtr(events[0].Message), notice.Message, "notice should be from the newest event")
+		require.NotZero(t, notice.NoticeAt)
+		assert.True(t, newer.Equal(*notice.NoticeAt))
+	}
+	assert.NotContains(t, notices, "ghi")
+
+	assert.Empty(t, DetectServiceRetirementNotices(nil))
+}
@@ -0,0 +1,143 @@
+package ecs
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/pkg/errors"
+)
+
+// ecsTasksServicePrincipal is the service principal that ECS tasks use to
+// assume their task and execution roles.
+const ecsTasksServicePrincipal = "ecs-tasks.amazonaws.com"
+
+// ValidatePodDefinitionRoles checks that the pod definition's task and
+// execution roles, if any, exist and can be assumed by ECS tasks. If a role is
+// missing or misconfigured, it returns an error wrapping a
+// *cocoa.RoleMisconfigurationError for the first such role.
+func ValidatePodDefinitionRoles(ctx context.Context, c cocoa.IAMClient, opts cocoa.ECSPodDefinitionOptions) error {
+	if opts.TaskRole != nil {
+		if err := ValidateRole(ctx, c, *opts.TaskRole); err != nil {
+			return errors.Wrap(err, "validating task role")
+		}
+	}
+	if opts.ExecutionRole != nil {
+		if err := ValidateRole(ctx, c, *opts.ExecutionRole); err != nil {
+			return errors.Wrap(err, "validating execution role")
+		}
+	}
+	return nil
+}
+
+// ValidateRole checks that the role exists and that its trust policy allows
+// ECS tasks to assume it. If the role is missing or misconfigured, it returns a
+// *cocoa.RoleMisconfigurationError.
+func ValidateRole(ctx context.Context, c cocoa.IAMClient, role string) error {
+	policy, err := c.GetRoleTrustPolicy(ctx, role)
+	if err != nil {
+		return errors.Wrapf(err, "getting trust policy for role '%s'", role)
+	}
+	if policy == nil {
+		return cocoa.NewRoleMisconfigurationError(role, "role does not exist")
+	}
+
+	allowed, err := trustPolicyAllowsECSTasks(*policy)
+	if err != nil {
+		return cocoa.NewRoleMisconfigurationError(role, errors.Wrap(err, "parsing trust policy").Error())
+	}
+	if !allowed {
+		return cocoa.NewRoleMisconfigurationError(role, "trust policy does not allow "+ecsTasksServicePrincipal+" to assume the role")
+	}
+
+	return nil
+}
+
+// trustPolicy is the subset of an IAM trust policy document needed to check
+// which services can assume a role.
+type trustPolicy struct {
+	Statement policyStatements `json:"Statement"`
+}
+
+// policyStatements are the statements in a policy document, which can be
+// either a single statement or a list of statements.
+type policyStatements []policyStatement
+
+func (s *policyStatements) UnmarshalJSON(b []byte) error {
+	var statements []policyStatement
+	if err := json.Unmarshal(b, &statements); err == nil {
+		*s = statements
+		return nil
+	}
+	var statement policyStatement
+	if err := json.Unmarshal(b, &statement); err != nil {
+		return err
+	}
+	*s = policyStatements{statement}
+	return nil
+}
+
+type policyStatement struct {
+	Effect    string          `json:"Effect"`
+	Action    stringOrStrings `json:"Action"`
+	Principal policyPrincipal `json:"Principal"`
+}
+
+type policyPrincipal struct {
+	Service stringOrStrings `json:"Service"`
+}
+
+// stringOrStrings is a policy element that can be either a single string or a
+// list of strings.
+type stringOrStrings []string
+
+func (s *stringOrStrings) UnmarshalJSON(b []byte) error {
+	var strs []string
+	if err := json.Unmarshal(b, &strs); err == nil {
+		*s = strs
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	*s = stringOrStrings{str}
+	return nil
+}
+
+// trustPolicyAllowsECSTasks returns whether or not the trust policy document
+// has a statement allowing ECS tasks to assume the role.
+func trustPolicyAllowsECSTasks(doc string) (bool, error) {
+	var policy trustPolicy
+	if err := json.Unmarshal([]byte(doc), &policy); err != nil {
+		return false, err
+	}
+
+	for _, statement := range policy.Statement {
+		if statement.Effect != "Allow" {
+			continue
+		}
+		if !containsMatching(statement.Action, func(action string) bool {
+			return action == "*" || strings.EqualFold(action, "sts:*") || strings.EqualFold(action, "sts:AssumeRole")
+		}) {
+			continue
+		}
+		if containsMatching(statement.Principal.Service, func(service string) bool {
+			return service == ecsTasksServicePrincipal
+		}) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func containsMatching(strs []string, match func(string) bool) bool {
+	for _, s := range strs {
+		if match(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustPolicyAllowsECSTasks(t *testing.T) {
+	t.Run("ReturnsTrueForStatementList", func(t *testing.T) {
+		allowed, err := trustPolicyAllowsECSTasks(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ecs-tasks.amazonaws.com"},"Action":"sts:AssumeRole"}]}`)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	})
+	t.Run("ReturnsTrueForSingleStatement", func(t *testing.T) {
+		allowed, err := trustPolicyAllowsECSTasks(`{"Statement":{"Effect":"Allow","Principal":{"Service":"ecs-tasks.amazonaws.com"},"Action":"sts:AssumeRole"}}`)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	})
+	t.Run("ReturnsTrueForListsOfServicesAndActions", func(t *testing.T) {
+		allowed, err := trustPolicyAllowsECSTasks(`{"Statement":[{"Effect":"Allow","Principal":{"Service":["ec2.amazonaws.com","ecs-tasks.amazonaws.com"]},"Action":["sts:TagSession","sts:AssumeRole"]}]}`)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	})
+	t.Run("ReturnsFalseForOtherService", func(t *testing.T) {
+		allowed, err := trustPolicyAllowsECSTasks(`{"Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`)
+		assert.NoError(t, err)
+		assert.False(t, allowed)
+	})
+	t.Run("ReturnsFalseForDeniedStatement", func(t *testing.T) {
+		allowed, err := trustPolicyAllowsECSTasks(`{"Statement":[{"Effect":"Deny","Principal":{"Service":"ecs-tasks.amazonaws.com"},"Action":"sts:AssumeRole"}]}`)
+		assert.NoError(t, err)
+		assert.False(t, allowed)
+	})
+	t.Run("ReturnsFalseForOtherAction", func(t *testing.T) {
+		allowed, err := trustPolicyAllowsECSTasks(`{"Statement":[{"Effect":"Allow","Principal":{"Service":"ecs-tasks.amazonaws.com"},"Action":"sts:TagSession"}]}`)
+		assert.NoError(t, err)
+		assert.False(t, allowed)
+	})
+	t.Run("ReturnsFalseForAWSPrincipal", func(t *testing.T) {
+		allowed, err := trustPolicyAllowsECSTasks(`{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":"sts:AssumeRole"}]}`)
+		assert.NoError(t, err)
+		assert.False(t, allowed)
+	})
+	t.Run("FailsWithInvalidJSON", func(t *testing.T) {
+		_, err := trustPolicyAllowsECSTasks("foo")
+		assert.Error(t, err)
+	})
+}
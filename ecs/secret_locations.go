@@ -0,0 +1,128 @@
+package ecs
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/awsutil"
+	"github.com/evergreen-ci/cocoa/identity"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// SecretLocationOptions are options to check that existing secrets referenced
+// by ARN are in the expected account and region. This catches misconfigured
+// secret ARNs before the pod definition is registered rather than when ECS
+// fails to fetch the secrets while starting the pod.
+type SecretLocationOptions struct {
+	// Region is the region that secrets must be in. If this is not set, secrets
+	// can be in any region.
+	Region *string
+	// AccountID is the account that secrets must be in. If this is not set,
+	// secrets can be in any account.
+	AccountID *string
+	// AllowCrossAccount indicates whether or not secrets can be in a different
+	// account than AccountID. By default, cross-account secrets are not
+	// allowed.
+	AllowCrossAccount *bool
+}
+
+// NewSecretLocationOptions returns new uninitialized options to check secret
+// locations.
+func NewSecretLocationOptions() *SecretLocationOptions {
+	return &SecretLocationOptions{}
+}
+
+// NewSecretLocationOptionsFromClientOptions returns options to check that
+// secrets are in the same region as the client and, if the client assumes a
+// role, in the same account as that role.
+func NewSecretLocationOptionsFromClientOptions(opts awsutil.ClientOptions) (*SecretLocationOptions, error) {
+	if utility.FromStringPtr(opts.Region) == "" {
+		return nil, errors.New("client options must specify a region")
+	}
+
+	locOpts := NewSecretLocationOptions().SetRegion(*opts.Region)
+	if role := utility.FromStringPtr(opts.Role); role != "" {
+		parsed, err := arn.Parse(role)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing role ARN '%s'", role)
+		}
+		locOpts.SetAccountID(parsed.AccountID)
+	}
+
+	return locOpts, nil
+}
+
+// SetRegion sets the region that secrets must be in.
+func (o *SecretLocationOptions) SetRegion(region string) *SecretLocationOptions {
+	o.Region = &region
+	return o
+}
+
+// SetAccountID sets the account that secrets must be in.
+func (o *SecretLocationOptions) SetAccountID(id string) *SecretLocationOptions {
+	o.AccountID = &id
+	return o
+}
+
+// SetAllowCrossAccount sets whether or not secrets can be in a different
+// account.
+func (o *SecretLocationOptions) SetAllowCrossAccount(allow bool) *SecretLocationOptions {
+	o.AllowCrossAccount = &allow
+	return o
+}
+
+// Validate checks that the region and account, if given, are non-empty.
+func (o *SecretLocationOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.Region != nil && *o.Region == "", "cannot specify an empty region")
+	catcher.NewWhen(o.AccountID != nil && *o.AccountID == "", "cannot specify an empty account ID")
+	return catcher.Resolve()
+}
+
+// ValidateSecretLocation checks that the secret is in the region and account
+// required by the options. Secrets referenced by name rather than by ARN are
+// always in the same region and account as the pod, so they are not checked.
+func ValidateSecretLocation(id string, opts SecretLocationOptions) error {
+	if !cocoa.IsSecretARN(id) {
+		return nil
+	}
+
+	parsed, err := identity.ParseSecretLocation(id)
+	if err != nil {
+		return err
+	}
+
+	if region := utility.FromStringPtr(opts.Region); region != "" && parsed.Region != region {
+		return errors.Errorf("secret '%s' is in region '%s', but must be in region '%s'", id, parsed.Region, region)
+	}
+	if account := utility.FromStringPtr(opts.AccountID); account != "" && parsed.AccountID != account && !utility.FromBoolPtr(opts.AllowCrossAccount) {
+		return errors.Errorf("secret '%s' is in account '%s', but must be in account '%s' unless cross-account secrets are allowed", id, parsed.AccountID, account)
+	}
+
+	return nil
+}
+
+// ValidatePodDefinitionSecretLocations checks that all the existing secrets
+// referenced by the pod definition's containers are in the region and account
+// required by the options.
+func ValidatePodDefinitionSecretLocations(defOpts cocoa.ECSPodDefinitionOptions, opts SecretLocationOptions) error {
+	catcher := grip.NewBasicCatcher()
+	for _, def := range defOpts.ContainerDefinitions {
+		name := utility.FromStringPtr(def.Name)
+		secretEnvVars := def.EnvVars
+		if def.LogConfiguration != nil {
+			secretEnvVars = append(append([]cocoa.EnvironmentVariable{}, def.EnvVars...), def.LogConfiguration.SecretOptions...)
+		}
+		for _, envVar := range secretEnvVars {
+			if envVar.SecretOpts == nil || envVar.SecretOpts.ID == nil {
+				continue
+			}
+			catcher.Wrapf(ValidateSecretLocation(*envVar.SecretOpts.ID, opts), "container '%s' secret '%s'", name, utility.FromStringPtr(envVar.Name))
+		}
+		if def.RepoCreds != nil && def.RepoCreds.ID != nil {
+			catcher.Wrapf(ValidateSecretLocation(*def.RepoCreds.ID, opts), "container '%s' repository credentials", name)
+		}
+	}
+	return catcher.Resolve()
+}
@@ -0,0 +1,126 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/awsutil"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSecretLocation(t *testing.T) {
+	const localARN = "arn:aws:secretsmanager:us-east-1:123456789012:secret:name-AbCdEf"
+	const otherAccountARN = "arn:aws:secretsmanager:us-east-1:210987654321:secret:name-AbCdEf"
+	const otherRegionARN = "arn:aws:secretsmanager:us-west-2:123456789012:secret:name-AbCdEf"
+	opts := *NewSecretLocationOptions().
+		SetRegion("us-east-1").
+		SetAccountID("123456789012")
+
+	t.Run("SucceedsWithMatchingARN", func(t *testing.T) {
+		assert.NoError(t, ValidateSecretLocation(localARN, opts))
+	})
+	t.Run("SucceedsWithSecretName", func(t *testing.T) {
+		assert.NoError(t, ValidateSecretLocation("name", opts))
+	})
+	t.Run("FailsWithMalformedARN", func(t *testing.T) {
+		assert.Error(t, ValidateSecretLocation("arn:aws:secretsmanager", opts))
+	})
+	t.Run("FailsWithDifferentRegion", func(t *testing.T) {
+		err := ValidateSecretLocation(otherRegionARN, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "us-west-2")
+	})
+	t.Run("FailsWithDifferentAccount", func(t *testing.T) {
+		err := ValidateSecretLocation(otherAccountARN, opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "210987654321")
+	})
+	t.Run("SucceedsWithDifferentAccountWhenCrossAccountIsAllowed", func(t *testing.T) {
+		crossAccountOpts := opts
+		crossAccountOpts.SetAllowCrossAccount(true)
+		assert.NoError(t, ValidateSecretLocation(otherAccountARN, crossAccountOpts))
+	})
+	t.Run("SucceedsWithAnyLocationWhenUnrestricted", func(t *testing.T) {
+		assert.NoError(t, ValidateSecretLocation(otherAccountARN, *NewSecretLocationOptions()))
+		assert.NoError(t, ValidateSecretLocation(otherRegionARN, *NewSecretLocationOptions()))
+	})
+}
+
+func TestValidatePodDefinitionSecretLocations(t *testing.T) {
+	opts := *NewSecretLocationOptions().SetAccountID("123456789012")
+	defOpts := *cocoa.NewECSPodDefinitionOptions().
+		AddContainerDefinitions(*cocoa.NewECSContainerDefinition().
+			SetName("container").
+			SetImage("image").
+			AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("env_var").
+				SetSecretOptions(*cocoa.NewSecretOptions().SetID("arn:aws:ssm:us-east-1:123456789012:parameter/name"))).
+			SetRepositoryCredentials(*cocoa.NewRepositoryCredentials().SetID("arn:aws:secretsmanager:us-east-1:210987654321:secret:creds")))
+
+	err := ValidatePodDefinitionSecretLocations(defOpts, opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository credentials")
+	assert.NotContains(t, err.Error(), "env_var")
+
+	opts.SetAllowCrossAccount(true)
+	assert.NoError(t, ValidatePodDefinitionSecretLocations(defOpts, opts))
+}
+
+func TestSecretLocationOptions(t *testing.T) {
+	t.Run("NewSecretLocationOptions", func(t *testing.T) {
+		opts := NewSecretLocationOptions()
+		require.NotZero(t, opts)
+		assert.Zero(t, *opts)
+	})
+	t.Run("NewSecretLocationOptionsFromClientOptions", func(t *testing.T) {
+		t.Run("SucceedsWithRegionAndRole", func(t *testing.T) {
+			opts, err := NewSecretLocationOptionsFromClientOptions(*awsutil.NewClientOptions().
+				SetRegion("us-east-1").
+				SetRole("arn:aws:iam::123456789012:role/role"))
+			require.NoError(t, err)
+			assert.Equal(t, "us-east-1", utility.FromStringPtr(opts.Region))
+			assert.Equal(t, "123456789012", utility.FromStringPtr(opts.AccountID))
+		})
+		t.Run("SucceedsWithoutRole", func(t *testing.T) {
+			opts, err := NewSecretLocationOptionsFromClientOptions(*awsutil.NewClientOptions().SetRegion("us-east-1"))
+			require.NoError(t, err)
+			assert.Equal(t, "us-east-1", utility.FromStringPtr(opts.Region))
+			assert.Zero(t, opts.AccountID)
+		})
+		t.Run("FailsWithoutRegion", func(t *testing.T) {
+			_, err := NewSecretLocationOptionsFromClientOptions(*awsutil.NewClientOptions())
+			assert.Error(t, err)
+		})
+		t.Run("FailsWithInvalidRole", func(t *testing.T) {
+			_, err := NewSecretLocationOptionsFromClientOptions(*awsutil.NewClientOptions().
+				SetRegion("us-east-1").
+				SetRole("role"))
+			assert.Error(t, err)
+		})
+	})
+	t.Run("SetRegion", func(t *testing.T) {
+		opts := NewSecretLocationOptions().SetRegion("us-east-1")
+		assert.Equal(t, "us-east-1", utility.FromStringPtr(opts.Region))
+	})
+	t.Run("SetAccountID", func(t *testing.T) {
+		opts := NewSecretLocationOptions().SetAccountID("123456789012")
+		assert.Equal(t, "123456789012", utility.FromStringPtr(opts.AccountID))
+	})
+	t.Run("SetAllowCrossAccount", func(t *testing.T) {
+		opts := NewSecretLocationOptions().SetAllowCrossAccount(true)
+		assert.True(t, utility.FromBoolPtr(opts.AllowCrossAccount))
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("SucceedsWithEmpty", func(t *testing.T) {
+			assert.NoError(t, NewSecretLocationOptions().Validate())
+		})
+		t.Run("FailsWithEmptyRegion", func(t *testing.T) {
+			assert.Error(t, NewSecretLocationOptions().SetRegion("").Validate())
+		})
+		t.Run("FailsWithEmptyAccountID", func(t *testing.T) {
+			assert.Error(t, NewSecretLocationOptions().SetAccountID("").Validate())
+		})
+	})
+}
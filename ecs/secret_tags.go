@@ -0,0 +1,78 @@
+package ecs
+
+import (
+	"github.com/evergreen-ci/cocoa"
+	"github.com/mongodb/grip"
+)
+
+// SecretTagPropagationOptions are options to apply a pod definition's tags to
+// the new secrets that are created for it. This keeps tags such as
+// cost-allocation tags consistent between the pod definition in ECS and its
+// secrets in Secrets Manager.
+type SecretTagPropagationOptions struct {
+	// Keys are the keys of the pod definition's tags to apply to its new
+	// secrets. If this is empty, all of the pod definition's tags are applied.
+	Keys []string
+}
+
+// NewSecretTagPropagationOptions returns new uninitialized options to apply a
+// pod definition's tags to its new secrets.
+func NewSecretTagPropagationOptions() *SecretTagPropagationOptions {
+	return &SecretTagPropagationOptions{}
+}
+
+// SetKeys sets the keys of the pod definition's tags to apply to its new
+// secrets. This overwrites any existing keys.
+func (o *SecretTagPropagationOptions) SetKeys(keys []string) *SecretTagPropagationOptions {
+	o.Keys = keys
+	return o
+}
+
+// AddKeys adds new keys of the pod definition's tags to apply to its new
+// secrets.
+func (o *SecretTagPropagationOptions) AddKeys(keys ...string) *SecretTagPropagationOptions {
+	o.Keys = append(o.Keys, keys...)
+	return o
+}
+
+// Validate checks that none of the keys are empty.
+func (o *SecretTagPropagationOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	for i, key := range o.Keys {
+		catcher.ErrorfWhen(key == "", "tag key at index %d cannot be empty", i)
+	}
+	return catcher.Resolve()
+}
+
+// filter returns a copy of the pod definition's tags that should be applied to
+// its new secrets.
+func (o *SecretTagPropagationOptions) filter(tags cocoa.Tags) cocoa.Tags {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	filtered := cocoa.NewTags()
+	if len(o.Keys) == 0 {
+		return filtered.Add(tags)
+	}
+	for _, key := range o.Keys {
+		if val, ok := tags[key]; ok {
+			filtered.Set(key, val)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+	return filtered
+}
+
+// withPropagatedTags returns a copy of the secret options with the propagated
+// tags applied. Tags that are explicitly set on the secret take precedence
+// over the propagated tags.
+func withPropagatedTags(opts cocoa.SecretOptions, tags cocoa.Tags) cocoa.SecretOptions {
+	if len(tags) == 0 {
+		return opts
+	}
+	opts.Tags = cocoa.NewTags().Add(tags).Add(opts.Tags)
+	return opts
+}
@@ -0,0 +1,57 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretTagPropagationOptions(t *testing.T) {
+	t.Run("ValidateSucceedsWithoutKeys", func(t *testing.T) {
+		assert.NoError(t, NewSecretTagPropagationOptions().Validate())
+	})
+	t.Run("ValidateFailsWithEmptyKey", func(t *testing.T) {
+		assert.Error(t, NewSecretTagPropagationOptions().AddKeys("team", "").Validate())
+	})
+
+	tags := cocoa.Tags{"team": "evergreen", "cost-center": "123", "env": "prod"}
+	t.Run("FilterReturnsAllTagsWithoutKeys", func(t *testing.T) {
+		filtered := NewSecretTagPropagationOptions().filter(tags)
+		assert.Equal(t, tags, filtered)
+		filtered.Set("other", "value")
+		assert.NotContains(t, tags, "other", "filtered tags should be a copy")
+	})
+	t.Run("FilterReturnsOnlySelectedTags", func(t *testing.T) {
+		filtered := NewSecretTagPropagationOptions().AddKeys("team", "cost-center", "nonexistent").filter(tags)
+		assert.Equal(t, cocoa.Tags{"team": "evergreen", "cost-center": "123"}, filtered)
+	})
+	t.Run("FilterReturnsNothingWithoutMatchingTags", func(t *testing.T) {
+		assert.Empty(t, NewSecretTagPropagationOptions().AddKeys("nonexistent").filter(tags))
+	})
+	t.Run("FilterReturnsNothingWithoutTags", func(t *testing.T) {
+		assert.Empty(t, NewSecretTagPropagationOptions().filter(nil))
+	})
+}
+
+func TestWithPropagatedTags(t *testing.T) {
+	t.Run("AddsPropagatedTags", func(t *testing.T) {
+		opts := *cocoa.NewSecretOptions().SetName("name").SetNewValue("value")
+		updated := withPropagatedTags(opts, cocoa.Tags{"team": "evergreen"})
+		assert.Equal(t, cocoa.Tags{"team": "evergreen"}, updated.Tags)
+		assert.Empty(t, opts.Tags, "original options should not be modified")
+	})
+	t.Run("SecretTagsTakePrecedence", func(t *testing.T) {
+		opts := *cocoa.NewSecretOptions().
+			SetName("name").
+			SetNewValue("value").
+			SetTags(map[string]string{"team": "other", "owner": "me"})
+		updated := withPropagatedTags(opts, cocoa.Tags{"team": "evergreen", "env": "prod"})
+		assert.Equal(t, cocoa.Tags{"team": "other", "owner": "me", "env": "prod"}, updated.Tags)
+		assert.Equal(t, cocoa.Tags{"team": "other", "owner": "me"}, opts.Tags, "original options should not be modified")
+	})
+	t.Run("NoopWithoutPropagatedTags", func(t *testing.T) {
+		opts := *cocoa.NewSecretOptions().SetName("name").SetNewValue("value")
+		assert.Equal(t, opts, withPropagatedTags(opts, nil))
+	})
+}
@@ -0,0 +1,155 @@
+package ecs
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/smithy-go"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/identity"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+const (
+	// secretNotFoundErrorCode is the error code that Secrets Manager returns
+	// when a secret does not exist.
+	secretNotFoundErrorCode = "ResourceNotFoundException"
+	// ssmService is the ARN service name for SSM Parameter Store.
+	ssmService = "ssm"
+)
+
+// VerifyPodDefinitionSecrets checks that every Secrets Manager secret
+// referenced by the pod definition with the given ID exists and is not
+// scheduled for deletion. Otherwise, ECS accepts the request to run the pod but
+// the pod fails asynchronously when its containers cannot fetch their secrets.
+// If any of the secrets are missing, it returns a *cocoa.MissingSecretsError
+// listing all of them. Secret ARNs that cannot be parsed are also reported as
+// missing, since the containers cannot fetch them either. Secrets referenced by
+// an SSM Parameter Store ARN are not checked.
+func VerifyPodDefinitionSecrets(ctx context.Context, c cocoa.ECSClient, v cocoa.SecretDescriber, id string) error {
+	if c == nil {
+		return errors.New("must specify an ECS client")
+	}
+	if v == nil {
+		return errors.New("must specify a vault that can describe secrets")
+	}
+	if id == "" {
+		return errors.New("must specify a pod definition ID")
+	}
+
+	out, err := c.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String(id),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "describing task definition '%s'", id)
+	}
+	if out.TaskDefinition == nil {
+		return errors.Errorf("expected task definition '%s' from ECS, but none was returned", id)
+	}
+
+	secretIDs, missing := referencedSecretIDs(*out.TaskDefinition)
+	for _, secretID := range secretIDs {
+		md, err := v.DescribeSecret(ctx, secretID)
+		if isSecretNotFoundError(err) {
+			missing = append(missing, secretID)
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "describing secret '%s'", secretID)
+		}
+		if md == nil || !md.Deleted.IsZero() {
+			missing = append(missing, secretID)
+		}
+	}
+
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return cocoa.NewMissingSecretsError(id, missing)
+	}
+
+	return nil
+}
+
+// referencedSecretIDs returns the sorted, unique IDs of the Secrets Manager
+// secrets referenced by the task definition's containers, including their
+// repository credentials and log configurations. It also returns the sorted,
+// unique references that look like secret ARNs but cannot be parsed.
+func referencedSecretIDs(def types.TaskDefinition) (ids []string, invalid []string) {
+	idSet := map[string]bool{}
+	invalidSet := map[string]bool{}
+	add := func(valueFrom *string) {
+		id, ok, err := secretsManagerSecretID(utility.FromStringPtr(valueFrom))
+		if err != nil {
+			invalidSet[utility.FromStringPtr(valueFrom)] = true
+			return
+		}
+		if ok {
+			idSet[id] = true
+		}
+	}
+	for _, containerDef := range def.ContainerDefinitions {
+		for _, s := range containerDef.Secrets {
+			add(s.ValueFrom)
+		}
+		if containerDef.RepositoryCredentials != nil {
+			add(containerDef.RepositoryCredentials.CredentialsParameter)
+		}
+		if containerDef.LogConfiguration != nil {
+			for _, s := range containerDef.LogConfiguration.SecretOptions {
+				add(s.ValueFrom)
+			}
+		}
+	}
+
+	return sortedKeys(idSet), sortedKeys(invalidSet)
+}
+
+// sortedKeys returns the keys of the set in sorted order.
+func sortedKeys(set map[string]bool) []string {
+	sorted := make([]string, 0, len(set))
+	for key := range set {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// secretsManagerSecretID returns the ID of the Secrets Manager secret that a
+// container secret is fetched from. A secret ARN may refer to a specific JSON
+// key, version stage, or version ID of the secret, which are removed so that
+// the ARN identifies the secret itself. It returns false if the value is empty
+// or is an SSM Parameter Store ARN. Any other ARN that is not a valid Secrets
+// Manager secret ARN results in an error.
+func secretsManagerSecretID(valueFrom string) (string, bool, error) {
+	if valueFrom == "" {
+		return "", false, nil
+	}
+	if !cocoa.IsSecretARN(valueFrom) {
+		return valueFrom, true, nil
+	}
+	if parsed, err := arn.Parse(valueFrom); err == nil && parsed.Service == ssmService {
+		return "", false, nil
+	}
+
+	parsed, err := identity.ParseSecretARN(valueFrom)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "parsing secret ARN '%s'", valueFrom)
+	}
+
+	return parsed.SecretID(), true, nil
+}
+
+// isSecretNotFoundError returns whether or not the error is because the secret
+// does not exist.
+func isSecretNotFoundError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == secretNotFoundErrorCode
+}
@@ -0,0 +1,110 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretsManagerSecretID(t *testing.T) {
+	const secretARN = "arn:aws:secretsmanager:us-east-1:123456789012:secret:name-AbCdEf"
+
+	t.Run("ReturnsSecretARN", func(t *testing.T) {
+		id, ok, err := secretsManagerSecretID(secretARN)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, secretARN, id)
+	})
+	t.Run("RemovesJSONKeyAndVersionFromSecretARN", func(t *testing.T) {
+		id, ok, err := secretsManagerSecretID(secretARN + ":key:AWSCURRENT:")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, secretARN, id)
+	})
+	t.Run("ReturnsSecretName", func(t *testing.T) {
+		id, ok, err := secretsManagerSecretID("name")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "name", id)
+	})
+	t.Run("IgnoresSSMParameterARN", func(t *testing.T) {
+		_, ok, err := secretsManagerSecretID("arn:aws:ssm:us-east-1:123456789012:parameter/name")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("IgnoresEmptyValue", func(t *testing.T) {
+		_, ok, err := secretsManagerSecretID("")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("FailsWithUnparseableSecretARN", func(t *testing.T) {
+		_, ok, err := secretsManagerSecretID("arn:aws:secretsmanager:us-east-1:123456789012:secret:name:key")
+		assert.Error(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("FailsWithMalformedARN", func(t *testing.T) {
+		_, ok, err := secretsManagerSecretID("arn:aws:secretsmanager")
+		assert.Error(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("FailsWithARNFromOtherService", func(t *testing.T) {
+		_, ok, err := secretsManagerSecretID("arn:aws:s3:::bucket/name")
+		assert.Error(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestReferencedSecretIDs(t *testing.T) {
+	t.Run("ReturnsSortedUniqueSecretsFromAllContainerFields", func(t *testing.T) {
+		def := types.TaskDefinition{
+			ContainerDefinitions: []types.ContainerDefinition{
+				{
+					Secrets: []types.Secret{
+						{Name: aws.String("s0"), ValueFrom: aws.String("secret1")},
+						{Name: aws.String("s1"), ValueFrom: aws.String("secret0")},
+					},
+					RepositoryCredentials: &types.RepositoryCredentials{
+						CredentialsParameter: aws.String("repo_creds"),
+					},
+				},
+				{
+					Secrets: []types.Secret{
+						{Name: aws.String("s0"), ValueFrom: aws.String("secret1")},
+						{Name: aws.String("s1"), ValueFrom: aws.String("arn:aws:ssm:us-east-1:123456789012:parameter/name")},
+					},
+					LogConfiguration: &types.LogConfiguration{
+						SecretOptions: []types.Secret{
+							{Name: aws.String("s2"), ValueFrom: aws.String("log_secret")},
+						},
+					},
+				},
+			},
+		}
+		ids, invalid := referencedSecretIDs(def)
+		assert.Equal(t, []string{"log_secret", "repo_creds", "secret0", "secret1"}, ids)
+		assert.Empty(t, invalid)
+	})
+	t.Run("ReturnsUnparseableSecretARNsSeparately", func(t *testing.T) {
+		const badARN = "arn:aws:secretsmanager:us-east-1:123456789012:secret:name:key"
+		def := types.TaskDefinition{
+			ContainerDefinitions: []types.ContainerDefinition{{
+				Secrets: []types.Secret{
+					{Name: aws.String("s0"), ValueFrom: aws.String("secret0")},
+					{Name: aws.String("s1"), ValueFrom: aws.String(badARN)},
+				},
+			}},
+		}
+		ids, invalid := referencedSecretIDs(def)
+		assert.Equal(t, []string{"secret0"}, ids)
+		assert.Equal(t, []string{badARN}, invalid)
+	})
+	t.Run("ReturnsNothingWithoutSecrets", func(t *testing.T) {
+		ids, invalid := referencedSecretIDs(types.TaskDefinition{
+			ContainerDefinitions: []types.ContainerDefinition{{Name: aws.String("container")}},
+		})
+		assert.Empty(t, ids)
+		assert.Empty(t, invalid)
+	})
+}
@@ -0,0 +1,52 @@
+package ecs
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// ExportCreateServiceInput converts the options for a pod definition and its
+// execution into an equivalent input to create a long-running ECS service.
+// The service is named after the pod definition and runs the latest active
+// revision of the pod definition's family, so the pod definition must already
+// be registered. The service maintains the desired number of pods running.
+//
+// Some execution options only apply to pods that are run individually and are
+// not supported by ECS services; in particular, it is an error to specify
+// options to override the pod definition or a max lifetime, and the placement
+// group is ignored.
+func ExportCreateServiceInput(defOpts cocoa.ECSPodDefinitionOptions, execOpts cocoa.ECSPodExecutionOptions, desiredCount int) (*ecs.CreateServiceInput, error) {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(utility.FromStringPtr(defOpts.Name) == "", "must specify a pod definition name to use for the service")
+	catcher.NewWhen(desiredCount < 0, "cannot specify a negative desired count")
+	catcher.NewWhen(execOpts.OverrideOpts != nil, "cannot specify pod definition overrides for a service")
+	catcher.NewWhen(execOpts.MaxLifetime != nil, "cannot specify a max lifetime for a service")
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	creationOpts := cocoa.NewECSPodCreationOptions().
+		SetDefinitionOptions(defOpts).
+		SetExecutionOptions(execOpts)
+	if err := creationOpts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid pod creation options")
+	}
+	validatedExecOpts := *creationOpts.ExecutionOpts
+
+	return &ecs.CreateServiceInput{
+		ServiceName:              defOpts.Name,
+		TaskDefinition:           defOpts.Name,
+		Cluster:                  validatedExecOpts.Cluster,
+		DesiredCount:             aws.Int32(int32(desiredCount)),
+		CapacityProviderStrategy: exportCapacityProvider(validatedExecOpts.CapacityProvider),
+		NetworkConfiguration:     exportAWSVPCOptions(validatedExecOpts.AWSVPCOpts),
+		PlacementStrategy:        exportStrategy(validatedExecOpts),
+		PlacementConstraints:     exportPlacementConstraints(validatedExecOpts.PlacementOpts),
+		EnableExecuteCommand:     utility.FromBoolPtr(validatedExecOpts.SupportsDebugMode),
+		Tags:                     ExportTags(validatedExecOpts.Tags),
+	}, nil
+}
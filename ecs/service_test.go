@@ -0,0 +1,89 @@
+package ecs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCreateServiceInput(t *testing.T) {
+	makeDefOpts := func() cocoa.ECSPodDefinitionOptions {
+		containerDef := cocoa.NewECSContainerDefinition().SetImage("image")
+		return *cocoa.NewECSPodDefinitionOptions().
+			SetName("name").
+			SetMemoryMB(128).
+			SetCPU(128).
+			AddContainerDefinitions(*containerDef)
+	}
+
+	t.Run("SucceedsWithMinimalOptions", func(t *testing.T) {
+		in, err := ExportCreateServiceInput(makeDefOpts(), *cocoa.NewECSPodExecutionOptions().SetCluster("cluster"), 2)
+		require.NoError(t, err)
+		require.NotZero(t, in)
+		assert.Equal(t, "name", utility.FromStringPtr(in.ServiceName))
+		assert.Equal(t, "name", utility.FromStringPtr(in.TaskDefinition))
+		assert.Equal(t, "cluster", utility.FromStringPtr(in.Cluster))
+		assert.EqualValues(t, 2, utility.FromInt32Ptr(in.DesiredCount))
+		require.Len(t, in.PlacementStrategy, 1)
+		assert.EqualValues(t, cocoa.StrategyBinpack, in.PlacementStrategy[0].Type)
+		assert.Zero(t, in.NetworkConfiguration)
+		assert.Zero(t, in.CapacityProviderStrategy)
+	})
+	t.Run("ExportsAllExecutionOptions", func(t *testing.T) {
+		defOpts := makeDefOpts()
+		defOpts.SetNetworkMode(cocoa.NetworkModeAWSVPC)
+		execOpts := cocoa.NewECSPodExecutionOptions().
+			SetCluster("cluster").
+			SetCapacityProvider("provider").
+			SetAWSVPCOptions(*cocoa.NewAWSVPCOptions().AddSubnets("subnet").AddSecurityGroups("sg")).
+			SetSupportsDebugMode(true).
+			SetTags(map[string]string{"key": "value"})
+
+		in, err := ExportCreateServiceInput(defOpts, *execOpts, 1)
+		require.NoError(t, err)
+		require.NotZero(t, in)
+		require.Len(t, in.CapacityProviderStrategy, 1)
+		assert.Equal(t, "provider", utility.FromStringPtr(in.CapacityProviderStrategy[0].CapacityProvider))
+		require.NotZero(t, in.NetworkConfiguration)
+		require.NotZero(t, in.NetworkConfiguration.AwsvpcConfiguration)
+		assert.ElementsMatch(t, []string{"subnet"}, in.NetworkConfiguration.AwsvpcConfiguration.Subnets)
+		assert.ElementsMatch(t, []string{"sg"}, in.NetworkConfiguration.AwsvpcConfiguration.SecurityGroups)
+		assert.True(t, in.EnableExecuteCommand)
+		require.Len(t, in.Tags, 1)
+		assert.Equal(t, "key", utility.FromStringPtr(in.Tags[0].Key))
+		assert.Equal(t, "value", utility.FromStringPtr(in.Tags[0].Value))
+	})
+	t.Run("FailsWithoutName", func(t *testing.T) {
+		defOpts := makeDefOpts()
+		defOpts.Name = nil
+		in, err := ExportCreateServiceInput(defOpts, *cocoa.NewECSPodExecutionOptions(), 1)
+		assert.Error(t, err)
+		assert.Zero(t, in)
+	})
+	t.Run("FailsWithNegativeDesiredCount", func(t *testing.T) {
+		in, err := ExportCreateServiceInput(makeDefOpts(), *cocoa.NewECSPodExecutionOptions(), -1)
+		assert.Error(t, err)
+		assert.Zero(t, in)
+	})
+	t.Run("FailsWithOverrideOptions", func(t *testing.T) {
+		execOpts := cocoa.NewECSPodExecutionOptions().SetOverrideOptions(*cocoa.NewECSOverridePodDefinitionOptions())
+		in, err := ExportCreateServiceInput(makeDefOpts(), *execOpts, 1)
+		assert.Error(t, err)
+		assert.Zero(t, in)
+	})
+	t.Run("FailsWithMaxLifetime", func(t *testing.T) {
+		execOpts := cocoa.NewECSPodExecutionOptions().SetMaxLifetime(time.Hour)
+		in, err := ExportCreateServiceInput(makeDefOpts(), *execOpts, 1)
+		assert.Error(t, err)
+		assert.Zero(t, in)
+	})
+	t.Run("FailsWithInvalidDefinitionOptions", func(t *testing.T) {
+		in, err := ExportCreateServiceInput(*cocoa.NewECSPodDefinitionOptions().SetName("name"), *cocoa.NewECSPodExecutionOptions(), 1)
+		assert.Error(t, err)
+		assert.Zero(t, in)
+	})
+}
@@ -0,0 +1,157 @@
+package ecs
+
+import (
+	"context"
+	"time"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+)
+
+// PodCreationStageTimeouts are the maximum amounts of time that each stage of
+// creating a pod can take. Stages without a timeout are only limited by the
+// context used to create the pod.
+type PodCreationStageTimeouts struct {
+	// Secrets is the timeout for creating the pod definition's new secrets.
+	Secrets *time.Duration
+	// Definition is the timeout for registering the pod definition.
+	Definition *time.Duration
+	// Run is the timeout for running the pod.
+	Run *time.Duration
+	// Translate is the timeout for translating the running pod's resources
+	// into the returned pod.
+	Translate *time.Duration
+}
+
+// NewPodCreationStageTimeouts returns new uninitialized timeouts for the
+// stages of creating a pod.
+func NewPodCreationStageTimeouts() *PodCreationStageTimeouts {
+	return &PodCreationStageTimeouts{}
+}
+
+// SetSecrets sets the timeout for creating the pod definition's new secrets.
+func (t *PodCreationStageTimeouts) SetSecrets(timeout time.Duration) *PodCreationStageTimeouts {
+	t.Secrets = &timeout
+	return t
+}
+
+// SetDefinition sets the timeout for registering the pod definition.
+func (t *PodCreationStageTimeouts) SetDefinition(timeout time.Duration) *PodCreationStageTimeouts {
+	t.Definition = &timeout
+	return t
+}
+
+// SetRun sets the timeout for running the pod.
+func (t *PodCreationStageTimeouts) SetRun(timeout time.Duration) *PodCreationStageTimeouts {
+	t.Run = &timeout
+	return t
+}
+
+// SetTranslate sets the timeout for translating the running pod's resources
+// into the returned pod.
+func (t *PodCreationStageTimeouts) SetTranslate(timeout time.Duration) *PodCreationStageTimeouts {
+	t.Translate = &timeout
+	return t
+}
+
+// Validate checks that all the given timeouts are positive.
+func (t *PodCreationStageTimeouts) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	for _, stage := range cocoa.ECSPodCreationStages() {
+		timeout := t.get(stage)
+		catcher.ErrorfWhen(timeout != nil && *timeout <= 0, "must specify a positive timeout for stage '%s'", stage)
+	}
+	return catcher.Resolve()
+}
+
+// get returns the timeout for the given stage, if any.
+func (t *PodCreationStageTimeouts) get(stage cocoa.ECSPodCreationStage) *time.Duration {
+	switch stage {
+	case cocoa.ECSPodCreationStageSecrets:
+		return t.Secrets
+	case cocoa.ECSPodCreationStageDefinition:
+		return t.Definition
+	case cocoa.ECSPodCreationStageRun:
+		return t.Run
+	case cocoa.ECSPodCreationStageTranslate:
+		return t.Translate
+	default:
+		return nil
+	}
+}
+
+// podCreationStages tracks the stages of creating a single pod. It applies the
+// timeout for each stage and records how long each stage takes. A nil
+// podCreationStages runs each stage without tracking it.
+type podCreationStages struct {
+	timeouts  *PodCreationStageTimeouts
+	durations map[cocoa.ECSPodCreationStage]time.Duration
+	// failed is the first stage that failed, if any.
+	failed cocoa.ECSPodCreationStage
+}
+
+func newPodCreationStages(timeouts *PodCreationStageTimeouts) *podCreationStages {
+	return &podCreationStages{
+		timeouts:  timeouts,
+		durations: map[cocoa.ECSPodCreationStage]time.Duration{},
+	}
+}
+
+// run runs the stage with its timeout, if any, and records how long it took
+// and whether it failed.
+func (s *podCreationStages) run(ctx context.Context, stage cocoa.ECSPodCreationStage, fn func(ctx context.Context) error) error {
+	if s == nil {
+		return fn(ctx)
+	}
+
+	if s.timeouts != nil {
+		if timeout := s.timeouts.get(stage); timeout != nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *timeout)
+			defer cancel()
+		}
+	}
+
+	start := time.Now()
+	err := fn(ctx)
+	s.durations[stage] += time.Since(start)
+	if err != nil && s.failed == "" {
+		s.failed = stage
+	}
+
+	return err
+}
+
+// wrapError returns the error as a *cocoa.StagedError identifying the stage
+// that failed. If no stage failed (e.g. because the options were invalid),
+// the error is returned as-is.
+func (s *podCreationStages) wrapError(err error) error {
+	if err == nil || s == nil || s.failed == "" {
+		return err
+	}
+	return cocoa.NewStagedError(err, s.failed, s.copyDurations())
+}
+
+// logDurations logs how long each stage took to create the pod.
+func (s *podCreationStages) logDurations(taskID string) {
+	if s == nil {
+		return
+	}
+	fields := message.Fields{
+		"message": "created pod",
+		"task_id": taskID,
+	}
+	for stage, d := range s.durations {
+		fields[string(stage)+"_duration_secs"] = d.Seconds()
+	}
+	grip.Debug(fields)
+}
+
+func (s *podCreationStages) copyDurations() map[cocoa.ECSPodCreationStage]time.Duration {
+	durations := make(map[cocoa.ECSPodCreationStage]time.Duration, len(s.durations))
+	for stage, d := range s.durations {
+		durations[stage] = d
+	}
+	return durations
+}
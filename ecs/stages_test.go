@@ -0,0 +1,109 @@
+package ecs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPodCreationStageTimeouts(t *testing.T) {
+	t.Run("NewPodCreationStageTimeouts", func(t *testing.T) {
+		timeouts := NewPodCreationStageTimeouts()
+		require.NotZero(t, timeouts)
+		assert.Zero(t, *timeouts)
+	})
+	t.Run("Setters", func(t *testing.T) {
+		timeouts := NewPodCreationStageTimeouts().
+			SetSecrets(time.Second).
+			SetDefinition(2 * time.Second).
+			SetRun(3 * time.Second).
+			SetTranslate(4 * time.Second)
+		assert.Equal(t, time.Second, *timeouts.get(cocoa.ECSPodCreationStageSecrets))
+		assert.Equal(t, 2*time.Second, *timeouts.get(cocoa.ECSPodCreationStageDefinition))
+		assert.Equal(t, 3*time.Second, *timeouts.get(cocoa.ECSPodCreationStageRun))
+		assert.Equal(t, 4*time.Second, *timeouts.get(cocoa.ECSPodCreationStageTranslate))
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("EmptyIsValid", func(t *testing.T) {
+			assert.NoError(t, NewPodCreationStageTimeouts().Validate())
+		})
+		t.Run("PositiveTimeoutsAreValid", func(t *testing.T) {
+			assert.NoError(t, NewPodCreationStageTimeouts().SetRun(time.Minute).Validate())
+		})
+		t.Run("ZeroTimeoutIsInvalid", func(t *testing.T) {
+			assert.Error(t, NewPodCreationStageTimeouts().SetSecrets(0).Validate())
+		})
+		t.Run("NegativeTimeoutIsInvalid", func(t *testing.T) {
+			assert.Error(t, NewPodCreationStageTimeouts().SetTranslate(-time.Second).Validate())
+		})
+	})
+}
+
+func TestPodCreationStages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("RecordsDurationsOfSuccessfulStages", func(t *testing.T) {
+		stages := newPodCreationStages(nil)
+		require.NoError(t, stages.run(ctx, cocoa.ECSPodCreationStageSecrets, func(ctx context.Context) error { return nil }))
+		require.NoError(t, stages.run(ctx, cocoa.ECSPodCreationStageRun, func(ctx context.Context) error { return nil }))
+
+		assert.Contains(t, stages.durations, cocoa.ECSPodCreationStageSecrets)
+		assert.Contains(t, stages.durations, cocoa.ECSPodCreationStageRun)
+		assert.NotContains(t, stages.durations, cocoa.ECSPodCreationStageDefinition)
+		assert.NoError(t, stages.wrapError(nil))
+	})
+	t.Run("AppliesStageTimeout", func(t *testing.T) {
+		stages := newPodCreationStages(NewPodCreationStageTimeouts().SetRun(time.Millisecond))
+		err := stages.run(ctx, cocoa.ECSPodCreationStageRun, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.NoError(t, ctx.Err(), "stage timeout should not affect the parent context")
+	})
+	t.Run("DoesNotApplyTimeoutToOtherStages", func(t *testing.T) {
+		stages := newPodCreationStages(NewPodCreationStageTimeouts().SetRun(time.Millisecond))
+		assert.NoError(t, stages.run(ctx, cocoa.ECSPodCreationStageSecrets, func(ctx context.Context) error {
+			_, hasDeadline := ctx.Deadline()
+			assert.False(t, hasDeadline)
+			return nil
+		}))
+	})
+	t.Run("WrapsErrorWithFirstFailedStage", func(t *testing.T) {
+		stages := newPodCreationStages(nil)
+		require.NoError(t, stages.run(ctx, cocoa.ECSPodCreationStageSecrets, func(ctx context.Context) error { return nil }))
+		cause := errors.New("fake error")
+		require.Error(t, stages.run(ctx, cocoa.ECSPodCreationStageDefinition, func(ctx context.Context) error { return cause }))
+
+		err := stages.wrapError(errors.Wrap(cause, "wrapping message"))
+		se, ok := cocoa.AsStagedError(err)
+		require.True(t, ok)
+		assert.Equal(t, cocoa.ECSPodCreationStageDefinition, se.Stage)
+		assert.Contains(t, se.Durations, cocoa.ECSPodCreationStageSecrets)
+		assert.Contains(t, se.Durations, cocoa.ECSPodCreationStageDefinition)
+		assert.True(t, errors.Is(err, cause))
+	})
+	t.Run("DoesNotWrapErrorWithoutFailedStage", func(t *testing.T) {
+		stages := newPodCreationStages(nil)
+		err := stages.wrapError(errors.New("fake error"))
+		assert.False(t, cocoa.IsStagedError(err))
+	})
+	t.Run("NilStagesRunWithoutTracking", func(t *testing.T) {
+		var stages *podCreationStages
+		var ran bool
+		assert.NoError(t, stages.run(ctx, cocoa.ECSPodCreationStageRun, func(ctx context.Context) error {
+			ran = true
+			return nil
+		}))
+		assert.True(t, ran)
+		cause := errors.New("fake error")
+		assert.Equal(t, cause, stages.wrapError(cause))
+	})
+}
@@ -0,0 +1,157 @@
+package ecs
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// defaultStopGroupConcurrency is the maximum number of tasks that are stopped
+// at once when stopping a group.
+const defaultStopGroupConcurrency = 10
+
+// StopGroupResult is the result of stopping all the pods in a group.
+type StopGroupResult struct {
+	// Stopped are the ARNs of the tasks that were stopped. Tasks that ECS no
+	// longer has information about are considered stopped.
+	Stopped []string
+	// Failed maps the ARNs of the tasks that could not be stopped to the
+	// error that occurred while stopping them.
+	Failed map[string]error
+}
+
+// StopGroup stops all the running pods in the cluster that belong to the given
+// placement group. Since ECS cannot filter tasks by group, all the running
+// tasks in the cluster are listed and described to find the ones in the group.
+// The tasks are stopped concurrently, but only up to a limited number at once
+// to avoid ECS throttling. Stopping a task only stops the pod without cleaning
+// up any of its underlying resources.
+//
+// All the tasks in the group are attempted even if some of them fail to stop.
+// The result contains which tasks were stopped and which ones failed; if any
+// failed, an error is also returned along with the result.
+func StopGroup(ctx context.Context, c cocoa.ECSClient, cluster, group, reason string) (*StopGroupResult, error) {
+	return stopGroup(ctx, c, cluster, group, reason, defaultStopGroupConcurrency)
+}
+
+func stopGroup(ctx context.Context, c cocoa.ECSClient, cluster, group, reason string, concurrency int) (*StopGroupResult, error) {
+	if c == nil {
+		return nil, errors.New("must specify a client")
+	}
+	if cluster == "" {
+		return nil, errors.New("must specify a cluster")
+	}
+	if group == "" {
+		return nil, errors.New("must specify a group")
+	}
+
+	arns, err := listGroupTaskARNs(ctx, c, cluster, group)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing tasks in group '%s'", group)
+	}
+
+	var res StopGroupResult
+	res.Stopped, res.Failed = stopTasks(ctx, c, cluster, arns, reason, concurrency)
+
+	if len(res.Failed) != 0 {
+		failed := make([]string, 0, len(res.Failed))
+		for arn := range res.Failed {
+			failed = append(failed, arn)
+		}
+		sort.Strings(failed)
+
+		catcher := grip.NewBasicCatcher()
+		for _, arn := range failed {
+			catcher.Wrapf(res.Failed[arn], "stopping task '%s'", arn)
+		}
+		return &res, errors.Wrapf(catcher.Resolve(), "stopping %d of %d tasks in group '%s'", len(res.Failed), len(arns), group)
+	}
+
+	return &res, nil
+}
+
+// listGroupTaskARNs lists the ARNs of all the running tasks in the cluster
+// that belong to the group.
+func listGroupTaskARNs(ctx context.Context, c cocoa.ECSClient, cluster, group string) ([]string, error) {
+	filters := NewListPodsFilters().SetDesiredStatus(types.DesiredStatusRunning).SetGroup(group)
+	arns, err := listTaskARNs(ctx, c, cluster, *filters)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing tasks")
+	}
+	if len(arns) == 0 {
+		return nil, nil
+	}
+
+	out, err := DescribeAllTasks(ctx, c, cluster, arns)
+	if err != nil {
+		return nil, errors.Wrap(err, "describing tasks")
+	}
+
+	var groupARNs []string
+	for _, task := range out.Tasks {
+		if utility.FromStringPtr(task.Group) != group {
+			continue
+		}
+		groupARNs = append(groupARNs, utility.FromStringPtr(task.TaskArn))
+	}
+
+	return groupARNs, nil
+}
+
+// stopTasks stops all the tasks in the cluster concurrently, but only up to
+// the given number at once. It returns the sorted ARNs of the tasks that were
+// stopped and the errors for the tasks that could not be stopped. Tasks that
+// ECS no longer has information about are considered stopped.
+func stopTasks(ctx context.Context, c cocoa.ECSClient, cluster string, arns []string, reason string, concurrency int) (stopped []string, failed map[string]error) {
+	failed = map[string]error{}
+	var resMu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for _, arn := range arns {
+		wg.Add(1)
+		go func(arn string) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				resMu.Lock()
+				failed[arn] = ctx.Err()
+				resMu.Unlock()
+				return
+			}
+
+			_, err := c.StopTask(ctx, &ecs.StopTaskInput{
+				Cluster: aws.String(cluster),
+				Task:    aws.String(arn),
+				Reason:  utility.ToStringPtr(reason),
+			})
+
+			resMu.Lock()
+			defer resMu.Unlock()
+			// If the task is not found, it has either already stopped or
+			// never existed, so stopping is considered successful.
+			if err != nil && !cocoa.IsECSTaskNotFoundError(err) {
+				failed[arn] = err
+				return
+			}
+			stopped = append(stopped, arn)
+		}(arn)
+	}
+
+	wg.Wait()
+
+	sort.Strings(stopped)
+
+	return stopped, failed
+}
@@ -0,0 +1,169 @@
+package ecs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// groupTrackingClient is an ECS client with a fixed set of running tasks that
+// records how many tasks are stopped at once.
+type groupTrackingClient struct {
+	cocoa.ECSClient
+
+	mu        sync.Mutex
+	groups    map[string]string
+	stopped   map[string]string
+	missing   map[string]bool
+	failARN   string
+	inFlight  int
+	maxSeen   int
+	listCalls int
+}
+
+func (c *groupTrackingClient) ListTasks(ctx context.Context, in *ecs.ListTasksInput) (*ecs.ListTasksOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.listCalls++
+	var arns []string
+	for arn := range c.groups {
+		arns = append(arns, arn)
+	}
+	return &ecs.ListTasksOutput{TaskArns: arns}, nil
+}
+
+func (c *groupTrackingClient) DescribeTasks(ctx context.Context, in *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var out ecs.DescribeTasksOutput
+	for _, arn := range in.Tasks {
+		out.Tasks = append(out.Tasks, types.Task{
+			TaskArn: utility.ToStringPtr(arn),
+			Group:   utility.ToStringPtr(c.groups[arn]),
+		})
+	}
+	return &out, nil
+}
+
+func (c *groupTrackingClient) StopTask(ctx context.Context, in *ecs.StopTaskInput) (*ecs.StopTaskOutput, error) {
+	arn := utility.FromStringPtr(in.Task)
+
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxSeen {
+		c.maxSeen = c.inFlight
+	}
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+
+	if arn == c.failARN {
+		return nil, errors.New("fake error")
+	}
+	if c.missing[arn] {
+		return nil, cocoa.NewECSTaskNotFoundError(arn)
+	}
+	c.stopped[arn] = utility.FromStringPtr(in.Reason)
+	return &ecs.StopTaskOutput{}, nil
+}
+
+func TestStopGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newClient := func() *groupTrackingClient {
+		return &groupTrackingClient{
+			groups: map[string]string{
+				"task0": "group",
+				"task1": "group",
+				"task2": "group",
+				"task3": "group",
+				"task4": "other",
+			},
+			stopped: map[string]string{},
+			missing: map[string]bool{},
+		}
+	}
+
+	t.Run("StopsOnlyTasksInGroup", func(t *testing.T) {
+		c := newClient()
+		res, err := stopGroup(ctx, c, "cluster", "group", "reason", 2)
+		require.NoError(t, err)
+		require.NotZero(t, res)
+		assert.Equal(t, []string{"task0", "task1", "task2", "task3"}, res.Stopped)
+		assert.Empty(t, res.Failed)
+		assert.Equal(t, map[string]string{
+			"task0": "reason",
+			"task1": "reason",
+			"task2": "reason",
+			"task3": "reason",
+		}, c.stopped)
+	})
+	t.Run("LimitsConcurrency", func(t *testing.T) {
+		c := newClient()
+		_, err := stopGroup(ctx, c, "cluster", "group", "reason", 2)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, c.maxSeen, 2)
+		assert.Equal(t, 2, c.maxSeen, "should stop tasks concurrently")
+	})
+	t.Run("ConsidersMissingTasksStopped", func(t *testing.T) {
+		c := newClient()
+		c.missing["task1"] = true
+		res, err := stopGroup(ctx, c, "cluster", "group", "reason", 2)
+		require.NoError(t, err)
+		require.NotZero(t, res)
+		assert.Equal(t, []string{"task0", "task1", "task2", "task3"}, res.Stopped)
+	})
+	t.Run("AggregatesFailuresAndStopsRemainingTasks", func(t *testing.T) {
+		c := newClient()
+		c.failARN = "task2"
+		res, err := stopGroup(ctx, c, "cluster", "group", "reason", 2)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "task2")
+		require.NotZero(t, res)
+		assert.Equal(t, []string{"task0", "task1", "task3"}, res.Stopped)
+		require.Len(t, res.Failed, 1)
+		assert.Error(t, res.Failed["task2"])
+	})
+	t.Run("SucceedsWithEmptyGroup", func(t *testing.T) {
+		c := newClient()
+		res, err := stopGroup(ctx, c, "cluster", "nonexistent", "reason", 2)
+		require.NoError(t, err)
+		require.NotZero(t, res)
+		assert.Empty(t, res.Stopped)
+		assert.Empty(t, res.Failed)
+		assert.Empty(t, c.stopped)
+	})
+	t.Run("FailsWithoutGroup", func(t *testing.T) {
+		c := newClient()
+		res, err := StopGroup(ctx, c, "cluster", "", "reason")
+		assert.Error(t, err)
+		assert.Zero(t, res)
+		assert.Zero(t, c.listCalls)
+	})
+	t.Run("FailsWithoutCluster", func(t *testing.T) {
+		res, err := StopGroup(ctx, newClient(), "", "group", "reason")
+		assert.Error(t, err)
+		assert.Zero(t, res)
+	})
+	t.Run("FailsWithoutClient", func(t *testing.T) {
+		res, err := StopGroup(ctx, nil, "cluster", "group", "reason")
+		assert.Error(t, err)
+		assert.Zero(t, res)
+	})
+}
@@ -0,0 +1,160 @@
+package ecs
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// defaultTagResourcesConcurrency is the maximum number of resources that are
+// tagged at once.
+const defaultTagResourcesConcurrency = 10
+
+// ResourceType is a type of ECS resource that can be tagged.
+type ResourceType string
+
+const (
+	// ResourceTypeTask is an ECS task (i.e. a pod).
+	ResourceTypeTask ResourceType = "task"
+	// ResourceTypeTaskDefinition is an ECS task definition (i.e. a pod
+	// definition).
+	ResourceTypeTaskDefinition ResourceType = "task-definition"
+)
+
+// DetectResourceType returns whether the ARN identifies a task or a task
+// definition.
+func DetectResourceType(id string) (ResourceType, error) {
+	parsed, err := arn.Parse(id)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing ARN '%s'", id)
+	}
+	if parsed.Service != "ecs" {
+		return "", errors.Errorf("ARN '%s' is for service '%s', not ECS", id, parsed.Service)
+	}
+
+	// The resource type is separated from the rest of the resource by either
+	// a slash or a colon.
+	resourceType := parsed.Resource
+	if i := strings.IndexAny(resourceType, "/:"); i != -1 {
+		resourceType = resourceType[:i]
+	}
+	switch ResourceType(resourceType) {
+	case ResourceTypeTask, ResourceTypeTaskDefinition:
+		return ResourceType(resourceType), nil
+	default:
+		return "", errors.Errorf("ARN '%s' has unsupported resource type '%s'", id, resourceType)
+	}
+}
+
+// TagResourcesResult is the result of tagging many resources.
+type TagResourcesResult struct {
+	// Tagged maps each type of resource to the ARNs of the resources of that
+	// type that were tagged.
+	Tagged map[ResourceType][]string
+	// Failed maps the ARNs of the resources that could not be tagged to the
+	// error that occurred while tagging them.
+	Failed map[string]error
+}
+
+// TagResources applies the tags to each of the tasks and task definitions
+// identified by ARN. This is useful for adding tags to existing resources
+// retroactively. The resources are tagged concurrently, but only up to a
+// limited number at once to avoid ECS throttling. Tagging a resource
+// overwrites the values of any of its existing tags with the same keys, so
+// resources that already have the tags are tagged successfully without
+// changing them. Resources that are given no tags are skipped.
+//
+// All the resources are attempted even if some of them fail to be tagged
+// (e.g. because the ARN is not a task or task definition). The result contains
+// which resources were tagged and which ones failed; if any failed, an error
+// is also returned along with the result.
+func TagResources(ctx context.Context, c cocoa.ECSClient, tags map[string][]types.Tag) (*TagResourcesResult, error) {
+	return tagResources(ctx, c, tags, defaultTagResourcesConcurrency)
+}
+
+func tagResources(ctx context.Context, c cocoa.ECSClient, tags map[string][]types.Tag, concurrency int) (*TagResourcesResult, error) {
+	if c == nil {
+		return nil, errors.New("must specify a client")
+	}
+
+	res := TagResourcesResult{
+		Tagged: map[ResourceType][]string{},
+		Failed: map[string]error{},
+	}
+	var resMu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for id, resourceTags := range tags {
+		if len(resourceTags) == 0 {
+			continue
+		}
+
+		resourceType, err := DetectResourceType(id)
+		if err != nil {
+			resMu.Lock()
+			res.Failed[id] = err
+			resMu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(id string, resourceType ResourceType, resourceTags []types.Tag) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				resMu.Lock()
+				res.Failed[id] = ctx.Err()
+				resMu.Unlock()
+				return
+			}
+
+			_, err := c.TagResource(ctx, &ecs.TagResourceInput{
+				ResourceArn: utility.ToStringPtr(id),
+				Tags:        resourceTags,
+			})
+
+			resMu.Lock()
+			defer resMu.Unlock()
+			if err != nil {
+				res.Failed[id] = err
+				return
+			}
+			res.Tagged[resourceType] = append(res.Tagged[resourceType], id)
+		}(id, resourceType, resourceTags)
+	}
+
+	wg.Wait()
+
+	for _, ids := range res.Tagged {
+		sort.Strings(ids)
+	}
+
+	if len(res.Failed) != 0 {
+		failed := make([]string, 0, len(res.Failed))
+		for id := range res.Failed {
+			failed = append(failed, id)
+		}
+		sort.Strings(failed)
+
+		catcher := grip.NewBasicCatcher()
+		for _, id := range failed {
+			catcher.Wrapf(res.Failed[id], "tagging resource '%s'", id)
+		}
+		return &res, errors.Wrapf(catcher.Resolve(), "tagging %d of %d resources", len(res.Failed), len(tags))
+	}
+
+	return &res, nil
+}
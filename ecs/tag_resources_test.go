@@ -0,0 +1,166 @@
+package ecs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tagTrackingClient is an ECS client that records the tags applied to each
+// resource and how many resources are tagged at once.
+type tagTrackingClient struct {
+	cocoa.ECSClient
+
+	mu       sync.Mutex
+	tagged   map[string]map[string]string
+	failARN  string
+	inFlight int
+	maxSeen  int
+}
+
+func (c *tagTrackingClient) TagResource(ctx context.Context, in *ecs.TagResourceInput) (*ecs.TagResourceOutput, error) {
+	id := utility.FromStringPtr(in.ResourceArn)
+
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxSeen {
+		c.maxSeen = c.inFlight
+	}
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+
+	if id == c.failARN {
+		return nil, errors.New("fake error")
+	}
+	if c.tagged[id] == nil {
+		c.tagged[id] = map[string]string{}
+	}
+	for _, tag := range in.Tags {
+		c.tagged[id][utility.FromStringPtr(tag.Key)] = utility.FromStringPtr(tag.Value)
+	}
+	return &ecs.TagResourceOutput{}, nil
+}
+
+func TestDetectResourceType(t *testing.T) {
+	for id, expected := range map[string]ResourceType{
+		"arn:aws:ecs:us-east-1:123456789012:task/cluster/0123456789abcdef":   ResourceTypeTask,
+		"arn:aws:ecs:us-east-1:123456789012:task/0123456789abcdef":           ResourceTypeTask,
+		"arn:aws:ecs:us-east-1:123456789012:task-definition/family:1":        ResourceTypeTaskDefinition,
+		"arn:aws:ecs:us-east-1:123456789012:task-definition:family/1":        ResourceTypeTaskDefinition,
+		"arn:aws:ecs:us-east-1:123456789012:task:family/1":                   ResourceTypeTask,
+		"arn:aws:ecs:us-east-1:123456789012:task-definition/family-task:123": ResourceTypeTaskDefinition,
+	} {
+		resourceType, err := DetectResourceType(id)
+		require.NoError(t, err, id)
+		assert.Equal(t, expected, resourceType, id)
+	}
+
+	for _, id := range []string{
+		"",
+		"not-an-arn",
+		"arn:aws:ecs:us-east-1:123456789012:cluster/cluster",
+		"arn:aws:secretsmanager:us-east-1:123456789012:secret:task/name",
+	} {
+		_, err := DetectResourceType(id)
+		assert.Error(t, err, id)
+	}
+}
+
+func TestTagResources(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const (
+		task0   = "arn:aws:ecs:us-east-1:123456789012:task/cluster/task0"
+		task1   = "arn:aws:ecs:us-east-1:123456789012:task/cluster/task1"
+		taskDef = "arn:aws:ecs:us-east-1:123456789012:task-definition/family:1"
+	)
+	newTags := func(key, value string) []types.Tag {
+		return []types.Tag{{Key: utility.ToStringPtr(key), Value: utility.ToStringPtr(value)}}
+	}
+	newClient := func() *tagTrackingClient {
+		return &tagTrackingClient{tagged: map[string]map[string]string{}}
+	}
+
+	t.Run("TagsTasksAndTaskDefinitions", func(t *testing.T) {
+		c := newClient()
+		res, err := tagResources(ctx, c, map[string][]types.Tag{
+			task0:   newTags("key", "value0"),
+			task1:   newTags("key", "value1"),
+			taskDef: newTags("key", "value2"),
+		}, 2)
+		require.NoError(t, err)
+		require.NotZero(t, res)
+		assert.Equal(t, []string{task0, task1}, res.Tagged[ResourceTypeTask])
+		assert.Equal(t, []string{taskDef}, res.Tagged[ResourceTypeTaskDefinition])
+		assert.Empty(t, res.Failed)
+		assert.Equal(t, "value0", c.tagged[task0]["key"])
+		assert.Equal(t, "value1", c.tagged[task1]["key"])
+		assert.Equal(t, "value2", c.tagged[taskDef]["key"])
+	})
+	t.Run("LimitsConcurrency", func(t *testing.T) {
+		c := newClient()
+		_, err := tagResources(ctx, c, map[string][]types.Tag{
+			task0:   newTags("key", "value"),
+			task1:   newTags("key", "value"),
+			taskDef: newTags("key", "value"),
+		}, 2)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, c.maxSeen, 2)
+	})
+	t.Run("SucceedsForAlreadyTaggedResources", func(t *testing.T) {
+		c := newClient()
+		tags := map[string][]types.Tag{task0: newTags("key", "value")}
+		_, err := tagResources(ctx, c, tags, 2)
+		require.NoError(t, err)
+		res, err := tagResources(ctx, c, tags, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []string{task0}, res.Tagged[ResourceTypeTask])
+		assert.Equal(t, map[string]string{"key": "value"}, c.tagged[task0])
+	})
+	t.Run("SkipsResourcesWithoutTags", func(t *testing.T) {
+		c := newClient()
+		res, err := tagResources(ctx, c, map[string][]types.Tag{task0: nil}, 2)
+		require.NoError(t, err)
+		assert.Empty(t, res.Tagged)
+		assert.Empty(t, c.tagged)
+	})
+	t.Run("ReportsPerResourceErrorsAndTagsRemainingResources", func(t *testing.T) {
+		c := newClient()
+		c.failARN = task1
+		res, err := tagResources(ctx, c, map[string][]types.Tag{
+			task0:        newTags("key", "value"),
+			task1:        newTags("key", "value"),
+			taskDef:      newTags("key", "value"),
+			"not-an-arn": newTags("key", "value"),
+		}, 2)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), task1)
+		assert.Contains(t, err.Error(), "not-an-arn")
+		require.NotZero(t, res)
+		assert.Equal(t, []string{task0}, res.Tagged[ResourceTypeTask])
+		assert.Equal(t, []string{taskDef}, res.Tagged[ResourceTypeTaskDefinition])
+		require.Len(t, res.Failed, 2)
+		assert.Error(t, res.Failed[task1])
+		assert.Error(t, res.Failed["not-an-arn"])
+	})
+	t.Run("FailsWithoutClient", func(t *testing.T) {
+		res, err := TagResources(ctx, nil, map[string][]types.Tag{task0: newTags("key", "value")})
+		assert.Error(t, err)
+		assert.Zero(t, res)
+	})
+}
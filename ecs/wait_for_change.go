@@ -0,0 +1,133 @@
+package ecs
+
+import (
+	"context"
+	"time"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/identity"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// PodStatusChange is a change in the status of a pod.
+type PodStatusChange struct {
+	// Pod is the pod whose status changed.
+	Pod cocoa.ECSPod
+	// Previous is the pod's status information before it changed, which is
+	// the pod's cached status information.
+	Previous cocoa.ECSPodStatusInfo
+	// Current is the pod's latest status information.
+	Current cocoa.ECSPodStatusInfo
+}
+
+// WaitForChange waits until the status of any of the pods is different from
+// its cached status and returns the changes for all the pods whose status
+// changed. This allows a single caller to watch many pods without polling
+// each pod separately. The pods are polled at the given interval by describing
+// all the pods in each cluster in as few requests as possible. A pod that ECS
+// no longer has information about is considered stopped.
+//
+// The pods' cached status information is not modified, so callers should
+// update their own state from the returned changes before waiting again. If
+// the context is done before any pod's status changes, the context's error is
+// returned.
+func WaitForChange(ctx context.Context, c cocoa.ECSClient, pods []cocoa.ECSPod, interval time.Duration) ([]PodStatusChange, error) {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(c == nil, "must specify a client")
+	catcher.NewWhen(len(pods) == 0, "must specify at least one pod")
+	catcher.NewWhen(interval <= 0, "must specify a positive polling interval")
+	for i, p := range pods {
+		if p == nil {
+			catcher.Errorf("pod at index %d cannot be nil", i)
+			continue
+		}
+		res := p.Resources()
+		catcher.ErrorfWhen(utility.FromStringPtr(res.Cluster) == "", "pod at index %d is missing a cluster", i)
+		catcher.ErrorfWhen(utility.FromStringPtr(res.TaskID) == "", "pod at index %d is missing a task ID", i)
+	}
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "waiting for pod status change")
+		case <-timer.C:
+		}
+
+		changes, err := getPodStatusChanges(ctx, c, pods)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) != 0 {
+			return changes, nil
+		}
+
+		timer.Reset(interval)
+	}
+}
+
+// getPodStatusChanges describes all the pods and returns the changes for the
+// pods whose latest status differs from their cached status.
+func getPodStatusChanges(ctx context.Context, c cocoa.ECSClient, pods []cocoa.ECSPod) ([]PodStatusChange, error) {
+	var clusters []string
+	taskIDsByCluster := map[string][]string{}
+	for _, p := range pods {
+		res := p.Resources()
+		cluster := utility.FromStringPtr(res.Cluster)
+		if _, ok := taskIDsByCluster[cluster]; !ok {
+			clusters = append(clusters, cluster)
+		}
+		taskIDsByCluster[cluster] = append(taskIDsByCluster[cluster], utility.FromStringPtr(res.TaskID))
+	}
+
+	latest := map[string]cocoa.ECSPodStatusInfo{}
+	for _, cluster := range clusters {
+		out, err := DescribeAllTasks(ctx, c, cluster, taskIDsByCluster[cluster])
+		if err != nil {
+			return nil, errors.Wrapf(err, "describing pods in cluster '%s'", cluster)
+		}
+
+		for _, task := range out.Tasks {
+			latest[podStatusKey(cluster, utility.FromStringPtr(task.TaskArn))] = translatePodStatusInfo(task)
+		}
+		for _, f := range out.Failures {
+			if !isTaskNotFoundFailure(f) {
+				return nil, errors.Wrapf(ConvertFailureToError(f), "describing pods in cluster '%s'", cluster)
+			}
+			latest[podStatusKey(cluster, utility.FromStringPtr(f.Arn))] = *cocoa.NewECSPodStatusInfo().SetStatus(cocoa.StatusStopped)
+		}
+	}
+
+	var changes []PodStatusChange
+	for _, p := range pods {
+		res := p.Resources()
+		current, ok := latest[podStatusKey(utility.FromStringPtr(res.Cluster), utility.FromStringPtr(res.TaskID))]
+		if !ok {
+			continue
+		}
+		previous := p.StatusInfo()
+		if current.Status == previous.Status {
+			continue
+		}
+		changes = append(changes, PodStatusChange{
+			Pod:      p,
+			Previous: previous,
+			Current:  current,
+		})
+	}
+
+	return changes, nil
+}
+
+// podStatusKey returns the key that identifies a task in a cluster regardless
+// of whether the task is identified by its ARN or its ID.
+func podStatusKey(cluster, taskID string) string {
+	return cluster + "/" + identity.TaskIDFromARN(taskID)
+}
@@ -0,0 +1,197 @@
+package ecs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusChangingClient is an ECS client whose tasks change to the next of
+// their statuses each time they are described.
+type statusChangingClient struct {
+	cocoa.ECSClient
+
+	mu            sync.Mutex
+	statuses      map[string][]string
+	failures      map[string]string
+	describeCalls int
+	describeErr   error
+}
+
+func (c *statusChangingClient) DescribeTasks(ctx context.Context, in *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.describeCalls++
+	if c.describeErr != nil {
+		return nil, c.describeErr
+	}
+
+	var out ecs.DescribeTasksOutput
+	for _, arn := range in.Tasks {
+		if reason, ok := c.failures[arn]; ok {
+			out.Failures = append(out.Failures, types.Failure{
+				Arn:    utility.ToStringPtr(arn),
+				Reason: utility.ToStringPtr(reason),
+			})
+			continue
+		}
+		statuses := c.statuses[arn]
+		status := statuses[0]
+		if len(statuses) > 1 {
+			c.statuses[arn] = statuses[1:]
+		}
+		out.Tasks = append(out.Tasks, types.Task{
+			TaskArn:    utility.ToStringPtr(arn),
+			LastStatus: utility.ToStringPtr(status),
+		})
+	}
+	return &out, nil
+}
+
+// statusPod is a pod with fixed resources and cached status.
+type statusPod struct {
+	cocoa.ECSPod
+
+	resources  cocoa.ECSPodResources
+	statusInfo cocoa.ECSPodStatusInfo
+}
+
+func newStatusPod(cluster, taskID string, status cocoa.ECSStatus) *statusPod {
+	return &statusPod{
+		resources:  *cocoa.NewECSPodResources().SetCluster(cluster).SetTaskID(taskID),
+		statusInfo: *cocoa.NewECSPodStatusInfo().SetStatus(status),
+	}
+}
+
+func (p *statusPod) Resources() cocoa.ECSPodResources {
+	return p.resources
+}
+
+func (p *statusPod) StatusInfo() cocoa.ECSPodStatusInfo {
+	return p.statusInfo
+}
+
+func TestWaitForChange(t *testing.T) {
+	const (
+		cluster  = "cluster"
+		interval = time.Millisecond
+	)
+
+	t.Run("ReturnsImmediatelyForAlreadyChangedPod", func(t *testing.T) {
+		c := &statusChangingClient{statuses: map[string][]string{
+			"task0": {string(TaskStatusRunning)},
+			"task1": {string(TaskStatusPending)},
+		}}
+		pods := []cocoa.ECSPod{
+			newStatusPod(cluster, "task0", cocoa.StatusStarting),
+			newStatusPod(cluster, "task1", cocoa.StatusStarting),
+		}
+
+		changes, err := WaitForChange(context.Background(), c, pods, time.Hour)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, pods[0], changes[0].Pod)
+		assert.Equal(t, cocoa.StatusStarting, changes[0].Previous.Status)
+		assert.Equal(t, cocoa.StatusRunning, changes[0].Current.Status)
+		assert.Equal(t, 1, c.describeCalls)
+	})
+	t.Run("PollsUntilAnyPodChanges", func(t *testing.T) {
+		c := &statusChangingClient{statuses: map[string][]string{
+			"task0": {string(TaskStatusPending), string(TaskStatusPending), string(TaskStatusStopped)},
+			"task1": {string(TaskStatusRunning)},
+		}}
+		pods := []cocoa.ECSPod{
+			newStatusPod(cluster, "task0", cocoa.StatusStarting),
+			newStatusPod(cluster, "task1", cocoa.StatusRunning),
+		}
+
+		changes, err := WaitForChange(context.Background(), c, pods, interval)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, pods[0], changes[0].Pod)
+		assert.Equal(t, cocoa.StatusStopped, changes[0].Current.Status)
+		assert.Equal(t, 3, c.describeCalls)
+	})
+	t.Run("ReturnsAllChangedPods", func(t *testing.T) {
+		c := &statusChangingClient{statuses: map[string][]string{
+			"task0": {string(TaskStatusRunning)},
+			"task1": {string(TaskStatusStopping)},
+			"task2": {string(TaskStatusRunning)},
+		}}
+		pods := []cocoa.ECSPod{
+			newStatusPod(cluster, "task0", cocoa.StatusStarting),
+			newStatusPod("other_cluster", "task1", cocoa.StatusRunning),
+			newStatusPod(cluster, "task2", cocoa.StatusRunning),
+		}
+
+		changes, err := WaitForChange(context.Background(), c, pods, interval)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		assert.Equal(t, pods[0], changes[0].Pod)
+		assert.Equal(t, cocoa.StatusRunning, changes[0].Current.Status)
+		assert.Equal(t, pods[1], changes[1].Pod)
+		assert.Equal(t, cocoa.StatusStopping, changes[1].Current.Status)
+		assert.Equal(t, 2, c.describeCalls, "should describe once per cluster")
+	})
+	t.Run("TreatsMissingPodAsStopped", func(t *testing.T) {
+		c := &statusChangingClient{failures: map[string]string{"task0": ReasonTaskMissing}}
+		pods := []cocoa.ECSPod{newStatusPod(cluster, "task0", cocoa.StatusRunning)}
+
+		changes, err := WaitForChange(context.Background(), c, pods, interval)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, cocoa.StatusStopped, changes[0].Current.Status)
+	})
+	t.Run("FailsWithOtherDescribeFailure", func(t *testing.T) {
+		c := &statusChangingClient{failures: map[string]string{"task0": "some other reason"}}
+		pods := []cocoa.ECSPod{newStatusPod(cluster, "task0", cocoa.StatusRunning)}
+
+		changes, err := WaitForChange(context.Background(), c, pods, interval)
+		assert.Error(t, err)
+		assert.Zero(t, changes)
+	})
+	t.Run("FailsWithDescribeError", func(t *testing.T) {
+		c := &statusChangingClient{describeErr: errors.New("fake error")}
+		pods := []cocoa.ECSPod{newStatusPod(cluster, "task0", cocoa.StatusRunning)}
+
+		changes, err := WaitForChange(context.Background(), c, pods, interval)
+		assert.Error(t, err)
+		assert.Zero(t, changes)
+	})
+	t.Run("FailsWhenContextIsDoneBeforeAnyChange", func(t *testing.T) {
+		c := &statusChangingClient{statuses: map[string][]string{"task0": {string(TaskStatusRunning)}}}
+		pods := []cocoa.ECSPod{newStatusPod(cluster, "task0", cocoa.StatusRunning)}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		changes, err := WaitForChange(ctx, c, pods, interval)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Zero(t, changes)
+		assert.NotZero(t, c.describeCalls)
+	})
+	t.Run("FailsWithInvalidInput", func(t *testing.T) {
+		c := &statusChangingClient{}
+		pod := newStatusPod(cluster, "task0", cocoa.StatusRunning)
+
+		_, err := WaitForChange(context.Background(), nil, []cocoa.ECSPod{pod}, interval)
+		assert.Error(t, err)
+		_, err = WaitForChange(context.Background(), c, nil, interval)
+		assert.Error(t, err)
+		_, err = WaitForChange(context.Background(), c, []cocoa.ECSPod{pod}, 0)
+		assert.Error(t, err)
+		_, err = WaitForChange(context.Background(), c, []cocoa.ECSPod{newStatusPod("", "task0", cocoa.StatusRunning)}, interval)
+		assert.Error(t, err)
+		assert.Zero(t, c.describeCalls)
+	})
+}
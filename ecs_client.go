@@ -20,13 +20,29 @@ type ECSClient interface {
 	DeregisterTaskDefinition(ctx context.Context, in *ecs.DeregisterTaskDefinitionInput) (*ecs.DeregisterTaskDefinitionOutput, error)
 	// RunTask runs a registered task.
 	RunTask(ctx context.Context, in *ecs.RunTaskInput) (*ecs.RunTaskOutput, error)
+	// StartTask runs a registered task on specific container instances.
+	StartTask(ctx context.Context, in *ecs.StartTaskInput) (*ecs.StartTaskOutput, error)
 	// DescribeTasks gets information about the configuration and status of
 	// tasks.
 	DescribeTasks(ctx context.Context, in *ecs.DescribeTasksInput) (*ecs.DescribeTasksOutput, error)
 	// ListTasks lists all ECS tasks matching the input.
 	ListTasks(ctx context.Context, in *ecs.ListTasksInput) (*ecs.ListTasksOutput, error)
+	// ListContainerInstances lists all ECS container instances matching the
+	// input.
+	ListContainerInstances(ctx context.Context, in *ecs.ListContainerInstancesInput) (*ecs.ListContainerInstancesOutput, error)
+	// DescribeContainerInstances gets information about the configuration,
+	// status, and resources of container instances.
+	DescribeContainerInstances(ctx context.Context, in *ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error)
 	// StopTask stops a running task.
 	StopTask(ctx context.Context, in *ecs.StopTaskInput) (*ecs.StopTaskOutput, error)
 	// TagResource adds tags to an ECS resource.
 	TagResource(ctx context.Context, in *ecs.TagResourceInput) (*ecs.TagResourceOutput, error)
+	// DescribeClusters gets information about the configuration and status of
+	// clusters.
+	DescribeClusters(ctx context.Context, in *ecs.DescribeClustersInput) (*ecs.DescribeClustersOutput, error)
+	// ListServices lists all ECS services matching the input.
+	ListServices(ctx context.Context, in *ecs.ListServicesInput) (*ecs.ListServicesOutput, error)
+	// DescribeServices gets information about the configuration and status of
+	// services.
+	DescribeServices(ctx context.Context, in *ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error)
 }
@@ -2,6 +2,8 @@ package cocoa
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/grip"
@@ -26,6 +28,35 @@ type ECSPod interface {
 	Delete(ctx context.Context) error
 }
 
+// ECSPodPendingDiagnoser is an ECSPod that can also diagnose why it is stuck
+// before it starts running.
+type ECSPodPendingDiagnoser interface {
+	ECSPod
+	// PendingDiagnosis returns the most likely reason that the pod is stuck
+	// before it starts running. Implementations should query ECS directly for
+	// the pod's most up-to-date state.
+	PendingDiagnosis(ctx context.Context) (*ECSPodPendingDiagnosis, error)
+}
+
+// ECSPodExporter is an ECSPod that can also export its state.
+type ECSPodExporter interface {
+	ECSPod
+	// Export returns a serializable snapshot of the pod's resources, cached
+	// status, and protection policy, which can be persisted and used to
+	// reconstruct the pod later.
+	Export() ECSPodState
+}
+
+// ECSPodProtectedDeleter is an ECSPod that can also be deleted with options
+// that control how its protected resources are handled.
+type ECSPodProtectedDeleter interface {
+	ECSPod
+	// DeleteWithOptions deletes the pod and its owned resources. Owned
+	// resources that are protected by the pod's protection policy are not
+	// deleted unless the deletion options override the protection.
+	DeleteWithOptions(ctx context.Context, opts ...ECSPodDeletionOptions) error
+}
+
 // ECSPodStatusInfo represents the current status of a pod and its containers in
 // ECS.
 type ECSPodStatusInfo struct {
@@ -34,6 +65,23 @@ type ECSPodStatusInfo struct {
 	// Containers represent the status information of the individual containers
 	// within the pod.
 	Containers []ECSContainerStatusInfo `bson:"-" json:"-" yaml:"-"`
+	// PullStartedAt is when the pod started pulling its container images, if
+	// it has started.
+	PullStartedAt *time.Time `bson:"-" json:"-" yaml:"-"`
+	// PullStoppedAt is when the pod finished pulling its container images, if
+	// it has finished.
+	PullStoppedAt *time.Time `bson:"-" json:"-" yaml:"-"`
+	// StartedAt is when the pod transitioned to running, if it has started.
+	StartedAt *time.Time `bson:"-" json:"-" yaml:"-"`
+	// StoppingAt is when the pod began stopping, if it is stopping.
+	StoppingAt *time.Time `bson:"-" json:"-" yaml:"-"`
+	// ExecutionStoppedAt is when the pod's containers stopped running, if they
+	// have stopped.
+	ExecutionStoppedAt *time.Time `bson:"-" json:"-" yaml:"-"`
+	// RetirementNotice, if set, indicates that AWS has flagged the pod to be
+	// retired (e.g. for a Spot interruption or infrastructure maintenance), so
+	// it should be replaced before AWS stops it.
+	RetirementNotice *PodRetirementNotice `bson:"-" json:"-" yaml:"-"`
 }
 
 // NewECSPodStatusInfo returns a new uninitialized set of status information for
@@ -63,6 +111,36 @@ func (i *ECSPodStatusInfo) AddContainers(containers ...ECSContainerStatusInfo) *
 	return i
 }
 
+// SetPullStartedAt sets when the pod started pulling its container images.
+func (i *ECSPodStatusInfo) SetPullStartedAt(t time.Time) *ECSPodStatusInfo {
+	i.PullStartedAt = &t
+	return i
+}
+
+// SetPullStoppedAt sets when the pod finished pulling its container images.
+func (i *ECSPodStatusInfo) SetPullStoppedAt(t time.Time) *ECSPodStatusInfo {
+	i.PullStoppedAt = &t
+	return i
+}
+
+// SetStartedAt sets when the pod transitioned to running.
+func (i *ECSPodStatusInfo) SetStartedAt(t time.Time) *ECSPodStatusInfo {
+	i.StartedAt = &t
+	return i
+}
+
+// SetStoppingAt sets when the pod began stopping.
+func (i *ECSPodStatusInfo) SetStoppingAt(t time.Time) *ECSPodStatusInfo {
+	i.StoppingAt = &t
+	return i
+}
+
+// SetExecutionStoppedAt sets when the pod's containers stopped running.
+func (i *ECSPodStatusInfo) SetExecutionStoppedAt(t time.Time) *ECSPodStatusInfo {
+	i.ExecutionStoppedAt = &t
+	return i
+}
+
 // Validate checks that the required pod status information is populated and the
 // pod status is valid.
 func (i *ECSPodStatusInfo) Validate() error {
@@ -82,6 +160,8 @@ type ECSContainerStatusInfo struct {
 	Name *string
 	// Status is the current status of the container.
 	Status ECSStatus
+	// ExitCode is the exit code returned by the container once it has stopped.
+	ExitCode *int
 }
 
 // NewECSContainerStatusInfo returns a new uninitialized set of status
@@ -108,6 +188,13 @@ func (i *ECSContainerStatusInfo) SetStatus(status ECSStatus) *ECSContainerStatus
 	return i
 }
 
+// SetExitCode sets the exit code returned by the container once it has
+// stopped.
+func (i *ECSContainerStatusInfo) SetExitCode(code int) *ECSContainerStatusInfo {
+	i.ExitCode = &code
+	return i
+}
+
 // Validate checks that the required container status information is populated
 // and the container status is valid.
 func (i *ECSContainerStatusInfo) Validate() error {
@@ -127,6 +214,8 @@ type ECSPodResources struct {
 	TaskDefinition *ECSTaskDefinition `bson:"-" json:"-" yaml:"-"`
 	// Cluster is the name of the cluster namespace in which the pod is running.
 	Cluster *string `bson:"-" json:"-" yaml:"-"`
+	// Group is the name of the task group that the pod belongs to.
+	Group *string `bson:"-" json:"-" yaml:"-"`
 	// Containers represent the resources associated with each individual
 	// container in the pod.
 	Containers []ECSContainerResources `bson:"-" json:"-" yaml:"-"`
@@ -156,6 +245,12 @@ func (r *ECSPodResources) SetCluster(cluster string) *ECSPodResources {
 	return r
 }
 
+// SetGroup sets the name of the task group that the pod belongs to.
+func (r *ECSPodResources) SetGroup(group string) *ECSPodResources {
+	r.Group = &group
+	return r
+}
+
 // SetContainers sets the containers associated with the pod. This overwrites
 // any existing containers.
 func (r *ECSPodResources) SetContainers(containers []ECSContainerResources) *ECSPodResources {
@@ -170,6 +265,24 @@ func (r *ECSPodResources) AddContainers(containers ...ECSContainerResources) *EC
 	return r
 }
 
+// SecretIDs returns the unique IDs of all the secrets referenced by the pod's
+// containers.
+func (r *ECSPodResources) SecretIDs() []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, c := range r.Containers {
+		for _, s := range c.Secrets {
+			id := utility.FromStringPtr(s.ID)
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
 // Validate checks that the task ID is set, the task definition is valid, and
 // all container resources are valid.
 func (r *ECSPodResources) Validate() error {
@@ -315,3 +428,118 @@ func (s ECSStatus) Validate() error {
 		return errors.Errorf("unrecognized status '%s'", s)
 	}
 }
+
+// ECSPodProtectionPolicy specifies owned resources that must not be deleted when
+// the pod is deleted, such as secrets that are shared with other pods.
+type ECSPodProtectionPolicy struct {
+	// TaskDefinition indicates whether or not the pod's owned task definition
+	// is protected. By default, it is not protected.
+	TaskDefinition *bool
+	// Secrets are the IDs or names of the pod's owned secrets (including
+	// repository credentials) that are protected.
+	Secrets []string
+}
+
+// NewECSPodProtectionPolicy returns a new uninitialized protection policy for
+// a pod's owned resources.
+func NewECSPodProtectionPolicy() *ECSPodProtectionPolicy {
+	return &ECSPodProtectionPolicy{}
+}
+
+// SetTaskDefinition sets whether or not the pod's owned task definition is
+// protected.
+func (p *ECSPodProtectionPolicy) SetTaskDefinition(protected bool) *ECSPodProtectionPolicy {
+	p.TaskDefinition = &protected
+	return p
+}
+
+// SetSecrets sets the IDs or names of the protected secrets. This overwrites
+// any existing protected secrets.
+func (p *ECSPodProtectionPolicy) SetSecrets(secrets []string) *ECSPodProtectionPolicy {
+	p.Secrets = secrets
+	return p
+}
+
+// AddSecrets adds new IDs or names of protected secrets to the existing ones.
+func (p *ECSPodProtectionPolicy) AddSecrets(secrets ...string) *ECSPodProtectionPolicy {
+	p.Secrets = append(p.Secrets, secrets...)
+	return p
+}
+
+// ProtectsTaskDefinition returns whether or not the policy protects the pod's
+// task definition.
+func (p *ECSPodProtectionPolicy) ProtectsTaskDefinition() bool {
+	return utility.FromBoolPtr(p.TaskDefinition)
+}
+
+// ProtectsSecret returns whether or not the policy protects the secret, which
+// is matched by either its ID or its name.
+func (p *ECSPodProtectionPolicy) ProtectsSecret(s ContainerSecret) bool {
+	for _, protected := range p.Secrets {
+		if (s.ID != nil && *s.ID == protected) || (s.Name != nil && *s.Name == protected) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the protected secrets are non-empty.
+func (p *ECSPodProtectionPolicy) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	for _, s := range p.Secrets {
+		catcher.NewWhen(s == "", "cannot specify an empty protected secret")
+	}
+	return catcher.Resolve()
+}
+
+// hash returns the hash digest of the protection policy.
+func (p *ECSPodProtectionPolicy) hash() string {
+	h := utility.NewSHA1Hash()
+
+	if p.TaskDefinition != nil {
+		h.Add("task_definition")
+		h.Add(strconv.FormatBool(*p.TaskDefinition))
+	}
+
+	if len(p.Secrets) != 0 {
+		h.Add("secrets")
+		h.Add(hashUnorderedStrings(p.Secrets))
+	}
+
+	return h.Sum()
+}
+
+// ECSPodDeletionOptions are options to control how a pod is deleted.
+type ECSPodDeletionOptions struct {
+	// OverrideProtection indicates that the pod's owned resources should be
+	// deleted even if they are protected by the pod's protection policy. By
+	// default, protected resources are not deleted.
+	OverrideProtection *bool
+}
+
+// NewECSPodDeletionOptions returns new uninitialized options to delete a pod.
+func NewECSPodDeletionOptions() *ECSPodDeletionOptions {
+	return &ECSPodDeletionOptions{}
+}
+
+// SetOverrideProtection sets whether or not the pod's protected resources
+// should be deleted anyways.
+func (o *ECSPodDeletionOptions) SetOverrideProtection(override bool) *ECSPodDeletionOptions {
+	o.OverrideProtection = &override
+	return o
+}
+
+// MergeECSPodDeletionOptions merges all the given options to delete a pod.
+// Options are applied in the order that they're specified and conflicting
+// options are overwritten.
+func MergeECSPodDeletionOptions(opts ...ECSPodDeletionOptions) ECSPodDeletionOptions {
+	merged := ECSPodDeletionOptions{}
+
+	for _, opt := range opts {
+		if opt.OverrideProtection != nil {
+			merged.OverrideProtection = opt.OverrideProtection
+		}
+	}
+
+	return merged
+}
@@ -2,14 +2,20 @@ package cocoa
 
 import (
 	"context"
+	"net"
+	"regexp"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa/identity"
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
 )
 
 // ECSPodCreator provides a means to create a new pod backed by AWS ECS.
@@ -21,6 +27,15 @@ type ECSPodCreator interface {
 	// CreatePodFromExistingDefinition creates a new pod backed by ECS from an
 	// existing task definition.
 	CreatePodFromExistingDefinition(ctx context.Context, def ECSTaskDefinition, opts ...ECSPodExecutionOptions) (ECSPod, error)
+	// CreatePodIdempotent creates a new pod backed by ECS for the given
+	// external key unless a pod that was previously created for the same key
+	// is still running, in which case it returns the existing pod instead.
+	CreatePodIdempotent(ctx context.Context, key string, opts ...ECSPodCreationOptions) (ECSPod, error)
+	// PrewarmDefinitions registers the given pod definitions ahead of time so
+	// that pods created later with the same pod definition options do not have
+	// to wait to register a pod definition. It returns the pod definition
+	// items in the same order as the given options.
+	PrewarmDefinitions(ctx context.Context, defs []ECSPodDefinitionOptions) ([]ECSPodDefinitionItem, error)
 }
 
 // ECSPodCreationOptions provide options to create a pod backed by ECS.
@@ -29,6 +44,14 @@ type ECSPodCreationOptions struct {
 	DefinitionOpts ECSPodDefinitionOptions
 	// ExecutionOpts specify options to configure how the pod executes.
 	ExecutionOpts *ECSPodExecutionOptions
+	// Version is the version of the serialized format of the options. It is
+	// set to OptionsVersion when the options are serialized if it is not
+	// already set.
+	Version int
+	// Extensions are the serialized fields that were not recognized when the
+	// options were deserialized. They are preserved when the options are
+	// serialized again.
+	Extensions OptionsExtensions `json:"-"`
 }
 
 // NewECSPodCreationOptions returns new uninitialized options to create a pod.
@@ -59,6 +82,18 @@ func (o *ECSPodCreationOptions) Validate() error {
 
 	if o.ExecutionOpts != nil {
 		catcher.Wrap(o.ExecutionOpts.Validate(), "invalid execution options")
+		if isFargateCapacityProvider(utility.FromStringPtr(o.ExecutionOpts.CapacityProvider)) {
+			for _, def := range o.DefinitionOpts.ContainerDefinitions {
+				catcher.ErrorfWhen(def.usesLinuxParameters(), "container definition '%s' cannot use tmpfs mounts or a shared memory size because they are not supported by Fargate", utility.FromStringPtr(def.Name))
+			}
+		}
+		if o.ExecutionOpts.OverrideOpts != nil {
+			for _, def := range o.ExecutionOpts.OverrideOpts.ContainerDefinitions {
+				name := utility.FromStringPtr(def.Name)
+				catcher.ErrorfWhen(len(def.BindMounts) != 0 && !o.DefinitionOpts.hasContainer(name), "cannot override bind mounts for container '%s' because it is not in the pod definition", name)
+				catcher.ErrorfWhen(len(def.SecretEnvVars) != 0 && !o.DefinitionOpts.hasContainer(name), "cannot override secret environment variables for container '%s' because it is not in the pod definition", name)
+			}
+		}
 	}
 
 	if catcher.HasErrors() {
@@ -73,6 +108,12 @@ func (o *ECSPodCreationOptions) Validate() error {
 	return nil
 }
 
+// isFargateCapacityProvider returns whether or not the capacity provider is one
+// of the capacity providers that runs pods on Fargate.
+func isFargateCapacityProvider(provider string) bool {
+	return provider == "FARGATE" || provider == "FARGATE_SPOT"
+}
+
 // MergeECSPodCreationOptions merges all the given options to create an ECS pod.
 // Options are applied in the order that they're specified and conflicting
 // options are overwritten.
@@ -91,11 +132,44 @@ func MergeECSPodCreationOptions(opts ...ECSPodCreationOptions) ECSPodCreationOpt
 			}
 			merged.ExecutionOpts = &execOpts
 		}
+
+		if opt.Version > merged.Version {
+			merged.Version = opt.Version
+		}
+
+		merged.Extensions = mergeOptionsExtensions(merged.Extensions, opt.Extensions)
 	}
 
 	return merged
 }
 
+// MarshalJSON marshals the pod creation options to JSON, including any
+// extensions that were preserved from deserialization.
+func (o ECSPodCreationOptions) MarshalJSON() ([]byte, error) {
+	type options ECSPodCreationOptions
+	known := options(o)
+	if known.Version == 0 {
+		known.Version = OptionsVersion
+	}
+	return marshalOptionsJSON(known, o.Extensions)
+}
+
+// UnmarshalJSON unmarshals the pod creation options from JSON. Any fields
+// that are not recognized are preserved as extensions. If the options were
+// serialized by a newer version of cocoa, it logs a warning.
+func (o *ECSPodCreationOptions) UnmarshalJSON(b []byte) error {
+	type options ECSPodCreationOptions
+	var known options
+	ext, err := unmarshalOptionsJSON(b, &known)
+	if err != nil {
+		return err
+	}
+	*o = ECSPodCreationOptions(known)
+	o.Extensions = ext
+	warnOnNewerOptionsVersion("pod creation", o.Version, ext)
+	return nil
+}
+
 // ECSPodDefinitionOptions represent options to configure a template for running
 // a pod.
 type ECSPodDefinitionOptions struct {
@@ -114,6 +188,10 @@ type ECSPodDefinitionOptions struct {
 	// specified, then each container is required to specify its own CPU.
 	// This is ignored for pods running Windows containers.
 	CPU *int
+	// ResourceProfile, if specified, is a named preset of the pod-level CPU
+	// and memory. Explicitly setting the pod-level CPU or memory overrides the
+	// profile's value.
+	ResourceProfile *ResourceProfile
 	// NetworkMode describes the networking capabilities of the pod's
 	// containers. If the NetworkMode is unspecified for a pod running Linux
 	// containers, the default value is NetworkModeBridge. If the NetworkMode is
@@ -128,7 +206,19 @@ type ECSPodDefinitionOptions struct {
 	// the configuration, this may be required if the container uses secrets.
 	ExecutionRole *string
 	// Tags are resource tags to apply to the pod definition.
-	Tags map[string]string
+	Tags Tags
+	// NameGenerator, if specified, generates the names of the pod definition
+	// and its containers if they are not explicitly named. It is not
+	// serialized. By default, they are given random names.
+	NameGenerator NameGenerator `json:"-"`
+	// Version is the version of the serialized format of the options. It is
+	// set to OptionsVersion when the options are serialized if it is not
+	// already set.
+	Version int
+	// Extensions are the serialized fields that were not recognized when the
+	// options were deserialized. They are preserved when the options are
+	// serialized again.
+	Extensions OptionsExtensions `json:"-"`
 }
 
 // NewECSPodDefinitionOptions returns new uninitialized options to create a pod
@@ -171,6 +261,18 @@ func (o *ECSPodDefinitionOptions) SetCPU(cpu int) *ECSPodDefinitionOptions {
 	return o
 }
 
+// SetMemory is the same as SetMemoryMB, but takes a typed amount of memory
+// (e.g. GiB(2)).
+func (o *ECSPodDefinitionOptions) SetMemory(mem MemoryMB) *ECSPodDefinitionOptions {
+	return o.SetMemoryMB(int(mem))
+}
+
+// SetCPUUnits is the same as SetCPU, but takes a typed amount of CPU (e.g.
+// VCPUs(0.5)).
+func (o *ECSPodDefinitionOptions) SetCPUUnits(cpu CPUUnits) *ECSPodDefinitionOptions {
+	return o.SetCPU(int(cpu))
+}
+
 // SetTaskRole sets the task role that the pod can use.
 func (o *ECSPodDefinitionOptions) SetTaskRole(role string) *ECSPodDefinitionOptions {
 	o.TaskRole = &role
@@ -199,15 +301,33 @@ func (o *ECSPodDefinitionOptions) SetTags(tags map[string]string) *ECSPodDefinit
 
 // AddTags adds new tags to the existing ones for the pod definition.
 func (o *ECSPodDefinitionOptions) AddTags(tags map[string]string) *ECSPodDefinitionOptions {
-	if o.Tags == nil {
-		o.Tags = map[string]string{}
-	}
-	for k, v := range tags {
-		o.Tags[k] = v
-	}
+	o.Tags = o.Tags.Add(tags)
+	return o
+}
+
+// GetTags returns the tags for the pod definition.
+func (o *ECSPodDefinitionOptions) GetTags() Tags {
+	return o.Tags
+}
+
+// SetNameGenerator sets the generator for the names of the pod definition and
+// its containers if they are not explicitly named.
+func (o *ECSPodDefinitionOptions) SetNameGenerator(g NameGenerator) *ECSPodDefinitionOptions {
+	o.NameGenerator = g
 	return o
 }
 
+// hasContainer returns whether or not the pod definition has a container
+// definition with the given name.
+func (o *ECSPodDefinitionOptions) hasContainer(name string) bool {
+	for _, def := range o.ContainerDefinitions {
+		if utility.FromStringPtr(def.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
 // getNetworkMode returns the network mode. If no network mode is explicitly
 // set, this returns the default network mode.
 func (o *ECSPodDefinitionOptions) getNetworkMode() ECSNetworkMode {
@@ -223,16 +343,28 @@ func (o *ECSPodDefinitionOptions) Validate() error {
 	catcher := grip.NewBasicCatcher()
 
 	catcher.NewWhen(o.Name != nil && *o.Name == "", "cannot specify an empty name")
+	if o.ResourceProfile != nil {
+		catcher.Wrap(o.ResourceProfile.Validate(), "invalid resource profile")
+		o.applyResourceProfile()
+	}
 	catcher.NewWhen(o.MemoryMB != nil && *o.MemoryMB <= 0, "must have positive memory value if non-default")
 	catcher.NewWhen(o.CPU != nil && *o.CPU <= 0, "must have positive CPU value if non-default")
+	if o.MemoryMB != nil && *o.MemoryMB > 0 {
+		catcher.Add(MemoryMB(*o.MemoryMB).Validate())
+	}
+	if o.CPU != nil && *o.CPU > 0 {
+		catcher.Add(CPUUnits(*o.CPU).ValidatePod())
+	}
 
 	catcher.Wrap(o.validateContainerDefinitions(), "invalid container definitions")
+	catcher.Wrap(ValidateECSTags(o.Tags), "invalid tags")
+	catcher.Wrap(o.Tags.validatePurpose(), "invalid purpose")
 
 	networkMode := o.getNetworkMode()
 	catcher.Wrap(networkMode.Validate(), "invalid network mode")
 
 	if o.Name == nil {
-		o.Name = utility.ToStringPtr(utility.RandomString())
+		o.Name = utility.ToStringPtr(o.NameGenerator.generate())
 	}
 
 	return catcher.Resolve()
@@ -245,23 +377,44 @@ func (o *ECSPodDefinitionOptions) validateContainerDefinitions() error {
 
 	catcher.NewWhen(len(o.ContainerDefinitions) == 0, "must specify at least one container definition")
 
+	containerNames := map[string]bool{}
+	for _, def := range o.ContainerDefinitions {
+		containerNames[utility.FromStringPtr(def.Name)] = true
+	}
+
 	networkMode := o.getNetworkMode()
+	portMappingNames := map[string]bool{}
+	hostPortOwners := map[string]string{}
 	var totalContainerMemMB, totalContainerCPU int
 	for i, def := range o.ContainerDefinitions {
-		catcher.Wrapf(o.ContainerDefinitions[i].Validate(), "container definition '%s'", utility.FromStringPtr(def.Name))
+		catcher.Wrapf(o.ContainerDefinitions[i].validate(o.NameGenerator), "container definition '%s'", utility.FromStringPtr(def.Name))
+
+		for _, vf := range def.VolumesFrom {
+			source := utility.FromStringPtr(vf.SourceContainer)
+			catcher.ErrorfWhen(source != "" && !containerNames[source], "container definition '%s' mounts volumes from container '%s', which does not exist in the pod", utility.FromStringPtr(def.Name), source)
+		}
 
-		switch networkMode {
-		case NetworkModeNone:
-			catcher.NewWhen(len(def.PortMappings) != 0, "cannot specify port mappings because networking is disabled")
-		case NetworkModeHost, NetworkModeAWSVPC:
-			for _, pm := range def.PortMappings {
-				containerPort := utility.FromIntPtr(pm.ContainerPort)
-				if pm.HostPort != nil {
-					hostPort := utility.FromIntPtr(pm.HostPort)
-					catcher.ErrorfWhen(hostPort != containerPort,
-						"host port '%d' must be omitted or identical to the container port '%d' when network mode is '%s'", hostPort, containerPort, networkMode)
-				}
+		catcher.Wrapf(def.validateNetworkMode(networkMode), "container definition '%s'", utility.FromStringPtr(def.Name))
+		for _, pm := range def.PortMappings {
+			hostPort, ok := pm.effectiveHostPort(networkMode)
+			if !ok {
+				continue
 			}
+			key := strconv.Itoa(hostPort) + "/" + string(pm.protocol())
+			if owner, ok := hostPortOwners[key]; ok {
+				catcher.Errorf("container definition '%s' uses host port %s, which is already used by container definition '%s'", utility.FromStringPtr(def.Name), key, owner)
+				continue
+			}
+			hostPortOwners[key] = utility.FromStringPtr(def.Name)
+		}
+
+		for _, pm := range def.PortMappings {
+			name := utility.FromStringPtr(pm.Name)
+			if name == "" {
+				continue
+			}
+			catcher.ErrorfWhen(portMappingNames[name], "port mapping name '%s' is used more than once in the pod", name)
+			portMappingNames[name] = true
 		}
 
 		if def.MemoryMB != nil {
@@ -287,6 +440,44 @@ func (o *ECSPodDefinitionOptions) validateContainerDefinitions() error {
 	return catcher.Resolve()
 }
 
+// validateNetworkMode checks that the container definition's network settings
+// are compatible with the pod's network mode, so that incompatible settings
+// are reported for the specific container rather than as a generic failure
+// from ECS.
+func (d *ECSContainerDefinition) validateNetworkMode(mode ECSNetworkMode) error {
+	catcher := grip.NewBasicCatcher()
+	switch mode {
+	case NetworkModeNone:
+		catcher.NewWhen(len(d.PortMappings) != 0, "cannot specify port mappings because networking is disabled")
+		catcher.NewWhen(len(d.ExtraHosts) != 0, "cannot specify extra hosts because networking is disabled")
+	case NetworkModeAWSVPC:
+		catcher.NewWhen(d.Hostname != nil, "cannot specify a container hostname when network mode is 'awsvpc'")
+		catcher.NewWhen(len(d.ExtraHosts) != 0, "cannot specify extra hosts when network mode is 'awsvpc'")
+		catcher.Add(d.validateHostPortsMatchContainerPorts(mode))
+	case NetworkModeHost:
+		catcher.Add(d.validateHostPortsMatchContainerPorts(mode))
+	}
+	return catcher.Resolve()
+}
+
+// validateHostPortsMatchContainerPorts checks that every explicit host port is
+// identical to its container port. This is required for network modes in which
+// the container shares a network interface rather than having its ports
+// dynamically mapped.
+func (d *ECSContainerDefinition) validateHostPortsMatchContainerPorts(mode ECSNetworkMode) error {
+	catcher := grip.NewBasicCatcher()
+	for _, pm := range d.PortMappings {
+		if pm.HostPort == nil {
+			continue
+		}
+		containerPort := utility.FromIntPtr(pm.ContainerPort)
+		hostPort := utility.FromIntPtr(pm.HostPort)
+		catcher.ErrorfWhen(hostPort != containerPort,
+			"host port '%d' must be omitted or identical to the container port '%d' when network mode is '%s' because host ports cannot be assigned separately from container ports", hostPort, containerPort, mode)
+	}
+	return catcher.Resolve()
+}
+
 // pair represents a key and value pair.
 type pair struct {
 	key   string
@@ -345,11 +536,29 @@ func (htp hashablePairs) hash() string {
 	return h.Sum()
 }
 
-// Hash returns the hash digest of the pod definition.
-func (o *ECSPodDefinitionOptions) Hash() string {
+// hashUnorderedStrings returns the hash digest of the strings regardless of
+// their order. It does not modify the given strings.
+func hashUnorderedStrings(ss []string) string {
+	sorted := make([]string, len(ss))
+	copy(sorted, ss)
+	sort.Strings(sorted)
+
+	h := utility.NewSHA1Hash()
+	for _, str := range sorted {
+		h.Add(str)
+	}
+	return h.Sum()
+}
+
+// Hash returns the hash digest of the pod definition. By default, every
+// field is included in the hash. If hash options are given, the fields that
+// they exclude do not affect the hash (see MergeHashOptions for how multiple
+// hash options are combined).
+func (o *ECSPodDefinitionOptions) Hash(opts ...HashOptions) string {
+	hashOpts := MergeHashOptions(opts...)
 	h := utility.NewSHA1Hash()
 
-	if o.Name != nil {
+	if o.Name != nil && !utility.FromBoolPtr(hashOpts.ExcludeName) {
 		h.Add(utility.FromStringPtr(o.Name))
 	}
 
@@ -357,12 +566,12 @@ func (o *ECSPodDefinitionOptions) Hash() string {
 		h.Add(newHashableContainerDefinitions(o.ContainerDefinitions).hash())
 	}
 
-	if o.MemoryMB != nil {
-		h.Add(strconv.Itoa(utility.FromIntPtr(o.MemoryMB)))
+	if memMB := o.effectiveMemoryMB(); memMB != nil {
+		h.Add(strconv.Itoa(utility.FromIntPtr(memMB)))
 	}
 
-	if o.CPU != nil {
-		h.Add(strconv.Itoa(utility.FromIntPtr(o.CPU)))
+	if cpu := o.effectiveCPU(); cpu != nil {
+		h.Add(strconv.Itoa(utility.FromIntPtr(cpu)))
 	}
 
 	if o.NetworkMode != nil {
@@ -377,8 +586,8 @@ func (o *ECSPodDefinitionOptions) Hash() string {
 		h.Add(utility.FromStringPtr(o.ExecutionRole))
 	}
 
-	if len(o.Tags) != 0 {
-		h.Add(newHashablePairs(o.Tags).hash())
+	if tags := hashOpts.hashedTags(o.Tags); len(tags) != 0 {
+		h.Add(newHashablePairs(tags).hash())
 	}
 
 	return h.Sum()
@@ -407,6 +616,10 @@ func MergeECSPodDefinitionOptions(opts ...ECSPodDefinitionOptions) ECSPodDefinit
 			merged.CPU = opt.CPU
 		}
 
+		if opt.ResourceProfile != nil {
+			merged.ResourceProfile = opt.ResourceProfile
+		}
+
 		if opt.NetworkMode != nil {
 			merged.NetworkMode = opt.NetworkMode
 		}
@@ -422,11 +635,47 @@ func MergeECSPodDefinitionOptions(opts ...ECSPodDefinitionOptions) ECSPodDefinit
 		if opt.Tags != nil {
 			merged.Tags = opt.Tags
 		}
+
+		if opt.NameGenerator != nil {
+			merged.NameGenerator = opt.NameGenerator
+		}
+		if opt.Version > merged.Version {
+			merged.Version = opt.Version
+		}
+
+		merged.Extensions = mergeOptionsExtensions(merged.Extensions, opt.Extensions)
 	}
 
 	return merged
 }
 
+// MarshalJSON marshals the pod definition options to JSON, including any
+// extensions that were preserved from deserialization.
+func (o ECSPodDefinitionOptions) MarshalJSON() ([]byte, error) {
+	type options ECSPodDefinitionOptions
+	known := options(o)
+	if known.Version == 0 {
+		known.Version = OptionsVersion
+	}
+	return marshalOptionsJSON(known, o.Extensions)
+}
+
+// UnmarshalJSON unmarshals the pod definition options from JSON. Any fields
+// that are not recognized are preserved as extensions. If the options were
+// serialized by a newer version of cocoa, it logs a warning.
+func (o *ECSPodDefinitionOptions) UnmarshalJSON(b []byte) error {
+	type options ECSPodDefinitionOptions
+	var known options
+	ext, err := unmarshalOptionsJSON(b, &known)
+	if err != nil {
+		return err
+	}
+	*o = ECSPodDefinitionOptions(known)
+	o.Extensions = ext
+	warnOnNewerOptionsVersion("pod definition", o.Version, ext)
+	return nil
+}
+
 // ECSContainerDefinition defines settings that apply to a single container
 // within an ECS pod.
 type ECSContainerDefinition struct {
@@ -458,6 +707,40 @@ type ECSContainerDefinition struct {
 	PortMappings []PortMapping
 	// LogConfiguration is the configuration for logging the container's output.
 	LogConfiguration *LogConfiguration
+	// BindMounts are directories on the container instance to mount into the
+	// container.
+	BindMounts []BindMount
+	// Interactive indicates whether or not the container keeps its standard
+	// input open so that a process can be attached to it. By default, it is
+	// not interactive.
+	Interactive *bool
+	// PseudoTerminal indicates whether or not the container allocates a
+	// pseudo-terminal. This requires the container to be interactive. By
+	// default, no pseudo-terminal is allocated.
+	PseudoTerminal *bool
+	// Hostname is the hostname of the container. This cannot be set if the
+	// pod uses NetworkModeAWSVPC.
+	Hostname *string
+	// User is the user that runs commands in the container. This can be a user
+	// name or UID, optionally followed by a group name or GID (e.g.
+	// "user:group" or "1000:1000"). By default, the container runs as the user
+	// specified by the image.
+	User *string
+	// ExtraHosts are additional hostname mappings to add to the container's
+	// /etc/hosts file. This cannot be set if the pod uses NetworkModeAWSVPC.
+	ExtraHosts []HostEntry
+	// VolumesFrom are other containers in the pod whose volumes should be
+	// mounted in this container, so that containers can share a data
+	// container's filesystem.
+	VolumesFrom []VolumeFrom
+	// TmpfsMounts are in-memory filesystems to mount into the container. This
+	// is only supported for Linux containers running on EC2 container
+	// instances.
+	TmpfsMounts []TmpfsMount
+	// SharedMemorySizeMB is the size (in MB) of the container's /dev/shm
+	// volume. By default, the container uses the Docker default size. This is
+	// only supported for Linux containers running on EC2 container instances.
+	SharedMemorySizeMB *int
 }
 
 // NewECSContainerDefinition returns a new uninitialized container definition.
@@ -502,6 +785,18 @@ func (d *ECSContainerDefinition) SetCPU(cpu int) *ECSContainerDefinition {
 	return d
 }
 
+// SetMemory is the same as SetMemoryMB, but takes a typed amount of memory
+// (e.g. GiB(2)).
+func (d *ECSContainerDefinition) SetMemory(mem MemoryMB) *ECSContainerDefinition {
+	return d.SetMemoryMB(int(mem))
+}
+
+// SetCPUUnits is the same as SetCPU, but takes a typed amount of CPU (e.g.
+// VCPUs(0.5)).
+func (d *ECSContainerDefinition) SetCPUUnits(cpu CPUUnits) *ECSContainerDefinition {
+	return d.SetCPU(int(cpu))
+}
+
 // SetEnvironmentVariables sets the environment variables for the container.
 // This overwrites any existing environment variables.
 func (d *ECSContainerDefinition) SetEnvironmentVariables(envVars []EnvironmentVariable) *ECSContainerDefinition {
@@ -543,14 +838,122 @@ func (d *ECSContainerDefinition) SetLogConfiguration(lc LogConfiguration) *ECSCo
 	return d
 }
 
+// SetBindMounts sets the bind mounts for the container. This overwrites any
+// existing bind mounts.
+func (d *ECSContainerDefinition) SetBindMounts(mounts []BindMount) *ECSContainerDefinition {
+	d.BindMounts = mounts
+	return d
+}
+
+// AddBindMounts adds new bind mounts to the existing ones for the container.
+func (d *ECSContainerDefinition) AddBindMounts(mounts ...BindMount) *ECSContainerDefinition {
+	d.BindMounts = append(d.BindMounts, mounts...)
+	return d
+}
+
+// SetInteractive sets whether or not the container keeps its standard input
+// open.
+func (d *ECSContainerDefinition) SetInteractive(interactive bool) *ECSContainerDefinition {
+	d.Interactive = &interactive
+	return d
+}
+
+// SetPseudoTerminal sets whether or not the container allocates a
+// pseudo-terminal.
+func (d *ECSContainerDefinition) SetPseudoTerminal(tty bool) *ECSContainerDefinition {
+	d.PseudoTerminal = &tty
+	return d
+}
+
+// SetHostname sets the hostname of the container.
+func (d *ECSContainerDefinition) SetHostname(hostname string) *ECSContainerDefinition {
+	d.Hostname = &hostname
+	return d
+}
+
+// SetUser sets the user that runs commands in the container.
+func (d *ECSContainerDefinition) SetUser(user string) *ECSContainerDefinition {
+	d.User = &user
+	return d
+}
+
+// SetExtraHosts sets the additional hostname mappings for the container. This
+// overwrites any existing extra hosts.
+func (d *ECSContainerDefinition) SetExtraHosts(hosts []HostEntry) *ECSContainerDefinition {
+	d.ExtraHosts = hosts
+	return d
+}
+
+// AddExtraHosts adds new hostname mappings to the existing ones for the
+// container.
+func (d *ECSContainerDefinition) AddExtraHosts(hosts ...HostEntry) *ECSContainerDefinition {
+	d.ExtraHosts = append(d.ExtraHosts, hosts...)
+	return d
+}
+
+// SetVolumesFrom sets the containers whose volumes should be mounted in the
+// container. This overwrites any existing volumes from other containers.
+func (d *ECSContainerDefinition) SetVolumesFrom(volumesFrom []VolumeFrom) *ECSContainerDefinition {
+	d.VolumesFrom = volumesFrom
+	return d
+}
+
+// AddVolumesFrom adds new containers whose volumes should be mounted in the
+// container to the existing ones.
+func (d *ECSContainerDefinition) AddVolumesFrom(volumesFrom ...VolumeFrom) *ECSContainerDefinition {
+	d.VolumesFrom = append(d.VolumesFrom, volumesFrom...)
+	return d
+}
+
+// SetTmpfsMounts sets the in-memory filesystems to mount into the container.
+// This overwrites any existing tmpfs mounts.
+func (d *ECSContainerDefinition) SetTmpfsMounts(mounts []TmpfsMount) *ECSContainerDefinition {
+	d.TmpfsMounts = mounts
+	return d
+}
+
+// AddTmpfsMounts adds new in-memory filesystems to mount into the container to
+// the existing ones.
+func (d *ECSContainerDefinition) AddTmpfsMounts(mounts ...TmpfsMount) *ECSContainerDefinition {
+	d.TmpfsMounts = append(d.TmpfsMounts, mounts...)
+	return d
+}
+
+// SetSharedMemorySizeMB sets the size (in MB) of the container's /dev/shm
+// volume.
+func (d *ECSContainerDefinition) SetSharedMemorySizeMB(size int) *ECSContainerDefinition {
+	d.SharedMemorySizeMB = &size
+	return d
+}
+
+// usesLinuxParameters returns whether or not the container definition uses any
+// settings that are only supported for Linux containers running on EC2
+// container instances.
+func (d *ECSContainerDefinition) usesLinuxParameters() bool {
+	return len(d.TmpfsMounts) != 0 || d.SharedMemorySizeMB != nil
+}
+
 // Validate checks that the container definition is valid and sets defaults
 // where possible.
 func (d *ECSContainerDefinition) Validate() error {
+	return d.validate(nil)
+}
+
+// validate checks that the container definition is valid and sets defaults
+// where possible. If the container is not named, it is named by the name
+// generator.
+func (d *ECSContainerDefinition) validate(g NameGenerator) error {
 	catcher := grip.NewBasicCatcher()
 	catcher.NewWhen(d.Image == nil, "must specify an image")
 	catcher.NewWhen(d.Image != nil && *d.Image == "", "cannot specify an empty image")
 	catcher.NewWhen(d.MemoryMB != nil && *d.MemoryMB <= 0, "must have positive memory value if non-default")
 	catcher.NewWhen(d.CPU != nil && *d.CPU <= 0, "must have positive CPU value if non-default")
+	if d.MemoryMB != nil && *d.MemoryMB > 0 {
+		catcher.Add(MemoryMB(*d.MemoryMB).Validate())
+	}
+	if d.CPU != nil && *d.CPU > 0 {
+		catcher.Add(CPUUnits(*d.CPU).ValidateContainer())
+	}
 	for _, ev := range d.EnvVars {
 		catcher.Wrapf(ev.Validate(), "environment variable '%s'", utility.FromStringPtr(ev.Name))
 	}
@@ -563,12 +966,36 @@ func (d *ECSContainerDefinition) Validate() error {
 	for _, pm := range d.PortMappings {
 		catcher.Wrapf(pm.Validate(), "invalid port mapping")
 	}
+	for _, bm := range d.BindMounts {
+		catcher.Wrapf(bm.Validate(), "invalid bind mount")
+	}
+	catcher.NewWhen(utility.FromBoolPtr(d.PseudoTerminal) && !utility.FromBoolPtr(d.Interactive), "cannot allocate a pseudo-terminal for a container that is not interactive")
+	catcher.NewWhen(d.Hostname != nil && *d.Hostname == "", "cannot specify an empty hostname")
+	if d.User != nil {
+		catcher.Wrap(validateContainerUser(*d.User), "invalid user")
+	}
+	for _, h := range d.ExtraHosts {
+		catcher.Wrapf(h.Validate(), "invalid extra host '%s'", utility.FromStringPtr(h.Hostname))
+	}
+	for _, vf := range d.VolumesFrom {
+		source := utility.FromStringPtr(vf.SourceContainer)
+		catcher.Wrapf(vf.Validate(), "invalid volumes from container '%s'", source)
+		catcher.ErrorfWhen(source != "" && source == utility.FromStringPtr(d.Name), "cannot mount volumes from the container itself")
+	}
+	tmpfsPaths := map[string]bool{}
+	for _, m := range d.TmpfsMounts {
+		path := utility.FromStringPtr(m.ContainerPath)
+		catcher.Wrapf(m.Validate(), "invalid tmpfs mount '%s'", path)
+		catcher.ErrorfWhen(path != "" && tmpfsPaths[path], "tmpfs mount container path '%s' is used more than once", path)
+		tmpfsPaths[path] = true
+	}
+	catcher.NewWhen(d.SharedMemorySizeMB != nil && *d.SharedMemorySizeMB <= 0, "must have positive shared memory size if non-default")
 	if catcher.HasErrors() {
 		return catcher.Resolve()
 	}
 
 	if d.Name == nil {
-		d.Name = utility.ToStringPtr(utility.RandomString())
+		d.Name = utility.ToStringPtr(g.generate())
 	}
 
 	return nil
@@ -619,9 +1046,66 @@ func (d *ECSContainerDefinition) hash() string {
 		h.Add(newHashablePortMappings(d.PortMappings).hash())
 	}
 
+	if len(d.BindMounts) != 0 {
+		h.Add(newHashableBindMounts(d.BindMounts).hash())
+	}
+
+	if utility.FromBoolPtr(d.Interactive) {
+		h.Add("interactive")
+	}
+
+	if utility.FromBoolPtr(d.PseudoTerminal) {
+		h.Add("pseudo-terminal")
+	}
+
+	if d.Hostname != nil {
+		h.Add("hostname")
+		h.Add(utility.FromStringPtr(d.Hostname))
+	}
+
+	if d.User != nil {
+		h.Add("user")
+		h.Add(utility.FromStringPtr(d.User))
+	}
+
+	if len(d.ExtraHosts) != 0 {
+		h.Add(newHashableHostEntries(d.ExtraHosts).hash())
+	}
+
+	if len(d.VolumesFrom) != 0 {
+		h.Add(newHashableVolumesFrom(d.VolumesFrom).hash())
+	}
+
+	if len(d.TmpfsMounts) != 0 {
+		h.Add(newHashableTmpfsMounts(d.TmpfsMounts).hash())
+	}
+
+	if d.SharedMemorySizeMB != nil {
+		h.Add("shared-memory-size")
+		h.Add(strconv.Itoa(utility.FromIntPtr(d.SharedMemorySizeMB)))
+	}
+
 	return h.Sum()
 }
 
+// validateContainerUser checks that the container user is either a user or a
+// user and group separated by a colon.
+func validateContainerUser(user string) error {
+	if user == "" {
+		return errors.New("cannot specify an empty user")
+	}
+	parts := strings.Split(user, ":")
+	if len(parts) > 2 {
+		return errors.Errorf("user '%s' must be in the format 'user' or 'user:group'", user)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return errors.Errorf("user '%s' cannot have an empty user or group", user)
+		}
+	}
+	return nil
+}
+
 // hashableECSContainerDefinitions represents a hashable slice of ECS container
 // definitions ordered by container name.
 type hashableECSContainerDefinitions []ECSContainerDefinition
@@ -818,6 +1302,15 @@ type SecretOptions struct {
 	// Owned determines whether or not the secret is owned by its container or
 	// not.
 	Owned *bool
+	// Tags are resource tags to apply to the secret if it must be created.
+	Tags Tags
+	// Deferred determines whether or not the secret's value is only known
+	// when the pod is created rather than when its pod definition is created.
+	// A deferred secret is created with a placeholder value along with the pod
+	// definition, and its value must be given each time a pod is created from
+	// the pod definition (see ECSPodExecutionOptions.DeferredSecretValues).
+	// Deferred secrets are identified by their names.
+	Deferred *bool
 }
 
 // NewSecretOptions returns new uninitialized options for a secret.
@@ -849,14 +1342,40 @@ func (s *SecretOptions) SetOwned(owned bool) *SecretOptions {
 	return s
 }
 
+// SetTags sets the tags for the new secret. This overwrites any existing tags.
+func (s *SecretOptions) SetTags(tags map[string]string) *SecretOptions {
+	s.Tags = tags
+	return s
+}
+
+// AddTags adds new tags to the existing ones for the new secret.
+func (s *SecretOptions) AddTags(tags map[string]string) *SecretOptions {
+	s.Tags = s.Tags.Add(tags)
+	return s
+}
+
+// GetTags returns the tags for the new secret.
+func (s *SecretOptions) GetTags() Tags {
+	return s.Tags
+}
+
 // Validate validates that the secret name is given and that either the secret
 // already exists or the new secret's value is given.
 func (s *SecretOptions) Validate() error {
 	catcher := grip.NewBasicCatcher()
-	catcher.NewWhen(s.ID == nil && s.NewValue == nil, "must specify either an existing secret ID or a new secret to be created")
+	deferred := utility.FromBoolPtr(s.Deferred)
+	catcher.NewWhen(s.ID == nil && s.NewValue == nil && !deferred, "must specify either an existing secret ID or a new secret to be created")
+	catcher.NewWhen(deferred && s.NewValue != nil, "cannot specify a new value for a deferred secret")
+	catcher.NewWhen(deferred && utility.FromStringPtr(s.Name) == "", "must specify a name for a deferred secret")
 	catcher.NewWhen(s.ID != nil && s.NewValue != nil, "cannot specify both an existing secret ID and a new secret to be created")
 	catcher.NewWhen(s.NewValue != nil && s.Name == nil, "cannot specify a new secret to be created without a name")
 	catcher.NewWhen(s.ID != nil && utility.FromStringPtr(s.ID) == "", "cannot specify an empty secret ID")
+	catcher.NewWhen(s.ID != nil && len(s.Tags) != 0, "cannot specify tags for an existing secret")
+	catcher.Wrap(ValidateSecretsManagerTags(s.Tags), "invalid tags")
+	if id := utility.FromStringPtr(s.ID); IsSecretARN(id) {
+		_, err := identity.ParseSecretLocation(id)
+		catcher.Wrap(err, "invalid secret ARN")
+	}
 	return catcher.Resolve()
 }
 
@@ -879,6 +1398,14 @@ func (s *SecretOptions) hash() string {
 		h.Add(strconv.FormatBool(utility.FromBoolPtr(s.Owned)))
 	}
 
+	if len(s.Tags) != 0 {
+		h.Add(newHashablePairs(s.Tags).hash())
+	}
+
+	if s.Deferred != nil {
+		h.Add(strconv.FormatBool(utility.FromBoolPtr(s.Deferred)))
+	}
+
 	return h.Sum()
 }
 
@@ -886,8 +1413,16 @@ func (s *SecretOptions) hash() string {
 type LogConfiguration struct {
 	// LogDriver is the logging driver to use.
 	LogDriver *string
-	// Options are the logging driver options.
+	// Options are the logging driver options. For the awslogs driver, prefer
+	// AWSLogsOpts, which cannot have misspelled option keys.
 	Options map[string]string
+	// AWSLogsOpts are typed options for the awslogs driver. If these are set,
+	// the log driver must be awslogs. These are rendered into the logging
+	// driver options, so they cannot also be given in Options.
+	AWSLogsOpts *AWSLogsOptions
+	// SecretOptions are the logging driver options whose values are stored in
+	// secrets. Each one must reference a secret.
+	SecretOptions []EnvironmentVariable
 }
 
 // NewLogConfiguration returns a new uninitialized log configuration.
@@ -907,30 +1442,257 @@ func (c *LogConfiguration) SetOptions(o map[string]string) *LogConfiguration {
 	return c
 }
 
+// SetAWSLogsOptions sets the typed options for the awslogs driver.
+func (c *LogConfiguration) SetAWSLogsOptions(opts AWSLogsOptions) *LogConfiguration {
+	c.AWSLogsOpts = &opts
+	return c
+}
+
+// GetOptions returns all the logging driver options, including the rendered
+// awslogs options.
+func (c *LogConfiguration) GetOptions() map[string]string {
+	if c.Options == nil && c.AWSLogsOpts == nil {
+		return nil
+	}
+
+	options := map[string]string{}
+	for k, v := range c.Options {
+		options[k] = v
+	}
+	if c.AWSLogsOpts != nil {
+		for k, v := range c.AWSLogsOpts.ToOptions() {
+			options[k] = v
+		}
+	}
+	return options
+}
+
+// SetSecretOptions sets the logging driver options that are stored in secrets.
+// This overwrites any existing secret options.
+func (c *LogConfiguration) SetSecretOptions(opts []EnvironmentVariable) *LogConfiguration {
+	c.SecretOptions = opts
+	return c
+}
+
+// AddSecretOptions adds new logging driver options that are stored in secrets
+// to the existing ones.
+func (c *LogConfiguration) AddSecretOptions(opts ...EnvironmentVariable) *LogConfiguration {
+	c.SecretOptions = append(c.SecretOptions, opts...)
+	return c
+}
+
 // Validate checks that the log driver as well as required groups "awslogs-group" and "awslogs-region" are both set.
+// If typed awslogs options are given, it checks that they are valid and do not
+// conflict with the raw options, and defaults the log driver to awslogs.
 func (c *LogConfiguration) Validate() error {
 	catcher := grip.NewBasicCatcher()
-	catcher.NewWhen(c.LogDriver == nil, "must specify a log driver")
-	catcher.NewWhen(c.Options == nil, "must specify log driver options")
-	if c.Options != nil {
-		catcher.NewWhen(c.Options["awslogs-group"] == "", "must specify awslogs-group in options")
-		catcher.NewWhen(c.Options["awslogs-region"] == "", "must specify awslogs-region in options")
+	if c.AWSLogsOpts != nil {
+		catcher.Wrap(c.AWSLogsOpts.Validate(), "invalid awslogs options")
+		catcher.ErrorfWhen(c.LogDriver != nil && *c.LogDriver != AWSLogsDriver, "cannot specify awslogs options with log driver '%s'", utility.FromStringPtr(c.LogDriver))
+		for k := range c.Options {
+			catcher.ErrorfWhen(strings.HasPrefix(k, "awslogs-") || k == "mode" || k == "max-buffer-size", "cannot specify option '%s' in both the raw options and the awslogs options", k)
+		}
+	} else {
+		catcher.NewWhen(c.LogDriver == nil, "must specify a log driver")
+		catcher.NewWhen(c.Options == nil, "must specify log driver options")
+		if c.Options != nil {
+			catcher.NewWhen(c.Options["awslogs-group"] == "", "must specify awslogs-group in options")
+			catcher.NewWhen(c.Options["awslogs-region"] == "", "must specify awslogs-region in options")
+		}
 	}
-	return catcher.Resolve()
+	for _, opt := range c.SecretOptions {
+		catcher.ErrorfWhen(opt.SecretOpts == nil, "secret option '%s' must reference a secret", utility.FromStringPtr(opt.Name))
+		catcher.Wrapf(opt.Validate(), "invalid secret option '%s'", utility.FromStringPtr(opt.Name))
+	}
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	if c.AWSLogsOpts != nil && c.LogDriver == nil {
+		c.SetLogDriver(AWSLogsDriver)
+	}
+
+	return nil
 }
 
-// hash returns the hash digest of the log configuration.
+// hash returns the hash digest of the log configuration. Since the awslogs
+// options are rendered into the raw options, equivalent log configurations
+// have the same hash regardless of how their options were given.
 func (c *LogConfiguration) hash() string {
 	h := utility.NewSHA1Hash()
 	if c.LogDriver != nil {
 		h.Add(utility.FromStringPtr(c.LogDriver))
 	}
-	if c.Options != nil {
-		h.Add(newHashablePairs(c.Options).hash())
+	if options := c.GetOptions(); options != nil {
+		h.Add(newHashablePairs(options).hash())
+	}
+	if len(c.SecretOptions) != 0 {
+		h.Add(newHashableEnvironmentVariables(c.SecretOptions).hash())
 	}
 	return h.Sum()
 }
 
+// AWSLogsDriver is the name of the log driver that sends container logs to
+// CloudWatch Logs.
+const AWSLogsDriver = "awslogs"
+
+// AWSLogsMode is the delivery mode for logs from the container to the awslogs
+// driver.
+type AWSLogsMode string
+
+const (
+	// AWSLogsModeBlocking indicates that the container blocks when it cannot
+	// deliver logs.
+	AWSLogsModeBlocking AWSLogsMode = "blocking"
+	// AWSLogsModeNonBlocking indicates that logs are buffered so that the
+	// container does not block when it cannot deliver logs. Logs may be lost
+	// if the buffer fills up.
+	AWSLogsModeNonBlocking AWSLogsMode = "non-blocking"
+)
+
+// Validate checks that the awslogs mode is one of the recognized modes.
+func (m AWSLogsMode) Validate() error {
+	switch m {
+	case AWSLogsModeBlocking, AWSLogsModeNonBlocking:
+		return nil
+	default:
+		return errors.Errorf("unrecognized awslogs mode '%s'", m)
+	}
+}
+
+// AWSLogsOptions are typed options for the awslogs log driver, which sends
+// container logs to CloudWatch Logs.
+// Docs: https://docs.aws.amazon.com/AmazonECS/latest/developerguide/using_awslogs.html
+type AWSLogsOptions struct {
+	// Group is the log group to send logs to. This is required.
+	Group *string
+	// Region is the region of the log group. This is required.
+	Region *string
+	// StreamPrefix is the prefix for the log stream names.
+	StreamPrefix *string
+	// CreateGroup indicates whether or not to create the log group if it does
+	// not exist.
+	CreateGroup *bool
+	// DatetimeFormat is the strftime format that marks the start of a
+	// multiline log message. This cannot be specified with MultilinePattern.
+	DatetimeFormat *string
+	// MultilinePattern is the regular expression that marks the start of a
+	// multiline log message. This cannot be specified with DatetimeFormat.
+	MultilinePattern *string
+	// Mode is the delivery mode for logs.
+	Mode *AWSLogsMode
+	// MaxBufferSize is the size of the buffer for logs when using the
+	// non-blocking mode (e.g. "25m").
+	MaxBufferSize *string
+}
+
+// NewAWSLogsOptions returns new uninitialized awslogs options.
+func NewAWSLogsOptions() *AWSLogsOptions {
+	return &AWSLogsOptions{}
+}
+
+// SetGroup sets the log group to send logs to.
+func (o *AWSLogsOptions) SetGroup(group string) *AWSLogsOptions {
+	o.Group = &group
+	return o
+}
+
+// SetRegion sets the region of the log group.
+func (o *AWSLogsOptions) SetRegion(region string) *AWSLogsOptions {
+	o.Region = &region
+	return o
+}
+
+// SetStreamPrefix sets the prefix for the log stream names.
+func (o *AWSLogsOptions) SetStreamPrefix(prefix string) *AWSLogsOptions {
+	o.StreamPrefix = &prefix
+	return o
+}
+
+// SetCreateGroup sets whether or not to create the log group if it does not
+// exist.
+func (o *AWSLogsOptions) SetCreateGroup(create bool) *AWSLogsOptions {
+	o.CreateGroup = &create
+	return o
+}
+
+// SetDatetimeFormat sets the strftime format that marks the start of a
+// multiline log message.
+func (o *AWSLogsOptions) SetDatetimeFormat(format string) *AWSLogsOptions {
+	o.DatetimeFormat = &format
+	return o
+}
+
+// SetMultilinePattern sets the regular expression that marks the start of a
+// multiline log message.
+func (o *AWSLogsOptions) SetMultilinePattern(pattern string) *AWSLogsOptions {
+	o.MultilinePattern = &pattern
+	return o
+}
+
+// SetMode sets the delivery mode for logs.
+func (o *AWSLogsOptions) SetMode(mode AWSLogsMode) *AWSLogsOptions {
+	o.Mode = &mode
+	return o
+}
+
+// SetMaxBufferSize sets the size of the buffer for logs when using the
+// non-blocking mode.
+func (o *AWSLogsOptions) SetMaxBufferSize(size string) *AWSLogsOptions {
+	o.MaxBufferSize = &size
+	return o
+}
+
+// Validate checks that the log group and region are given and that the options
+// are compatible with each other.
+func (o *AWSLogsOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(utility.FromStringPtr(o.Group) == "", "must specify a log group")
+	catcher.NewWhen(utility.FromStringPtr(o.Region) == "", "must specify a region")
+	catcher.NewWhen(o.StreamPrefix != nil && *o.StreamPrefix == "", "cannot specify an empty stream prefix")
+	catcher.NewWhen(o.DatetimeFormat != nil && *o.DatetimeFormat == "", "cannot specify an empty datetime format")
+	catcher.NewWhen(o.MultilinePattern != nil && *o.MultilinePattern == "", "cannot specify an empty multiline pattern")
+	catcher.NewWhen(o.DatetimeFormat != nil && o.MultilinePattern != nil, "cannot specify both a datetime format and a multiline pattern")
+	if o.Mode != nil {
+		catcher.Wrap(o.Mode.Validate(), "invalid mode")
+	}
+	if o.MaxBufferSize != nil {
+		catcher.NewWhen(*o.MaxBufferSize == "", "cannot specify an empty max buffer size")
+		catcher.NewWhen(o.Mode == nil || *o.Mode != AWSLogsModeNonBlocking, "can only specify a max buffer size with non-blocking mode")
+	}
+	return catcher.Resolve()
+}
+
+// ToOptions renders the awslogs options into logging driver options.
+func (o *AWSLogsOptions) ToOptions() map[string]string {
+	options := map[string]string{}
+	if o.Group != nil {
+		options["awslogs-group"] = *o.Group
+	}
+	if o.Region != nil {
+		options["awslogs-region"] = *o.Region
+	}
+	if o.StreamPrefix != nil {
+		options["awslogs-stream-prefix"] = *o.StreamPrefix
+	}
+	if o.CreateGroup != nil {
+		options["awslogs-create-group"] = strconv.FormatBool(*o.CreateGroup)
+	}
+	if o.DatetimeFormat != nil {
+		options["awslogs-datetime-format"] = *o.DatetimeFormat
+	}
+	if o.MultilinePattern != nil {
+		options["awslogs-multiline-pattern"] = *o.MultilinePattern
+	}
+	if o.Mode != nil {
+		options["mode"] = string(*o.Mode)
+	}
+	if o.MaxBufferSize != nil {
+		options["max-buffer-size"] = *o.MaxBufferSize
+	}
+	return options
+}
+
 // RepositoryCredentials are credentials for using images from private
 // repositories. The credentials must be stored in a secret vault.
 type RepositoryCredentials struct {
@@ -943,8 +1705,21 @@ type RepositoryCredentials struct {
 	// NewCreds are the new credentials to be stored. If this is unspecified,
 	// the secrets are assumed to already exist.
 	NewCreds *StoredRepositoryCredentials
+	// CreateIfMissing determines whether the new credentials should only be
+	// created if no secret with the given name already exists. If the secret
+	// already exists, it is used as-is and is never owned by the pod. This
+	// requires the vault to implement SecretFinder.
+	CreateIfMissing *bool
 	// Owned determines whether or not the secret is owned by its pod or not.
 	Owned *bool
+	// SkipForPublicImages determines whether the credentials should be
+	// skipped if the container's image is in a public registry (see
+	// IsPublicImage). Skipped credentials are neither created nor used by the
+	// container, which avoids looking up the secret every time the image is
+	// pulled. This is useful for credentials that are shared between many
+	// container definitions, only some of which use private images. By
+	// default, the credentials are always used.
+	SkipForPublicImages *bool
 }
 
 // NewRepositoryCredentials returns a new uninitialized set of repository
@@ -971,13 +1746,27 @@ func (c *RepositoryCredentials) SetNewCredentials(creds StoredRepositoryCredenti
 	return c
 }
 
-// SetOwned sets whether or not the secret credentials are owned by its pod or
-// not.
+// SetCreateIfMissing sets whether or not the new credentials should only be
+// created if no secret with the given name already exists.
+func (c *RepositoryCredentials) SetCreateIfMissing(createIfMissing bool) *RepositoryCredentials {
+	c.CreateIfMissing = &createIfMissing
+	return c
+}
+
+// SetOwned sets whether or not the secret credentials are owned by its pod or
+// not.
 func (c *RepositoryCredentials) SetOwned(owned bool) *RepositoryCredentials {
 	c.Owned = &owned
 	return c
 }
 
+// SetSkipForPublicImages sets whether or not the credentials should be skipped
+// if the container's image is in a public registry.
+func (c *RepositoryCredentials) SetSkipForPublicImages(skip bool) *RepositoryCredentials {
+	c.SkipForPublicImages = &skip
+	return c
+}
+
 // Validate check that the secret options are given and that either the
 // new credentials to create are specified, or the secret already exists.
 func (c *RepositoryCredentials) Validate() error {
@@ -985,7 +1774,12 @@ func (c *RepositoryCredentials) Validate() error {
 	catcher.NewWhen(c.ID == nil && c.NewCreds == nil, "must specify either an existing secret ID or new credentials to create")
 	catcher.NewWhen(c.ID != nil && c.NewCreds != nil, "cannot specify both an existing secret ID and a new secret to create")
 	catcher.NewWhen(c.NewCreds != nil && c.Name == nil, "cannot specify a new secret to be created without a name")
+	catcher.NewWhen(utility.FromBoolPtr(c.CreateIfMissing) && c.NewCreds == nil, "cannot create credentials if missing without new credentials to create")
 	catcher.NewWhen(c.ID != nil && utility.FromStringPtr(c.ID) == "", "cannot specify an empty secret ID")
+	if id := utility.FromStringPtr(c.ID); IsSecretARN(id) {
+		_, err := identity.ParseSecretARN(id)
+		catcher.Wrapf(err, "repository credentials ARN '%s' must refer to a valid Secrets Manager secret", id)
+	}
 	if c.NewCreds != nil {
 		catcher.Wrap(c.NewCreds.Validate(), "invalid new credentials to create")
 	}
@@ -1007,13 +1801,49 @@ func (c *RepositoryCredentials) hash() string {
 		h.Add(c.NewCreds.hash())
 	}
 
+	if c.CreateIfMissing != nil {
+		h.Add(strconv.FormatBool(utility.FromBoolPtr(c.CreateIfMissing)))
+	}
+
 	if c.Owned != nil {
 		h.Add(strconv.FormatBool(utility.FromBoolPtr(c.Owned)))
 	}
 
+	if c.SkipForPublicImages != nil {
+		h.Add(strconv.FormatBool(utility.FromBoolPtr(c.SkipForPublicImages)))
+	}
+
 	return h.Sum()
 }
 
+// publicImageRegistries are the registry hosts that only serve public images.
+var publicImageRegistries = []string{"public.ecr.aws"}
+
+// IsPublicImage returns whether or not the image is in a registry that only
+// serves public images (i.e. Amazon ECR Public), so pulling it never requires
+// repository credentials. Images in registries that serve both public and
+// private images (e.g. Docker Hub) are not considered public.
+func IsPublicImage(image string) bool {
+	host := imageRegistryHost(image)
+	for _, registry := range publicImageRegistries {
+		if strings.EqualFold(host, registry) {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesRepositoryCredentials returns whether or not the container uses its
+// repository credentials to pull its image. The container does not use its
+// repository credentials if it has none or if they are skipped for its public
+// image.
+func (d *ECSContainerDefinition) UsesRepositoryCredentials() bool {
+	if d.RepoCreds == nil {
+		return false
+	}
+	return !utility.FromBoolPtr(d.RepoCreds.SkipForPublicImages) || !IsPublicImage(utility.FromStringPtr(d.Image))
+}
+
 // StoredRepositoryCredentials represents the storage format of repository
 // credentials for using images from private repositories.
 type StoredRepositoryCredentials struct {
@@ -1064,7 +1894,7 @@ func (c *StoredRepositoryCredentials) hash() string {
 }
 
 // PortMapping represents a mapping from a container port to a port in the
-// container instance. The transport protocol is TCP.
+// container instance. By default, the transport protocol is TCP.
 type PortMapping struct {
 	// ContainerPort is the port within the container to expose to network
 	// traffic.
@@ -1076,6 +1906,12 @@ type PortMapping struct {
 	// If the pod's network mode is NetworkModeBridge, this can either be
 	// explicitly set or omitted to be assigned a port at random.
 	HostPort *int
+	// Protocol is the transport protocol for the port mapping. If this is
+	// unspecified, it defaults to PortProtocolTCP.
+	Protocol *PortProtocol
+	// Name is the name of the port mapping, which must be unique within the
+	// pod. This is required to use the port mapping with ECS Service Connect.
+	Name *string
 }
 
 // NewPortMapping returns a new uninitialized port mapping.
@@ -1097,6 +1933,23 @@ func (m *PortMapping) SetHostPort(port int) *PortMapping {
 	return m
 }
 
+// SetProtocol sets the transport protocol for the port mapping.
+func (m *PortMapping) SetProtocol(protocol PortProtocol) *PortMapping {
+	m.Protocol = &protocol
+	return m
+}
+
+// SetName sets the name of the port mapping.
+func (m *PortMapping) SetName(name string) *PortMapping {
+	m.Name = &name
+	return m
+}
+
+// portMappingNameRegexp matches valid port mapping names. Names can only
+// contain lowercase letters, numbers, underscores, and hyphens, and cannot
+// begin with a hyphen.
+var portMappingNameRegexp = regexp.MustCompile(`^[a-z0-9_][a-z0-9_-]{0,63}$`)
+
 // Validate checks that the required port mapping settings are given. It does
 // not check that the pod-level network mode is valid with the port mapping.
 func (m *PortMapping) Validate() error {
@@ -1112,6 +1965,13 @@ func (m *PortMapping) Validate() error {
 		hostPort := utility.FromIntPtr(m.HostPort)
 		catcher.ErrorfWhen(hostPort <= minPort || hostPort >= maxPort, "must specify a container port between %d-%d", minPort, maxPort)
 	}
+	if m.Protocol != nil {
+		catcher.Add(m.Protocol.Validate())
+	}
+	if m.Name != nil {
+		name := utility.FromStringPtr(m.Name)
+		catcher.ErrorfWhen(!portMappingNameRegexp.MatchString(name), "port mapping name '%s' must be 1-64 characters containing only lowercase letters, numbers, underscores, and hyphens, and cannot begin with a hyphen", name)
+	}
 	return catcher.Resolve()
 }
 
@@ -1126,6 +1986,14 @@ func (m *PortMapping) hash() string {
 		h.Add(strconv.Itoa(utility.FromIntPtr(m.HostPort)))
 	}
 
+	if m.Protocol != nil {
+		h.Add(string(*m.Protocol))
+	}
+
+	if m.Name != nil {
+		h.Add(utility.FromStringPtr(m.Name))
+	}
+
 	return h.Sum()
 }
 
@@ -1145,14 +2013,23 @@ func (hpm hashablePortMappings) Len() int {
 
 // Less returns whether or not the container port for the mapping at index i is
 // less than the container port for the mapping at index j. If they're equal,
-// the host ports are compared.
+// the host ports are compared, followed by the protocols and names.
 func (hpm hashablePortMappings) Less(i, j int) bool {
 	cpi, cpj := utility.FromIntPtr(hpm[i].ContainerPort), utility.FromIntPtr(hpm[j].ContainerPort)
-	if cpi == cpj {
-		return utility.FromIntPtr(hpm[i].HostPort) < utility.FromIntPtr(hpm[j].HostPort)
+	if cpi != cpj {
+		return cpi < cpj
+	}
+
+	hpi, hpj := utility.FromIntPtr(hpm[i].HostPort), utility.FromIntPtr(hpm[j].HostPort)
+	if hpi != hpj {
+		return hpi < hpj
 	}
 
-	return cpi < cpj
+	if pi, pj := hpm[i].protocol(), hpm[j].protocol(); pi != pj {
+		return pi < pj
+	}
+
+	return utility.FromStringPtr(hpm[i].Name) < utility.FromStringPtr(hpm[j].Name)
 }
 
 // Swap swaps the port mappings at indexes i and j.
@@ -1175,6 +2052,482 @@ func (hpm hashablePortMappings) hash() string {
 	return h.Sum()
 }
 
+// effectiveHostPort returns the port that the port mapping binds on the
+// container instance in the given network mode. For NetworkModeHost and
+// NetworkModeAWSVPC, the host port is always the container port. For
+// NetworkModeBridge, the host port is only known if it is explicitly set;
+// otherwise, it is dynamically assigned by ECS.
+func (m *PortMapping) effectiveHostPort(mode ECSNetworkMode) (int, bool) {
+	switch mode {
+	case NetworkModeHost, NetworkModeAWSVPC:
+		return utility.FromIntPtr(m.ContainerPort), m.ContainerPort != nil
+	case NetworkModeBridge:
+		return utility.FromIntPtr(m.HostPort), m.HostPort != nil
+	default:
+		return 0, false
+	}
+}
+
+// protocol returns the port mapping's transport protocol, which defaults to
+// TCP if it is unspecified.
+func (m *PortMapping) protocol() PortProtocol {
+	if m.Protocol == nil {
+		return PortProtocolTCP
+	}
+	return *m.Protocol
+}
+
+// PortProtocol represents a transport protocol for a port mapping.
+type PortProtocol string
+
+const (
+	// PortProtocolTCP indicates that the port uses TCP.
+	PortProtocolTCP PortProtocol = "tcp"
+	// PortProtocolUDP indicates that the port uses UDP.
+	PortProtocolUDP PortProtocol = "udp"
+)
+
+// Validate checks that the port protocol is one of the recognized protocols.
+func (p PortProtocol) Validate() error {
+	switch p {
+	case PortProtocolTCP, PortProtocolUDP:
+		return nil
+	default:
+		return errors.Errorf("unrecognized port protocol '%s'", p)
+	}
+}
+
+// BindMount represents a directory on the container instance that is mounted
+// into a container.
+type BindMount struct {
+	// SourcePath is the absolute path of the directory on the container
+	// instance to mount. This is required.
+	SourcePath *string
+	// ContainerPath is the absolute path within the container where the
+	// directory is mounted. This is required.
+	ContainerPath *string
+	// ReadOnly indicates that the container can only read from the mounted
+	// directory. By default, this is false.
+	ReadOnly *bool
+}
+
+// NewBindMount returns a new uninitialized bind mount.
+func NewBindMount() *BindMount {
+	return &BindMount{}
+}
+
+// SetSourcePath sets the path of the directory on the container instance to
+// mount.
+func (m *BindMount) SetSourcePath(path string) *BindMount {
+	m.SourcePath = &path
+	return m
+}
+
+// SetContainerPath sets the path within the container where the directory is
+// mounted.
+func (m *BindMount) SetContainerPath(path string) *BindMount {
+	m.ContainerPath = &path
+	return m
+}
+
+// SetReadOnly sets whether or not the container can only read from the
+// mounted directory.
+func (m *BindMount) SetReadOnly(readOnly bool) *BindMount {
+	m.ReadOnly = &readOnly
+	return m
+}
+
+// Validate checks that the required bind mount settings are given.
+func (m *BindMount) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(utility.FromStringPtr(m.SourcePath) == "", "must specify a source path")
+	catcher.NewWhen(utility.FromStringPtr(m.ContainerPath) == "", "must specify a container path")
+	return catcher.Resolve()
+}
+
+// hash returns the hash digest of the bind mount.
+func (m *BindMount) hash() string {
+	h := utility.NewSHA1Hash()
+	h.Add(utility.FromStringPtr(m.SourcePath))
+	h.Add(utility.FromStringPtr(m.ContainerPath))
+	h.Add(strconv.FormatBool(utility.FromBoolPtr(m.ReadOnly)))
+	return h.Sum()
+}
+
+type hashableBindMounts []BindMount
+
+// newHashableBindMounts returns a sorted slice of hashable bind mounts.
+func newHashableBindMounts(bm []BindMount) hashableBindMounts {
+	hbm := hashableBindMounts(bm)
+	sort.Sort(hbm)
+	return hbm
+}
+
+// Len returns the number of bind mounts.
+func (hbm hashableBindMounts) Len() int {
+	return len(hbm)
+}
+
+// Less returns whether or not the container path for the bind mount at index i
+// is less than the container path for the bind mount at index j.
+func (hbm hashableBindMounts) Less(i, j int) bool {
+	return utility.FromStringPtr(hbm[i].ContainerPath) < utility.FromStringPtr(hbm[j].ContainerPath)
+}
+
+// Swap swaps the bind mounts at indexes i and j.
+func (hbm hashableBindMounts) Swap(i, j int) {
+	hbm[i], hbm[j] = hbm[j], hbm[i]
+}
+
+// hash returns the hash digest of the bind mounts.
+func (hbm hashableBindMounts) hash() string {
+	if !sort.IsSorted(hbm) {
+		sort.Sort(hbm)
+	}
+
+	h := utility.NewSHA1Hash()
+
+	for _, bm := range hbm {
+		h.Add(bm.hash())
+	}
+
+	return h.Sum()
+}
+
+// HostEntry represents a hostname mapping to add to a container's /etc/hosts
+// file.
+type HostEntry struct {
+	// Hostname is the hostname to map. This is required.
+	Hostname *string
+	// IPAddress is the IP address that the hostname maps to. This is required.
+	IPAddress *string
+}
+
+// NewHostEntry returns a new uninitialized host entry.
+func NewHostEntry() *HostEntry {
+	return &HostEntry{}
+}
+
+// SetHostname sets the hostname to map.
+func (h *HostEntry) SetHostname(hostname string) *HostEntry {
+	h.Hostname = &hostname
+	return h
+}
+
+// SetIPAddress sets the IP address that the hostname maps to.
+func (h *HostEntry) SetIPAddress(ip string) *HostEntry {
+	h.IPAddress = &ip
+	return h
+}
+
+// Validate checks that the hostname and a valid IP address are given.
+func (h *HostEntry) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(utility.FromStringPtr(h.Hostname) == "", "must specify a hostname")
+	ip := utility.FromStringPtr(h.IPAddress)
+	catcher.NewWhen(ip == "", "must specify an IP address")
+	catcher.ErrorfWhen(ip != "" && net.ParseIP(ip) == nil, "invalid IP address '%s'", ip)
+	return catcher.Resolve()
+}
+
+// hash returns the hash digest of the host entry.
+func (h *HostEntry) hash() string {
+	hash := utility.NewSHA1Hash()
+	hash.Add(utility.FromStringPtr(h.Hostname))
+	hash.Add(utility.FromStringPtr(h.IPAddress))
+	return hash.Sum()
+}
+
+type hashableHostEntries []HostEntry
+
+// newHashableHostEntries returns a sorted slice of hashable host entries.
+func newHashableHostEntries(entries []HostEntry) hashableHostEntries {
+	hhe := hashableHostEntries(entries)
+	sort.Sort(hhe)
+	return hhe
+}
+
+// Len returns the number of host entries.
+func (hhe hashableHostEntries) Len() int {
+	return len(hhe)
+}
+
+// Less returns whether or not the host entry at index i is ordered before the
+// host entry at index j by hostname and then by IP address.
+func (hhe hashableHostEntries) Less(i, j int) bool {
+	hi, hj := utility.FromStringPtr(hhe[i].Hostname), utility.FromStringPtr(hhe[j].Hostname)
+	if hi != hj {
+		return hi < hj
+	}
+	return utility.FromStringPtr(hhe[i].IPAddress) < utility.FromStringPtr(hhe[j].IPAddress)
+}
+
+// Swap swaps the host entries at indexes i and j.
+func (hhe hashableHostEntries) Swap(i, j int) {
+	hhe[i], hhe[j] = hhe[j], hhe[i]
+}
+
+// hash returns the hash digest of the host entries.
+func (hhe hashableHostEntries) hash() string {
+	if !sort.IsSorted(hhe) {
+		sort.Sort(hhe)
+	}
+
+	h := utility.NewSHA1Hash()
+
+	for _, he := range hhe {
+		h.Add(he.hash())
+	}
+
+	return h.Sum()
+}
+
+// VolumeFrom represents another container in the pod whose volumes should be
+// mounted in a container.
+type VolumeFrom struct {
+	// SourceContainer is the name of the container in the pod whose volumes
+	// should be mounted. This is required.
+	SourceContainer *string
+	// ReadOnly determines whether or not the volumes are mounted as
+	// read-only. By default, the volumes are writable.
+	ReadOnly *bool
+}
+
+// NewVolumeFrom returns a new uninitialized volume from another container.
+func NewVolumeFrom() *VolumeFrom {
+	return &VolumeFrom{}
+}
+
+// SetSourceContainer sets the name of the container whose volumes should be
+// mounted.
+func (v *VolumeFrom) SetSourceContainer(name string) *VolumeFrom {
+	v.SourceContainer = &name
+	return v
+}
+
+// SetReadOnly sets whether or not the volumes are mounted as read-only.
+func (v *VolumeFrom) SetReadOnly(readOnly bool) *VolumeFrom {
+	v.ReadOnly = &readOnly
+	return v
+}
+
+// Validate checks that the source container is given.
+func (v *VolumeFrom) Validate() error {
+	if utility.FromStringPtr(v.SourceContainer) == "" {
+		return errors.New("must specify a source container")
+	}
+	return nil
+}
+
+// hash returns the hash digest of the volume from another container.
+func (v *VolumeFrom) hash() string {
+	h := utility.NewSHA1Hash()
+	h.Add(utility.FromStringPtr(v.SourceContainer))
+	h.Add(strconv.FormatBool(utility.FromBoolPtr(v.ReadOnly)))
+	return h.Sum()
+}
+
+type hashableVolumesFrom []VolumeFrom
+
+// newHashableVolumesFrom returns a sorted slice of hashable volumes from other
+// containers.
+func newHashableVolumesFrom(volumesFrom []VolumeFrom) hashableVolumesFrom {
+	hvf := hashableVolumesFrom(volumesFrom)
+	sort.Sort(hvf)
+	return hvf
+}
+
+// Len returns the number of volumes from other containers.
+func (hvf hashableVolumesFrom) Len() int {
+	return len(hvf)
+}
+
+// Less returns whether or not the volume from another container at index i is
+// ordered before the one at index j by source container and then by whether
+// it's read-only.
+func (hvf hashableVolumesFrom) Less(i, j int) bool {
+	si, sj := utility.FromStringPtr(hvf[i].SourceContainer), utility.FromStringPtr(hvf[j].SourceContainer)
+	if si != sj {
+		return si < sj
+	}
+	return !utility.FromBoolPtr(hvf[i].ReadOnly) && utility.FromBoolPtr(hvf[j].ReadOnly)
+}
+
+// Swap swaps the volumes from other containers at indexes i and j.
+func (hvf hashableVolumesFrom) Swap(i, j int) {
+	hvf[i], hvf[j] = hvf[j], hvf[i]
+}
+
+// hash returns the hash digest of the volumes from other containers.
+func (hvf hashableVolumesFrom) hash() string {
+	if !sort.IsSorted(hvf) {
+		sort.Sort(hvf)
+	}
+
+	h := utility.NewSHA1Hash()
+
+	for _, vf := range hvf {
+		h.Add(vf.hash())
+	}
+
+	return h.Sum()
+}
+
+// validTmpfsMountOptions are the tmpfs mount options that ECS supports.
+var validTmpfsMountOptions = map[string]bool{
+	"defaults":      true,
+	"ro":            true,
+	"rw":            true,
+	"suid":          true,
+	"nosuid":        true,
+	"dev":           true,
+	"nodev":         true,
+	"exec":          true,
+	"noexec":        true,
+	"sync":          true,
+	"async":         true,
+	"dirsync":       true,
+	"remount":       true,
+	"mand":          true,
+	"nomand":        true,
+	"atime":         true,
+	"noatime":       true,
+	"diratime":      true,
+	"nodiratime":    true,
+	"bind":          true,
+	"rbind":         true,
+	"unbindable":    true,
+	"runbindable":   true,
+	"private":       true,
+	"rprivate":      true,
+	"shared":        true,
+	"rshared":       true,
+	"slave":         true,
+	"rslave":        true,
+	"relatime":      true,
+	"norelatime":    true,
+	"strictatime":   true,
+	"nostrictatime": true,
+	"mode":          true,
+	"uid":           true,
+	"gid":           true,
+	"nr_inodes":     true,
+	"nr_blocks":     true,
+	"mpol":          true,
+}
+
+// TmpfsMount represents an in-memory filesystem to mount into a container.
+type TmpfsMount struct {
+	// ContainerPath is the absolute path within the container where the tmpfs
+	// is mounted. This is required.
+	ContainerPath *string
+	// SizeMB is the maximum size (in MB) of the tmpfs. This is required.
+	SizeMB *int
+	// MountOptions are the tmpfs mount options (e.g. "noexec" or
+	// "mode=1777"). By default, there are no mount options.
+	MountOptions []string
+}
+
+// NewTmpfsMount returns a new uninitialized tmpfs mount.
+func NewTmpfsMount() *TmpfsMount {
+	return &TmpfsMount{}
+}
+
+// SetContainerPath sets the path within the container where the tmpfs is
+// mounted.
+func (m *TmpfsMount) SetContainerPath(path string) *TmpfsMount {
+	m.ContainerPath = &path
+	return m
+}
+
+// SetSizeMB sets the maximum size (in MB) of the tmpfs.
+func (m *TmpfsMount) SetSizeMB(size int) *TmpfsMount {
+	m.SizeMB = &size
+	return m
+}
+
+// SetMountOptions sets the tmpfs mount options. This overwrites any existing
+// mount options.
+func (m *TmpfsMount) SetMountOptions(opts []string) *TmpfsMount {
+	m.MountOptions = opts
+	return m
+}
+
+// AddMountOptions adds new tmpfs mount options to the existing ones.
+func (m *TmpfsMount) AddMountOptions(opts ...string) *TmpfsMount {
+	m.MountOptions = append(m.MountOptions, opts...)
+	return m
+}
+
+// Validate checks that the container path is absolute, the size is positive,
+// and the mount options are supported by ECS.
+func (m *TmpfsMount) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	path := utility.FromStringPtr(m.ContainerPath)
+	catcher.NewWhen(path == "", "must specify a container path")
+	catcher.ErrorfWhen(path != "" && !strings.HasPrefix(path, "/"), "container path '%s' must be an absolute path", path)
+	catcher.NewWhen(m.SizeMB == nil, "must specify a size")
+	catcher.NewWhen(m.SizeMB != nil && *m.SizeMB <= 0, "must have positive size")
+	for _, opt := range m.MountOptions {
+		name := strings.SplitN(opt, "=", 2)[0]
+		catcher.ErrorfWhen(!validTmpfsMountOptions[name], "unsupported mount option '%s'", opt)
+	}
+	return catcher.Resolve()
+}
+
+// hash returns the hash digest of the tmpfs mount.
+func (m *TmpfsMount) hash() string {
+	h := utility.NewSHA1Hash()
+	h.Add(utility.FromStringPtr(m.ContainerPath))
+	h.Add(strconv.Itoa(utility.FromIntPtr(m.SizeMB)))
+	opts := append([]string{}, m.MountOptions...)
+	sort.Strings(opts)
+	for _, opt := range opts {
+		h.Add(opt)
+	}
+	return h.Sum()
+}
+
+type hashableTmpfsMounts []TmpfsMount
+
+// newHashableTmpfsMounts returns a sorted slice of hashable tmpfs mounts.
+func newHashableTmpfsMounts(mounts []TmpfsMount) hashableTmpfsMounts {
+	htm := hashableTmpfsMounts(mounts)
+	sort.Sort(htm)
+	return htm
+}
+
+// Len returns the number of tmpfs mounts.
+func (htm hashableTmpfsMounts) Len() int {
+	return len(htm)
+}
+
+// Less returns whether or not the tmpfs mount at index i is ordered before the
+// tmpfs mount at index j by container path.
+func (htm hashableTmpfsMounts) Less(i, j int) bool {
+	return utility.FromStringPtr(htm[i].ContainerPath) < utility.FromStringPtr(htm[j].ContainerPath)
+}
+
+// Swap swaps the tmpfs mounts at indexes i and j.
+func (htm hashableTmpfsMounts) Swap(i, j int) {
+	htm[i], htm[j] = htm[j], htm[i]
+}
+
+// hash returns the hash digest of the tmpfs mounts.
+func (htm hashableTmpfsMounts) hash() string {
+	if !sort.IsSorted(htm) {
+		sort.Sort(htm)
+	}
+
+	h := utility.NewSHA1Hash()
+
+	for _, m := range htm {
+		h.Add(m.hash())
+	}
+
+	return h.Sum()
+}
+
 // ECSPodExecutionOptions represent options to configure how a pod is started.
 type ECSPodExecutionOptions struct {
 	// Cluster is the name of the cluster where the pod will run. If none is
@@ -1193,6 +2546,14 @@ type ECSPodExecutionOptions struct {
 	// PlacementOptions specify options that determine how a pod is assigned to
 	// a container instance.
 	PlacementOpts *ECSPodPlacementOptions
+	// SuppressPlacementStrategy indicates whether or not to omit the placement
+	// strategy when the pod runs with a capacity provider. A capacity
+	// provider with managed scaling places pods itself, so sending a
+	// placement strategy (such as the default binpack strategy) can conflict
+	// with its scaling decisions. Instance filters are still applied. This
+	// has no effect if no capacity provider is specified. By default, the
+	// placement strategy is always sent to ECS.
+	SuppressPlacementStrategy *bool
 	// AWSVPCOpts specify additional networking configuration when using
 	// NetworkModeAWSVPC.
 	AWSVPCOpts *AWSVPCOptions
@@ -1202,7 +2563,35 @@ type ECSPodExecutionOptions struct {
 	// defined. By default, this is false.
 	SupportsDebugMode *bool
 	// Tags are any tags to apply to the running pods.
-	Tags map[string]string
+	Tags Tags
+	// ProtectionPolicy specifies the pod's owned resources that are protected
+	// from deletion when the pod is deleted. By default, all owned resources
+	// are deleted with the pod.
+	ProtectionPolicy *ECSPodProtectionPolicy
+	// MaxLifetime is the maximum amount of time that the pod can run. If this
+	// is specified, the pod is tagged with the deadline by which it should be
+	// stopped when it is run. By default, pods have no deadline.
+	MaxLifetime *time.Duration
+	// DeferredSecretValues are the values of the pod definition's deferred
+	// secrets, keyed by secret name (see SecretOptions.Deferred). Each
+	// deferred secret must be given a value when the pod is created. Since
+	// these are secret values, they are never serialized.
+	DeferredSecretValues map[string]string `json:"-"`
+	// AssumeRoleOpts, if specified, make the pod creator assume the role for
+	// the AWS calls to create and run the pod, which makes it possible to
+	// create the pod in another account. The role is not retained by the
+	// returned pod, so later operations on the pod must assume the role using
+	// ContextWithAssumeRole. By default, the client's own credentials are
+	// used.
+	AssumeRoleOpts *AssumeRoleOptions
+	// Version is the version of the serialized format of the options. It is
+	// set to OptionsVersion when the options are serialized if it is not
+	// already set.
+	Version int
+	// Extensions are the serialized fields that were not recognized when the
+	// options were deserialized. They are preserved when the options are
+	// serialized again.
+	Extensions OptionsExtensions `json:"-"`
 }
 
 // NewECSPodExecutionOptions returns new uninitialized options to run a pod.
@@ -1223,6 +2612,24 @@ func (o *ECSPodExecutionOptions) SetCapacityProvider(provider string) *ECSPodExe
 	return o
 }
 
+// SetSuppressPlacementStrategy sets whether or not to omit the placement
+// strategy when the pod runs with a capacity provider.
+func (o *ECSPodExecutionOptions) SetSuppressPlacementStrategy(suppress bool) *ECSPodExecutionOptions {
+	o.SuppressPlacementStrategy = &suppress
+	return o
+}
+
+// UsesPlacementStrategy returns whether or not the placement strategy is sent
+// to ECS when the pod runs. The placement strategy is not used if it is
+// StrategyNone or if it is suppressed because the pod runs with a capacity
+// provider.
+func (o *ECSPodExecutionOptions) UsesPlacementStrategy() bool {
+	if o.CapacityProvider != nil && utility.FromBoolPtr(o.SuppressPlacementStrategy) {
+		return false
+	}
+	return o.PlacementOpts != nil && o.PlacementOpts.Strategy != nil && *o.PlacementOpts.Strategy != StrategyNone
+}
+
 // SetOverrideOptions sets the options that override the pod definition.
 func (o *ECSPodExecutionOptions) SetOverrideOptions(opts ECSOverridePodDefinitionOptions) *ECSPodExecutionOptions {
 	o.OverrideOpts = &opts
@@ -1259,18 +2666,41 @@ func (o *ECSPodExecutionOptions) SetTags(tags map[string]string) *ECSPodExecutio
 
 // AddTags adds new tags to the existing ones for the pod itself when it is run.
 func (o *ECSPodExecutionOptions) AddTags(tags map[string]string) *ECSPodExecutionOptions {
-	if o.Tags == nil {
-		o.Tags = map[string]string{}
-	}
-	for k, v := range tags {
-		o.Tags[k] = v
-	}
+	o.Tags = o.Tags.Add(tags)
+	return o
+}
+
+// GetTags returns the tags for the pod itself when it is run.
+func (o *ECSPodExecutionOptions) GetTags() Tags {
+	return o.Tags
+}
+
+// SetProtectionPolicy sets the policy that protects the pod's owned resources
+// from deletion.
+func (o *ECSPodExecutionOptions) SetProtectionPolicy(p ECSPodProtectionPolicy) *ECSPodExecutionOptions {
+	o.ProtectionPolicy = &p
+	return o
+}
+
+// SetAssumeRoleOptions sets the options to assume a role for the AWS calls to
+// create and run the pod.
+func (o *ECSPodExecutionOptions) SetAssumeRoleOptions(opts AssumeRoleOptions) *ECSPodExecutionOptions {
+	o.AssumeRoleOpts = &opts
 	return o
 }
 
 // Validate checks that the placement options are valid.
 func (o *ECSPodExecutionOptions) Validate() error {
 	catcher := grip.NewBasicCatcher()
+
+	// The placement strategy has to be checked before the placement options
+	// are validated, since validation sets a default strategy.
+	defaultStrategy := o.PlacementOpts == nil || o.PlacementOpts.Strategy == nil
+	suppressStrategy := o.CapacityProvider != nil && utility.FromBoolPtr(o.SuppressPlacementStrategy)
+	if suppressStrategy && !defaultStrategy {
+		catcher.ErrorfWhen(*o.PlacementOpts.Strategy != StrategyNone, "cannot specify placement strategy '%s' when the placement strategy is suppressed for capacity provider '%s'", *o.PlacementOpts.Strategy, *o.CapacityProvider)
+	}
+
 	if o.OverrideOpts != nil {
 		catcher.Wrap(o.OverrideOpts.Validate(), "invalid pod definition override options")
 	}
@@ -1280,6 +2710,17 @@ func (o *ECSPodExecutionOptions) Validate() error {
 	if o.AWSVPCOpts != nil {
 		catcher.Wrap(o.AWSVPCOpts.Validate(), "invalid AWSVPC options")
 	}
+	if o.ProtectionPolicy != nil {
+		catcher.Wrap(o.ProtectionPolicy.Validate(), "invalid protection policy")
+	}
+	if o.AssumeRoleOpts != nil {
+		catcher.Wrap(o.AssumeRoleOpts.Validate(), "invalid assume role options")
+	}
+	catcher.Wrap(ValidateECSTags(o.Tags), "invalid tags")
+	catcher.Wrap(o.Tags.validatePurpose(), "invalid purpose")
+	catcher.Wrap(o.Tags.validateStopDeadline(), "invalid stop deadline")
+	catcher.NewWhen(o.MaxLifetime != nil && *o.MaxLifetime <= 0, "must have positive max lifetime if specified")
+	catcher.Wrap(o.validateDeferredSecretValues(), "invalid deferred secret values")
 	if catcher.HasErrors() {
 		return catcher.Resolve()
 	}
@@ -1287,10 +2728,96 @@ func (o *ECSPodExecutionOptions) Validate() error {
 	if o.PlacementOpts == nil {
 		o.PlacementOpts = NewECSPodPlacementOptions().SetStrategy(StrategyBinpack).SetStrategyParameter(StrategyParamBinpackMemory)
 	}
+	if suppressStrategy {
+		o.PlacementOpts.Strategy = nil
+		o.PlacementOpts.StrategyParameter = nil
+		o.PlacementOpts.SetStrategy(StrategyNone)
+	} else if o.CapacityProvider != nil && defaultStrategy {
+		grip.Warning(message.Fields{
+			"message":           "using the default placement strategy with a capacity provider, which may conflict with the capacity provider's managed scaling; set the placement strategy explicitly or suppress it",
+			"capacity_provider": *o.CapacityProvider,
+			"strategy":          *o.PlacementOpts.Strategy,
+		})
+	}
 
 	return nil
 }
 
+// Hash returns the hash digest of the pod execution options. The order of
+// maps and of slices whose order does not matter (e.g. subnets) does not
+// affect the hash, so equivalent execution options have the same hash. This
+// can be combined with the pod definition's hash to identify a particular
+// pod configuration. Since validation sets defaults, the options should be
+// validated before they are hashed. The version and extensions are not
+// included in the hash.
+func (o *ECSPodExecutionOptions) Hash() string {
+	h := utility.NewSHA1Hash()
+
+	// Each field is labeled so that different fields with the same value
+	// produce different hashes.
+	if o.Cluster != nil {
+		h.Add("cluster")
+		h.Add(utility.FromStringPtr(o.Cluster))
+	}
+
+	if o.CapacityProvider != nil {
+		h.Add("capacity_provider")
+		h.Add(utility.FromStringPtr(o.CapacityProvider))
+	}
+
+	if o.OverrideOpts != nil {
+		h.Add("override_opts")
+		h.Add(o.OverrideOpts.hash())
+	}
+
+	if o.PlacementOpts != nil {
+		h.Add("placement_opts")
+		h.Add(o.PlacementOpts.hash())
+	}
+
+	if o.SuppressPlacementStrategy != nil {
+		h.Add("suppress_placement_strategy")
+		h.Add(strconv.FormatBool(*o.SuppressPlacementStrategy))
+	}
+
+	if o.AWSVPCOpts != nil {
+		h.Add("awsvpc_opts")
+		h.Add(o.AWSVPCOpts.hash())
+	}
+
+	if o.SupportsDebugMode != nil {
+		h.Add("supports_debug_mode")
+		h.Add(strconv.FormatBool(*o.SupportsDebugMode))
+	}
+
+	if len(o.Tags) != 0 {
+		h.Add("tags")
+		h.Add(newHashablePairs(o.Tags).hash())
+	}
+
+	if o.ProtectionPolicy != nil {
+		h.Add("protection_policy")
+		h.Add(o.ProtectionPolicy.hash())
+	}
+
+	if o.AssumeRoleOpts != nil {
+		h.Add("assume_role_opts")
+		h.Add(o.AssumeRoleOpts.hash())
+	}
+
+	if o.MaxLifetime != nil {
+		h.Add("max_lifetime")
+		h.Add(o.MaxLifetime.String())
+	}
+
+	if len(o.DeferredSecretValues) != 0 {
+		h.Add("deferred_secret_values")
+		h.Add(newHashablePairs(o.DeferredSecretValues).hash())
+	}
+
+	return h.Sum()
+}
+
 // MergeECSPodExecutionOptions merges all the given options to run an ECS pod.
 // Options are applied in the order that they're specified and conflicting
 // options are overwritten.
@@ -1310,6 +2837,10 @@ func MergeECSPodExecutionOptions(opts ...ECSPodExecutionOptions) ECSPodExecution
 			merged.PlacementOpts = opt.PlacementOpts
 		}
 
+		if opt.SuppressPlacementStrategy != nil {
+			merged.SuppressPlacementStrategy = opt.SuppressPlacementStrategy
+		}
+
 		if opt.AWSVPCOpts != nil {
 			merged.AWSVPCOpts = opt.AWSVPCOpts
 		}
@@ -1325,11 +2856,60 @@ func MergeECSPodExecutionOptions(opts ...ECSPodExecutionOptions) ECSPodExecution
 		if opt.OverrideOpts != nil {
 			merged.OverrideOpts = opt.OverrideOpts
 		}
+
+		if opt.ProtectionPolicy != nil {
+			merged.ProtectionPolicy = opt.ProtectionPolicy
+		}
+
+		if opt.AssumeRoleOpts != nil {
+			merged.AssumeRoleOpts = opt.AssumeRoleOpts
+		}
+
+		if opt.MaxLifetime != nil {
+			merged.MaxLifetime = opt.MaxLifetime
+		}
+
+		if opt.DeferredSecretValues != nil {
+			merged.DeferredSecretValues = opt.DeferredSecretValues
+		}
+
+		if opt.Version > merged.Version {
+			merged.Version = opt.Version
+		}
+
+		merged.Extensions = mergeOptionsExtensions(merged.Extensions, opt.Extensions)
 	}
 
 	return merged
 }
 
+// MarshalJSON marshals the pod execution options to JSON, including any
+// extensions that were preserved from deserialization.
+func (o ECSPodExecutionOptions) MarshalJSON() ([]byte, error) {
+	type options ECSPodExecutionOptions
+	known := options(o)
+	if known.Version == 0 {
+		known.Version = OptionsVersion
+	}
+	return marshalOptionsJSON(known, o.Extensions)
+}
+
+// UnmarshalJSON unmarshals the pod execution options from JSON. Any fields
+// that are not recognized are preserved as extensions. If the options were
+// serialized by a newer version of cocoa, it logs a warning.
+func (o *ECSPodExecutionOptions) UnmarshalJSON(b []byte) error {
+	type options ECSPodExecutionOptions
+	var known options
+	ext, err := unmarshalOptionsJSON(b, &known)
+	if err != nil {
+		return err
+	}
+	*o = ECSPodExecutionOptions(known)
+	o.Extensions = ext
+	warnOnNewerOptionsVersion("pod execution", o.Version, ext)
+	return nil
+}
+
 // Note for future maintainenace: many of fields in
 // ECSOverridePodDefinitionOptions are shared with the ECSPodDefinitionOptions
 // because the overridable fields are a subset of the options available when
@@ -1402,6 +2982,18 @@ func (o *ECSOverridePodDefinitionOptions) SetCPU(cpu int) *ECSOverridePodDefinit
 	return o
 }
 
+// SetMemory is the same as SetMemoryMB, but takes a typed amount of memory
+// (e.g. GiB(2)).
+func (o *ECSOverridePodDefinitionOptions) SetMemory(mem MemoryMB) *ECSOverridePodDefinitionOptions {
+	return o.SetMemoryMB(int(mem))
+}
+
+// SetCPUUnits is the same as SetCPU, but takes a typed amount of CPU (e.g.
+// VCPUs(0.5)).
+func (o *ECSOverridePodDefinitionOptions) SetCPUUnits(cpu CPUUnits) *ECSOverridePodDefinitionOptions {
+	return o.SetCPU(int(cpu))
+}
+
 // SetTaskRole sets the overriding task role that the pod can use.
 func (o *ECSOverridePodDefinitionOptions) SetTaskRole(role string) *ECSOverridePodDefinitionOptions {
 	o.TaskRole = &role
@@ -1414,17 +3006,168 @@ func (o *ECSOverridePodDefinitionOptions) SetExecutionRole(role string) *ECSOver
 	return o
 }
 
+// HasBindMounts returns whether or not any of the container overrides specify
+// bind mounts.
+func (o *ECSOverridePodDefinitionOptions) HasBindMounts() bool {
+	for _, def := range o.ContainerDefinitions {
+		if len(def.BindMounts) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSecretEnvironmentVariables returns whether or not any of the container
+// overrides specify secret environment variables.
+func (o *ECSOverridePodDefinitionOptions) HasSecretEnvironmentVariables() bool {
+	for _, def := range o.ContainerDefinitions {
+		if len(def.SecretEnvVars) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresNewDefinition returns whether or not any of the overrides can only be
+// applied by creating a new pod definition because ECS does not support
+// overriding them when starting a pod.
+func (o *ECSOverridePodDefinitionOptions) RequiresNewDefinition() bool {
+	return o.HasBindMounts() || o.HasSecretEnvironmentVariables()
+}
+
 // Validate checks that all specified override options are valid.
 func (o *ECSOverridePodDefinitionOptions) Validate() error {
 	catcher := grip.NewBasicCatcher()
 	catcher.NewWhen(o.MemoryMB != nil && *o.MemoryMB <= 0, "must have positive memory value if specified")
 	catcher.NewWhen(o.CPU != nil && *o.CPU <= 0, "must have positive CPU value if specified")
+	if o.MemoryMB != nil && *o.MemoryMB > 0 {
+		catcher.Add(MemoryMB(*o.MemoryMB).Validate())
+	}
+	if o.CPU != nil && *o.CPU > 0 {
+		catcher.Add(CPUUnits(*o.CPU).ValidatePod())
+	}
 	for i, def := range o.ContainerDefinitions {
 		catcher.Wrapf(o.ContainerDefinitions[i].Validate(), "container definition '%s'", utility.FromStringPtr(def.Name))
 	}
 	return catcher.Resolve()
 }
 
+// ValidateAgainst checks that the override options can be applied to a pod
+// with the given definition. It checks that every overridden container exists
+// in the pod definition, that the containers' resulting CPU and memory do not
+// exceed the pod's resulting CPU and memory limits, and that the overriding
+// environment variables do not have the same name as an environment variable
+// that references a secret. These mistakes would otherwise only be caught by
+// ECS when it runs the pod.
+func (o *ECSOverridePodDefinitionOptions) ValidateAgainst(defOpts ECSPodDefinitionOptions) error {
+	defOpts.applyResourceProfile()
+
+	podMemMB := defOpts.MemoryMB
+	if o.MemoryMB != nil {
+		podMemMB = o.MemoryMB
+	}
+	podCPU := defOpts.CPU
+	if o.CPU != nil {
+		podCPU = o.CPU
+	}
+
+	catcher := grip.NewBasicCatcher()
+
+	containerDefs := map[string]ECSContainerDefinition{}
+	for _, def := range defOpts.ContainerDefinitions {
+		containerDefs[utility.FromStringPtr(def.Name)] = def
+	}
+	overrideDefs := map[string]ECSOverrideContainerDefinition{}
+	for _, override := range o.ContainerDefinitions {
+		name := utility.FromStringPtr(override.Name)
+		if _, ok := containerDefs[name]; !ok {
+			catcher.Errorf("cannot override container definition '%s' because it does not exist in the pod definition", name)
+			continue
+		}
+		overrideDefs[name] = override
+	}
+
+	var totalContainerMemMB, totalContainerCPU int
+	for _, def := range defOpts.ContainerDefinitions {
+		name := utility.FromStringPtr(def.Name)
+		override, hasOverride := overrideDefs[name]
+
+		containerMemMB := utility.FromIntPtr(def.MemoryMB)
+		if override.MemoryMB != nil {
+			containerMemMB = *override.MemoryMB
+		}
+		containerCPU := utility.FromIntPtr(def.CPU)
+		if override.CPU != nil {
+			containerCPU = *override.CPU
+		}
+		totalContainerMemMB += containerMemMB
+		totalContainerCPU += containerCPU
+
+		if !hasOverride {
+			continue
+		}
+
+		secretEnvVarNames := map[string]bool{}
+		for _, ev := range def.EnvVars {
+			if ev.SecretOpts != nil {
+				secretEnvVarNames[utility.FromStringPtr(ev.Name)] = true
+			}
+		}
+		for _, ev := range override.SecretEnvVars {
+			secretEnvVarNames[utility.FromStringPtr(ev.Name)] = true
+		}
+		for _, ev := range override.EnvVars {
+			evName := utility.FromStringPtr(ev.Name)
+			catcher.ErrorfWhen(secretEnvVarNames[evName], "container definition '%s' overrides environment variable '%s', which has the same name as an environment variable that references a secret", name, evName)
+		}
+	}
+
+	if podMemMB != nil {
+		catcher.ErrorfWhen(*podMemMB < totalContainerMemMB, "total memory requested for the individual containers (%d MB) is greater than the memory available for the entire task (%d MB)", totalContainerMemMB, *podMemMB)
+	}
+	if podCPU != nil {
+		catcher.ErrorfWhen(*podCPU < totalContainerCPU, "total CPU requested for the individual containers (%d units) is greater than the CPU available for the entire task (%d units)", totalContainerCPU, *podCPU)
+	}
+
+	return catcher.Resolve()
+}
+
+// hash returns the hash digest of the pod definition override options.
+func (o *ECSOverridePodDefinitionOptions) hash() string {
+	h := utility.NewSHA1Hash()
+
+	if len(o.ContainerDefinitions) != 0 {
+		h.Add("container_definitions")
+		defHashes := make([]string, 0, len(o.ContainerDefinitions))
+		for _, def := range o.ContainerDefinitions {
+			defHashes = append(defHashes, def.hash())
+		}
+		h.Add(hashUnorderedStrings(defHashes))
+	}
+
+	if o.MemoryMB != nil {
+		h.Add("memory_mb")
+		h.Add(strconv.Itoa(*o.MemoryMB))
+	}
+
+	if o.CPU != nil {
+		h.Add("cpu")
+		h.Add(strconv.Itoa(*o.CPU))
+	}
+
+	if o.TaskRole != nil {
+		h.Add("task_role")
+		h.Add(*o.TaskRole)
+	}
+
+	if o.ExecutionRole != nil {
+		h.Add("execution_role")
+		h.Add(*o.ExecutionRole)
+	}
+
+	return h.Sum()
+}
+
 // ECSOverrideContainerDefinition are container-level options that can be
 // specified when starting a pod that override those in the pod's definition.
 // Each specified field will override the corresponding field in the pod
@@ -1444,6 +3187,18 @@ type ECSOverrideContainerDefinition struct {
 	// overridden; otherwise, the environment variable is appended to the
 	// existing ones.
 	EnvVars []KeyValue
+	// BindMounts are directories on the container instance to mount into the
+	// container in addition to the ones in the container definition. ECS does
+	// not support overriding mounts when starting a pod, so these can only be
+	// applied when the pod definition is created along with the pod.
+	BindMounts []BindMount
+	// SecretEnvVars are environment variables that reference existing secrets
+	// by ID to override for this container. If there is an existing
+	// environment variable with the same name, it is overridden; otherwise,
+	// the environment variable is appended to the existing ones. ECS does not
+	// support overriding secrets when starting a pod, so these can only be
+	// applied when the pod definition is created along with the pod.
+	SecretEnvVars []EnvironmentVariable
 }
 
 // NewECSOverrideContainerDefinition returns new uninitialized options to
@@ -1477,6 +3232,18 @@ func (d *ECSOverrideContainerDefinition) SetCPU(cpu int) *ECSOverrideContainerDe
 	return d
 }
 
+// SetMemory is the same as SetMemoryMB, but takes a typed amount of memory
+// (e.g. GiB(2)).
+func (d *ECSOverrideContainerDefinition) SetMemory(mem MemoryMB) *ECSOverrideContainerDefinition {
+	return d.SetMemoryMB(int(mem))
+}
+
+// SetCPUUnits is the same as SetCPU, but takes a typed amount of CPU (e.g.
+// VCPUs(0.5)).
+func (d *ECSOverrideContainerDefinition) SetCPUUnits(cpu CPUUnits) *ECSOverrideContainerDefinition {
+	return d.SetCPU(int(cpu))
+}
+
 // SetEnvironmentVariables sets the environment variables to override existing
 // ones or append new ones for the container.
 func (d *ECSOverrideContainerDefinition) SetEnvironmentVariables(envVars []KeyValue) *ECSOverrideContainerDefinition {
@@ -1491,6 +3258,34 @@ func (d *ECSOverrideContainerDefinition) AddEnvironmentVariables(envVars ...KeyV
 	return d
 }
 
+// SetBindMounts sets the additional bind mounts for the container. This
+// overwrites any existing bind mounts.
+func (d *ECSOverrideContainerDefinition) SetBindMounts(mounts []BindMount) *ECSOverrideContainerDefinition {
+	d.BindMounts = mounts
+	return d
+}
+
+// AddBindMounts adds additional bind mounts for the container.
+func (d *ECSOverrideContainerDefinition) AddBindMounts(mounts ...BindMount) *ECSOverrideContainerDefinition {
+	d.BindMounts = append(d.BindMounts, mounts...)
+	return d
+}
+
+// SetSecretEnvironmentVariables sets the environment variables referencing
+// existing secrets to override existing ones or append new ones for the
+// container. This overwrites any existing secret environment variables.
+func (d *ECSOverrideContainerDefinition) SetSecretEnvironmentVariables(envVars []EnvironmentVariable) *ECSOverrideContainerDefinition {
+	d.SecretEnvVars = envVars
+	return d
+}
+
+// AddSecretEnvironmentVariables adds environment variables referencing existing
+// secrets to override existing ones or append new ones for the container.
+func (d *ECSOverrideContainerDefinition) AddSecretEnvironmentVariables(envVars ...EnvironmentVariable) *ECSOverrideContainerDefinition {
+	d.SecretEnvVars = append(d.SecretEnvVars, envVars...)
+	return d
+}
+
 // Validate checks that all specified container definition overrides are valid.
 func (d *ECSOverrideContainerDefinition) Validate() error {
 	catcher := grip.NewBasicCatcher()
@@ -1498,12 +3293,89 @@ func (d *ECSOverrideContainerDefinition) Validate() error {
 	catcher.NewWhen(d.Name != nil && *d.Name == "", "must specify a non-empty container name")
 	catcher.NewWhen(d.MemoryMB != nil && *d.MemoryMB <= 0, "must have positive memory value if specified")
 	catcher.NewWhen(d.CPU != nil && *d.CPU <= 0, "must have positive CPU value if specified")
+	if d.MemoryMB != nil && *d.MemoryMB > 0 {
+		catcher.Add(MemoryMB(*d.MemoryMB).Validate())
+	}
+	if d.CPU != nil && *d.CPU > 0 {
+		catcher.Add(CPUUnits(*d.CPU).ValidateContainer())
+	}
 	for _, ev := range d.EnvVars {
 		catcher.Wrapf(ev.Validate(), "environment variable '%s'", utility.FromStringPtr(ev.Name))
 	}
+	for _, bm := range d.BindMounts {
+		catcher.Wrap(bm.Validate(), "invalid bind mount")
+	}
+	for _, ev := range d.SecretEnvVars {
+		name := utility.FromStringPtr(ev.Name)
+		catcher.Wrapf(ev.Validate(), "secret environment variable '%s'", name)
+		if ev.SecretOpts == nil {
+			catcher.Errorf("secret environment variable '%s' must reference a secret", name)
+			continue
+		}
+		catcher.ErrorfWhen(utility.FromStringPtr(ev.SecretOpts.ID) == "", "secret environment variable '%s' must reference an existing secret by ID", name)
+		catcher.ErrorfWhen(ev.SecretOpts.NewValue != nil, "secret environment variable '%s' cannot create a new secret", name)
+	}
 	return catcher.Resolve()
 }
 
+// hash returns the hash digest of the container definition override options.
+// The command is order-sensitive, but the order of the other settings does not
+// affect the hash.
+func (d *ECSOverrideContainerDefinition) hash() string {
+	h := utility.NewSHA1Hash()
+
+	if d.Name != nil {
+		h.Add("name")
+		h.Add(*d.Name)
+	}
+
+	if d.Command != nil {
+		h.Add("command")
+		for _, arg := range d.Command {
+			h.Add(arg)
+		}
+	}
+
+	if d.MemoryMB != nil {
+		h.Add("memory_mb")
+		h.Add(strconv.Itoa(*d.MemoryMB))
+	}
+
+	if d.CPU != nil {
+		h.Add("cpu")
+		h.Add(strconv.Itoa(*d.CPU))
+	}
+
+	if len(d.EnvVars) != 0 {
+		h.Add("env_vars")
+		envVarHashes := make([]string, 0, len(d.EnvVars))
+		for _, ev := range d.EnvVars {
+			envVarHashes = append(envVarHashes, pair{key: utility.FromStringPtr(ev.Name), value: utility.FromStringPtr(ev.Value)}.hash())
+		}
+		h.Add(hashUnorderedStrings(envVarHashes))
+	}
+
+	if len(d.BindMounts) != 0 {
+		h.Add("bind_mounts")
+		mountHashes := make([]string, 0, len(d.BindMounts))
+		for _, bm := range d.BindMounts {
+			mountHashes = append(mountHashes, bm.hash())
+		}
+		h.Add(hashUnorderedStrings(mountHashes))
+	}
+
+	if len(d.SecretEnvVars) != 0 {
+		h.Add("secret_env_vars")
+		envVarHashes := make([]string, 0, len(d.SecretEnvVars))
+		for _, ev := range d.SecretEnvVars {
+			envVarHashes = append(envVarHashes, ev.hash())
+		}
+		h.Add(hashUnorderedStrings(envVarHashes))
+	}
+
+	return h.Sum()
+}
+
 // ECSPodPlacementOptions represent options to control how an ECS pod is
 // assigned to a container instance.
 type ECSPodPlacementOptions struct {
@@ -1511,8 +3383,20 @@ type ECSPodPlacementOptions struct {
 	// same group can support additional placement configuration.
 	Group *string
 
+	// GroupNameTemplate, if specified, is a template for the group name with
+	// references to GroupMetadata of the form {key} (e.g. "{app}-{env}"). It
+	// is resolved into Group when the options are validated. If Group is also
+	// specified, it must match the resolved group name.
+	GroupNameTemplate *string
+
+	// GroupMetadata are the values used to resolve references in the
+	// GroupNameTemplate.
+	GroupMetadata map[string]string
+
 	// Strategy is the overall placement strategy. By default, it uses the
-	// binpack strategy.
+	// binpack strategy. If this is StrategyNone, no placement strategy is
+	// sent to ECS, so ECS uses its own default placement (e.g. the capacity
+	// provider's managed scaling).
 	Strategy *ECSPlacementStrategy
 
 	// StrategyParameter is the parameter that determines how the placement
@@ -1520,7 +3404,7 @@ type ECSPodPlacementOptions struct {
 	// strategy:
 	// If the strategy is spread, it defaults to "host".
 	// If the strategy is binpack, it defaults to "memory".
-	// If the strategy is random, this does not apply.
+	// If the strategy is random or none, this does not apply.
 	StrategyParameter *ECSStrategyParameter
 
 	// InstanceFilter is a set of query expressions that restrict the placement
@@ -1532,6 +3416,15 @@ type ECSPodPlacementOptions struct {
 	// pod. Docs:
 	// https://docs.aws.amazon.com/AmazonECS/latest/developerguide/cluster-query-language.html
 	InstanceFilters []string
+
+	// ImagePullBehavior, if specified, restricts the placement of the pod to
+	// container instances whose ECS agent pulls images with the given
+	// behavior. This only applies to pods running on EC2 container instances.
+	// ECS does not report the agent's image pull behavior, so container
+	// instances must advertise it with the ImagePullBehaviorAttribute custom
+	// attribute. By default, the pod can be placed on any container instance
+	// regardless of its image pull behavior.
+	ImagePullBehavior *ImagePullBehavior
 }
 
 // NewECSPodPlacementOptions creates new options to specify how an ECS pod
@@ -1546,6 +3439,44 @@ func (o *ECSPodPlacementOptions) SetGroup(group string) *ECSPodPlacementOptions
 	return o
 }
 
+// SetGroupNameTemplate sets the template for the name of the group that the pod
+// belongs to.
+func (o *ECSPodPlacementOptions) SetGroupNameTemplate(template string) *ECSPodPlacementOptions {
+	o.GroupNameTemplate = &template
+	return o
+}
+
+// SetGroupMetadata sets the values used to resolve the group name template.
+// This overwrites any existing metadata.
+func (o *ECSPodPlacementOptions) SetGroupMetadata(metadata map[string]string) *ECSPodPlacementOptions {
+	o.GroupMetadata = metadata
+	return o
+}
+
+// AddGroupMetadata adds new values used to resolve the group name template to
+// the existing ones.
+func (o *ECSPodPlacementOptions) AddGroupMetadata(metadata map[string]string) *ECSPodPlacementOptions {
+	if o.GroupMetadata == nil {
+		o.GroupMetadata = map[string]string{}
+	}
+	for k, v := range metadata {
+		o.GroupMetadata[k] = v
+	}
+	return o
+}
+
+// SetGroupFromPod sets the group to the same group as an existing pod, so that
+// the new pod joins the existing pod's group. Combined with
+// ConstraintDistinctInstance, this places the new pod on a different container
+// instance from every other pod in the group. If the existing pod does not
+// have a group, the group is left unchanged.
+func (o *ECSPodPlacementOptions) SetGroupFromPod(p ECSPod) *ECSPodPlacementOptions {
+	if group := p.Resources().Group; group != nil {
+		o.SetGroup(*group)
+	}
+	return o
+}
+
 // SetStrategy sets the strategy for placing the pod on a container instance.
 func (o *ECSPodPlacementOptions) SetStrategy(s ECSPlacementStrategy) *ECSPodPlacementOptions {
 	o.Strategy = &s
@@ -1573,12 +3504,30 @@ func (o *ECSPodPlacementOptions) AddInstanceFilters(filters ...string) *ECSPodPl
 	return o
 }
 
+// SetImagePullBehavior sets the image pull behavior that the container
+// instance must use for the pod to be placed on it.
+func (o *ECSPodPlacementOptions) SetImagePullBehavior(b ImagePullBehavior) *ECSPodPlacementOptions {
+	o.ImagePullBehavior = &b
+	return o
+}
+
 // Validate checks that the the strategy and its parameter to optimize are a
-// valid combination.
+// valid combination. If a group name template is given, it resolves the group
+// name from the template.
 func (o *ECSPodPlacementOptions) Validate() error {
 	catcher := grip.NewBasicCatcher()
 
 	catcher.ErrorfWhen(o.Group != nil && *o.Group == "", "cannot specify an empty group name")
+	var resolvedGroup string
+	if o.GroupNameTemplate != nil {
+		var err error
+		resolvedGroup, err = ResolveGroupNameTemplate(*o.GroupNameTemplate, o.GroupMetadata)
+		catcher.Wrap(err, "resolving group name template")
+		catcher.ErrorfWhen(err == nil && o.Group != nil && *o.Group != resolvedGroup, "group name '%s' does not match group name '%s' resolved from template", *o.Group, resolvedGroup)
+	}
+	if o.ImagePullBehavior != nil {
+		catcher.Wrap(o.ImagePullBehavior.Validate(), "invalid image pull behavior")
+	}
 
 	if o.Strategy != nil {
 		catcher.Add(o.Strategy.Validate())
@@ -1586,6 +3535,7 @@ func (o *ECSPodPlacementOptions) Validate() error {
 		if o.StrategyParameter != nil {
 			catcher.ErrorfWhen(*o.Strategy == StrategyBinpack && *o.StrategyParameter != StrategyParamBinpackMemory && *o.StrategyParameter != StrategyParamBinpackCPU, "strategy parameter cannot be '%s' when the strategy is '%s'", *o.StrategyParameter, *o.Strategy)
 			catcher.ErrorfWhen(*o.Strategy != StrategySpread && *o.StrategyParameter == StrategyParamSpreadHost, "strategy parameter cannot be '%s' when the strategy is not '%s'", *o.StrategyParameter, StrategySpread)
+			catcher.ErrorfWhen(*o.Strategy == StrategyNone, "cannot specify a strategy parameter when the strategy is '%s'", StrategyNone)
 		}
 	}
 
@@ -1593,6 +3543,10 @@ func (o *ECSPodPlacementOptions) Validate() error {
 		return catcher.Resolve()
 	}
 
+	if o.GroupNameTemplate != nil {
+		o.SetGroup(resolvedGroup)
+	}
+
 	if o.Strategy == nil {
 		strategy := StrategyBinpack
 		o.Strategy = &strategy
@@ -1610,6 +3564,48 @@ func (o *ECSPodPlacementOptions) Validate() error {
 	return nil
 }
 
+// hash returns the hash digest of the placement options.
+func (o *ECSPodPlacementOptions) hash() string {
+	h := utility.NewSHA1Hash()
+
+	if o.Group != nil {
+		h.Add("group")
+		h.Add(*o.Group)
+	}
+
+	if o.GroupNameTemplate != nil {
+		h.Add("group_name_template")
+		h.Add(*o.GroupNameTemplate)
+	}
+
+	if len(o.GroupMetadata) != 0 {
+		h.Add("group_metadata")
+		h.Add(newHashablePairs(o.GroupMetadata).hash())
+	}
+
+	if o.Strategy != nil {
+		h.Add("strategy")
+		h.Add(string(*o.Strategy))
+	}
+
+	if o.StrategyParameter != nil {
+		h.Add("strategy_parameter")
+		h.Add(*o.StrategyParameter)
+	}
+
+	if len(o.InstanceFilters) != 0 {
+		h.Add("instance_filters")
+		h.Add(hashUnorderedStrings(o.InstanceFilters))
+	}
+
+	if o.ImagePullBehavior != nil {
+		h.Add("image_pull_behavior")
+		h.Add(string(*o.ImagePullBehavior))
+	}
+
+	return h.Sum()
+}
+
 // ECSPlacementStrategy represents a placement strategy for ECS pods.
 type ECSPlacementStrategy string
 
@@ -1624,13 +3620,18 @@ const (
 	// container instance with the least amount of memory or CPU that will be
 	// sufficient for the pod's requirements if possible.
 	StrategyBinpack ECSPlacementStrategy = ECSPlacementStrategy(types.PlacementStrategyTypeBinpack)
+	// StrategyNone indicates that no placement strategy should be specified
+	// for the ECS pod, so ECS will place it using its own default behavior.
+	// This is useful to avoid overriding the placement of capacity providers
+	// with managed scaling.
+	StrategyNone ECSPlacementStrategy = "none"
 )
 
 // Validate checks that the ECS pod status is one of the recognized placement
 // strategies.
 func (s ECSPlacementStrategy) Validate() error {
 	switch s {
-	case StrategySpread, StrategyRandom, StrategyBinpack:
+	case StrategySpread, StrategyRandom, StrategyBinpack, StrategyNone:
 		return nil
 	default:
 		return errors.Errorf("unrecognized placement strategy '%s'", s)
@@ -1653,6 +3654,57 @@ const (
 	StrategyParamSpreadHost ECSStrategyParameter = "host"
 )
 
+// ImagePullBehavior represents the behavior that the ECS agent on an EC2
+// container instance uses to decide whether to pull a container's image or to
+// use a copy of it that is already cached on the instance. The behavior is
+// configured on the instance itself with the agent's ECS_IMAGE_PULL_BEHAVIOR
+// setting, so it cannot be set in the pod definition.
+//
+// The agent caches images by their image reference, so the cached behaviors
+// (ImagePullBehaviorOnce and ImagePullBehaviorPreferCached) only run the
+// intended image if the image reference is pinned by digest or uses a tag that
+// never changes. Repository credentials are only used when an image is
+// actually pulled, so a cached image can run even if its credentials are no
+// longer valid.
+type ImagePullBehavior string
+
+const (
+	// ImagePullBehaviorDefault indicates that the agent pulls the image
+	// remotely and falls back to the cached image if the pull fails.
+	ImagePullBehaviorDefault ImagePullBehavior = "default"
+	// ImagePullBehaviorAlways indicates that the agent always pulls the image
+	// remotely and fails if the pull fails.
+	ImagePullBehaviorAlways ImagePullBehavior = "always"
+	// ImagePullBehaviorOnce indicates that the agent only pulls the image
+	// remotely if it has not already been pulled by a previous task on the
+	// same container instance.
+	ImagePullBehaviorOnce ImagePullBehavior = "once"
+	// ImagePullBehaviorPreferCached indicates that the agent only pulls the
+	// image remotely if there is no cached image.
+	ImagePullBehaviorPreferCached ImagePullBehavior = "prefer-cached"
+)
+
+// ImagePullBehaviorAttribute is the name of the custom container instance
+// attribute that advertises the instance's image pull behavior.
+const ImagePullBehaviorAttribute = "cocoa.image-pull-behavior"
+
+// Validate checks that the image pull behavior is one of the recognized
+// behaviors.
+func (b ImagePullBehavior) Validate() error {
+	switch b {
+	case ImagePullBehaviorDefault, ImagePullBehaviorAlways, ImagePullBehaviorOnce, ImagePullBehaviorPreferCached:
+		return nil
+	default:
+		return errors.Errorf("unrecognized image pull behavior '%s'", b)
+	}
+}
+
+// UsesCachedImages returns whether or not the image pull behavior can run a
+// cached image even if the remote image has changed since it was cached.
+func (b ImagePullBehavior) UsesCachedImages() bool {
+	return b == ImagePullBehaviorOnce || b == ImagePullBehaviorPreferCached
+}
+
 const (
 	// ConstraintDistinctInstance is a container instance filter indicating that
 	// ECS should place all pods in the same group on different container
@@ -1709,6 +3761,23 @@ func (o *AWSVPCOptions) Validate() error {
 	return catcher.Resolve()
 }
 
+// hash returns the hash digest of the AWSVPC options.
+func (o *AWSVPCOptions) hash() string {
+	h := utility.NewSHA1Hash()
+
+	if len(o.Subnets) != 0 {
+		h.Add("subnets")
+		h.Add(hashUnorderedStrings(o.Subnets))
+	}
+
+	if len(o.SecurityGroups) != 0 {
+		h.Add("security_groups")
+		h.Add(hashUnorderedStrings(o.SecurityGroups))
+	}
+
+	return h.Sum()
+}
+
 // ECSNetworkMode represents possible kinds of networking configuration for a
 // pod in ECS.
 type ECSNetworkMode string
@@ -1,7 +1,9 @@
 package cocoa
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
@@ -41,6 +43,30 @@ func TestECSPodCreationOptions(t *testing.T) {
 		t.Run("FailsWithNoFieldsPopulated", func(t *testing.T) {
 			assert.Error(t, NewECSPodCreationOptions().Validate())
 		})
+		t.Run("SucceedsWithTmpfsMountsOnEC2CapacityProvider", func(t *testing.T) {
+			defOpts := getValidPodDefOpts()
+			defOpts.ContainerDefinitions[0].AddTmpfsMounts(*NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(64))
+			opts := NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*NewECSPodExecutionOptions().SetCapacityProvider("capacity_provider"))
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithTmpfsMountsOnFargate", func(t *testing.T) {
+			defOpts := getValidPodDefOpts()
+			defOpts.ContainerDefinitions[0].AddTmpfsMounts(*NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(64))
+			opts := NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*NewECSPodExecutionOptions().SetCapacityProvider("FARGATE"))
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithSharedMemorySizeOnFargateSpot", func(t *testing.T) {
+			defOpts := getValidPodDefOpts()
+			defOpts.ContainerDefinitions[0].SetSharedMemorySizeMB(256)
+			opts := NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*NewECSPodExecutionOptions().SetCapacityProvider("FARGATE_SPOT"))
+			assert.Error(t, opts.Validate())
+		})
 		t.Run("SucceedsWithValidFieldsPopulated", func(t *testing.T) {
 			containerDef := NewECSContainerDefinition().SetImage("image")
 			defOpts := NewECSPodDefinitionOptions().
@@ -73,6 +99,62 @@ func TestECSPodCreationOptions(t *testing.T) {
 				SetExecutionOptions(*execOpts)
 			assert.Error(t, opts.Validate())
 		})
+		t.Run("SucceedsWithBindMountOverrideForExistingContainer", func(t *testing.T) {
+			defOpts := getValidPodDefOpts()
+			defOpts.ContainerDefinitions[0].SetName("container")
+			overrideDef := NewECSOverrideContainerDefinition().
+				SetName("container").
+				AddBindMounts(*NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data"))
+			execOpts := NewECSPodExecutionOptions().
+				SetOverrideOptions(*NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*overrideDef))
+			opts := NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*execOpts)
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithBindMountOverrideForNonexistentContainer", func(t *testing.T) {
+			defOpts := getValidPodDefOpts()
+			defOpts.ContainerDefinitions[0].SetName("container")
+			overrideDef := NewECSOverrideContainerDefinition().
+				SetName("foo").
+				AddBindMounts(*NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data"))
+			execOpts := NewECSPodExecutionOptions().
+				SetOverrideOptions(*NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*overrideDef))
+			opts := NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*execOpts)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("SucceedsWithSecretEnvVarOverrideForExistingContainer", func(t *testing.T) {
+			defOpts := getValidPodDefOpts()
+			defOpts.ContainerDefinitions[0].SetName("container")
+			overrideDef := NewECSOverrideContainerDefinition().
+				SetName("container").
+				AddSecretEnvironmentVariables(*NewEnvironmentVariable().
+					SetName("SECRET").
+					SetSecretOptions(*NewSecretOptions().SetID("secret_id")))
+			execOpts := NewECSPodExecutionOptions().
+				SetOverrideOptions(*NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*overrideDef))
+			opts := NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*execOpts)
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithSecretEnvVarOverrideForNonexistentContainer", func(t *testing.T) {
+			defOpts := getValidPodDefOpts()
+			defOpts.ContainerDefinitions[0].SetName("container")
+			overrideDef := NewECSOverrideContainerDefinition().
+				SetName("foo").
+				AddSecretEnvironmentVariables(*NewEnvironmentVariable().
+					SetName("SECRET").
+					SetSecretOptions(*NewSecretOptions().SetID("secret_id")))
+			execOpts := NewECSPodExecutionOptions().
+				SetOverrideOptions(*NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*overrideDef))
+			opts := NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*execOpts)
+			assert.Error(t, opts.Validate())
+		})
 		t.Run("AWSVPCOptionsWithNetworkModeAWSVPCIsValid", func(t *testing.T) {
 			defOpts := getValidPodDefOpts().SetNetworkMode(NetworkModeAWSVPC)
 			awsvpcOpts := NewAWSVPCOptions().AddSubnets("subnet-12345")
@@ -238,6 +320,40 @@ func TestECSPodCreationOptions(t *testing.T) {
 			opts := NewECSPodCreationOptions().SetDefinitionOptions(*defOpts)
 			assert.NoError(t, opts.Validate())
 		})
+		t.Run("SucceedsWithUniquePortMappingNames", func(t *testing.T) {
+			containerDef0 := NewECSContainerDefinition().
+				SetName("container0").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(1337).SetName("http"))
+			containerDef1 := NewECSContainerDefinition().
+				SetName("container1").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(8125).SetProtocol(PortProtocolUDP).SetName("statsd"))
+			defOpts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef0, *containerDef1).
+				SetMemoryMB(128).
+				SetCPU(128).
+				SetNetworkMode(NetworkModeBridge)
+			opts := NewECSPodCreationOptions().SetDefinitionOptions(*defOpts)
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithDuplicatePortMappingNames", func(t *testing.T) {
+			containerDef0 := NewECSContainerDefinition().
+				SetName("container0").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(1337).SetName("http"))
+			containerDef1 := NewECSContainerDefinition().
+				SetName("container1").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(1338).SetName("http"))
+			defOpts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef0, *containerDef1).
+				SetMemoryMB(128).
+				SetCPU(128).
+				SetNetworkMode(NetworkModeBridge)
+			opts := NewECSPodCreationOptions().SetDefinitionOptions(*defOpts)
+			assert.Error(t, opts.Validate())
+		})
 	})
 }
 
@@ -283,6 +399,14 @@ func TestECSPodDefinition(t *testing.T) {
 		opts := NewECSPodDefinitionOptions().SetCPU(cpu)
 		assert.Equal(t, cpu, utility.FromIntPtr(opts.CPU))
 	})
+	t.Run("SetMemory", func(t *testing.T) {
+		opts := NewECSPodDefinitionOptions().SetMemory(GiB(2))
+		assert.Equal(t, 2048, utility.FromIntPtr(opts.MemoryMB))
+	})
+	t.Run("SetCPUUnits", func(t *testing.T) {
+		opts := NewECSPodDefinitionOptions().SetCPUUnits(VCPUs(0.5))
+		assert.Equal(t, 512, utility.FromIntPtr(opts.CPU))
+	})
 	t.Run("SetNetworkMode", func(t *testing.T) {
 		mode := NetworkModeAWSVPC
 		opts := NewECSPodDefinitionOptions().SetNetworkMode(mode)
@@ -312,7 +436,7 @@ func TestECSPodDefinition(t *testing.T) {
 		assert.Empty(t, opts.Tags)
 	})
 	t.Run("AddTags", func(t *testing.T) {
-		tags := map[string]string{"key0": "val0", "key1": "val1"}
+		tags := Tags{"key0": "val0", "key1": "val1"}
 		opts := NewECSPodDefinitionOptions().AddTags(tags)
 		assert.Equal(t, tags, opts.Tags)
 		opts.AddTags(map[string]string{})
@@ -327,6 +451,204 @@ func TestECSPodDefinition(t *testing.T) {
 				SetCPU(128)
 			assert.NoError(t, opts.Validate())
 		})
+		t.Run("GeneratesNamesWithNameGenerator", func(t *testing.T) {
+			makeOpts := func() *ECSPodDefinitionOptions {
+				return NewECSPodDefinitionOptions().
+					AddContainerDefinitions(
+						*NewECSContainerDefinition().SetImage("image0"),
+						*NewECSContainerDefinition().SetImage("image1").SetName("named"),
+					).
+					SetMemoryMB(128).
+					SetCPU(128).
+					SetNameGenerator(NewSequentialNameGenerator("generated"))
+			}
+			opts := makeOpts()
+			require.NoError(t, opts.Validate())
+			assert.Equal(t, "generated0", utility.FromStringPtr(opts.ContainerDefinitions[0].Name))
+			assert.Equal(t, "named", utility.FromStringPtr(opts.ContainerDefinitions[1].Name))
+			assert.Equal(t, "generated1", utility.FromStringPtr(opts.Name))
+
+			other := makeOpts()
+			require.NoError(t, other.Validate())
+			assert.Equal(t, opts.Hash(), other.Hash(), "generated names should be deterministic")
+		})
+		t.Run("NameGeneratorIsNotSerialized", func(t *testing.T) {
+			opts := NewECSPodDefinitionOptions().SetName("name").SetNameGenerator(NewSequentialNameGenerator("generated"))
+			b, err := json.Marshal(opts)
+			require.NoError(t, err)
+			assert.NotContains(t, string(b), "NameGenerator")
+		})
+		t.Run("SucceedsWithContainerHostnameAndExtraHostsInBridgeNetworkMode", func(t *testing.T) {
+			containerDef := NewECSContainerDefinition().
+				SetImage("image").
+				SetHostname("hostname").
+				AddExtraHosts(*NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1"))
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef).
+				SetNetworkMode(NetworkModeBridge).
+				SetMemoryMB(128).
+				SetCPU(128)
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithContainerHostnameInAWSVPCNetworkMode", func(t *testing.T) {
+			containerDef := NewECSContainerDefinition().
+				SetImage("image").
+				SetHostname("hostname")
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef).
+				SetNetworkMode(NetworkModeAWSVPC).
+				SetMemoryMB(128).
+				SetCPU(128)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithContainerExtraHostsInAWSVPCNetworkMode", func(t *testing.T) {
+			containerDef := NewECSContainerDefinition().
+				SetImage("image").
+				AddExtraHosts(*NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1"))
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef).
+				SetNetworkMode(NetworkModeAWSVPC).
+				SetMemoryMB(128).
+				SetCPU(128)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithContainerExtraHostsInNoneNetworkMode", func(t *testing.T) {
+			containerDef := NewECSContainerDefinition().
+				SetImage("image").
+				AddExtraHosts(*NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1"))
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef).
+				SetNetworkMode(NetworkModeNone).
+				SetMemoryMB(128).
+				SetCPU(128)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("NetworkModeErrorsIncludeContainerName", func(t *testing.T) {
+			valid := NewECSContainerDefinition().
+				SetName("valid").
+				SetImage("image")
+			invalid := NewECSContainerDefinition().
+				SetName("invalid").
+				SetImage("image").
+				SetHostname("hostname")
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*valid, *invalid).
+				SetNetworkMode(NetworkModeAWSVPC).
+				SetMemoryMB(128).
+				SetCPU(128)
+			err := opts.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "container definition 'invalid'")
+			assert.NotContains(t, err.Error(), "container definition 'valid'")
+		})
+		t.Run("FailsWithConflictingContainerPortsInAWSVPCNetworkMode", func(t *testing.T) {
+			containerDef0 := NewECSContainerDefinition().
+				SetName("container0").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(8080))
+			containerDef1 := NewECSContainerDefinition().
+				SetName("container1").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(8080))
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef0, *containerDef1).
+				SetNetworkMode(NetworkModeAWSVPC).
+				SetMemoryMB(128).
+				SetCPU(128)
+			err := opts.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "container0")
+			assert.Contains(t, err.Error(), "container1")
+		})
+		t.Run("FailsWithConflictingHostPortsInHostNetworkMode", func(t *testing.T) {
+			containerDef0 := NewECSContainerDefinition().
+				SetName("container0").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(8080))
+			containerDef1 := NewECSContainerDefinition().
+				SetName("container1").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(8080).SetHostPort(8080))
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef0, *containerDef1).
+				SetNetworkMode(NetworkModeHost).
+				SetMemoryMB(128).
+				SetCPU(128)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithConflictingExplicitHostPortsInBridgeNetworkMode", func(t *testing.T) {
+			containerDef0 := NewECSContainerDefinition().
+				SetName("container0").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(8080).SetHostPort(9000))
+			containerDef1 := NewECSContainerDefinition().
+				SetName("container1").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(8081).SetHostPort(9000))
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef0, *containerDef1).
+				SetNetworkMode(NetworkModeBridge).
+				SetMemoryMB(128).
+				SetCPU(128)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("SucceedsWithSameContainerPortAndDynamicHostPortsInBridgeNetworkMode", func(t *testing.T) {
+			containerDef0 := NewECSContainerDefinition().
+				SetName("container0").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(8080))
+			containerDef1 := NewECSContainerDefinition().
+				SetName("container1").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(8080))
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef0, *containerDef1).
+				SetNetworkMode(NetworkModeBridge).
+				SetMemoryMB(128).
+				SetCPU(128)
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("SucceedsWithSamePortForDifferentProtocolsInAWSVPCNetworkMode", func(t *testing.T) {
+			containerDef0 := NewECSContainerDefinition().
+				SetName("container0").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(8125))
+			containerDef1 := NewECSContainerDefinition().
+				SetName("container1").
+				SetImage("image").
+				AddPortMappings(*NewPortMapping().SetContainerPort(8125).SetProtocol(PortProtocolUDP))
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef0, *containerDef1).
+				SetNetworkMode(NetworkModeAWSVPC).
+				SetMemoryMB(128).
+				SetCPU(128)
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("SucceedsWithVolumesFromContainerInPod", func(t *testing.T) {
+			data := NewECSContainerDefinition().
+				SetName("data").
+				SetImage("image")
+			sidecar := NewECSContainerDefinition().
+				SetName("sidecar").
+				SetImage("image").
+				AddVolumesFrom(*NewVolumeFrom().SetSourceContainer("data").SetReadOnly(true))
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*data, *sidecar).
+				SetMemoryMB(128).
+				SetCPU(128)
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithVolumesFromContainerNotInPod", func(t *testing.T) {
+			sidecar := NewECSContainerDefinition().
+				SetName("sidecar").
+				SetImage("image").
+				AddVolumesFrom(*NewVolumeFrom().SetSourceContainer("data"))
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*sidecar).
+				SetMemoryMB(128).
+				SetCPU(128)
+			assert.Error(t, opts.Validate())
+		})
 		t.Run("FailsWithoutContainerDefinition", func(t *testing.T) {
 			opts := NewECSPodDefinitionOptions().
 				SetMemoryMB(128).
@@ -390,6 +712,30 @@ func TestECSPodDefinition(t *testing.T) {
 				SetCPU(0)
 			assert.Error(t, opts.Validate())
 		})
+		t.Run("FailsWithCPUInVCPUsRatherThanCPUUnits", func(t *testing.T) {
+			containerDef := NewECSContainerDefinition().SetImage("image")
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef).
+				SetMemoryMB(128).
+				SetCPU(2)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithCPUAboveECSMaximum", func(t *testing.T) {
+			containerDef := NewECSContainerDefinition().SetImage("image")
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef).
+				SetMemoryMB(128).
+				SetCPUUnits(MaxCPU + 1)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithMemoryInGiBRatherThanMB", func(t *testing.T) {
+			containerDef := NewECSContainerDefinition().SetImage("image")
+			opts := NewECSPodDefinitionOptions().
+				AddContainerDefinitions(*containerDef).
+				SetMemoryMB(2).
+				SetCPU(128)
+			assert.Error(t, opts.Validate())
+		})
 		t.Run("FailsWithoutMemory", func(t *testing.T) {
 			containerDef := NewECSContainerDefinition().SetImage("image")
 			opts := NewECSPodDefinitionOptions().
@@ -468,6 +814,40 @@ func TestECSPodDefinition(t *testing.T) {
 			})
 			assert.NotEqual(t, baseHash, opts.Hash(), "tags should affect hash")
 		})
+		t.Run("DoesNotChangeForExcludedTags", func(t *testing.T) {
+			opts := getValidPodDefOpts().SetTags(map[string]string{"key": "value"})
+			hashOpts := *NewHashOptions().SetExcludeTagKeys([]string{"created_at", "job_id"})
+			h0 := opts.Hash(hashOpts)
+
+			opts.AddTags(map[string]string{"created_at": "2023-01-01T00:00:00Z", "job_id": "job0"})
+			assert.Equal(t, h0, opts.Hash(hashOpts), "excluded tags should not affect hash")
+			assert.NotEqual(t, h0, opts.Hash(), "excluded tags should affect hash without hash options")
+
+			opts.AddTags(map[string]string{"key": "new_value"})
+			assert.NotEqual(t, h0, opts.Hash(hashOpts), "tags that are not excluded should affect hash")
+		})
+		t.Run("ReturnsSameValueWhenAllTagsAreExcluded", func(t *testing.T) {
+			opts := getValidPodDefOpts().SetTags(map[string]string{"job_id": "job0"})
+			assert.Equal(t, baseHash, opts.Hash(*NewHashOptions().AddExcludeTagKeys("job_id")))
+			assert.Equal(t, "job0", opts.Tags["job_id"], "hashing should not modify tags")
+		})
+		t.Run("DoesNotChangeForExcludedName", func(t *testing.T) {
+			hashOpts := *NewHashOptions().SetExcludeName(true)
+			h0 := getValidPodDefOpts().Hash(hashOpts)
+			opts := getValidPodDefOpts().SetName("new_name")
+			assert.Equal(t, h0, opts.Hash(hashOpts), "excluded name should not affect hash")
+			assert.NotEqual(t, h0, baseHash)
+		})
+		t.Run("ReturnsSameValueWithEmptyHashOptions", func(t *testing.T) {
+			opts := getValidPodDefOpts().SetTags(map[string]string{"key": "value"})
+			assert.Equal(t, opts.Hash(), opts.Hash(*NewHashOptions()))
+			assert.Equal(t, opts.Hash(), opts.Hash(*NewHashOptions().SetExcludeName(false)))
+		})
+		t.Run("CombinesMultipleHashOptions", func(t *testing.T) {
+			opts := getValidPodDefOpts().SetTags(map[string]string{"created_at": "now", "job_id": "job0"})
+			combined := opts.Hash(*NewHashOptions().AddExcludeTagKeys("created_at"), *NewHashOptions().AddExcludeTagKeys("job_id").SetExcludeName(true))
+			assert.Equal(t, getValidPodDefOpts().Hash(*NewHashOptions().SetExcludeName(true)), combined)
+		})
 		t.Run("ReturnsSameValueForSameUnorderedTags", func(t *testing.T) {
 			opts := getValidPodDefOpts()
 			for i := 0; i < 10; i++ {
@@ -523,6 +903,113 @@ func TestECSPodDefinition(t *testing.T) {
 			opts.ContainerDefinitions[0].SetWorkingDir("/var/run")
 			assert.NotEqual(t, baseHash, opts.Hash(), "container working directory should affect hash")
 		})
+		t.Run("ChangesForInteractiveContainer", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].SetInteractive(true)
+			assert.NotEqual(t, baseHash, opts.Hash(), "container interactivity should affect hash")
+		})
+		t.Run("ChangesForContainerPseudoTerminal", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].SetInteractive(true)
+			h0 := opts.Hash()
+			opts.ContainerDefinitions[0].SetPseudoTerminal(true)
+			assert.NotEqual(t, h0, opts.Hash(), "container pseudo-terminal should affect hash")
+		})
+		t.Run("ChangesForDifferentContainerHostname", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].SetHostname("hostname")
+			assert.NotEqual(t, baseHash, opts.Hash(), "container hostname should affect hash")
+		})
+		t.Run("ChangesForDifferentContainerUser", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].SetUser("1000:1000")
+			assert.NotEqual(t, baseHash, opts.Hash(), "container user should affect hash")
+		})
+		t.Run("ChangesForSameValueAsHostnameOrUser", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].SetHostname("name")
+			h0 := opts.Hash()
+
+			opts.ContainerDefinitions[0].Hostname = nil
+			opts.ContainerDefinitions[0].SetUser("name")
+			assert.NotEqual(t, h0, opts.Hash(), "hostname and user with the same value should have different hashes")
+		})
+		t.Run("ChangesForDifferentContainerExtraHosts", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].AddExtraHosts(*NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1"))
+			h0 := opts.Hash()
+			assert.NotEqual(t, baseHash, h0, "container extra hosts should affect hash")
+
+			opts.ContainerDefinitions[0].ExtraHosts[0].SetIPAddress("10.0.0.2")
+			assert.NotEqual(t, h0, opts.Hash(), "extra host IP address should affect hash")
+		})
+		t.Run("DoesNotChangeForDifferentContainerExtraHostsOrder", func(t *testing.T) {
+			h0 := *NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1")
+			h1 := *NewHostEntry().SetHostname("cache").SetIPAddress("10.0.0.2")
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].SetExtraHosts([]HostEntry{h0, h1})
+			hash0 := opts.Hash()
+
+			opts.ContainerDefinitions[0].SetExtraHosts([]HostEntry{h1, h0})
+			assert.Equal(t, hash0, opts.Hash(), "order of extra hosts should not affect hash")
+		})
+		t.Run("ChangesForDifferentContainerVolumesFrom", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].AddVolumesFrom(*NewVolumeFrom().SetSourceContainer("data"))
+			h0 := opts.Hash()
+			assert.NotEqual(t, baseHash, h0, "container volumes from other containers should affect hash")
+
+			opts.ContainerDefinitions[0].VolumesFrom[0].SetReadOnly(true)
+			assert.NotEqual(t, h0, opts.Hash(), "read-only volumes from other containers should affect hash")
+		})
+		t.Run("DoesNotChangeForDifferentContainerVolumesFromOrder", func(t *testing.T) {
+			vf0 := *NewVolumeFrom().SetSourceContainer("data")
+			vf1 := *NewVolumeFrom().SetSourceContainer("cache")
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].SetVolumesFrom([]VolumeFrom{vf0, vf1})
+			h0 := opts.Hash()
+
+			opts.ContainerDefinitions[0].SetVolumesFrom([]VolumeFrom{vf1, vf0})
+			assert.Equal(t, h0, opts.Hash(), "order of volumes from other containers should not affect hash")
+		})
+		t.Run("ChangesForDifferentContainerTmpfsMounts", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].AddTmpfsMounts(*NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(64))
+			h0 := opts.Hash()
+			assert.NotEqual(t, baseHash, h0, "container tmpfs mounts should affect hash")
+
+			opts.ContainerDefinitions[0].TmpfsMounts[0].SetSizeMB(128)
+			h1 := opts.Hash()
+			assert.NotEqual(t, h0, h1, "tmpfs mount size should affect hash")
+
+			opts.ContainerDefinitions[0].TmpfsMounts[0].AddMountOptions("noexec")
+			assert.NotEqual(t, h1, opts.Hash(), "tmpfs mount options should affect hash")
+		})
+		t.Run("DoesNotChangeForDifferentContainerTmpfsMountsOrder", func(t *testing.T) {
+			m0 := *NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(64).SetMountOptions([]string{"noexec", "nosuid"})
+			m1 := *NewTmpfsMount().SetContainerPath("/run").SetSizeMB(32)
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].SetTmpfsMounts([]TmpfsMount{m0, m1})
+			h0 := opts.Hash()
+
+			m0.SetMountOptions([]string{"nosuid", "noexec"})
+			opts.ContainerDefinitions[0].SetTmpfsMounts([]TmpfsMount{m1, m0})
+			assert.Equal(t, h0, opts.Hash(), "order of tmpfs mounts and their mount options should not affect hash")
+		})
+		t.Run("ChangesForDifferentContainerSharedMemorySize", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].SetSharedMemorySizeMB(256)
+			h0 := opts.Hash()
+			assert.NotEqual(t, baseHash, h0, "container shared memory size should affect hash")
+
+			opts.ContainerDefinitions[0].SetSharedMemorySizeMB(512)
+			assert.NotEqual(t, h0, opts.Hash(), "different shared memory size should affect hash")
+		})
+		t.Run("DoesNotChangeForExplicitlyNonInteractiveContainer", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].SetInteractive(false).SetPseudoTerminal(false)
+			assert.Equal(t, baseHash, opts.Hash(), "explicitly disabling interactivity should not affect hash")
+		})
 		t.Run("ChangesForDifferentContainerMemoryMB", func(t *testing.T) {
 			opts := getValidPodDefOpts()
 			opts.ContainerDefinitions[0].SetMemoryMB(64)
@@ -668,6 +1155,18 @@ func TestECSPodDefinition(t *testing.T) {
 
 			assert.NotEqual(t, h0, h1, "container repo creds name should affect hash")
 		})
+		t.Run("ChangesForDifferentRepoCredsCreateIfMissing", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+
+			creds := NewRepositoryCredentials().SetName("name")
+			opts.ContainerDefinitions[0].SetRepositoryCredentials(*creds)
+			h0 := opts.Hash()
+
+			opts.ContainerDefinitions[0].SetRepositoryCredentials(*creds.SetCreateIfMissing(true))
+			h1 := opts.Hash()
+
+			assert.NotEqual(t, h0, h1, "container repo creds create if missing should affect hash")
+		})
 		t.Run("ChangesForNewRepoCredsUsername", func(t *testing.T) {
 			opts := getValidPodDefOpts()
 
@@ -712,6 +1211,27 @@ func TestECSPodDefinition(t *testing.T) {
 			opts.ContainerDefinitions[0].SetRepositoryCredentials(*creds)
 			assert.NotEqual(t, baseHash, opts.Hash(), "container repo creds should affect hash")
 		})
+		t.Run("ChangesForDifferentBindMounts", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			opts.ContainerDefinitions[0].AddBindMounts(*NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data"))
+			h0 := opts.Hash()
+			assert.NotEqual(t, baseHash, h0, "container bind mounts should affect hash")
+
+			opts.ContainerDefinitions[0].BindMounts[0].SetReadOnly(true)
+			h1 := opts.Hash()
+			assert.NotEqual(t, h0, h1, "container bind mount read-only setting should affect hash")
+		})
+		t.Run("ReturnsSameValueForDifferentBindMountOrder", func(t *testing.T) {
+			bm0 := NewBindMount().SetSourcePath("/scratch0").SetContainerPath("/data0")
+			bm1 := NewBindMount().SetSourcePath("/scratch1").SetContainerPath("/data1")
+
+			opts0 := getValidPodDefOpts()
+			opts0.ContainerDefinitions[0].AddBindMounts(*bm0, *bm1)
+			opts1 := getValidPodDefOpts()
+			opts1.ContainerDefinitions[0].AddBindMounts(*bm1, *bm0)
+
+			assert.Equal(t, opts0.Hash(), opts1.Hash(), "bind mount order should not affect hash")
+		})
 		t.Run("ChangesForDifferentLogConfigurationDriver", func(t *testing.T) {
 			opts := getValidPodDefOpts()
 
@@ -788,28 +1308,67 @@ func TestECSPodDefinition(t *testing.T) {
 
 			assert.NotEqual(t, h0, h1, "host port mapping should affect hash")
 		})
-		t.Run("ReturnsSameValueForDifferentPortMappingOrder", func(t *testing.T) {
+		t.Run("ChangesForDifferentPortMappingProtocol", func(t *testing.T) {
 			opts := getValidPodDefOpts()
-			pm0 := NewPortMapping().SetContainerPort(1234).SetHostPort(5678)
-			pm1 := NewPortMapping().SetContainerPort(1337).SetHostPort(9001)
 
-			opts.ContainerDefinitions[0].SetPortMappings([]PortMapping{*pm0, *pm1})
+			pm := NewPortMapping().SetContainerPort(12345)
+			opts.ContainerDefinitions[0].SetPortMappings([]PortMapping{*pm})
 			h0 := opts.Hash()
 
-			opts.ContainerDefinitions[0].SetPortMappings([]PortMapping{*pm1, *pm0})
+			pm.SetProtocol(PortProtocolUDP)
+			opts.ContainerDefinitions[0].SetPortMappings([]PortMapping{*pm})
 			h1 := opts.Hash()
 
-			assert.Equal(t, h0, h1, "order of port mappings should not affect hash")
+			assert.NotEqual(t, h0, h1, "port mapping protocol should affect hash")
 		})
-	})
-}
+		t.Run("ChangesForDifferentPortMappingName", func(t *testing.T) {
+			opts := getValidPodDefOpts()
 
-func TestECSNetworkMode(t *testing.T) {
-	t.Run("Validate", func(t *testing.T) {
-		for _, m := range []ECSNetworkMode{
-			NetworkModeNone,
-			NetworkModeAWSVPC,
-			NetworkModeBridge,
+			pm := NewPortMapping().SetContainerPort(12345)
+			opts.ContainerDefinitions[0].SetPortMappings([]PortMapping{*pm})
+			h0 := opts.Hash()
+
+			pm.SetName("name")
+			opts.ContainerDefinitions[0].SetPortMappings([]PortMapping{*pm})
+			h1 := opts.Hash()
+
+			assert.NotEqual(t, h0, h1, "port mapping name should affect hash")
+		})
+		t.Run("ReturnsSameValueForDifferentOrderOfPortMappingsWithSamePort", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			pm0 := NewPortMapping().SetContainerPort(53).SetProtocol(PortProtocolTCP)
+			pm1 := NewPortMapping().SetContainerPort(53).SetProtocol(PortProtocolUDP)
+
+			opts.ContainerDefinitions[0].SetPortMappings([]PortMapping{*pm0, *pm1})
+			h0 := opts.Hash()
+
+			opts.ContainerDefinitions[0].SetPortMappings([]PortMapping{*pm1, *pm0})
+			h1 := opts.Hash()
+
+			assert.Equal(t, h0, h1, "order of port mappings should not affect hash")
+		})
+		t.Run("ReturnsSameValueForDifferentPortMappingOrder", func(t *testing.T) {
+			opts := getValidPodDefOpts()
+			pm0 := NewPortMapping().SetContainerPort(1234).SetHostPort(5678)
+			pm1 := NewPortMapping().SetContainerPort(1337).SetHostPort(9001)
+
+			opts.ContainerDefinitions[0].SetPortMappings([]PortMapping{*pm0, *pm1})
+			h0 := opts.Hash()
+
+			opts.ContainerDefinitions[0].SetPortMappings([]PortMapping{*pm1, *pm0})
+			h1 := opts.Hash()
+
+			assert.Equal(t, h0, h1, "order of port mappings should not affect hash")
+		})
+	})
+}
+
+func TestECSNetworkMode(t *testing.T) {
+	t.Run("Validate", func(t *testing.T) {
+		for _, m := range []ECSNetworkMode{
+			NetworkModeNone,
+			NetworkModeAWSVPC,
+			NetworkModeBridge,
 			NetworkModeHost,
 		} {
 			t.Run(fmt.Sprintf("SucceedsForStatus=%s", m), func(t *testing.T) {
@@ -861,6 +1420,14 @@ func TestECSContainerDefinition(t *testing.T) {
 		def := NewECSContainerDefinition().SetCPU(cpu)
 		assert.Equal(t, cpu, utility.FromIntPtr(def.CPU))
 	})
+	t.Run("SetMemory", func(t *testing.T) {
+		def := NewECSContainerDefinition().SetMemory(GiB(0.5))
+		assert.Equal(t, 512, utility.FromIntPtr(def.MemoryMB))
+	})
+	t.Run("SetCPUUnits", func(t *testing.T) {
+		def := NewECSContainerDefinition().SetCPUUnits(VCPUs(0.25))
+		assert.Equal(t, 256, utility.FromIntPtr(def.CPU))
+	})
 	t.Run("SetEnvironmentVariables", func(t *testing.T) {
 		ev := NewEnvironmentVariable().SetName("name").SetValue("value")
 
@@ -924,7 +1491,233 @@ func TestECSContainerDefinition(t *testing.T) {
 		def = NewECSContainerDefinition().SetLogConfiguration(LogConfiguration{})
 		assert.Empty(t, def.LogConfiguration)
 	})
+	t.Run("SetBindMounts", func(t *testing.T) {
+		bm := NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data")
+		def := NewECSContainerDefinition().SetBindMounts([]BindMount{*bm})
+		require.Len(t, def.BindMounts, 1)
+		assert.Equal(t, *bm, def.BindMounts[0])
+
+		def.SetBindMounts(nil)
+		assert.Empty(t, def.BindMounts)
+	})
+	t.Run("AddBindMounts", func(t *testing.T) {
+		bms := []BindMount{
+			*NewBindMount().SetSourcePath("/scratch0").SetContainerPath("/data0"),
+			*NewBindMount().SetSourcePath("/scratch1").SetContainerPath("/data1"),
+		}
+		def := NewECSContainerDefinition().AddBindMounts(bms...)
+		assert.ElementsMatch(t, bms, def.BindMounts)
+
+		def.AddBindMounts()
+		assert.ElementsMatch(t, bms, def.BindMounts)
+	})
+	t.Run("SetInteractive", func(t *testing.T) {
+		def := NewECSContainerDefinition().SetInteractive(true)
+		assert.True(t, utility.FromBoolPtr(def.Interactive))
+	})
+	t.Run("SetPseudoTerminal", func(t *testing.T) {
+		def := NewECSContainerDefinition().SetPseudoTerminal(true)
+		assert.True(t, utility.FromBoolPtr(def.PseudoTerminal))
+	})
+	t.Run("SetHostname", func(t *testing.T) {
+		def := NewECSContainerDefinition().SetHostname("hostname")
+		assert.Equal(t, "hostname", utility.FromStringPtr(def.Hostname))
+	})
+	t.Run("SetUser", func(t *testing.T) {
+		def := NewECSContainerDefinition().SetUser("1000:1000")
+		assert.Equal(t, "1000:1000", utility.FromStringPtr(def.User))
+	})
+	t.Run("SetExtraHosts", func(t *testing.T) {
+		hosts := []HostEntry{
+			*NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1"),
+			*NewHostEntry().SetHostname("cache").SetIPAddress("10.0.0.2"),
+		}
+		def := NewECSContainerDefinition().SetExtraHosts(hosts)
+		assert.ElementsMatch(t, hosts, def.ExtraHosts)
+
+		def.SetExtraHosts(nil)
+		assert.Empty(t, def.ExtraHosts)
+	})
+	t.Run("AddExtraHosts", func(t *testing.T) {
+		hosts := []HostEntry{
+			*NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1"),
+			*NewHostEntry().SetHostname("cache").SetIPAddress("10.0.0.2"),
+		}
+		def := NewECSContainerDefinition().AddExtraHosts(hosts...)
+		assert.ElementsMatch(t, hosts, def.ExtraHosts)
+
+		def.AddExtraHosts()
+		assert.ElementsMatch(t, hosts, def.ExtraHosts)
+	})
+	t.Run("SetVolumesFrom", func(t *testing.T) {
+		volumesFrom := []VolumeFrom{
+			*NewVolumeFrom().SetSourceContainer("data"),
+			*NewVolumeFrom().SetSourceContainer("cache").SetReadOnly(true),
+		}
+		def := NewECSContainerDefinition().SetVolumesFrom(volumesFrom)
+		assert.ElementsMatch(t, volumesFrom, def.VolumesFrom)
+
+		def.SetVolumesFrom(nil)
+		assert.Empty(t, def.VolumesFrom)
+	})
+	t.Run("AddVolumesFrom", func(t *testing.T) {
+		volumesFrom := []VolumeFrom{
+			*NewVolumeFrom().SetSourceContainer("data"),
+			*NewVolumeFrom().SetSourceContainer("cache").SetReadOnly(true),
+		}
+		def := NewECSContainerDefinition().AddVolumesFrom(volumesFrom...)
+		assert.ElementsMatch(t, volumesFrom, def.VolumesFrom)
+
+		def.AddVolumesFrom()
+		assert.ElementsMatch(t, volumesFrom, def.VolumesFrom)
+	})
+	t.Run("SetTmpfsMounts", func(t *testing.T) {
+		mounts := []TmpfsMount{
+			*NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(64),
+			*NewTmpfsMount().SetContainerPath("/run").SetSizeMB(32),
+		}
+		def := NewECSContainerDefinition().SetTmpfsMounts(mounts)
+		assert.ElementsMatch(t, mounts, def.TmpfsMounts)
+
+		def.SetTmpfsMounts(nil)
+		assert.Empty(t, def.TmpfsMounts)
+	})
+	t.Run("AddTmpfsMounts", func(t *testing.T) {
+		mounts := []TmpfsMount{
+			*NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(64),
+			*NewTmpfsMount().SetContainerPath("/run").SetSizeMB(32),
+		}
+		def := NewECSContainerDefinition().AddTmpfsMounts(mounts...)
+		assert.ElementsMatch(t, mounts, def.TmpfsMounts)
+
+		def.AddTmpfsMounts()
+		assert.ElementsMatch(t, mounts, def.TmpfsMounts)
+	})
+	t.Run("SetSharedMemorySizeMB", func(t *testing.T) {
+		def := NewECSContainerDefinition().SetSharedMemorySizeMB(256)
+		assert.Equal(t, 256, utility.FromIntPtr(def.SharedMemorySizeMB))
+	})
 	t.Run("Validate", func(t *testing.T) {
+		t.Run("SucceedsWithVolumesFrom", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetName("sidecar").
+				SetImage("image").
+				AddVolumesFrom(*NewVolumeFrom().SetSourceContainer("data"))
+			assert.NoError(t, def.Validate())
+		})
+		t.Run("SucceedsWithTmpfsMountsAndSharedMemorySize", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				AddTmpfsMounts(
+					*NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(64),
+					*NewTmpfsMount().SetContainerPath("/run").SetSizeMB(32),
+				).
+				SetSharedMemorySizeMB(256)
+			assert.NoError(t, def.Validate())
+		})
+		t.Run("FailsWithInvalidTmpfsMount", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				AddTmpfsMounts(*NewTmpfsMount().SetContainerPath("/tmp"))
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithDuplicateTmpfsMountPaths", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				AddTmpfsMounts(
+					*NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(64),
+					*NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(32),
+				)
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithNonPositiveSharedMemorySize", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetSharedMemorySizeMB(0)
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithInvalidVolumesFrom", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				AddVolumesFrom(*NewVolumeFrom())
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithVolumesFromItself", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetName("sidecar").
+				SetImage("image").
+				AddVolumesFrom(*NewVolumeFrom().SetSourceContainer("sidecar"))
+			assert.Error(t, def.Validate())
+		})
+		t.Run("SucceedsWithHostnameUserAndExtraHosts", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetHostname("hostname").
+				SetUser("user:group").
+				AddExtraHosts(*NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1"))
+			assert.NoError(t, def.Validate())
+		})
+		t.Run("SucceedsWithUserName", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetUser("user")
+			assert.NoError(t, def.Validate())
+		})
+		t.Run("SucceedsWithUIDAndGID", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetUser("1000:1000")
+			assert.NoError(t, def.Validate())
+		})
+		t.Run("FailsWithEmptyHostname", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetHostname("")
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithEmptyUser", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetUser("")
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithEmptyGroup", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetUser("user:")
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithTooManyUserParts", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetUser("user:group:other")
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithInvalidExtraHost", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				AddExtraHosts(*NewHostEntry().SetHostname("db"))
+			assert.Error(t, def.Validate())
+		})
+		t.Run("SucceedsWithInteractivePseudoTerminal", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetInteractive(true).
+				SetPseudoTerminal(true)
+			assert.NoError(t, def.Validate())
+		})
+		t.Run("FailsWithPseudoTerminalButNotInteractive", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetPseudoTerminal(true)
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithInvalidBindMount", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				AddBindMounts(*NewBindMount().SetSourcePath("/scratch"))
+			assert.Error(t, def.Validate())
+		})
 		t.Run("FailsWithNoFieldsPopulated", func(t *testing.T) {
 			assert.Error(t, NewECSContainerDefinition().Validate())
 		})
@@ -965,6 +1758,18 @@ func TestECSContainerDefinition(t *testing.T) {
 				SetMemoryMB(0)
 			assert.Error(t, def.Validate())
 		})
+		t.Run("FailsWithMemoryBelowECSMinimum", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetMemoryMB(1)
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithCPUAboveECSMaximum", func(t *testing.T) {
+			def := NewECSContainerDefinition().
+				SetImage("image").
+				SetCPUUnits(VCPUs(200))
+			assert.Error(t, def.Validate())
+		})
 		t.Run("FailsWithBadEnvironmentVariables", func(t *testing.T) {
 			def := NewECSContainerDefinition().
 				SetImage("image").
@@ -986,6 +1791,31 @@ func TestECSContainerDefinition(t *testing.T) {
 	})
 }
 
+func TestUsesRepositoryCredentials(t *testing.T) {
+	t.Run("FalseWithoutRepoCreds", func(t *testing.T) {
+		def := NewECSContainerDefinition().SetImage("image")
+		assert.False(t, def.UsesRepositoryCredentials())
+	})
+	t.Run("TrueWithRepoCreds", func(t *testing.T) {
+		def := NewECSContainerDefinition().
+			SetImage("public.ecr.aws/image:tag").
+			SetRepositoryCredentials(*NewRepositoryCredentials().SetID("id"))
+		assert.True(t, def.UsesRepositoryCredentials())
+	})
+	t.Run("FalseWithSkippedRepoCredsForPublicImage", func(t *testing.T) {
+		def := NewECSContainerDefinition().
+			SetImage("public.ecr.aws/image:tag").
+			SetRepositoryCredentials(*NewRepositoryCredentials().SetID("id").SetSkipForPublicImages(true))
+		assert.False(t, def.UsesRepositoryCredentials())
+	})
+	t.Run("TrueWithSkippedRepoCredsForPrivateImage", func(t *testing.T) {
+		def := NewECSContainerDefinition().
+			SetImage("image:tag").
+			SetRepositoryCredentials(*NewRepositoryCredentials().SetID("id").SetSkipForPublicImages(true))
+		assert.True(t, def.UsesRepositoryCredentials())
+	})
+}
+
 func TestEnvironmentVariable(t *testing.T) {
 	t.Run("NewEnvironmentVariable", func(t *testing.T) {
 		ev := NewEnvironmentVariable()
@@ -1068,6 +1898,14 @@ func TestRepositoryCredentials(t *testing.T) {
 		creds := NewRepositoryCredentials().SetOwned(true)
 		assert.True(t, utility.FromBoolPtr(creds.Owned))
 	})
+	t.Run("SetCreateIfMissing", func(t *testing.T) {
+		creds := NewRepositoryCredentials().SetCreateIfMissing(true)
+		assert.True(t, utility.FromBoolPtr(creds.CreateIfMissing))
+	})
+	t.Run("SetSkipForPublicImages", func(t *testing.T) {
+		creds := NewRepositoryCredentials().SetSkipForPublicImages(true)
+		assert.True(t, utility.FromBoolPtr(creds.SkipForPublicImages))
+	})
 	t.Run("SetNewCredentials", func(t *testing.T) {
 		storedCreds := NewStoredRepositoryCredentials().
 			SetUsername("username").
@@ -1098,6 +1936,18 @@ func TestRepositoryCredentials(t *testing.T) {
 			creds := NewRepositoryCredentials().SetID("")
 			assert.Error(t, creds.Validate())
 		})
+		t.Run("SucceedsWithSecretsManagerARN", func(t *testing.T) {
+			creds := NewRepositoryCredentials().SetID("arn:aws:secretsmanager:us-east-1:123456789012:secret:creds-AbCdEf")
+			assert.NoError(t, creds.Validate())
+		})
+		t.Run("FailsWithSSMParameterARN", func(t *testing.T) {
+			creds := NewRepositoryCredentials().SetID("arn:aws:ssm:us-east-1:123456789012:parameter/creds")
+			assert.Error(t, creds.Validate())
+		})
+		t.Run("FailsWithMalformedARN", func(t *testing.T) {
+			creds := NewRepositoryCredentials().SetID("arn:aws:secretsmanager:us-east-1")
+			assert.Error(t, creds.Validate())
+		})
 		t.Run("FailsWithJustNewCreds", func(t *testing.T) {
 			storedCreds := NewStoredRepositoryCredentials().
 				SetUsername("username").
@@ -1121,6 +1971,20 @@ func TestRepositoryCredentials(t *testing.T) {
 			creds := NewRepositoryCredentials().SetID("id").SetNewCredentials(*storedCreds)
 			assert.Error(t, creds.Validate())
 		})
+		t.Run("SucceedsWithCreateIfMissingAndNewCreds", func(t *testing.T) {
+			storedCreds := NewStoredRepositoryCredentials().
+				SetUsername("username").
+				SetPassword("password")
+			creds := NewRepositoryCredentials().
+				SetName("name").
+				SetNewCredentials(*storedCreds).
+				SetCreateIfMissing(true)
+			assert.NoError(t, creds.Validate())
+		})
+		t.Run("FailsWithCreateIfMissingWithoutNewCreds", func(t *testing.T) {
+			creds := NewRepositoryCredentials().SetID("id").SetCreateIfMissing(true)
+			assert.Error(t, creds.Validate())
+		})
 	})
 }
 
@@ -1221,11 +2085,35 @@ func TestSecretOptions(t *testing.T) {
 		opts := NewSecretOptions().SetOwned(true)
 		assert.True(t, utility.FromBoolPtr(opts.Owned))
 	})
+	t.Run("SetTags", func(t *testing.T) {
+		tags := Tags{"key": "value"}
+		opts := NewSecretOptions().SetTags(tags)
+		assert.Equal(t, tags, opts.Tags)
+		assert.Equal(t, tags, opts.GetTags())
+		opts.SetTags(nil)
+		assert.Empty(t, opts.Tags)
+	})
+	t.Run("AddTags", func(t *testing.T) {
+		opts := NewSecretOptions().AddTags(map[string]string{"key0": "val0"}).AddTags(map[string]string{"key1": "val1"})
+		assert.Equal(t, Tags{"key0": "val0", "key1": "val1"}, opts.Tags)
+	})
 	t.Run("Validate", func(t *testing.T) {
 		t.Run("SucceedsWithNameAndNewValue", func(t *testing.T) {
 			s := NewSecretOptions().SetName("name").SetNewValue("value")
 			assert.NoError(t, s.Validate())
 		})
+		t.Run("SucceedsWithTagsForNewSecret", func(t *testing.T) {
+			s := NewSecretOptions().SetName("name").SetNewValue("value").SetTags(map[string]string{"key": "value"})
+			assert.NoError(t, s.Validate())
+		})
+		t.Run("FailsWithTagsForExistingSecret", func(t *testing.T) {
+			s := NewSecretOptions().SetID("id").SetTags(map[string]string{"key": "value"})
+			assert.Error(t, s.Validate())
+		})
+		t.Run("FailsWithInvalidTags", func(t *testing.T) {
+			s := NewSecretOptions().SetName("name").SetNewValue("value").SetTags(map[string]string{"aws:key": "value"})
+			assert.Error(t, s.Validate())
+		})
 		t.Run("SucceedsWithID", func(t *testing.T) {
 			s := NewSecretOptions().SetID("id")
 			assert.NoError(t, s.Validate())
@@ -1242,6 +2130,18 @@ func TestSecretOptions(t *testing.T) {
 			s := NewSecretOptions().SetID("")
 			assert.Error(t, s.Validate())
 		})
+		t.Run("SucceedsWithSecretARN", func(t *testing.T) {
+			s := NewSecretOptions().SetID("arn:aws:secretsmanager:us-east-1:123456789012:secret:secret-AbCdEf")
+			assert.NoError(t, s.Validate())
+		})
+		t.Run("SucceedsWithParameterARN", func(t *testing.T) {
+			s := NewSecretOptions().SetID("arn:aws:ssm:us-east-1:123456789012:parameter/secret")
+			assert.NoError(t, s.Validate())
+		})
+		t.Run("FailsWithMalformedARN", func(t *testing.T) {
+			s := NewSecretOptions().SetID("arn:aws:secretsmanager:us-east-1:123456789012:secret-AbCdEf")
+			assert.Error(t, s.Validate())
+		})
 		t.Run("FailsWithJustName", func(t *testing.T) {
 			s := NewSecretOptions().SetName("name")
 			assert.Error(t, s.Validate())
@@ -1273,6 +2173,15 @@ func TestPortMappings(t *testing.T) {
 		pm := NewPortMapping().SetHostPort(1337)
 		assert.Equal(t, port, utility.FromIntPtr(pm.HostPort))
 	})
+	t.Run("SetProtocol", func(t *testing.T) {
+		pm := NewPortMapping().SetProtocol(PortProtocolUDP)
+		require.NotZero(t, pm.Protocol)
+		assert.Equal(t, PortProtocolUDP, *pm.Protocol)
+	})
+	t.Run("SetName", func(t *testing.T) {
+		pm := NewPortMapping().SetName("name")
+		assert.Equal(t, "name", utility.FromStringPtr(pm.Name))
+	})
 	t.Run("Validate", func(t *testing.T) {
 		t.Run("FailsWithNoFieldsPopulated", func(t *testing.T) {
 			pm := NewPortMapping()
@@ -1306,6 +2215,214 @@ func TestPortMappings(t *testing.T) {
 				SetHostPort(100000)
 			assert.Error(t, pm.Validate())
 		})
+		t.Run("SucceedsWithProtocolAndName", func(t *testing.T) {
+			pm := NewPortMapping().
+				SetContainerPort(8125).
+				SetProtocol(PortProtocolUDP).
+				SetName("statsd_udp-1")
+			assert.NoError(t, pm.Validate())
+		})
+		t.Run("FailsWithInvalidProtocol", func(t *testing.T) {
+			pm := NewPortMapping().
+				SetContainerPort(1337).
+				SetProtocol("sctp")
+			assert.Error(t, pm.Validate())
+		})
+		t.Run("FailsWithEmptyName", func(t *testing.T) {
+			pm := NewPortMapping().
+				SetContainerPort(1337).
+				SetName("")
+			assert.Error(t, pm.Validate())
+		})
+		t.Run("FailsWithInvalidName", func(t *testing.T) {
+			for _, name := range []string{"-http", "HTTP", "http port", strings.Repeat("a", 65)} {
+				pm := NewPortMapping().
+					SetContainerPort(1337).
+					SetName(name)
+				assert.Error(t, pm.Validate(), name)
+			}
+		})
+	})
+}
+
+func TestPortProtocol(t *testing.T) {
+	t.Run("Validate", func(t *testing.T) {
+		assert.NoError(t, PortProtocolTCP.Validate())
+		assert.NoError(t, PortProtocolUDP.Validate())
+		assert.Error(t, PortProtocol("").Validate())
+		assert.Error(t, PortProtocol("sctp").Validate())
+	})
+}
+
+func TestBindMount(t *testing.T) {
+	t.Run("NewBindMount", func(t *testing.T) {
+		bm := NewBindMount()
+		require.NotZero(t, bm)
+		assert.Zero(t, *bm)
+	})
+	t.Run("SetSourcePath", func(t *testing.T) {
+		path := "/scratch"
+		bm := NewBindMount().SetSourcePath(path)
+		assert.Equal(t, path, utility.FromStringPtr(bm.SourcePath))
+	})
+	t.Run("SetContainerPath", func(t *testing.T) {
+		path := "/data"
+		bm := NewBindMount().SetContainerPath(path)
+		assert.Equal(t, path, utility.FromStringPtr(bm.ContainerPath))
+	})
+	t.Run("SetReadOnly", func(t *testing.T) {
+		bm := NewBindMount().SetReadOnly(true)
+		assert.True(t, utility.FromBoolPtr(bm.ReadOnly))
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("FailsWithNoFieldsPopulated", func(t *testing.T) {
+			assert.Error(t, NewBindMount().Validate())
+		})
+		t.Run("SucceedsWithSourceAndContainerPath", func(t *testing.T) {
+			bm := NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data")
+			assert.NoError(t, bm.Validate())
+		})
+		t.Run("FailsWithoutSourcePath", func(t *testing.T) {
+			bm := NewBindMount().SetContainerPath("/data")
+			assert.Error(t, bm.Validate())
+		})
+		t.Run("FailsWithoutContainerPath", func(t *testing.T) {
+			bm := NewBindMount().SetSourcePath("/scratch")
+			assert.Error(t, bm.Validate())
+		})
+	})
+}
+
+func TestHostEntry(t *testing.T) {
+	t.Run("NewHostEntry", func(t *testing.T) {
+		h := NewHostEntry()
+		require.NotZero(t, h)
+		assert.Zero(t, *h)
+	})
+	t.Run("SetHostname", func(t *testing.T) {
+		h := NewHostEntry().SetHostname("db")
+		assert.Equal(t, "db", utility.FromStringPtr(h.Hostname))
+	})
+	t.Run("SetIPAddress", func(t *testing.T) {
+		h := NewHostEntry().SetIPAddress("10.0.0.1")
+		assert.Equal(t, "10.0.0.1", utility.FromStringPtr(h.IPAddress))
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("FailsWithNoFieldsPopulated", func(t *testing.T) {
+			assert.Error(t, NewHostEntry().Validate())
+		})
+		t.Run("SucceedsWithHostnameAndIPv4Address", func(t *testing.T) {
+			h := NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1")
+			assert.NoError(t, h.Validate())
+		})
+		t.Run("SucceedsWithHostnameAndIPv6Address", func(t *testing.T) {
+			h := NewHostEntry().SetHostname("db").SetIPAddress("::1")
+			assert.NoError(t, h.Validate())
+		})
+		t.Run("FailsWithoutHostname", func(t *testing.T) {
+			h := NewHostEntry().SetIPAddress("10.0.0.1")
+			assert.Error(t, h.Validate())
+		})
+		t.Run("FailsWithoutIPAddress", func(t *testing.T) {
+			h := NewHostEntry().SetHostname("db")
+			assert.Error(t, h.Validate())
+		})
+		t.Run("FailsWithInvalidIPAddress", func(t *testing.T) {
+			h := NewHostEntry().SetHostname("db").SetIPAddress("not-an-ip")
+			assert.Error(t, h.Validate())
+		})
+	})
+}
+
+func TestVolumeFrom(t *testing.T) {
+	t.Run("NewVolumeFrom", func(t *testing.T) {
+		vf := NewVolumeFrom()
+		require.NotZero(t, vf)
+		assert.Zero(t, *vf)
+	})
+	t.Run("SetSourceContainer", func(t *testing.T) {
+		vf := NewVolumeFrom().SetSourceContainer("data")
+		assert.Equal(t, "data", utility.FromStringPtr(vf.SourceContainer))
+	})
+	t.Run("SetReadOnly", func(t *testing.T) {
+		vf := NewVolumeFrom().SetReadOnly(true)
+		assert.True(t, utility.FromBoolPtr(vf.ReadOnly))
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("FailsWithNoFieldsPopulated", func(t *testing.T) {
+			assert.Error(t, NewVolumeFrom().Validate())
+		})
+		t.Run("SucceedsWithSourceContainer", func(t *testing.T) {
+			assert.NoError(t, NewVolumeFrom().SetSourceContainer("data").Validate())
+		})
+		t.Run("SucceedsWithAllFieldsPopulated", func(t *testing.T) {
+			assert.NoError(t, NewVolumeFrom().SetSourceContainer("data").SetReadOnly(true).Validate())
+		})
+		t.Run("FailsWithEmptySourceContainer", func(t *testing.T) {
+			assert.Error(t, NewVolumeFrom().SetSourceContainer("").SetReadOnly(true).Validate())
+		})
+	})
+}
+
+func TestTmpfsMount(t *testing.T) {
+	t.Run("NewTmpfsMount", func(t *testing.T) {
+		m := NewTmpfsMount()
+		require.NotZero(t, m)
+		assert.Zero(t, *m)
+	})
+	t.Run("SetContainerPath", func(t *testing.T) {
+		m := NewTmpfsMount().SetContainerPath("/tmp")
+		assert.Equal(t, "/tmp", utility.FromStringPtr(m.ContainerPath))
+	})
+	t.Run("SetSizeMB", func(t *testing.T) {
+		m := NewTmpfsMount().SetSizeMB(64)
+		assert.Equal(t, 64, utility.FromIntPtr(m.SizeMB))
+	})
+	t.Run("SetMountOptions", func(t *testing.T) {
+		opts := []string{"noexec", "nosuid"}
+		m := NewTmpfsMount().SetMountOptions(opts)
+		assert.ElementsMatch(t, opts, m.MountOptions)
+
+		m.SetMountOptions(nil)
+		assert.Empty(t, m.MountOptions)
+	})
+	t.Run("AddMountOptions", func(t *testing.T) {
+		opts := []string{"noexec", "nosuid"}
+		m := NewTmpfsMount().AddMountOptions(opts...)
+		assert.ElementsMatch(t, opts, m.MountOptions)
+
+		m.AddMountOptions()
+		assert.ElementsMatch(t, opts, m.MountOptions)
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("FailsWithNoFieldsPopulated", func(t *testing.T) {
+			assert.Error(t, NewTmpfsMount().Validate())
+		})
+		t.Run("SucceedsWithContainerPathAndSize", func(t *testing.T) {
+			assert.NoError(t, NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(64).Validate())
+		})
+		t.Run("SucceedsWithAllFieldsPopulated", func(t *testing.T) {
+			m := NewTmpfsMount().
+				SetContainerPath("/tmp").
+				SetSizeMB(64).
+				AddMountOptions("noexec", "nosuid", "mode=1777")
+			assert.NoError(t, m.Validate())
+		})
+		t.Run("FailsWithoutContainerPath", func(t *testing.T) {
+			assert.Error(t, NewTmpfsMount().SetSizeMB(64).Validate())
+		})
+		t.Run("FailsWithRelativeContainerPath", func(t *testing.T) {
+			assert.Error(t, NewTmpfsMount().SetContainerPath("tmp").SetSizeMB(64).Validate())
+		})
+		t.Run("FailsWithoutSize", func(t *testing.T) {
+			assert.Error(t, NewTmpfsMount().SetContainerPath("/tmp").Validate())
+		})
+		t.Run("FailsWithNonPositiveSize", func(t *testing.T) {
+			assert.Error(t, NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(0).Validate())
+		})
+		t.Run("FailsWithUnsupportedMountOption", func(t *testing.T) {
+			assert.Error(t, NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(64).AddMountOptions("foo").Validate())
+		})
 	})
 }
 
@@ -1331,6 +2448,20 @@ func TestLogConfiguration(t *testing.T) {
 		})
 		assert.Equal(t, options, lc.Options)
 	})
+	t.Run("SetSecretOptions", func(t *testing.T) {
+		opt := NewEnvironmentVariable().SetName("token").SetSecretOptions(*NewSecretOptions().SetID("id"))
+		lc := NewLogConfiguration().SetSecretOptions([]EnvironmentVariable{*opt})
+		require.Len(t, lc.SecretOptions, 1)
+		assert.Equal(t, *opt, lc.SecretOptions[0])
+	})
+	t.Run("AddSecretOptions", func(t *testing.T) {
+		opt0 := NewEnvironmentVariable().SetName("token0").SetSecretOptions(*NewSecretOptions().SetID("id0"))
+		opt1 := NewEnvironmentVariable().SetName("token1").SetSecretOptions(*NewSecretOptions().SetID("id1"))
+		lc := NewLogConfiguration().AddSecretOptions(*opt0).AddSecretOptions(*opt1)
+		require.Len(t, lc.SecretOptions, 2)
+		assert.Equal(t, *opt0, lc.SecretOptions[0])
+		assert.Equal(t, *opt1, lc.SecretOptions[1])
+	})
 	t.Run("Validate", func(t *testing.T) {
 		t.Run("FailsWithNoFieldsPopulated", func(t *testing.T) {
 			pm := NewLogConfiguration()
@@ -1373,6 +2504,160 @@ func TestLogConfiguration(t *testing.T) {
 				})
 			assert.NoError(t, lc.Validate())
 		})
+		t.Run("SucceedsWithSecretOptions", func(t *testing.T) {
+			lc := NewLogConfiguration().
+				SetLogDriver(string(types.LogDriverAwslogs)).
+				SetOptions(map[string]string{
+					"awslogs-group":  "group",
+					"awslogs-region": "region",
+				}).
+				AddSecretOptions(*NewEnvironmentVariable().
+					SetName("token").
+					SetSecretOptions(*NewSecretOptions().SetName("name").SetNewValue("value")))
+			assert.NoError(t, lc.Validate())
+		})
+		t.Run("FailsWithSecretOptionWithoutSecret", func(t *testing.T) {
+			lc := NewLogConfiguration().
+				SetLogDriver(string(types.LogDriverAwslogs)).
+				SetOptions(map[string]string{
+					"awslogs-group":  "group",
+					"awslogs-region": "region",
+				}).
+				AddSecretOptions(*NewEnvironmentVariable().
+					SetName("token").
+					SetValue("value"))
+			assert.Error(t, lc.Validate())
+		})
+		t.Run("FailsWithInvalidSecretOption", func(t *testing.T) {
+			lc := NewLogConfiguration().
+				SetLogDriver(string(types.LogDriverAwslogs)).
+				SetOptions(map[string]string{
+					"awslogs-group":  "group",
+					"awslogs-region": "region",
+				}).
+				AddSecretOptions(*NewEnvironmentVariable().
+					SetSecretOptions(*NewSecretOptions().SetID("id")))
+			assert.Error(t, lc.Validate())
+		})
+		t.Run("SucceedsWithAWSLogsOptionsAndDefaultsDriver", func(t *testing.T) {
+			lc := NewLogConfiguration().
+				SetAWSLogsOptions(*NewAWSLogsOptions().SetGroup("group").SetRegion("region"))
+			require.NoError(t, lc.Validate())
+			assert.Equal(t, AWSLogsDriver, utility.FromStringPtr(lc.LogDriver))
+		})
+		t.Run("SucceedsWithAWSLogsOptionsAndOtherRawOptions", func(t *testing.T) {
+			lc := NewLogConfiguration().
+				SetLogDriver(AWSLogsDriver).
+				SetOptions(map[string]string{"tag": "value"}).
+				SetAWSLogsOptions(*NewAWSLogsOptions().SetGroup("group").SetRegion("region"))
+			assert.NoError(t, lc.Validate())
+		})
+		t.Run("FailsWithInvalidAWSLogsOptions", func(t *testing.T) {
+			lc := NewLogConfiguration().
+				SetAWSLogsOptions(*NewAWSLogsOptions().SetGroup("group"))
+			assert.Error(t, lc.Validate())
+		})
+		t.Run("FailsWithAWSLogsOptionsForOtherDriver", func(t *testing.T) {
+			lc := NewLogConfiguration().
+				SetLogDriver(string(types.LogDriverSplunk)).
+				SetAWSLogsOptions(*NewAWSLogsOptions().SetGroup("group").SetRegion("region"))
+			assert.Error(t, lc.Validate())
+		})
+		t.Run("FailsWithAWSLogsOptionsConflictingWithRawOptions", func(t *testing.T) {
+			lc := NewLogConfiguration().
+				SetOptions(map[string]string{"awslogs-stream-prefix": "prefix"}).
+				SetAWSLogsOptions(*NewAWSLogsOptions().SetGroup("group").SetRegion("region"))
+			assert.Error(t, lc.Validate())
+		})
+	})
+	t.Run("GetOptions", func(t *testing.T) {
+		t.Run("ReturnsNilWithoutOptions", func(t *testing.T) {
+			assert.Nil(t, NewLogConfiguration().GetOptions())
+		})
+		t.Run("MergesRawAndAWSLogsOptions", func(t *testing.T) {
+			lc := NewLogConfiguration().
+				SetOptions(map[string]string{"tag": "value"}).
+				SetAWSLogsOptions(*NewAWSLogsOptions().SetGroup("group").SetRegion("region"))
+			assert.Equal(t, map[string]string{
+				"tag":            "value",
+				"awslogs-group":  "group",
+				"awslogs-region": "region",
+			}, lc.GetOptions())
+			assert.Equal(t, map[string]string{"tag": "value"}, lc.Options, "raw options should not be modified")
+		})
+	})
+	t.Run("HashIsSameForEquivalentRawAndAWSLogsOptions", func(t *testing.T) {
+		raw := NewLogConfiguration().
+			SetLogDriver(AWSLogsDriver).
+			SetOptions(map[string]string{
+				"awslogs-group":  "group",
+				"awslogs-region": "region",
+			})
+		typed := NewLogConfiguration().
+			SetLogDriver(AWSLogsDriver).
+			SetAWSLogsOptions(*NewAWSLogsOptions().SetGroup("group").SetRegion("region"))
+		assert.Equal(t, raw.hash(), typed.hash())
+	})
+}
+
+func TestAWSLogsOptions(t *testing.T) {
+	t.Run("ToOptionsRendersAllOptions", func(t *testing.T) {
+		opts := NewAWSLogsOptions().
+			SetGroup("group").
+			SetRegion("region").
+			SetStreamPrefix("prefix").
+			SetCreateGroup(true).
+			SetDatetimeFormat("%Y-%m-%d").
+			SetMode(AWSLogsModeNonBlocking).
+			SetMaxBufferSize("25m")
+		assert.Equal(t, map[string]string{
+			"awslogs-group":           "group",
+			"awslogs-region":          "region",
+			"awslogs-stream-prefix":   "prefix",
+			"awslogs-create-group":    "true",
+			"awslogs-datetime-format": "%Y-%m-%d",
+			"mode":                    "non-blocking",
+			"max-buffer-size":         "25m",
+		}, opts.ToOptions())
+	})
+	t.Run("ToOptionsOmitsUnsetOptions", func(t *testing.T) {
+		opts := NewAWSLogsOptions().SetGroup("group").SetRegion("region")
+		assert.Equal(t, map[string]string{
+			"awslogs-group":  "group",
+			"awslogs-region": "region",
+		}, opts.ToOptions())
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("SucceedsWithGroupAndRegion", func(t *testing.T) {
+			assert.NoError(t, NewAWSLogsOptions().SetGroup("group").SetRegion("region").Validate())
+		})
+		t.Run("SucceedsWithMultilinePattern", func(t *testing.T) {
+			assert.NoError(t, NewAWSLogsOptions().SetGroup("group").SetRegion("region").SetMultilinePattern("^INFO").Validate())
+		})
+		t.Run("FailsWithoutGroup", func(t *testing.T) {
+			assert.Error(t, NewAWSLogsOptions().SetRegion("region").Validate())
+		})
+		t.Run("FailsWithoutRegion", func(t *testing.T) {
+			assert.Error(t, NewAWSLogsOptions().SetGroup("group").Validate())
+		})
+		t.Run("FailsWithBothDatetimeFormatAndMultilinePattern", func(t *testing.T) {
+			opts := NewAWSLogsOptions().
+				SetGroup("group").
+				SetRegion("region").
+				SetDatetimeFormat("%Y-%m-%d").
+				SetMultilinePattern("^INFO")
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithInvalidMode", func(t *testing.T) {
+			assert.Error(t, NewAWSLogsOptions().SetGroup("group").SetRegion("region").SetMode("foo").Validate())
+		})
+		t.Run("FailsWithMaxBufferSizeWithoutNonBlockingMode", func(t *testing.T) {
+			assert.Error(t, NewAWSLogsOptions().SetGroup("group").SetRegion("region").SetMaxBufferSize("25m").Validate())
+			assert.Error(t, NewAWSLogsOptions().SetGroup("group").SetRegion("region").SetMode(AWSLogsModeBlocking).SetMaxBufferSize("25m").Validate())
+		})
+		t.Run("SucceedsWithMaxBufferSizeAndNonBlockingMode", func(t *testing.T) {
+			assert.NoError(t, NewAWSLogsOptions().SetGroup("group").SetRegion("region").SetMode(AWSLogsModeNonBlocking).SetMaxBufferSize("25m").Validate())
+		})
 	})
 }
 
@@ -1416,12 +2701,52 @@ func TestECSPodExecutionOptions(t *testing.T) {
 		require.NotZero(t, opts.AWSVPCOpts)
 		assert.Equal(t, *awsvpcOpts, *opts.AWSVPCOpts)
 	})
+	t.Run("SetProtectionPolicy", func(t *testing.T) {
+		pp := NewECSPodProtectionPolicy().SetTaskDefinition(true).AddSecrets("secret")
+		opts := NewECSPodExecutionOptions().SetProtectionPolicy(*pp)
+		require.NotZero(t, opts.ProtectionPolicy)
+		assert.Equal(t, *pp, *opts.ProtectionPolicy)
+	})
+	t.Run("SetAssumeRoleOptions", func(t *testing.T) {
+		roleOpts := NewAssumeRoleOptions().SetRoleARN("arn:aws:iam::123456789012:role/role")
+		opts := NewECSPodExecutionOptions().SetAssumeRoleOptions(*roleOpts)
+		require.NotZero(t, opts.AssumeRoleOpts)
+		assert.Equal(t, *roleOpts, *opts.AssumeRoleOpts)
+	})
 	t.Run("SetSupportsDebugMode", func(t *testing.T) {
 		opts := NewECSPodExecutionOptions().SetSupportsDebugMode(true)
 		assert.True(t, utility.FromBoolPtr(opts.SupportsDebugMode))
 	})
+	t.Run("SetSuppressPlacementStrategy", func(t *testing.T) {
+		opts := NewECSPodExecutionOptions().SetSuppressPlacementStrategy(true)
+		assert.True(t, utility.FromBoolPtr(opts.SuppressPlacementStrategy))
+	})
+	t.Run("UsesPlacementStrategy", func(t *testing.T) {
+		t.Run("ReturnsTrueWithStrategy", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().SetCapacityProvider("capacity_provider")
+			require.NoError(t, opts.Validate())
+			assert.True(t, opts.UsesPlacementStrategy())
+		})
+		t.Run("ReturnsFalseWithStrategyNone", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().SetPlacementOptions(*NewECSPodPlacementOptions().SetStrategy(StrategyNone))
+			require.NoError(t, opts.Validate())
+			assert.False(t, opts.UsesPlacementStrategy())
+		})
+		t.Run("ReturnsFalseWhenSuppressedWithCapacityProvider", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().
+				SetPlacementOptions(*NewECSPodPlacementOptions().SetStrategy(StrategySpread)).
+				SetCapacityProvider("capacity_provider").
+				SetSuppressPlacementStrategy(true)
+			assert.False(t, opts.UsesPlacementStrategy())
+		})
+		t.Run("ReturnsTrueWhenSuppressedWithoutCapacityProvider", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().SetSuppressPlacementStrategy(true)
+			require.NoError(t, opts.Validate())
+			assert.True(t, opts.UsesPlacementStrategy())
+		})
+	})
 	t.Run("SetTags", func(t *testing.T) {
-		tags := map[string]string{
+		tags := Tags{
 			"key0": "val0",
 			"key1": "val1",
 		}
@@ -1431,7 +2756,7 @@ func TestECSPodExecutionOptions(t *testing.T) {
 		assert.Empty(t, opts.Tags)
 	})
 	t.Run("AddTags", func(t *testing.T) {
-		tags := map[string]string{
+		tags := Tags{
 			"key0": "val0",
 			"key1": "val1",
 		}
@@ -1460,6 +2785,48 @@ func TestECSPodExecutionOptions(t *testing.T) {
 			assert.Equal(t, StrategyBinpack, *opts.PlacementOpts.Strategy)
 			assert.Equal(t, StrategyParamBinpackMemory, utility.FromStringPtr(opts.PlacementOpts.StrategyParameter))
 		})
+		t.Run("PlacementOptionsWithNoStrategyAreNotDefaulted", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().SetPlacementOptions(*NewECSPodPlacementOptions().SetStrategy(StrategyNone))
+			require.NoError(t, opts.Validate())
+			require.NotZero(t, opts.PlacementOpts)
+			require.NotZero(t, opts.PlacementOpts.Strategy)
+			assert.Equal(t, StrategyNone, *opts.PlacementOpts.Strategy)
+			assert.Zero(t, opts.PlacementOpts.StrategyParameter)
+		})
+		t.Run("SuppressedPlacementStrategyWithCapacityProviderIsDefaultedToNone", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().
+				SetCapacityProvider("capacity_provider").
+				SetPlacementOptions(*NewECSPodPlacementOptions().AddInstanceFilters(ConstraintDistinctInstance)).
+				SetSuppressPlacementStrategy(true)
+			require.NoError(t, opts.Validate())
+			require.NotZero(t, opts.PlacementOpts)
+			require.NotZero(t, opts.PlacementOpts.Strategy)
+			assert.Equal(t, StrategyNone, *opts.PlacementOpts.Strategy)
+			assert.Zero(t, opts.PlacementOpts.StrategyParameter)
+			assert.Equal(t, []string{ConstraintDistinctInstance}, opts.PlacementOpts.InstanceFilters)
+			assert.NoError(t, opts.Validate(), "validation should be idempotent")
+		})
+		t.Run("SuppressedPlacementStrategyWithoutCapacityProviderIsDefaultedToBinpack", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().SetSuppressPlacementStrategy(true)
+			require.NoError(t, opts.Validate())
+			require.NotZero(t, opts.PlacementOpts)
+			require.NotZero(t, opts.PlacementOpts.Strategy)
+			assert.Equal(t, StrategyBinpack, *opts.PlacementOpts.Strategy)
+		})
+		t.Run("FailsWithExplicitStrategyWhenSuppressedWithCapacityProvider", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().
+				SetCapacityProvider("capacity_provider").
+				SetPlacementOptions(*NewECSPodPlacementOptions().SetStrategy(StrategySpread)).
+				SetSuppressPlacementStrategy(true)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("SucceedsWithDefaultStrategyAndCapacityProvider", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().SetCapacityProvider("capacity_provider")
+			require.NoError(t, opts.Validate())
+			require.NotZero(t, opts.PlacementOpts)
+			require.NotZero(t, opts.PlacementOpts.Strategy)
+			assert.Equal(t, StrategyBinpack, *opts.PlacementOpts.Strategy)
+		})
 		t.Run("FailsWithBadPlacementOptions", func(t *testing.T) {
 			placementOpts := NewECSPodPlacementOptions().SetStrategy("foo")
 			opts := NewECSPodExecutionOptions().SetPlacementOptions(*placementOpts)
@@ -1469,6 +2836,116 @@ func TestECSPodExecutionOptions(t *testing.T) {
 			opts := NewECSPodExecutionOptions().SetAWSVPCOptions(*NewAWSVPCOptions())
 			assert.Error(t, opts.Validate())
 		})
+		t.Run("SucceedsWithAssumeRoleOptions", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().SetAssumeRoleOptions(*NewAssumeRoleOptions().
+				SetRoleARN("arn:aws:iam::123456789012:role/role").
+				SetExternalID("external_id"))
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithBadAssumeRoleOptions", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().SetAssumeRoleOptions(*NewAssumeRoleOptions())
+			assert.Error(t, opts.Validate())
+		})
+	})
+	t.Run("Hash", func(t *testing.T) {
+		getValidExecOpts := func() *ECSPodExecutionOptions {
+			return NewECSPodExecutionOptions().
+				SetCluster("cluster").
+				SetCapacityProvider("capacity_provider").
+				SetAWSVPCOptions(*NewAWSVPCOptions().AddSubnets("subnet0", "subnet1").AddSecurityGroups("sg0", "sg1")).
+				SetPlacementOptions(*NewECSPodPlacementOptions().AddInstanceFilters("filter0", "filter1")).
+				SetOverrideOptions(*NewECSOverridePodDefinitionOptions().AddContainerDefinitions(
+					*NewECSOverrideContainerDefinition().SetName("container0").SetCommand([]string{"echo", "foo"}),
+					*NewECSOverrideContainerDefinition().SetName("container1"),
+				))
+		}
+		baseHash := getValidExecOpts().Hash()
+
+		t.Run("ReturnsSameValueForSameInput", func(t *testing.T) {
+			assert.Equal(t, baseHash, getValidExecOpts().Hash())
+		})
+		t.Run("ReturnsSameValueForEmptyOptions", func(t *testing.T) {
+			assert.Equal(t, NewECSPodExecutionOptions().Hash(), NewECSPodExecutionOptions().Hash())
+		})
+		t.Run("ChangesForCluster", func(t *testing.T) {
+			opts := getValidExecOpts().SetCluster("new_cluster")
+			assert.NotEqual(t, baseHash, opts.Hash(), "cluster should affect hash")
+		})
+		t.Run("ChangesForSameValueInDifferentField", func(t *testing.T) {
+			h0 := NewECSPodExecutionOptions().SetCluster("value").Hash()
+			h1 := NewECSPodExecutionOptions().SetCapacityProvider("value").Hash()
+			assert.NotEqual(t, h0, h1, "same value in different fields should affect hash")
+		})
+		t.Run("ChangesForCapacityProvider", func(t *testing.T) {
+			opts := getValidExecOpts().SetCapacityProvider("new_capacity_provider")
+			assert.NotEqual(t, baseHash, opts.Hash(), "capacity provider should affect hash")
+		})
+		t.Run("ChangesForSupportsDebugMode", func(t *testing.T) {
+			opts := getValidExecOpts().SetSupportsDebugMode(true)
+			assert.NotEqual(t, baseHash, opts.Hash(), "debug mode should affect hash")
+		})
+		t.Run("ChangesForSuppressPlacementStrategy", func(t *testing.T) {
+			opts := getValidExecOpts().SetSuppressPlacementStrategy(true)
+			assert.NotEqual(t, baseHash, opts.Hash(), "suppressing placement strategy should affect hash")
+		})
+		t.Run("ChangesForTags", func(t *testing.T) {
+			opts := getValidExecOpts().SetTags(map[string]string{"key": "value"})
+			assert.NotEqual(t, baseHash, opts.Hash(), "tags should affect hash")
+		})
+		t.Run("ChangesForPlacementOptions", func(t *testing.T) {
+			opts := getValidExecOpts()
+			opts.PlacementOpts.SetGroup("group")
+			assert.NotEqual(t, baseHash, opts.Hash(), "placement group should affect hash")
+		})
+		t.Run("ChangesForOverrideOptions", func(t *testing.T) {
+			opts := getValidExecOpts()
+			opts.OverrideOpts.SetMemoryMB(256)
+			assert.NotEqual(t, baseHash, opts.Hash(), "override memory should affect hash")
+		})
+		t.Run("ChangesForOverrideCommandOrder", func(t *testing.T) {
+			opts := getValidExecOpts()
+			opts.OverrideOpts.ContainerDefinitions[0].SetCommand([]string{"foo", "echo"})
+			assert.NotEqual(t, baseHash, opts.Hash(), "order of command arguments should affect hash")
+		})
+		t.Run("ChangesForProtectionPolicy", func(t *testing.T) {
+			opts := getValidExecOpts().SetProtectionPolicy(*NewECSPodProtectionPolicy().SetTaskDefinition(true))
+			assert.NotEqual(t, baseHash, opts.Hash(), "protection policy should affect hash")
+		})
+		t.Run("ChangesForAssumeRoleOptions", func(t *testing.T) {
+			opts := getValidExecOpts().SetAssumeRoleOptions(*NewAssumeRoleOptions().SetRoleARN("arn:aws:iam::123456789012:role/role"))
+			assert.NotEqual(t, baseHash, opts.Hash(), "assume role options should affect hash")
+		})
+		t.Run("ReturnsSameValueForDifferentSliceOrder", func(t *testing.T) {
+			opts := NewECSPodExecutionOptions().
+				SetCluster("cluster").
+				SetCapacityProvider("capacity_provider").
+				SetAWSVPCOptions(*NewAWSVPCOptions().AddSubnets("subnet1", "subnet0").AddSecurityGroups("sg1", "sg0")).
+				SetPlacementOptions(*NewECSPodPlacementOptions().AddInstanceFilters("filter1", "filter0")).
+				SetOverrideOptions(*NewECSOverridePodDefinitionOptions().AddContainerDefinitions(
+					*NewECSOverrideContainerDefinition().SetName("container1"),
+					*NewECSOverrideContainerDefinition().SetName("container0").SetCommand([]string{"echo", "foo"}),
+				))
+			assert.Equal(t, baseHash, opts.Hash(), "order of subnets, security groups, instance filters, and container overrides should not affect hash")
+			assert.Equal(t, []string{"subnet1", "subnet0"}, opts.AWSVPCOpts.Subnets, "hashing should not reorder the options")
+		})
+		t.Run("ReturnsSameValueForDifferentOverrideEnvironmentVariableOrder", func(t *testing.T) {
+			ev0 := *NewKeyValue().SetName("name0").SetValue("value0")
+			ev1 := *NewKeyValue().SetName("name1").SetValue("value1")
+			h0 := NewECSPodExecutionOptions().SetOverrideOptions(*NewECSOverridePodDefinitionOptions().AddContainerDefinitions(
+				*NewECSOverrideContainerDefinition().SetName("container").AddEnvironmentVariables(ev0, ev1),
+			)).Hash()
+			h1 := NewECSPodExecutionOptions().SetOverrideOptions(*NewECSOverridePodDefinitionOptions().AddContainerDefinitions(
+				*NewECSOverrideContainerDefinition().SetName("container").AddEnvironmentVariables(ev1, ev0),
+			)).Hash()
+			assert.Equal(t, h0, h1, "order of override environment variables should not affect hash")
+		})
+		t.Run("ReturnsSameValueForDifferentTagOrder", func(t *testing.T) {
+			opts := getValidExecOpts()
+			for i := 0; i < 10; i++ {
+				opts.AddTags(map[string]string{utility.RandomString(): utility.RandomString()})
+			}
+			assert.Equal(t, opts.Hash(), opts.Hash(), "order of tags should not affect hash")
+		})
 	})
 }
 
@@ -1511,6 +2988,14 @@ func TestECSOverridePodDefinitionOptions(t *testing.T) {
 		opts := NewECSOverridePodDefinitionOptions().SetCPU(cpu)
 		assert.Equal(t, cpu, utility.FromIntPtr(opts.CPU))
 	})
+	t.Run("SetMemory", func(t *testing.T) {
+		opts := NewECSOverridePodDefinitionOptions().SetMemory(GiB(4))
+		assert.Equal(t, 4096, utility.FromIntPtr(opts.MemoryMB))
+	})
+	t.Run("SetCPUUnits", func(t *testing.T) {
+		opts := NewECSOverridePodDefinitionOptions().SetCPUUnits(VCPUs(2))
+		assert.Equal(t, 2048, utility.FromIntPtr(opts.CPU))
+	})
 	t.Run("SetTaskRole", func(t *testing.T) {
 		const r = "task_role"
 		opts := NewECSPodDefinitionOptions().SetTaskRole(r)
@@ -1541,6 +3026,148 @@ func TestECSOverridePodDefinitionOptions(t *testing.T) {
 			assert.Error(t, NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition()).Validate())
 		})
 	})
+	t.Run("HasBindMounts", func(t *testing.T) {
+		opts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().SetName("name"))
+		assert.False(t, opts.HasBindMounts())
+
+		opts.AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+			SetName("other").
+			AddBindMounts(*NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data")))
+		assert.True(t, opts.HasBindMounts())
+	})
+	t.Run("HasSecretEnvironmentVariables", func(t *testing.T) {
+		opts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().SetName("name"))
+		assert.False(t, opts.HasSecretEnvironmentVariables())
+
+		opts.AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+			SetName("other").
+			AddSecretEnvironmentVariables(*NewEnvironmentVariable().
+				SetName("SECRET").
+				SetSecretOptions(*NewSecretOptions().SetID("secret_id"))))
+		assert.True(t, opts.HasSecretEnvironmentVariables())
+	})
+	t.Run("RequiresNewDefinition", func(t *testing.T) {
+		opts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+			SetName("name").
+			AddEnvironmentVariables(*NewKeyValue().SetName("name").SetValue("value")))
+		assert.False(t, opts.RequiresNewDefinition())
+
+		bindMountOpts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+			SetName("name").
+			AddBindMounts(*NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data")))
+		assert.True(t, bindMountOpts.RequiresNewDefinition())
+
+		secretOpts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+			SetName("name").
+			AddSecretEnvironmentVariables(*NewEnvironmentVariable().
+				SetName("SECRET").
+				SetSecretOptions(*NewSecretOptions().SetID("secret_id"))))
+		assert.True(t, secretOpts.RequiresNewDefinition())
+	})
+	t.Run("ValidateAgainst", func(t *testing.T) {
+		makeDefOpts := func() ECSPodDefinitionOptions {
+			return *NewECSPodDefinitionOptions().
+				SetMemoryMB(512).
+				SetCPU(1024).
+				AddContainerDefinitions(
+					*NewECSContainerDefinition().
+						SetName("main").
+						SetImage("image").
+						SetMemoryMB(256).
+						SetCPU(512).
+						AddEnvironmentVariables(
+							*NewEnvironmentVariable().SetName("PLAIN").SetValue("value"),
+							*NewEnvironmentVariable().SetName("SECRET").SetSecretOptions(*NewSecretOptions().SetID("secret_id")),
+						),
+					*NewECSContainerDefinition().
+						SetName("sidecar").
+						SetImage("image"),
+				)
+		}
+
+		t.Run("SucceedsWithZero", func(t *testing.T) {
+			assert.NoError(t, NewECSOverridePodDefinitionOptions().ValidateAgainst(makeDefOpts()))
+		})
+		t.Run("SucceedsWithValidOverrides", func(t *testing.T) {
+			opts := NewECSOverridePodDefinitionOptions().
+				SetMemoryMB(2048).
+				SetCPU(2048).
+				AddContainerDefinitions(
+					*NewECSOverrideContainerDefinition().
+						SetName("main").
+						SetMemoryMB(1024).
+						SetCPU(1024).
+						AddEnvironmentVariables(*NewKeyValue().SetName("PLAIN").SetValue("override")),
+					*NewECSOverrideContainerDefinition().
+						SetName("sidecar").
+						SetMemoryMB(1024).
+						SetCPU(1024),
+				)
+			assert.NoError(t, opts.ValidateAgainst(makeDefOpts()))
+		})
+		t.Run("SucceedsWithSecretOverridingPlainEnvironmentVariable", func(t *testing.T) {
+			opts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+				SetName("main").
+				AddSecretEnvironmentVariables(*NewEnvironmentVariable().SetName("PLAIN").SetSecretOptions(*NewSecretOptions().SetID("other_id"))))
+			assert.NoError(t, opts.ValidateAgainst(makeDefOpts()))
+		})
+		t.Run("SucceedsWithResourceProfileLimits", func(t *testing.T) {
+			defOpts := makeDefOpts()
+			defOpts.CPU = nil
+			defOpts.MemoryMB = nil
+			defOpts.SetResourceProfile(ResourceProfileLarge)
+			opts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+				SetName("main").
+				SetMemoryMB(1024).
+				SetCPU(1024))
+			assert.NoError(t, opts.ValidateAgainst(defOpts))
+		})
+		t.Run("FailsWithContainerExceedingResourceProfileLimits", func(t *testing.T) {
+			defOpts := makeDefOpts()
+			defOpts.CPU = nil
+			defOpts.MemoryMB = nil
+			defOpts.SetResourceProfile(ResourceProfileSmall)
+			opts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+				SetName("main").
+				SetCPU(1024))
+			assert.Error(t, opts.ValidateAgainst(defOpts))
+		})
+		t.Run("FailsWithNonexistentContainer", func(t *testing.T) {
+			opts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+				SetName("nonexistent").
+				SetCommand([]string{"echo"}))
+			assert.Error(t, opts.ValidateAgainst(makeDefOpts()))
+		})
+		t.Run("FailsWithContainerMemoryExceedingPodMemory", func(t *testing.T) {
+			opts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+				SetName("main").
+				SetMemoryMB(1024))
+			assert.Error(t, opts.ValidateAgainst(makeDefOpts()))
+		})
+		t.Run("FailsWithContainerCPUExceedingPodCPU", func(t *testing.T) {
+			opts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+				SetName("main").
+				SetCPU(2048))
+			assert.Error(t, opts.ValidateAgainst(makeDefOpts()))
+		})
+		t.Run("FailsWithPodCPUBelowContainerCPU", func(t *testing.T) {
+			opts := NewECSOverridePodDefinitionOptions().SetCPU(256)
+			assert.Error(t, opts.ValidateAgainst(makeDefOpts()))
+		})
+		t.Run("FailsWithEnvironmentVariableCollidingWithSecret", func(t *testing.T) {
+			opts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+				SetName("main").
+				AddEnvironmentVariables(*NewKeyValue().SetName("SECRET").SetValue("value")))
+			assert.Error(t, opts.ValidateAgainst(makeDefOpts()))
+		})
+		t.Run("FailsWithEnvironmentVariableCollidingWithOverridingSecret", func(t *testing.T) {
+			opts := NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*NewECSOverrideContainerDefinition().
+				SetName("main").
+				AddEnvironmentVariables(*NewKeyValue().SetName("TOKEN").SetValue("value")).
+				AddSecretEnvironmentVariables(*NewEnvironmentVariable().SetName("TOKEN").SetSecretOptions(*NewSecretOptions().SetID("token_id"))))
+			assert.Error(t, opts.ValidateAgainst(makeDefOpts()))
+		})
+	})
 }
 
 func TestECSOverrideContainerDefinition(t *testing.T) {
@@ -1569,6 +3196,14 @@ func TestECSOverrideContainerDefinition(t *testing.T) {
 		def := NewECSOverrideContainerDefinition().SetCPU(mem)
 		assert.Equal(t, mem, utility.FromIntPtr(def.CPU))
 	})
+	t.Run("SetMemory", func(t *testing.T) {
+		def := NewECSOverrideContainerDefinition().SetMemory(GiB(1))
+		assert.Equal(t, 1024, utility.FromIntPtr(def.MemoryMB))
+	})
+	t.Run("SetCPUUnits", func(t *testing.T) {
+		def := NewECSOverrideContainerDefinition().SetCPUUnits(VCPUs(1))
+		assert.Equal(t, 1024, utility.FromIntPtr(def.CPU))
+	})
 	t.Run("SetEnvironmentVariables", func(t *testing.T) {
 		envVar := NewKeyValue().SetName("name").SetValue("value")
 		def := NewECSOverrideContainerDefinition().SetEnvironmentVariables([]KeyValue{*envVar})
@@ -1589,6 +3224,48 @@ func TestECSOverrideContainerDefinition(t *testing.T) {
 		def.AddEnvironmentVariables()
 		assert.ElementsMatch(t, envVars, def.EnvVars)
 	})
+	t.Run("SetBindMounts", func(t *testing.T) {
+		bm := NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data")
+		def := NewECSOverrideContainerDefinition().SetBindMounts([]BindMount{*bm})
+		require.Len(t, def.BindMounts, 1)
+		assert.Equal(t, *bm, def.BindMounts[0])
+
+		def.SetBindMounts(nil)
+		assert.Empty(t, def.BindMounts)
+	})
+	t.Run("AddBindMounts", func(t *testing.T) {
+		bms := []BindMount{
+			*NewBindMount().SetSourcePath("/scratch0").SetContainerPath("/data0"),
+			*NewBindMount().SetSourcePath("/scratch1").SetContainerPath("/data1"),
+		}
+		def := NewECSOverrideContainerDefinition().AddBindMounts(bms...)
+		assert.ElementsMatch(t, bms, def.BindMounts)
+
+		def.AddBindMounts()
+		assert.ElementsMatch(t, bms, def.BindMounts)
+	})
+	t.Run("SetSecretEnvironmentVariables", func(t *testing.T) {
+		ev := NewEnvironmentVariable().
+			SetName("SECRET").
+			SetSecretOptions(*NewSecretOptions().SetID("secret_id"))
+		def := NewECSOverrideContainerDefinition().SetSecretEnvironmentVariables([]EnvironmentVariable{*ev})
+		require.Len(t, def.SecretEnvVars, 1)
+		assert.Equal(t, *ev, def.SecretEnvVars[0])
+
+		def.SetSecretEnvironmentVariables(nil)
+		assert.Empty(t, def.SecretEnvVars)
+	})
+	t.Run("AddSecretEnvironmentVariables", func(t *testing.T) {
+		evs := []EnvironmentVariable{
+			*NewEnvironmentVariable().SetName("SECRET0").SetSecretOptions(*NewSecretOptions().SetID("secret_id0")),
+			*NewEnvironmentVariable().SetName("SECRET1").SetSecretOptions(*NewSecretOptions().SetID("secret_id1")),
+		}
+		def := NewECSOverrideContainerDefinition().AddSecretEnvironmentVariables(evs...)
+		assert.ElementsMatch(t, evs, def.SecretEnvVars)
+
+		def.AddSecretEnvironmentVariables()
+		assert.ElementsMatch(t, evs, def.SecretEnvVars)
+	})
 	t.Run("Validate", func(t *testing.T) {
 		t.Run("SucceedsWithJustName", func(t *testing.T) {
 			assert.NoError(t, NewECSOverrideContainerDefinition().SetName("name").Validate())
@@ -1631,6 +3308,48 @@ func TestECSOverrideContainerDefinition(t *testing.T) {
 				AddEnvironmentVariables(*NewKeyValue())
 			assert.Error(t, def.Validate())
 		})
+		t.Run("SucceedsWithValidBindMounts", func(t *testing.T) {
+			def := NewECSOverrideContainerDefinition().
+				SetName("name").
+				AddBindMounts(*NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data"))
+			assert.NoError(t, def.Validate())
+		})
+		t.Run("FailsWithInvalidBindMounts", func(t *testing.T) {
+			def := NewECSOverrideContainerDefinition().
+				SetName("name").
+				AddBindMounts(*NewBindMount())
+			assert.Error(t, def.Validate())
+		})
+		t.Run("SucceedsWithSecretEnvVarsReferencingExistingSecrets", func(t *testing.T) {
+			def := NewECSOverrideContainerDefinition().
+				SetName("name").
+				AddSecretEnvironmentVariables(*NewEnvironmentVariable().
+					SetName("SECRET").
+					SetSecretOptions(*NewSecretOptions().SetID("secret_id")))
+			assert.NoError(t, def.Validate())
+		})
+		t.Run("FailsWithSecretEnvVarCreatingNewSecret", func(t *testing.T) {
+			def := NewECSOverrideContainerDefinition().
+				SetName("name").
+				AddSecretEnvironmentVariables(*NewEnvironmentVariable().
+					SetName("SECRET").
+					SetSecretOptions(*NewSecretOptions().SetName("secret_name").SetNewValue("secret_value")))
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithSecretEnvVarWithPlaintextValue", func(t *testing.T) {
+			def := NewECSOverrideContainerDefinition().
+				SetName("name").
+				AddSecretEnvironmentVariables(*NewEnvironmentVariable().
+					SetName("SECRET").
+					SetValue("value"))
+			assert.Error(t, def.Validate())
+		})
+		t.Run("FailsWithInvalidSecretEnvVar", func(t *testing.T) {
+			def := NewECSOverrideContainerDefinition().
+				SetName("name").
+				AddSecretEnvironmentVariables(*NewEnvironmentVariable())
+			assert.Error(t, def.Validate())
+		})
 	})
 }
 
@@ -1645,6 +3364,18 @@ func TestECSPodPlacementOptions(t *testing.T) {
 		opts := NewECSPodPlacementOptions().SetGroup(group)
 		assert.Equal(t, group, utility.FromStringPtr(opts.Group))
 	})
+	t.Run("SetGroupFromPod", func(t *testing.T) {
+		t.Run("SetsGroupFromPodResources", func(t *testing.T) {
+			p := &resourcesOnlyPod{resources: *NewECSPodResources().SetGroup("group")}
+			opts := NewECSPodPlacementOptions().SetGroupFromPod(p)
+			assert.Equal(t, "group", utility.FromStringPtr(opts.Group))
+		})
+		t.Run("NoopsWithoutPodGroup", func(t *testing.T) {
+			p := &resourcesOnlyPod{resources: *NewECSPodResources()}
+			opts := NewECSPodPlacementOptions().SetGroup("group").SetGroupFromPod(p)
+			assert.Equal(t, "group", utility.FromStringPtr(opts.Group))
+		})
+	})
 	t.Run("SetStrategy", func(t *testing.T) {
 		strategy := StrategyBinpack
 		opts := NewECSPodPlacementOptions().SetStrategy(strategy)
@@ -1671,10 +3402,56 @@ func TestECSPodPlacementOptions(t *testing.T) {
 		require.Len(t, opts.InstanceFilters, 1)
 		assert.Equal(t, filter, opts.InstanceFilters[0])
 	})
+	t.Run("SetGroupNameTemplate", func(t *testing.T) {
+		opts := NewECSPodPlacementOptions().SetGroupNameTemplate("{app}-{env}")
+		assert.Equal(t, "{app}-{env}", utility.FromStringPtr(opts.GroupNameTemplate))
+	})
+	t.Run("SetGroupMetadata", func(t *testing.T) {
+		metadata := map[string]string{"app": "evergreen"}
+		opts := NewECSPodPlacementOptions().SetGroupMetadata(metadata)
+		assert.Equal(t, metadata, opts.GroupMetadata)
+	})
+	t.Run("AddGroupMetadata", func(t *testing.T) {
+		opts := NewECSPodPlacementOptions().
+			AddGroupMetadata(map[string]string{"app": "evergreen"}).
+			AddGroupMetadata(map[string]string{"env": "prod"})
+		assert.Equal(t, map[string]string{"app": "evergreen", "env": "prod"}, opts.GroupMetadata)
+	})
 	t.Run("Validate", func(t *testing.T) {
 		t.Run("SucceedsWithNoFieldsPopulated", func(t *testing.T) {
 			assert.NoError(t, NewECSPodPlacementOptions().Validate())
 		})
+		t.Run("ResolvesGroupFromTemplate", func(t *testing.T) {
+			opts := NewECSPodPlacementOptions().
+				SetGroupNameTemplate("{app}-{env}").
+				SetGroupMetadata(map[string]string{"app": "evergreen", "env": "prod"})
+			require.NoError(t, opts.Validate())
+			assert.Equal(t, "evergreen-prod", utility.FromStringPtr(opts.Group))
+
+			require.NoError(t, opts.Validate(), "validating again should succeed with the resolved group")
+			assert.Equal(t, "evergreen-prod", utility.FromStringPtr(opts.Group))
+		})
+		t.Run("SucceedsWithGroupMatchingTemplate", func(t *testing.T) {
+			opts := NewECSPodPlacementOptions().
+				SetGroup("evergreen-prod").
+				SetGroupNameTemplate("{app}-{env}").
+				SetGroupMetadata(map[string]string{"app": "evergreen", "env": "prod"})
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithGroupNotMatchingTemplate", func(t *testing.T) {
+			opts := NewECSPodPlacementOptions().
+				SetGroup("group").
+				SetGroupNameTemplate("{app}-{env}").
+				SetGroupMetadata(map[string]string{"app": "evergreen", "env": "prod"})
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithUndefinedTemplateMetadata", func(t *testing.T) {
+			opts := NewECSPodPlacementOptions().
+				SetGroupNameTemplate("{app}-{env}").
+				SetGroupMetadata(map[string]string{"app": "evergreen"})
+			assert.Error(t, opts.Validate())
+			assert.Zero(t, opts.Group)
+		})
 		t.Run("EmptyDefaultsToBinpackMemory", func(t *testing.T) {
 			var opts ECSPodPlacementOptions
 			require.NoError(t, opts.Validate())
@@ -1733,6 +3510,17 @@ func TestECSPodPlacementOptions(t *testing.T) {
 			assert.Equal(t, StrategySpread, *opts.Strategy)
 			assert.Equal(t, "custom", utility.FromStringPtr(opts.StrategyParameter))
 		})
+		t.Run("SucceedsWithNoStrategy", func(t *testing.T) {
+			opts := NewECSPodPlacementOptions().SetStrategy(StrategyNone)
+			require.NoError(t, opts.Validate())
+			require.NotZero(t, opts.Strategy)
+			assert.Equal(t, StrategyNone, *opts.Strategy)
+			assert.Zero(t, opts.StrategyParameter, "strategy parameter should not be defaulted")
+		})
+		t.Run("FailsWithNoStrategyAndParameter", func(t *testing.T) {
+			opts := NewECSPodPlacementOptions().SetStrategy(StrategyNone).SetStrategyParameter(StrategyParamBinpackMemory)
+			assert.Error(t, opts.Validate())
+		})
 		t.Run("SucceedsWithNonemptyGroupName", func(t *testing.T) {
 			opts := NewECSPodPlacementOptions().SetGroup("group")
 			assert.NoError(t, opts.Validate())
@@ -1741,7 +3529,40 @@ func TestECSPodPlacementOptions(t *testing.T) {
 			opts := NewECSPodPlacementOptions().SetGroup("")
 			assert.Error(t, opts.Validate())
 		})
+		t.Run("SucceedsWithImagePullBehavior", func(t *testing.T) {
+			opts := NewECSPodPlacementOptions().SetImagePullBehavior(ImagePullBehaviorPreferCached)
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithInvalidImagePullBehavior", func(t *testing.T) {
+			opts := NewECSPodPlacementOptions().SetImagePullBehavior("foo")
+			assert.Error(t, opts.Validate())
+		})
+	})
+}
+
+func TestImagePullBehavior(t *testing.T) {
+	t.Run("Validate", func(t *testing.T) {
+		for _, b := range []ImagePullBehavior{ImagePullBehaviorDefault, ImagePullBehaviorAlways, ImagePullBehaviorOnce, ImagePullBehaviorPreferCached} {
+			assert.NoError(t, b.Validate(), b)
+		}
+		assert.Error(t, ImagePullBehavior("").Validate())
+		assert.Error(t, ImagePullBehavior("foo").Validate())
 	})
+	t.Run("UsesCachedImages", func(t *testing.T) {
+		assert.False(t, ImagePullBehaviorDefault.UsesCachedImages())
+		assert.False(t, ImagePullBehaviorAlways.UsesCachedImages())
+		assert.True(t, ImagePullBehaviorOnce.UsesCachedImages())
+		assert.True(t, ImagePullBehaviorPreferCached.UsesCachedImages())
+	})
+}
+
+func TestIsPublicImage(t *testing.T) {
+	assert.True(t, IsPublicImage("public.ecr.aws/docker/library/alpine:3.18"))
+	assert.True(t, IsPublicImage("PUBLIC.ECR.AWS/docker/library/alpine:3.18"))
+	assert.False(t, IsPublicImage("alpine:3.18"))
+	assert.False(t, IsPublicImage("docker.io/library/alpine:3.18"))
+	assert.False(t, IsPublicImage("123456789012.dkr.ecr.us-east-1.amazonaws.com/image:tag"))
+	assert.False(t, IsPublicImage("public.ecr.aws"))
 }
 
 func TestAWSVPCOptions(t *testing.T) {
@@ -1833,3 +3654,13 @@ func TestECSTaskDefinition(t *testing.T) {
 		})
 	})
 }
+
+// resourcesOnlyPod is an ECSPod that only supports returning its resources.
+type resourcesOnlyPod struct {
+	ECSPod
+	resources ECSPodResources
+}
+
+func (p *resourcesOnlyPod) Resources() ECSPodResources {
+	return p.resources
+}
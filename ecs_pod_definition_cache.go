@@ -14,3 +14,16 @@ type ECSPodDefinitionCache interface {
 	// definition. Implementations are allowed to return an empty string.
 	GetTag() string
 }
+
+// ECSPodDefinitionRunCache represents an external cache that tracks pod
+// definitions along with the last pod run for each external key. Caches that
+// implement it allow pods to be created idempotently.
+type ECSPodDefinitionRunCache interface {
+	ECSPodDefinitionCache
+	// PutRun records the item's last run for the external key in the item's
+	// LastRun, replacing any run previously recorded for the same key.
+	PutRun(ctx context.Context, item ECSPodDefinitionItem) error
+	// GetRun returns the item whose last run was for the given external key.
+	// If no run is recorded for the key, it returns nil.
+	GetRun(ctx context.Context, key string) (*ECSPodDefinitionItem, error)
+}
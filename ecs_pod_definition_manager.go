@@ -1,6 +1,11 @@
 package cocoa
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/mongodb/grip"
+)
 
 // ECSPodDefinitionItem represents an item that can be cached in a
 // ECSPodDefinitionCache.
@@ -10,6 +15,29 @@ type ECSPodDefinitionItem struct {
 	ID string
 	// DefinitionOpts are the options used to create the pod definition.
 	DefinitionOpts ECSPodDefinitionOptions
+	// Hash is the hash of the pod definition options that were requested
+	// when the pod definition was created, so caches can key the item by it
+	// to find the pod definition again for the same options. It is empty if
+	// the hash is not known.
+	Hash string
+	// LastRun is the last pod that was run from the pod definition for an
+	// external key. This is only set for caches that track pod runs.
+	LastRun *ECSPodRun
+	// Prewarmed is whether or not the pod definition was registered ahead of
+	// time so that it can be shared between many pods. Pods that run from a
+	// prewarmed pod definition do not own the pod definition or its secrets.
+	Prewarmed bool
+}
+
+// ECSPodRun identifies a pod that was run for a caller-defined external key.
+type ECSPodRun struct {
+	// Key is the caller-defined key that uniquely identifies the work that the
+	// pod runs.
+	Key string
+	// Cluster is the name of the cluster in which the pod runs.
+	Cluster string
+	// TaskID is the unique identifier for the pod's ECS task.
+	TaskID string
 }
 
 // ECSPodDefinitionManager manages pod definitions, which are configuration
@@ -21,3 +49,117 @@ type ECSPodDefinitionManager interface {
 	// should ensure that deletion is idempotent.
 	DeletePodDefinition(ctx context.Context, id string) error
 }
+
+// ECSPodDefinitionRevisionLister represents a pod definition manager that can
+// list the revisions of a pod definition family.
+type ECSPodDefinitionRevisionLister interface {
+	ECSPodDefinitionManager
+	// ListPodDefinitionRevisions lists all the revisions of the pod definition
+	// family, including revisions that are no longer active.
+	ListPodDefinitionRevisions(ctx context.Context, family string) ([]ECSPodDefinitionRevision, error)
+}
+
+// ECSPodDefinitionImporter represents a pod definition manager that can import
+// existing pod definitions that were created outside of it.
+type ECSPodDefinitionImporter interface {
+	ECSPodDefinitionManager
+	// ImportPodDefinition imports an existing pod definition that was created
+	// outside of the pod definition manager so that it can be managed like any
+	// other pod definition.
+	ImportPodDefinition(ctx context.Context, id string) (*ECSPodDefinitionItem, error)
+}
+
+// ECSPodDefinitionUpdater represents a pod definition manager that can update
+// existing pod definitions by creating new revisions of them.
+type ECSPodDefinitionUpdater interface {
+	ECSPodDefinitionManager
+	// UpdatePodDefinition creates a new revision in the same family as an
+	// existing pod definition and replaces the existing pod definition in the
+	// cache with the new revision.
+	UpdatePodDefinition(ctx context.Context, id string, opts ECSPodDefinitionUpdateOptions) (*ECSPodDefinitionItem, error)
+}
+
+// ECSPodDefinitionIntegrityVerifier represents a pod definition manager that
+// can detect pod definitions that were modified outside of it.
+type ECSPodDefinitionIntegrityVerifier interface {
+	ECSPodDefinitionManager
+	// VerifyDefinitionIntegrity checks that an existing pod definition has not
+	// been modified outside of the pod definition manager since it was
+	// created. Implementations should return a DefinitionDriftError if it has.
+	VerifyDefinitionIntegrity(ctx context.Context, id string) error
+}
+
+// ECSPodDefinitionUpdateOptions are options to update an existing pod
+// definition by creating a new revision of it.
+type ECSPodDefinitionUpdateOptions struct {
+	// DefinitionOpts are the options for the new revision. The new revision is
+	// always created in the same family as the existing pod definition, so if
+	// a name is specified, it must match the existing pod definition's family.
+	DefinitionOpts *ECSPodDefinitionOptions
+	// DeregisterPrevious indicates whether or not the existing pod definition
+	// should be deleted once the new revision is created. By default, the
+	// existing pod definition remains active.
+	DeregisterPrevious *bool
+}
+
+// NewECSPodDefinitionUpdateOptions returns new uninitialized options to update
+// a pod definition.
+func NewECSPodDefinitionUpdateOptions() *ECSPodDefinitionUpdateOptions {
+	return &ECSPodDefinitionUpdateOptions{}
+}
+
+// SetDefinitionOptions sets the options for the new revision.
+func (o *ECSPodDefinitionUpdateOptions) SetDefinitionOptions(opts ECSPodDefinitionOptions) *ECSPodDefinitionUpdateOptions {
+	o.DefinitionOpts = &opts
+	return o
+}
+
+// SetDeregisterPrevious sets whether or not the existing pod definition should
+// be deleted once the new revision is created.
+func (o *ECSPodDefinitionUpdateOptions) SetDeregisterPrevious(deregister bool) *ECSPodDefinitionUpdateOptions {
+	o.DeregisterPrevious = &deregister
+	return o
+}
+
+// Validate checks that the options for the new revision are given. The
+// options for the new revision are fully validated when the revision is
+// created, since its name depends on the existing pod definition.
+func (o *ECSPodDefinitionUpdateOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.DefinitionOpts == nil, "must specify options for the new revision")
+	return catcher.Resolve()
+}
+
+// ECSPodDefinitionStatus represents the status of a pod definition revision.
+type ECSPodDefinitionStatus string
+
+const (
+	// PodDefinitionStatusActive indicates that the pod definition revision
+	// can be used to run new pods.
+	PodDefinitionStatusActive ECSPodDefinitionStatus = "active"
+	// PodDefinitionStatusInactive indicates that the pod definition revision
+	// has been deleted and can no longer be used to run new pods.
+	PodDefinitionStatusInactive ECSPodDefinitionStatus = "inactive"
+	// PodDefinitionStatusUnknown indicates that the status of the pod
+	// definition revision cannot be determined.
+	PodDefinitionStatusUnknown ECSPodDefinitionStatus = "unknown"
+)
+
+// ECSPodDefinitionRevision represents a single revision of a pod definition
+// family.
+type ECSPodDefinitionRevision struct {
+	// ID is the unique identifier in ECS for the pod definition revision.
+	ID string
+	// Family is the name of the pod definition family that the revision
+	// belongs to.
+	Family string
+	// Revision is the revision number within the family.
+	Revision int
+	// Status is the current status of the revision.
+	Status ECSPodDefinitionStatus
+	// RegisteredAt is the time at which the revision was registered.
+	RegisteredAt *time.Time
+	// DeregisteredAt is the time at which the revision was deleted, if it has
+	// been deleted.
+	DeregisteredAt *time.Time
+}
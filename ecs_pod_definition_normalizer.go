@@ -0,0 +1,110 @@
+package cocoa
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+// DefinitionNormalizer normalizes pod definitions so that logically identical
+// definitions produced by different callers are represented the same way. This
+// makes them converge to the same hash and reuse the same pod definition.
+type DefinitionNormalizer interface {
+	// Normalize returns the normalized pod definition. Implementations must
+	// not modify the given pod definition.
+	Normalize(opts ECSPodDefinitionOptions) (ECSPodDefinitionOptions, error)
+}
+
+// DefinitionNormalizerFunc is an adapter to allow the use of ordinary functions
+// as definition normalizers.
+type DefinitionNormalizerFunc func(opts ECSPodDefinitionOptions) (ECSPodDefinitionOptions, error)
+
+// Normalize returns the result of calling the function on the pod definition.
+func (f DefinitionNormalizerFunc) Normalize(opts ECSPodDefinitionOptions) (ECSPodDefinitionOptions, error) {
+	return f(opts)
+}
+
+// NormalizePodDefinition applies the normalizers to the pod definition in the
+// order that they're given.
+func NormalizePodDefinition(opts ECSPodDefinitionOptions, normalizers ...DefinitionNormalizer) (ECSPodDefinitionOptions, error) {
+	for i, n := range normalizers {
+		normalized, err := n.Normalize(opts)
+		if err != nil {
+			return ECSPodDefinitionOptions{}, errors.Wrapf(err, "applying normalizer at index %d", i)
+		}
+		opts = normalized
+	}
+	return opts, nil
+}
+
+// SortEnvironmentVariablesNormalizer returns a normalizer that sorts the
+// environment variables in each container definition by name.
+func SortEnvironmentVariablesNormalizer() DefinitionNormalizer {
+	return DefinitionNormalizerFunc(func(opts ECSPodDefinitionOptions) (ECSPodDefinitionOptions, error) {
+		opts.ContainerDefinitions = copyContainerDefinitions(opts.ContainerDefinitions)
+		for i := range opts.ContainerDefinitions {
+			envVars := append([]EnvironmentVariable{}, opts.ContainerDefinitions[i].EnvVars...)
+			sort.SliceStable(envVars, func(j, k int) bool {
+				return utility.FromStringPtr(envVars[j].Name) < utility.FromStringPtr(envVars[k].Name)
+			})
+			if len(envVars) != 0 {
+				opts.ContainerDefinitions[i].EnvVars = envVars
+			}
+		}
+		return opts, nil
+	})
+}
+
+// LowercaseImageHostsNormalizer returns a normalizer that lowercases the
+// registry host of each container's image. Registry hosts are
+// case-insensitive, but the rest of the image reference is not modified.
+func LowercaseImageHostsNormalizer() DefinitionNormalizer {
+	return DefinitionNormalizerFunc(func(opts ECSPodDefinitionOptions) (ECSPodDefinitionOptions, error) {
+		opts.ContainerDefinitions = copyContainerDefinitions(opts.ContainerDefinitions)
+		for i := range opts.ContainerDefinitions {
+			if opts.ContainerDefinitions[i].Image == nil {
+				continue
+			}
+			image := lowercaseImageHost(*opts.ContainerDefinitions[i].Image)
+			opts.ContainerDefinitions[i].Image = &image
+		}
+		return opts, nil
+	})
+}
+
+// lowercaseImageHost lowercases the registry host in the image reference, if it
+// has one.
+func lowercaseImageHost(image string) string {
+	host := imageRegistryHost(image)
+	if host == "" {
+		return image
+	}
+	return strings.ToLower(host) + image[len(host):]
+}
+
+// imageRegistryHost returns the registry host in the image reference. If the
+// image reference does not explicitly include a registry host, it returns an
+// empty string. Following Docker's convention, the first component of the
+// image is a registry host if it contains a '.' or ':' or is "localhost".
+func imageRegistryHost(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	host := parts[0]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return ""
+	}
+	return host
+}
+
+// copyContainerDefinitions returns a shallow copy of the container definitions
+// so that normalizers can modify them without affecting the original.
+func copyContainerDefinitions(defs []ECSContainerDefinition) []ECSContainerDefinition {
+	if defs == nil {
+		return nil
+	}
+	return append([]ECSContainerDefinition{}, defs...)
+}
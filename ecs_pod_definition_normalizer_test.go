@@ -0,0 +1,87 @@
+package cocoa
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePodDefinition(t *testing.T) {
+	makeOpts := func() ECSPodDefinitionOptions {
+		return *NewECSPodDefinitionOptions().
+			SetName("name").
+			AddContainerDefinitions(*NewECSContainerDefinition().
+				SetImage("Registry.Example.com/Repo/Image:Tag").
+				AddEnvironmentVariables(
+					*NewEnvironmentVariable().SetName("b").SetValue("value"),
+					*NewEnvironmentVariable().SetName("a").SetValue("value"),
+				))
+	}
+
+	t.Run("ReturnsUnmodifiedWithoutNormalizers", func(t *testing.T) {
+		opts := makeOpts()
+		normalized, err := NormalizePodDefinition(opts)
+		require.NoError(t, err)
+		assert.Equal(t, opts, normalized)
+	})
+	t.Run("AppliesNormalizersInOrder", func(t *testing.T) {
+		var order []string
+		makeNormalizer := func(name string) DefinitionNormalizer {
+			return DefinitionNormalizerFunc(func(opts ECSPodDefinitionOptions) (ECSPodDefinitionOptions, error) {
+				order = append(order, name)
+				return *opts.SetName(utility.FromStringPtr(opts.Name) + "-" + name), nil
+			})
+		}
+		normalized, err := NormalizePodDefinition(makeOpts(), makeNormalizer("first"), makeNormalizer("second"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first", "second"}, order)
+		assert.Equal(t, "name-first-second", utility.FromStringPtr(normalized.Name))
+	})
+	t.Run("FailsWithNormalizerError", func(t *testing.T) {
+		_, err := NormalizePodDefinition(makeOpts(), DefinitionNormalizerFunc(func(ECSPodDefinitionOptions) (ECSPodDefinitionOptions, error) {
+			return ECSPodDefinitionOptions{}, errors.New("fake error")
+		}))
+		assert.Error(t, err)
+	})
+	t.Run("SortEnvironmentVariablesNormalizer", func(t *testing.T) {
+		opts := makeOpts()
+		normalized, err := SortEnvironmentVariablesNormalizer().Normalize(opts)
+		require.NoError(t, err)
+		require.Len(t, normalized.ContainerDefinitions, 1)
+		envVars := normalized.ContainerDefinitions[0].EnvVars
+		require.Len(t, envVars, 2)
+		assert.Equal(t, "a", utility.FromStringPtr(envVars[0].Name))
+		assert.Equal(t, "b", utility.FromStringPtr(envVars[1].Name))
+
+		assert.Equal(t, "b", utility.FromStringPtr(opts.ContainerDefinitions[0].EnvVars[0].Name), "original should not be modified")
+	})
+	t.Run("LowercaseImageHostsNormalizer", func(t *testing.T) {
+		opts := makeOpts()
+		normalized, err := LowercaseImageHostsNormalizer().Normalize(opts)
+		require.NoError(t, err)
+		require.Len(t, normalized.ContainerDefinitions, 1)
+		assert.Equal(t, "registry.example.com/Repo/Image:Tag", utility.FromStringPtr(normalized.ContainerDefinitions[0].Image))
+
+		assert.Equal(t, "Registry.Example.com/Repo/Image:Tag", utility.FromStringPtr(opts.ContainerDefinitions[0].Image), "original should not be modified")
+	})
+}
+
+func TestLowercaseImageHost(t *testing.T) {
+	for image, expected := range map[string]string{
+		"image":                         "image",
+		"image:Tag":                     "image:Tag",
+		"Library/Image":                 "Library/Image",
+		"LocalHost/image":               "LocalHost/image",
+		"localhost/Image":               "localhost/Image",
+		"Registry.Example.com/Image":    "registry.example.com/Image",
+		"Registry:5000/Repo/Image:Tag":  "registry:5000/Repo/Image:Tag",
+		"123456789012.dkr.ECR.aws/Repo": "123456789012.dkr.ecr.aws/Repo",
+	} {
+		t.Run(image, func(t *testing.T) {
+			assert.Equal(t, expected, lowercaseImageHost(image))
+		})
+	}
+}
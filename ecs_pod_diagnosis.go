@@ -0,0 +1,55 @@
+package cocoa
+
+import "time"
+
+// PendingCause is the cause of a pod being stuck before it starts running.
+type PendingCause string
+
+const (
+	// PendingCauseNone indicates that the pod is not stuck because it is no
+	// longer pending.
+	PendingCauseNone PendingCause = "none"
+	// PendingCauseUnknown indicates that the pod is pending, but the cause
+	// cannot be determined.
+	PendingCauseUnknown PendingCause = "unknown"
+	// PendingCauseInsufficientCapacity indicates that the pod cannot be placed
+	// because there is not enough CPU or memory available in the cluster.
+	PendingCauseInsufficientCapacity PendingCause = "insufficient-capacity"
+	// PendingCauseImagePull indicates that the pod cannot start because one of
+	// its container images cannot be pulled.
+	PendingCauseImagePull PendingCause = "image-pull"
+	// PendingCauseENILimit indicates that the pod cannot start because no
+	// more elastic network interfaces can be attached for its networking.
+	PendingCauseENILimit PendingCause = "eni-limit"
+)
+
+// ECSPodPendingDiagnosis describes why a pod is stuck before it starts running.
+// The cause is machine-readable, whereas the message is a human-readable
+// explanation of the cause.
+type ECSPodPendingDiagnosis struct {
+	// Cause is the most likely cause of the pod being stuck.
+	Cause PendingCause
+	// Message is a human-readable explanation of the cause.
+	Message string
+	// Status is the pod's status at the time of the diagnosis.
+	Status ECSStatus
+	// StopCode is the code that ECS gave for stopping the pod, if it has
+	// stopped.
+	StopCode string
+	// StoppedReason is the reason that ECS gave for stopping the pod, if it
+	// has stopped.
+	StoppedReason string
+	// RecentFailures are the failures that recently occurred when trying to
+	// run pods in the same cluster and group as the pod.
+	RecentFailures []RunTaskFailure
+}
+
+// RunTaskFailure is a failure to run a pod in ECS.
+type RunTaskFailure struct {
+	// Reason is the reason that ECS gave for the failure (e.g. "RESOURCE:CPU").
+	Reason string
+	// Detail is additional detail that ECS gave about the failure, if any.
+	Detail string
+	// Time is when the failure occurred.
+	Time time.Time
+}
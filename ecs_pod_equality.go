@@ -0,0 +1,260 @@
+package cocoa
+
+import "github.com/evergreen-ci/utility"
+
+// Equals returns whether or not the pod definition options are semantically
+// equivalent to the other pod definition options. Container definitions and
+// tags are compared regardless of their order.
+func (o *ECSPodDefinitionOptions) Equals(other ECSPodDefinitionOptions) bool {
+	if !equalPtrs(o.Name, other.Name) ||
+		!equalPtrs(o.effectiveMemoryMB(), other.effectiveMemoryMB()) ||
+		!equalPtrs(o.effectiveCPU(), other.effectiveCPU()) ||
+		!equalPtrs(o.NetworkMode, other.NetworkMode) ||
+		!equalPtrs(o.TaskRole, other.TaskRole) ||
+		!equalPtrs(o.ExecutionRole, other.ExecutionRole) {
+		return false
+	}
+
+	if !equalMaps(o.Tags, other.Tags) {
+		return false
+	}
+
+	return equalUnordered(o.ContainerDefinitions, other.ContainerDefinitions, func(a, b ECSContainerDefinition) bool {
+		return a.Equals(b)
+	})
+}
+
+// Equals returns whether or not the container definition is semantically
+// equivalent to the other container definition. Environment variables, port
+// mappings, bind mounts, tmpfs mounts, extra hosts and volumes from other
+// containers are compared regardless of their order.
+func (d *ECSContainerDefinition) Equals(other ECSContainerDefinition) bool {
+	if !equalPtrs(d.Name, other.Name) ||
+		!equalPtrs(d.Image, other.Image) ||
+		!equalPtrs(d.WorkingDir, other.WorkingDir) ||
+		!equalPtrs(d.MemoryMB, other.MemoryMB) ||
+		!equalPtrs(d.CPU, other.CPU) ||
+		utility.FromBoolPtr(d.Interactive) != utility.FromBoolPtr(other.Interactive) ||
+		utility.FromBoolPtr(d.PseudoTerminal) != utility.FromBoolPtr(other.PseudoTerminal) ||
+		!equalPtrs(d.Hostname, other.Hostname) ||
+		!equalPtrs(d.User, other.User) ||
+		!equalPtrs(d.SharedMemorySizeMB, other.SharedMemorySizeMB) {
+		return false
+	}
+
+	if len(d.Command) != len(other.Command) {
+		return false
+	}
+	for i := range d.Command {
+		if d.Command[i] != other.Command[i] {
+			return false
+		}
+	}
+
+	if (d.RepoCreds == nil) != (other.RepoCreds == nil) {
+		return false
+	}
+	if d.RepoCreds != nil && !d.RepoCreds.Equals(*other.RepoCreds) {
+		return false
+	}
+
+	if (d.LogConfiguration == nil) != (other.LogConfiguration == nil) {
+		return false
+	}
+	if d.LogConfiguration != nil && !d.LogConfiguration.Equals(*other.LogConfiguration) {
+		return false
+	}
+
+	if !equalUnordered(d.EnvVars, other.EnvVars, func(a, b EnvironmentVariable) bool {
+		return a.Equals(b)
+	}) {
+		return false
+	}
+
+	if !equalUnordered(d.PortMappings, other.PortMappings, func(a, b PortMapping) bool {
+		return a.Equals(b)
+	}) {
+		return false
+	}
+
+	if !equalUnordered(d.BindMounts, other.BindMounts, func(a, b BindMount) bool {
+		return a.Equals(b)
+	}) {
+		return false
+	}
+
+	if !equalUnordered(d.TmpfsMounts, other.TmpfsMounts, func(a, b TmpfsMount) bool {
+		return a.Equals(b)
+	}) {
+		return false
+	}
+
+	if !equalUnordered(d.ExtraHosts, other.ExtraHosts, func(a, b HostEntry) bool {
+		return a.Equals(b)
+	}) {
+		return false
+	}
+
+	return equalUnordered(d.VolumesFrom, other.VolumesFrom, func(a, b VolumeFrom) bool {
+		return a.Equals(b)
+	})
+}
+
+// Equals returns whether or not the environment variable is semantically
+// equivalent to the other environment variable.
+func (e *EnvironmentVariable) Equals(other EnvironmentVariable) bool {
+	if !equalPtrs(e.Name, other.Name) || !equalPtrs(e.Value, other.Value) {
+		return false
+	}
+
+	if (e.SecretOpts == nil) != (other.SecretOpts == nil) {
+		return false
+	}
+	if e.SecretOpts != nil && !e.SecretOpts.Equals(*other.SecretOpts) {
+		return false
+	}
+
+	return true
+}
+
+// Equals returns whether or not the secret options are semantically equivalent
+// to the other secret options.
+func (s *SecretOptions) Equals(other SecretOptions) bool {
+	return equalPtrs(s.ID, other.ID) &&
+		equalPtrs(s.Name, other.Name) &&
+		equalPtrs(s.NewValue, other.NewValue) &&
+		equalPtrs(s.Owned, other.Owned) &&
+		equalMaps(s.Tags, other.Tags)
+}
+
+// Equals returns whether or not the log configuration is semantically
+// equivalent to the other log configuration. Options and secret options are
+// compared regardless of their order.
+func (c *LogConfiguration) Equals(other LogConfiguration) bool {
+	if !equalPtrs(c.LogDriver, other.LogDriver) || !equalMaps(c.Options, other.Options) {
+		return false
+	}
+
+	return equalUnordered(c.SecretOptions, other.SecretOptions, func(a, b EnvironmentVariable) bool {
+		return a.Equals(b)
+	})
+}
+
+// Equals returns whether or not the repository credentials are semantically
+// equivalent to the other repository credentials.
+func (c *RepositoryCredentials) Equals(other RepositoryCredentials) bool {
+	if !equalPtrs(c.ID, other.ID) || !equalPtrs(c.Name, other.Name) || !equalPtrs(c.Owned, other.Owned) {
+		return false
+	}
+	if utility.FromBoolPtr(c.CreateIfMissing) != utility.FromBoolPtr(other.CreateIfMissing) {
+		return false
+	}
+	if utility.FromBoolPtr(c.SkipForPublicImages) != utility.FromBoolPtr(other.SkipForPublicImages) {
+		return false
+	}
+
+	if (c.NewCreds == nil) != (other.NewCreds == nil) {
+		return false
+	}
+	if c.NewCreds != nil && !c.NewCreds.Equals(*other.NewCreds) {
+		return false
+	}
+
+	return true
+}
+
+// Equals returns whether or not the stored repository credentials are
+// semantically equivalent to the other stored repository credentials.
+func (c *StoredRepositoryCredentials) Equals(other StoredRepositoryCredentials) bool {
+	return equalPtrs(c.Username, other.Username) && equalPtrs(c.Password, other.Password)
+}
+
+// Equals returns whether or not the port mapping is semantically equivalent to
+// the other port mapping. An unset protocol is equivalent to TCP.
+func (m *PortMapping) Equals(other PortMapping) bool {
+	return equalPtrs(m.ContainerPort, other.ContainerPort) &&
+		equalPtrs(m.HostPort, other.HostPort) &&
+		m.protocol() == other.protocol() &&
+		equalPtrs(m.Name, other.Name)
+}
+
+// Equals returns whether or not the bind mount is semantically equivalent to
+// the other bind mount. An unset read-only flag is equivalent to false.
+func (m *BindMount) Equals(other BindMount) bool {
+	return equalPtrs(m.SourcePath, other.SourcePath) &&
+		equalPtrs(m.ContainerPath, other.ContainerPath) &&
+		utility.FromBoolPtr(m.ReadOnly) == utility.FromBoolPtr(other.ReadOnly)
+}
+
+// Equals returns whether or not the tmpfs mount is equivalent to the other tmpfs
+// mount. Mount options are compared regardless of their order.
+func (m *TmpfsMount) Equals(other TmpfsMount) bool {
+	return equalPtrs(m.ContainerPath, other.ContainerPath) &&
+		equalPtrs(m.SizeMB, other.SizeMB) &&
+		equalUnordered(m.MountOptions, other.MountOptions, func(a, b string) bool {
+			return a == b
+		})
+}
+
+// Equals returns whether or not the host entry is equivalent to the other host
+// entry.
+func (h *HostEntry) Equals(other HostEntry) bool {
+	return equalPtrs(h.Hostname, other.Hostname) &&
+		equalPtrs(h.IPAddress, other.IPAddress)
+}
+
+// Equals returns whether or not the volume from another container is equivalent
+// to the other volume from another container.
+func (v *VolumeFrom) Equals(other VolumeFrom) bool {
+	return equalPtrs(v.SourceContainer, other.SourceContainer) &&
+		utility.FromBoolPtr(v.ReadOnly) == utility.FromBoolPtr(other.ReadOnly)
+}
+
+// equalPtrs returns whether or not the two pointers are either both nil or
+// both point to equal values.
+func equalPtrs[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// equalMaps returns whether or not the two maps contain the same key-value
+// pairs. A nil map is equal to an empty map.
+func equalMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, va := range a {
+		if vb, ok := b[k]; !ok || va != vb {
+			return false
+		}
+	}
+	return true
+}
+
+// equalUnordered returns whether or not the two slices contain the same
+// elements regardless of their order. A nil slice is equal to an empty slice.
+func equalUnordered[T any](a, b []T, equal func(x, y T) bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	matched := make([]bool, len(b))
+	for _, x := range a {
+		found := false
+		for j, y := range b {
+			if matched[j] || !equal(x, y) {
+				continue
+			}
+			matched[j] = true
+			found = true
+			break
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
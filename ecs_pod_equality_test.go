@@ -0,0 +1,272 @@
+package cocoa
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestECSPodDefinitionOptionsEquals(t *testing.T) {
+	makeOpts := func() ECSPodDefinitionOptions {
+		ev0 := NewEnvironmentVariable().SetName("ev0").SetValue("val0")
+		ev1 := NewEnvironmentVariable().SetName("ev1").SetSecretOptions(*NewSecretOptions().SetName("secret").SetNewValue("val1").SetOwned(true))
+		pm0 := NewPortMapping().SetContainerPort(1337)
+		pm1 := NewPortMapping().SetContainerPort(9001).SetHostPort(9002)
+		creds := NewRepositoryCredentials().
+			SetName("creds").
+			SetNewCredentials(*NewStoredRepositoryCredentials().SetUsername("username").SetPassword("password"))
+		lc := NewLogConfiguration().
+			SetLogDriver(string(types.LogDriverAwslogs)).
+			SetOptions(map[string]string{"awslogs-group": "group", "awslogs-region": "region"})
+		containerDef0 := NewECSContainerDefinition().
+			SetName("container0").
+			SetImage("image").
+			SetCommand([]string{"echo", "foo"}).
+			SetWorkingDir("working_dir").
+			SetMemoryMB(128).
+			SetCPU(256).
+			AddEnvironmentVariables(*ev0, *ev1).
+			AddPortMappings(*pm0, *pm1).
+			SetRepositoryCredentials(*creds).
+			SetLogConfiguration(*lc).
+			AddBindMounts(*NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data")).
+			AddTmpfsMounts(
+				*NewTmpfsMount().SetContainerPath("/tmp").SetSizeMB(64).AddMountOptions("noexec", "nosuid"),
+				*NewTmpfsMount().SetContainerPath("/run").SetSizeMB(32),
+			).
+			SetSharedMemorySizeMB(128)
+		containerDef1 := NewECSContainerDefinition().
+			SetName("container1").
+			SetImage("image")
+		return *NewECSPodDefinitionOptions().
+			SetName("name").
+			SetMemoryMB(512).
+			SetCPU(1024).
+			SetNetworkMode(NetworkModeAWSVPC).
+			SetTaskRole("task_role").
+			SetExecutionRole("execution_role").
+			SetTags(map[string]string{"key0": "val0", "key1": "val1"}).
+			AddContainerDefinitions(*containerDef0, *containerDef1)
+	}
+
+	t.Run("ReturnsTrueForIdenticalOptions", func(t *testing.T) {
+		opts := makeOpts()
+		assert.True(t, opts.Equals(makeOpts()))
+	})
+	t.Run("ReturnsTrueForEmptyOptions", func(t *testing.T) {
+		opts := NewECSPodDefinitionOptions()
+		assert.True(t, opts.Equals(ECSPodDefinitionOptions{}))
+	})
+	t.Run("ReturnsTrueForReorderedContainerDefinitions", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0], other.ContainerDefinitions[1] = other.ContainerDefinitions[1], other.ContainerDefinitions[0]
+		assert.True(t, opts.Equals(other))
+	})
+	t.Run("ReturnsTrueForReorderedEnvironmentVariablesAndPortMappings", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		def := &other.ContainerDefinitions[0]
+		def.EnvVars[0], def.EnvVars[1] = def.EnvVars[1], def.EnvVars[0]
+		def.PortMappings[0], def.PortMappings[1] = def.PortMappings[1], def.PortMappings[0]
+		assert.True(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentName", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.SetName("other")
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForMissingField", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.TaskRole = nil
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentTags", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.SetTags(map[string]string{"key0": "val0"})
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForReorderedCommand", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].SetCommand([]string{"foo", "echo"})
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentSecret", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].EnvVars[1].SecretOpts.SetNewValue("other")
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentSecretTags", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].EnvVars[1].SecretOpts.SetTags(map[string]string{"key": "value"})
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentRepositoryCredentials", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].RepoCreds.NewCreds.SetPassword("other")
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentRepositoryCredentialsCreateIfMissing", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].RepoCreds.SetCreateIfMissing(true)
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForMissingLogConfiguration", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].LogConfiguration = nil
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentPortMapping", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].PortMappings[0].SetHostPort(1)
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsTrueForUnsetAndTCPPortMappingProtocol", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].PortMappings[0].SetProtocol(PortProtocolTCP)
+		assert.True(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentPortMappingProtocol", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].PortMappings[0].SetProtocol(PortProtocolUDP)
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentPortMappingName", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].PortMappings[0].SetName("name")
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentBindMount", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].BindMounts[0].SetReadOnly(true)
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsTrueForUnsetAndFalseBindMountReadOnly", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].BindMounts[0].SetReadOnly(false)
+		assert.True(t, opts.Equals(other))
+	})
+	t.Run("ReturnsTrueForReorderedTmpfsMounts", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		mounts := other.ContainerDefinitions[0].TmpfsMounts
+		mounts[0], mounts[1] = mounts[1], mounts[0]
+		assert.True(t, opts.Equals(other))
+	})
+	t.Run("ReturnsTrueForReorderedTmpfsMountOptions", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].TmpfsMounts[0].SetMountOptions([]string{"nosuid", "noexec"})
+		assert.True(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentTmpfsMount", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].TmpfsMounts[0].SetSizeMB(128)
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForMissingTmpfsMount", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].TmpfsMounts = other.ContainerDefinitions[0].TmpfsMounts[:1]
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentSharedMemorySize", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].SetSharedMemorySizeMB(256)
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentInteractive", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].SetInteractive(true)
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentHostname", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].SetHostname("hostname")
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentUser", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].SetUser("user")
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsTrueForExtraHostsInDifferentOrder", func(t *testing.T) {
+		h0 := *NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1")
+		h1 := *NewHostEntry().SetHostname("cache").SetIPAddress("10.0.0.2")
+		opts := makeOpts()
+		opts.ContainerDefinitions[0].SetExtraHosts([]HostEntry{h0, h1})
+		other := makeOpts()
+		other.ContainerDefinitions[0].SetExtraHosts([]HostEntry{h1, h0})
+		assert.True(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentExtraHosts", func(t *testing.T) {
+		opts := makeOpts()
+		opts.ContainerDefinitions[0].AddExtraHosts(*NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1"))
+		other := makeOpts()
+		other.ContainerDefinitions[0].AddExtraHosts(*NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.2"))
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsTrueForVolumesFromInDifferentOrder", func(t *testing.T) {
+		vf0 := *NewVolumeFrom().SetSourceContainer("data")
+		vf1 := *NewVolumeFrom().SetSourceContainer("cache")
+		opts := makeOpts()
+		opts.ContainerDefinitions[0].SetVolumesFrom([]VolumeFrom{vf0, vf1})
+		other := makeOpts()
+		other.ContainerDefinitions[0].SetVolumesFrom([]VolumeFrom{vf1, vf0})
+		assert.True(t, opts.Equals(other))
+	})
+	t.Run("ReturnsTrueForUnsetAndFalseReadOnlyVolumesFrom", func(t *testing.T) {
+		opts := makeOpts()
+		opts.ContainerDefinitions[0].AddVolumesFrom(*NewVolumeFrom().SetSourceContainer("data"))
+		other := makeOpts()
+		other.ContainerDefinitions[0].AddVolumesFrom(*NewVolumeFrom().SetSourceContainer("data").SetReadOnly(false))
+		assert.True(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentVolumesFrom", func(t *testing.T) {
+		opts := makeOpts()
+		opts.ContainerDefinitions[0].AddVolumesFrom(*NewVolumeFrom().SetSourceContainer("data"))
+		other := makeOpts()
+		other.ContainerDefinitions[0].AddVolumesFrom(*NewVolumeFrom().SetSourceContainer("data").SetReadOnly(true))
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("ReturnsTrueForUnsetAndFalsePseudoTerminal", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0].SetPseudoTerminal(false)
+		assert.True(t, opts.Equals(other))
+	})
+	t.Run("ReturnsFalseForDifferentNumberOfContainerDefinitions", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions = other.ContainerDefinitions[:1]
+		assert.False(t, opts.Equals(other))
+	})
+	t.Run("IsConsistentWithHash", func(t *testing.T) {
+		opts := makeOpts()
+		other := makeOpts()
+		other.ContainerDefinitions[0], other.ContainerDefinitions[1] = other.ContainerDefinitions[1], other.ContainerDefinitions[0]
+		assert.True(t, opts.Equals(other))
+		assert.Equal(t, opts.Hash(), other.Hash())
+	})
+}
@@ -0,0 +1,73 @@
+package cocoa
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// maxGroupNameLength is the maximum length of a pod's group name.
+const maxGroupNameLength = 255
+
+// groupNameTemplateRegexp matches metadata references of the form {key} in a
+// group name template.
+var groupNameTemplateRegexp = regexp.MustCompile(`\{([A-Za-z0-9_.-]+)\}`)
+
+// groupNameRegexp matches the characters allowed in a group name resolved from
+// a template.
+var groupNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// ResolveGroupNameTemplate resolves the group name template (e.g.
+// "{app}-{env}") by replacing each {key} reference with its value from the
+// metadata. It returns an error if any referenced key is missing or if the
+// resolved group name is invalid. The same template and metadata always
+// resolve to the same group name, so it can be used both to place pods in a
+// group and to find the pods in that group later.
+func ResolveGroupNameTemplate(template string, metadata map[string]string) (string, error) {
+	if template == "" {
+		return "", errors.New("cannot specify an empty group name template")
+	}
+
+	missing := map[string]struct{}{}
+	resolved := groupNameTemplateRegexp.ReplaceAllStringFunc(template, func(ref string) string {
+		key := groupNameTemplateRegexp.FindStringSubmatch(ref)[1]
+		if val, ok := metadata[key]; ok {
+			return val
+		}
+		missing[key] = struct{}{}
+		return ref
+	})
+
+	if len(missing) != 0 {
+		keys := make([]string, 0, len(missing))
+		for key := range missing {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		catcher := grip.NewBasicCatcher()
+		for _, key := range keys {
+			catcher.Errorf("group name template references undefined metadata key '%s'", key)
+		}
+		return "", catcher.Resolve()
+	}
+
+	if err := validateResolvedGroupName(resolved); err != nil {
+		return "", errors.Wrapf(err, "invalid group name '%s' resolved from template '%s'", resolved, template)
+	}
+
+	return resolved, nil
+}
+
+// validateResolvedGroupName checks that the group name resolved from a
+// template has a valid length and only contains letters, numbers, hyphens,
+// underscores, periods, and colons.
+func validateResolvedGroupName(name string) error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(name == "", "group name cannot be empty")
+	catcher.ErrorfWhen(len(name) > maxGroupNameLength, "group name cannot exceed %d characters", maxGroupNameLength)
+	catcher.NewWhen(name != "" && !groupNameRegexp.MatchString(name), "group name can only contain letters, numbers, hyphens, underscores, periods, and colons")
+	return catcher.Resolve()
+}
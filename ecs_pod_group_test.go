@@ -0,0 +1,53 @@
+package cocoa
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveGroupNameTemplate(t *testing.T) {
+	t.Run("ResolvesMetadataReferences", func(t *testing.T) {
+		group, err := ResolveGroupNameTemplate("{app}-{env}", map[string]string{"app": "evergreen", "env": "prod", "unused": "value"})
+		require.NoError(t, err)
+		assert.Equal(t, "evergreen-prod", group)
+	})
+	t.Run("ResolvesRepeatedReferences", func(t *testing.T) {
+		group, err := ResolveGroupNameTemplate("{app}:{app}", map[string]string{"app": "evergreen"})
+		require.NoError(t, err)
+		assert.Equal(t, "evergreen:evergreen", group)
+	})
+	t.Run("SucceedsWithoutReferences", func(t *testing.T) {
+		group, err := ResolveGroupNameTemplate("group", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "group", group)
+	})
+	t.Run("FailsWithEmptyTemplate", func(t *testing.T) {
+		group, err := ResolveGroupNameTemplate("", nil)
+		assert.Error(t, err)
+		assert.Zero(t, group)
+	})
+	t.Run("FailsWithUndefinedMetadata", func(t *testing.T) {
+		group, err := ResolveGroupNameTemplate("{app}-{env}", map[string]string{"app": "evergreen"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "env")
+		assert.Zero(t, group)
+	})
+	t.Run("FailsWithInvalidCharacters", func(t *testing.T) {
+		group, err := ResolveGroupNameTemplate("{app}-{env}", map[string]string{"app": "ever green", "env": "prod"})
+		assert.Error(t, err)
+		assert.Zero(t, group)
+	})
+	t.Run("FailsWithEmptyResolvedName", func(t *testing.T) {
+		group, err := ResolveGroupNameTemplate("{app}", map[string]string{"app": ""})
+		assert.Error(t, err)
+		assert.Zero(t, group)
+	})
+	t.Run("FailsWithResolvedNameExceedingMaxLength", func(t *testing.T) {
+		group, err := ResolveGroupNameTemplate("{app}", map[string]string{"app": strings.Repeat("a", maxGroupNameLength+1)})
+		assert.Error(t, err)
+		assert.Zero(t, group)
+	})
+}
@@ -0,0 +1,73 @@
+package cocoa
+
+import "github.com/evergreen-ci/utility"
+
+// PodDefinitionHashTagKey is the reserved tag key that records the hash of the
+// pod definition options that a cached pod definition was created from, so
+// that the hash can be recovered from the pod definition in ECS.
+const PodDefinitionHashTagKey = "cocoa-pod-definition-hash"
+
+// HashOptions are options to customize which fields of the pod definition
+// options are included in its hash. This is useful for pod definitions that
+// contain volatile fields (e.g. a tag with a timestamp or job ID) that do not
+// affect how the pod runs, so that pod definitions that only differ in those
+// fields have the same hash and can be reused.
+type HashOptions struct {
+	// ExcludeTagKeys are the keys of the tags that are excluded from the
+	// hash.
+	ExcludeTagKeys []string
+	// ExcludeName determines whether or not the pod definition name is
+	// excluded from the hash. By default, the name is included.
+	ExcludeName *bool
+}
+
+// NewHashOptions returns new uninitialized options to customize the hash of
+// pod definition options.
+func NewHashOptions() *HashOptions {
+	return &HashOptions{}
+}
+
+// SetExcludeTagKeys sets the keys of the tags that are excluded from the hash.
+// This overwrites any existing excluded tag keys.
+func (o *HashOptions) SetExcludeTagKeys(keys []string) *HashOptions {
+	o.ExcludeTagKeys = keys
+	return o
+}
+
+// AddExcludeTagKeys adds new keys of the tags that are excluded from the hash.
+func (o *HashOptions) AddExcludeTagKeys(keys ...string) *HashOptions {
+	o.ExcludeTagKeys = append(o.ExcludeTagKeys, keys...)
+	return o
+}
+
+// SetExcludeName sets whether or not the pod definition name is excluded from
+// the hash.
+func (o *HashOptions) SetExcludeName(exclude bool) *HashOptions {
+	o.ExcludeName = &exclude
+	return o
+}
+
+// MergeHashOptions merges all the given hash options. Excluded tag keys are
+// combined, and the pod definition name is excluded if any of the options
+// exclude it.
+func MergeHashOptions(opts ...HashOptions) HashOptions {
+	merged := HashOptions{}
+
+	for _, opt := range opts {
+		merged.ExcludeTagKeys = append(merged.ExcludeTagKeys, opt.ExcludeTagKeys...)
+		if utility.FromBoolPtr(opt.ExcludeName) {
+			merged.ExcludeName = opt.ExcludeName
+		}
+	}
+
+	return merged
+}
+
+// hashedTags returns the tags that are included in the hash.
+func (o *HashOptions) hashedTags(tags Tags) Tags {
+	if len(o.ExcludeTagKeys) == 0 {
+		return tags
+	}
+
+	return NewTags().Add(tags).Remove(o.ExcludeTagKeys...)
+}
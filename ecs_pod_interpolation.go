@@ -0,0 +1,188 @@
+package cocoa
+
+import (
+	"regexp"
+
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// InterpolationMode represents the way that variable references are handled
+// when they cannot be resolved during interpolation.
+type InterpolationMode string
+
+const (
+	// InterpolationModeStrict returns an error if any variable reference
+	// cannot be resolved.
+	InterpolationModeStrict InterpolationMode = "strict"
+	// InterpolationModeLenient leaves any variable reference that cannot be
+	// resolved as-is.
+	InterpolationModeLenient InterpolationMode = "lenient"
+)
+
+// Validate checks that the interpolation mode is a recognized mode.
+func (m InterpolationMode) Validate() error {
+	switch m {
+	case InterpolationModeStrict, InterpolationModeLenient:
+		return nil
+	default:
+		return errors.Errorf("unrecognized interpolation mode '%s'", m)
+	}
+}
+
+// interpolationRegexp matches variable references of the form ${VAR}.
+var interpolationRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ECSPodInterpolationOptions represent options to expand variable references
+// of the form ${VAR} in a pod definition.
+type ECSPodInterpolationOptions struct {
+	// Variables map the names of variables to the values that should replace
+	// references to them.
+	Variables map[string]string
+	// Mode determines how to handle references to variables that are not
+	// defined in Variables. By default, this is InterpolationModeStrict.
+	Mode *InterpolationMode
+}
+
+// NewECSPodInterpolationOptions returns new uninitialized options to
+// interpolate variables in a pod definition.
+func NewECSPodInterpolationOptions() *ECSPodInterpolationOptions {
+	return &ECSPodInterpolationOptions{}
+}
+
+// SetVariables sets the variables available for interpolation. This overwrites
+// any existing variables.
+func (o *ECSPodInterpolationOptions) SetVariables(vars map[string]string) *ECSPodInterpolationOptions {
+	o.Variables = vars
+	return o
+}
+
+// AddVariables adds new variables available for interpolation to the existing
+// ones.
+func (o *ECSPodInterpolationOptions) AddVariables(vars map[string]string) *ECSPodInterpolationOptions {
+	if o.Variables == nil {
+		o.Variables = map[string]string{}
+	}
+	for k, v := range vars {
+		o.Variables[k] = v
+	}
+	return o
+}
+
+// SetMode sets the mode for handling references to undefined variables.
+func (o *ECSPodInterpolationOptions) SetMode(mode InterpolationMode) *ECSPodInterpolationOptions {
+	o.Mode = &mode
+	return o
+}
+
+// Validate checks that the interpolation options are valid. It sets defaults
+// where possible.
+func (o *ECSPodInterpolationOptions) Validate() error {
+	if o.Mode == nil {
+		mode := InterpolationModeStrict
+		o.Mode = &mode
+	}
+	return o.Mode.Validate()
+}
+
+// interpolate expands all variable references in the given string.
+func (o *ECSPodInterpolationOptions) interpolate(s string) (string, error) {
+	var undefined []string
+	expanded := interpolationRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		name := interpolationRegexp.FindStringSubmatch(ref)[1]
+		if val, ok := o.Variables[name]; ok {
+			return val
+		}
+		undefined = append(undefined, name)
+		return ref
+	})
+
+	if len(undefined) != 0 && *o.Mode == InterpolationModeStrict {
+		catcher := grip.NewBasicCatcher()
+		for _, name := range undefined {
+			catcher.Errorf("undefined variable '%s'", name)
+		}
+		return "", catcher.Resolve()
+	}
+
+	return expanded, nil
+}
+
+// interpolatePtr expands all variable references in the given string if it is
+// non-nil.
+func (o *ECSPodInterpolationOptions) interpolatePtr(s *string) (*string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	expanded, err := o.interpolate(*s)
+	if err != nil {
+		return nil, err
+	}
+	return utility.ToStringPtr(expanded), nil
+}
+
+// Interpolate returns a copy of the pod definition options in which all
+// variable references of the form ${VAR} in the containers' commands, working
+// directories and plaintext environment variable values are expanded using the
+// given interpolation options. This should be done before the pod definition
+// options are validated or hashed. The original pod definition options are
+// not modified.
+func (o *ECSPodDefinitionOptions) Interpolate(opts ECSPodInterpolationOptions) (*ECSPodDefinitionOptions, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid interpolation options")
+	}
+
+	interpolated := *o
+	interpolated.ContainerDefinitions = nil
+	catcher := grip.NewBasicCatcher()
+	for _, def := range o.ContainerDefinitions {
+		interpolatedDef, err := def.interpolate(opts)
+		if err != nil {
+			catcher.Wrapf(err, "container '%s'", utility.FromStringPtr(def.Name))
+			continue
+		}
+		interpolated.ContainerDefinitions = append(interpolated.ContainerDefinitions, *interpolatedDef)
+	}
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	return &interpolated, nil
+}
+
+// interpolate returns a copy of the container definition with all variable
+// references expanded.
+func (d *ECSContainerDefinition) interpolate(opts ECSPodInterpolationOptions) (*ECSContainerDefinition, error) {
+	interpolated := *d
+	catcher := grip.NewBasicCatcher()
+
+	if d.Command != nil {
+		interpolated.Command = make([]string, 0, len(d.Command))
+		for _, arg := range d.Command {
+			expanded, err := opts.interpolate(arg)
+			catcher.Wrapf(err, "command argument '%s'", arg)
+			interpolated.Command = append(interpolated.Command, expanded)
+		}
+	}
+
+	workingDir, err := opts.interpolatePtr(d.WorkingDir)
+	catcher.Wrap(err, "working directory")
+	interpolated.WorkingDir = workingDir
+
+	if d.EnvVars != nil {
+		interpolated.EnvVars = make([]EnvironmentVariable, 0, len(d.EnvVars))
+		for _, ev := range d.EnvVars {
+			val, err := opts.interpolatePtr(ev.Value)
+			catcher.Wrapf(err, "environment variable '%s'", utility.FromStringPtr(ev.Name))
+			ev.Value = val
+			interpolated.EnvVars = append(interpolated.EnvVars, ev)
+		}
+	}
+
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	return &interpolated, nil
+}
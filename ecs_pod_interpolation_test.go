@@ -0,0 +1,109 @@
+package cocoa
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestECSPodInterpolationOptions(t *testing.T) {
+	t.Run("NewECSPodInterpolationOptions", func(t *testing.T) {
+		opts := NewECSPodInterpolationOptions()
+		require.NotZero(t, opts)
+		assert.Zero(t, *opts)
+	})
+	t.Run("SetVariables", func(t *testing.T) {
+		vars := map[string]string{"key": "val"}
+		opts := NewECSPodInterpolationOptions().SetVariables(vars)
+		assert.Equal(t, vars, opts.Variables)
+	})
+	t.Run("AddVariables", func(t *testing.T) {
+		opts := NewECSPodInterpolationOptions().
+			AddVariables(map[string]string{"key0": "val0"}).
+			AddVariables(map[string]string{"key1": "val1"})
+		assert.Equal(t, map[string]string{"key0": "val0", "key1": "val1"}, opts.Variables)
+	})
+	t.Run("SetMode", func(t *testing.T) {
+		opts := NewECSPodInterpolationOptions().SetMode(InterpolationModeLenient)
+		assert.Equal(t, InterpolationModeLenient, *opts.Mode)
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("DefaultsToStrictMode", func(t *testing.T) {
+			opts := NewECSPodInterpolationOptions()
+			require.NoError(t, opts.Validate())
+			require.NotZero(t, opts.Mode)
+			assert.Equal(t, InterpolationModeStrict, *opts.Mode)
+		})
+		t.Run("FailsWithInvalidMode", func(t *testing.T) {
+			opts := NewECSPodInterpolationOptions().SetMode("foo")
+			assert.Error(t, opts.Validate())
+		})
+	})
+}
+
+func TestECSPodDefinitionOptionsInterpolate(t *testing.T) {
+	makeDefOpts := func() *ECSPodDefinitionOptions {
+		ev := NewEnvironmentVariable().SetName("ENV").SetValue("${FOO}-${BAR}")
+		containerDef := NewECSContainerDefinition().
+			SetName("container").
+			SetImage("${FOO}").
+			SetCommand([]string{"echo", "${FOO}"}).
+			SetWorkingDir("/${BAR}").
+			AddEnvironmentVariables(*ev)
+		return NewECSPodDefinitionOptions().
+			SetName("name").
+			AddContainerDefinitions(*containerDef)
+	}
+
+	t.Run("ExpandsDefinedVariables", func(t *testing.T) {
+		defOpts := makeDefOpts()
+		opts := NewECSPodInterpolationOptions().SetVariables(map[string]string{"FOO": "foo", "BAR": "bar"})
+		interpolated, err := defOpts.Interpolate(*opts)
+		require.NoError(t, err)
+		require.Len(t, interpolated.ContainerDefinitions, 1)
+		def := interpolated.ContainerDefinitions[0]
+		assert.Equal(t, []string{"echo", "foo"}, def.Command)
+		assert.Equal(t, "/bar", utility.FromStringPtr(def.WorkingDir))
+		require.Len(t, def.EnvVars, 1)
+		assert.Equal(t, "foo-bar", utility.FromStringPtr(def.EnvVars[0].Value))
+		assert.Equal(t, "${FOO}", utility.FromStringPtr(def.Image), "image should not be interpolated")
+	})
+	t.Run("DoesNotModifyOriginal", func(t *testing.T) {
+		defOpts := makeDefOpts()
+		opts := NewECSPodInterpolationOptions().SetVariables(map[string]string{"FOO": "foo", "BAR": "bar"})
+		_, err := defOpts.Interpolate(*opts)
+		require.NoError(t, err)
+		assert.Equal(t, *makeDefOpts(), *defOpts)
+	})
+	t.Run("FailsWithUndefinedVariableInStrictMode", func(t *testing.T) {
+		defOpts := makeDefOpts()
+		opts := NewECSPodInterpolationOptions().
+			SetVariables(map[string]string{"FOO": "foo"}).
+			SetMode(InterpolationModeStrict)
+		interpolated, err := defOpts.Interpolate(*opts)
+		assert.Error(t, err)
+		assert.Zero(t, interpolated)
+	})
+	t.Run("LeavesUndefinedVariableInLenientMode", func(t *testing.T) {
+		defOpts := makeDefOpts()
+		opts := NewECSPodInterpolationOptions().
+			SetVariables(map[string]string{"FOO": "foo"}).
+			SetMode(InterpolationModeLenient)
+		interpolated, err := defOpts.Interpolate(*opts)
+		require.NoError(t, err)
+		require.Len(t, interpolated.ContainerDefinitions, 1)
+		def := interpolated.ContainerDefinitions[0]
+		assert.Equal(t, "/${BAR}", utility.FromStringPtr(def.WorkingDir))
+		require.Len(t, def.EnvVars, 1)
+		assert.Equal(t, "foo-${BAR}", utility.FromStringPtr(def.EnvVars[0].Value))
+	})
+	t.Run("FailsWithInvalidMode", func(t *testing.T) {
+		defOpts := makeDefOpts()
+		opts := NewECSPodInterpolationOptions().SetMode("foo")
+		interpolated, err := defOpts.Interpolate(*opts)
+		assert.Error(t, err)
+		assert.Zero(t, interpolated)
+	})
+}
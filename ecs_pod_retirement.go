@@ -0,0 +1,44 @@
+package cocoa
+
+import "time"
+
+// RetirementCause is the reason that AWS is retiring a pod.
+type RetirementCause string
+
+const (
+	// RetirementCauseSpotInterruption indicates that the pod is being
+	// stopped because the Spot capacity that it runs on is being reclaimed.
+	RetirementCauseSpotInterruption RetirementCause = "spot-interruption"
+	// RetirementCauseTerminationNotice indicates that the pod is being
+	// stopped because the infrastructure that it runs on received a
+	// termination notice.
+	RetirementCauseTerminationNotice RetirementCause = "termination-notice"
+	// RetirementCauseMaintenance indicates that the pod is being retired so
+	// that AWS can perform maintenance on the infrastructure that it runs on.
+	RetirementCauseMaintenance RetirementCause = "maintenance"
+)
+
+// PodRetirementNotice indicates that AWS has flagged a pod to be retired, so
+// the pod should be replaced before AWS stops it.
+type PodRetirementNotice struct {
+	// Cause is the reason that the pod is being retired.
+	Cause RetirementCause
+	// StopCode is the code that ECS gave for stopping the pod, if any.
+	StopCode string
+	// Message is the human-readable explanation that AWS gave for retiring
+	// the pod.
+	Message string
+	// NoticeAt is when the pod was flagged for retirement, if known.
+	NoticeAt *time.Time
+}
+
+// SetRetirementNotice sets the notice that the pod is being retired.
+func (i *ECSPodStatusInfo) SetRetirementNotice(notice PodRetirementNotice) *ECSPodStatusInfo {
+	i.RetirementNotice = &notice
+	return i
+}
+
+// IsRetiring returns whether or not AWS has flagged the pod to be retired.
+func (i *ECSPodStatusInfo) IsRetiring() bool {
+	return i.RetirementNotice != nil
+}
@@ -0,0 +1,197 @@
+package cocoa
+
+import (
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/grip"
+)
+
+// ECSPodState is a serializable snapshot of a pod's resources, status, and
+// protection policy. It can be persisted (e.g. in a database) and used to
+// reconstruct the pod later without querying ECS for its resources.
+type ECSPodState struct {
+	// TaskID is the resource identifier for the pod.
+	TaskID string `bson:"task_id" json:"task_id" yaml:"task_id"`
+	// Cluster is the name of the cluster in which the pod is running.
+	Cluster string `bson:"cluster,omitempty" json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	// Group is the name of the task group that the pod belongs to.
+	Group string `bson:"group,omitempty" json:"group,omitempty" yaml:"group,omitempty"`
+	// TaskDefinition is the definition template that created the pod.
+	TaskDefinition *ECSTaskDefinitionState `bson:"task_definition,omitempty" json:"task_definition,omitempty" yaml:"task_definition,omitempty"`
+	// Status is the cached status of the pod as a whole.
+	Status ECSStatus `bson:"status" json:"status" yaml:"status"`
+	// Containers are the resources and cached statuses of the pod's
+	// containers.
+	Containers []ECSContainerState `bson:"containers,omitempty" json:"containers,omitempty" yaml:"containers,omitempty"`
+	// ProtectedTaskDefinition indicates whether or not the pod's owned task
+	// definition is protected from deletion.
+	ProtectedTaskDefinition bool `bson:"protected_task_definition,omitempty" json:"protected_task_definition,omitempty" yaml:"protected_task_definition,omitempty"`
+	// ProtectedSecrets are the IDs or names of the pod's owned secrets that are
+	// protected from deletion.
+	ProtectedSecrets []string `bson:"protected_secrets,omitempty" json:"protected_secrets,omitempty" yaml:"protected_secrets,omitempty"`
+}
+
+// ECSTaskDefinitionState is a serializable snapshot of a pod's task
+// definition.
+type ECSTaskDefinitionState struct {
+	// ID is the ID of the task definition.
+	ID string `bson:"id" json:"id" yaml:"id"`
+	// Owned determines whether or not the task definition is owned by its pod.
+	Owned bool `bson:"owned,omitempty" json:"owned,omitempty" yaml:"owned,omitempty"`
+}
+
+// ECSContainerState is a serializable snapshot of a container's resources and
+// status.
+type ECSContainerState struct {
+	// ContainerID is the resource identifier for the container.
+	ContainerID string `bson:"container_id" json:"container_id" yaml:"container_id"`
+	// Name is the friendly name of the container.
+	Name string `bson:"name,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	// Status is the cached status of the container. This is empty if the
+	// container has no status information.
+	Status ECSStatus `bson:"status,omitempty" json:"status,omitempty" yaml:"status,omitempty"`
+	// Secrets are the secrets associated with the container.
+	Secrets []ECSContainerSecretState `bson:"secrets,omitempty" json:"secrets,omitempty" yaml:"secrets,omitempty"`
+}
+
+// ECSContainerSecretState is a serializable snapshot of a container's secret.
+type ECSContainerSecretState struct {
+	// ID is the unique resource identifier for the secret.
+	ID string `bson:"id" json:"id" yaml:"id"`
+	// Name is the friendly name of the secret.
+	Name string `bson:"name,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	// Owned determines whether or not the secret is owned by its container.
+	Owned bool `bson:"owned,omitempty" json:"owned,omitempty" yaml:"owned,omitempty"`
+}
+
+// NewECSPodState returns a snapshot of the pod state from its resources,
+// status, and protection policy. The status of each container is matched to its
+// resources by container ID.
+func NewECSPodState(res ECSPodResources, info ECSPodStatusInfo, protection ECSPodProtectionPolicy) ECSPodState {
+	s := ECSPodState{
+		TaskID:                  utility.FromStringPtr(res.TaskID),
+		Cluster:                 utility.FromStringPtr(res.Cluster),
+		Group:                   utility.FromStringPtr(res.Group),
+		Status:                  info.Status,
+		ProtectedTaskDefinition: protection.ProtectsTaskDefinition(),
+		ProtectedSecrets:        protection.Secrets,
+	}
+	if res.TaskDefinition != nil {
+		s.TaskDefinition = &ECSTaskDefinitionState{
+			ID:    utility.FromStringPtr(res.TaskDefinition.ID),
+			Owned: utility.FromBoolPtr(res.TaskDefinition.Owned),
+		}
+	}
+
+	statuses := map[string]ECSStatus{}
+	for _, c := range info.Containers {
+		statuses[utility.FromStringPtr(c.ContainerID)] = c.Status
+	}
+	for _, c := range res.Containers {
+		id := utility.FromStringPtr(c.ContainerID)
+		container := ECSContainerState{
+			ContainerID: id,
+			Name:        utility.FromStringPtr(c.Name),
+			Status:      statuses[id],
+		}
+		for _, secret := range c.Secrets {
+			container.Secrets = append(container.Secrets, ECSContainerSecretState{
+				ID:    utility.FromStringPtr(secret.ID),
+				Name:  utility.FromStringPtr(secret.Name),
+				Owned: utility.FromBoolPtr(secret.Owned),
+			})
+		}
+		s.Containers = append(s.Containers, container)
+	}
+
+	return s
+}
+
+// Validate checks that the pod state has a task ID, a valid status, and that
+// all its containers are valid.
+func (s *ECSPodState) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(s.TaskID == "", "must specify a task ID")
+	catcher.Wrap(s.Status.Validate(), "invalid pod status")
+	if s.TaskDefinition != nil {
+		catcher.NewWhen(s.TaskDefinition.ID == "", "must specify a task definition ID")
+	}
+	for _, c := range s.Containers {
+		catcher.Wrapf(c.validate(), "container '%s'", c.Name)
+	}
+	for _, secret := range s.ProtectedSecrets {
+		catcher.NewWhen(secret == "", "cannot specify an empty protected secret")
+	}
+	return catcher.Resolve()
+}
+
+func (s *ECSContainerState) validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(s.ContainerID == "", "must specify a container ID")
+	if s.Status != "" {
+		catcher.Wrap(s.Status.Validate(), "invalid status")
+	}
+	for _, secret := range s.Secrets {
+		catcher.NewWhen(secret.ID == "", "must specify a secret ID")
+	}
+	return catcher.Resolve()
+}
+
+// Resources returns the pod resources from the state.
+func (s *ECSPodState) Resources() ECSPodResources {
+	res := NewECSPodResources().SetTaskID(s.TaskID)
+	if s.Cluster != "" {
+		res.SetCluster(s.Cluster)
+	}
+	if s.Group != "" {
+		res.SetGroup(s.Group)
+	}
+	if s.TaskDefinition != nil {
+		res.SetTaskDefinition(*NewECSTaskDefinition().
+			SetID(s.TaskDefinition.ID).
+			SetOwned(s.TaskDefinition.Owned))
+	}
+	for _, c := range s.Containers {
+		container := NewECSContainerResources().SetContainerID(c.ContainerID)
+		if c.Name != "" {
+			container.SetName(c.Name)
+		}
+		for _, secret := range c.Secrets {
+			cs := NewContainerSecret().
+				SetID(secret.ID).
+				SetOwned(secret.Owned)
+			if secret.Name != "" {
+				cs.SetName(secret.Name)
+			}
+			container.AddSecrets(*cs)
+		}
+		res.AddContainers(*container)
+	}
+	return *res
+}
+
+// StatusInfo returns the cached pod status information from the state.
+func (s *ECSPodState) StatusInfo() ECSPodStatusInfo {
+	info := NewECSPodStatusInfo().SetStatus(s.Status)
+	for _, c := range s.Containers {
+		if c.Status == "" {
+			continue
+		}
+		container := NewECSContainerStatusInfo().
+			SetContainerID(c.ContainerID).
+			SetStatus(c.Status)
+		if c.Name != "" {
+			container.SetName(c.Name)
+		}
+		info.AddContainers(*container)
+	}
+	return *info
+}
+
+// ProtectionPolicy returns the pod's protection policy from the state.
+func (s *ECSPodState) ProtectionPolicy() ECSPodProtectionPolicy {
+	p := NewECSPodProtectionPolicy().SetSecrets(s.ProtectedSecrets)
+	if s.ProtectedTaskDefinition {
+		p.SetTaskDefinition(true)
+	}
+	return *p
+}
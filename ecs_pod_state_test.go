@@ -0,0 +1,114 @@
+package cocoa
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestECSPodState(t *testing.T) {
+	makeResources := func() ECSPodResources {
+		return *NewECSPodResources().
+			SetTaskID("task_id").
+			SetCluster("cluster").
+			SetGroup("group").
+			SetTaskDefinition(*NewECSTaskDefinition().SetID("task_definition_id").SetOwned(true)).
+			AddContainers(*NewECSContainerResources().
+				SetContainerID("container_id").
+				SetName("container").
+				AddSecrets(*NewContainerSecret().
+					SetID("secret_id").
+					SetName("secret").
+					SetOwned(true)))
+	}
+	makeStatusInfo := func() ECSPodStatusInfo {
+		return *NewECSPodStatusInfo().
+			SetStatus(StatusRunning).
+			AddContainers(*NewECSContainerStatusInfo().
+				SetContainerID("container_id").
+				SetName("container").
+				SetStatus(StatusRunning))
+	}
+	makeProtection := func() ECSPodProtectionPolicy {
+		return *NewECSPodProtectionPolicy().
+			SetTaskDefinition(true).
+			AddSecrets("secret_id")
+	}
+
+	t.Run("NewECSPodState", func(t *testing.T) {
+		s := NewECSPodState(makeResources(), makeStatusInfo(), makeProtection())
+		assert.Equal(t, "task_id", s.TaskID)
+		assert.Equal(t, "cluster", s.Cluster)
+		assert.Equal(t, "group", s.Group)
+		require.NotZero(t, s.TaskDefinition)
+		assert.Equal(t, "task_definition_id", s.TaskDefinition.ID)
+		assert.True(t, s.TaskDefinition.Owned)
+		assert.Equal(t, StatusRunning, s.Status)
+		require.Len(t, s.Containers, 1)
+		assert.Equal(t, "container_id", s.Containers[0].ContainerID)
+		assert.Equal(t, "container", s.Containers[0].Name)
+		assert.Equal(t, StatusRunning, s.Containers[0].Status)
+		require.Len(t, s.Containers[0].Secrets, 1)
+		assert.Equal(t, ECSContainerSecretState{ID: "secret_id", Name: "secret", Owned: true}, s.Containers[0].Secrets[0])
+		assert.True(t, s.ProtectedTaskDefinition)
+		assert.Equal(t, []string{"secret_id"}, s.ProtectedSecrets)
+	})
+	t.Run("RoundTripsThroughJSON", func(t *testing.T) {
+		s := NewECSPodState(makeResources(), makeStatusInfo(), makeProtection())
+		b, err := json.Marshal(s)
+		require.NoError(t, err)
+
+		var unmarshalled ECSPodState
+		require.NoError(t, json.Unmarshal(b, &unmarshalled))
+		assert.Equal(t, s, unmarshalled)
+
+		assert.Equal(t, makeResources(), unmarshalled.Resources())
+		assert.Equal(t, makeStatusInfo(), unmarshalled.StatusInfo())
+		assert.Equal(t, makeProtection(), unmarshalled.ProtectionPolicy())
+	})
+	t.Run("StatusInfoOmitsContainersWithoutStatus", func(t *testing.T) {
+		s := NewECSPodState(makeResources(), *NewECSPodStatusInfo().SetStatus(StatusStarting), ECSPodProtectionPolicy{})
+		info := s.StatusInfo()
+		assert.Equal(t, StatusStarting, info.Status)
+		assert.Empty(t, info.Containers)
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("SucceedsWithAllFieldsPopulated", func(t *testing.T) {
+			s := NewECSPodState(makeResources(), makeStatusInfo(), makeProtection())
+			assert.NoError(t, s.Validate())
+		})
+		t.Run("SucceedsWithJustTaskIDAndStatus", func(t *testing.T) {
+			s := ECSPodState{TaskID: "task_id", Status: StatusStarting}
+			assert.NoError(t, s.Validate())
+		})
+		t.Run("FailsWithEmpty", func(t *testing.T) {
+			s := ECSPodState{}
+			assert.Error(t, s.Validate())
+		})
+		t.Run("FailsWithoutTaskID", func(t *testing.T) {
+			s := ECSPodState{Status: StatusStarting}
+			assert.Error(t, s.Validate())
+		})
+		t.Run("FailsWithInvalidStatus", func(t *testing.T) {
+			s := ECSPodState{TaskID: "task_id", Status: "invalid"}
+			assert.Error(t, s.Validate())
+		})
+		t.Run("FailsWithEmptyTaskDefinitionID", func(t *testing.T) {
+			s := ECSPodState{TaskID: "task_id", Status: StatusStarting, TaskDefinition: &ECSTaskDefinitionState{}}
+			assert.Error(t, s.Validate())
+		})
+		t.Run("FailsWithContainerWithoutID", func(t *testing.T) {
+			s := ECSPodState{TaskID: "task_id", Status: StatusStarting, Containers: []ECSContainerState{{Name: "container"}}}
+			assert.Error(t, s.Validate())
+		})
+		t.Run("FailsWithSecretWithoutID", func(t *testing.T) {
+			s := ECSPodState{TaskID: "task_id", Status: StatusStarting, Containers: []ECSContainerState{{
+				ContainerID: "container_id",
+				Secrets:     []ECSContainerSecretState{{Name: "secret"}},
+			}}}
+			assert.Error(t, s.Validate())
+		})
+	})
+}
@@ -79,6 +79,11 @@ func TestECSPodResources(t *testing.T) {
 		require.NotZero(t, res.Cluster)
 		assert.Equal(t, cluster, *res.Cluster)
 	})
+	t.Run("SetGroup", func(t *testing.T) {
+		group := "group"
+		res := NewECSPodResources().SetGroup(group)
+		assert.Equal(t, group, utility.FromStringPtr(res.Group))
+	})
 	t.Run("SetContainers", func(t *testing.T) {
 		containerRes := NewECSContainerResources().SetContainerID("id").SetName("name")
 		res := NewECSPodResources().SetContainers([]ECSContainerResources{*containerRes})
@@ -257,6 +262,10 @@ func TestECSContainerStatusInfo(t *testing.T) {
 		cs := NewECSContainerStatusInfo().SetStatus(status)
 		assert.Equal(t, status, cs.Status)
 	})
+	t.Run("SetExitCode", func(t *testing.T) {
+		cs := NewECSContainerStatusInfo().SetExitCode(1)
+		assert.Equal(t, 1, utility.FromIntPtr(cs.ExitCode))
+	})
 }
 
 func TestECSStatus(t *testing.T) {
@@ -280,3 +289,77 @@ func TestECSStatus(t *testing.T) {
 		})
 	})
 }
+
+func TestECSPodProtectionPolicy(t *testing.T) {
+	t.Run("NewECSPodProtectionPolicy", func(t *testing.T) {
+		p := NewECSPodProtectionPolicy()
+		require.NotZero(t, p)
+		assert.Zero(t, *p)
+	})
+	t.Run("SetTaskDefinition", func(t *testing.T) {
+		p := NewECSPodProtectionPolicy().SetTaskDefinition(true)
+		assert.True(t, utility.FromBoolPtr(p.TaskDefinition))
+		assert.True(t, p.ProtectsTaskDefinition())
+	})
+	t.Run("SetSecrets", func(t *testing.T) {
+		secrets := []string{"secret0", "secret1"}
+		p := NewECSPodProtectionPolicy().SetSecrets(secrets)
+		assert.ElementsMatch(t, secrets, p.Secrets)
+	})
+	t.Run("AddSecrets", func(t *testing.T) {
+		p := NewECSPodProtectionPolicy().AddSecrets("secret0").AddSecrets("secret1")
+		assert.ElementsMatch(t, []string{"secret0", "secret1"}, p.Secrets)
+	})
+	t.Run("ProtectsTaskDefinitionIsFalseByDefault", func(t *testing.T) {
+		assert.False(t, NewECSPodProtectionPolicy().ProtectsTaskDefinition())
+	})
+	t.Run("ProtectsSecret", func(t *testing.T) {
+		p := NewECSPodProtectionPolicy().AddSecrets("id", "name")
+		t.Run("MatchesByID", func(t *testing.T) {
+			assert.True(t, p.ProtectsSecret(*NewContainerSecret().SetID("id").SetName("other")))
+		})
+		t.Run("MatchesByName", func(t *testing.T) {
+			assert.True(t, p.ProtectsSecret(*NewContainerSecret().SetID("other").SetName("name")))
+		})
+		t.Run("DoesNotMatchUnprotectedSecret", func(t *testing.T) {
+			assert.False(t, p.ProtectsSecret(*NewContainerSecret().SetID("other").SetName("other")))
+		})
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("SucceedsWithEmpty", func(t *testing.T) {
+			assert.NoError(t, NewECSPodProtectionPolicy().Validate())
+		})
+		t.Run("SucceedsWithAllFieldsPopulated", func(t *testing.T) {
+			p := NewECSPodProtectionPolicy().SetTaskDefinition(true).AddSecrets("secret")
+			assert.NoError(t, p.Validate())
+		})
+		t.Run("FailsWithEmptySecret", func(t *testing.T) {
+			p := NewECSPodProtectionPolicy().AddSecrets("")
+			assert.Error(t, p.Validate())
+		})
+	})
+}
+
+func TestECSPodDeletionOptions(t *testing.T) {
+	t.Run("NewECSPodDeletionOptions", func(t *testing.T) {
+		opts := NewECSPodDeletionOptions()
+		require.NotZero(t, opts)
+		assert.Zero(t, *opts)
+	})
+	t.Run("SetOverrideProtection", func(t *testing.T) {
+		opts := NewECSPodDeletionOptions().SetOverrideProtection(true)
+		assert.True(t, utility.FromBoolPtr(opts.OverrideProtection))
+	})
+	t.Run("MergeECSPodDeletionOptions", func(t *testing.T) {
+		t.Run("ReturnsEmptyWithNoOptions", func(t *testing.T) {
+			assert.Zero(t, MergeECSPodDeletionOptions())
+		})
+		t.Run("OverwritesEarlierOptions", func(t *testing.T) {
+			merged := MergeECSPodDeletionOptions(
+				*NewECSPodDeletionOptions().SetOverrideProtection(true),
+				*NewECSPodDeletionOptions().SetOverrideProtection(false),
+			)
+			assert.False(t, utility.FromBoolPtr(merged.OverrideProtection))
+		})
+	})
+}
@@ -2,6 +2,8 @@ package cocoa
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -32,3 +34,585 @@ func IsECSTaskNotFoundError(err error) bool {
 	_, ok := errors.Cause(err).(*ECSTaskNotFoundError)
 	return ok
 }
+
+// PartialCreationError indicates that an operation to create a pod or pod
+// definition failed after some of its cloud resources were already created.
+// It records the resources that were created so that the caller can clean them
+// up or resume creation.
+type PartialCreationError struct {
+	// SecretIDs are the unique identifiers of the secrets that were created.
+	SecretIDs []string
+	// TaskDefinitionID is the unique identifier of the task definition that
+	// was registered, if any.
+	TaskDefinitionID string
+	// Err is the error that caused creation to fail.
+	Err error
+}
+
+// Error returns the formatted error message including the resources that were
+// created.
+func (e *PartialCreationError) Error() string {
+	msg := fmt.Sprintf("creation failed after partially creating resources (secrets: %v, task definition: '%s')", e.SecretIDs, e.TaskDefinitionID)
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Err.Error())
+	}
+	return msg
+}
+
+// Unwrap returns the error that caused creation to fail.
+func (e *PartialCreationError) Unwrap() error {
+	return e.Err
+}
+
+// NewPartialCreationError returns a new error indicating that creation failed
+// due to the given error after the given resources were created.
+func NewPartialCreationError(err error, secretIDs []string, taskDefID string) *PartialCreationError {
+	return &PartialCreationError{
+		SecretIDs:        secretIDs,
+		TaskDefinitionID: taskDefID,
+		Err:              err,
+	}
+}
+
+// IsPartialCreationError returns whether or not the error is due to creation
+// failing after some resources were already created.
+func IsPartialCreationError(err error) bool {
+	_, ok := AsPartialCreationError(err)
+	return ok
+}
+
+// AsPartialCreationError returns the partial creation error if the error is
+// due to creation failing after some resources were already created.
+func AsPartialCreationError(err error) (*PartialCreationError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var pce *PartialCreationError
+	if !errors.As(err, &pce) {
+		return nil, false
+	}
+	return pce, true
+}
+
+// ECSPodCreationStage is a stage in the process of creating a pod.
+type ECSPodCreationStage string
+
+const (
+	// ECSPodCreationStageSecrets is the stage that creates the new secrets
+	// for the pod definition.
+	ECSPodCreationStageSecrets ECSPodCreationStage = "secrets"
+	// ECSPodCreationStageDefinition is the stage that registers the pod
+	// definition.
+	ECSPodCreationStageDefinition ECSPodCreationStage = "definition"
+	// ECSPodCreationStageRun is the stage that runs the pod from its pod
+	// definition.
+	ECSPodCreationStageRun ECSPodCreationStage = "run"
+	// ECSPodCreationStageTranslate is the stage that translates the running
+	// pod's ECS resources into the pod that is returned.
+	ECSPodCreationStageTranslate ECSPodCreationStage = "translate"
+)
+
+// ECSPodCreationStages returns all the stages of creating a pod in the order
+// that they run.
+func ECSPodCreationStages() []ECSPodCreationStage {
+	return []ECSPodCreationStage{
+		ECSPodCreationStageSecrets,
+		ECSPodCreationStageDefinition,
+		ECSPodCreationStageRun,
+		ECSPodCreationStageTranslate,
+	}
+}
+
+// Validate checks that the pod creation stage is recognized.
+func (s ECSPodCreationStage) Validate() error {
+	for _, stage := range ECSPodCreationStages() {
+		if s == stage {
+			return nil
+		}
+	}
+	return errors.Errorf("unrecognized pod creation stage '%s'", s)
+}
+
+// StagedError indicates that creating a pod failed during a particular stage.
+// It records how long each stage that ran took so that slow or failing stages
+// can be identified.
+type StagedError struct {
+	// Stage is the stage that failed.
+	Stage ECSPodCreationStage
+	// Durations are how long each stage took, including the stage that
+	// failed. Stages that did not run are omitted.
+	Durations map[ECSPodCreationStage]time.Duration
+	// Err is the error that caused the stage to fail.
+	Err error
+}
+
+// Error returns the formatted error message including the stage that failed
+// and how long each stage took.
+func (e *StagedError) Error() string {
+	var durations []string
+	for _, stage := range ECSPodCreationStages() {
+		if d, ok := e.Durations[stage]; ok {
+			durations = append(durations, fmt.Sprintf("%s: %s", stage, d))
+		}
+	}
+	msg := fmt.Sprintf("pod creation failed during stage '%s' (stage durations: %s)", e.Stage, strings.Join(durations, ", "))
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Err.Error())
+	}
+	return msg
+}
+
+// Unwrap returns the error that caused the stage to fail.
+func (e *StagedError) Unwrap() error {
+	return e.Err
+}
+
+// NewStagedError returns a new error indicating that creating a pod failed
+// during the given stage due to the given error.
+func NewStagedError(err error, stage ECSPodCreationStage, durations map[ECSPodCreationStage]time.Duration) *StagedError {
+	return &StagedError{
+		Stage:     stage,
+		Durations: durations,
+		Err:       err,
+	}
+}
+
+// IsStagedError returns whether or not the error is due to creating a pod
+// failing during a particular stage.
+func IsStagedError(err error) bool {
+	_, ok := AsStagedError(err)
+	return ok
+}
+
+// AsStagedError returns the staged error if the error is due to creating a
+// pod failing during a particular stage.
+func AsStagedError(err error) (*StagedError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var se *StagedError
+	if !errors.As(err, &se) {
+		return nil, false
+	}
+	return se, true
+}
+
+// RoleMisconfigurationError indicates that an IAM role cannot be used by a pod
+// because it is missing or misconfigured.
+type RoleMisconfigurationError struct {
+	// Role is the name or ARN of the role.
+	Role string
+	// Reason describes why the role cannot be used.
+	Reason string
+}
+
+// Error returns the formatted error message including the role and the reason
+// it is misconfigured.
+func (e *RoleMisconfigurationError) Error() string {
+	return fmt.Sprintf("role '%s' is misconfigured: %s", e.Role, e.Reason)
+}
+
+// NewRoleMisconfigurationError returns a new error indicating that the role
+// cannot be used for the given reason.
+func NewRoleMisconfigurationError(role, reason string) *RoleMisconfigurationError {
+	return &RoleMisconfigurationError{Role: role, Reason: reason}
+}
+
+// IsRoleMisconfigurationError returns whether or not the error is due to a
+// missing or misconfigured role.
+func IsRoleMisconfigurationError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rme *RoleMisconfigurationError
+	return errors.As(err, &rme)
+}
+
+// NetworkMisconfigurationError indicates that the networking resources for a
+// pod using AWSVPC networking cannot be used because they are missing or
+// misconfigured.
+type NetworkMisconfigurationError struct {
+	// Resources are the IDs of the networking resources that are
+	// misconfigured.
+	Resources []string
+	// Reason describes why the resources cannot be used.
+	Reason string
+}
+
+// Error returns the formatted error message including the resources and the
+// reason they are misconfigured.
+func (e *NetworkMisconfigurationError) Error() string {
+	return fmt.Sprintf("network resources [%s] are misconfigured: %s", strings.Join(e.Resources, ", "), e.Reason)
+}
+
+// NewNetworkMisconfigurationError returns a new error indicating that the
+// networking resources cannot be used for the given reason.
+func NewNetworkMisconfigurationError(resources []string, reason string) *NetworkMisconfigurationError {
+	return &NetworkMisconfigurationError{Resources: resources, Reason: reason}
+}
+
+// IsNetworkMisconfigurationError returns whether or not the error is due to
+// missing or misconfigured networking resources.
+func IsNetworkMisconfigurationError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var nme *NetworkMisconfigurationError
+	return errors.As(err, &nme)
+}
+
+// MissingSecretsError indicates that a pod cannot run because some of the
+// secrets referenced by its pod definition do not exist or are scheduled for
+// deletion.
+type MissingSecretsError struct {
+	// PodDefinitionID is the ID of the pod definition that references the
+	// secrets.
+	PodDefinitionID string
+	// SecretIDs are the IDs of the secrets that are missing.
+	SecretIDs []string
+}
+
+// Error returns the formatted error message including the pod definition and
+// the missing secrets.
+func (e *MissingSecretsError) Error() string {
+	return fmt.Sprintf("pod definition '%s' references missing secrets [%s]", e.PodDefinitionID, strings.Join(e.SecretIDs, ", "))
+}
+
+// NewMissingSecretsError returns a new error indicating that the pod
+// definition references secrets that are missing.
+func NewMissingSecretsError(podDefID string, secretIDs []string) *MissingSecretsError {
+	return &MissingSecretsError{PodDefinitionID: podDefID, SecretIDs: secretIDs}
+}
+
+// IsMissingSecretsError returns whether or not the error is due to a pod
+// definition referencing secrets that are missing.
+func IsMissingSecretsError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var mse *MissingSecretsError
+	return errors.As(err, &mse)
+}
+
+// AWSError is an error returned from a request to an AWS API. It identifies
+// the request so that the failure can be traced (e.g. in a support ticket to
+// AWS) without enabling debug logging for the AWS SDK.
+type AWSError struct {
+	// Service is the AWS service that the request was made to.
+	Service string
+	// Operation is the name of the API operation that was requested.
+	Operation string
+	// RequestID is the unique identifier that AWS assigned to the request, if
+	// the request received a response.
+	RequestID string
+	// StatusCode is the HTTP status code of the response, if the request
+	// received a response.
+	StatusCode int
+	// ErrorCode is the AWS error code (e.g. "ResourceNotFoundException"), if
+	// any.
+	ErrorCode string
+	// Resources are the identifiers of the resources that the request was
+	// for, if any.
+	Resources []string
+	// Err is the error returned from the request.
+	Err error
+}
+
+// Error returns the error message from the request including the resources it
+// was for.
+func (e *AWSError) Error() string {
+	msg := fmt.Sprintf("%s %s request failed", e.Service, e.Operation)
+	if e.RequestID != "" {
+		msg = fmt.Sprintf("%s (request ID '%s')", msg, e.RequestID)
+	}
+	if len(e.Resources) != 0 {
+		msg = fmt.Sprintf("%s for resources %v", msg, e.Resources)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Err.Error())
+	}
+	return msg
+}
+
+// Unwrap returns the error returned from the request.
+func (e *AWSError) Unwrap() error {
+	return e.Err
+}
+
+// IsAWSError returns whether or not the error is from a request to an AWS API.
+func IsAWSError(err error) bool {
+	_, ok := AsAWSError(err)
+	return ok
+}
+
+// AsAWSError returns the AWS error if the error is from a request to an AWS
+// API.
+func AsAWSError(err error) (*AWSError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var awsErr *AWSError
+	if !errors.As(err, &awsErr) {
+		return nil, false
+	}
+	return awsErr, true
+}
+
+// ECSTaskFailureError indicates that ECS reported a failure for a task in an
+// otherwise successful request (e.g. a task that could not be placed when
+// running it).
+type ECSTaskFailureError struct {
+	// ARN is the ARN of the resource that failed, if any.
+	ARN string
+	// Reason is the reason for the failure reported by ECS.
+	Reason string
+	// Detail is the additional detail about the failure reported by ECS, if
+	// any.
+	Detail string
+}
+
+// Error returns the formatted error message including the ARN, reason, and
+// detail for the failure.
+func (e *ECSTaskFailureError) Error() string {
+	var parts []string
+	if e.ARN != "" {
+		parts = append(parts, fmt.Sprintf("task '%s'", e.ARN))
+	}
+	if e.Reason != "" {
+		parts = append(parts, fmt.Sprintf("(reason) %s", e.Reason))
+	}
+	if e.Detail != "" {
+		parts = append(parts, fmt.Sprintf("(detail) %s", e.Detail))
+	}
+	if len(parts) == 0 {
+		return "ECS failure did not contain any additional failure information"
+	}
+	return strings.Join(parts, ": ")
+}
+
+// NewECSTaskFailureError returns a new error for a task failure reported by
+// ECS.
+func NewECSTaskFailureError(arn, reason, detail string) *ECSTaskFailureError {
+	return &ECSTaskFailureError{ARN: arn, Reason: reason, Detail: detail}
+}
+
+// AsECSTaskFailureError returns the ECS task failure error if the error is due
+// to a task failure reported by ECS.
+func AsECSTaskFailureError(err error) (*ECSTaskFailureError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var tfe *ECSTaskFailureError
+	if !errors.As(err, &tfe) {
+		return nil, false
+	}
+	return tfe, true
+}
+
+// RunTaskFailureClass is the classification of why a task could not be run.
+type RunTaskFailureClass string
+
+const (
+	// RunTaskFailureClassNone indicates that there is no failure.
+	RunTaskFailureClassNone RunTaskFailureClass = ""
+	// RunTaskFailureClassCapacity indicates that the task could not be run
+	// because there is not enough capacity available (e.g. insufficient CPU
+	// or memory in the cluster). This is transient since capacity can become
+	// available later.
+	RunTaskFailureClassCapacity RunTaskFailureClass = "capacity"
+	// RunTaskFailureClassThrottling indicates that the task could not be run
+	// because a rate or concurrency limit was exceeded. This is transient
+	// since the limit will eventually allow more tasks.
+	RunTaskFailureClassThrottling RunTaskFailureClass = "throttling"
+	// RunTaskFailureClassMisconfiguration indicates that the task could not be
+	// run because of a problem with the request or the cluster configuration
+	// that will not resolve itself by retrying.
+	RunTaskFailureClassMisconfiguration RunTaskFailureClass = "misconfiguration"
+	// RunTaskFailureClassUnknown indicates that the task could not be run for
+	// a reason that cannot be classified.
+	RunTaskFailureClassUnknown RunTaskFailureClass = "unknown"
+)
+
+// ClassifyRunTaskFailure classifies the reason that a task could not be run.
+// It recognizes task failures reported by ECS (see ECSTaskFailureError) as well
+// as throttling and client errors from the AWS API (see AWSError). If the
+// error contains multiple failures that were combined into a single error
+// message, the failure cannot be classified.
+// Docs: https://docs.aws.amazon.com/AmazonECS/latest/developerguide/api_failures_messages.html
+func ClassifyRunTaskFailure(err error) RunTaskFailureClass {
+	if err == nil {
+		return RunTaskFailureClassNone
+	}
+
+	if tfe, ok := AsECSTaskFailureError(err); ok {
+		return classifyECSFailureReason(tfe.Reason)
+	}
+
+	if awsErr, ok := AsAWSError(err); ok {
+		if strings.Contains(awsErr.Error(), "provisioning capacity limit exceeded") {
+			// The cluster has too many tasks in the PROVISIONING state, which
+			// frees up as the tasks are provisioned.
+			return RunTaskFailureClassThrottling
+		}
+		if IsThrottlingErrorCode(awsErr.ErrorCode) {
+			return RunTaskFailureClassThrottling
+		}
+		switch awsErr.ErrorCode {
+		case "AccessDeniedException", "ClientException", "InvalidParameterException", "ClusterNotFoundException", "PlatformUnknownException", "PlatformTaskDefinitionIncompatibilityException":
+			return RunTaskFailureClassMisconfiguration
+		}
+	}
+
+	return RunTaskFailureClassUnknown
+}
+
+// IsThrottlingErrorCode returns whether or not the AWS error code indicates
+// that the request was throttled.
+func IsThrottlingErrorCode(code string) bool {
+	switch code {
+	case "Throttling", "ThrottlingException", "ThrottledException", "TooManyRequestsException", "RequestLimitExceeded", "RequestThrottled", "RequestThrottledException":
+		return true
+	default:
+		return false
+	}
+}
+
+// classifyECSFailureReason classifies the failure reason reported by ECS for a
+// task that could not be run.
+func classifyECSFailureReason(reason string) RunTaskFailureClass {
+	switch {
+	case strings.HasPrefix(reason, "RESOURCE:"), reason == "AGENT", strings.HasPrefix(reason, "Capacity is unavailable"):
+		return RunTaskFailureClassCapacity
+	case strings.Contains(reason, "limit on the number of tasks"), strings.Contains(reason, "Rate exceeded"):
+		return RunTaskFailureClassThrottling
+	case reason == "ATTRIBUTE", reason == "INACTIVE", reason == "MISSING":
+		return RunTaskFailureClassMisconfiguration
+	default:
+		return RunTaskFailureClassUnknown
+	}
+}
+
+// IsRetryableRunTaskFailure returns whether or not the task could not be run
+// due to a transient issue, so running the task again later may succeed.
+func IsRetryableRunTaskFailure(err error) bool {
+	switch ClassifyRunTaskFailure(err) {
+	case RunTaskFailureClassCapacity, RunTaskFailureClassThrottling:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsCapacityRunTaskFailure returns whether or not the task could not be run
+// because there is not enough capacity available.
+func IsCapacityRunTaskFailure(err error) bool {
+	return ClassifyRunTaskFailure(err) == RunTaskFailureClassCapacity
+}
+
+// IsThrottlingRunTaskFailure returns whether or not the task could not be run
+// because a rate or concurrency limit was exceeded.
+func IsThrottlingRunTaskFailure(err error) bool {
+	return ClassifyRunTaskFailure(err) == RunTaskFailureClassThrottling
+}
+
+// IsMisconfigurationRunTaskFailure returns whether or not the task could not be
+// run because of a problem that will not resolve itself by retrying.
+func IsMisconfigurationRunTaskFailure(err error) bool {
+	return ClassifyRunTaskFailure(err) == RunTaskFailureClassMisconfiguration
+}
+
+// ErrCircuitOpen indicates that a request was not made because the circuit
+// breaker for the client is open. Errors due to an open circuit breaker match
+// ErrCircuitOpen with errors.Is.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError indicates that a request to an AWS service failed fast
+// without being sent because too many recent requests to the service have
+// failed.
+type CircuitOpenError struct {
+	// Service is the AWS service that the request was for.
+	Service string
+	// Operation is the name of the API operation that was requested.
+	Operation string
+	// RetryAfter is the time after which the circuit breaker will allow a
+	// request through to check whether the service has recovered.
+	RetryAfter time.Time
+}
+
+// Error returns the formatted error message including the service and when
+// requests will be allowed again.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s %s request not sent: %s until %s", e.Service, e.Operation, ErrCircuitOpen.Error(), e.RetryAfter.Format(time.RFC3339))
+}
+
+// Is returns whether the target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// NewCircuitOpenError returns a new error indicating that a request to the
+// service was not sent because the circuit breaker is open.
+func NewCircuitOpenError(service, operation string, retryAfter time.Time) *CircuitOpenError {
+	return &CircuitOpenError{Service: service, Operation: operation, RetryAfter: retryAfter}
+}
+
+// IsCircuitOpenError returns whether or not the error is due to an open
+// circuit breaker.
+func IsCircuitOpenError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+// AsCircuitOpenError returns the circuit open error if the error is due to an
+// open circuit breaker.
+func AsCircuitOpenError(err error) (*CircuitOpenError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var coe *CircuitOpenError
+	if !errors.As(err, &coe) {
+		return nil, false
+	}
+	return coe, true
+}
+
+// DefinitionDriftError indicates that a pod definition no longer matches the
+// checksum that was recorded when it was registered, so it was modified or
+// replaced outside of cocoa.
+type DefinitionDriftError struct {
+	// PodDefinitionID is the ID of the pod definition that drifted.
+	PodDefinitionID string
+	// ExpectedChecksum is the checksum that was recorded when the pod
+	// definition was registered. This is empty if the checksum is missing.
+	ExpectedChecksum string
+	// ActualChecksum is the checksum of the pod definition as it currently
+	// exists.
+	ActualChecksum string
+}
+
+// Error returns the formatted error message including the pod definition and
+// the mismatched checksums.
+func (e *DefinitionDriftError) Error() string {
+	if e.ExpectedChecksum == "" {
+		return fmt.Sprintf("pod definition '%s' is missing its recorded checksum (actual checksum is '%s')", e.PodDefinitionID, e.ActualChecksum)
+	}
+	return fmt.Sprintf("pod definition '%s' has checksum '%s' but expected '%s'", e.PodDefinitionID, e.ActualChecksum, e.ExpectedChecksum)
+}
+
+// NewDefinitionDriftError returns a new error indicating that the pod
+// definition's checksum does not match its recorded checksum.
+func NewDefinitionDriftError(podDefID, expected, actual string) *DefinitionDriftError {
+	return &DefinitionDriftError{PodDefinitionID: podDefID, ExpectedChecksum: expected, ActualChecksum: actual}
+}
+
+// IsDefinitionDriftError returns whether or not the error is due to a pod
+// definition being modified outside of cocoa.
+func IsDefinitionDriftError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var dde *DefinitionDriftError
+	return errors.As(err, &dde)
+}
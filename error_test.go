@@ -2,10 +2,12 @@ package cocoa
 
 import (
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestECSTaskNotFoundError(t *testing.T) {
@@ -24,3 +26,318 @@ func TestECSTaskNotFoundError(t *testing.T) {
 		assert.True(t, IsECSTaskNotFoundError(err))
 	})
 }
+
+func TestPartialCreationError(t *testing.T) {
+	assert.Implements(t, (*error)(nil), new(PartialCreationError))
+	t.Run("IsPartialCreationError", func(t *testing.T) {
+		err := NewPartialCreationError(errors.New("cause"), []string{"secret"}, "task_definition")
+		assert.Error(t, err)
+		assert.True(t, IsPartialCreationError(err))
+	})
+	t.Run("OtherErrorsAreNotPartialCreationError", func(t *testing.T) {
+		err := errors.New("some error")
+		assert.False(t, IsPartialCreationError(err))
+	})
+	t.Run("WrappedPartialCreationError", func(t *testing.T) {
+		err := errors.Wrap(NewPartialCreationError(errors.New("cause"), []string{"secret"}, "task_definition"), "wrapping message")
+		pce, ok := AsPartialCreationError(err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"secret"}, pce.SecretIDs)
+		assert.Equal(t, "task_definition", pce.TaskDefinitionID)
+	})
+	t.Run("UnwrapsToCause", func(t *testing.T) {
+		cause := errors.New("cause")
+		err := NewPartialCreationError(cause, nil, "task_definition")
+		assert.True(t, errors.Is(err, cause))
+	})
+}
+
+func TestStagedError(t *testing.T) {
+	assert.Implements(t, (*error)(nil), new(StagedError))
+	t.Run("IsStagedError", func(t *testing.T) {
+		err := NewStagedError(errors.New("cause"), ECSPodCreationStageRun, nil)
+		assert.Error(t, err)
+		assert.True(t, IsStagedError(err))
+	})
+	t.Run("OtherErrorsAreNotStagedError", func(t *testing.T) {
+		assert.False(t, IsStagedError(errors.New("some error")))
+	})
+	t.Run("WrappedStagedError", func(t *testing.T) {
+		durations := map[ECSPodCreationStage]time.Duration{
+			ECSPodCreationStageSecrets:    time.Second,
+			ECSPodCreationStageDefinition: 2 * time.Second,
+		}
+		err := errors.Wrap(NewStagedError(errors.New("cause"), ECSPodCreationStageDefinition, durations), "wrapping message")
+		se, ok := AsStagedError(err)
+		require.True(t, ok)
+		assert.Equal(t, ECSPodCreationStageDefinition, se.Stage)
+		assert.Equal(t, durations, se.Durations)
+	})
+	t.Run("ErrorIncludesStageAndDurations", func(t *testing.T) {
+		err := NewStagedError(errors.New("cause"), ECSPodCreationStageRun, map[ECSPodCreationStage]time.Duration{
+			ECSPodCreationStageRun:     time.Second,
+			ECSPodCreationStageSecrets: time.Minute,
+		})
+		assert.Contains(t, err.Error(), "'run'")
+		assert.Contains(t, err.Error(), "secrets: 1m0s, run: 1s")
+		assert.Contains(t, err.Error(), "cause")
+	})
+	t.Run("UnwrapsToCause", func(t *testing.T) {
+		pce := NewPartialCreationError(errors.New("cause"), []string{"secret"}, "")
+		err := NewStagedError(pce, ECSPodCreationStageSecrets, nil)
+		assert.True(t, errors.Is(err, pce))
+		assert.True(t, IsPartialCreationError(err))
+	})
+}
+
+func TestECSPodCreationStage(t *testing.T) {
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("SucceedsForAllStages", func(t *testing.T) {
+			for _, stage := range ECSPodCreationStages() {
+				assert.NoError(t, stage.Validate())
+			}
+		})
+		t.Run("FailsForUnrecognizedStage", func(t *testing.T) {
+			assert.Error(t, ECSPodCreationStage("foo").Validate())
+		})
+	})
+}
+
+func TestRoleMisconfigurationError(t *testing.T) {
+	assert.Implements(t, (*error)(nil), new(RoleMisconfigurationError))
+	t.Run("IsRoleMisconfigurationError", func(t *testing.T) {
+		err := NewRoleMisconfigurationError("role", "reason")
+		assert.Error(t, err)
+		assert.True(t, IsRoleMisconfigurationError(err))
+		assert.Contains(t, err.Error(), "role")
+		assert.Contains(t, err.Error(), "reason")
+	})
+	t.Run("OtherErrorsAreNotRoleMisconfigurationError", func(t *testing.T) {
+		err := errors.New("some error")
+		assert.False(t, IsRoleMisconfigurationError(err))
+	})
+	t.Run("WrappedRoleMisconfigurationError", func(t *testing.T) {
+		err := errors.Wrap(NewRoleMisconfigurationError("role", "reason"), "wrapping message")
+		assert.True(t, IsRoleMisconfigurationError(err))
+	})
+}
+
+func TestNetworkMisconfigurationError(t *testing.T) {
+	assert.Implements(t, (*error)(nil), new(NetworkMisconfigurationError))
+	t.Run("IsNetworkMisconfigurationError", func(t *testing.T) {
+		err := NewNetworkMisconfigurationError([]string{"subnet-1", "sg-1"}, "reason")
+		assert.Error(t, err)
+		assert.True(t, IsNetworkMisconfigurationError(err))
+		assert.Contains(t, err.Error(), "subnet-1")
+		assert.Contains(t, err.Error(), "sg-1")
+		assert.Contains(t, err.Error(), "reason")
+	})
+	t.Run("OtherErrorsAreNotNetworkMisconfigurationError", func(t *testing.T) {
+		err := errors.New("some error")
+		assert.False(t, IsNetworkMisconfigurationError(err))
+	})
+	t.Run("WrappedNetworkMisconfigurationError", func(t *testing.T) {
+		err := errors.Wrap(NewNetworkMisconfigurationError([]string{"subnet-1"}, "reason"), "wrapping message")
+		assert.True(t, IsNetworkMisconfigurationError(err))
+	})
+}
+
+func TestAWSError(t *testing.T) {
+	assert.Implements(t, (*error)(nil), new(AWSError))
+	newAWSError := func() *AWSError {
+		return &AWSError{
+			Service:   "ECS",
+			Operation: "RunTask",
+			RequestID: "request_id",
+			Resources: []string{"cluster", "task_definition"},
+			Err:       errors.New("cause"),
+		}
+	}
+	t.Run("ErrorIncludesRequestInformation", func(t *testing.T) {
+		msg := newAWSError().Error()
+		assert.Contains(t, msg, "ECS")
+		assert.Contains(t, msg, "RunTask")
+		assert.Contains(t, msg, "request_id")
+		assert.Contains(t, msg, "cluster")
+		assert.Contains(t, msg, "task_definition")
+		assert.Contains(t, msg, "cause")
+	})
+	t.Run("IsAWSError", func(t *testing.T) {
+		assert.True(t, IsAWSError(newAWSError()))
+	})
+	t.Run("OtherErrorsAreNotAWSError", func(t *testing.T) {
+		assert.False(t, IsAWSError(errors.New("some error")))
+		assert.False(t, IsAWSError(nil))
+	})
+	t.Run("WrappedAWSError", func(t *testing.T) {
+		err := errors.Wrap(newAWSError(), "wrapping message")
+		awsErr, ok := AsAWSError(err)
+		require.True(t, ok)
+		assert.Equal(t, "request_id", awsErr.RequestID)
+	})
+	t.Run("UnwrapsToCause", func(t *testing.T) {
+		cause := errors.New("cause")
+		err := newAWSError()
+		err.Err = cause
+		assert.True(t, errors.Is(err, cause))
+	})
+}
+
+func TestECSTaskFailureError(t *testing.T) {
+	assert.Implements(t, (*error)(nil), new(ECSTaskFailureError))
+	t.Run("ContainsFailureInformation", func(t *testing.T) {
+		err := NewECSTaskFailureError("arn", "reason", "detail")
+		assert.Contains(t, err.Error(), "arn")
+		assert.Contains(t, err.Error(), "reason")
+		assert.Contains(t, err.Error(), "detail")
+	})
+	t.Run("WithoutFailureInformation", func(t *testing.T) {
+		err := NewECSTaskFailureError("", "", "")
+		assert.NotEmpty(t, err.Error())
+	})
+	t.Run("WrappedECSTaskFailureError", func(t *testing.T) {
+		err := errors.Wrap(NewECSTaskFailureError("arn", "reason", "detail"), "wrapping message")
+		tfe, ok := AsECSTaskFailureError(err)
+		require.True(t, ok)
+		assert.Equal(t, "arn", tfe.ARN)
+		assert.Equal(t, "reason", tfe.Reason)
+		assert.Equal(t, "detail", tfe.Detail)
+	})
+	t.Run("OtherErrorsAreNotECSTaskFailureError", func(t *testing.T) {
+		_, ok := AsECSTaskFailureError(errors.New("some error"))
+		assert.False(t, ok)
+	})
+}
+
+func TestClassifyRunTaskFailure(t *testing.T) {
+	for reason, expected := range map[string]RunTaskFailureClass{
+		"RESOURCE:CPU":      RunTaskFailureClassCapacity,
+		"RESOURCE:MEMORY":   RunTaskFailureClassCapacity,
+		"RESOURCE:PORTS":    RunTaskFailureClassCapacity,
+		"AGENT":             RunTaskFailureClassCapacity,
+		"ATTRIBUTE":         RunTaskFailureClassMisconfiguration,
+		"INACTIVE":          RunTaskFailureClassMisconfiguration,
+		"some other reason": RunTaskFailureClassUnknown,
+		"Capacity is unavailable at this time. Please try again later or in a different availability zone": RunTaskFailureClassCapacity,
+		"You've reached the limit on the number of tasks you can run concurrently":                         RunTaskFailureClassThrottling,
+	} {
+		t.Run(reason, func(t *testing.T) {
+			err := errors.Wrap(NewECSTaskFailureError("arn", reason, ""), "running task")
+			assert.Equal(t, expected, ClassifyRunTaskFailure(err))
+		})
+	}
+	t.Run("ReturnsNoneForNilError", func(t *testing.T) {
+		assert.Equal(t, RunTaskFailureClassNone, ClassifyRunTaskFailure(nil))
+	})
+	t.Run("ReturnsUnknownForOtherErrors", func(t *testing.T) {
+		assert.Equal(t, RunTaskFailureClassUnknown, ClassifyRunTaskFailure(errors.New("some error")))
+	})
+	t.Run("ClassifiesThrottledAWSError", func(t *testing.T) {
+		err := &AWSError{Service: "ECS", Operation: "RunTask", ErrorCode: "ThrottlingException", Err: errors.New("rate exceeded")}
+		assert.Equal(t, RunTaskFailureClassThrottling, ClassifyRunTaskFailure(errors.Wrap(err, "running task")))
+	})
+	t.Run("ClassifiesProvisioningLimitAWSErrorAsThrottling", func(t *testing.T) {
+		err := &AWSError{Service: "ECS", Operation: "RunTask", ErrorCode: "LimitExceededException", Err: errors.New("provisioning capacity limit exceeded")}
+		assert.Equal(t, RunTaskFailureClassThrottling, ClassifyRunTaskFailure(err))
+	})
+	t.Run("ClassifiesClientAWSErrorAsMisconfiguration", func(t *testing.T) {
+		err := &AWSError{Service: "ECS", Operation: "RunTask", ErrorCode: "ClusterNotFoundException", Err: errors.New("cluster not found")}
+		assert.Equal(t, RunTaskFailureClassMisconfiguration, ClassifyRunTaskFailure(err))
+	})
+}
+
+func TestIsThrottlingErrorCode(t *testing.T) {
+	for _, code := range []string{"Throttling", "ThrottlingException", "ThrottledException", "TooManyRequestsException", "RequestLimitExceeded", "RequestThrottled", "RequestThrottledException"} {
+		assert.True(t, IsThrottlingErrorCode(code), code)
+	}
+	for _, code := range []string{"", "ClientException", "LimitExceededException"} {
+		assert.False(t, IsThrottlingErrorCode(code), code)
+	}
+}
+
+func TestIsRetryableRunTaskFailure(t *testing.T) {
+	assert.True(t, IsRetryableRunTaskFailure(NewECSTaskFailureError("arn", "RESOURCE:MEMORY", "")))
+	assert.True(t, IsCapacityRunTaskFailure(NewECSTaskFailureError("arn", "RESOURCE:MEMORY", "")))
+
+	throttled := &AWSError{ErrorCode: "ThrottlingException"}
+	assert.True(t, IsRetryableRunTaskFailure(throttled))
+	assert.True(t, IsThrottlingRunTaskFailure(throttled))
+
+	misconfigured := NewECSTaskFailureError("arn", "ATTRIBUTE", "")
+	assert.False(t, IsRetryableRunTaskFailure(misconfigured))
+	assert.True(t, IsMisconfigurationRunTaskFailure(misconfigured))
+
+	assert.False(t, IsRetryableRunTaskFailure(errors.New("some error")))
+	assert.False(t, IsRetryableRunTaskFailure(nil))
+}
+
+func TestCircuitOpenError(t *testing.T) {
+	assert.Implements(t, (*error)(nil), new(CircuitOpenError))
+	retryAfter := time.Now().Add(time.Minute)
+	t.Run("IsCircuitOpenError", func(t *testing.T) {
+		err := NewCircuitOpenError("ECS", "RunTask", retryAfter)
+		assert.True(t, IsCircuitOpenError(err))
+		assert.True(t, errors.Is(err, ErrCircuitOpen))
+		assert.Contains(t, err.Error(), "ECS")
+		assert.Contains(t, err.Error(), "RunTask")
+	})
+	t.Run("WrappedCircuitOpenError", func(t *testing.T) {
+		err := errors.Wrap(NewCircuitOpenError("ECS", "RunTask", retryAfter), "wrapping message")
+		assert.True(t, IsCircuitOpenError(err))
+		coe, ok := AsCircuitOpenError(err)
+		require.True(t, ok)
+		assert.Equal(t, "ECS", coe.Service)
+		assert.Equal(t, "RunTask", coe.Operation)
+		assert.True(t, retryAfter.Equal(coe.RetryAfter))
+	})
+	t.Run("OtherErrorsAreNotCircuitOpenError", func(t *testing.T) {
+		err := errors.New("some error")
+		assert.False(t, IsCircuitOpenError(err))
+		_, ok := AsCircuitOpenError(err)
+		assert.False(t, ok)
+		assert.False(t, IsCircuitOpenError(nil))
+	})
+}
+
+func TestMissingSecretsError(t *testing.T) {
+	assert.Implements(t, (*error)(nil), new(MissingSecretsError))
+	t.Run("IsMissingSecretsError", func(t *testing.T) {
+		err := NewMissingSecretsError("pod_def", []string{"secret0", "secret1"})
+		assert.True(t, IsMissingSecretsError(err))
+		assert.Contains(t, err.Error(), "pod_def")
+		assert.Contains(t, err.Error(), "secret0")
+		assert.Contains(t, err.Error(), "secret1")
+	})
+	t.Run("WrappedMissingSecretsError", func(t *testing.T) {
+		err := errors.Wrap(NewMissingSecretsError("pod_def", []string{"secret"}), "wrapping message")
+		assert.True(t, IsMissingSecretsError(err))
+	})
+	t.Run("OtherErrorsAreNotMissingSecretsError", func(t *testing.T) {
+		assert.False(t, IsMissingSecretsError(errors.New("some error")))
+		assert.False(t, IsMissingSecretsError(nil))
+	})
+}
+
+func TestDefinitionDriftError(t *testing.T) {
+	assert.Implements(t, (*error)(nil), new(DefinitionDriftError))
+	t.Run("IsDefinitionDriftError", func(t *testing.T) {
+		err := NewDefinitionDriftError("pod_def", "expected", "actual")
+		assert.True(t, IsDefinitionDriftError(err))
+		assert.Contains(t, err.Error(), "pod_def")
+		assert.Contains(t, err.Error(), "expected")
+		assert.Contains(t, err.Error(), "actual")
+	})
+	t.Run("MissingChecksum", func(t *testing.T) {
+		err := NewDefinitionDriftError("pod_def", "", "actual")
+		assert.True(t, IsDefinitionDriftError(err))
+		assert.Contains(t, err.Error(), "missing")
+	})
+	t.Run("WrappedDefinitionDriftError", func(t *testing.T) {
+		err := errors.Wrap(NewDefinitionDriftError("pod_def", "expected", "actual"), "wrapping message")
+		assert.True(t, IsDefinitionDriftError(err))
+	})
+	t.Run("OtherErrorsAreNotDefinitionDriftError", func(t *testing.T) {
+		assert.False(t, IsDefinitionDriftError(errors.New("some error")))
+		assert.False(t, IsDefinitionDriftError(nil))
+	})
+}
@@ -0,0 +1,109 @@
+package cocoa
+
+import (
+	"context"
+	"time"
+
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/level"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+// EventType represents the kind of lifecycle event that occurred.
+type EventType string
+
+const (
+	// EventTypePodCreated indicates that a pod was created.
+	EventTypePodCreated EventType = "pod-created"
+	// EventTypePodDefinitionRegistered indicates that a pod definition was
+	// registered.
+	EventTypePodDefinitionRegistered EventType = "pod-definition-registered"
+	// EventTypeSecretCreated indicates that a secret was created for a pod
+	// definition.
+	EventTypeSecretCreated EventType = "secret-created"
+	// EventTypePodStopped indicates that a pod was stopped.
+	EventTypePodStopped EventType = "pod-stopped"
+	// EventTypePodDeleted indicates that a pod and its owned resources were
+	// deleted.
+	EventTypePodDeleted EventType = "pod-deleted"
+)
+
+// Validate checks that the event type is recognized.
+func (t EventType) Validate() error {
+	switch t {
+	case EventTypePodCreated, EventTypePodDefinitionRegistered, EventTypeSecretCreated, EventTypePodStopped, EventTypePodDeleted:
+		return nil
+	default:
+		return errors.Errorf("unrecognized event type '%s'", t)
+	}
+}
+
+// Event is a structured notification about a change in the lifecycle of a pod
+// or one of its resources. Only the fields relevant to the event type are set.
+type Event struct {
+	// Type is the kind of event.
+	Type EventType
+	// Time is when the event occurred.
+	Time time.Time
+	// Cluster is the cluster of the pod, if any.
+	Cluster string
+	// TaskID is the ID of the pod's ECS task, if any.
+	TaskID string
+	// PodDefinitionID is the ID of the pod definition, if any.
+	PodDefinitionID string
+	// PodDefinitionFamily is the family name of the pod definition, if any.
+	PodDefinitionFamily string
+	// SecretID is the ID of the secret, if any.
+	SecretID string
+}
+
+// Fields returns the event as structured log fields.
+func (e Event) Fields() message.Fields {
+	fields := message.Fields{
+		"event_type": e.Type,
+		"time":       e.Time,
+	}
+	for key, val := range map[string]string{
+		"cluster":               e.Cluster,
+		"task_id":               e.TaskID,
+		"pod_definition_id":     e.PodDefinitionID,
+		"pod_definition_family": e.PodDefinitionFamily,
+		"secret_id":             e.SecretID,
+	} {
+		if val != "" {
+			fields[key] = val
+		}
+	}
+	return fields
+}
+
+// EventSink receives lifecycle events so that they can be forwarded to
+// external systems (e.g. for auditing). Implementations must be safe for
+// concurrent use and should not block for long, since events are sent
+// synchronously as part of the operation that produced them. Failures to handle
+// an event do not fail the operation, so the sink is responsible for handling
+// its own errors.
+type EventSink interface {
+	// SendEvent handles a single event.
+	SendEvent(ctx context.Context, e Event)
+}
+
+// GripEventSink is an EventSink that logs events as structured messages using
+// the global grip logger.
+type GripEventSink struct {
+	priority level.Priority
+}
+
+// NewGripEventSink returns an event sink that logs events at the given
+// priority.
+func NewGripEventSink(priority level.Priority) *GripEventSink {
+	return &GripEventSink{priority: priority}
+}
+
+// SendEvent logs the event.
+func (s *GripEventSink) SendEvent(_ context.Context, e Event) {
+	fields := e.Fields()
+	fields["message"] = "pod lifecycle event"
+	grip.Log(s.priority, fields)
+}
@@ -0,0 +1,68 @@
+package cocoa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mongodb/grip/level"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventType(t *testing.T) {
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("SucceedsForValidEventTypes", func(t *testing.T) {
+			for _, et := range []EventType{
+				EventTypePodCreated,
+				EventTypePodDefinitionRegistered,
+				EventTypeSecretCreated,
+				EventTypePodStopped,
+				EventTypePodDeleted,
+			} {
+				assert.NoError(t, et.Validate())
+			}
+		})
+		t.Run("FailsForInvalidEventType", func(t *testing.T) {
+			assert.Error(t, EventType("foo").Validate())
+		})
+	})
+}
+
+func TestEvent(t *testing.T) {
+	t.Run("Fields", func(t *testing.T) {
+		t.Run("IncludesOnlyPopulatedFields", func(t *testing.T) {
+			ts := time.Now()
+			e := Event{
+				Type:     EventTypeSecretCreated,
+				Time:     ts,
+				SecretID: "secret_id",
+			}
+			fields := e.Fields()
+			assert.Len(t, fields, 3)
+			assert.Equal(t, EventTypeSecretCreated, fields["event_type"])
+			assert.Equal(t, ts, fields["time"])
+			assert.Equal(t, "secret_id", fields["secret_id"])
+		})
+		t.Run("IncludesPodFields", func(t *testing.T) {
+			e := Event{
+				Type:            EventTypePodCreated,
+				Cluster:         "cluster",
+				TaskID:          "task_id",
+				PodDefinitionID: "pod_definition_id",
+			}
+			fields := e.Fields()
+			assert.Equal(t, "cluster", fields["cluster"])
+			assert.Equal(t, "task_id", fields["task_id"])
+			assert.Equal(t, "pod_definition_id", fields["pod_definition_id"])
+			assert.NotContains(t, fields, "secret_id")
+			assert.NotContains(t, fields, "pod_definition_family")
+		})
+	})
+}
+
+func TestGripEventSink(t *testing.T) {
+	assert.Implements(t, (*EventSink)(nil), &GripEventSink{})
+	assert.NotPanics(t, func() {
+		NewGripEventSink(level.Debug).SendEvent(context.Background(), Event{Type: EventTypePodCreated})
+	})
+}
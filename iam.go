@@ -0,0 +1,15 @@
+package cocoa
+
+import "context"
+
+// IAMClient provides a common interface to look up roles in AWS IAM.
+// Implementations must handle retrying and backoff.
+type IAMClient interface {
+	// RoleExists returns whether or not the role with the given name or ARN
+	// exists.
+	RoleExists(ctx context.Context, role string) (bool, error)
+	// GetRoleTrustPolicy returns the JSON trust policy document that controls
+	// which principals can assume the role with the given name or ARN. If the
+	// role does not exist, it returns nil.
+	GetRoleTrustPolicy(ctx context.Context, role string) (*string, error)
+}
@@ -0,0 +1,405 @@
+package identity
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ecsService is the ARN service name for ECS.
+	ecsService = "ecs"
+	// secretsManagerService is the ARN service name for Secrets Manager.
+	secretsManagerService = "secretsmanager"
+	// ssmService is the ARN service name for SSM Parameter Store.
+	ssmService = "ssm"
+)
+
+var (
+	// accountIDPattern matches a valid AWS account ID.
+	accountIDPattern = regexp.MustCompile(`^[0-9]{12}$`)
+	// ecsNamePattern matches a valid ECS cluster name or task definition
+	// family.
+	ecsNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,255}$`)
+	// ecsIDPattern matches a valid ECS task or container ID.
+	ecsIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	// secretNamePattern matches a valid Secrets Manager secret name.
+	secretNamePattern = regexp.MustCompile(`^[a-zA-Z0-9/_+=.@-]{1,512}$`)
+)
+
+// Location is the partition, region, and account that an AWS resource belongs
+// to.
+type Location struct {
+	// Partition is the AWS partition (e.g. "aws").
+	Partition string
+	// Region is the AWS region (e.g. "us-east-1").
+	Region string
+	// AccountID is the 12-digit ID of the AWS account.
+	AccountID string
+}
+
+// parseARN parses the ARN and checks that it belongs to the expected service
+// and has a complete location.
+func parseARN(id, service string) (arn.ARN, error) {
+	parsed, err := arn.Parse(id)
+	if err != nil {
+		return arn.ARN{}, errors.Wrapf(err, "parsing ARN '%s'", id)
+	}
+
+	catcher := grip.NewBasicCatcher()
+	catcher.ErrorfWhen(parsed.Service != service, "ARN has service '%s' but expected '%s'", parsed.Service, service)
+	catcher.NewWhen(parsed.Partition == "", "ARN is missing a partition")
+	catcher.NewWhen(parsed.Region == "", "ARN is missing a region")
+	catcher.ErrorfWhen(!accountIDPattern.MatchString(parsed.AccountID), "ARN has invalid account ID '%s'", parsed.AccountID)
+	if catcher.HasErrors() {
+		return arn.ARN{}, errors.Wrapf(catcher.Resolve(), "invalid ARN '%s'", id)
+	}
+
+	return parsed, nil
+}
+
+// newLocation returns the location of the parsed ARN.
+func newLocation(parsed arn.ARN) Location {
+	return Location{
+		Partition: parsed.Partition,
+		Region:    parsed.Region,
+		AccountID: parsed.AccountID,
+	}
+}
+
+// format returns the ARN for the resource in the service at the location.
+func (l Location) format(service, resource string) string {
+	return arn.ARN{
+		Partition: l.Partition,
+		Service:   service,
+		Region:    l.Region,
+		AccountID: l.AccountID,
+		Resource:  resource,
+	}.String()
+}
+
+// TaskARN is the parsed ARN of an ECS task (i.e. a pod).
+type TaskARN struct {
+	Location
+	// Cluster is the name of the cluster that the task belongs to. This is
+	// empty for ARNs in the older format, which do not include the cluster.
+	Cluster string
+	// TaskID is the unique ID of the task.
+	TaskID string
+}
+
+// ParseTaskARN parses and validates the ARN of an ECS task in either the
+// current format (task/<cluster>/<task-id>) or the older format
+// (task/<task-id>).
+func ParseTaskARN(id string) (*TaskARN, error) {
+	parsed, err := parseARN(id, ecsService)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(parsed.Resource, "/")
+	if parts[0] != "task" {
+		return nil, errors.Errorf("ARN '%s' does not refer to a task", id)
+	}
+
+	a := TaskARN{Location: newLocation(parsed)}
+	switch len(parts) {
+	case 2:
+		a.TaskID = parts[1]
+	case 3:
+		a.Cluster = parts[1]
+		a.TaskID = parts[2]
+		if !ecsNamePattern.MatchString(a.Cluster) {
+			return nil, errors.Errorf("task ARN '%s' has invalid cluster name '%s'", id, a.Cluster)
+		}
+	default:
+		return nil, errors.Errorf("task ARN '%s' is not in the format task/<cluster>/<task-id>", id)
+	}
+	if !ecsIDPattern.MatchString(a.TaskID) {
+		return nil, errors.Errorf("task ARN '%s' has invalid task ID '%s'", id, a.TaskID)
+	}
+
+	return &a, nil
+}
+
+// String returns the ARN of the task.
+func (a TaskARN) String() string {
+	if a.Cluster == "" {
+		return a.format(ecsService, fmt.Sprintf("task/%s", a.TaskID))
+	}
+	return a.format(ecsService, fmt.Sprintf("task/%s/%s", a.Cluster, a.TaskID))
+}
+
+// TaskIDFromARN returns the task ID from the ARN of an ECS task. If the
+// identifier is not a valid task ARN, it is assumed to already be a task ID
+// and is returned unchanged.
+func TaskIDFromARN(id string) string {
+	a, err := ParseTaskARN(id)
+	if err != nil {
+		return id
+	}
+	return a.TaskID
+}
+
+// TaskDefinitionARN is the parsed ARN of an ECS task definition (i.e. a pod
+// definition).
+type TaskDefinitionARN struct {
+	Location
+	// Family is the name of the task definition family.
+	Family string
+	// Revision is the revision number within the family, starting from 1.
+	Revision int
+}
+
+// ParseTaskDefinitionARN parses and validates the ARN of an ECS task
+// definition in the format task-definition/<family>:<revision>.
+func ParseTaskDefinitionARN(id string) (*TaskDefinitionARN, error) {
+	parsed, err := parseARN(id, ecsService)
+	if err != nil {
+		return nil, err
+	}
+
+	familyRevision := strings.TrimPrefix(parsed.Resource, "task-definition/")
+	if familyRevision == parsed.Resource {
+		return nil, errors.Errorf("ARN '%s' does not refer to a task definition", id)
+	}
+	family, revision, err := ParseFamilyRevision(familyRevision)
+	if err != nil {
+		return nil, errors.Wrapf(err, "task definition ARN '%s'", id)
+	}
+
+	return &TaskDefinitionARN{
+		Location: newLocation(parsed),
+		Family:   family,
+		Revision: revision,
+	}, nil
+}
+
+// String returns the ARN of the task definition.
+func (a TaskDefinitionARN) String() string {
+	return a.format(ecsService, fmt.Sprintf("task-definition/%s", FormatFamilyRevision(a.Family, a.Revision)))
+}
+
+// FamilyRevision returns the task definition identified by its family and
+// revision in the format <family>:<revision>.
+func (a TaskDefinitionARN) FamilyRevision() string {
+	return FormatFamilyRevision(a.Family, a.Revision)
+}
+
+// ParseFamilyRevision parses and validates a task definition identified by its
+// family and revision in the format <family>:<revision>.
+func ParseFamilyRevision(id string) (family string, revision int, err error) {
+	sep := strings.LastIndex(id, ":")
+	if sep == -1 {
+		return "", 0, errors.Errorf("task definition '%s' is not in the format <family>:<revision>", id)
+	}
+
+	family = id[:sep]
+	if !ecsNamePattern.MatchString(family) {
+		return "", 0, errors.Errorf("task definition '%s' has invalid family '%s'", id, family)
+	}
+	revision, err = strconv.Atoi(id[sep+1:])
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "parsing revision of task definition '%s'", id)
+	}
+	if revision < 1 {
+		return "", 0, errors.Errorf("task definition '%s' has revision %d, but revisions start from 1", id, revision)
+	}
+
+	return family, revision, nil
+}
+
+// FormatFamilyRevision returns the task definition identified by its family
+// and revision in the format <family>:<revision>.
+func FormatFamilyRevision(family string, revision int) string {
+	return fmt.Sprintf("%s:%d", family, revision)
+}
+
+// ContainerARN is the parsed ARN of a container in an ECS task.
+type ContainerARN struct {
+	Location
+	// Cluster is the name of the cluster that the container's task belongs
+	// to. This is empty for ARNs in the older format, which do not include
+	// the cluster or task.
+	Cluster string
+	// TaskID is the unique ID of the task that the container belongs to. This
+	// is empty for ARNs in the older format, which do not include the cluster
+	// or task.
+	TaskID string
+	// ContainerID is the unique ID of the container.
+	ContainerID string
+}
+
+// ParseContainerARN parses and validates the ARN of a container in an ECS task
+// in either the current format (container/<cluster>/<task-id>/<container-id>)
+// or the older format (container/<container-id>).
+func ParseContainerARN(id string) (*ContainerARN, error) {
+	parsed, err := parseARN(id, ecsService)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(parsed.Resource, "/")
+	if parts[0] != "container" {
+		return nil, errors.Errorf("ARN '%s' does not refer to a container", id)
+	}
+
+	a := ContainerARN{Location: newLocation(parsed)}
+	switch len(parts) {
+	case 2:
+		a.ContainerID = parts[1]
+	case 4:
+		a.Cluster = parts[1]
+		a.TaskID = parts[2]
+		a.ContainerID = parts[3]
+		if !ecsNamePattern.MatchString(a.Cluster) {
+			return nil, errors.Errorf("container ARN '%s' has invalid cluster name '%s'", id, a.Cluster)
+		}
+		if !ecsIDPattern.MatchString(a.TaskID) {
+			return nil, errors.Errorf("container ARN '%s' has invalid task ID '%s'", id, a.TaskID)
+		}
+	default:
+		return nil, errors.Errorf("container ARN '%s' is not in the format container/<cluster>/<task-id>/<container-id>", id)
+	}
+	if !ecsIDPattern.MatchString(a.ContainerID) {
+		return nil, errors.Errorf("container ARN '%s' has invalid container ID '%s'", id, a.ContainerID)
+	}
+
+	return &a, nil
+}
+
+// String returns the ARN of the container.
+func (a ContainerARN) String() string {
+	if a.Cluster == "" && a.TaskID == "" {
+		return a.format(ecsService, fmt.Sprintf("container/%s", a.ContainerID))
+	}
+	return a.format(ecsService, fmt.Sprintf("container/%s/%s/%s", a.Cluster, a.TaskID, a.ContainerID))
+}
+
+// SecretARN is the parsed ARN of a Secrets Manager secret. When a pod
+// definition references a secret, the ARN can also select a JSON key and
+// version of the secret.
+type SecretARN struct {
+	Location
+	// Name is the name of the secret, including the random suffix that
+	// Secrets Manager appends to it.
+	Name string
+	// JSONKey is the key within the secret's JSON value, if any.
+	JSONKey string
+	// VersionStage is the staging label of the version of the secret, if any.
+	VersionStage string
+	// VersionID is the unique ID of the version of the secret, if any.
+	VersionID string
+}
+
+// ParseSecretARN parses and validates the ARN of a Secrets Manager secret in
+// the format secret:<name>, optionally followed by
+// :<json-key>:<version-stage>:<version-id> as used by pod definitions.
+func ParseSecretARN(id string) (*SecretARN, error) {
+	parsed, err := parseARN(id, secretsManagerService)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(parsed.Resource, ":")
+	if parts[0] != "secret" {
+		return nil, errors.Errorf("ARN '%s' does not refer to a secret", id)
+	}
+
+	a := SecretARN{Location: newLocation(parsed)}
+	switch len(parts) {
+	case 2:
+		a.Name = parts[1]
+	case 5:
+		a.Name = parts[1]
+		a.JSONKey = parts[2]
+		a.VersionStage = parts[3]
+		a.VersionID = parts[4]
+	default:
+		return nil, errors.Errorf("secret ARN '%s' is not in the format secret:<name>[:<json-key>:<version-stage>:<version-id>]", id)
+	}
+	if !secretNamePattern.MatchString(a.Name) {
+		return nil, errors.Errorf("secret ARN '%s' has invalid secret name '%s'", id, a.Name)
+	}
+
+	return &a, nil
+}
+
+// String returns the ARN of the secret, including the JSON key and version if
+// any are set.
+func (a SecretARN) String() string {
+	if a.JSONKey == "" && a.VersionStage == "" && a.VersionID == "" {
+		return a.SecretID()
+	}
+	return a.format(secretsManagerService, fmt.Sprintf("secret:%s:%s:%s:%s", a.Name, a.JSONKey, a.VersionStage, a.VersionID))
+}
+
+// SecretID returns the ARN that identifies the secret itself without any JSON
+// key or version.
+func (a SecretARN) SecretID() string {
+	return a.format(secretsManagerService, fmt.Sprintf("secret:%s", a.Name))
+}
+
+// ParameterARN is the parsed ARN of an SSM Parameter Store parameter.
+type ParameterARN struct {
+	Location
+	// Name is the name of the parameter. For parameters in a hierarchy (e.g.
+	// /path/to/name), this excludes the leading slash.
+	Name string
+}
+
+// ParseParameterARN parses and validates the ARN of an SSM Parameter Store
+// parameter in the format parameter/<name>.
+func ParseParameterARN(id string) (*ParameterARN, error) {
+	parsed, err := parseARN(id, ssmService)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimPrefix(parsed.Resource, "parameter/")
+	if name == parsed.Resource || name == "" {
+		return nil, errors.Errorf("ARN '%s' does not refer to a parameter", id)
+	}
+
+	return &ParameterARN{
+		Location: newLocation(parsed),
+		Name:     name,
+	}, nil
+}
+
+// String returns the ARN of the parameter.
+func (a ParameterARN) String() string {
+	return a.format(ssmService, fmt.Sprintf("parameter/%s", a.Name))
+}
+
+// ParseSecretLocation parses and validates the ARN of a secret stored in
+// either Secrets Manager or SSM Parameter Store, and returns the location of
+// the secret.
+func ParseSecretLocation(id string) (*Location, error) {
+	parsed, err := arn.Parse(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing ARN '%s'", id)
+	}
+
+	switch parsed.Service {
+	case secretsManagerService:
+		a, err := ParseSecretARN(id)
+		if err != nil {
+			return nil, err
+		}
+		return &a.Location, nil
+	case ssmService:
+		a, err := ParseParameterARN(id)
+		if err != nil {
+			return nil, err
+		}
+		return &a.Location, nil
+	default:
+		return nil, errors.Errorf("ARN '%s' must refer to a Secrets Manager secret or SSM parameter, but has service '%s'", id, parsed.Service)
+	}
+}
@@ -0,0 +1,240 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTaskARN(t *testing.T) {
+	t.Run("SucceedsWithCurrentFormat", func(t *testing.T) {
+		id := "arn:aws:ecs:us-east-1:123456789012:task/cluster/0123abcd"
+		a, err := ParseTaskARN(id)
+		require.NoError(t, err)
+		assert.Equal(t, "aws", a.Partition)
+		assert.Equal(t, "us-east-1", a.Region)
+		assert.Equal(t, "123456789012", a.AccountID)
+		assert.Equal(t, "cluster", a.Cluster)
+		assert.Equal(t, "0123abcd", a.TaskID)
+		assert.Equal(t, id, a.String())
+	})
+	t.Run("SucceedsWithOlderFormat", func(t *testing.T) {
+		id := "arn:aws:ecs:us-east-1:123456789012:task/0123abcd"
+		a, err := ParseTaskARN(id)
+		require.NoError(t, err)
+		assert.Zero(t, a.Cluster)
+		assert.Equal(t, "0123abcd", a.TaskID)
+		assert.Equal(t, id, a.String())
+	})
+	for name, id := range map[string]string{
+		"NonARN":              "0123abcd",
+		"OtherService":        "arn:aws:ec2:us-east-1:123456789012:task/cluster/0123abcd",
+		"MissingRegion":       "arn:aws:ecs::123456789012:task/cluster/0123abcd",
+		"InvalidAccountID":    "arn:aws:ecs:us-east-1:1234:task/cluster/0123abcd",
+		"OtherResource":       "arn:aws:ecs:us-east-1:123456789012:service/cluster/0123abcd",
+		"ColonSeparator":      "arn:aws:ecs:us-east-1:123456789012:task:cluster/0123abcd",
+		"TooManySegments":     "arn:aws:ecs:us-east-1:123456789012:task/cluster/0123abcd/extra",
+		"EmptyTaskID":         "arn:aws:ecs:us-east-1:123456789012:task/cluster/",
+		"InvalidClusterName":  "arn:aws:ecs:us-east-1:123456789012:task/clu.ster/0123abcd",
+		"InvalidTaskIDSymbol": "arn:aws:ecs:us-east-1:123456789012:task/cluster/0123.abcd",
+	} {
+		t.Run("FailsWith"+name, func(t *testing.T) {
+			a, err := ParseTaskARN(id)
+			assert.Error(t, err)
+			assert.Zero(t, a)
+		})
+	}
+}
+
+func TestTaskIDFromARN(t *testing.T) {
+	t.Run("ReturnsTaskIDFromARN", func(t *testing.T) {
+		assert.Equal(t, "0123abcd", TaskIDFromARN("arn:aws:ecs:us-east-1:123456789012:task/cluster/0123abcd"))
+	})
+	t.Run("ReturnsIdentifierThatIsNotAnARN", func(t *testing.T) {
+		assert.Equal(t, "0123abcd", TaskIDFromARN("0123abcd"))
+	})
+}
+
+func TestParseTaskDefinitionARN(t *testing.T) {
+	t.Run("Succeeds", func(t *testing.T) {
+		id := "arn:aws:ecs:us-east-1:123456789012:task-definition/family_name-1:42"
+		a, err := ParseTaskDefinitionARN(id)
+		require.NoError(t, err)
+		assert.Equal(t, "family_name-1", a.Family)
+		assert.Equal(t, 42, a.Revision)
+		assert.Equal(t, "family_name-1:42", a.FamilyRevision())
+		assert.Equal(t, id, a.String())
+	})
+	for name, id := range map[string]string{
+		"NonARN":          "family:1",
+		"OtherResource":   "arn:aws:ecs:us-east-1:123456789012:task/family:1",
+		"ColonSeparator":  "arn:aws:ecs:us-east-1:123456789012:task-definition:family/1",
+		"MissingRevision": "arn:aws:ecs:us-east-1:123456789012:task-definition/family",
+		"ZeroRevision":    "arn:aws:ecs:us-east-1:123456789012:task-definition/family:0",
+		"InvalidFamily":   "arn:aws:ecs:us-east-1:123456789012:task-definition/fam/ily:1",
+	} {
+		t.Run("FailsWith"+name, func(t *testing.T) {
+			a, err := ParseTaskDefinitionARN(id)
+			assert.Error(t, err)
+			assert.Zero(t, a)
+		})
+	}
+}
+
+func TestParseFamilyRevision(t *testing.T) {
+	t.Run("Succeeds", func(t *testing.T) {
+		family, revision, err := ParseFamilyRevision("family:3")
+		require.NoError(t, err)
+		assert.Equal(t, "family", family)
+		assert.Equal(t, 3, revision)
+		assert.Equal(t, "family:3", FormatFamilyRevision(family, revision))
+	})
+	for name, id := range map[string]string{
+		"MissingSeparator":   "family",
+		"EmptyFamily":        ":1",
+		"NonNumericRevision": "family:latest",
+		"NegativeRevision":   "family:-1",
+		"InvalidFamily":      "fam ily:1",
+	} {
+		t.Run("FailsWith"+name, func(t *testing.T) {
+			family, revision, err := ParseFamilyRevision(id)
+			assert.Error(t, err)
+			assert.Zero(t, family)
+			assert.Zero(t, revision)
+		})
+	}
+}
+
+func TestParseContainerARN(t *testing.T) {
+	t.Run("SucceedsWithCurrentFormat", func(t *testing.T) {
+		id := "arn:aws:ecs:us-east-1:123456789012:container/cluster/0123abcd/4567efgh"
+		a, err := ParseContainerARN(id)
+		require.NoError(t, err)
+		assert.Equal(t, "cluster", a.Cluster)
+		assert.Equal(t, "0123abcd", a.TaskID)
+		assert.Equal(t, "4567efgh", a.ContainerID)
+		assert.Equal(t, id, a.String())
+	})
+	t.Run("SucceedsWithOlderFormat", func(t *testing.T) {
+		id := "arn:aws:ecs:us-east-1:123456789012:container/4567efgh"
+		a, err := ParseContainerARN(id)
+		require.NoError(t, err)
+		assert.Zero(t, a.Cluster)
+		assert.Zero(t, a.TaskID)
+		assert.Equal(t, "4567efgh", a.ContainerID)
+		assert.Equal(t, id, a.String())
+	})
+	for name, id := range map[string]string{
+		"OtherResource":      "arn:aws:ecs:us-east-1:123456789012:task/cluster/0123abcd",
+		"ThreeSegments":      "arn:aws:ecs:us-east-1:123456789012:container/cluster/4567efgh",
+		"EmptyContainerID":   "arn:aws:ecs:us-east-1:123456789012:container/cluster/0123abcd/",
+		"InvalidTaskID":      "arn:aws:ecs:us-east-1:123456789012:container/cluster/01.23/4567efgh",
+		"InvalidClusterName": "arn:aws:ecs:us-east-1:123456789012:container/clu.ster/0123abcd/4567efgh",
+	} {
+		t.Run("FailsWith"+name, func(t *testing.T) {
+			a, err := ParseContainerARN(id)
+			assert.Error(t, err)
+			assert.Zero(t, a)
+		})
+	}
+}
+
+func TestParseSecretARN(t *testing.T) {
+	t.Run("SucceedsWithSecretOnly", func(t *testing.T) {
+		id := "arn:aws:secretsmanager:us-east-1:123456789012:secret:path/to/name-AbCdEf"
+		a, err := ParseSecretARN(id)
+		require.NoError(t, err)
+		assert.Equal(t, "path/to/name-AbCdEf", a.Name)
+		assert.Zero(t, a.JSONKey)
+		assert.Zero(t, a.VersionStage)
+		assert.Zero(t, a.VersionID)
+		assert.Equal(t, id, a.String())
+		assert.Equal(t, id, a.SecretID())
+	})
+	t.Run("SucceedsWithJSONKeyAndVersion", func(t *testing.T) {
+		secretID := "arn:aws:secretsmanager:us-east-1:123456789012:secret:name-AbCdEf"
+		id := secretID + ":key:AWSCURRENT:"
+		a, err := ParseSecretARN(id)
+		require.NoError(t, err)
+		assert.Equal(t, "name-AbCdEf", a.Name)
+		assert.Equal(t, "key", a.JSONKey)
+		assert.Equal(t, "AWSCURRENT", a.VersionStage)
+		assert.Zero(t, a.VersionID)
+		assert.Equal(t, id, a.String())
+		assert.Equal(t, secretID, a.SecretID())
+	})
+	for name, id := range map[string]string{
+		"NonARN":            "name",
+		"OtherService":      "arn:aws:ssm:us-east-1:123456789012:secret:name",
+		"OtherResource":     "arn:aws:secretsmanager:us-east-1:123456789012:parameter:name",
+		"MissingAccountID":  "arn:aws:secretsmanager:us-east-1::secret:name",
+		"EmptyName":         "arn:aws:secretsmanager:us-east-1:123456789012:secret:",
+		"PartialVersion":    "arn:aws:secretsmanager:us-east-1:123456789012:secret:name:key",
+		"InvalidNameSymbol": "arn:aws:secretsmanager:us-east-1:123456789012:secret:na me",
+	} {
+		t.Run("FailsWith"+name, func(t *testing.T) {
+			a, err := ParseSecretARN(id)
+			assert.Error(t, err)
+			assert.Zero(t, a)
+		})
+	}
+}
+
+func TestParseParameterARN(t *testing.T) {
+	t.Run("Succeeds", func(t *testing.T) {
+		id := "arn:aws:ssm:us-east-1:123456789012:parameter/path/to/name"
+		a, err := ParseParameterARN(id)
+		require.NoError(t, err)
+		assert.Equal(t, "path/to/name", a.Name)
+		assert.Equal(t, "us-east-1", a.Region)
+		assert.Equal(t, "123456789012", a.AccountID)
+		assert.Equal(t, id, a.String())
+	})
+	for name, id := range map[string]string{
+		"NonARN":           "name",
+		"OtherService":     "arn:aws:secretsmanager:us-east-1:123456789012:parameter/name",
+		"OtherResource":    "arn:aws:ssm:us-east-1:123456789012:document/name",
+		"MissingRegion":    "arn:aws:ssm::123456789012:parameter/name",
+		"MissingAccountID": "arn:aws:ssm:us-east-1::parameter/name",
+		"EmptyName":        "arn:aws:ssm:us-east-1:123456789012:parameter/",
+	} {
+		t.Run("FailsWith"+name, func(t *testing.T) {
+			a, err := ParseParameterARN(id)
+			assert.Error(t, err)
+			assert.Zero(t, a)
+		})
+	}
+}
+
+func TestParseSecretLocation(t *testing.T) {
+	t.Run("SucceedsWithSecretsManagerARN", func(t *testing.T) {
+		l, err := ParseSecretLocation("arn:aws:secretsmanager:us-east-1:123456789012:secret:name-AbCdEf")
+		require.NoError(t, err)
+		require.NotZero(t, l)
+		assert.Equal(t, Location{Partition: "aws", Region: "us-east-1", AccountID: "123456789012"}, *l)
+	})
+	t.Run("SucceedsWithSecretsManagerARNReferencingJSONKey", func(t *testing.T) {
+		_, err := ParseSecretLocation("arn:aws:secretsmanager:us-east-1:123456789012:secret:name-AbCdEf:key::")
+		assert.NoError(t, err)
+	})
+	t.Run("SucceedsWithSSMParameterARN", func(t *testing.T) {
+		l, err := ParseSecretLocation("arn:aws:ssm:us-west-2:123456789012:parameter/path/to/name")
+		require.NoError(t, err)
+		require.NotZero(t, l)
+		assert.Equal(t, "us-west-2", l.Region)
+	})
+	for name, id := range map[string]string{
+		"MalformedARN":       "arn:aws:secretsmanager",
+		"UnsupportedService": "arn:aws:s3:us-east-1:123456789012:secret:name",
+		"NonSecretResource":  "arn:aws:secretsmanager:us-east-1:123456789012:name",
+		"MissingRegion":      "arn:aws:secretsmanager::123456789012:secret:name",
+		"InvalidAccountID":   "arn:aws:secretsmanager:us-east-1:1234:secret:name",
+	} {
+		t.Run("FailsWith"+name, func(t *testing.T) {
+			l, err := ParseSecretLocation(id)
+			assert.Error(t, err)
+			assert.Zero(t, l)
+		})
+	}
+}
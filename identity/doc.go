@@ -0,0 +1,5 @@
+/*
+Package identity provides parsers and formatters for the ARNs that identify
+pods, pod definitions, containers, and secrets in AWS.
+*/
+package identity
@@ -216,6 +216,43 @@ func ECSPodCreatorVaultTests() map[string]ECSPodCreatorTestCase {
 			require.Len(t, res.Containers, 1)
 			require.Len(t, res.Containers[0].Secrets, 1)
 		},
+		"CreatePodReturnsPartialCreationErrorWhenRunningTaskFails": func(ctx context.Context, t *testing.T, c cocoa.ECSPodCreator) {
+			envVar := cocoa.NewEnvironmentVariable().
+				SetName("envVar").
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(testutil.NewSecretName(t)).
+					SetNewValue("value").
+					SetOwned(true))
+
+			containerDef := cocoa.NewECSContainerDefinition().
+				SetImage("image").
+				AddEnvironmentVariables(*envVar).
+				SetMemoryMB(128).
+				SetCPU(128).
+				SetName("container")
+
+			defOpts := cocoa.NewECSPodDefinitionOptions().
+				SetName(testutil.NewTaskDefinitionFamily(t)).
+				AddContainerDefinitions(*containerDef).
+				SetMemoryMB(128).
+				SetCPU(128).
+				SetTaskRole(testutil.ECSTaskRole()).
+				SetExecutionRole(testutil.ECSExecutionRole())
+
+			opts := cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*validECSPodExecutionOptions().SetCluster("foo"))
+			assert.NoError(t, opts.Validate())
+
+			p, err := c.CreatePod(ctx, *opts)
+			require.Error(t, err)
+			assert.Zero(t, p)
+
+			pce, ok := cocoa.AsPartialCreationError(err)
+			require.True(t, ok, "error should be a partial creation error")
+			assert.Len(t, pce.SecretIDs, 1)
+			assert.NotZero(t, pce.TaskDefinitionID)
+		},
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 
 	"github.com/evergreen-ci/cocoa"
 	"github.com/evergreen-ci/cocoa/internal/testutil"
+	"github.com/evergreen-ci/utility"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -176,5 +177,122 @@ func ECSPodDefinitionManagerVaultTests() map[string]ECSPodDefinitionManagerTestC
 			assert.NotZero(t, pdi.ID)
 			assert.NotZero(t, pdi.DefinitionOpts)
 		},
+		"ListPodDefinitionRevisionsReturnsActiveAndInactiveRevisions": func(ctx context.Context, t *testing.T, pdm cocoa.ECSPodDefinitionManager) {
+			containerDef := cocoa.NewECSContainerDefinition().
+				SetImage("image").
+				SetMemoryMB(128).
+				SetCPU(128).
+				SetName("container")
+			opts := cocoa.NewECSPodDefinitionOptions().
+				SetName(testutil.NewTaskDefinitionFamily(t)).
+				AddContainerDefinitions(*containerDef).
+				SetMemoryMB(128).
+				SetCPU(128)
+			assert.NoError(t, opts.Validate())
+
+			deleted, err := pdm.CreatePodDefinition(ctx, *opts)
+			require.NoError(t, err)
+			require.NotZero(t, deleted)
+			require.NoError(t, pdm.DeletePodDefinition(ctx, deleted.ID))
+
+			active, err := pdm.CreatePodDefinition(ctx, *opts)
+			require.NoError(t, err)
+			require.NotZero(t, active)
+
+			revisions, err := revisionLister(t, pdm).ListPodDefinitionRevisions(ctx, utility.FromStringPtr(opts.Name))
+			require.NoError(t, err)
+			require.Len(t, revisions, 2)
+
+			assert.Equal(t, deleted.ID, revisions[0].ID)
+			assert.Equal(t, utility.FromStringPtr(opts.Name), revisions[0].Family)
+			assert.Equal(t, cocoa.PodDefinitionStatusInactive, revisions[0].Status)
+			assert.NotZero(t, revisions[0].RegisteredAt)
+			assert.NotZero(t, revisions[0].DeregisteredAt)
+
+			assert.Equal(t, active.ID, revisions[1].ID)
+			assert.Equal(t, utility.FromStringPtr(opts.Name), revisions[1].Family)
+			assert.Equal(t, cocoa.PodDefinitionStatusActive, revisions[1].Status)
+			assert.NotZero(t, revisions[1].RegisteredAt)
+			assert.Zero(t, revisions[1].DeregisteredAt)
+			assert.True(t, revisions[0].Revision < revisions[1].Revision)
+		},
+		"ListPodDefinitionRevisionsReturnsNoRevisionsForNonexistentFamily": func(ctx context.Context, t *testing.T, pdm cocoa.ECSPodDefinitionManager) {
+			revisions, err := revisionLister(t, pdm).ListPodDefinitionRevisions(ctx, testutil.NewTaskDefinitionFamily(t))
+			require.NoError(t, err)
+			assert.Empty(t, revisions)
+		},
+		"ListPodDefinitionRevisionsFailsWithEmptyFamily": func(ctx context.Context, t *testing.T, pdm cocoa.ECSPodDefinitionManager) {
+			revisions, err := revisionLister(t, pdm).ListPodDefinitionRevisions(ctx, "")
+			assert.Error(t, err)
+			assert.Empty(t, revisions)
+		},
+		"ImportPodDefinitionReturnsEquivalentPodDefinitionOptions": func(ctx context.Context, t *testing.T, pdm cocoa.ECSPodDefinitionManager) {
+			containerDef := cocoa.NewECSContainerDefinition().
+				SetImage("image").
+				SetCommand([]string{"echo", "foo"}).
+				SetWorkingDir("working_dir").
+				AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().SetName("name").SetValue("value")).
+				SetMemoryMB(128).
+				SetCPU(128).
+				AddPortMappings(*cocoa.NewPortMapping().SetContainerPort(1337)).
+				AddBindMounts(*cocoa.NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data").SetReadOnly(true)).
+				SetInteractive(true).
+				SetPseudoTerminal(true).
+				SetName("container")
+			opts := cocoa.NewECSPodDefinitionOptions().
+				SetName(testutil.NewTaskDefinitionFamily(t)).
+				AddContainerDefinitions(*containerDef).
+				SetMemoryMB(128).
+				SetCPU(128).
+				SetNetworkMode(cocoa.NetworkModeBridge)
+			assert.NoError(t, opts.Validate())
+
+			created, err := pdm.CreatePodDefinition(ctx, *opts)
+			require.NoError(t, err)
+			require.NotZero(t, created)
+
+			imported, err := importer(t, pdm).ImportPodDefinition(ctx, created.ID)
+			require.NoError(t, err)
+			require.NotZero(t, imported)
+			assert.Equal(t, created.ID, imported.ID)
+
+			importedOpts := imported.DefinitionOpts
+			importedOpts.Tags = nil
+			createdOpts := created.DefinitionOpts
+			createdOpts.Tags = nil
+			assert.True(t, createdOpts.Equals(importedOpts), "imported pod definition options should be equivalent to the created ones")
+		},
+		"ImportPodDefinitionFailsWithNonexistentPodDefinition": func(ctx context.Context, t *testing.T, pdm cocoa.ECSPodDefinitionManager) {
+			pdi, err := importer(t, pdm).ImportPodDefinition(ctx, testutil.NewTaskDefinitionFamily(t)+":1")
+			assert.Error(t, err)
+			assert.Zero(t, pdi)
+		},
+		"ImportPodDefinitionFailsWithEmptyID": func(ctx context.Context, t *testing.T, pdm cocoa.ECSPodDefinitionManager) {
+			pdi, err := importer(t, pdm).ImportPodDefinition(ctx, "")
+			assert.Error(t, err)
+			assert.Zero(t, pdi)
+		},
+	}
+}
+
+// revisionLister returns the pod definition manager as a
+// cocoa.ECSPodDefinitionRevisionLister, skipping the test if it does not
+// support listing revisions.
+func revisionLister(t *testing.T, pdm cocoa.ECSPodDefinitionManager) cocoa.ECSPodDefinitionRevisionLister {
+	lister, ok := pdm.(cocoa.ECSPodDefinitionRevisionLister)
+	if !ok {
+		t.Skip("pod definition manager does not support listing pod definition revisions")
+	}
+	return lister
+}
+
+// importer returns the pod definition manager as a
+// cocoa.ECSPodDefinitionImporter, skipping the test if it does not support
+// importing pod definitions.
+func importer(t *testing.T, pdm cocoa.ECSPodDefinitionManager) cocoa.ECSPodDefinitionImporter {
+	imp, ok := pdm.(cocoa.ECSPodDefinitionImporter)
+	if !ok {
+		t.Skip("pod definition manager does not support importing pod definitions")
 	}
+	return imp
 }
@@ -177,6 +177,52 @@ func SecretsManagerClientTests() map[string]SecretsManagerClientTestCase {
 			require.NoError(t, err)
 			require.NotZero(t, out)
 		},
+		"RestoreSecretSucceedsWithSecretScheduledForDeletion": func(ctx context.Context, t *testing.T, c cocoa.SecretsManagerClient) {
+			createOut := testutil.CreateSecret(ctx, t, c, secretsmanager.CreateSecretInput{
+				Name:         aws.String(testutil.NewSecretName(t)),
+				SecretString: aws.String("hello"),
+			})
+			defer cleanupSecret(ctx, t, c, &createOut)
+
+			deleteOut, err := c.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+				RecoveryWindowInDays: aws.Int64(7),
+				SecretId:             createOut.ARN,
+			})
+			require.NoError(t, err)
+			require.NotZero(t, deleteOut)
+			assert.NotZero(t, deleteOut.DeletionDate)
+
+			_, err = c.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+				SecretId: createOut.ARN,
+			})
+			assert.Error(t, err, "should not be able to get a deleted secret's value")
+
+			restoreOut, err := c.RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{
+				SecretId: createOut.ARN,
+			})
+			require.NoError(t, err)
+			require.NotZero(t, restoreOut)
+			assert.Equal(t, utility.FromStringPtr(createOut.ARN), utility.FromStringPtr(restoreOut.ARN))
+
+			getOut, err := c.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+				SecretId: createOut.ARN,
+			})
+			require.NoError(t, err)
+			require.NotZero(t, getOut)
+			assert.Equal(t, "hello", utility.FromStringPtr(getOut.SecretString))
+		},
+		"RestoreSecretFailsWithInvalidInput": func(ctx context.Context, t *testing.T, c cocoa.SecretsManagerClient) {
+			out, err := c.RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{})
+			assert.Error(t, err)
+			assert.Zero(t, out)
+		},
+		"RestoreSecretFailsWithValidNonexistentSecret": func(ctx context.Context, t *testing.T, c cocoa.SecretsManagerClient) {
+			out, err := c.RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{
+				SecretId: aws.String(testutil.NewSecretName(t)),
+			})
+			assert.Error(t, err)
+			assert.Zero(t, out)
+		},
 		"TagResourceSucceeds": func(ctx context.Context, t *testing.T, c cocoa.SecretsManagerClient) {
 			createOut := testutil.CreateSecret(ctx, t, c, secretsmanager.CreateSecretInput{
 				Name:         aws.String(testutil.NewSecretName(t)),
@@ -0,0 +1,9 @@
+/*
+Package metadata provides types and parsers for the ECS task metadata (version
+4) that ECS makes available to the containers within a pod. This allows
+processes running inside a pod to inspect the pod using the same types that
+are used to manage it.
+
+Docs: https://docs.aws.amazon.com/AmazonECS/latest/developerguide/task-metadata-endpoint-v4.html
+*/
+package metadata
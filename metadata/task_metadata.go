@@ -0,0 +1,337 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/ecs"
+	"github.com/evergreen-ci/cocoa/identity"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// URIEnvVar is the environment variable that ECS sets in each container to
+// the base URI of the container's task metadata endpoint.
+const URIEnvVar = "ECS_CONTAINER_METADATA_URI_V4"
+
+// TaskMetadata is the task metadata document describing the pod that a
+// container is running in.
+type TaskMetadata struct {
+	// Cluster is the name or ARN of the cluster that the pod is running in.
+	Cluster string `json:"Cluster"`
+	// TaskARN is the ARN of the pod's task.
+	TaskARN string `json:"TaskARN"`
+	// Family is the family of the pod definition.
+	Family string `json:"Family"`
+	// Revision is the revision of the pod definition.
+	Revision string `json:"Revision"`
+	// ServiceName is the name of the service that the pod belongs to, if any.
+	ServiceName string `json:"ServiceName,omitempty"`
+	// DesiredStatus is the status that ECS wants the pod to have.
+	DesiredStatus string `json:"DesiredStatus"`
+	// KnownStatus is the last known status of the pod.
+	KnownStatus string `json:"KnownStatus"`
+	// Limits are the pod-level resource limits. The CPU is in vCPUs.
+	Limits *Limits `json:"Limits,omitempty"`
+	// PullStartedAt is when the pod started pulling its container images.
+	PullStartedAt *time.Time `json:"PullStartedAt,omitempty"`
+	// PullStoppedAt is when the pod finished pulling its container images.
+	PullStoppedAt *time.Time `json:"PullStoppedAt,omitempty"`
+	// ExecutionStoppedAt is when the pod's containers stopped running.
+	ExecutionStoppedAt *time.Time `json:"ExecutionStoppedAt,omitempty"`
+	// AvailabilityZone is the availability zone that the pod is running in.
+	AvailabilityZone string `json:"AvailabilityZone,omitempty"`
+	// LaunchType is the launch type of the pod (e.g. EC2 or FARGATE).
+	LaunchType string `json:"LaunchType,omitempty"`
+	// TaskTags are the tags on the pod's task. These are only included if
+	// requested from the task metadata endpoint with tags.
+	TaskTags map[string]string `json:"TaskTags,omitempty"`
+	// Containers are the metadata for each container in the pod.
+	Containers []ContainerMetadata `json:"Containers"`
+}
+
+// ContainerMetadata is the container metadata document describing a single
+// container in a pod.
+type ContainerMetadata struct {
+	// DockerID is the Docker ID of the container.
+	DockerID string `json:"DockerId"`
+	// Name is the name of the container from the pod definition.
+	Name string `json:"Name"`
+	// DockerName is the name that Docker gave to the container.
+	DockerName string `json:"DockerName,omitempty"`
+	// Image is the image that the container is running.
+	Image string `json:"Image"`
+	// ImageID is the digest of the image that the container is running.
+	ImageID string `json:"ImageID,omitempty"`
+	// Labels are the Docker labels on the container.
+	Labels map[string]string `json:"Labels,omitempty"`
+	// DesiredStatus is the status that ECS wants the container to have.
+	DesiredStatus string `json:"DesiredStatus"`
+	// KnownStatus is the last known status of the container.
+	KnownStatus string `json:"KnownStatus"`
+	// ExitCode is the exit code of the container once it has stopped.
+	ExitCode *int `json:"ExitCode,omitempty"`
+	// Limits are the container-level resource limits. The CPU is in CPU
+	// units.
+	Limits *Limits `json:"Limits,omitempty"`
+	// CreatedAt is when the container was created.
+	CreatedAt *time.Time `json:"CreatedAt,omitempty"`
+	// StartedAt is when the container started.
+	StartedAt *time.Time `json:"StartedAt,omitempty"`
+	// FinishedAt is when the container stopped.
+	FinishedAt *time.Time `json:"FinishedAt,omitempty"`
+	// Type is the type of the container. Containers from the pod definition
+	// have the type NORMAL.
+	Type string `json:"Type,omitempty"`
+	// ContainerARN is the ARN of the container.
+	ContainerARN string `json:"ContainerARN"`
+	// LogDriver is the log driver that the container uses.
+	LogDriver string `json:"LogDriver,omitempty"`
+	// LogOptions are the options for the container's log driver.
+	LogOptions map[string]string `json:"LogOptions,omitempty"`
+	// Networks are the networks that the container is attached to.
+	Networks []Network `json:"Networks,omitempty"`
+}
+
+// Limits are the CPU and memory limits of a pod or container. The CPU limit
+// of a pod is in vCPUs, whereas the CPU limit of a container is in CPU units.
+type Limits struct {
+	// CPU is the CPU limit.
+	CPU float64 `json:"CPU"`
+	// Memory is the memory limit (in MB).
+	Memory int `json:"Memory"`
+}
+
+// Network is a network that a container is attached to.
+type Network struct {
+	// NetworkMode is the network mode of the pod.
+	NetworkMode string `json:"NetworkMode"`
+	// IPv4Addresses are the IPv4 addresses of the container in the network.
+	IPv4Addresses []string `json:"IPv4Addresses,omitempty"`
+	// IPv6Addresses are the IPv6 addresses of the container in the network.
+	IPv6Addresses []string `json:"IPv6Addresses,omitempty"`
+	// PrivateDNSName is the private DNS name of the network interface, if
+	// using AWSVPC networking.
+	PrivateDNSName string `json:"PrivateDNSName,omitempty"`
+	// MACAddress is the MAC address of the network interface, if using AWSVPC
+	// networking.
+	MACAddress string `json:"MACAddress,omitempty"`
+	// IPv4SubnetCIDRBlock is the CIDR block of the subnet, if using AWSVPC
+	// networking.
+	IPv4SubnetCIDRBlock string `json:"IPv4SubnetCIDRBlock,omitempty"`
+}
+
+// ParseTaskMetadata parses and validates a task metadata document.
+func ParseTaskMetadata(r io.Reader) (*TaskMetadata, error) {
+	var m TaskMetadata
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, errors.Wrap(err, "decoding task metadata")
+	}
+	if err := m.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid task metadata")
+	}
+	return &m, nil
+}
+
+// ParseContainerMetadata parses and validates a container metadata document.
+func ParseContainerMetadata(r io.Reader) (*ContainerMetadata, error) {
+	var m ContainerMetadata
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, errors.Wrap(err, "decoding container metadata")
+	}
+	if err := m.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid container metadata")
+	}
+	return &m, nil
+}
+
+// FetchTaskMetadata fetches the task metadata document for the pod that the
+// current process is running in from the task metadata endpoint. If the
+// HTTP client is nil, the default HTTP client is used.
+func FetchTaskMetadata(ctx context.Context, c *http.Client) (*TaskMetadata, error) {
+	body, err := fetch(ctx, c, "task")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return ParseTaskMetadata(body)
+}
+
+// FetchContainerMetadata fetches the container metadata document for the
+// container that the current process is running in from the task metadata
+// endpoint. If the HTTP client is nil, the default HTTP client is used.
+func FetchContainerMetadata(ctx context.Context, c *http.Client) (*ContainerMetadata, error) {
+	body, err := fetch(ctx, c, "")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return ParseContainerMetadata(body)
+}
+
+// fetch requests the metadata at the path relative to the task metadata
+// endpoint and returns the response body.
+func fetch(ctx context.Context, c *http.Client, path string) (io.ReadCloser, error) {
+	baseURI := os.Getenv(URIEnvVar)
+	if baseURI == "" {
+		return nil, errors.Errorf("environment variable '%s' is not set, so the process does not appear to be running in an ECS container", URIEnvVar)
+	}
+	uri := strings.TrimSuffix(baseURI, "/")
+	if path != "" {
+		uri = fmt.Sprintf("%s/%s", uri, path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating metadata request")
+	}
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "requesting metadata from '%s'", uri)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("requesting metadata from '%s' returned status %d", uri, resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+// Validate checks that the task metadata identifies the pod and its
+// containers.
+func (m *TaskMetadata) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(m.TaskARN == "", "must have a task ARN")
+	catcher.NewWhen(m.Cluster == "", "must have a cluster")
+	if _, _, err := identity.ParseFamilyRevision(m.familyRevision()); err != nil {
+		catcher.Wrap(err, "invalid pod definition family and revision")
+	}
+	for i := range m.Containers {
+		catcher.Wrapf(m.Containers[i].Validate(), "container '%s'", m.Containers[i].Name)
+	}
+	return catcher.Resolve()
+}
+
+// familyRevision returns the pod definition identified by its family and
+// revision.
+func (m *TaskMetadata) familyRevision() string {
+	return fmt.Sprintf("%s:%s", m.Family, m.Revision)
+}
+
+// Resources returns the resources associated with the pod. The pod definition
+// is identified by its family and revision.
+func (m *TaskMetadata) Resources() cocoa.ECSPodResources {
+	res := cocoa.NewECSPodResources().
+		SetTaskID(m.TaskARN).
+		SetCluster(m.Cluster).
+		SetTaskDefinition(*cocoa.NewECSTaskDefinition().SetID(m.familyRevision()))
+	for _, c := range m.Containers {
+		res.AddContainers(*cocoa.NewECSContainerResources().
+			SetContainerID(c.ContainerARN).
+			SetName(c.Name))
+	}
+	return *res
+}
+
+// StatusInfo returns the status information for the pod and its containers.
+func (m *TaskMetadata) StatusInfo() cocoa.ECSPodStatusInfo {
+	statusInfo := cocoa.NewECSPodStatusInfo().SetStatus(ecs.TaskStatus(m.KnownStatus).ToCocoaStatus())
+	for _, c := range m.Containers {
+		statusInfo.AddContainers(c.StatusInfo())
+	}
+	statusInfo.PullStartedAt = m.PullStartedAt
+	statusInfo.PullStoppedAt = m.PullStoppedAt
+	statusInfo.ExecutionStoppedAt = m.ExecutionStoppedAt
+	return *statusInfo
+}
+
+// CPU returns the pod-level CPU limit, if any.
+func (m *TaskMetadata) CPU() *cocoa.CPUUnits {
+	if m.Limits == nil {
+		return nil
+	}
+	cpu := cocoa.VCPUs(m.Limits.CPU)
+	return &cpu
+}
+
+// MemoryMB returns the pod-level memory limit, if any.
+func (m *TaskMetadata) MemoryMB() *cocoa.MemoryMB {
+	if m.Limits == nil {
+		return nil
+	}
+	mem := cocoa.MemoryMB(m.Limits.Memory)
+	return &mem
+}
+
+// Tags returns the pod's tags. The tags are only available if they were
+// requested from the task metadata endpoint.
+func (m *TaskMetadata) Tags() cocoa.Tags {
+	return cocoa.NewTags().Add(m.TaskTags)
+}
+
+// Validate checks that the container metadata identifies the container.
+func (m *ContainerMetadata) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(m.Name == "", "must have a container name")
+	catcher.NewWhen(m.ContainerARN == "", "must have a container ARN")
+	return catcher.Resolve()
+}
+
+// StatusInfo returns the status information for the container.
+func (m *ContainerMetadata) StatusInfo() cocoa.ECSContainerStatusInfo {
+	statusInfo := cocoa.NewECSContainerStatusInfo().
+		SetContainerID(m.ContainerARN).
+		SetName(m.Name).
+		SetStatus(ecs.TaskStatus(m.KnownStatus).ToCocoaStatus())
+	if m.ExitCode != nil {
+		statusInfo.SetExitCode(*m.ExitCode)
+	}
+	return *statusInfo
+}
+
+// CPU returns the container-level CPU limit, if any.
+func (m *ContainerMetadata) CPU() *cocoa.CPUUnits {
+	if m.Limits == nil || m.Limits.CPU == 0 {
+		return nil
+	}
+	cpu := cocoa.CPUUnits(m.Limits.CPU)
+	return &cpu
+}
+
+// MemoryMB returns the container-level memory limit, if any.
+func (m *ContainerMetadata) MemoryMB() *cocoa.MemoryMB {
+	if m.Limits == nil || m.Limits.Memory == 0 {
+		return nil
+	}
+	mem := cocoa.MemoryMB(m.Limits.Memory)
+	return &mem
+}
+
+// IPv4Addresses returns all the IPv4 addresses of the container across all its
+// networks.
+func (m *ContainerMetadata) IPv4Addresses() []string {
+	var addrs []string
+	seen := map[string]bool{}
+	for _, n := range m.Networks {
+		for _, addr := range n.IPv4Addresses {
+			if seen[addr] {
+				continue
+			}
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,183 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const containerMetadataJSON = `{
+	"DockerId": "cd189a933e5849daa93386466019ab50-2495160603",
+	"Name": "main",
+	"DockerName": "ecs-family-3-main-b4a8e3f1d9f5c3a5",
+	"Image": "busybox:latest",
+	"ImageID": "sha256:7a41c3d6ac6b0fde1e3d14b5a9c7ed9c8f3a5d3f2b0b4f4e6f4d9a7f0c3b2a1e",
+	"Labels": {"com.amazonaws.ecs.task-definition-family": "family"},
+	"DesiredStatus": "RUNNING",
+	"KnownStatus": "STOPPED",
+	"ExitCode": 1,
+	"Limits": {"CPU": 512, "Memory": 1024},
+	"CreatedAt": "2023-06-01T00:00:01.123456789Z",
+	"StartedAt": "2023-06-01T00:00:02.123456789Z",
+	"Type": "NORMAL",
+	"ContainerARN": "arn:aws:ecs:us-east-1:123456789012:container/cluster/0123abcd/4567efgh",
+	"LogDriver": "awslogs",
+	"LogOptions": {"awslogs-group": "group"},
+	"Networks": [{
+		"NetworkMode": "awsvpc",
+		"IPv4Addresses": ["10.0.0.108"],
+		"PrivateDNSName": "ip-10-0-0-108.ec2.internal",
+		"MACAddress": "0e:9e:32:c7:48:85",
+		"IPv4SubnetCIDRBlock": "10.0.0.0/24"
+	}]
+}`
+
+const taskMetadataJSON = `{
+	"Cluster": "arn:aws:ecs:us-east-1:123456789012:cluster/cluster",
+	"TaskARN": "arn:aws:ecs:us-east-1:123456789012:task/cluster/0123abcd",
+	"Family": "family",
+	"Revision": "3",
+	"DesiredStatus": "RUNNING",
+	"KnownStatus": "RUNNING",
+	"Limits": {"CPU": 0.5, "Memory": 2048},
+	"PullStartedAt": "2023-06-01T00:00:00.123456789Z",
+	"PullStoppedAt": "2023-06-01T00:00:01.123456789Z",
+	"AvailabilityZone": "us-east-1a",
+	"LaunchType": "FARGATE",
+	"TaskTags": {"cocoa-purpose": "agent"},
+	"Containers": [` + containerMetadataJSON + `]
+}`
+
+func TestParseTaskMetadata(t *testing.T) {
+	t.Run("Succeeds", func(t *testing.T) {
+		m, err := ParseTaskMetadata(strings.NewReader(taskMetadataJSON))
+		require.NoError(t, err)
+		assert.Equal(t, "arn:aws:ecs:us-east-1:123456789012:task/cluster/0123abcd", m.TaskARN)
+		assert.Equal(t, "family", m.Family)
+		assert.Equal(t, "3", m.Revision)
+		assert.Equal(t, "FARGATE", m.LaunchType)
+		require.NotZero(t, m.PullStartedAt)
+		require.Len(t, m.Containers, 1)
+		assert.Equal(t, "main", m.Containers[0].Name)
+		assert.Equal(t, 1, utility.FromIntPtr(m.Containers[0].ExitCode))
+	})
+	t.Run("FailsWithMalformedJSON", func(t *testing.T) {
+		m, err := ParseTaskMetadata(strings.NewReader("{"))
+		assert.Error(t, err)
+		assert.Zero(t, m)
+	})
+	t.Run("FailsWithoutTaskARN", func(t *testing.T) {
+		m, err := ParseTaskMetadata(strings.NewReader(`{"Cluster": "cluster", "Family": "family", "Revision": "1"}`))
+		assert.Error(t, err)
+		assert.Zero(t, m)
+	})
+	t.Run("FailsWithInvalidRevision", func(t *testing.T) {
+		m, err := ParseTaskMetadata(strings.NewReader(`{"Cluster": "cluster", "TaskARN": "arn", "Family": "family", "Revision": "latest"}`))
+		assert.Error(t, err)
+		assert.Zero(t, m)
+	})
+	t.Run("FailsWithInvalidContainer", func(t *testing.T) {
+		m, err := ParseTaskMetadata(strings.NewReader(`{"Cluster": "cluster", "TaskARN": "arn", "Family": "family", "Revision": "1", "Containers": [{"Name": "main"}]}`))
+		assert.Error(t, err)
+		assert.Zero(t, m)
+	})
+}
+
+func TestTaskMetadata(t *testing.T) {
+	m, err := ParseTaskMetadata(strings.NewReader(taskMetadataJSON))
+	require.NoError(t, err)
+
+	t.Run("Resources", func(t *testing.T) {
+		res := m.Resources()
+		assert.NoError(t, res.Validate())
+		assert.Equal(t, m.TaskARN, utility.FromStringPtr(res.TaskID))
+		assert.Equal(t, m.Cluster, utility.FromStringPtr(res.Cluster))
+		require.NotZero(t, res.TaskDefinition)
+		assert.Equal(t, "family:3", utility.FromStringPtr(res.TaskDefinition.ID))
+		require.Len(t, res.Containers, 1)
+		assert.Equal(t, "main", utility.FromStringPtr(res.Containers[0].Name))
+		assert.Equal(t, m.Containers[0].ContainerARN, utility.FromStringPtr(res.Containers[0].ContainerID))
+	})
+	t.Run("StatusInfo", func(t *testing.T) {
+		statusInfo := m.StatusInfo()
+		assert.Equal(t, cocoa.StatusRunning, statusInfo.Status)
+		assert.Equal(t, m.PullStartedAt, statusInfo.PullStartedAt)
+		assert.Equal(t, m.PullStoppedAt, statusInfo.PullStoppedAt)
+		require.Len(t, statusInfo.Containers, 1)
+		assert.Equal(t, cocoa.StatusStopped, statusInfo.Containers[0].Status)
+		assert.Equal(t, 1, utility.FromIntPtr(statusInfo.Containers[0].ExitCode))
+	})
+	t.Run("Limits", func(t *testing.T) {
+		require.NotZero(t, m.CPU())
+		assert.Equal(t, cocoa.VCPUs(0.5), *m.CPU())
+		require.NotZero(t, m.MemoryMB())
+		assert.Equal(t, cocoa.GiB(2), *m.MemoryMB())
+
+		c := m.Containers[0]
+		require.NotZero(t, c.CPU())
+		assert.Equal(t, cocoa.CPUUnits(512), *c.CPU())
+		require.NotZero(t, c.MemoryMB())
+		assert.Equal(t, cocoa.MemoryMB(1024), *c.MemoryMB())
+	})
+	t.Run("LimitsAreNilWithoutLimits", func(t *testing.T) {
+		noLimits := TaskMetadata{Containers: []ContainerMetadata{{}}}
+		assert.Zero(t, noLimits.CPU())
+		assert.Zero(t, noLimits.MemoryMB())
+		assert.Zero(t, noLimits.Containers[0].CPU())
+		assert.Zero(t, noLimits.Containers[0].MemoryMB())
+	})
+	t.Run("Tags", func(t *testing.T) {
+		purpose, ok := m.Tags().GetPurpose()
+		require.True(t, ok)
+		assert.Equal(t, "agent", purpose)
+	})
+	t.Run("IPv4Addresses", func(t *testing.T) {
+		assert.Equal(t, []string{"10.0.0.108"}, m.Containers[0].IPv4Addresses())
+	})
+}
+
+func TestFetchMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v4/id":
+			_, _ = w.Write([]byte(containerMetadataJSON))
+		case "/v4/id/task":
+			_, _ = w.Write([]byte(taskMetadataJSON))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("FetchTaskMetadataSucceeds", func(t *testing.T) {
+		t.Setenv(URIEnvVar, srv.URL+"/v4/id")
+		m, err := FetchTaskMetadata(context.Background(), srv.Client())
+		require.NoError(t, err)
+		assert.Equal(t, "family", m.Family)
+	})
+	t.Run("FetchContainerMetadataSucceeds", func(t *testing.T) {
+		t.Setenv(URIEnvVar, srv.URL+"/v4/id")
+		m, err := FetchContainerMetadata(context.Background(), srv.Client())
+		require.NoError(t, err)
+		assert.Equal(t, "main", m.Name)
+	})
+	t.Run("FailsWithErrorStatus", func(t *testing.T) {
+		t.Setenv(URIEnvVar, srv.URL+"/v4/other")
+		m, err := FetchTaskMetadata(context.Background(), srv.Client())
+		assert.Error(t, err)
+		assert.Zero(t, m)
+	})
+	t.Run("FailsOutsideECSContainer", func(t *testing.T) {
+		t.Setenv(URIEnvVar, "")
+		m, err := FetchTaskMetadata(context.Background(), srv.Client())
+		assert.Error(t, err)
+		assert.Zero(t, m)
+	})
+}
@@ -0,0 +1,149 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/ecs"
+	"github.com/evergreen-ci/cocoa/internal/testutil"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetClusterCapacity(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer resetECSAndSecretsManagerCache()
+
+	addInstance := func(cpu, memMB int) ECSContainerInstance {
+		instance := NewECSContainerInstance()
+		instance.RegisteredCPU = cpu
+		instance.RegisteredMemoryMB = memMB
+		GlobalECSService.ContainerInstances[testutil.ECSClusterName()] = append(GlobalECSService.ContainerInstances[testutil.ECSClusterName()], instance)
+		return instance
+	}
+	addTask := func(instance ECSContainerInstance, cpu, memMB string, status types.DesiredStatus) {
+		arn := utility.RandomString()
+		GlobalECSService.Clusters[testutil.ECSClusterName()][arn] = ECSTask{
+			ARN:               arn,
+			ContainerInstance: utility.ToStringPtr(instance.ARN),
+			Status:            string(status),
+			TaskDef: ECSTaskDefinition{
+				CPU:      utility.ToStringPtr(cpu),
+				MemoryMB: utility.ToStringPtr(memMB),
+			},
+		}
+	}
+	makeDef := func(cpu, memMB int) cocoa.ECSPodDefinitionOptions {
+		return *cocoa.NewECSPodDefinitionOptions().
+			AddContainerDefinitions(
+				*cocoa.NewECSContainerDefinition().SetCPU(cpu / 2).SetMemoryMB(memMB / 2),
+				*cocoa.NewECSContainerDefinition().SetCPU(cpu / 2).SetMemoryMB(memMB / 2),
+			)
+	}
+
+	for tName, tCase := range map[string]func(ctx context.Context, t *testing.T, c *ECSClient){
+		"AggregatesRegisteredAndRemainingResourcesAcrossInstances": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			first := addInstance(1024, 2048)
+			second := addInstance(2048, 4096)
+			addTask(first, "256", "512", types.DesiredStatusRunning)
+			addTask(first, "512", "512", types.DesiredStatusStopped)
+			addTask(second, "1024", "1024", types.DesiredStatusPending)
+
+			capacity, err := ecs.GetClusterCapacity(ctx, c, testutil.ECSClusterName())
+			require.NoError(t, err)
+			require.NotZero(t, capacity)
+			assert.Equal(t, testutil.ECSClusterName(), capacity.Cluster)
+			assert.Equal(t, 3072, capacity.RegisteredCPU)
+			assert.Equal(t, 6144, capacity.RegisteredMemoryMB)
+			assert.Equal(t, 1792, capacity.RemainingCPU)
+			assert.Equal(t, 4608, capacity.RemainingMemoryMB)
+			assert.ElementsMatch(t, []ecs.ContainerInstanceCapacity{
+				{
+					ARN:                first.ARN,
+					RegisteredCPU:      1024,
+					RegisteredMemoryMB: 2048,
+					RemainingCPU:       768,
+					RemainingMemoryMB:  1536,
+				},
+				{
+					ARN:                second.ARN,
+					RegisteredCPU:      2048,
+					RegisteredMemoryMB: 4096,
+					RemainingCPU:       1024,
+					RemainingMemoryMB:  3072,
+				},
+			}, capacity.Instances)
+		},
+		"ExcludesInactiveInstances": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			addInstance(1024, 2048)
+			draining := NewECSContainerInstance()
+			draining.Status = types.ContainerInstanceStatusDraining
+			draining.RegisteredCPU = 1024
+			draining.RegisteredMemoryMB = 2048
+			GlobalECSService.ContainerInstances[testutil.ECSClusterName()] = append(GlobalECSService.ContainerInstances[testutil.ECSClusterName()], draining)
+
+			capacity, err := ecs.GetClusterCapacity(ctx, c, testutil.ECSClusterName())
+			require.NoError(t, err)
+			require.NotZero(t, capacity)
+			assert.Len(t, capacity.Instances, 1)
+			assert.Equal(t, 1024, capacity.RegisteredCPU)
+		},
+		"ReturnsEmptyCapacityWithoutInstances": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			capacity, err := ecs.GetClusterCapacity(ctx, c, testutil.ECSClusterName())
+			require.NoError(t, err)
+			require.NotZero(t, capacity)
+			assert.Empty(t, capacity.Instances)
+			assert.Zero(t, capacity.RegisteredCPU)
+			assert.Zero(t, c.DescribeContainerInstancesInput, "should not describe instances when there are none")
+			assert.False(t, capacity.CanFit(makeDef(2, 2)))
+		},
+		"CanFitChecksEachInstanceSeparately": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			addInstance(1024, 1024)
+			addInstance(1024, 1024)
+
+			capacity, err := ecs.GetClusterCapacity(ctx, c, testutil.ECSClusterName())
+			require.NoError(t, err)
+			require.NotZero(t, capacity)
+			assert.True(t, capacity.CanFit(makeDef(1024, 1024)))
+			assert.False(t, capacity.CanFit(makeDef(2048, 512)), "pod should not fit even though the cluster has enough CPU in total")
+		},
+		"CanFitUsesPodLevelResources": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			addInstance(1024, 1024)
+
+			capacity, err := ecs.GetClusterCapacity(ctx, c, testutil.ECSClusterName())
+			require.NoError(t, err)
+			require.NotZero(t, capacity)
+			def := makeDef(512, 512)
+			assert.True(t, capacity.CanFit(def))
+			assert.False(t, capacity.CanFit(*def.SetCPU(2048)))
+		},
+		"FailsWithNonexistentCluster": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			capacity, err := ecs.GetClusterCapacity(ctx, c, "nonexistent")
+			assert.Error(t, err)
+			assert.Zero(t, capacity)
+		},
+		"FailsWhenDescribingContainerInstancesFails": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			addInstance(1024, 1024)
+			c.DescribeContainerInstancesError = errors.New("fake error")
+
+			capacity, err := ecs.GetClusterCapacity(ctx, c, testutil.ECSClusterName())
+			assert.Error(t, err)
+			assert.Zero(t, capacity)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			resetECSAndSecretsManagerCache()
+
+			tCase(tctx, t, &ECSClient{})
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+)
+
+// EC2Client provides a mock implementation of a cocoa.EC2Client. This makes it
+// possible to introspect on inputs to the client and control the client's
+// output. By default, it checks for resources in Subnets and SecurityGroups.
+type EC2Client struct {
+	// Subnets are the subnets that exist.
+	Subnets []cocoa.EC2Subnet
+	// SecurityGroups are the security groups that exist.
+	SecurityGroups []cocoa.EC2SecurityGroup
+
+	DescribeSubnetsInput  []string
+	DescribeSubnetsOutput []cocoa.EC2Subnet
+	DescribeSubnetsError  error
+
+	DescribeSecurityGroupsInput  []string
+	DescribeSecurityGroupsOutput []cocoa.EC2SecurityGroup
+	DescribeSecurityGroupsError  error
+}
+
+// DescribeSubnets saves the input and returns the subnets that exist. The mock
+// output can be customized. By default, it will return the requested subnets
+// that are among the mock subnets.
+func (c *EC2Client) DescribeSubnets(ctx context.Context, ids []string) ([]cocoa.EC2Subnet, error) {
+	c.DescribeSubnetsInput = ids
+
+	if c.DescribeSubnetsOutput != nil || c.DescribeSubnetsError != nil {
+		return c.DescribeSubnetsOutput, c.DescribeSubnetsError
+	}
+
+	var found []cocoa.EC2Subnet
+	for _, s := range c.Subnets {
+		if utility.StringSliceContains(ids, s.ID) {
+			found = append(found, s)
+		}
+	}
+	return found, nil
+}
+
+// DescribeSecurityGroups saves the input and returns the security groups that
+// exist. The mock output can be customized. By default, it will return the
+// requested security groups that are among the mock security groups.
+func (c *EC2Client) DescribeSecurityGroups(ctx context.Context, ids []string) ([]cocoa.EC2SecurityGroup, error) {
+	c.DescribeSecurityGroupsInput = ids
+
+	if c.DescribeSecurityGroupsOutput != nil || c.DescribeSecurityGroupsError != nil {
+		return c.DescribeSecurityGroupsOutput, c.DescribeSecurityGroupsError
+	}
+
+	var found []cocoa.EC2SecurityGroup
+	for _, sg := range c.SecurityGroups {
+		if utility.StringSliceContains(ids, sg.ID) {
+			found = append(found, sg)
+		}
+	}
+	return found, nil
+}
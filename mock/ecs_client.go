@@ -3,8 +3,10 @@ package mock
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -15,6 +17,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/evergreen-ci/cocoa"
 	"github.com/evergreen-ci/cocoa/ecs"
+	"github.com/evergreen-ci/cocoa/identity"
 	"github.com/evergreen-ci/utility"
 )
 
@@ -28,27 +31,51 @@ type ECSTaskDefinition struct {
 	CPU           *string
 	TaskRole      *string
 	ExecutionRole *string
+	NetworkMode   types.NetworkMode
+	Volumes       []types.Volume
 	Tags          map[string]string
 	Status        *string
 	Registered    *time.Time
 	Deregistered  *time.Time
 }
 
-func newECSTaskDefinition(def *awsECS.RegisterTaskDefinitionInput, rev int) ECSTaskDefinition {
-	id := arn.ARN{
-		Partition: "aws",
-		Service:   "ecs",
-		Resource:  fmt.Sprintf("task-definition:%s/%s", utility.FromStringPtr(def.Family), strconv.Itoa(rev)),
-	}
+// mockLocation is the partition, region, and account that the resources in the
+// mock ECS service belong to.
+var mockLocation = identity.Location{
+	Partition: "aws",
+	Region:    "us-east-1",
+	AccountID: "123456789012",
+}
 
+// newECSTaskDefinitionARN returns the ARN of a mock task definition.
+func newECSTaskDefinitionARN(family string, rev int) string {
+	return identity.TaskDefinitionARN{
+		Location: mockLocation,
+		Family:   family,
+		Revision: rev,
+	}.String()
+}
+
+// newECSTaskARN returns the ARN of a mock task in the cluster.
+func newECSTaskARN(cluster, taskID string) string {
+	return identity.TaskARN{
+		Location: mockLocation,
+		Cluster:  cluster,
+		TaskID:   taskID,
+	}.String()
+}
+
+func newECSTaskDefinition(def *awsECS.RegisterTaskDefinitionInput, rev int) ECSTaskDefinition {
 	taskDef := ECSTaskDefinition{
-		ARN:           id.String(),
+		ARN:           newECSTaskDefinitionARN(utility.FromStringPtr(def.Family), rev),
 		Family:        def.Family,
 		Revision:      utility.ToInt64Ptr(int64(rev)),
 		CPU:           def.Cpu,
 		MemoryMB:      def.Memory,
 		TaskRole:      def.TaskRoleArn,
 		ExecutionRole: def.ExecutionRoleArn,
+		NetworkMode:   def.NetworkMode,
+		Volumes:       def.Volumes,
 		Status:        utility.ToStringPtr(string(types.TaskDefinitionStatusActive)),
 		Registered:    utility.ToTimePtr(time.Now()),
 	}
@@ -62,6 +89,29 @@ func newECSTaskDefinition(def *awsECS.RegisterTaskDefinitionInput, rev int) ECST
 	return taskDef
 }
 
+// resourceRequirements returns the CPU units and memory (in MB) needed to run
+// a task from the task definition. If the task definition does not have a
+// task-level limit, the requirement is the total across all its containers.
+func (d *ECSTaskDefinition) resourceRequirements() (cpu int, memMB int) {
+	if d.CPU != nil {
+		cpu, _ = strconv.Atoi(*d.CPU)
+	} else {
+		for _, def := range d.ContainerDefs {
+			cpu += int(def.CPU)
+		}
+	}
+
+	if d.MemoryMB != nil {
+		memMB, _ = strconv.Atoi(*d.MemoryMB)
+	} else {
+		for _, def := range d.ContainerDefs {
+			memMB += int(utility.FromInt32Ptr(def.MemoryMB))
+		}
+	}
+
+	return cpu, memMB
+}
+
 func (d *ECSTaskDefinition) export() types.TaskDefinition {
 	var containerDefs []types.ContainerDefinition
 	for _, def := range d.ContainerDefs {
@@ -76,6 +126,8 @@ func (d *ECSTaskDefinition) export() types.TaskDefinition {
 		Memory:               d.MemoryMB,
 		TaskRoleArn:          d.TaskRole,
 		ExecutionRoleArn:     d.ExecutionRole,
+		NetworkMode:          d.NetworkMode,
+		Volumes:              d.Volumes,
 		Status:               types.TaskDefinitionStatus(utility.FromStringPtr(d.Status)),
 		ContainerDefinitions: containerDefs,
 		RegisteredAt:         d.Registered,
@@ -86,36 +138,72 @@ func (d *ECSTaskDefinition) export() types.TaskDefinition {
 // ECSContainerDefinition represents a mock ECS container definition in a mock
 // ECS task definition.
 type ECSContainerDefinition struct {
-	Name     *string
-	Image    *string
-	Command  []string
-	MemoryMB *int32
-	CPU      int32
-	EnvVars  map[string]string
-	Secrets  map[string]string
+	Name           *string
+	Image          *string
+	Command        []string
+	WorkingDir     *string
+	MemoryMB       *int32
+	CPU            int32
+	EnvVars        map[string]string
+	Secrets        map[string]string
+	LogConfig      *types.LogConfiguration
+	RepoCreds      *types.RepositoryCredentials
+	PortMappings   []types.PortMapping
+	MountPoints    []types.MountPoint
+	Interactive    *bool
+	PseudoTerminal *bool
+	Hostname       *string
+	User           *string
+	ExtraHosts     []types.HostEntry
+	VolumesFrom    []types.VolumeFrom
+	LinuxParams    *types.LinuxParameters
 }
 
 func newECSContainerDefinition(def types.ContainerDefinition) ECSContainerDefinition {
 	return ECSContainerDefinition{
-		Name:     def.Name,
-		Image:    def.Image,
-		Command:  def.Command,
-		MemoryMB: def.Memory,
-		CPU:      def.Cpu,
-		EnvVars:  newEnvVars(def.Environment),
-		Secrets:  newSecrets(def.Secrets),
+		Name:           def.Name,
+		Image:          def.Image,
+		Command:        def.Command,
+		WorkingDir:     def.WorkingDirectory,
+		MemoryMB:       def.Memory,
+		CPU:            def.Cpu,
+		EnvVars:        newEnvVars(def.Environment),
+		Secrets:        newSecrets(def.Secrets),
+		LogConfig:      def.LogConfiguration,
+		RepoCreds:      def.RepositoryCredentials,
+		PortMappings:   def.PortMappings,
+		MountPoints:    def.MountPoints,
+		Interactive:    def.Interactive,
+		PseudoTerminal: def.PseudoTerminal,
+		Hostname:       def.Hostname,
+		User:           def.User,
+		ExtraHosts:     def.ExtraHosts,
+		VolumesFrom:    def.VolumesFrom,
+		LinuxParams:    def.LinuxParameters,
 	}
 }
 
 func (d *ECSContainerDefinition) export() types.ContainerDefinition {
 	return types.ContainerDefinition{
-		Name:        d.Name,
-		Image:       d.Image,
-		Command:     d.Command,
-		Memory:      d.MemoryMB,
-		Cpu:         d.CPU,
-		Environment: exportEnvVars(d.EnvVars),
-		Secrets:     exportSecrets(d.Secrets),
+		Name:                  d.Name,
+		Image:                 d.Image,
+		Command:               d.Command,
+		WorkingDirectory:      d.WorkingDir,
+		Memory:                d.MemoryMB,
+		Cpu:                   d.CPU,
+		Environment:           exportEnvVars(d.EnvVars),
+		Secrets:               exportSecrets(d.Secrets),
+		LogConfiguration:      d.LogConfig,
+		RepositoryCredentials: d.RepoCreds,
+		PortMappings:          d.PortMappings,
+		MountPoints:           d.MountPoints,
+		Interactive:           d.Interactive,
+		PseudoTerminal:        d.PseudoTerminal,
+		Hostname:              d.Hostname,
+		User:                  d.User,
+		ExtraHosts:            d.ExtraHosts,
+		VolumesFrom:           d.VolumesFrom,
+		LinuxParameters:       d.LinuxParams,
 	}
 }
 
@@ -132,26 +220,32 @@ type ECSTask struct {
 	ContainerInstance *string
 	Containers        []ECSContainer
 	Overrides         *types.TaskOverride
+	CPU               *string
+	MemoryMB          *string
 	Group             *string
 	ExecEnabled       bool
 	Status            string
 	GoalStatus        string
 	Created           *time.Time
+	PullStarted       *time.Time
+	PullStopped       *time.Time
+	Started           *time.Time
+	Stopping          *time.Time
+	ExecutionStopped  *time.Time
 	StopCode          string
 	StopReason        *string
 	Stopped           *time.Time
 	Tags              map[string]string
 }
 
-func newECSTask(in *awsECS.RunTaskInput, taskDef ECSTaskDefinition) ECSTask {
-	id := arn.ARN{
-		Partition: "aws",
-		Service:   "ecs",
-		Resource:  fmt.Sprintf("task:%s/%s", utility.FromStringPtr(taskDef.Family), strconv.Itoa(int(utility.FromInt64Ptr(taskDef.Revision)))),
-	}
+// taskID returns the ID of a mock task run from the task definition.
+func (d *ECSTaskDefinition) taskID() string {
+	return fmt.Sprintf("%s-%d", utility.FromStringPtr(d.Family), utility.FromInt64Ptr(d.Revision))
+}
 
+func newECSTask(in *awsECS.RunTaskInput, taskDef ECSTaskDefinition) ECSTask {
 	t := ECSTask{
-		ARN:              id.String(),
+		ARN:              newECSTaskARN(utility.FromStringPtr(in.Cluster), taskDef.taskID()),
 		Cluster:          in.Cluster,
 		CapacityProvider: newCapacityProvider(in.CapacityProviderStrategy),
 		ExecEnabled:      in.EnableExecuteCommand,
@@ -161,30 +255,84 @@ func newECSTask(in *awsECS.RunTaskInput, taskDef ECSTaskDefinition) ECSTask {
 		Created:          utility.ToTimePtr(time.Now()),
 		TaskDef:          taskDef,
 		Overrides:        in.Overrides,
+		CPU:              taskDef.CPU,
+		MemoryMB:         taskDef.MemoryMB,
 		Tags:             newECSTags(in.Tags),
 	}
+	if in.Overrides != nil {
+		if in.Overrides.Cpu != nil {
+			t.CPU = in.Overrides.Cpu
+		}
+		if in.Overrides.Memory != nil {
+			t.MemoryMB = in.Overrides.Memory
+		}
+	}
+
+	// ECS puts the task in a group named after its task definition family if
+	// no group is specified.
+	if t.Group == nil {
+		t.Group = utility.ToStringPtr(fmt.Sprintf("family:%s", utility.FromStringPtr(taskDef.Family)))
+	}
 
 	for _, containerDef := range taskDef.ContainerDefs {
-		t.Containers = append(t.Containers, newECSContainer(containerDef, t))
+		container := newECSContainer(containerDef, t)
+		if in.Overrides != nil {
+			for _, o := range in.Overrides.ContainerOverrides {
+				if utility.FromStringPtr(o.Name) == utility.FromStringPtr(containerDef.Name) {
+					container.applyOverride(o)
+				}
+			}
+		}
+		t.Containers = append(t.Containers, container)
 	}
 
 	return t
 }
 
+// validateTaskOverride checks that the task override only overrides containers
+// that exist in the task definition.
+func validateTaskOverride(o *types.TaskOverride, taskDef ECSTaskDefinition) error {
+	if o == nil {
+		return nil
+	}
+
+	names := map[string]bool{}
+	for _, def := range taskDef.ContainerDefs {
+		names[utility.FromStringPtr(def.Name)] = true
+	}
+	for _, co := range o.ContainerOverrides {
+		name := utility.FromStringPtr(co.Name)
+		if name == "" {
+			return &types.InvalidParameterException{Message: aws.String("container override is missing a container name")}
+		}
+		if !names[name] {
+			return &types.InvalidParameterException{Message: aws.String(fmt.Sprintf("container override for '%s' does not match any container in the task definition", name))}
+		}
+	}
+
+	return nil
+}
+
 func (t *ECSTask) export(includeTags bool) types.Task {
 	exported := types.Task{
 		TaskArn:              utility.ToStringPtr(t.ARN),
 		ClusterArn:           t.Cluster,
 		CapacityProviderName: t.CapacityProvider,
+		ContainerInstanceArn: t.ContainerInstance,
 		EnableExecuteCommand: t.ExecEnabled,
 		Group:                t.Group,
 		TaskDefinitionArn:    utility.ToStringPtr(t.TaskDef.ARN),
 		Overrides:            t.Overrides,
-		Cpu:                  t.TaskDef.CPU,
-		Memory:               t.TaskDef.MemoryMB,
+		Cpu:                  t.CPU,
+		Memory:               t.MemoryMB,
 		LastStatus:           aws.String(t.Status),
 		DesiredStatus:        aws.String(t.GoalStatus),
 		CreatedAt:            t.Created,
+		PullStartedAt:        t.PullStarted,
+		PullStoppedAt:        t.PullStopped,
+		StartedAt:            t.Started,
+		StoppingAt:           t.Stopping,
+		ExecutionStoppedAt:   t.ExecutionStopped,
 		StopCode:             types.TaskStopCode(t.StopCode),
 		StoppedReason:        t.StopReason,
 		StoppedAt:            t.Stopped,
@@ -206,10 +354,20 @@ type ECSContainer struct {
 	TaskARN    *string
 	Name       *string
 	Image      *string
+	Command    []string
+	EnvVars    map[string]string
 	CPU        *int32
 	MemoryMB   *int32
 	Status     string
 	GoalStatus string
+	// HealthStatus is the container's health as determined by its health
+	// check. By default, this is types.HealthStatusUnknown.
+	HealthStatus types.HealthStatus
+	// ExitCode is the exit code returned by the container once it has
+	// stopped.
+	ExitCode *int32
+	// Reason is a short explanation of why the container stopped.
+	Reason *string
 }
 
 func newECSContainer(def ECSContainerDefinition, task ECSTask) ECSContainer {
@@ -217,21 +375,47 @@ func newECSContainer(def ECSContainerDefinition, task ECSTask) ECSContainer {
 	if name == "" {
 		name = utility.RandomString()
 	}
-	id := arn.ARN{
-		Partition: "aws",
-		Service:   "ecs",
-		Resource:  fmt.Sprintf("container-definition:%s-%s/%s", utility.FromStringPtr(task.TaskDef.Family), name, strconv.Itoa(int(utility.FromInt64Ptr(task.TaskDef.Revision)))),
+	id := identity.ContainerARN{
+		Location:    mockLocation,
+		ContainerID: fmt.Sprintf("%s-%s", task.TaskDef.taskID(), name),
 	}
 
 	return ECSContainer{
-		ARN:        id.String(),
-		TaskARN:    utility.ToStringPtr(task.ARN),
-		Name:       def.Name,
-		Image:      def.Image,
-		CPU:        aws.Int32(def.CPU),
-		MemoryMB:   def.MemoryMB,
-		Status:     string(types.DesiredStatusPending),
-		GoalStatus: string(types.DesiredStatusRunning),
+		ARN:          id.String(),
+		TaskARN:      utility.ToStringPtr(task.ARN),
+		Name:         def.Name,
+		Image:        def.Image,
+		Command:      def.Command,
+		EnvVars:      def.EnvVars,
+		CPU:          aws.Int32(def.CPU),
+		MemoryMB:     def.MemoryMB,
+		Status:       string(types.DesiredStatusPending),
+		GoalStatus:   string(types.DesiredStatusRunning),
+		HealthStatus: types.HealthStatusUnknown,
+	}
+}
+
+// applyOverride applies the container override's command, environment
+// variables, CPU, and memory to the container.
+func (c *ECSContainer) applyOverride(o types.ContainerOverride) {
+	if o.Command != nil {
+		c.Command = o.Command
+	}
+	if len(o.Environment) != 0 {
+		envVars := map[string]string{}
+		for k, v := range c.EnvVars {
+			envVars[k] = v
+		}
+		for k, v := range newEnvVars(o.Environment) {
+			envVars[k] = v
+		}
+		c.EnvVars = envVars
+	}
+	if o.Cpu != nil {
+		c.CPU = o.Cpu
+	}
+	if o.Memory != nil {
+		c.MemoryMB = o.Memory
 	}
 }
 
@@ -242,6 +426,9 @@ func (c *ECSContainer) export() types.Container {
 		Name:         c.Name,
 		Image:        c.Image,
 		LastStatus:   aws.String(c.Status),
+		HealthStatus: c.HealthStatus,
+		ExitCode:     c.ExitCode,
+		Reason:       c.Reason,
 	}
 
 	if c.CPU != nil {
@@ -254,6 +441,24 @@ func (c *ECSContainer) export() types.Container {
 	return exported
 }
 
+// validateECSTags checks that ECS would accept the tags when they are added to
+// a resource that already has the existing tags. Like ECS, a tag whose key is
+// repeated or already exists on the resource replaces the previous value
+// rather than adding a new tag, so only distinct keys count toward the limit.
+func validateECSTags(existing map[string]string, tags []types.Tag) error {
+	merged := cocoa.NewTags().Add(existing)
+	for _, t := range tags {
+		if t.Key == nil {
+			return &types.InvalidParameterException{Message: aws.String("tag is missing a key")}
+		}
+		merged.Set(*t.Key, utility.FromStringPtr(t.Value))
+	}
+	if err := cocoa.ValidateECSTags(merged); err != nil {
+		return &types.InvalidParameterException{Message: aws.String(err.Error())}
+	}
+	return nil
+}
+
 func newECSTags(tags []types.Tag) map[string]string {
 	converted := map[string]string{}
 	for _, t := range tags {
@@ -309,14 +514,125 @@ func exportSecrets(secrets map[string]string) []types.Secret {
 	return exported
 }
 
+// ECSClusterCapacity represents the total resources available in a mock ECS
+// cluster to run tasks. Any resource that is not set is treated as unlimited.
+type ECSClusterCapacity struct {
+	// CPU is the total number of CPU units available in the cluster.
+	CPU *int
+	// MemoryMB is the total amount of memory (in MB) available in the
+	// cluster.
+	MemoryMB *int
+}
+
+// ECSContainerInstance represents a mock container instance registered to a
+// cluster in the global ECS service.
+type ECSContainerInstance struct {
+	ARN    string
+	Status types.ContainerInstanceStatus
+	// RegisteredCPU is the total number of CPU units that the container
+	// instance registered with the cluster.
+	RegisteredCPU int
+	// RegisteredMemoryMB is the total amount of memory (in MB) that the
+	// container instance registered with the cluster.
+	RegisteredMemoryMB int
+}
+
+// NewECSContainerInstance returns a new active mock container instance with
+// a unique ARN.
+func NewECSContainerInstance() ECSContainerInstance {
+	id := arn.ARN{
+		Partition: "aws",
+		Service:   "ecs",
+		Resource:  fmt.Sprintf("container-instance/%s", utility.RandomString()),
+	}
+	return ECSContainerInstance{
+		ARN:    id.String(),
+		Status: types.ContainerInstanceStatusActive,
+	}
+}
+
+// ECSClusterService represents a mock ECS service running in a cluster. The
+// mock service does not schedule any tasks itself; it only records how many
+// tasks it wants to run so that its reservations can be accounted for.
+type ECSClusterService struct {
+	ARN                string
+	Name               string
+	TaskDefinition     string
+	LaunchType         types.LaunchType
+	SchedulingStrategy types.SchedulingStrategy
+	DesiredCount       int32
+	RunningCount       int32
+	PendingCount       int32
+	Status             string
+	Tags               map[string]string
+	Created            time.Time
+}
+
+// NewECSClusterService returns a new active mock replica service with the
+// given name that runs the given number of tasks from the task definition.
+func NewECSClusterService(name, taskDef string, desiredCount int32) ECSClusterService {
+	id := arn.ARN{
+		Partition: "aws",
+		Service:   "ecs",
+		Resource:  fmt.Sprintf("service/%s", name),
+	}
+	return ECSClusterService{
+		ARN:                id.String(),
+		Name:               name,
+		TaskDefinition:     taskDef,
+		LaunchType:         types.LaunchTypeEc2,
+		SchedulingStrategy: types.SchedulingStrategyReplica,
+		DesiredCount:       desiredCount,
+		RunningCount:       desiredCount,
+		Status:             "ACTIVE",
+		Created:            time.Now(),
+	}
+}
+
+func (s *ECSClusterService) export(clusterName string, includeTags bool) types.Service {
+	exported := types.Service{
+		ServiceArn:         utility.ToStringPtr(s.ARN),
+		ServiceName:        utility.ToStringPtr(s.Name),
+		ClusterArn:         utility.ToStringPtr(clusterName),
+		TaskDefinition:     utility.ToStringPtr(s.TaskDefinition),
+		LaunchType:         s.LaunchType,
+		SchedulingStrategy: s.SchedulingStrategy,
+		DesiredCount:       s.DesiredCount,
+		RunningCount:       s.RunningCount,
+		PendingCount:       s.PendingCount,
+		Status:             utility.ToStringPtr(s.Status),
+		CreatedAt:          utility.ToTimePtr(s.Created),
+	}
+	if includeTags {
+		exported.Tags = ecs.ExportTags(s.Tags)
+	}
+	return exported
+}
+
 // ECSService is a global implementation of ECS that provides a simplified
 // in-memory implementation of the service that only stores metadata and does
 // not orchestrate real containers or container instances. This can be used
 // indirectly with the ECSClient to access or modify ECS resources, or used
-// directly.
+// directly. The ECSClient locks the service while it accesses it, so the
+// service's helper methods (e.g. Snapshot) can safely be used while clients
+// concurrently make API calls; however, its fields are not safe to access
+// directly while clients are in use.
 type ECSService struct {
+	mu sync.RWMutex
+
 	Clusters map[string]ECSCluster
 	TaskDefs map[string][]ECSTaskDefinition
+	// ClusterCapacities limit the resources available to run tasks in each
+	// cluster. If a cluster has no capacity set, it can run an unlimited
+	// number of tasks.
+	ClusterCapacities map[string]ECSClusterCapacity
+	// ContainerInstances are the container instances registered to each
+	// cluster. Container instances only need to be registered to use them for
+	// task placement explicitly (e.g. with StartTask).
+	ContainerInstances map[string][]ECSContainerInstance
+	// Services are the ECS services running in each cluster alongside its
+	// tasks.
+	Services map[string][]ECSClusterService
 }
 
 // GlobalECSService represents the global fake ECS service state.
@@ -329,12 +645,135 @@ func init() {
 // ResetGlobalECSService resets the global fake ECS service back to an
 // initialized but clean state.
 func ResetGlobalECSService() {
-	GlobalECSService = ECSService{
-		Clusters: map[string]ECSCluster{},
-		TaskDefs: map[string][]ECSTaskDefinition{},
+	GlobalECSService.mu.Lock()
+	defer GlobalECSService.mu.Unlock()
+
+	clean := newECSService()
+	GlobalECSService.Clusters = clean.Clusters
+	GlobalECSService.TaskDefs = clean.TaskDefs
+	GlobalECSService.ClusterCapacities = clean.ClusterCapacities
+	GlobalECSService.ContainerInstances = clean.ContainerInstances
+	GlobalECSService.Services = clean.Services
+}
+
+// newECSService returns an initialized but clean fake ECS service.
+func newECSService() *ECSService {
+	return &ECSService{
+		Clusters:           map[string]ECSCluster{},
+		TaskDefs:           map[string][]ECSTaskDefinition{},
+		ClusterCapacities:  map[string]ECSClusterCapacity{},
+		ContainerInstances: map[string][]ECSContainerInstance{},
+		Services:           map[string][]ECSClusterService{},
 	}
 }
 
+// NewScopedECSService returns a new fake ECS service that is isolated from
+// GlobalECSService, along with an ECSClient that issues its API calls to that
+// service. This is useful for tests that run in parallel (e.g. across
+// packages), since they do not share any state and do not need to reset
+// GlobalECSService. The scoped service is cleaned up once it is no longer
+// referenced.
+func NewScopedECSService() (*ECSService, *ECSClient) {
+	s := newECSService()
+	return s, &ECSClient{Service: s}
+}
+
+// getService returns the service in the cluster with the given name or ARN, if
+// any.
+func (s *ECSService) getService(clusterName, id string) (*ECSClusterService, bool) {
+	for _, svc := range s.Services[clusterName] {
+		if svc.ARN == id || svc.Name == id {
+			return &svc, true
+		}
+	}
+	return nil, false
+}
+
+// getContainerInstance returns the container instance registered to the
+// cluster with the given ARN, if any.
+func (s *ECSService) getContainerInstance(clusterName, id string) (*ECSContainerInstance, bool) {
+	for _, instance := range s.ContainerInstances[clusterName] {
+		if instance.ARN == id {
+			return &instance, true
+		}
+	}
+	return nil, false
+}
+
+// SetContainerHealthStatus sets the health status of the container with the
+// given name in an existing task.
+func (s *ECSService) SetContainerHealthStatus(clusterName, taskARN, containerName string, status types.HealthStatus) error {
+	return s.updateContainer(clusterName, taskARN, containerName, func(c *ECSContainer) {
+		c.HealthStatus = status
+	})
+}
+
+// SetContainerExit marks the container with the given name in an existing
+// task as stopped with the given exit code and reason.
+func (s *ECSService) SetContainerExit(clusterName, taskARN, containerName string, exitCode int32, reason string) error {
+	return s.updateContainer(clusterName, taskARN, containerName, func(c *ECSContainer) {
+		c.Status = string(types.DesiredStatusStopped)
+		c.ExitCode = aws.Int32(exitCode)
+		if reason != "" {
+			c.Reason = aws.String(reason)
+		}
+	})
+}
+
+// updateContainer applies the update to the container with the given name in
+// an existing task.
+func (s *ECSService) updateContainer(clusterName, taskARN, containerName string, update func(c *ECSContainer)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.Clusters[clusterName]
+	if !ok {
+		return errors.Errorf("cluster '%s' not found", clusterName)
+	}
+	task, ok := cluster[taskARN]
+	if !ok {
+		return errors.Errorf("task '%s' not found in cluster '%s'", taskARN, clusterName)
+	}
+	for i := range task.Containers {
+		if utility.FromStringPtr(task.Containers[i].Name) == containerName {
+			update(&task.Containers[i])
+			cluster[taskARN] = task
+			return nil
+		}
+	}
+	return errors.Errorf("container '%s' not found in task '%s'", containerName, taskARN)
+}
+
+// checkCapacity checks whether the cluster has enough remaining capacity to
+// run a task with the given definition. If it does not, it returns the ECS
+// failure reason for the insufficient resource.
+func (s *ECSService) checkCapacity(clusterName string, def ECSTaskDefinition) (reason string, ok bool) {
+	capacity, hasCapacity := s.ClusterCapacities[clusterName]
+	if !hasCapacity {
+		return "", true
+	}
+
+	var usedCPU, usedMemMB int
+	for _, task := range s.Clusters[clusterName] {
+		if task.Status == string(types.DesiredStatusStopped) {
+			continue
+		}
+		cpu, mem := task.TaskDef.resourceRequirements()
+		usedCPU += cpu
+		usedMemMB += mem
+	}
+
+	cpu, mem := def.resourceRequirements()
+	if capacity.MemoryMB != nil && usedMemMB+mem > *capacity.MemoryMB {
+		return ecs.ReasonResourceMemory, false
+	}
+	if capacity.CPU != nil && usedCPU+cpu > *capacity.CPU {
+		return ecs.ReasonResourceCPU, false
+	}
+
+	return "", true
+}
+
 // getLatestTaskDefinition is the same as getTaskDefinition, but it can also
 // interpret the identifier as just a family name if it's neither an ARN or a
 // family and revision. If it matches a family name, the latest active revision
@@ -346,7 +785,7 @@ func (s *ECSService) getLatestTaskDefinition(id string) (*ECSTaskDefinition, err
 
 	// Use the latest active revision in the family if no revision is given.
 	family := id
-	revisions, ok := GlobalECSService.TaskDefs[family]
+	revisions, ok := s.TaskDefs[family]
 	if !ok {
 		return nil, errors.New("task definition family not found")
 	}
@@ -368,12 +807,12 @@ func (s *ECSService) getTaskDefinition(id string) (*ECSTaskDefinition, error) {
 		if !found {
 			return nil, errors.New("task definition not found")
 		}
-		return &GlobalECSService.TaskDefs[family][revNum-1], nil
+		return &s.TaskDefs[family][revNum-1], nil
 	}
 
-	family, revNum, err := parseFamilyAndRevision(id)
+	family, revNum, err := identity.ParseFamilyRevision(id)
 	if err == nil {
-		revisions, ok := GlobalECSService.TaskDefs[family]
+		revisions, ok := s.TaskDefs[family]
 		if !ok {
 			return nil, errors.New("task definition family not found")
 		}
@@ -387,29 +826,8 @@ func (s *ECSService) getTaskDefinition(id string) (*ECSTaskDefinition, error) {
 	return nil, errors.New("task definition not found")
 }
 
-// parseFamilyAndRevision parses a task definition in the format
-// "family:revision".
-func parseFamilyAndRevision(taskDef string) (family string, revNum int, err error) {
-	partition := strings.LastIndex(taskDef, ":")
-	if partition == -1 {
-		return "", -1, errors.New("task definition is not in family:revision format")
-	}
-
-	family = taskDef[:partition]
-
-	revNum, err = strconv.Atoi(taskDef[partition+1:])
-	if err != nil {
-		return "", -1, errors.Wrap(err, "parsing revision")
-	}
-	if revNum <= 0 {
-		return "", -1, errors.New("revision cannot be less than 1")
-	}
-
-	return family, revNum, nil
-}
-
 func (s *ECSService) taskDefIndexFromARN(arn string) (family string, revNum int, found bool) {
-	for family, revisions := range GlobalECSService.TaskDefs {
+	for family, revisions := range s.TaskDefs {
 		for revIdx, def := range revisions {
 			if def.ARN == arn {
 				return family, revIdx + 1, true
@@ -424,6 +842,10 @@ func (s *ECSService) taskDefIndexFromARN(arn string) (family string, revNum int,
 // output. It provides some default implementations where possible. By default,
 // it will issue the API calls to the fake GlobalECSService.
 type ECSClient struct {
+	// Service is the fake ECS service that the client issues its API calls to.
+	// If this is not set, it uses GlobalECSService.
+	Service *ECSService
+
 	RegisterTaskDefinitionInput  *awsECS.RegisterTaskDefinitionInput
 	RegisterTaskDefinitionOutput *awsECS.RegisterTaskDefinitionOutput
 	RegisterTaskDefinitionError  error
@@ -443,6 +865,14 @@ type ECSClient struct {
 	RunTaskInput  *awsECS.RunTaskInput
 	RunTaskOutput *awsECS.RunTaskOutput
 	RunTaskError  error
+	// RunTaskFailureReason is the reason that RunTask should fail to place
+	// the task (e.g. ecs.ReasonResourceMemory). If set, RunTask returns a
+	// failure with this reason instead of running the task.
+	RunTaskFailureReason *string
+
+	StartTaskInput  *awsECS.StartTaskInput
+	StartTaskOutput *awsECS.StartTaskOutput
+	StartTaskError  error
 
 	DescribeTasksInput  *awsECS.DescribeTasksInput
 	DescribeTasksOutput *awsECS.DescribeTasksOutput
@@ -452,6 +882,14 @@ type ECSClient struct {
 	ListTasksOutput *awsECS.ListTasksOutput
 	ListTasksError  error
 
+	ListContainerInstancesInput  *awsECS.ListContainerInstancesInput
+	ListContainerInstancesOutput *awsECS.ListContainerInstancesOutput
+	ListContainerInstancesError  error
+
+	DescribeContainerInstancesInput  *awsECS.DescribeContainerInstancesInput
+	DescribeContainerInstancesOutput *awsECS.DescribeContainerInstancesOutput
+	DescribeContainerInstancesError  error
+
 	StopTaskInput  *awsECS.StopTaskInput
 	StopTaskOutput *awsECS.StopTaskOutput
 	StopTaskError  error
@@ -459,6 +897,32 @@ type ECSClient struct {
 	TagResourceInput  *awsECS.TagResourceInput
 	TagResourceOutput *awsECS.TagResourceOutput
 	TagResourceError  error
+
+	DescribeClustersInput  *awsECS.DescribeClustersInput
+	DescribeClustersOutput *awsECS.DescribeClustersOutput
+	DescribeClustersError  error
+
+	ListServicesInput  *awsECS.ListServicesInput
+	ListServicesOutput *awsECS.ListServicesOutput
+	ListServicesError  error
+
+	DescribeServicesInput  *awsECS.DescribeServicesInput
+	DescribeServicesOutput *awsECS.DescribeServicesOutput
+	DescribeServicesError  error
+
+	// Latency, if set, delays each API call to simulate slow responses. If the
+	// call's context is done before the delay is over, the call returns the
+	// context's error.
+	Latency *LatencySimulator
+}
+
+// service returns the fake ECS service that the client issues its API calls
+// to.
+func (c *ECSClient) service() *ECSService {
+	if c.Service != nil {
+		return c.Service
+	}
+	return &GlobalECSService
 }
 
 // RegisterTaskDefinition saves the input and returns a new mock task
@@ -467,20 +931,31 @@ type ECSClient struct {
 func (c *ECSClient) RegisterTaskDefinition(ctx context.Context, in *awsECS.RegisterTaskDefinitionInput) (*awsECS.RegisterTaskDefinitionOutput, error) {
 	c.RegisterTaskDefinitionInput = in
 
+	if err := c.Latency.wait(ctx, "RegisterTaskDefinition"); err != nil {
+		return nil, err
+	}
+
 	if c.RegisterTaskDefinitionOutput != nil || c.RegisterTaskDefinitionError != nil {
 		return c.RegisterTaskDefinitionOutput, c.RegisterTaskDefinitionError
 	}
 
+	state := c.service()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
 	if in.Family == nil {
 		return nil, &types.InvalidParameterException{Message: aws.String("missing family")}
 	}
+	if err := validateECSTags(nil, in.Tags); err != nil {
+		return nil, err
+	}
 
-	revisions := GlobalECSService.TaskDefs[utility.FromStringPtr(in.Family)]
+	revisions := state.TaskDefs[utility.FromStringPtr(in.Family)]
 	rev := len(revisions) + 1
 
 	taskDef := newECSTaskDefinition(in, rev)
 
-	GlobalECSService.TaskDefs[utility.FromStringPtr(in.Family)] = append(revisions, taskDef)
+	state.TaskDefs[utility.FromStringPtr(in.Family)] = append(revisions, taskDef)
 
 	exportedTask := taskDef.export()
 	return &awsECS.RegisterTaskDefinitionOutput{
@@ -495,13 +970,21 @@ func (c *ECSClient) RegisterTaskDefinition(ctx context.Context, in *awsECS.Regis
 func (c *ECSClient) DescribeTaskDefinition(ctx context.Context, in *awsECS.DescribeTaskDefinitionInput) (*awsECS.DescribeTaskDefinitionOutput, error) {
 	c.DescribeTaskDefinitionInput = in
 
+	if err := c.Latency.wait(ctx, "DescribeTaskDefinition"); err != nil {
+		return nil, err
+	}
+
 	if c.DescribeTaskDefinitionOutput != nil || c.DescribeTaskDefinitionError != nil {
 		return c.DescribeTaskDefinitionOutput, c.DescribeTaskDefinitionError
 	}
 
+	state := c.service()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
 	id := utility.FromStringPtr(in.TaskDefinition)
 
-	def, err := GlobalECSService.getLatestTaskDefinition(id)
+	def, err := state.getLatestTaskDefinition(id)
 	if err != nil {
 		return nil, &types.ResourceNotFoundException{Message: aws.String("task definition not found")}
 	}
@@ -521,18 +1004,50 @@ func (c *ECSClient) DescribeTaskDefinition(ctx context.Context, in *awsECS.Descr
 	return &resp, nil
 }
 
+// defaultListTaskDefinitionsMaxResults is the default maximum number of task
+// definitions returned in a single page, which matches the ECS default.
+const defaultListTaskDefinitionsMaxResults = 100
+
 // ListTaskDefinitions saves the input and lists all matching task definitions.
 // The mock output can be customized. By default, it will list all cached task
-// definitions that match the input filters.
+// definitions that match the input filters. Like ECS, the results are sorted by
+// family and revision (in ascending order unless the input specifies
+// descending order) and paginated according to the input's max results and
+// next token.
 func (c *ECSClient) ListTaskDefinitions(ctx context.Context, in *awsECS.ListTaskDefinitionsInput) (*awsECS.ListTaskDefinitionsOutput, error) {
 	c.ListTaskDefinitionsInput = in
 
+	if err := c.Latency.wait(ctx, "ListTaskDefinitions"); err != nil {
+		return nil, err
+	}
+
 	if c.ListTaskDefinitionsOutput != nil || c.ListTaskDefinitionsError != nil {
 		return c.ListTaskDefinitionsOutput, c.ListTaskDefinitionsError
 	}
 
-	var arns []string
-	for _, revisions := range GlobalECSService.TaskDefs {
+	state := c.service()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	maxResults := defaultListTaskDefinitionsMaxResults
+	if in.MaxResults != nil {
+		maxResults = int(*in.MaxResults)
+		if maxResults < 1 || maxResults > defaultListTaskDefinitionsMaxResults {
+			return nil, &types.InvalidParameterException{Message: aws.String(fmt.Sprintf("max results must be between 1 and %d", defaultListTaskDefinitionsMaxResults))}
+		}
+	}
+
+	start := 0
+	if in.NextToken != nil {
+		var err error
+		start, err = strconv.Atoi(*in.NextToken)
+		if err != nil || start < 0 {
+			return nil, &types.InvalidParameterException{Message: aws.String("invalid next token")}
+		}
+	}
+
+	var defs []ECSTaskDefinition
+	for _, revisions := range state.TaskDefs {
 		for _, def := range revisions {
 			if in.FamilyPrefix != nil && utility.FromStringPtr(def.Family) != *in.FamilyPrefix {
 				continue
@@ -541,12 +1056,40 @@ func (c *ECSClient) ListTaskDefinitions(ctx context.Context, in *awsECS.ListTask
 				continue
 			}
 
-			arns = append(arns, def.ARN)
+			defs = append(defs, def)
 		}
 	}
 
+	sort.SliceStable(defs, func(i, j int) bool {
+		if in.Sort == types.SortOrderDesc {
+			i, j = j, i
+		}
+		famI, famJ := utility.FromStringPtr(defs[i].Family), utility.FromStringPtr(defs[j].Family)
+		if famI != famJ {
+			return famI < famJ
+		}
+		return utility.FromInt64Ptr(defs[i].Revision) < utility.FromInt64Ptr(defs[j].Revision)
+	})
+
+	if start > len(defs) {
+		start = len(defs)
+	}
+	end := start + maxResults
+	var nextToken *string
+	if end < len(defs) {
+		nextToken = aws.String(strconv.Itoa(end))
+	} else {
+		end = len(defs)
+	}
+
+	var arns []string
+	for _, def := range defs[start:end] {
+		arns = append(arns, def.ARN)
+	}
+
 	return &awsECS.ListTaskDefinitionsOutput{
 		TaskDefinitionArns: arns,
+		NextToken:          nextToken,
 	}, nil
 }
 
@@ -556,24 +1099,32 @@ func (c *ECSClient) ListTaskDefinitions(ctx context.Context, in *awsECS.ListTask
 func (c *ECSClient) DeregisterTaskDefinition(ctx context.Context, in *awsECS.DeregisterTaskDefinitionInput) (*awsECS.DeregisterTaskDefinitionOutput, error) {
 	c.DeregisterTaskDefinitionInput = in
 
+	if err := c.Latency.wait(ctx, "DeregisterTaskDefinition"); err != nil {
+		return nil, err
+	}
+
 	if c.DeregisterTaskDefinitionOutput != nil || c.DeregisterTaskDefinitionError != nil {
 		return c.DeregisterTaskDefinitionOutput, c.DeregisterTaskDefinitionError
 	}
 
+	state := c.service()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
 	if in.TaskDefinition == nil {
 		return nil, &types.InvalidParameterException{Message: aws.String("missing task definition")}
 	}
 
 	id := utility.FromStringPtr(in.TaskDefinition)
 
-	def, err := GlobalECSService.getTaskDefinition(id)
+	def, err := state.getTaskDefinition(id)
 	if err != nil {
 		return nil, &types.ResourceNotFoundException{Message: aws.String("task definition not found")}
 	}
 
 	def.Status = utility.ToStringPtr(string(types.TaskDefinitionStatusInactive))
 	def.Deregistered = utility.ToTimePtr(time.Now())
-	GlobalECSService.TaskDefs[utility.FromStringPtr(def.Family)][utility.FromInt64Ptr(def.Revision)-1] = *def
+	state.TaskDefs[utility.FromStringPtr(def.Family)][utility.FromInt64Ptr(def.Revision)-1] = *def
 
 	exportedDef := def.export()
 	return &awsECS.DeregisterTaskDefinitionOutput{
@@ -583,31 +1134,56 @@ func (c *ECSClient) DeregisterTaskDefinition(ctx context.Context, in *awsECS.Der
 
 // RunTask saves the input options and returns the mock result of running a task
 // definition. The mock output can be customized. By default, it will create
-// mock output based on the input.
+// mock output based on the input. If the cluster does not have enough capacity
+// to run the task, it returns a failure indicating which resource is
+// insufficient.
 func (c *ECSClient) RunTask(ctx context.Context, in *awsECS.RunTaskInput) (*awsECS.RunTaskOutput, error) {
 	c.RunTaskInput = in
 
+	if err := c.Latency.wait(ctx, "RunTask"); err != nil {
+		return nil, err
+	}
+
 	if c.RunTaskOutput != nil || c.RunTaskError != nil {
 		return c.RunTaskOutput, c.RunTaskError
 	}
 
+	state := c.service()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if c.RunTaskFailureReason != nil {
+		return newRunTaskFailureOutput(*c.RunTaskFailureReason), nil
+	}
+
 	if in.TaskDefinition == nil {
 		return nil, &types.InvalidParameterException{Message: aws.String("missing task definition")}
 	}
+	if err := validateECSTags(nil, in.Tags); err != nil {
+		return nil, err
+	}
 
 	clusterName := c.getOrDefaultCluster(in.Cluster)
-	cluster, ok := GlobalECSService.Clusters[clusterName]
+	cluster, ok := state.Clusters[clusterName]
 	if !ok {
 		return nil, &types.ResourceNotFoundException{Message: aws.String("cluster not found")}
 	}
 
 	taskDefID := utility.FromStringPtr(in.TaskDefinition)
 
-	def, err := GlobalECSService.getLatestTaskDefinition(taskDefID)
+	def, err := state.getLatestTaskDefinition(taskDefID)
 	if err != nil {
 		return nil, &types.ResourceNotFoundException{Message: aws.String("task definition not found")}
 	}
 
+	if err := validateTaskOverride(in.Overrides, *def); err != nil {
+		return nil, err
+	}
+
+	if reason, ok := state.checkCapacity(clusterName, *def); !ok {
+		return newRunTaskFailureOutput(reason), nil
+	}
+
 	task := newECSTask(in, *def)
 
 	cluster[task.ARN] = task
@@ -617,6 +1193,100 @@ func (c *ECSClient) RunTask(ctx context.Context, in *awsECS.RunTaskInput) (*awsE
 	}, nil
 }
 
+// maxStartTaskContainerInstances is the maximum number of container instances
+// that ECS can start tasks on in a single request.
+const maxStartTaskContainerInstances = 10
+
+// StartTask saves the input options and returns the mock result of starting a
+// task on each of the given container instances. The mock output can be
+// customized. By default, it will create a mock task on each container instance
+// that is registered to the cluster and return a failure for each one that is
+// not.
+func (c *ECSClient) StartTask(ctx context.Context, in *awsECS.StartTaskInput) (*awsECS.StartTaskOutput, error) {
+	c.StartTaskInput = in
+
+	if err := c.Latency.wait(ctx, "StartTask"); err != nil {
+		return nil, err
+	}
+
+	if c.StartTaskOutput != nil || c.StartTaskError != nil {
+		return c.StartTaskOutput, c.StartTaskError
+	}
+
+	state := c.service()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if in.TaskDefinition == nil {
+		return nil, &types.InvalidParameterException{Message: aws.String("missing task definition")}
+	}
+	if len(in.ContainerInstances) == 0 {
+		return nil, &types.InvalidParameterException{Message: aws.String("missing container instances")}
+	}
+	if len(in.ContainerInstances) > maxStartTaskContainerInstances {
+		return nil, &types.InvalidParameterException{Message: aws.String(fmt.Sprintf("cannot start tasks on more than %d container instances", maxStartTaskContainerInstances))}
+	}
+	if err := validateECSTags(nil, in.Tags); err != nil {
+		return nil, err
+	}
+
+	clusterName := c.getOrDefaultCluster(in.Cluster)
+	cluster, ok := state.Clusters[clusterName]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("cluster not found")}
+	}
+
+	def, err := state.getLatestTaskDefinition(utility.FromStringPtr(in.TaskDefinition))
+	if err != nil {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("task definition not found")}
+	}
+
+	if err := validateTaskOverride(in.Overrides, *def); err != nil {
+		return nil, err
+	}
+
+	var out awsECS.StartTaskOutput
+	for _, id := range in.ContainerInstances {
+		if _, ok := state.getContainerInstance(clusterName, id); !ok {
+			out.Failures = append(out.Failures, types.Failure{
+				Arn:    utility.ToStringPtr(id),
+				Reason: utility.ToStringPtr(ecs.ReasonTaskMissing),
+			})
+			continue
+		}
+
+		task := newECSTask(&awsECS.RunTaskInput{
+			Cluster:              in.Cluster,
+			EnableExecuteCommand: in.EnableExecuteCommand,
+			Group:                in.Group,
+			Overrides:            in.Overrides,
+			Tags:                 in.Tags,
+		}, *def)
+		// Unlike RunTask, StartTask can start the same task definition
+		// multiple times in one request, so each task needs a distinct ARN.
+		task.ARN = newECSTaskARN(utility.FromStringPtr(in.Cluster), fmt.Sprintf("%s-%s", def.taskID(), id[strings.LastIndex(id, "/")+1:]))
+		for i := range task.Containers {
+			task.Containers[i].TaskARN = utility.ToStringPtr(task.ARN)
+		}
+		task.ContainerInstance = utility.ToStringPtr(id)
+
+		cluster[task.ARN] = task
+		out.Tasks = append(out.Tasks, task.export(true))
+	}
+
+	return &out, nil
+}
+
+// newRunTaskFailureOutput returns the output from RunTask when the task could
+// not be placed for the given reason.
+func newRunTaskFailureOutput(reason string) *awsECS.RunTaskOutput {
+	return &awsECS.RunTaskOutput{
+		Failures: []types.Failure{{
+			Reason: utility.ToStringPtr(reason),
+		}},
+	}
+}
+
 func (c *ECSClient) getOrDefaultCluster(name *string) string {
 	if name == nil {
 		return "default"
@@ -630,11 +1300,19 @@ func (c *ECSClient) getOrDefaultCluster(name *string) string {
 func (c *ECSClient) DescribeTasks(ctx context.Context, in *awsECS.DescribeTasksInput) (*awsECS.DescribeTasksOutput, error) {
 	c.DescribeTasksInput = in
 
+	if err := c.Latency.wait(ctx, "DescribeTasks"); err != nil {
+		return nil, err
+	}
+
 	if c.DescribeTasksOutput != nil || c.DescribeTasksError != nil {
 		return c.DescribeTasksOutput, c.DescribeTasksError
 	}
 
-	cluster, ok := GlobalECSService.Clusters[c.getOrDefaultCluster(in.Cluster)]
+	state := c.service()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	cluster, ok := state.Clusters[c.getOrDefaultCluster(in.Cluster)]
 	if !ok {
 		return nil, &types.ResourceNotFoundException{Message: aws.String("cluster not found")}
 	}
@@ -687,11 +1365,19 @@ func shouldIncludeTags(includes []string) bool {
 func (c *ECSClient) ListTasks(ctx context.Context, in *awsECS.ListTasksInput) (*awsECS.ListTasksOutput, error) {
 	c.ListTasksInput = in
 
+	if err := c.Latency.wait(ctx, "ListTasks"); err != nil {
+		return nil, err
+	}
+
 	if c.ListTasksOutput != nil || c.ListTasksError != nil {
 		return c.ListTasksOutput, c.ListTasksError
 	}
 
-	cluster, ok := GlobalECSService.Clusters[c.getOrDefaultCluster(in.Cluster)]
+	state := c.service()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	cluster, ok := state.Clusters[c.getOrDefaultCluster(in.Cluster)]
 	if !ok {
 		return &awsECS.ListTasksOutput{}, nil
 	}
@@ -718,17 +1404,145 @@ func (c *ECSClient) ListTasks(ctx context.Context, in *awsECS.ListTasksInput) (*
 	}, nil
 }
 
+// ListContainerInstances saves the input and lists all matching container
+// instances. The mock output can be customized. By default, it will list all
+// container instances registered to the cluster that match the input status.
+func (c *ECSClient) ListContainerInstances(ctx context.Context, in *awsECS.ListContainerInstancesInput) (*awsECS.ListContainerInstancesOutput, error) {
+	c.ListContainerInstancesInput = in
+
+	if err := c.Latency.wait(ctx, "ListContainerInstances"); err != nil {
+		return nil, err
+	}
+
+	if c.ListContainerInstancesOutput != nil || c.ListContainerInstancesError != nil {
+		return c.ListContainerInstancesOutput, c.ListContainerInstancesError
+	}
+
+	state := c.service()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	clusterName := c.getOrDefaultCluster(in.Cluster)
+	if _, ok := state.Clusters[clusterName]; !ok {
+		return nil, &types.ClusterNotFoundException{Message: aws.String("cluster not found")}
+	}
+
+	var arns []string
+	for _, instance := range state.ContainerInstances[clusterName] {
+		if in.Status != "" && instance.Status != in.Status {
+			continue
+		}
+		arns = append(arns, instance.ARN)
+	}
+
+	return &awsECS.ListContainerInstancesOutput{
+		ContainerInstanceArns: arns,
+	}, nil
+}
+
+// DescribeContainerInstances saves the input and describes the container
+// instances registered to the cluster. The mock output can be customized. By
+// default, it will describe the matching container instances, where the
+// remaining resources of each container instance are its registered resources
+// minus the resources used by the tasks that are not stopped on it.
+func (c *ECSClient) DescribeContainerInstances(ctx context.Context, in *awsECS.DescribeContainerInstancesInput) (*awsECS.DescribeContainerInstancesOutput, error) {
+	c.DescribeContainerInstancesInput = in
+
+	if err := c.Latency.wait(ctx, "DescribeContainerInstances"); err != nil {
+		return nil, err
+	}
+
+	if c.DescribeContainerInstancesOutput != nil || c.DescribeContainerInstancesError != nil {
+		return c.DescribeContainerInstancesOutput, c.DescribeContainerInstancesError
+	}
+
+	state := c.service()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	clusterName := c.getOrDefaultCluster(in.Cluster)
+	cluster, ok := state.Clusters[clusterName]
+	if !ok {
+		return nil, &types.ClusterNotFoundException{Message: aws.String("cluster not found")}
+	}
+
+	instances := map[string]ECSContainerInstance{}
+	for _, instance := range state.ContainerInstances[clusterName] {
+		instances[instance.ARN] = instance
+	}
+
+	var described []types.ContainerInstance
+	var failures []types.Failure
+	for _, id := range in.ContainerInstances {
+		instance, ok := instances[id]
+		if !ok {
+			failures = append(failures, types.Failure{
+				Arn:    utility.ToStringPtr(id),
+				Reason: utility.ToStringPtr(ecs.ReasonTaskMissing),
+			})
+			continue
+		}
+
+		var usedCPU, usedMemMB int
+		for _, task := range cluster {
+			if task.Status == string(types.DesiredStatusStopped) || utility.FromStringPtr(task.ContainerInstance) != instance.ARN {
+				continue
+			}
+			cpu, mem := task.TaskDef.resourceRequirements()
+			usedCPU += cpu
+			usedMemMB += mem
+		}
+
+		described = append(described, types.ContainerInstance{
+			ContainerInstanceArn: utility.ToStringPtr(instance.ARN),
+			Status:               utility.ToStringPtr(string(instance.Status)),
+			RegisteredResources:  exportContainerInstanceResources(instance.RegisteredCPU, instance.RegisteredMemoryMB),
+			RemainingResources:   exportContainerInstanceResources(instance.RegisteredCPU-usedCPU, instance.RegisteredMemoryMB-usedMemMB),
+		})
+	}
+
+	return &awsECS.DescribeContainerInstancesOutput{
+		ContainerInstances: described,
+		Failures:           failures,
+	}, nil
+}
+
+// exportContainerInstanceResources exports the CPU and memory of a container
+// instance into ECS resources.
+func exportContainerInstanceResources(cpu, memMB int) []types.Resource {
+	return []types.Resource{
+		{
+			Name:         utility.ToStringPtr("CPU"),
+			Type:         utility.ToStringPtr("INTEGER"),
+			IntegerValue: int32(cpu),
+		},
+		{
+			Name:         utility.ToStringPtr("MEMORY"),
+			Type:         utility.ToStringPtr("INTEGER"),
+			IntegerValue: int32(memMB),
+		},
+	}
+}
+
 // StopTask saves the input and stops a mock task. The mock output can be
 // customized. By default, it will mark a cached task as stopped if it exists
 // and is running.
 func (c *ECSClient) StopTask(ctx context.Context, in *awsECS.StopTaskInput) (*awsECS.StopTaskOutput, error) {
 	c.StopTaskInput = in
 
+	if err := c.Latency.wait(ctx, "StopTask"); err != nil {
+		return nil, err
+	}
+
 	if c.StopTaskOutput != nil || c.StopTaskError != nil {
 		return c.StopTaskOutput, c.StopTaskError
 	}
 
-	cluster, ok := GlobalECSService.Clusters[c.getOrDefaultCluster(in.Cluster)]
+	state := c.service()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	cluster, ok := state.Clusters[c.getOrDefaultCluster(in.Cluster)]
 	if !ok {
 		return nil, &types.ResourceNotFoundException{Message: aws.String("cluster not found")}
 	}
@@ -738,11 +1552,16 @@ func (c *ECSClient) StopTask(ctx context.Context, in *awsECS.StopTaskInput) (*aw
 		return nil, cocoa.NewECSTaskNotFoundError(utility.FromStringPtr(in.Task))
 	}
 
+	now := time.Now()
 	task.Status = string(types.DesiredStatusStopped)
 	task.GoalStatus = string(types.DesiredStatusStopped)
 	task.StopCode = string(types.TaskStopCodeUserInitiated)
 	task.StopReason = in.Reason
-	task.Stopped = utility.ToTimePtr(time.Now())
+	task.Stopping = utility.ToTimePtr(now)
+	if task.ExecutionStopped == nil {
+		task.ExecutionStopped = utility.ToTimePtr(now)
+	}
+	task.Stopped = utility.ToTimePtr(now)
 	for i := range task.Containers {
 		task.Containers[i].Status = string(types.DesiredStatusStopped)
 	}
@@ -756,30 +1575,50 @@ func (c *ECSClient) StopTask(ctx context.Context, in *awsECS.StopTaskInput) (*aw
 }
 
 // TagResource saves the input and tags a mock task or task definition. The mock
-// output can be customized. By default, it will add the tag to the resource if
-// it exists.
+// output can be customized. By default, it will add the tags to the resource if
+// it exists and would not exceed the ECS tag limits.
 func (c *ECSClient) TagResource(ctx context.Context, in *awsECS.TagResourceInput) (*awsECS.TagResourceOutput, error) {
 	c.TagResourceInput = in
 
+	if err := c.Latency.wait(ctx, "TagResource"); err != nil {
+		return nil, err
+	}
+
 	if c.TagResourceOutput != nil || c.TagResourceError != nil {
 		return c.TagResourceOutput, c.TagResourceError
 	}
 
+	state := c.service()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
 	id := utility.FromStringPtr(in.ResourceArn)
 
-	taskDef, err := GlobalECSService.getTaskDefinition(id)
+	taskDef, err := state.getTaskDefinition(id)
 	if err == nil {
+		if err := validateECSTags(taskDef.Tags, in.Tags); err != nil {
+			return nil, err
+		}
+		if taskDef.Tags == nil {
+			taskDef.Tags = map[string]string{}
+		}
 		for k, v := range newECSTags(in.Tags) {
 			taskDef.Tags[k] = v
 		}
 		return &awsECS.TagResourceOutput{}, nil
 	}
 
-	for _, cluster := range GlobalECSService.Clusters {
+	for _, cluster := range state.Clusters {
 		task, ok := cluster[id]
 		if !ok {
 			continue
 		}
+		if err := validateECSTags(task.Tags, in.Tags); err != nil {
+			return nil, err
+		}
+		if task.Tags == nil {
+			task.Tags = map[string]string{}
+		}
 		for k, v := range newECSTags(in.Tags) {
 			task.Tags[k] = v
 		}
@@ -789,3 +1628,162 @@ func (c *ECSClient) TagResource(ctx context.Context, in *awsECS.TagResourceInput
 
 	return nil, &types.ResourceNotFoundException{Message: aws.String("task or task definition not found")}
 }
+
+// DescribeClusters saves the input and returns information about the existing
+// clusters. The mock output can be customized. By default, it will describe
+// all cached clusters that match.
+func (c *ECSClient) DescribeClusters(ctx context.Context, in *awsECS.DescribeClustersInput) (*awsECS.DescribeClustersOutput, error) {
+	c.DescribeClustersInput = in
+
+	if err := c.Latency.wait(ctx, "DescribeClusters"); err != nil {
+		return nil, err
+	}
+
+	if c.DescribeClustersOutput != nil || c.DescribeClustersError != nil {
+		return c.DescribeClustersOutput, c.DescribeClustersError
+	}
+
+	state := c.service()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	names := in.Clusters
+	if len(names) == 0 {
+		names = []string{c.getOrDefaultCluster(nil)}
+	}
+
+	var clusters []types.Cluster
+	var failures []types.Failure
+	for _, name := range names {
+		cluster, ok := state.Clusters[name]
+		if !ok {
+			failures = append(failures, types.Failure{
+				Arn: utility.ToStringPtr(name),
+				// ECS uses the same reason for missing clusters as it does for
+				// missing tasks.
+				Reason: utility.ToStringPtr(ecs.ReasonTaskMissing),
+			})
+			continue
+		}
+
+		var running, pending int32
+		for _, task := range cluster {
+			switch task.Status {
+			case string(types.DesiredStatusRunning):
+				running++
+			case string(types.DesiredStatusPending):
+				pending++
+			}
+		}
+
+		clusters = append(clusters, types.Cluster{
+			ClusterArn:        utility.ToStringPtr(name),
+			ClusterName:       utility.ToStringPtr(name),
+			Status:            utility.ToStringPtr("ACTIVE"),
+			RunningTasksCount: running,
+			PendingTasksCount: pending,
+		})
+	}
+
+	return &awsECS.DescribeClustersOutput{
+		Clusters: clusters,
+		Failures: failures,
+	}, nil
+}
+
+// ListServices saves the input and lists all matching services. The mock
+// output can be customized. By default, it will list all services in the
+// cluster that match the input launch type and scheduling strategy.
+func (c *ECSClient) ListServices(ctx context.Context, in *awsECS.ListServicesInput) (*awsECS.ListServicesOutput, error) {
+	c.ListServicesInput = in
+
+	if err := c.Latency.wait(ctx, "ListServices"); err != nil {
+		return nil, err
+	}
+
+	if c.ListServicesOutput != nil || c.ListServicesError != nil {
+		return c.ListServicesOutput, c.ListServicesError
+	}
+
+	state := c.service()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	clusterName := c.getOrDefaultCluster(in.Cluster)
+	if _, ok := state.Clusters[clusterName]; !ok {
+		return nil, &types.ClusterNotFoundException{Message: aws.String("cluster not found")}
+	}
+
+	var arns []string
+	for _, svc := range state.Services[clusterName] {
+		if in.LaunchType != "" && svc.LaunchType != in.LaunchType {
+			continue
+		}
+		if in.SchedulingStrategy != "" && svc.SchedulingStrategy != in.SchedulingStrategy {
+			continue
+		}
+		arns = append(arns, svc.ARN)
+	}
+
+	return &awsECS.ListServicesOutput{
+		ServiceArns: arns,
+	}, nil
+}
+
+// DescribeServices saves the input and returns information about the existing
+// services. The mock output can be customized. By default, it will describe
+// the services in the cluster matching the input names or ARNs. Services that
+// do not exist are returned as failures.
+func (c *ECSClient) DescribeServices(ctx context.Context, in *awsECS.DescribeServicesInput) (*awsECS.DescribeServicesOutput, error) {
+	c.DescribeServicesInput = in
+
+	if err := c.Latency.wait(ctx, "DescribeServices"); err != nil {
+		return nil, err
+	}
+
+	if c.DescribeServicesOutput != nil || c.DescribeServicesError != nil {
+		return c.DescribeServicesOutput, c.DescribeServicesError
+	}
+
+	state := c.service()
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	if len(in.Services) == 0 {
+		return nil, &types.InvalidParameterException{Message: aws.String("must specify at least one service")}
+	}
+	if len(in.Services) > 10 {
+		return nil, &types.InvalidParameterException{Message: aws.String("cannot describe more than 10 services at once")}
+	}
+
+	clusterName := c.getOrDefaultCluster(in.Cluster)
+	if _, ok := state.Clusters[clusterName]; !ok {
+		return nil, &types.ClusterNotFoundException{Message: aws.String("cluster not found")}
+	}
+
+	var includeTags bool
+	for _, field := range in.Include {
+		if field == types.ServiceFieldTags {
+			includeTags = true
+		}
+	}
+
+	var services []types.Service
+	var failures []types.Failure
+	for _, id := range in.Services {
+		svc, ok := state.getService(clusterName, id)
+		if !ok {
+			failures = append(failures, types.Failure{
+				Arn:    utility.ToStringPtr(id),
+				Reason: utility.ToStringPtr(ecs.ReasonTaskMissing),
+			})
+			continue
+		}
+		services = append(services, svc.export(clusterName, includeTags))
+	}
+
+	return &awsECS.DescribeServicesOutput{
+		Services: services,
+		Failures: failures,
+	}, nil
+}
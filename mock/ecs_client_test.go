@@ -2,13 +2,22 @@ package mock
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsECS "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/ecs"
 	"github.com/evergreen-ci/cocoa/internal/testcase"
 	"github.com/evergreen-ci/cocoa/internal/testutil"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // defaultTestTimeout is the default test timeout for mock tests.
@@ -45,4 +54,655 @@ func TestECSClient(t *testing.T) {
 			tCase(tctx, t, c, *registerOut.TaskDefinition)
 		})
 	}
+
+	for tName, tCase := range ecsClientTests() {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			resetECSAndSecretsManagerCache()
+
+			tCase(tctx, t, &ECSClient{})
+		})
+	}
+}
+
+// ecsClientTests are mock-specific tests for the ECS client.
+func ecsClientTests() map[string]func(ctx context.Context, t *testing.T, c *ECSClient) {
+	runTaskInput := func(taskDefARN *string) *awsECS.RunTaskInput {
+		return &awsECS.RunTaskInput{
+			Cluster:        aws.String(testutil.ECSClusterName()),
+			Count:          aws.Int32(1),
+			TaskDefinition: taskDefARN,
+		}
+	}
+
+	// registerFamilies registers two revisions for each of the given families
+	// in reverse order and returns their ARNs sorted by family and revision.
+	registerFamilies := func(ctx context.Context, t *testing.T, c *ECSClient, families ...string) []string {
+		arnsByFamily := map[string][]string{}
+		for i := len(families) - 1; i >= 0; i-- {
+			for rev := 0; rev < 2; rev++ {
+				in := testutil.ValidRegisterTaskDefinitionInput(t)
+				in.Family = aws.String(families[i])
+				out := testutil.RegisterTaskDefinition(ctx, t, c, in)
+				arnsByFamily[families[i]] = append(arnsByFamily[families[i]], utility.FromStringPtr(out.TaskDefinition.TaskDefinitionArn))
+			}
+		}
+		var arns []string
+		for _, family := range families {
+			arns = append(arns, arnsByFamily[family]...)
+		}
+		return arns
+	}
+	listActiveInput := func() *awsECS.ListTaskDefinitionsInput {
+		return &awsECS.ListTaskDefinitionsInput{Status: types.TaskDefinitionStatusActive}
+	}
+	// makeTags returns the given number of tags with distinct keys.
+	makeTags := func(n int) []types.Tag {
+		tags := make([]types.Tag, 0, n)
+		for i := 0; i < n; i++ {
+			tags = append(tags, types.Tag{
+				Key:   aws.String(fmt.Sprintf("key%d", i)),
+				Value: aws.String(fmt.Sprintf("value%d", i)),
+			})
+		}
+		return tags
+	}
+
+	return map[string]func(ctx context.Context, t *testing.T, c *ECSClient){
+		"RunTaskReturnsFailureWithReasonWhenRunTaskFailureReasonIsSet": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			c.RunTaskFailureReason = aws.String(ecs.ReasonAgent)
+
+			out, err := c.RunTask(ctx, runTaskInput(registerOut.TaskDefinition.TaskDefinitionArn))
+			require.NoError(t, err)
+			require.NotZero(t, out)
+			assert.Empty(t, out.Tasks)
+			require.Len(t, out.Failures, 1)
+			assert.Equal(t, ecs.ReasonAgent, utility.FromStringPtr(out.Failures[0].Reason))
+			assert.Empty(t, GlobalECSService.Clusters[testutil.ECSClusterName()], "task should not run")
+		},
+		"StartTaskStartsTaskOnEachRegisteredContainerInstance": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			instances := []ECSContainerInstance{NewECSContainerInstance(), NewECSContainerInstance()}
+			GlobalECSService.ContainerInstances[testutil.ECSClusterName()] = instances
+			missingInstance := NewECSContainerInstance()
+
+			out, err := c.StartTask(ctx, &awsECS.StartTaskInput{
+				Cluster:            aws.String(testutil.ECSClusterName()),
+				TaskDefinition:     registerOut.TaskDefinition.TaskDefinitionArn,
+				ContainerInstances: []string{instances[0].ARN, instances[1].ARN, missingInstance.ARN},
+			})
+			require.NoError(t, err)
+			require.NotZero(t, out)
+			require.Len(t, out.Tasks, 2)
+			assert.Equal(t, instances[0].ARN, utility.FromStringPtr(out.Tasks[0].ContainerInstanceArn))
+			assert.Equal(t, instances[1].ARN, utility.FromStringPtr(out.Tasks[1].ContainerInstanceArn))
+			assert.NotEqual(t, utility.FromStringPtr(out.Tasks[0].TaskArn), utility.FromStringPtr(out.Tasks[1].TaskArn))
+			require.Len(t, out.Failures, 1)
+			assert.Equal(t, missingInstance.ARN, utility.FromStringPtr(out.Failures[0].Arn))
+			assert.Len(t, GlobalECSService.Clusters[testutil.ECSClusterName()], 2)
+		},
+		"StartTaskFailsWithoutContainerInstances": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+
+			out, err := c.StartTask(ctx, &awsECS.StartTaskInput{
+				Cluster:        aws.String(testutil.ECSClusterName()),
+				TaskDefinition: registerOut.TaskDefinition.TaskDefinitionArn,
+			})
+			assert.Error(t, err)
+			assert.Zero(t, out)
+		},
+		"ListContainerInstancesFiltersByStatus": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			active := NewECSContainerInstance()
+			draining := NewECSContainerInstance()
+			draining.Status = types.ContainerInstanceStatusDraining
+			GlobalECSService.ContainerInstances[testutil.ECSClusterName()] = []ECSContainerInstance{active, draining}
+
+			out, err := c.ListContainerInstances(ctx, &awsECS.ListContainerInstancesInput{
+				Cluster: aws.String(testutil.ECSClusterName()),
+				Status:  types.ContainerInstanceStatusActive,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{active.ARN}, out.ContainerInstanceArns)
+
+			out, err = c.ListContainerInstances(ctx, &awsECS.ListContainerInstancesInput{
+				Cluster: aws.String(testutil.ECSClusterName()),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{active.ARN, draining.ARN}, out.ContainerInstanceArns)
+		},
+		"ListContainerInstancesFailsWithNonexistentCluster": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			out, err := c.ListContainerInstances(ctx, &awsECS.ListContainerInstancesInput{
+				Cluster: aws.String("foo"),
+			})
+			assert.Error(t, err)
+			assert.Zero(t, out)
+		},
+		"ListServicesFiltersByLaunchTypeAndSchedulingStrategy": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			replica := NewECSClusterService("replica", "task_def", 2)
+			daemon := NewECSClusterService("daemon", "task_def", 1)
+			daemon.SchedulingStrategy = types.SchedulingStrategyDaemon
+			fargate := NewECSClusterService("fargate", "task_def", 3)
+			fargate.LaunchType = types.LaunchTypeFargate
+			GlobalECSService.Services[testutil.ECSClusterName()] = []ECSClusterService{replica, daemon, fargate}
+
+			out, err := c.ListServices(ctx, &awsECS.ListServicesInput{
+				Cluster: aws.String(testutil.ECSClusterName()),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{replica.ARN, daemon.ARN, fargate.ARN}, out.ServiceArns)
+
+			out, err = c.ListServices(ctx, &awsECS.ListServicesInput{
+				Cluster:    aws.String(testutil.ECSClusterName()),
+				LaunchType: types.LaunchTypeEc2,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{replica.ARN, daemon.ARN}, out.ServiceArns)
+
+			out, err = c.ListServices(ctx, &awsECS.ListServicesInput{
+				Cluster:            aws.String(testutil.ECSClusterName()),
+				SchedulingStrategy: types.SchedulingStrategyDaemon,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{daemon.ARN}, out.ServiceArns)
+		},
+		"ListServicesFailsWithNonexistentCluster": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			out, err := c.ListServices(ctx, &awsECS.ListServicesInput{
+				Cluster: aws.String("foo"),
+			})
+			assert.Error(t, err)
+			assert.Zero(t, out)
+		},
+		"DescribeServicesReturnsExistingServicesAndFailures": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			svc := NewECSClusterService("service", "task_def", 2)
+			svc.Tags = map[string]string{"key": "value"}
+			GlobalECSService.Services[testutil.ECSClusterName()] = []ECSClusterService{svc}
+
+			out, err := c.DescribeServices(ctx, &awsECS.DescribeServicesInput{
+				Cluster:  aws.String(testutil.ECSClusterName()),
+				Services: []string{svc.Name, "nonexistent"},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.Services, 1)
+			assert.Equal(t, svc.ARN, utility.FromStringPtr(out.Services[0].ServiceArn))
+			assert.Equal(t, svc.Name, utility.FromStringPtr(out.Services[0].ServiceName))
+			assert.Equal(t, "task_def", utility.FromStringPtr(out.Services[0].TaskDefinition))
+			assert.EqualValues(t, 2, out.Services[0].DesiredCount)
+			assert.EqualValues(t, 2, out.Services[0].RunningCount)
+			assert.Empty(t, out.Services[0].Tags, "tags should not be included unless requested")
+			require.Len(t, out.Failures, 1)
+			assert.Equal(t, "nonexistent", utility.FromStringPtr(out.Failures[0].Arn))
+
+			out, err = c.DescribeServices(ctx, &awsECS.DescribeServicesInput{
+				Cluster:  aws.String(testutil.ECSClusterName()),
+				Services: []string{svc.ARN},
+				Include:  []types.ServiceField{types.ServiceFieldTags},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.Services, 1)
+			assert.Len(t, out.Services[0].Tags, 1)
+		},
+		"DescribeServicesFailsWithoutServices": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			out, err := c.DescribeServices(ctx, &awsECS.DescribeServicesInput{
+				Cluster: aws.String(testutil.ECSClusterName()),
+			})
+			assert.Error(t, err)
+			assert.Zero(t, out)
+		},
+		"RunTaskAppliesOverridesToTaskAndContainers": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerIn := testutil.ValidRegisterTaskDefinitionInput(t)
+			registerIn.ContainerDefinitions[0].Environment = []types.KeyValuePair{
+				{Name: aws.String("ENV_VAR"), Value: aws.String("original")},
+				{Name: aws.String("UNCHANGED"), Value: aws.String("unchanged")},
+			}
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, registerIn)
+			containerName := utility.FromStringPtr(registerIn.ContainerDefinitions[0].Name)
+
+			in := runTaskInput(registerOut.TaskDefinition.TaskDefinitionArn)
+			in.Overrides = &types.TaskOverride{
+				Cpu:    aws.String("64"),
+				Memory: aws.String("128"),
+				ContainerOverrides: []types.ContainerOverride{{
+					Name:        aws.String(containerName),
+					Command:     []string{"echo", "bar"},
+					Environment: []types.KeyValuePair{{Name: aws.String("ENV_VAR"), Value: aws.String("overridden")}},
+					Cpu:         aws.Int32(32),
+					Memory:      aws.Int32(64),
+				}},
+			}
+			out, err := c.RunTask(ctx, in)
+			require.NoError(t, err)
+			require.Len(t, out.Tasks, 1)
+
+			task, ok := GlobalECSService.Clusters[testutil.ECSClusterName()][utility.FromStringPtr(out.Tasks[0].TaskArn)]
+			require.True(t, ok)
+			assert.Equal(t, "64", utility.FromStringPtr(task.CPU))
+			assert.Equal(t, "128", utility.FromStringPtr(task.MemoryMB))
+			require.Len(t, task.Containers, 1)
+			assert.Equal(t, []string{"echo", "bar"}, task.Containers[0].Command)
+			assert.Equal(t, map[string]string{"ENV_VAR": "overridden", "UNCHANGED": "unchanged"}, task.Containers[0].EnvVars)
+			assert.EqualValues(t, 32, utility.FromInt32Ptr(task.Containers[0].CPU))
+			assert.EqualValues(t, 64, utility.FromInt32Ptr(task.Containers[0].MemoryMB))
+
+			describeOut, err := c.DescribeTasks(ctx, &awsECS.DescribeTasksInput{
+				Cluster: aws.String(testutil.ECSClusterName()),
+				Tasks:   []string{task.ARN},
+			})
+			require.NoError(t, err)
+			require.Len(t, describeOut.Tasks, 1)
+			described := describeOut.Tasks[0]
+			assert.Equal(t, "64", utility.FromStringPtr(described.Cpu))
+			assert.Equal(t, "128", utility.FromStringPtr(described.Memory))
+			require.NotZero(t, described.Overrides)
+			require.Len(t, described.Overrides.ContainerOverrides, 1)
+			assert.Equal(t, []string{"echo", "bar"}, described.Overrides.ContainerOverrides[0].Command)
+			require.Len(t, described.Containers, 1)
+			assert.Equal(t, "32", utility.FromStringPtr(described.Containers[0].Cpu))
+			assert.Equal(t, "64", utility.FromStringPtr(described.Containers[0].Memory))
+
+			assert.Equal(t, []string{"echo", "foo"}, GlobalECSService.TaskDefs[utility.FromStringPtr(registerIn.Family)][0].ContainerDefs[0].Command, "task definition should not be modified")
+		},
+		"DescribeTasksIncludesContainerHealthAndExit": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			containerName := utility.FromStringPtr(registerOut.TaskDefinition.ContainerDefinitions[0].Name)
+
+			out, err := c.RunTask(ctx, runTaskInput(registerOut.TaskDefinition.TaskDefinitionArn))
+			require.NoError(t, err)
+			require.Len(t, out.Tasks, 1)
+			taskARN := utility.FromStringPtr(out.Tasks[0].TaskArn)
+			require.Len(t, out.Tasks[0].Containers, 1)
+			assert.Equal(t, types.HealthStatusUnknown, out.Tasks[0].Containers[0].HealthStatus)
+			assert.Zero(t, out.Tasks[0].Containers[0].ExitCode)
+
+			require.NoError(t, GlobalECSService.SetContainerHealthStatus(testutil.ECSClusterName(), taskARN, containerName, types.HealthStatusUnhealthy))
+			require.NoError(t, GlobalECSService.SetContainerExit(testutil.ECSClusterName(), taskARN, containerName, 137, "OutOfMemoryError: Container killed due to memory usage"))
+
+			describeOut, err := c.DescribeTasks(ctx, &awsECS.DescribeTasksInput{
+				Cluster: aws.String(testutil.ECSClusterName()),
+				Tasks:   []string{taskARN},
+			})
+			require.NoError(t, err)
+			require.Len(t, describeOut.Tasks, 1)
+			require.Len(t, describeOut.Tasks[0].Containers, 1)
+			container := describeOut.Tasks[0].Containers[0]
+			assert.Equal(t, types.HealthStatusUnhealthy, container.HealthStatus)
+			assert.EqualValues(t, 137, utility.FromInt32Ptr(container.ExitCode))
+			assert.Equal(t, "OutOfMemoryError: Container killed due to memory usage", utility.FromStringPtr(container.Reason))
+			assert.Equal(t, string(types.DesiredStatusStopped), utility.FromStringPtr(container.LastStatus))
+		},
+		"SetContainerExitFailsWithNonexistentContainer": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+
+			out, err := c.RunTask(ctx, runTaskInput(registerOut.TaskDefinition.TaskDefinitionArn))
+			require.NoError(t, err)
+			require.Len(t, out.Tasks, 1)
+			taskARN := utility.FromStringPtr(out.Tasks[0].TaskArn)
+
+			assert.Error(t, GlobalECSService.SetContainerExit(testutil.ECSClusterName(), taskARN, "nonexistent", 1, ""))
+			assert.Error(t, GlobalECSService.SetContainerExit(testutil.ECSClusterName(), "nonexistent", "print_foo", 1, ""))
+			assert.Error(t, GlobalECSService.SetContainerHealthStatus("nonexistent", taskARN, "print_foo", types.HealthStatusHealthy))
+		},
+		"RunTaskFailsWithOverrideForNonexistentContainer": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+
+			in := runTaskInput(registerOut.TaskDefinition.TaskDefinitionArn)
+			in.Overrides = &types.TaskOverride{
+				ContainerOverrides: []types.ContainerOverride{{
+					Name:    aws.String("foo"),
+					Command: []string{"echo", "bar"},
+				}},
+			}
+			out, err := c.RunTask(ctx, in)
+			assert.Error(t, err)
+			assert.Zero(t, out)
+			assert.Empty(t, GlobalECSService.Clusters[testutil.ECSClusterName()], "task should not run")
+		},
+		"RunTaskSucceedsWhenClusterHasSufficientCapacity": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			GlobalECSService.ClusterCapacities[testutil.ECSClusterName()] = ECSClusterCapacity{
+				CPU:      utility.ToIntPtr(128),
+				MemoryMB: utility.ToIntPtr(256),
+			}
+
+			out, err := c.RunTask(ctx, runTaskInput(registerOut.TaskDefinition.TaskDefinitionArn))
+			require.NoError(t, err)
+			require.NotZero(t, out)
+			assert.Empty(t, out.Failures)
+			assert.Len(t, out.Tasks, 1)
+		},
+		"RunTaskReturnsMemoryFailureWhenClusterHasInsufficientMemory": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			GlobalECSService.ClusterCapacities[testutil.ECSClusterName()] = ECSClusterCapacity{
+				MemoryMB: utility.ToIntPtr(128),
+			}
+
+			out, err := c.RunTask(ctx, runTaskInput(registerOut.TaskDefinition.TaskDefinitionArn))
+			require.NoError(t, err)
+			require.NotZero(t, out)
+			assert.Empty(t, out.Tasks)
+			require.Len(t, out.Failures, 1)
+			assert.Equal(t, ecs.ReasonResourceMemory, utility.FromStringPtr(out.Failures[0].Reason))
+		},
+		"RunTaskReturnsCPUFailureWhenClusterHasInsufficientCPU": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			GlobalECSService.ClusterCapacities[testutil.ECSClusterName()] = ECSClusterCapacity{
+				CPU: utility.ToIntPtr(64),
+			}
+
+			out, err := c.RunTask(ctx, runTaskInput(registerOut.TaskDefinition.TaskDefinitionArn))
+			require.NoError(t, err)
+			require.NotZero(t, out)
+			assert.Empty(t, out.Tasks)
+			require.Len(t, out.Failures, 1)
+			assert.Equal(t, ecs.ReasonResourceCPU, utility.FromStringPtr(out.Failures[0].Reason))
+		},
+		"RunTaskAccountsForCapacityUsedByRunningTasks": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			GlobalECSService.ClusterCapacities[testutil.ECSClusterName()] = ECSClusterCapacity{
+				MemoryMB: utility.ToIntPtr(256),
+			}
+
+			firstOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			runOut, err := c.RunTask(ctx, runTaskInput(firstOut.TaskDefinition.TaskDefinitionArn))
+			require.NoError(t, err)
+			require.Len(t, runOut.Tasks, 1)
+
+			secondOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			out, err := c.RunTask(ctx, runTaskInput(secondOut.TaskDefinition.TaskDefinitionArn))
+			require.NoError(t, err)
+			assert.Empty(t, out.Tasks)
+			require.Len(t, out.Failures, 1)
+			assert.Equal(t, ecs.ReasonResourceMemory, utility.FromStringPtr(out.Failures[0].Reason))
+
+			_, err = c.StopTask(ctx, &awsECS.StopTaskInput{
+				Cluster: aws.String(testutil.ECSClusterName()),
+				Task:    runOut.Tasks[0].TaskArn,
+			})
+			require.NoError(t, err)
+
+			out, err = c.RunTask(ctx, runTaskInput(secondOut.TaskDefinition.TaskDefinitionArn))
+			require.NoError(t, err)
+			assert.Empty(t, out.Failures, "stopped task should free up its capacity")
+			assert.Len(t, out.Tasks, 1)
+		},
+		"ListTaskDefinitionsSortsByFamilyAndRevisionInAscendingOrderByDefault": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			expected := registerFamilies(ctx, t, c, "family-a", "family-b")
+
+			out, err := c.ListTaskDefinitions(ctx, listActiveInput())
+			require.NoError(t, err)
+			require.NotZero(t, out)
+			assert.Equal(t, expected, out.TaskDefinitionArns)
+			assert.Zero(t, out.NextToken)
+		},
+		"ListTaskDefinitionsSortsInDescendingOrder": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			asc := registerFamilies(ctx, t, c, "family-a", "family-b")
+			var expected []string
+			for i := len(asc) - 1; i >= 0; i-- {
+				expected = append(expected, asc[i])
+			}
+
+			in := listActiveInput()
+			in.Sort = types.SortOrderDesc
+			out, err := c.ListTaskDefinitions(ctx, in)
+			require.NoError(t, err)
+			require.NotZero(t, out)
+			assert.Equal(t, expected, out.TaskDefinitionArns)
+		},
+		"ListTaskDefinitionsPaginatesResults": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			expected := registerFamilies(ctx, t, c, "family-a", "family-b", "family-c")
+
+			in := listActiveInput()
+			in.MaxResults = aws.Int32(4)
+			var arns []string
+			var numPages int
+			for {
+				out, err := c.ListTaskDefinitions(ctx, in)
+				require.NoError(t, err)
+				require.NotZero(t, out)
+				assert.LessOrEqual(t, len(out.TaskDefinitionArns), 4)
+				arns = append(arns, out.TaskDefinitionArns...)
+				numPages++
+				if out.NextToken == nil {
+					break
+				}
+				in.NextToken = out.NextToken
+			}
+			assert.Equal(t, 2, numPages)
+			assert.Equal(t, expected, arns)
+		},
+		"ListTaskDefinitionsFailsWithInvalidMaxResults": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			in := listActiveInput()
+			in.MaxResults = aws.Int32(0)
+			out, err := c.ListTaskDefinitions(ctx, in)
+			assert.Error(t, err)
+			assert.Zero(t, out)
+
+			in.MaxResults = aws.Int32(101)
+			out, err = c.ListTaskDefinitions(ctx, in)
+			assert.Error(t, err)
+			assert.Zero(t, out)
+		},
+		"RegisterTaskDefinitionSucceedsWithMaxTags": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			in := testutil.ValidRegisterTaskDefinitionInput(t)
+			in.Tags = makeTags(cocoa.ECSTagConstraints.MaxTags)
+			testutil.RegisterTaskDefinition(ctx, t, c, in)
+
+			assert.Len(t, GlobalECSService.TaskDefs[utility.FromStringPtr(in.Family)][0].Tags, cocoa.ECSTagConstraints.MaxTags)
+		},
+		"RegisterTaskDefinitionFailsWithTooManyTags": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			in := testutil.ValidRegisterTaskDefinitionInput(t)
+			in.Tags = makeTags(cocoa.ECSTagConstraints.MaxTags + 1)
+			out, err := c.RegisterTaskDefinition(ctx, &in)
+			assert.Error(t, err)
+			assert.Zero(t, out)
+			assert.Empty(t, GlobalECSService.TaskDefs[utility.FromStringPtr(in.Family)], "task definition should not be registered")
+		},
+		"RegisterTaskDefinitionCountsDuplicateTagKeysOnce": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			in := testutil.ValidRegisterTaskDefinitionInput(t)
+			in.Tags = append(makeTags(cocoa.ECSTagConstraints.MaxTags), types.Tag{
+				Key:   aws.String("key0"),
+				Value: aws.String("new_value"),
+			})
+			testutil.RegisterTaskDefinition(ctx, t, c, in)
+
+			tags := GlobalECSService.TaskDefs[utility.FromStringPtr(in.Family)][0].Tags
+			assert.Len(t, tags, cocoa.ECSTagConstraints.MaxTags)
+			assert.Equal(t, "new_value", tags["key0"], "last value for a duplicate key should win")
+		},
+		"RegisterTaskDefinitionFailsWithTagMissingKey": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			in := testutil.ValidRegisterTaskDefinitionInput(t)
+			in.Tags = []types.Tag{{Value: aws.String("value")}}
+			out, err := c.RegisterTaskDefinition(ctx, &in)
+			assert.Error(t, err)
+			assert.Zero(t, out)
+		},
+		"RunTaskFailsWithTooLongTagKey": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+
+			in := runTaskInput(registerOut.TaskDefinition.TaskDefinitionArn)
+			in.Tags = []types.Tag{{
+				Key:   aws.String(strings.Repeat("k", cocoa.ECSTagConstraints.MaxKeyLength+1)),
+				Value: aws.String("value"),
+			}}
+			out, err := c.RunTask(ctx, in)
+			assert.Error(t, err)
+			assert.Zero(t, out)
+			assert.Empty(t, GlobalECSService.Clusters[testutil.ECSClusterName()], "task should not run")
+		},
+		"RunTaskFailsWithTooLongTagValue": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+
+			in := runTaskInput(registerOut.TaskDefinition.TaskDefinitionArn)
+			in.Tags = []types.Tag{{
+				Key:   aws.String("key"),
+				Value: aws.String(strings.Repeat("v", cocoa.ECSTagConstraints.MaxValueLength+1)),
+			}}
+			out, err := c.RunTask(ctx, in)
+			assert.Error(t, err)
+			assert.Zero(t, out)
+			assert.Empty(t, GlobalECSService.Clusters[testutil.ECSClusterName()], "task should not run")
+		},
+		"TagResourceFailsWhenExceedingTagLimit": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			in := testutil.ValidRegisterTaskDefinitionInput(t)
+			in.Tags = makeTags(cocoa.ECSTagConstraints.MaxTags)
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, in)
+
+			out, err := c.TagResource(ctx, &awsECS.TagResourceInput{
+				ResourceArn: registerOut.TaskDefinition.TaskDefinitionArn,
+				Tags:        []types.Tag{{Key: aws.String("new_key"), Value: aws.String("value")}},
+			})
+			assert.Error(t, err)
+			assert.Zero(t, out)
+
+			tags := GlobalECSService.TaskDefs[utility.FromStringPtr(in.Family)][0].Tags
+			assert.Len(t, tags, cocoa.ECSTagConstraints.MaxTags)
+			assert.NotContains(t, tags, "new_key")
+		},
+		"TagResourceOverwritesExistingTagKeysAtTagLimit": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			in := testutil.ValidRegisterTaskDefinitionInput(t)
+			in.Tags = makeTags(cocoa.ECSTagConstraints.MaxTags)
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, in)
+
+			_, err := c.TagResource(ctx, &awsECS.TagResourceInput{
+				ResourceArn: registerOut.TaskDefinition.TaskDefinitionArn,
+				Tags:        []types.Tag{{Key: aws.String("key0"), Value: aws.String("new_value")}},
+			})
+			require.NoError(t, err)
+
+			tags := GlobalECSService.TaskDefs[utility.FromStringPtr(in.Family)][0].Tags
+			assert.Len(t, tags, cocoa.ECSTagConstraints.MaxTags)
+			assert.Equal(t, "new_value", tags["key0"])
+		},
+		"TagResourceFailsWithTooManyTagsOnTask": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			in := runTaskInput(registerOut.TaskDefinition.TaskDefinitionArn)
+			in.Tags = makeTags(cocoa.ECSTagConstraints.MaxTags - 1)
+			runOut, err := c.RunTask(ctx, in)
+			require.NoError(t, err)
+			require.Len(t, runOut.Tasks, 1)
+
+			out, err := c.TagResource(ctx, &awsECS.TagResourceInput{
+				ResourceArn: runOut.Tasks[0].TaskArn,
+				Tags: []types.Tag{
+					{Key: aws.String("new_key0"), Value: aws.String("value")},
+					{Key: aws.String("new_key1"), Value: aws.String("value")},
+				},
+			})
+			assert.Error(t, err)
+			assert.Zero(t, out)
+
+			task := GlobalECSService.Clusters[testutil.ECSClusterName()][utility.FromStringPtr(runOut.Tasks[0].TaskArn)]
+			assert.Len(t, task.Tags, cocoa.ECSTagConstraints.MaxTags-1)
+		},
+		"ListTaskDefinitionsFailsWithInvalidNextToken": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			in := listActiveInput()
+			in.NextToken = aws.String("foo")
+			out, err := c.ListTaskDefinitions(ctx, in)
+			assert.Error(t, err)
+			assert.Zero(t, out)
+		},
+	}
+}
+
+// reversingECSClient is an ECS client that describes tasks in the reverse of
+// the requested order.
+type reversingECSClient struct {
+	*ECSClient
+	describeTasksCalls int
+}
+
+func (c *reversingECSClient) DescribeTasks(ctx context.Context, in *awsECS.DescribeTasksInput) (*awsECS.DescribeTasksOutput, error) {
+	c.describeTasksCalls++
+	out, err := c.ECSClient.DescribeTasks(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(out.Tasks)-1; i < j; i, j = i+1, j-1 {
+		out.Tasks[i], out.Tasks[j] = out.Tasks[j], out.Tasks[i]
+	}
+	return out, nil
+}
+
+func TestDescribeAllTasks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// addTasks adds the given number of tasks directly to the cluster and
+	// returns their ARNs.
+	addTasks := func(n int) []string {
+		var arns []string
+		for i := 0; i < n; i++ {
+			arn := fmt.Sprintf("arn:aws:ecs:us-east-1:123456789012:task/%s/%d", testutil.ECSClusterName(), i)
+			GlobalECSService.Clusters[testutil.ECSClusterName()][arn] = ECSTask{ARN: arn}
+			arns = append(arns, arn)
+		}
+		return arns
+	}
+
+	for tName, tCase := range map[string]func(ctx context.Context, t *testing.T, c *reversingECSClient){
+		"DescribesTasksInMultipleRequestsAndPreservesOrder": func(ctx context.Context, t *testing.T, c *reversingECSClient) {
+			arns := addTasks(250)
+
+			out, err := ecs.DescribeAllTasks(ctx, c, testutil.ECSClusterName(), arns)
+			require.NoError(t, err)
+			require.Len(t, out.Tasks, len(arns))
+			for i, task := range out.Tasks {
+				assert.Equal(t, arns[i], utility.FromStringPtr(task.TaskArn))
+			}
+			assert.Empty(t, out.Failures)
+			assert.Equal(t, 3, c.describeTasksCalls)
+			require.NotZero(t, c.DescribeTasksInput)
+			assert.Len(t, c.DescribeTasksInput.Tasks, 50)
+		},
+		"PreservesOrderForTasksRequestedByID": func(ctx context.Context, t *testing.T, c *reversingECSClient) {
+			arns := addTasks(3)
+			ids := []string{arns[0], "1", arns[2]}
+			GlobalECSService.Clusters[testutil.ECSClusterName()]["1"] = ECSTask{ARN: arns[1]}
+
+			out, err := ecs.DescribeAllTasks(ctx, c, testutil.ECSClusterName(), ids)
+			require.NoError(t, err)
+			require.Len(t, out.Tasks, len(arns))
+			for i, task := range out.Tasks {
+				assert.Equal(t, arns[i], utility.FromStringPtr(task.TaskArn))
+			}
+		},
+		"MergesFailuresInOrder": func(ctx context.Context, t *testing.T, c *reversingECSClient) {
+			arns := addTasks(150)
+			missing := []string{"missing0", "missing1"}
+			in := append([]string{missing[0]}, arns...)
+			in = append(in, missing[1])
+
+			out, err := ecs.DescribeAllTasks(ctx, c, testutil.ECSClusterName(), in)
+			require.NoError(t, err)
+			assert.Len(t, out.Tasks, len(arns))
+			require.Len(t, out.Failures, 2)
+			assert.Equal(t, missing[0], utility.FromStringPtr(out.Failures[0].Arn))
+			assert.Equal(t, missing[1], utility.FromStringPtr(out.Failures[1].Arn))
+		},
+		"SucceedsWithoutAnyTasks": func(ctx context.Context, t *testing.T, c *reversingECSClient) {
+			out, err := ecs.DescribeAllTasks(ctx, c, testutil.ECSClusterName(), nil)
+			require.NoError(t, err)
+			assert.Empty(t, out.Tasks)
+			assert.Empty(t, out.Failures)
+			assert.Zero(t, c.describeTasksCalls)
+		},
+		"FailsWhenDescribeTasksFails": func(ctx context.Context, t *testing.T, c *reversingECSClient) {
+			arns := addTasks(1)
+			c.DescribeTasksError = errors.New("fake error")
+
+			out, err := ecs.DescribeAllTasks(ctx, c, testutil.ECSClusterName(), arns)
+			assert.Error(t, err)
+			assert.Zero(t, out)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			resetECSAndSecretsManagerCache()
+
+			tCase(tctx, t, &reversingECSClient{ECSClient: &ECSClient{}})
+		})
+	}
 }
@@ -4,10 +4,13 @@ import (
 	"context"
 
 	"github.com/evergreen-ci/cocoa"
+	"github.com/pkg/errors"
 )
 
 // ECSPod provides a mock implementation of a cocoa.ECSPod backed by another ECS
-// pod implementation.
+// pod implementation. It also implements the optional pod interfaces (e.g.
+// cocoa.ECSPodExporter), which fail by default if the backing ECS pod does not
+// support them.
 type ECSPod struct {
 	cocoa.ECSPod
 
@@ -18,8 +21,14 @@ type ECSPod struct {
 	LatestStatusInfoOutput *cocoa.ECSPodStatusInfo
 	LatestStatusInfoError  error
 
+	PendingDiagnosisOutput *cocoa.ECSPodPendingDiagnosis
+	PendingDiagnosisError  error
+
+	ExportOutput *cocoa.ECSPodState
+
 	StopError error
 
+	DeleteInput []cocoa.ECSPodDeletionOptions
 	DeleteError error
 }
 
@@ -52,6 +61,22 @@ func (p *ECSPod) LatestStatusInfo(ctx context.Context) (*cocoa.ECSPodStatusInfo,
 	return p.ECSPod.LatestStatusInfo(ctx)
 }
 
+// PendingDiagnosis returns the mock diagnosis of why the pod is pending. The
+// mock output can be customized. By default, it will return the result of the
+// backing ECS pod.
+func (p *ECSPod) PendingDiagnosis(ctx context.Context) (*cocoa.ECSPodPendingDiagnosis, error) {
+	if p.PendingDiagnosisOutput != nil || p.PendingDiagnosisError != nil {
+		return p.PendingDiagnosisOutput, p.PendingDiagnosisError
+	}
+
+	diagnoser, ok := p.ECSPod.(cocoa.ECSPodPendingDiagnoser)
+	if !ok {
+		return nil, errors.New("backing pod does not support pending diagnosis")
+	}
+
+	return diagnoser.PendingDiagnosis(ctx)
+}
+
 // Resources returns mock resource information about the pod. The mock output
 // can be customized. By default, it will return the result of the backing ECS
 // pod.
@@ -63,6 +88,22 @@ func (p *ECSPod) Resources() cocoa.ECSPodResources {
 	return p.ECSPod.Resources()
 }
 
+// Export returns a mock snapshot of the pod's state. The mock output can be
+// customized. By default, it will return the result of the backing ECS pod, or
+// an empty state if the backing ECS pod cannot be exported.
+func (p *ECSPod) Export() cocoa.ECSPodState {
+	if p.ExportOutput != nil {
+		return *p.ExportOutput
+	}
+
+	exporter, ok := p.ECSPod.(cocoa.ECSPodExporter)
+	if !ok {
+		return cocoa.ECSPodState{}
+	}
+
+	return exporter.Export()
+}
+
 // Stop stops the mock pod. The mock output can be customized. By default, it
 // will return the result of stopping the backing ECS pod.
 func (p *ECSPod) Stop(ctx context.Context) error {
@@ -77,9 +118,30 @@ func (p *ECSPod) Stop(ctx context.Context) error {
 // output can be customized. By default, it will return the result of the
 // deleting the backing ECS pod.
 func (p *ECSPod) Delete(ctx context.Context) error {
+	p.DeleteInput = nil
+
 	if p.DeleteError != nil {
 		return p.DeleteError
 	}
 
 	return p.ECSPod.Delete(ctx)
 }
+
+// DeleteWithOptions deletes the mock pod and all of its underlying resources
+// using the given deletion options. The mock output can be customized. By
+// default, it will return the result of deleting the backing ECS pod with the
+// options.
+func (p *ECSPod) DeleteWithOptions(ctx context.Context, opts ...cocoa.ECSPodDeletionOptions) error {
+	p.DeleteInput = opts
+
+	if p.DeleteError != nil {
+		return p.DeleteError
+	}
+
+	deleter, ok := p.ECSPod.(cocoa.ECSPodProtectedDeleter)
+	if !ok {
+		return errors.New("backing pod does not support deletion options")
+	}
+
+	return deleter.DeleteWithOptions(ctx, opts...)
+}
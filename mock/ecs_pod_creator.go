@@ -18,6 +18,15 @@ type ECSPodCreator struct {
 	CreatePodFromExistingDefinitionInput  []cocoa.ECSPodExecutionOptions
 	CreatePodFromExistingDefinitionOutput *cocoa.ECSPod
 	CreatePodFromExistingDefinitionError  error
+
+	CreatePodIdempotentKeyInput *string
+	CreatePodIdempotentInput    []cocoa.ECSPodCreationOptions
+	CreatePodIdempotentOutput   *cocoa.ECSPod
+	CreatePodIdempotentError    error
+
+	PrewarmDefinitionsInput  []cocoa.ECSPodDefinitionOptions
+	PrewarmDefinitionsOutput []cocoa.ECSPodDefinitionItem
+	PrewarmDefinitionsError  error
 }
 
 // NewECSPodCreator creates a mock ECS pod creator backed by the given pod
@@ -57,3 +66,34 @@ func (m *ECSPodCreator) CreatePodFromExistingDefinition(ctx context.Context, def
 
 	return m.ECSPodCreator.CreatePodFromExistingDefinition(ctx, def, opts...)
 }
+
+// CreatePodIdempotent saves the input and returns a new or existing mock pod.
+// The mock output can be customized. By default, it will return the result of
+// idempotently creating the pod in the backing ECS pod creator.
+func (m *ECSPodCreator) CreatePodIdempotent(ctx context.Context, key string, opts ...cocoa.ECSPodCreationOptions) (cocoa.ECSPod, error) {
+	m.CreatePodIdempotentKeyInput = &key
+	m.CreatePodIdempotentInput = opts
+
+	if m.CreatePodIdempotentOutput != nil {
+		return *m.CreatePodIdempotentOutput, m.CreatePodIdempotentError
+	} else if m.CreatePodIdempotentError != nil {
+		return nil, m.CreatePodIdempotentError
+	}
+
+	return m.ECSPodCreator.CreatePodIdempotent(ctx, key, opts...)
+}
+
+// PrewarmDefinitions saves the input and returns the prewarmed pod definition
+// items. The mock output can be customized. By default, it will return the
+// result of prewarming the pod definitions in the backing ECS pod creator.
+func (m *ECSPodCreator) PrewarmDefinitions(ctx context.Context, defs []cocoa.ECSPodDefinitionOptions) ([]cocoa.ECSPodDefinitionItem, error) {
+	m.PrewarmDefinitionsInput = defs
+
+	if m.PrewarmDefinitionsOutput != nil {
+		return m.PrewarmDefinitionsOutput, m.PrewarmDefinitionsError
+	} else if m.PrewarmDefinitionsError != nil {
+		return nil, m.PrewarmDefinitionsError
+	}
+
+	return m.ECSPodCreator.PrewarmDefinitions(ctx, defs)
+}
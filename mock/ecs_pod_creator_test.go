@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsECS "github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/evergreen-ci/cocoa"
@@ -115,10 +119,496 @@ func TestECSPodCreator(t *testing.T) {
 	}
 }
 
+func TestPrewarmDefinitions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer resetECSAndSecretsManagerCache()
+
+	makeDefOpts := func(t *testing.T) cocoa.ECSPodDefinitionOptions {
+		envVar := cocoa.NewEnvironmentVariable().
+			SetName("envVar").
+			SetSecretOptions(*cocoa.NewSecretOptions().
+				SetName(testutil.NewSecretName(t)).
+				SetNewValue("value").
+				SetOwned(true))
+		containerDef := cocoa.NewECSContainerDefinition().
+			SetName("container").
+			SetImage("image").
+			SetMemoryMB(128).
+			SetCPU(128).
+			AddEnvironmentVariables(*envVar)
+		return *cocoa.NewECSPodDefinitionOptions().
+			SetName(testutil.NewTaskDefinitionFamily(t)).
+			AddContainerDefinitions(*containerDef)
+	}
+	execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+
+	for tName, tCase := range map[string]func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient){
+		"RegistersEachDefinition": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			defs := []cocoa.ECSPodDefinitionOptions{makeDefOpts(t), makeDefOpts(t)}
+			items, err := pc.PrewarmDefinitions(ctx, defs)
+			require.NoError(t, err)
+			require.Len(t, items, len(defs))
+			for i, item := range items {
+				assert.NotZero(t, item.ID)
+				assert.Equal(t, defs[i].Name, item.DefinitionOpts.Name, "items should be in the same order as the definitions")
+				_, ok := GlobalECSService.TaskDefs[utility.FromStringPtr(defs[i].Name)]
+				assert.True(t, ok, "should have registered the definition")
+			}
+			assert.NotEqual(t, items[0].ID, items[1].ID)
+		},
+		"RegistersDuplicateDefinitionsOnce": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			def := makeDefOpts(t)
+			items, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{def, def})
+			require.NoError(t, err)
+			require.Len(t, items, 2)
+			assert.Equal(t, items[0], items[1])
+			assert.Len(t, GlobalECSService.TaskDefs[utility.FromStringPtr(def.Name)], 1)
+		},
+		"ReusesAlreadyPrewarmedDefinition": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			def := makeDefOpts(t)
+			first, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{def})
+			require.NoError(t, err)
+
+			c.RegisterTaskDefinitionInput = nil
+			second, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{def})
+			require.NoError(t, err)
+			assert.Equal(t, first, second)
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not have registered the definition again")
+		},
+		"CreatePodUsesPrewarmedDefinition": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			def := makeDefOpts(t)
+			items, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{def})
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+
+			c.RegisterTaskDefinitionInput = nil
+			p, err := pc.CreatePod(ctx, *cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(def).
+				SetExecutionOptions(*execOpts))
+			require.NoError(t, err)
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not have registered a new definition")
+
+			res := p.Resources()
+			require.NotZero(t, res.TaskDefinition)
+			assert.Equal(t, items[0].ID, utility.FromStringPtr(res.TaskDefinition.ID))
+			assert.False(t, utility.FromBoolPtr(res.TaskDefinition.Owned), "pod should not own the shared definition")
+			require.Len(t, res.Containers, 1)
+			require.Len(t, res.Containers[0].Secrets, 1)
+			assert.False(t, utility.FromBoolPtr(res.Containers[0].Secrets[0].Owned), "pod should not own the shared secret")
+
+			require.NoError(t, p.Delete(ctx))
+			_, err = pc.CreatePod(ctx, *cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(def).
+				SetExecutionOptions(*execOpts))
+			assert.NoError(t, err, "deleting a pod should not clean up the shared definition")
+		},
+		"CreatePodWithDifferentDefinitionRegistersNewDefinition": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			_, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{makeDefOpts(t)})
+			require.NoError(t, err)
+
+			c.RegisterTaskDefinitionInput = nil
+			p, err := pc.CreatePod(ctx, *cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(makeDefOpts(t)).
+				SetExecutionOptions(*execOpts))
+			require.NoError(t, err)
+			assert.NotZero(t, c.RegisterTaskDefinitionInput)
+			assert.True(t, utility.FromBoolPtr(p.Resources().TaskDefinition.Owned))
+		},
+		"FailsWithDeferredSecrets": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			def := makeDefOpts(t)
+			def.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("deferred").
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(testutil.NewSecretName(t)).
+					SetDeferred(true)))
+			valid := makeDefOpts(t)
+
+			items, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{def, valid})
+			assert.Error(t, err)
+			require.Len(t, items, 2)
+			assert.Zero(t, items[0])
+			assert.NotZero(t, items[1].ID, "definition without deferred secrets should still be prewarmed")
+			_, ok := GlobalECSService.TaskDefs[utility.FromStringPtr(def.Name)]
+			assert.False(t, ok, "should not have registered the definition with deferred secrets")
+		},
+		"CreatePodWithPrewarmedDefinitionFailsWithDeferredSecretValues": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			def := makeDefOpts(t)
+			_, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{def})
+			require.NoError(t, err)
+
+			p, err := pc.CreatePod(ctx, *cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(def).
+				SetExecutionOptions(*cocoa.NewECSPodExecutionOptions().
+					SetCluster(testutil.ECSClusterName()).
+					AddDeferredSecretValue("envVar", "value")))
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.Zero(t, c.RunTaskInput, "should not run the pod")
+		},
+		"CreatePodWithDefinitionDifferingInExcludedTagUsesPrewarmedDefinition": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			def := makeDefOpts(t)
+			def.SetTags(map[string]string{"job_id": "prewarmed"})
+			items, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{def})
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+
+			c.RegisterTaskDefinitionInput = nil
+			def.SetTags(map[string]string{"job_id": "other"})
+			p, err := pc.CreatePod(ctx, *cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(def).
+				SetExecutionOptions(*execOpts))
+			require.NoError(t, err)
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not have registered a new definition")
+			assert.Equal(t, items[0].ID, utility.FromStringPtr(p.Resources().TaskDefinition.ID))
+		},
+		"PrewarmsDefinitionsDifferingInExcludedTagOnce": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			def := makeDefOpts(t)
+			other := def
+			def.Tags = cocoa.NewTags().Set("job_id", "first")
+			other.Tags = cocoa.NewTags().Set("job_id", "second")
+
+			items, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{def, other})
+			require.NoError(t, err)
+			require.Len(t, items, 2)
+			assert.Equal(t, items[0].ID, items[1].ID)
+			assert.Len(t, GlobalECSService.TaskDefs[utility.FromStringPtr(def.Name)], 1)
+		},
+		"CreatePodWithEquivalentNormalizedDefinitionUsesPrewarmedDefinition": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			def := makeDefOpts(t)
+			def.ContainerDefinitions[0].SetImage("Registry.Example.com/image")
+			items, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{def})
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+
+			c.RegisterTaskDefinitionInput = nil
+			equivalent := def
+			equivalent.ContainerDefinitions = []cocoa.ECSContainerDefinition{def.ContainerDefinitions[0]}
+			equivalent.ContainerDefinitions[0].SetImage("registry.example.com/image")
+			p, err := pc.CreatePod(ctx, *cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(equivalent).
+				SetExecutionOptions(*execOpts))
+			require.NoError(t, err)
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not have registered a new definition")
+			assert.Equal(t, items[0].ID, utility.FromStringPtr(p.Resources().TaskDefinition.ID))
+		},
+		"FailsWithInvalidDefinition": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			valid := makeDefOpts(t)
+			items, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{*cocoa.NewECSPodDefinitionOptions(), valid})
+			assert.Error(t, err)
+			require.Len(t, items, 2)
+			assert.Zero(t, items[0])
+			assert.NotZero(t, items[1].ID, "valid definition should still be prewarmed")
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			resetECSAndSecretsManagerCache()
+
+			c := &ECSClient{}
+			sm := &SecretsManagerClient{}
+			v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(sm))
+			require.NoError(t, err)
+
+			// The mock clients are not safe for concurrent use, so the
+			// definitions have to be prewarmed one at a time. The job ID tag
+			// is excluded from the hash and image hosts are normalized so
+			// that equivalent pod definitions can share a prewarmed
+			// definition.
+			pc, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetVault(NewVault(v)).
+				SetPrewarmConcurrency(1).
+				SetHashOptions(*cocoa.NewHashOptions().AddExcludeTagKeys("job_id")).
+				AddNormalizers(cocoa.LowercaseImageHostsNormalizer()))
+			require.NoError(t, err)
+
+			tCase(tctx, t, NewECSPodCreator(pc), c)
+		})
+	}
+}
+
 // ecsPodCreatorTests are mock-specific tests for ECS and Secrets Manager with
 // the ECS pod creator.
 func ecsPodCreatorTests() map[string]func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+	makeIdempotentOpts := func(t *testing.T) cocoa.ECSPodCreationOptions {
+		containerDef := cocoa.NewECSContainerDefinition().
+			SetName("container_name").
+			SetImage("image").
+			SetMemoryMB(128).
+			SetCPU(128)
+		defOpts := cocoa.NewECSPodDefinitionOptions().
+			SetName(testutil.NewTaskDefinitionFamily(t)).
+			AddContainerDefinitions(*containerDef)
+		execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+		return *cocoa.NewECSPodCreationOptions().
+			SetDefinitionOptions(*defOpts).
+			SetExecutionOptions(*execOpts)
+	}
+
 	return map[string]func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient){
+		"CreatePodIdempotentReturnsExistingRunningPodForSameKey": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+
+			first, err := pc.CreatePodIdempotent(ctx, "key", opts)
+			require.NoError(t, err)
+			require.NotZero(t, first)
+
+			require.NotZero(t, pdc.PutRunInput, "should have recorded the pod run")
+			require.NotZero(t, pdc.PutRunInput.LastRun)
+			assert.Equal(t, "key", pdc.PutRunInput.LastRun.Key)
+			assert.Equal(t, utility.FromStringPtr(first.Resources().TaskID), pdc.PutRunInput.LastRun.TaskID)
+			assert.Equal(t, utility.FromStringPtr(first.Resources().TaskDefinition.ID), pdc.PutRunInput.ID)
+
+			c.RunTaskInput = nil
+			second, err := pc.CreatePodIdempotent(ctx, "key", opts)
+			require.NoError(t, err)
+			require.NotZero(t, second)
+			assert.Zero(t, c.RunTaskInput, "should not have run a duplicate task")
+			assert.Equal(t, first.Resources().TaskID, second.Resources().TaskID)
+			assert.Equal(t, first.Resources().TaskDefinition, second.Resources().TaskDefinition)
+			assert.Len(t, GlobalECSService.Clusters[testutil.ECSClusterName()], 1)
+		},
+		"CreatePodIdempotentCreatesSeparatePodsForDifferentKeys": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+
+			first, err := pc.CreatePodIdempotent(ctx, "key0", opts)
+			require.NoError(t, err)
+			second, err := pc.CreatePodIdempotent(ctx, "key1", opts)
+			require.NoError(t, err)
+
+			assert.NotEqual(t, utility.FromStringPtr(first.Resources().TaskID), utility.FromStringPtr(second.Resources().TaskID))
+			assert.Len(t, GlobalECSService.Clusters[testutil.ECSClusterName()], 2)
+		},
+		"CreatePodIdempotentCreatesNewPodAfterPreviousPodStops": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+
+			first, err := pc.CreatePodIdempotent(ctx, "key", opts)
+			require.NoError(t, err)
+			require.NoError(t, first.Stop(ctx))
+
+			second, err := pc.CreatePodIdempotent(ctx, "key", opts)
+			require.NoError(t, err)
+			assert.NotEqual(t, utility.FromStringPtr(first.Resources().TaskID), utility.FromStringPtr(second.Resources().TaskID))
+
+			require.NotZero(t, pdc.PutRunInput)
+			require.NotZero(t, pdc.PutRunInput.LastRun)
+			assert.Equal(t, utility.FromStringPtr(second.Resources().TaskID), pdc.PutRunInput.LastRun.TaskID, "should have recorded the new pod run")
+		},
+		"CreatePodIdempotentCreatesNewPodWhenPreviousTaskIsMissing": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+
+			first, err := pc.CreatePodIdempotent(ctx, "key", opts)
+			require.NoError(t, err)
+			delete(GlobalECSService.Clusters[testutil.ECSClusterName()], utility.FromStringPtr(first.Resources().TaskID))
+
+			second, err := pc.CreatePodIdempotent(ctx, "key", opts)
+			require.NoError(t, err)
+			assert.NotEqual(t, utility.FromStringPtr(first.Resources().TaskID), utility.FromStringPtr(second.Resources().TaskID))
+		},
+		"CreatePodIdempotentCreatesOnePodForConcurrentCallsWithSameKey": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			// The mock pod creator records its inputs, so the pod creator it
+			// wraps is called directly to avoid racing on them.
+			basicCreator, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetCache(pdc))
+			require.NoError(t, err)
+			opts := makeIdempotentOpts(t)
+
+			const numCalls = 10
+			var wg sync.WaitGroup
+			taskIDs := make(chan string, numCalls)
+			errs := make(chan error, numCalls)
+			for i := 0; i < numCalls; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					p, err := basicCreator.CreatePodIdempotent(ctx, "key", opts)
+					if err != nil {
+						errs <- err
+						return
+					}
+					taskIDs <- utility.FromStringPtr(p.Resources().TaskID)
+				}()
+			}
+			wg.Wait()
+			close(taskIDs)
+			close(errs)
+
+			for err := range errs {
+				require.NoError(t, err)
+			}
+			var ids []string
+			for id := range taskIDs {
+				ids = append(ids, id)
+			}
+			require.Len(t, ids, numCalls)
+			for _, id := range ids {
+				assert.Equal(t, ids[0], id, "all calls should return the same pod")
+			}
+			assert.Len(t, GlobalECSService.Clusters[testutil.ECSClusterName()], 1, "should have only run one task")
+		},
+		"NewPodCreatorFailsWithVaultThatEncryptsValues": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			v := NewVault(nil)
+			v.EncryptsValuesOutput = aws.Bool(true)
+
+			encryptingCreator, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetVault(v))
+			assert.Error(t, err, "ECS should not be given encrypted secret values")
+			assert.Zero(t, encryptingCreator)
+		},
+		"CreatePodIdempotentFailsWithCacheThatDoesNotTrackRuns": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			noRunsCreator, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetCache(&testutil.NoopECSPodDefinitionCache{Tag: "cache-tag"}))
+			require.NoError(t, err)
+
+			p, err := noRunsCreator.CreatePodIdempotent(ctx, "key", makeIdempotentOpts(t))
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.Zero(t, c.RunTaskInput)
+		},
+		"CreatePodIdempotentFailsWithEmptyKey": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			p, err := pc.CreatePodIdempotent(ctx, "", makeIdempotentOpts(t))
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.Zero(t, c.RunTaskInput)
+		},
+		"CreatePodIdempotentFailsWhenGettingRunFails": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			pdc.GetRunError = errors.New("fake error")
+
+			p, err := pc.CreatePodIdempotent(ctx, "key", makeIdempotentOpts(t))
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.Zero(t, c.RunTaskInput, "should not run a task without checking for an existing pod")
+		},
+		"CreatePodIdempotentDeletesPodWhenRecordingRunFails": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			pdc.PutRunError = errors.New("fake error")
+
+			p, err := pc.CreatePodIdempotent(ctx, "key", makeIdempotentOpts(t))
+			assert.Error(t, err)
+			assert.Zero(t, p)
+
+			require.NotZero(t, c.StopTaskInput, "should have stopped the untracked pod")
+			task, ok := GlobalECSService.Clusters[testutil.ECSClusterName()][utility.FromStringPtr(c.StopTaskInput.Task)]
+			require.True(t, ok)
+			assert.EqualValues(t, types.DesiredStatusStopped, task.Status)
+		},
+		"CreatePodIdempotentFromPrewarmedDefinitionDoesNotCleanUpSharedResources": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			secretName := testutil.NewSecretName(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("envVar").
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(secretName).
+					SetNewValue("value").
+					SetOwned(true)))
+
+			items, err := pc.PrewarmDefinitions(ctx, []cocoa.ECSPodDefinitionOptions{opts.DefinitionOpts})
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+			assert.True(t, items[0].Prewarmed)
+
+			first, err := pc.CreatePodIdempotent(ctx, "key", opts)
+			require.NoError(t, err)
+			require.NotZero(t, pdc.PutRunInput)
+			assert.True(t, pdc.PutRunInput.Prewarmed, "pod run should record that it used a prewarmed definition")
+
+			second, err := pc.CreatePodIdempotent(ctx, "key", opts)
+			require.NoError(t, err)
+			assert.Equal(t, first.Resources().TaskID, second.Resources().TaskID)
+			res := second.Resources()
+			require.NotZero(t, res.TaskDefinition)
+			assert.Equal(t, items[0].ID, utility.FromStringPtr(res.TaskDefinition.ID))
+			assert.False(t, utility.FromBoolPtr(res.TaskDefinition.Owned), "existing pod should not own the shared definition")
+			require.Len(t, res.Containers, 1)
+			require.Len(t, res.Containers[0].Secrets, 1)
+			assert.False(t, utility.FromBoolPtr(res.Containers[0].Secrets[0].Owned), "existing pod should not own the shared secret")
+
+			require.NoError(t, second.Delete(ctx))
+
+			defs := GlobalECSService.TaskDefs[utility.FromStringPtr(opts.DefinitionOpts.Name)]
+			require.Len(t, defs, 1)
+			assert.Equal(t, string(types.TaskDefinitionStatusActive), utility.FromStringPtr(defs[0].Status), "shared definition should not be deregistered")
+			s, ok := GlobalSecretCache[secretName]
+			require.True(t, ok)
+			assert.False(t, s.IsDeleted, "shared secret should not be deleted")
+
+			third, err := pc.CreatePodIdempotent(ctx, "key", opts)
+			require.NoError(t, err)
+			assert.NotEqual(t, first.Resources().TaskID, third.Resources().TaskID)
+			assert.Equal(t, items[0].ID, utility.FromStringPtr(third.Resources().TaskDefinition.ID), "new pod should still use the shared definition")
+		},
+		"CreatePodJoinsGroupOfExistingPodWithDistinctInstances": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			makeOpts := func(placementOpts cocoa.ECSPodPlacementOptions) cocoa.ECSPodCreationOptions {
+				containerDef := cocoa.NewECSContainerDefinition().
+					SetName("container_name").
+					SetImage("image").
+					SetMemoryMB(128).
+					SetCPU(128)
+				defOpts := cocoa.NewECSPodDefinitionOptions().
+					SetName(testutil.NewTaskDefinitionFamily(t)).
+					AddContainerDefinitions(*containerDef)
+				execOpts := cocoa.NewECSPodExecutionOptions().
+					SetCluster(testutil.ECSClusterName()).
+					SetPlacementOptions(placementOpts)
+				return *cocoa.NewECSPodCreationOptions().
+					SetDefinitionOptions(*defOpts).
+					SetExecutionOptions(*execOpts)
+			}
+
+			first, err := pc.CreatePod(ctx, makeOpts(*cocoa.NewECSPodPlacementOptions().
+				SetGroup("group").
+				AddInstanceFilters(cocoa.ConstraintDistinctInstance)))
+			require.NoError(t, err)
+			assert.Equal(t, "group", utility.FromStringPtr(first.Resources().Group))
+
+			for i := 0; i < 3; i++ {
+				p, err := pc.CreatePod(ctx, makeOpts(*cocoa.NewECSPodPlacementOptions().
+					SetGroupFromPod(first).
+					AddInstanceFilters(cocoa.ConstraintDistinctInstance)))
+				require.NoError(t, err)
+				assert.Equal(t, "group", utility.FromStringPtr(p.Resources().Group))
+
+				require.NotZero(t, c.RunTaskInput)
+				assert.Equal(t, "group", utility.FromStringPtr(c.RunTaskInput.Group))
+				require.Len(t, c.RunTaskInput.PlacementConstraints, 1)
+				assert.EqualValues(t, cocoa.ConstraintDistinctInstance, c.RunTaskInput.PlacementConstraints[0].Type)
+				assert.Zero(t, c.RunTaskInput.PlacementConstraints[0].Expression)
+			}
+		},
+		"CreatePodJoinsDefaultFamilyGroupOfExistingPod": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			containerDef := cocoa.NewECSContainerDefinition().
+				SetName("container_name").
+				SetImage("image").
+				SetMemoryMB(128).
+				SetCPU(128)
+			defOpts := cocoa.NewECSPodDefinitionOptions().
+				SetName(testutil.NewTaskDefinitionFamily(t)).
+				AddContainerDefinitions(*containerDef)
+			execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+			opts := cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*execOpts)
+
+			first, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+			expectedGroup := "family:" + utility.FromStringPtr(defOpts.Name)
+			assert.Equal(t, expectedGroup, utility.FromStringPtr(first.Resources().Group))
+
+			execOpts.SetPlacementOptions(*cocoa.NewECSPodPlacementOptions().
+				SetGroupFromPod(first).
+				AddInstanceFilters(cocoa.ConstraintDistinctInstance))
+			opts.SetExecutionOptions(*execOpts)
+			_, err = pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+			require.NotZero(t, c.RunTaskInput)
+			assert.Equal(t, expectedGroup, utility.FromStringPtr(c.RunTaskInput.Group))
+		},
 		"CreatePodRegistersTaskDefinitionAndRunsTaskWithAllFieldsSetAndSendsExpectedInput": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
 			envVar := cocoa.NewEnvironmentVariable().
 				SetName("env_var_name").
@@ -186,110 +676,1146 @@ func ecsPodCreatorTests() map[string]func(ctx context.Context, t *testing.T, pc
 
 			// Verify RegisterTaskDefinition inputs.
 
-			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+
+			mem, err := strconv.Atoi(utility.FromStringPtr(c.RegisterTaskDefinitionInput.Memory))
+			require.NoError(t, err)
+			assert.Equal(t, utility.FromIntPtr(podDefOpts.MemoryMB), mem)
+
+			cpu, err := strconv.Atoi(utility.FromStringPtr(c.RegisterTaskDefinitionInput.Cpu))
+			require.NoError(t, err)
+			assert.Equal(t, utility.FromIntPtr(podDefOpts.CPU), cpu)
+
+			require.NotZero(t, podDefOpts.NetworkMode)
+			assert.EqualValues(t, *podDefOpts.NetworkMode, c.RegisterTaskDefinitionInput.NetworkMode)
+
+			assert.Equal(t, utility.FromStringPtr(podDefOpts.TaskRole), utility.FromStringPtr(c.RegisterTaskDefinitionInput.TaskRoleArn))
+			assert.Equal(t, utility.FromStringPtr(podDefOpts.ExecutionRole), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ExecutionRoleArn))
+
+			assert.Len(t, c.RegisterTaskDefinitionInput.Tags, 3, "should have user-defined tag, cache tracking tag, and hash tag")
+			for _, tag := range c.RegisterTaskDefinitionInput.Tags {
+				key := utility.FromStringPtr(tag.Key)
+				switch key {
+				case "creation_tag":
+					assert.Equal(t, podDefOpts.Tags["creation_tag"], utility.FromStringPtr(tag.Value), "user-defined tag should be defined")
+				case pdc.GetTag():
+					assert.Equal(t, "false", utility.FromStringPtr(tag.Value), "cache tag should initially mark pod definition as uncached before caching")
+				case cocoa.PodDefinitionHashTagKey:
+					assert.NotZero(t, utility.FromStringPtr(tag.Value), "hash tag should record the hash of the pod definition")
+				default:
+					assert.FailNow(t, "unrecognized tag", "unexpected tag '%s'", key)
+				}
+			}
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			assert.Equal(t, containerDef.Command, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Command)
+			assert.Equal(t, utility.FromStringPtr(containerDef.WorkingDir), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].WorkingDirectory))
+			assert.EqualValues(t, utility.FromIntPtr(containerDef.MemoryMB), utility.FromInt32Ptr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Memory))
+			assert.EqualValues(t, utility.FromIntPtr(containerDef.CPU), c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Cpu)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Environment, 1)
+			assert.Equal(t, utility.FromStringPtr(envVar.Name), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Environment[0].Name))
+			assert.Equal(t, utility.FromStringPtr(envVar.Value), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Environment[0].Value))
+			assert.EqualValues(t, utility.FromStringPtr(containerDef.LogConfiguration.LogDriver), c.RegisterTaskDefinitionInput.ContainerDefinitions[0].LogConfiguration.LogDriver)
+			for k, v := range containerDef.LogConfiguration.Options {
+				assert.Equal(t, v, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].LogConfiguration.Options[k])
+			}
+
+			// Verify RunTask inputs.
+
+			require.NotZero(t, c.RunTaskInput)
+			assert.Equal(t, utility.FromStringPtr(execOpts.Cluster), utility.FromStringPtr(c.RunTaskInput.Cluster))
+
+			require.Len(t, c.RunTaskInput.CapacityProviderStrategy, 1)
+			assert.Equal(t, utility.FromStringPtr(execOpts.CapacityProvider), utility.FromStringPtr(c.RunTaskInput.CapacityProviderStrategy[0].CapacityProvider))
+
+			require.NotZero(t, c.RunTaskInput.Overrides)
+
+			mem, err = strconv.Atoi(utility.FromStringPtr(c.RunTaskInput.Overrides.Memory))
+			require.NoError(t, err)
+			assert.Equal(t, utility.FromIntPtr(overridePodDefOpts.MemoryMB), mem)
+
+			cpu, err = strconv.Atoi(utility.FromStringPtr(c.RunTaskInput.Overrides.Cpu))
+			require.NoError(t, err)
+			assert.Equal(t, utility.FromIntPtr(overridePodDefOpts.CPU), cpu)
+
+			assert.Equal(t, utility.FromStringPtr(overridePodDefOpts.TaskRole), utility.FromStringPtr(c.RunTaskInput.Overrides.TaskRoleArn))
+			assert.Equal(t, utility.FromStringPtr(overridePodDefOpts.ExecutionRole), utility.FromStringPtr(c.RunTaskInput.Overrides.ExecutionRoleArn))
+
+			require.Len(t, c.RunTaskInput.Overrides.ContainerOverrides, 1)
+			containerOverride := c.RunTaskInput.Overrides.ContainerOverrides[0]
+			assert.Equal(t, overrideContainerDef.Command, containerOverride.Command)
+			assert.EqualValues(t, utility.FromIntPtr(overrideContainerDef.MemoryMB), utility.FromInt32Ptr(containerOverride.Memory))
+			assert.EqualValues(t, utility.FromIntPtr(overrideContainerDef.CPU), utility.FromInt32Ptr(containerOverride.Cpu))
+			require.Len(t, containerOverride.Environment, 1)
+			assert.Equal(t, utility.FromStringPtr(overrideEnvVar.Name), utility.FromStringPtr(containerOverride.Environment[0].Name))
+			assert.Equal(t, utility.FromStringPtr(overrideEnvVar.Value), utility.FromStringPtr(containerOverride.Environment[0].Value))
+
+			assert.Equal(t, utility.FromStringPtr(placementOpts.Group), utility.FromStringPtr(c.RunTaskInput.Group))
+			require.Len(t, c.RunTaskInput.PlacementStrategy, 1)
+			assert.EqualValues(t, *placementOpts.Strategy, c.RunTaskInput.PlacementStrategy[0].Type)
+			assert.Equal(t, utility.FromStringPtr(placementOpts.StrategyParameter), utility.FromStringPtr(c.RunTaskInput.PlacementStrategy[0].Field))
+			require.Len(t, c.RunTaskInput.PlacementConstraints, 2)
+			assert.EqualValues(t, "memberOf", c.RunTaskInput.PlacementConstraints[0].Type)
+			assert.Equal(t, placementOpts.InstanceFilters[0], utility.FromStringPtr(c.RunTaskInput.PlacementConstraints[0].Expression))
+			assert.EqualValues(t, cocoa.ConstraintDistinctInstance, c.RunTaskInput.PlacementConstraints[1].Type)
+			assert.Zero(t, c.RunTaskInput.PlacementConstraints[1].Expression)
+
+			require.NotZero(t, c.RunTaskInput.NetworkConfiguration)
+			require.NotZero(t, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration)
+			assert.ElementsMatch(t, execOpts.AWSVPCOpts.Subnets, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.Subnets)
+			assert.ElementsMatch(t, execOpts.AWSVPCOpts.SecurityGroups, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.SecurityGroups)
+
+			require.Len(t, c.RunTaskInput.Tags, 1)
+			assert.Equal(t, "execution_tag", utility.FromStringPtr(c.RunTaskInput.Tags[0].Key))
+			assert.Equal(t, execOpts.Tags["execution_tag"], utility.FromStringPtr(c.RunTaskInput.Tags[0].Value))
+
+			assert.True(t, c.RunTaskInput.EnableExecuteCommand)
+		},
+		"CreatePodSendsLifecycleEvents": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(sm))
+			require.NoError(t, err)
+			sink := NewEventSink()
+			eventPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetVault(v).
+				SetEventSink(sink))
+			require.NoError(t, err)
+
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("env_var_name").
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(testutil.NewSecretName(t)).
+					SetNewValue("secret_value")))
+
+			p, err := eventPC.CreatePod(ctx, opts)
+			require.NoError(t, err)
+			res := p.Resources()
+
+			events := sink.Events()
+			require.Len(t, events, 3)
+			assert.Equal(t, cocoa.EventTypeSecretCreated, events[0].Type)
+			require.Len(t, res.Containers, 1)
+			require.Len(t, res.Containers[0].Secrets, 1)
+			assert.Equal(t, utility.FromStringPtr(res.Containers[0].Secrets[0].ID), events[0].SecretID)
+			assert.Equal(t, cocoa.EventTypePodDefinitionRegistered, events[1].Type)
+			assert.Equal(t, utility.FromStringPtr(res.TaskDefinition.ID), events[1].PodDefinitionID)
+			assert.Equal(t, utility.FromStringPtr(opts.DefinitionOpts.Name), events[1].PodDefinitionFamily)
+			assert.Equal(t, cocoa.EventTypePodCreated, events[2].Type)
+			assert.Equal(t, utility.FromStringPtr(res.TaskID), events[2].TaskID)
+			assert.Equal(t, utility.FromStringPtr(res.Cluster), events[2].Cluster)
+			for _, e := range events {
+				assert.NotZero(t, e.Time)
+			}
+
+			require.NoError(t, p.Delete(ctx))
+
+			stopped := sink.EventsOfType(cocoa.EventTypePodStopped)
+			require.Len(t, stopped, 1)
+			assert.Equal(t, utility.FromStringPtr(res.TaskID), stopped[0].TaskID)
+			deleted := sink.EventsOfType(cocoa.EventTypePodDeleted)
+			require.Len(t, deleted, 1)
+			assert.Equal(t, utility.FromStringPtr(res.TaskID), deleted[0].TaskID)
+			assert.Equal(t, utility.FromStringPtr(res.TaskDefinition.ID), deleted[0].PodDefinitionID)
+		},
+		"CreatePodDoesNotSendPodCreatedEventWhenRunningTaskFails": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			sink := NewEventSink()
+			eventPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetEventSink(sink))
+			require.NoError(t, err)
+			c.RunTaskError = errors.New("fake error")
+
+			p, err := eventPC.CreatePod(ctx, makeIdempotentOpts(t))
+			assert.Error(t, err)
+			assert.Zero(t, p)
+
+			assert.Len(t, sink.EventsOfType(cocoa.EventTypePodDefinitionRegistered), 1)
+			assert.Empty(t, sink.EventsOfType(cocoa.EventTypePodCreated))
+		},
+		"CreatePodTracksSecretUsageUntilPodIsDeleted": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(sm))
+			require.NoError(t, err)
+			tracker := NewSecretUsageTracker(cocoa.NewMemorySecretUsageTracker())
+			trackingPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetVault(v).
+				SetSecretUsageTracker(tracker))
+			require.NoError(t, err)
+
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("env_var_name").
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(testutil.NewSecretName(t)).
+					SetNewValue("secret_value")))
+
+			p, err := trackingPC.CreatePod(ctx, opts)
+			require.NoError(t, err)
+			res := p.Resources()
+			require.Len(t, res.Containers, 1)
+			require.Len(t, res.Containers[0].Secrets, 1)
+			secretID := utility.FromStringPtr(res.Containers[0].Secrets[0].ID)
+
+			require.NotZero(t, tracker.TrackPodSecretsInput)
+			assert.Equal(t, utility.FromStringPtr(res.TaskID), tracker.TrackPodSecretsInput.PodID)
+			assert.Equal(t, []string{secretID}, tracker.TrackPodSecretsInput.SecretIDs)
+
+			unreferenced, err := tracker.FindUnreferencedSecrets(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, unreferenced, "secret should be referenced while the pod is live")
+
+			require.NoError(t, p.Delete(ctx))
+
+			require.NotZero(t, tracker.UntrackPodInput)
+			assert.Equal(t, utility.FromStringPtr(res.TaskID), *tracker.UntrackPodInput)
+			unreferenced, err = tracker.FindUnreferencedSecrets(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []string{secretID}, unreferenced)
+		},
+		"CreatePodCreatesNewSecretsWithTags": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			secretName := testutil.NewSecretName(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("env_var_name").
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(secretName).
+					SetNewValue("secret_value").
+					SetTags(map[string]string{"owner": "team"})))
+
+			_, err := pc.CreatePod(ctx, opts)
+			require.NoError(t, err)
+
+			require.NotZero(t, sm.CreateSecretInput)
+			assert.Equal(t, secretName, utility.FromStringPtr(sm.CreateSecretInput.Name))
+			var found bool
+			for _, tag := range sm.CreateSecretInput.Tags {
+				if utility.FromStringPtr(tag.Key) == "owner" {
+					assert.Equal(t, "team", utility.FromStringPtr(tag.Value))
+					found = true
+				}
+			}
+			assert.True(t, found, "secret should be created with its tags")
+		},
+		"CreatePodWithMaxLifetimeTagsTaskWithStopDeadline": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			opts.ExecutionOpts.SetMaxLifetime(time.Hour)
+
+			start := time.Now()
+			_, err := pc.CreatePod(ctx, opts)
+			require.NoError(t, err)
+
+			require.NotZero(t, c.RunTaskInput)
+			var deadline string
+			for _, tag := range c.RunTaskInput.Tags {
+				if utility.FromStringPtr(tag.Key) == cocoa.StopDeadlineTagKey {
+					deadline = utility.FromStringPtr(tag.Value)
+				}
+			}
+			parsed, ok, err := cocoa.NewTags().Set(cocoa.StopDeadlineTagKey, deadline).GetStopDeadline()
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.False(t, parsed.Before(start.Add(time.Hour).Truncate(time.Second)), "stop deadline should be at least the max lifetime after the pod is created")
+			assert.Zero(t, opts.ExecutionOpts.Tags[cocoa.StopDeadlineTagKey], "execution options' tags should not be modified")
+		},
+		"CreatePodCreatesDeferredSecretAndResolvesItBeforeRunning": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			secretName := testutil.NewSecretName(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("env_var_name").
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(secretName).
+					SetDeferred(true)))
+			opts.ExecutionOpts.AddDeferredSecretValue(secretName, "resolved_value")
+
+			p, err := pc.CreatePod(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, p)
+
+			require.NotZero(t, sm.CreateSecretInput)
+			assert.Equal(t, secretName, utility.FromStringPtr(sm.CreateSecretInput.Name))
+			assert.NotEqual(t, "resolved_value", utility.FromStringPtr(sm.CreateSecretInput.SecretString), "deferred secret should be created with a placeholder value")
+
+			require.NotZero(t, sm.UpdateSecretInput, "deferred secret should be resolved")
+			assert.Equal(t, utility.FromStringPtr(sm.CreateSecretInput.Name), utility.FromStringPtr(sm.UpdateSecretInput.SecretId))
+			assert.Equal(t, "resolved_value", utility.FromStringPtr(sm.UpdateSecretInput.SecretString))
+			assert.NotZero(t, c.RunTaskInput)
+		},
+		"CreatePodFailsWithUnresolvedDeferredSecret": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("env_var_name").
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(testutil.NewSecretName(t)).
+					SetDeferred(true)))
+
+			p, err := pc.CreatePod(ctx, opts)
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.Zero(t, sm.CreateSecretInput, "should not create the deferred secret")
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not register the pod definition")
+			assert.Zero(t, c.RunTaskInput, "should not run the pod")
+		},
+		"CreatePodFailsWithInvalidTags": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.AddTags(map[string]string{"aws:reserved": "value"})
+
+			p, err := pc.CreatePod(ctx, opts)
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not register the pod definition")
+		},
+		"CreatePodSkipsRepoCredsForPublicImage": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].
+				SetImage("public.ecr.aws/docker/library/alpine:3.18").
+				SetRepositoryCredentials(*cocoa.NewRepositoryCredentials().
+					SetName(testutil.NewSecretName(t)).
+					SetNewCredentials(*cocoa.NewStoredRepositoryCredentials().
+						SetUsername("username").
+						SetPassword("password")).
+					SetSkipForPublicImages(true))
+
+			p, err := pc.CreatePod(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, p)
+
+			assert.Zero(t, sm.CreateSecretInput, "should not create the skipped credentials")
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			assert.Zero(t, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].RepositoryCredentials)
+			require.Len(t, p.Resources().Containers, 1)
+			assert.Empty(t, p.Resources().Containers[0].Secrets)
+		},
+		"CreatePodUsesRepoCredsForPrivateImageEvenIfSkippedForPublicImages": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].
+				SetRepositoryCredentials(*cocoa.NewRepositoryCredentials().
+					SetName(testutil.NewSecretName(t)).
+					SetNewCredentials(*cocoa.NewStoredRepositoryCredentials().
+						SetUsername("username").
+						SetPassword("password")).
+					SetSkipForPublicImages(true))
+
+			p, err := pc.CreatePod(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, p)
+
+			assert.NotZero(t, sm.CreateSecretInput)
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			assert.NotZero(t, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].RepositoryCredentials)
+		},
+		"CreatePodConstrainsPlacementToImagePullBehavior": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].SetImage("image:1.0")
+			opts.ExecutionOpts.SetPlacementOptions(*cocoa.NewECSPodPlacementOptions().SetImagePullBehavior(cocoa.ImagePullBehaviorPreferCached))
+
+			p, err := pc.CreatePod(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, p)
+
+			require.NotZero(t, c.RunTaskInput)
+			require.Len(t, c.RunTaskInput.PlacementConstraints, 1)
+			assert.Equal(t, types.PlacementConstraintTypeMemberOf, c.RunTaskInput.PlacementConstraints[0].Type)
+			assert.Equal(t, "attribute:"+cocoa.ImagePullBehaviorAttribute+" == prefer-cached", utility.FromStringPtr(c.RunTaskInput.PlacementConstraints[0].Expression))
+		},
+		"CreatePodFailsWithUnpinnedImageForCachedImagePullBehavior": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			opts.ExecutionOpts.SetPlacementOptions(*cocoa.NewECSPodPlacementOptions().SetImagePullBehavior(cocoa.ImagePullBehaviorOnce))
+
+			p, err := pc.CreatePod(ctx, opts)
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.Zero(t, c.RegisterTaskDefinitionInput)
+		},
+		"CreatePodDoesNotTrackPodWithoutSecrets": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			tracker := NewSecretUsageTracker(cocoa.NewMemorySecretUsageTracker())
+			trackingPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetSecretUsageTracker(tracker))
+			require.NoError(t, err)
+
+			_, err = trackingPC.CreatePod(ctx, makeIdempotentOpts(t))
+			require.NoError(t, err)
+			assert.Zero(t, tracker.TrackPodSecretsInput)
+		},
+		"CreatePodDeletesPodWhenTrackingSecretUsageFails": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(sm))
+			require.NoError(t, err)
+			tracker := NewSecretUsageTracker(cocoa.NewMemorySecretUsageTracker())
+			tracker.TrackPodSecretsError = errors.New("fake error")
+			trackingPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetVault(v).
+				SetSecretUsageTracker(tracker))
+			require.NoError(t, err)
+
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("env_var_name").
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(testutil.NewSecretName(t)).
+					SetNewValue("secret_value")))
+
+			p, err := trackingPC.CreatePod(ctx, opts)
+			assert.Error(t, err)
+			assert.Zero(t, p)
+
+			require.NotZero(t, c.StopTaskInput, "should have stopped the untracked pod")
+			task, ok := GlobalECSService.Clusters[testutil.ECSClusterName()][utility.FromStringPtr(c.StopTaskInput.Task)]
+			require.True(t, ok)
+			assert.EqualValues(t, types.DesiredStatusStopped, task.Status)
+		},
+		"CreatePodFromExistingDefinitionWithSuppressedPlacementStrategyOmitsPlacementStrategy": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+
+			execOpts := cocoa.NewECSPodExecutionOptions().
+				SetCluster(testutil.ECSClusterName()).
+				SetCapacityProvider("capacity_provider").
+				SetPlacementOptions(*cocoa.NewECSPodPlacementOptions().AddInstanceFilters(cocoa.ConstraintDistinctInstance)).
+				SetSuppressPlacementStrategy(true)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			_, err := pc.CreatePodFromExistingDefinition(ctx, *def, *execOpts)
+			require.NoError(t, err)
+
+			require.NotZero(t, c.RunTaskInput)
+			assert.Empty(t, c.RunTaskInput.PlacementStrategy)
+			require.Len(t, c.RunTaskInput.PlacementConstraints, 1)
+			assert.EqualValues(t, cocoa.ConstraintDistinctInstance, c.RunTaskInput.PlacementConstraints[0].Type)
+			require.Len(t, c.RunTaskInput.CapacityProviderStrategy, 1)
+			assert.Equal(t, "capacity_provider", utility.FromStringPtr(c.RunTaskInput.CapacityProviderStrategy[0].CapacityProvider))
+		},
+		"CreatePodFromExistingDefinitionWithNoPlacementStrategyOmitsPlacementStrategy": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+
+			placementOpts := cocoa.NewECSPodPlacementOptions().
+				SetStrategy(cocoa.StrategyNone).
+				AddInstanceFilters(cocoa.ConstraintDistinctInstance)
+			execOpts := cocoa.NewECSPodExecutionOptions().
+				SetCluster(testutil.ECSClusterName()).
+				SetCapacityProvider("capacity_provider").
+				SetPlacementOptions(*placementOpts)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			_, err := pc.CreatePodFromExistingDefinition(ctx, *def, *execOpts)
+			require.NoError(t, err)
+
+			require.NotZero(t, c.RunTaskInput)
+			assert.Empty(t, c.RunTaskInput.PlacementStrategy)
+			require.Len(t, c.RunTaskInput.PlacementConstraints, 1)
+			assert.EqualValues(t, cocoa.ConstraintDistinctInstance, c.RunTaskInput.PlacementConstraints[0].Type)
+		},
+		"CreatePodPerInstanceCreatesOnePodOnEachActiveContainerInstance": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			var instances []ECSContainerInstance
+			for i := 0; i < 12; i++ {
+				instances = append(instances, NewECSContainerInstance())
+			}
+			draining := NewECSContainerInstance()
+			draining.Status = types.ContainerInstanceStatusDraining
+			GlobalECSService.ContainerInstances[testutil.ECSClusterName()] = append(instances, draining)
+
+			sink := NewEventSink()
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetEventSink(sink))
+			require.NoError(t, err)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+
+			pods, err := basicPC.CreatePodPerInstance(ctx, *def, *execOpts)
+			require.NoError(t, err)
+			require.Len(t, pods, len(instances))
+			for _, instance := range instances {
+				p, ok := pods[instance.ARN]
+				require.True(t, ok, "container instance '%s' should have a pod", instance.ARN)
+				task, ok := GlobalECSService.Clusters[testutil.ECSClusterName()][utility.FromStringPtr(p.Resources().TaskID)]
+				require.True(t, ok)
+				assert.Equal(t, instance.ARN, utility.FromStringPtr(task.ContainerInstance))
+			}
+			assert.NotContains(t, pods, draining.ARN)
+			assert.Len(t, sink.EventsOfType(cocoa.EventTypePodCreated), len(instances))
+		},
+		"CreatePodPerInstanceReturnsCreatedPodsWhenSomeTasksFail": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			instance := NewECSContainerInstance()
+			GlobalECSService.ContainerInstances[testutil.ECSClusterName()] = []ECSContainerInstance{instance}
+			c.ListContainerInstancesOutput = &awsECS.ListContainerInstancesOutput{
+				ContainerInstanceArns: []string{instance.ARN, NewECSContainerInstance().ARN},
+			}
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+
+			pods, err := basicPC.CreatePodPerInstance(ctx, *def, *execOpts)
+			assert.Error(t, err)
+			require.Len(t, pods, 1)
+			assert.Contains(t, pods, instance.ARN)
+		},
+		"CreatePodPerInstanceFailsWithPlacementOptions": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			GlobalECSService.ContainerInstances[testutil.ECSClusterName()] = []ECSContainerInstance{NewECSContainerInstance()}
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			execOpts := cocoa.NewECSPodExecutionOptions().
+				SetCluster(testutil.ECSClusterName()).
+				SetPlacementOptions(*cocoa.NewECSPodPlacementOptions().SetStrategy(cocoa.StrategyBinpack))
+
+			pods, err := basicPC.CreatePodPerInstance(ctx, *def, *execOpts)
+			assert.Error(t, err)
+			assert.Zero(t, pods)
+			assert.Zero(t, c.StartTaskInput)
+		},
+		"CreatePodPerInstanceSucceedsWithNoContainerInstances": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+
+			pods, err := basicPC.CreatePodPerInstance(ctx, *def, *execOpts)
+			require.NoError(t, err)
+			assert.Empty(t, pods)
+			assert.Zero(t, c.StartTaskInput)
+		},
+		"CreatePodWithFallbacksUsesFallbackWhenClusterHasInsufficientCapacity": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			const fallbackCluster = "fallback_cluster"
+			GlobalECSService.Clusters[fallbackCluster] = ECSCluster{}
+			GlobalECSService.ClusterCapacities[testutil.ECSClusterName()] = ECSClusterCapacity{
+				CPU: utility.ToIntPtr(64),
+			}
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			opts := makeIdempotentOpts(t)
+			fallbacks := []cocoa.ECSPodExecutionOptions{*cocoa.NewECSPodExecutionOptions().SetCluster(fallbackCluster)}
+			res, err := basicPC.CreatePodWithFallbacks(ctx, opts, fallbacks)
+			require.NoError(t, err)
+			require.NotZero(t, res)
+			assert.Equal(t, 0, res.FallbackIndex)
+
+			taskID := utility.FromStringPtr(res.Pod.Resources().TaskID)
+			assert.Contains(t, GlobalECSService.Clusters[fallbackCluster], taskID)
+			assert.Empty(t, GlobalECSService.Clusters[testutil.ECSClusterName()])
+			assert.Equal(t, fallbackCluster, utility.FromStringPtr(res.Pod.Resources().Cluster))
+			assert.Len(t, GlobalECSService.TaskDefs[utility.FromStringPtr(opts.DefinitionOpts.Name)], 1, "pod definition should only be registered once")
+		},
+		"CreatePodWithFallbacksUsesOriginalOptionsWhenClusterHasCapacity": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			const fallbackCluster = "fallback_cluster"
+			GlobalECSService.Clusters[fallbackCluster] = ECSCluster{}
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			fallbacks := []cocoa.ECSPodExecutionOptions{*cocoa.NewECSPodExecutionOptions().SetCluster(fallbackCluster)}
+			res, err := basicPC.CreatePodWithFallbacks(ctx, makeIdempotentOpts(t), fallbacks)
+			require.NoError(t, err)
+			require.NotZero(t, res)
+			assert.Equal(t, -1, res.FallbackIndex)
+			assert.Contains(t, GlobalECSService.Clusters[testutil.ECSClusterName()], utility.FromStringPtr(res.Pod.Resources().TaskID))
+			assert.Empty(t, GlobalECSService.Clusters[fallbackCluster])
+		},
+		"CreatePodWithFallbacksFailsWhenAllOptionsHaveInsufficientCapacity": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			const fallbackCluster = "fallback_cluster"
+			GlobalECSService.Clusters[fallbackCluster] = ECSCluster{}
+			for _, cluster := range []string{testutil.ECSClusterName(), fallbackCluster} {
+				GlobalECSService.ClusterCapacities[cluster] = ECSClusterCapacity{
+					MemoryMB: utility.ToIntPtr(64),
+				}
+			}
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			fallbacks := []cocoa.ECSPodExecutionOptions{*cocoa.NewECSPodExecutionOptions().SetCluster(fallbackCluster)}
+			res, err := basicPC.CreatePodWithFallbacks(ctx, makeIdempotentOpts(t), fallbacks)
+			assert.Error(t, err)
+			assert.Zero(t, res)
+			assert.True(t, cocoa.IsCapacityRunTaskFailure(err))
+		},
+		"CreatePodWithFallbacksDoesNotUseFallbackForNonCapacityFailure": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			const fallbackCluster = "fallback_cluster"
+			GlobalECSService.Clusters[fallbackCluster] = ECSCluster{}
+			c.RunTaskFailureReason = aws.String("ATTRIBUTE")
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			fallbacks := []cocoa.ECSPodExecutionOptions{*cocoa.NewECSPodExecutionOptions().SetCluster(fallbackCluster)}
+			res, err := basicPC.CreatePodWithFallbacks(ctx, makeIdempotentOpts(t), fallbacks)
+			assert.Error(t, err)
+			assert.Zero(t, res)
+			require.NotZero(t, c.RunTaskInput)
+			assert.Equal(t, testutil.ECSClusterName(), utility.FromStringPtr(c.RunTaskInput.Cluster), "should not have tried fallback cluster")
+		},
+		"CreatePodWithFallbacksFailsWithFallbackThatOverridesBindMounts": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			overrideOpts := cocoa.NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*cocoa.NewECSOverrideContainerDefinition().
+				SetName("container_name").
+				AddBindMounts(*cocoa.NewBindMount().SetSourcePath("/src").SetContainerPath("/dst")))
+			fallbacks := []cocoa.ECSPodExecutionOptions{*cocoa.NewECSPodExecutionOptions().SetOverrideOptions(*overrideOpts)}
+			res, err := basicPC.CreatePodWithFallbacks(ctx, makeIdempotentOpts(t), fallbacks)
+			assert.Error(t, err)
+			assert.Zero(t, res)
+			assert.Zero(t, c.RegisterTaskDefinitionInput)
+			assert.Zero(t, c.RunTaskInput)
+		},
+		"RunOneOffPodRunsCommandAndCollectsExitCodes": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			containerName := utility.FromStringPtr(registerOut.TaskDefinition.ContainerDefinitions[0].Name)
+			c.DescribeTasksOutput = &awsECS.DescribeTasksOutput{
+				Tasks: []types.Task{{
+					LastStatus: aws.String(string(types.DesiredStatusStopped)),
+					Containers: []types.Container{{
+						ContainerArn: aws.String("container_arn"),
+						Name:         aws.String(containerName),
+						LastStatus:   aws.String(string(types.DesiredStatusStopped)),
+						ExitCode:     aws.Int32(3),
+					}},
+				}},
+			}
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+			command := []string{"echo", "bar"}
+
+			res, err := basicPC.RunOneOffPod(ctx, *def, command, time.Minute, *execOpts)
+			require.NoError(t, err)
+			require.NotZero(t, res)
+			assert.False(t, res.TimedOut)
+			assert.Equal(t, map[string]int{containerName: 3}, res.ExitCodes)
+
+			require.NotZero(t, c.RunTaskInput)
+			require.NotZero(t, c.RunTaskInput.Overrides)
+			require.Len(t, c.RunTaskInput.Overrides.ContainerOverrides, 1)
+			assert.Equal(t, containerName, utility.FromStringPtr(c.RunTaskInput.Overrides.ContainerOverrides[0].Name))
+			assert.Equal(t, command, c.RunTaskInput.Overrides.ContainerOverrides[0].Command)
+
+			task, ok := GlobalECSService.Clusters[testutil.ECSClusterName()][res.TaskID]
+			require.True(t, ok)
+			assert.Equal(t, command, task.Containers[0].Command)
+		},
+		"RunOneOffPodStopsPodAfterTimeout": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+
+			res, err := basicPC.RunOneOffPod(ctx, *def, []string{"sleep", "infinity"}, 10*time.Millisecond, *execOpts)
+			require.NoError(t, err)
+			require.NotZero(t, res)
+			assert.True(t, res.TimedOut)
+			assert.Empty(t, res.ExitCodes)
+
+			require.NotZero(t, c.StopTaskInput)
+			assert.Equal(t, res.TaskID, utility.FromStringPtr(c.StopTaskInput.Task))
+			task, ok := GlobalECSService.Clusters[testutil.ECSClusterName()][res.TaskID]
+			require.True(t, ok)
+			assert.Equal(t, string(types.DesiredStatusStopped), task.Status)
+		},
+		"RunOneOffPodPreservesOtherContainerOverrides": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			containerName := utility.FromStringPtr(registerOut.TaskDefinition.ContainerDefinitions[0].Name)
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			overrideOpts := cocoa.NewECSOverridePodDefinitionOptions().AddContainerDefinitions(*cocoa.NewECSOverrideContainerDefinition().
+				SetName(containerName).
+				SetCommand([]string{"echo", "foo"}).
+				AddEnvironmentVariables(*cocoa.NewKeyValue().SetName("name").SetValue("value")))
+			execOpts := cocoa.NewECSPodExecutionOptions().
+				SetCluster(testutil.ECSClusterName()).
+				SetOverrideOptions(*overrideOpts)
+			command := []string{"echo", "bar"}
+
+			_, err = basicPC.RunOneOffPod(ctx, *def, command, 10*time.Millisecond, *execOpts)
+			require.NoError(t, err)
+
+			require.NotZero(t, c.RunTaskInput)
+			require.NotZero(t, c.RunTaskInput.Overrides)
+			require.Len(t, c.RunTaskInput.Overrides.ContainerOverrides, 1)
+			override := c.RunTaskInput.Overrides.ContainerOverrides[0]
+			assert.Equal(t, command, override.Command)
+			require.Len(t, override.Environment, 1)
+			assert.Equal(t, "name", utility.FromStringPtr(override.Environment[0].Name))
+			assert.Equal(t, []string{"echo", "foo"}, overrideOpts.ContainerDefinitions[0].Command, "original override options should not be modified")
+		},
+		"RunOneOffPodFailsWithMultipleContainers": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			in := testutil.ValidRegisterTaskDefinitionInput(t)
+			in.ContainerDefinitions = append(in.ContainerDefinitions, types.ContainerDefinition{
+				Image: aws.String("busybox"),
+				Name:  aws.String("other"),
+			})
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, in)
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+
+			res, err := basicPC.RunOneOffPod(ctx, *def, []string{"echo", "bar"}, time.Minute, *execOpts)
+			assert.Error(t, err)
+			assert.Zero(t, res)
+			assert.Zero(t, c.RunTaskInput)
+		},
+		"RunOneOffPodFailsWithoutCommand": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+
+			res, err := basicPC.RunOneOffPod(ctx, *def, nil, time.Minute, *execOpts)
+			assert.Error(t, err)
+			assert.Zero(t, res)
+			assert.Zero(t, c.RunTaskInput)
+		},
+		"CreatePodRegistersTaskDefinitionAndRunsTaskWithNewlyCreatedSecrets": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, dc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			secretOpts := cocoa.NewSecretOptions().
+				SetName("secret_name").
+				SetNewValue("secret_value")
+			envVar := cocoa.NewEnvironmentVariable().
+				SetName("env_var_name").
+				SetSecretOptions(*secretOpts)
+			containerDef := cocoa.NewECSContainerDefinition().
+				SetName("name").
+				SetImage("image").
+				SetCommand([]string{"echo", "foo"}).
+				AddEnvironmentVariables(*envVar)
+			defOpts := cocoa.NewECSPodDefinitionOptions().
+				SetMemoryMB(512).
+				SetCPU(1024).
+				SetExecutionRole("execution_role").
+				AddContainerDefinitions(*containerDef)
+			execOpts := cocoa.NewECSPodExecutionOptions().
+				SetCluster(testutil.ECSClusterName())
+			opts := cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*execOpts)
+
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+
+			mem, err := strconv.Atoi(utility.FromStringPtr(c.RegisterTaskDefinitionInput.Memory))
+			require.NoError(t, err)
+			assert.Equal(t, utility.FromIntPtr(defOpts.MemoryMB), mem)
+			cpu, err := strconv.Atoi(utility.FromStringPtr(c.RegisterTaskDefinitionInput.Cpu))
+			require.NoError(t, err)
+			assert.Equal(t, utility.FromIntPtr(defOpts.CPU), cpu)
+			assert.Equal(t, utility.FromStringPtr(defOpts.ExecutionRole), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ExecutionRoleArn))
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			assert.Equal(t, containerDef.Command, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Command)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Secrets, 1)
+			assert.Equal(t, utility.FromStringPtr(envVar.Name), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Secrets[0].Name))
+			res := p.Resources()
+			require.Len(t, res.Containers, 1)
+			require.Len(t, res.Containers[0].Secrets, 1)
+			secretID := res.Containers[0].Secrets[0].ID
+			assert.Equal(t, utility.FromStringPtr(secretID), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Secrets[0].ValueFrom))
+
+			assert.Equal(t, utility.FromStringPtr(secretOpts.Name), utility.FromStringPtr(sm.CreateSecretInput.Name))
+			assert.Equal(t, utility.FromStringPtr(secretOpts.NewValue), utility.FromStringPtr(sm.CreateSecretInput.SecretString))
+		},
+		"CreatePodAssumesRoleForCreateAndRunCalls": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			rc := &roleRecordingECSClient{ECSClient: c}
+			rolePC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(rc))
+			require.NoError(t, err)
+
+			roleOpts := cocoa.NewAssumeRoleOptions().
+				SetRoleARN("arn:aws:iam::123456789012:role/role").
+				SetExternalID("external_id")
+			opts := makeIdempotentOpts(t)
+			opts.ExecutionOpts.SetAssumeRoleOptions(*roleOpts)
+
+			p, err := rolePC.CreatePod(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, p)
+
+			require.NotZero(t, rc.registerTaskDefinitionRole)
+			assert.Equal(t, *roleOpts, *rc.registerTaskDefinitionRole)
+			require.NotZero(t, rc.runTaskRole)
+			assert.Equal(t, *roleOpts, *rc.runTaskRole)
+		},
+		"CreatePodDoesNotAssumeRoleByDefault": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			rc := &roleRecordingECSClient{ECSClient: c}
+			rolePC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(rc))
+			require.NoError(t, err)
+
+			p, err := rolePC.CreatePod(ctx, makeIdempotentOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, p)
+
+			assert.Zero(t, rc.registerTaskDefinitionRole)
+			assert.Zero(t, rc.runTaskRole)
+		},
+		"CreatePodFailsWithInvalidAssumeRoleOptions": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			opts.ExecutionOpts.SetAssumeRoleOptions(*cocoa.NewAssumeRoleOptions().SetRoleARN("role"))
+
+			p, err := pc.CreatePod(ctx, opts)
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.Zero(t, c.RegisterTaskDefinitionInput)
+		},
+		"CreatePodUsesNameGeneratorForUnnamedResources": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			namedPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetNameGenerator(cocoa.NewSequentialNameGenerator("generated")))
+			require.NoError(t, err)
+
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.Name = nil
+			opts.DefinitionOpts.ContainerDefinitions[0].Name = nil
+
+			p, err := namedPC.CreatePod(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, p)
+
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			assert.Equal(t, "generated1", utility.FromStringPtr(c.RegisterTaskDefinitionInput.Family))
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			assert.Equal(t, "generated0", utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Name))
+		},
+		"CreatePodUsesDefaultAWSVPCOptions": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			vpcPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetDefaultAWSVPCOptions(*cocoa.NewAWSVPCOptions().
+					AddSubnets("default-subnet").
+					AddSecurityGroups("default-sg")))
+			require.NoError(t, err)
+
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.SetNetworkMode(cocoa.NetworkModeAWSVPC)
+
+			p, err := vpcPC.CreatePod(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, p)
+
+			require.NotZero(t, c.RunTaskInput)
+			require.NotZero(t, c.RunTaskInput.NetworkConfiguration)
+			require.NotZero(t, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration)
+			assert.Equal(t, []string{"default-subnet"}, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.Subnets)
+			assert.Equal(t, []string{"default-sg"}, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.SecurityGroups)
+		},
+		"CreatePodPrefersExplicitAWSVPCOptionsOverDefaults": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			vpcPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetDefaultAWSVPCOptions(*cocoa.NewAWSVPCOptions().
+					AddSubnets("default-subnet").
+					AddSecurityGroups("default-sg")))
+			require.NoError(t, err)
+
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.SetNetworkMode(cocoa.NetworkModeAWSVPC)
+			opts.ExecutionOpts.SetAWSVPCOptions(*cocoa.NewAWSVPCOptions().AddSubnets("subnet"))
+
+			p, err := vpcPC.CreatePod(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, p)
+
+			require.NotZero(t, c.RunTaskInput)
+			require.NotZero(t, c.RunTaskInput.NetworkConfiguration)
+			require.NotZero(t, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration)
+			assert.Equal(t, []string{"subnet"}, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.Subnets)
+			assert.Equal(t, []string{"default-sg"}, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.SecurityGroups)
+		},
+		"CreatePodDoesNotUseDefaultAWSVPCOptionsWithoutAWSVPCNetworkMode": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			vpcPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetDefaultAWSVPCOptions(*cocoa.NewAWSVPCOptions().AddSubnets("default-subnet")))
+			require.NoError(t, err)
+
+			p, err := vpcPC.CreatePod(ctx, makeIdempotentOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, p)
+
+			require.NotZero(t, c.RunTaskInput)
+			assert.Zero(t, c.RunTaskInput.NetworkConfiguration)
+		},
+		"CreatePodWithFallbacksInheritsPurposeForFallbackWithTags": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			const fallbackCluster = "fallback_cluster"
+			GlobalECSService.Clusters[fallbackCluster] = ECSCluster{}
+			GlobalECSService.ClusterCapacities[testutil.ECSClusterName()] = ECSClusterCapacity{
+				CPU: utility.ToIntPtr(64),
+			}
+
+			basicPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c))
+			require.NoError(t, err)
+
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.SetPurpose("build")
+			fallbacks := []cocoa.ECSPodExecutionOptions{*cocoa.NewECSPodExecutionOptions().
+				SetCluster(fallbackCluster).
+				SetTags(map[string]string{"fallback_tag": "fallback_val"})}
+			res, err := basicPC.CreatePodWithFallbacks(ctx, opts, fallbacks)
+			require.NoError(t, err)
+			require.NotZero(t, res)
+			assert.Equal(t, 0, res.FallbackIndex)
+
+			require.NotZero(t, c.RunTaskInput)
+			assert.Equal(t, fallbackCluster, utility.FromStringPtr(c.RunTaskInput.Cluster))
+			assert.Contains(t, c.RunTaskInput.Tags, types.Tag{Key: aws.String("fallback_tag"), Value: aws.String("fallback_val")})
+			assert.Contains(t, c.RunTaskInput.Tags, types.Tag{Key: aws.String(cocoa.PurposeTagKey), Value: aws.String("build")}, "fallback should inherit the pod definition's purpose")
+		},
+		"CreatePodWithFallbacksUsesDefaultAWSVPCOptionsForFallback": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			const fallbackCluster = "fallback_cluster"
+			GlobalECSService.Clusters[fallbackCluster] = ECSCluster{}
+			GlobalECSService.ClusterCapacities[testutil.ECSClusterName()] = ECSClusterCapacity{
+				CPU: utility.ToIntPtr(64),
+			}
+
+			vpcPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetDefaultAWSVPCOptions(*cocoa.NewAWSVPCOptions().
+					AddSubnets("default-subnet").
+					AddSecurityGroups("default-sg")))
+			require.NoError(t, err)
+
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.SetNetworkMode(cocoa.NetworkModeAWSVPC)
+			fallbacks := []cocoa.ECSPodExecutionOptions{*cocoa.NewECSPodExecutionOptions().
+				SetCluster(fallbackCluster).
+				SetAWSVPCOptions(*cocoa.NewAWSVPCOptions().AddSubnets("fallback-subnet"))}
+			res, err := vpcPC.CreatePodWithFallbacks(ctx, opts, fallbacks)
+			require.NoError(t, err)
+			require.NotZero(t, res)
+			assert.Equal(t, 0, res.FallbackIndex)
+
+			require.NotZero(t, c.RunTaskInput)
+			require.NotZero(t, c.RunTaskInput.NetworkConfiguration)
+			require.NotZero(t, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration)
+			assert.Equal(t, fallbackCluster, utility.FromStringPtr(c.RunTaskInput.Cluster))
+			assert.Equal(t, []string{"fallback-subnet"}, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.Subnets)
+			assert.Equal(t, []string{"default-sg"}, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.SecurityGroups, "fallback should use the default security groups")
+		},
+		"CreatePodFromExistingDefinitionUsesDefaultAWSVPCOptions": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			vpcPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetDefaultAWSVPCOptions(*cocoa.NewAWSVPCOptions().
+					AddSubnets("default-subnet").
+					AddSecurityGroups("default-sg")))
+			require.NoError(t, err)
+
+			registerIn := testutil.ValidRegisterTaskDefinitionInput(t)
+			registerIn.NetworkMode = types.NetworkModeAwsvpc
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, registerIn)
+			taskDef := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
 
-			mem, err := strconv.Atoi(utility.FromStringPtr(c.RegisterTaskDefinitionInput.Memory))
+			p, err := vpcPC.CreatePodFromExistingDefinition(ctx, *taskDef, *cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName()))
 			require.NoError(t, err)
-			assert.Equal(t, utility.FromIntPtr(podDefOpts.MemoryMB), mem)
+			require.NotZero(t, p)
 
-			cpu, err := strconv.Atoi(utility.FromStringPtr(c.RegisterTaskDefinitionInput.Cpu))
+			require.NotZero(t, c.RunTaskInput)
+			require.NotZero(t, c.RunTaskInput.NetworkConfiguration)
+			require.NotZero(t, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration)
+			assert.Equal(t, []string{"default-subnet"}, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.Subnets)
+			assert.Equal(t, []string{"default-sg"}, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.SecurityGroups)
+		},
+		"CreatePodFromExistingDefinitionDoesNotUseDefaultAWSVPCOptionsWithoutAWSVPCNetworkMode": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			vpcPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetDefaultAWSVPCOptions(*cocoa.NewAWSVPCOptions().AddSubnets("default-subnet")))
 			require.NoError(t, err)
-			assert.Equal(t, utility.FromIntPtr(podDefOpts.CPU), cpu)
-
-			require.NotZero(t, podDefOpts.NetworkMode)
-			assert.EqualValues(t, *podDefOpts.NetworkMode, c.RegisterTaskDefinitionInput.NetworkMode)
-
-			assert.Equal(t, utility.FromStringPtr(podDefOpts.TaskRole), utility.FromStringPtr(c.RegisterTaskDefinitionInput.TaskRoleArn))
-			assert.Equal(t, utility.FromStringPtr(podDefOpts.ExecutionRole), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ExecutionRoleArn))
 
-			assert.Len(t, c.RegisterTaskDefinitionInput.Tags, 2, "should have user-defined tag and cache tracking tag")
-			for _, tag := range c.RegisterTaskDefinitionInput.Tags {
-				key := utility.FromStringPtr(tag.Key)
-				switch key {
-				case "creation_tag":
-					assert.Equal(t, podDefOpts.Tags["creation_tag"], utility.FromStringPtr(tag.Value), "user-defined tag should be defined")
-				case pdc.GetTag():
-					assert.Equal(t, "false", utility.FromStringPtr(tag.Value), "cache tag should initially mark pod definition as uncached before caching")
-				default:
-					assert.FailNow(t, "unrecognized tag", "unexpected tag '%s'", key)
-				}
-			}
-			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
-			assert.Equal(t, containerDef.Command, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Command)
-			assert.Equal(t, utility.FromStringPtr(containerDef.WorkingDir), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].WorkingDirectory))
-			assert.EqualValues(t, utility.FromIntPtr(containerDef.MemoryMB), utility.FromInt32Ptr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Memory))
-			assert.EqualValues(t, utility.FromIntPtr(containerDef.CPU), c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Cpu)
-			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Environment, 1)
-			assert.Equal(t, utility.FromStringPtr(envVar.Name), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Environment[0].Name))
-			assert.Equal(t, utility.FromStringPtr(envVar.Value), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Environment[0].Value))
-			assert.EqualValues(t, utility.FromStringPtr(containerDef.LogConfiguration.LogDriver), c.RegisterTaskDefinitionInput.ContainerDefinitions[0].LogConfiguration.LogDriver)
-			for k, v := range containerDef.LogConfiguration.Options {
-				assert.Equal(t, v, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].LogConfiguration.Options[k])
-			}
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			taskDef := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
 
-			// Verify RunTask inputs.
+			p, err := vpcPC.CreatePodFromExistingDefinition(ctx, *taskDef, *cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName()))
+			require.NoError(t, err)
+			require.NotZero(t, p)
 
 			require.NotZero(t, c.RunTaskInput)
-			assert.Equal(t, utility.FromStringPtr(execOpts.Cluster), utility.FromStringPtr(c.RunTaskInput.Cluster))
-
-			require.Len(t, c.RunTaskInput.CapacityProviderStrategy, 1)
-			assert.Equal(t, utility.FromStringPtr(execOpts.CapacityProvider), utility.FromStringPtr(c.RunTaskInput.CapacityProviderStrategy[0].CapacityProvider))
+			assert.Zero(t, c.RunTaskInput.NetworkConfiguration)
+		},
+		"NewPodCreatorFailsWithInvalidDefaultAWSVPCOptions": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			vpcPC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetDefaultAWSVPCOptions(*cocoa.NewAWSVPCOptions().AddSecurityGroups("default-sg")))
+			assert.Error(t, err)
+			assert.Zero(t, vpcPC)
+		},
+		"CreatePodFromExistingDefinitionAssumesRoleForRunCall": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			rc := &roleRecordingECSClient{ECSClient: c}
+			rolePC, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(rc))
+			require.NoError(t, err)
 
-			require.NotZero(t, c.RunTaskInput.Overrides)
+			registerIn := testutil.ValidRegisterTaskDefinitionInput(t)
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, registerIn)
+			taskDef := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			roleOpts := cocoa.NewAssumeRoleOptions().SetRoleARN("arn:aws:iam::123456789012:role/role")
+			execOpts := cocoa.NewECSPodExecutionOptions().
+				SetCluster(testutil.ECSClusterName()).
+				SetAssumeRoleOptions(*roleOpts)
 
-			mem, err = strconv.Atoi(utility.FromStringPtr(c.RunTaskInput.Overrides.Memory))
+			p, err := rolePC.CreatePodFromExistingDefinition(ctx, *taskDef, *execOpts)
 			require.NoError(t, err)
-			assert.Equal(t, utility.FromIntPtr(overridePodDefOpts.MemoryMB), mem)
+			require.NotZero(t, p)
 
-			cpu, err = strconv.Atoi(utility.FromStringPtr(c.RunTaskInput.Overrides.Cpu))
+			require.NotZero(t, rc.runTaskRole)
+			assert.Equal(t, *roleOpts, *rc.runTaskRole)
+		},
+		"CreatePodCreatesSharedNewSecretOnceForMultipleContainers": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			secretOpts := cocoa.NewSecretOptions().
+				SetName("secret_name").
+				SetNewValue("secret_value")
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("env_var_name").
+				SetSecretOptions(*secretOpts))
+			opts.DefinitionOpts.AddContainerDefinitions(*cocoa.NewECSContainerDefinition().
+				SetName("other_container").
+				SetImage("image").
+				SetMemoryMB(128).
+				SetCPU(128).
+				AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+					SetName("other_env_var_name").
+					SetSecretOptions(*secretOpts)))
+
+			p, err := pc.CreatePod(ctx, opts)
 			require.NoError(t, err)
-			assert.Equal(t, utility.FromIntPtr(overridePodDefOpts.CPU), cpu)
 
-			assert.Equal(t, utility.FromStringPtr(overridePodDefOpts.TaskRole), utility.FromStringPtr(c.RunTaskInput.Overrides.TaskRoleArn))
-			assert.Equal(t, utility.FromStringPtr(overridePodDefOpts.ExecutionRole), utility.FromStringPtr(c.RunTaskInput.Overrides.ExecutionRoleArn))
+			assert.Len(t, GlobalSecretCache, 1, "shared secret should only be created once")
 
-			require.Len(t, c.RunTaskInput.Overrides.ContainerOverrides, 1)
-			containerOverride := c.RunTaskInput.Overrides.ContainerOverrides[0]
-			assert.Equal(t, overrideContainerDef.Command, containerOverride.Command)
-			assert.EqualValues(t, utility.FromIntPtr(overrideContainerDef.MemoryMB), utility.FromInt32Ptr(containerOverride.Memory))
-			assert.EqualValues(t, utility.FromIntPtr(overrideContainerDef.CPU), utility.FromInt32Ptr(containerOverride.Cpu))
-			require.Len(t, containerOverride.Environment, 1)
-			assert.Equal(t, utility.FromStringPtr(overrideEnvVar.Name), utility.FromStringPtr(containerOverride.Environment[0].Name))
-			assert.Equal(t, utility.FromStringPtr(overrideEnvVar.Value), utility.FromStringPtr(containerOverride.Environment[0].Value))
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 2)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Secrets, 1)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions[1].Secrets, 1)
+			secretID := utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Secrets[0].ValueFrom)
+			assert.NotZero(t, secretID)
+			assert.Equal(t, secretID, utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[1].Secrets[0].ValueFrom))
 
-			assert.Equal(t, utility.FromStringPtr(placementOpts.Group), utility.FromStringPtr(c.RunTaskInput.Group))
-			require.Len(t, c.RunTaskInput.PlacementStrategy, 1)
-			assert.EqualValues(t, *placementOpts.Strategy, c.RunTaskInput.PlacementStrategy[0].Type)
-			assert.Equal(t, utility.FromStringPtr(placementOpts.StrategyParameter), utility.FromStringPtr(c.RunTaskInput.PlacementStrategy[0].Field))
-			require.Len(t, c.RunTaskInput.PlacementConstraints, 2)
-			assert.EqualValues(t, "memberOf", c.RunTaskInput.PlacementConstraints[0].Type)
-			assert.Equal(t, placementOpts.InstanceFilters[0], utility.FromStringPtr(c.RunTaskInput.PlacementConstraints[0].Expression))
-			assert.EqualValues(t, cocoa.ConstraintDistinctInstance, c.RunTaskInput.PlacementConstraints[1].Type)
-			assert.Zero(t, c.RunTaskInput.PlacementConstraints[1].Expression)
+			res := p.Resources()
+			require.Len(t, res.Containers, 2)
+			for _, container := range res.Containers {
+				require.Len(t, container.Secrets, 1)
+				assert.Equal(t, secretID, utility.FromStringPtr(container.Secrets[0].ID))
+			}
+		},
+		"CreatePodReturnsStagedErrorWhenRunningTaskFails": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			c.RunTaskError = errors.New("fake error")
 
-			require.NotZero(t, c.RunTaskInput.NetworkConfiguration)
-			require.NotZero(t, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration)
-			assert.ElementsMatch(t, execOpts.AWSVPCOpts.Subnets, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.Subnets)
-			assert.ElementsMatch(t, execOpts.AWSVPCOpts.SecurityGroups, c.RunTaskInput.NetworkConfiguration.AwsvpcConfiguration.SecurityGroups)
+			p, err := pc.CreatePod(ctx, makeIdempotentOpts(t))
+			assert.Error(t, err)
+			assert.Zero(t, p)
+
+			se, ok := cocoa.AsStagedError(err)
+			require.True(t, ok, "error should identify the failed stage")
+			assert.Equal(t, cocoa.ECSPodCreationStageRun, se.Stage)
+			assert.Contains(t, se.Durations, cocoa.ECSPodCreationStageSecrets)
+			assert.Contains(t, se.Durations, cocoa.ECSPodCreationStageDefinition)
+			assert.Contains(t, se.Durations, cocoa.ECSPodCreationStageRun)
+			assert.NotContains(t, se.Durations, cocoa.ECSPodCreationStageTranslate, "translate stage should not run after run stage fails")
+
+			pce, ok := cocoa.AsPartialCreationError(err)
+			require.True(t, ok, "staged error should still indicate the partially-created resources")
+			assert.NotZero(t, pce.TaskDefinitionID)
+		},
+		"CreatePodReturnsStagedErrorWhenRegisteringTaskDefinitionFails": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			c.RegisterTaskDefinitionError = errors.New("fake error")
 
-			require.Len(t, c.RunTaskInput.Tags, 1)
-			assert.Equal(t, "execution_tag", utility.FromStringPtr(c.RunTaskInput.Tags[0].Key))
-			assert.Equal(t, execOpts.Tags["execution_tag"], utility.FromStringPtr(c.RunTaskInput.Tags[0].Value))
+			p, err := pc.CreatePod(ctx, makeIdempotentOpts(t))
+			assert.Error(t, err)
+			assert.Zero(t, p)
 
-			assert.True(t, c.RunTaskInput.EnableExecuteCommand)
+			se, ok := cocoa.AsStagedError(err)
+			require.True(t, ok, "error should identify the failed stage")
+			assert.Equal(t, cocoa.ECSPodCreationStageDefinition, se.Stage)
+			assert.Contains(t, se.Durations, cocoa.ECSPodCreationStageSecrets)
+			assert.NotContains(t, se.Durations, cocoa.ECSPodCreationStageRun)
+			assert.Zero(t, c.RunTaskInput, "should not run task after registering task definition fails")
 		},
-		"CreatePodRegistersTaskDefinitionAndRunsTaskWithNewlyCreatedSecrets": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, dc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+		"CreatePodReturnsStagedErrorWhenCreatingSecretsFails": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			sm.CreateSecretError = errors.New("fake error")
+
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("env_var_name").
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(testutil.NewSecretName(t)).
+					SetNewValue("secret_value")))
+
+			p, err := pc.CreatePod(ctx, opts)
+			assert.Error(t, err)
+			assert.Zero(t, p)
+
+			se, ok := cocoa.AsStagedError(err)
+			require.True(t, ok, "error should identify the failed stage")
+			assert.Equal(t, cocoa.ECSPodCreationStageSecrets, se.Stage)
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not register task definition after creating secrets fails")
+		},
+		"CreatePodDoesNotReturnStagedErrorForInvalidOptions": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			p, err := pc.CreatePod(ctx, *cocoa.NewECSPodCreationOptions())
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.False(t, cocoa.IsStagedError(err), "invalid options should fail before any stage runs")
+		},
+		"CreatePodFailsWithSameNewSecretNameAndDifferentValues": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].AddEnvironmentVariables(
+				*cocoa.NewEnvironmentVariable().
+					SetName("env_var_name").
+					SetSecretOptions(*cocoa.NewSecretOptions().
+						SetName("secret_name").
+						SetNewValue("secret_value")),
+				*cocoa.NewEnvironmentVariable().
+					SetName("other_env_var_name").
+					SetSecretOptions(*cocoa.NewSecretOptions().
+						SetName("secret_name").
+						SetNewValue("other_secret_value")),
+			)
+
+			p, err := pc.CreatePod(ctx, opts)
+			assert.Error(t, err)
+			assert.Zero(t, p)
+
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not register pod definition with conflicting secrets")
+		},
+		"CreatePodRegistersTaskDefinitionAndRunsTaskWithNewlyCreatedLogConfigurationSecrets": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
 			secretOpts := cocoa.NewSecretOptions().
 				SetName("secret_name").
-				SetNewValue("secret_value")
-			envVar := cocoa.NewEnvironmentVariable().
-				SetName("env_var_name").
+				SetNewValue("secret_value").
+				SetOwned(true)
+			secretOpt := cocoa.NewEnvironmentVariable().
+				SetName("log_option_name").
 				SetSecretOptions(*secretOpts)
+			logConfiguration := cocoa.NewLogConfiguration().
+				SetLogDriver(string(types.LogDriverAwslogs)).
+				SetOptions(map[string]string{
+					"awslogs-group":  "group",
+					"awslogs-region": "region",
+				}).
+				AddSecretOptions(*secretOpt)
 			containerDef := cocoa.NewECSContainerDefinition().
 				SetName("name").
 				SetImage("image").
 				SetCommand([]string{"echo", "foo"}).
-				AddEnvironmentVariables(*envVar)
+				SetLogConfiguration(*logConfiguration)
 			defOpts := cocoa.NewECSPodDefinitionOptions().
 				SetMemoryMB(512).
 				SetCPU(1024).
@@ -305,26 +1831,203 @@ func ecsPodCreatorTests() map[string]func(ctx context.Context, t *testing.T, pc
 			require.NoError(t, err)
 
 			require.NotZero(t, c.RegisterTaskDefinitionInput)
-
-			mem, err := strconv.Atoi(utility.FromStringPtr(c.RegisterTaskDefinitionInput.Memory))
-			require.NoError(t, err)
-			assert.Equal(t, utility.FromIntPtr(defOpts.MemoryMB), mem)
-			cpu, err := strconv.Atoi(utility.FromStringPtr(c.RegisterTaskDefinitionInput.Cpu))
-			require.NoError(t, err)
-			assert.Equal(t, utility.FromIntPtr(defOpts.CPU), cpu)
-			assert.Equal(t, utility.FromStringPtr(defOpts.ExecutionRole), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ExecutionRoleArn))
 			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
-			assert.Equal(t, containerDef.Command, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Command)
-			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Secrets, 1)
-			assert.Equal(t, utility.FromStringPtr(envVar.Name), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Secrets[0].Name))
+			exportedLogConfig := c.RegisterTaskDefinitionInput.ContainerDefinitions[0].LogConfiguration
+			require.NotZero(t, exportedLogConfig)
+			assert.EqualValues(t, types.LogDriverAwslogs, exportedLogConfig.LogDriver)
+			assert.Equal(t, logConfiguration.Options, exportedLogConfig.Options)
+			require.Len(t, exportedLogConfig.SecretOptions, 1)
+			assert.Equal(t, utility.FromStringPtr(secretOpt.Name), utility.FromStringPtr(exportedLogConfig.SecretOptions[0].Name))
+
 			res := p.Resources()
 			require.Len(t, res.Containers, 1)
 			require.Len(t, res.Containers[0].Secrets, 1)
-			secretID := res.Containers[0].Secrets[0].ID
-			assert.Equal(t, utility.FromStringPtr(secretID), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Secrets[0].ValueFrom))
+			assert.Equal(t, utility.FromStringPtr(res.Containers[0].Secrets[0].ID), utility.FromStringPtr(exportedLogConfig.SecretOptions[0].ValueFrom))
+			assert.True(t, utility.FromBoolPtr(res.Containers[0].Secrets[0].Owned), "log configuration secret should be owned by the pod")
 
 			assert.Equal(t, utility.FromStringPtr(secretOpts.Name), utility.FromStringPtr(sm.CreateSecretInput.Name))
 			assert.Equal(t, utility.FromStringPtr(secretOpts.NewValue), utility.FromStringPtr(sm.CreateSecretInput.SecretString))
+			assert.Zero(t, logConfiguration.SecretOptions[0].SecretOpts.ID, "original log configuration should not be modified")
+		},
+		"CreatePodFailsWithOverridesIncompatibleWithDefinition": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			containerDef := cocoa.NewECSContainerDefinition().
+				SetName("name").
+				SetImage("image").
+				SetCommand([]string{"echo", "foo"})
+			defOpts := cocoa.NewECSPodDefinitionOptions().
+				SetMemoryMB(512).
+				SetCPU(1024).
+				AddContainerDefinitions(*containerDef)
+			overrideOpts := cocoa.NewECSOverridePodDefinitionOptions().
+				AddContainerDefinitions(*cocoa.NewECSOverrideContainerDefinition().
+					SetName("name").
+					SetMemoryMB(1024))
+			execOpts := cocoa.NewECSPodExecutionOptions().
+				SetCluster(testutil.ECSClusterName()).
+				SetOverrideOptions(*overrideOpts)
+			opts := cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*execOpts)
+
+			p, err := pc.CreatePod(ctx, *opts)
+			assert.Error(t, err)
+			assert.Zero(t, p)
+
+			assert.Zero(t, c.RegisterTaskDefinitionInput)
+			assert.Zero(t, c.RunTaskInput)
+		},
+		"CreatePodRegistersTaskDefinitionWithBindMountsFromDefinitionAndOverrides": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			defMount := cocoa.NewBindMount().
+				SetSourcePath("/shared").
+				SetContainerPath("/shared").
+				SetReadOnly(true)
+			overrideMount := cocoa.NewBindMount().
+				SetSourcePath("/scratch").
+				SetContainerPath("/data")
+			containerDef := cocoa.NewECSContainerDefinition().
+				SetName("name").
+				SetImage("image").
+				SetCommand([]string{"echo", "foo"}).
+				AddBindMounts(*defMount)
+			defOpts := cocoa.NewECSPodDefinitionOptions().
+				SetMemoryMB(512).
+				SetCPU(1024).
+				AddContainerDefinitions(*containerDef)
+			overrideOpts := cocoa.NewECSOverridePodDefinitionOptions().
+				AddContainerDefinitions(*cocoa.NewECSOverrideContainerDefinition().
+					SetName("name").
+					AddBindMounts(*overrideMount))
+			execOpts := cocoa.NewECSPodExecutionOptions().
+				SetCluster(testutil.ECSClusterName()).
+				SetOverrideOptions(*overrideOpts)
+			opts := cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*execOpts)
+
+			_, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			volumes := c.RegisterTaskDefinitionInput.Volumes
+			require.Len(t, volumes, 2)
+			volumeSources := map[string]string{}
+			for _, v := range volumes {
+				require.NotZero(t, v.Host)
+				volumeSources[utility.FromStringPtr(v.Name)] = utility.FromStringPtr(v.Host.SourcePath)
+			}
+
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			mountPoints := c.RegisterTaskDefinitionInput.ContainerDefinitions[0].MountPoints
+			require.Len(t, mountPoints, 2)
+			for _, mp := range mountPoints {
+				switch utility.FromStringPtr(mp.ContainerPath) {
+				case utility.FromStringPtr(defMount.ContainerPath):
+					assert.Equal(t, utility.FromStringPtr(defMount.SourcePath), volumeSources[utility.FromStringPtr(mp.SourceVolume)])
+					assert.True(t, utility.FromBoolPtr(mp.ReadOnly))
+				case utility.FromStringPtr(overrideMount.ContainerPath):
+					assert.Equal(t, utility.FromStringPtr(overrideMount.SourcePath), volumeSources[utility.FromStringPtr(mp.SourceVolume)])
+					assert.False(t, utility.FromBoolPtr(mp.ReadOnly))
+				default:
+					assert.FailNow(t, "unexpected mount point", utility.FromStringPtr(mp.ContainerPath))
+				}
+			}
+
+			require.NotZero(t, c.RunTaskInput)
+			require.NotZero(t, c.RunTaskInput.Overrides)
+			require.Len(t, c.RunTaskInput.Overrides.ContainerOverrides, 1)
+			assert.Len(t, containerDef.BindMounts, 1, "original container definition should not be modified")
+		},
+		"CreatePodFromExistingDefinitionFailsWithBindMountOverrides": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerIn := testutil.ValidRegisterTaskDefinitionInput(t)
+			registerOut, err := c.RegisterTaskDefinition(ctx, &registerIn)
+			require.NoError(t, err)
+			require.NotZero(t, registerOut)
+			require.NotZero(t, registerOut.TaskDefinition)
+
+			overrideOpts := cocoa.NewECSOverridePodDefinitionOptions().
+				AddContainerDefinitions(*cocoa.NewECSOverrideContainerDefinition().
+					SetName(utility.FromStringPtr(registerIn.ContainerDefinitions[0].Name)).
+					AddBindMounts(*cocoa.NewBindMount().SetSourcePath("/scratch").SetContainerPath("/data")))
+			execOpts := cocoa.NewECSPodExecutionOptions().
+				SetCluster(testutil.ECSClusterName()).
+				SetOverrideOptions(*overrideOpts)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			p, err := pc.CreatePodFromExistingDefinition(ctx, *def, *execOpts)
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.Zero(t, c.RunTaskInput)
+		},
+		"CreatePodRegistersTaskDefinitionWithSecretEnvVarOverrides": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			containerDef := cocoa.NewECSContainerDefinition().
+				SetName("name").
+				SetImage("image").
+				SetCommand([]string{"echo", "foo"}).
+				AddEnvironmentVariables(
+					*cocoa.NewEnvironmentVariable().SetName("PLAIN").SetValue("plain_value"),
+					*cocoa.NewEnvironmentVariable().SetName("CREDENTIALS").SetSecretOptions(*cocoa.NewSecretOptions().SetID("default_credentials")),
+				)
+			defOpts := cocoa.NewECSPodDefinitionOptions().
+				SetMemoryMB(512).
+				SetCPU(1024).
+				AddContainerDefinitions(*containerDef)
+			overrideOpts := cocoa.NewECSOverridePodDefinitionOptions().
+				AddContainerDefinitions(*cocoa.NewECSOverrideContainerDefinition().
+					SetName("name").
+					AddSecretEnvironmentVariables(
+						*cocoa.NewEnvironmentVariable().SetName("CREDENTIALS").SetSecretOptions(*cocoa.NewSecretOptions().SetID("run_credentials")),
+						*cocoa.NewEnvironmentVariable().SetName("TOKEN").SetSecretOptions(*cocoa.NewSecretOptions().SetID("run_token")),
+					))
+			execOpts := cocoa.NewECSPodExecutionOptions().
+				SetCluster(testutil.ECSClusterName()).
+				SetOverrideOptions(*overrideOpts)
+			opts := cocoa.NewECSPodCreationOptions().
+				SetDefinitionOptions(*defOpts).
+				SetExecutionOptions(*execOpts)
+
+			_, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			registeredDef := c.RegisterTaskDefinitionInput.ContainerDefinitions[0]
+			require.Len(t, registeredDef.Environment, 1)
+			assert.Equal(t, "PLAIN", utility.FromStringPtr(registeredDef.Environment[0].Name))
+			secrets := map[string]string{}
+			for _, s := range registeredDef.Secrets {
+				secrets[utility.FromStringPtr(s.Name)] = utility.FromStringPtr(s.ValueFrom)
+			}
+			assert.Equal(t, map[string]string{
+				"CREDENTIALS": "run_credentials",
+				"TOKEN":       "run_token",
+			}, secrets, "overriding secrets should replace or be added to the definition's secrets")
+
+			assert.Zero(t, sm.CreateSecretInput, "should not have created any secrets")
+			require.Len(t, containerDef.EnvVars, 2, "original container definition should not be modified")
+			assert.Equal(t, "default_credentials", utility.FromStringPtr(containerDef.EnvVars[1].SecretOpts.ID), "original container definition should not be modified")
+		},
+		"CreatePodFromExistingDefinitionFailsWithSecretEnvVarOverrides": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			registerIn := testutil.ValidRegisterTaskDefinitionInput(t)
+			registerOut, err := c.RegisterTaskDefinition(ctx, &registerIn)
+			require.NoError(t, err)
+			require.NotZero(t, registerOut)
+			require.NotZero(t, registerOut.TaskDefinition)
+
+			overrideOpts := cocoa.NewECSOverridePodDefinitionOptions().
+				AddContainerDefinitions(*cocoa.NewECSOverrideContainerDefinition().
+					SetName(utility.FromStringPtr(registerIn.ContainerDefinitions[0].Name)).
+					AddSecretEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+						SetName("TOKEN").
+						SetSecretOptions(*cocoa.NewSecretOptions().SetID("run_token"))))
+			execOpts := cocoa.NewECSPodExecutionOptions().
+				SetCluster(testutil.ECSClusterName()).
+				SetOverrideOptions(*overrideOpts)
+
+			def := cocoa.NewECSTaskDefinition().SetID(utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn))
+			p, err := pc.CreatePodFromExistingDefinition(ctx, *def, *execOpts)
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.Zero(t, c.RunTaskInput)
 		},
 		"CreatePodRegistersTaskDefinitionAndRunsTaskWithNewlyCreatedRepositoryCredentials": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
 			repoCreds := cocoa.NewRepositoryCredentials().
@@ -373,6 +2076,86 @@ func ecsPodCreatorTests() map[string]func(ctx context.Context, t *testing.T, pc
 			require.NoError(t, err)
 			assert.Equal(t, string(storedCreds), utility.FromStringPtr(sm.CreateSecretInput.SecretString))
 		},
+		"CreatePodUsesExistingRepositoryCredentialsWithCreateIfMissing": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			name := testutil.NewSecretName(t)
+			createOut, err := sm.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+				Name:         utility.ToStringPtr(name),
+				SecretString: utility.ToStringPtr("existing_creds"),
+			})
+			require.NoError(t, err)
+			sm.CreateSecretInput = nil
+
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].SetRepositoryCredentials(*cocoa.NewRepositoryCredentials().
+				SetName(name).
+				SetNewCredentials(*cocoa.NewStoredRepositoryCredentials().
+					SetUsername("username").
+					SetPassword("password")).
+				SetCreateIfMissing(true).
+				SetOwned(true))
+
+			p, err := pc.CreatePod(ctx, opts)
+			require.NoError(t, err)
+
+			assert.Zero(t, sm.CreateSecretInput, "should not have created credentials that already exist")
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			require.NotZero(t, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].RepositoryCredentials)
+			assert.Equal(t, utility.FromStringPtr(createOut.ARN), utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].RepositoryCredentials.CredentialsParameter))
+
+			res := p.Resources()
+			require.Len(t, res.Containers, 1)
+			require.Len(t, res.Containers[0].Secrets, 1)
+			assert.Equal(t, utility.FromStringPtr(createOut.ARN), utility.FromStringPtr(res.Containers[0].Secrets[0].ID))
+			assert.False(t, utility.FromBoolPtr(res.Containers[0].Secrets[0].Owned), "pod should not own credentials that already existed")
+
+			require.NoError(t, p.Delete(ctx))
+			val, err := sm.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: createOut.ARN})
+			require.NoError(t, err, "existing credentials should not be deleted with the pod")
+			assert.Equal(t, "existing_creds", utility.FromStringPtr(val.SecretString))
+		},
+		"CreatePodCreatesMissingRepositoryCredentialsWithCreateIfMissing": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			repoCreds := cocoa.NewRepositoryCredentials().
+				SetName(testutil.NewSecretName(t)).
+				SetNewCredentials(*cocoa.NewStoredRepositoryCredentials().
+					SetUsername("username").
+					SetPassword("password")).
+				SetCreateIfMissing(true).
+				SetOwned(true)
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].SetRepositoryCredentials(*repoCreds)
+
+			p, err := pc.CreatePod(ctx, opts)
+			require.NoError(t, err)
+
+			require.NotZero(t, sm.CreateSecretInput, "should have created the missing credentials")
+			assert.Equal(t, utility.FromStringPtr(repoCreds.Name), utility.FromStringPtr(sm.CreateSecretInput.Name))
+			storedCreds, err := json.Marshal(repoCreds.NewCreds)
+			require.NoError(t, err)
+			assert.Equal(t, string(storedCreds), utility.FromStringPtr(sm.CreateSecretInput.SecretString))
+
+			res := p.Resources()
+			require.Len(t, res.Containers, 1)
+			require.Len(t, res.Containers[0].Secrets, 1)
+			assert.True(t, utility.FromBoolPtr(res.Containers[0].Secrets[0].Owned))
+		},
+		"CreatePodFailsWithCreateIfMissingRepositoryCredentialsWhenFindingSecretFails": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			sm.DescribeSecretError = errors.New("fake error")
+
+			opts := makeIdempotentOpts(t)
+			opts.DefinitionOpts.ContainerDefinitions[0].SetRepositoryCredentials(*cocoa.NewRepositoryCredentials().
+				SetName(testutil.NewSecretName(t)).
+				SetNewCredentials(*cocoa.NewStoredRepositoryCredentials().
+					SetUsername("username").
+					SetPassword("password")).
+				SetCreateIfMissing(true))
+
+			p, err := pc.CreatePod(ctx, opts)
+			assert.Error(t, err)
+			assert.Zero(t, p)
+			assert.Zero(t, sm.CreateSecretInput)
+			assert.Zero(t, c.RegisterTaskDefinitionInput)
+		},
 		"CreatingNewSecretsIsRetryable": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
 			secretOpts := cocoa.NewSecretOptions().
 				SetName("secret_name").
@@ -476,3 +2259,21 @@ func ecsPodCreatorTests() map[string]func(ctx context.Context, t *testing.T, pc
 		},
 	}
 }
+
+// roleRecordingECSClient is an ECS client that records the role to assume from
+// the context of the calls to create and run pods.
+type roleRecordingECSClient struct {
+	*ECSClient
+	registerTaskDefinitionRole *cocoa.AssumeRoleOptions
+	runTaskRole                *cocoa.AssumeRoleOptions
+}
+
+func (c *roleRecordingECSClient) RegisterTaskDefinition(ctx context.Context, in *awsECS.RegisterTaskDefinitionInput) (*awsECS.RegisterTaskDefinitionOutput, error) {
+	c.registerTaskDefinitionRole = cocoa.AssumeRoleFromContext(ctx)
+	return c.ECSClient.RegisterTaskDefinition(ctx, in)
+}
+
+func (c *roleRecordingECSClient) RunTask(ctx context.Context, in *awsECS.RunTaskInput) (*awsECS.RunTaskOutput, error) {
+	c.runTaskRole = cocoa.AssumeRoleFromContext(ctx)
+	return c.ECSClient.RunTask(ctx, in)
+}
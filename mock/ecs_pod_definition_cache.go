@@ -5,10 +5,11 @@ import (
 
 	"github.com/evergreen-ci/cocoa"
 	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
 )
 
 // ECSPodDefinitionCache provides a mock implementation of a
-// cocoa.ECSPodDefinitionCache backed by another ECS pod definition cache
+// cocoa.ECSPodDefinitionRunCache backed by another ECS pod definition cache
 // implementation.
 type ECSPodDefinitionCache struct {
 	cocoa.ECSPodDefinitionCache
@@ -20,6 +21,17 @@ type ECSPodDefinitionCache struct {
 	DeleteError error
 
 	Tag *string
+
+	PutRunInput *cocoa.ECSPodDefinitionItem
+	PutRunError error
+
+	GetRunInput  *string
+	GetRunOutput *cocoa.ECSPodDefinitionItem
+	GetRunError  error
+
+	// Runs are the pod runs recorded by external key if the backing cache does
+	// not support tracking pod runs.
+	Runs map[string]cocoa.ECSPodDefinitionItem
 }
 
 // NewECSPodDefinitionCache creates a mock ECS pod definition cache backed
@@ -66,3 +78,53 @@ func (c *ECSPodDefinitionCache) GetTag() string {
 
 	return c.ECSPodDefinitionCache.GetTag()
 }
+
+// PutRun records the item's last pod run in the mock cache. The mock output can
+// be customized. By default, it will return the result of putting the run in
+// the backing ECS pod definition cache if it supports tracking pod runs;
+// otherwise, it records the run in the mock cache's runs.
+func (c *ECSPodDefinitionCache) PutRun(ctx context.Context, item cocoa.ECSPodDefinitionItem) error {
+	c.PutRunInput = &item
+
+	if c.PutRunError != nil {
+		return c.PutRunError
+	}
+
+	if rc, ok := c.ECSPodDefinitionCache.(cocoa.ECSPodDefinitionRunCache); ok {
+		return rc.PutRun(ctx, item)
+	}
+
+	if item.LastRun == nil {
+		return errors.New("cannot record a pod run without a last run")
+	}
+	if c.Runs == nil {
+		c.Runs = map[string]cocoa.ECSPodDefinitionItem{}
+	}
+	c.Runs[item.LastRun.Key] = item
+
+	return nil
+}
+
+// GetRun returns the item whose last pod run was for the key from the mock
+// cache. The mock output can be customized. By default, it will return the
+// result of getting the run from the backing ECS pod definition cache if it
+// supports tracking pod runs; otherwise, it returns the run from the mock
+// cache's runs.
+func (c *ECSPodDefinitionCache) GetRun(ctx context.Context, key string) (*cocoa.ECSPodDefinitionItem, error) {
+	c.GetRunInput = &key
+
+	if c.GetRunOutput != nil || c.GetRunError != nil {
+		return c.GetRunOutput, c.GetRunError
+	}
+
+	if rc, ok := c.ECSPodDefinitionCache.(cocoa.ECSPodDefinitionRunCache); ok {
+		return rc.GetRun(ctx, key)
+	}
+
+	item, ok := c.Runs[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return &item, nil
+}
@@ -9,4 +9,5 @@ import (
 
 func TestECSPodDefinitionCache(t *testing.T) {
 	assert.Implements(t, (*cocoa.ECSPodDefinitionCache)(nil), &ECSPodDefinitionCache{})
+	assert.Implements(t, (*cocoa.ECSPodDefinitionRunCache)(nil), &ECSPodDefinitionCache{})
 }
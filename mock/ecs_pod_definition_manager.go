@@ -5,11 +5,14 @@ import (
 
 	"github.com/evergreen-ci/cocoa"
 	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
 )
 
 // ECSPodDefinitionManager provides a mock implementation of a
 // cocoa.ECSPodDefinitionManager backed by another ECS pod definition manager
-// implementation.
+// implementation. It also implements the optional pod definition manager
+// interfaces, which fail by default if the backing pod definition manager does
+// not implement them.
 type ECSPodDefinitionManager struct {
 	cocoa.ECSPodDefinitionManager
 
@@ -19,6 +22,27 @@ type ECSPodDefinitionManager struct {
 
 	DeletePodDefinitionInput *string
 	DeletePodDefinitionError error
+
+	ListPodDefinitionRevisionsInput  *string
+	ListPodDefinitionRevisionsOutput []cocoa.ECSPodDefinitionRevision
+	ListPodDefinitionRevisionsError  error
+
+	ImportPodDefinitionInput  *string
+	ImportPodDefinitionOutput *cocoa.ECSPodDefinitionItem
+	ImportPodDefinitionError  error
+
+	UpdatePodDefinitionInput  *UpdatePodDefinitionInput
+	UpdatePodDefinitionOutput *cocoa.ECSPodDefinitionItem
+	UpdatePodDefinitionError  error
+
+	VerifyDefinitionIntegrityInput *string
+	VerifyDefinitionIntegrityError error
+}
+
+// UpdatePodDefinitionInput is the input to UpdatePodDefinition.
+type UpdatePodDefinitionInput struct {
+	ID   string
+	Opts cocoa.ECSPodDefinitionUpdateOptions
 }
 
 // NewECSPodDefinitionManager creates a mock ECS pod definition manager backed
@@ -57,3 +81,77 @@ func (m *ECSPodDefinitionManager) DeletePodDefinition(ctx context.Context, id st
 
 	return m.ECSPodDefinitionManager.DeletePodDefinition(ctx, id)
 }
+
+// ListPodDefinitionRevisions saves the input and lists the revisions of the
+// mock pod definition family. The mock output can be customized. By default, it
+// will return the result of listing the revisions from the backing ECS pod
+// definition manager.
+func (m *ECSPodDefinitionManager) ListPodDefinitionRevisions(ctx context.Context, family string) ([]cocoa.ECSPodDefinitionRevision, error) {
+	m.ListPodDefinitionRevisionsInput = utility.ToStringPtr(family)
+
+	if m.ListPodDefinitionRevisionsOutput != nil || m.ListPodDefinitionRevisionsError != nil {
+		return m.ListPodDefinitionRevisionsOutput, m.ListPodDefinitionRevisionsError
+	}
+
+	lister, ok := m.ECSPodDefinitionManager.(cocoa.ECSPodDefinitionRevisionLister)
+	if !ok {
+		return nil, errors.New("backing pod definition manager does not support listing pod definition revisions")
+	}
+
+	return lister.ListPodDefinitionRevisions(ctx, family)
+}
+
+// ImportPodDefinition saves the input and imports the mock pod definition. The
+// mock output can be customized. By default, it will return the result of
+// importing the pod definition into the backing ECS pod definition manager.
+func (m *ECSPodDefinitionManager) ImportPodDefinition(ctx context.Context, id string) (*cocoa.ECSPodDefinitionItem, error) {
+	m.ImportPodDefinitionInput = utility.ToStringPtr(id)
+
+	if m.ImportPodDefinitionOutput != nil || m.ImportPodDefinitionError != nil {
+		return m.ImportPodDefinitionOutput, m.ImportPodDefinitionError
+	}
+
+	importer, ok := m.ECSPodDefinitionManager.(cocoa.ECSPodDefinitionImporter)
+	if !ok {
+		return nil, errors.New("backing pod definition manager does not support importing pod definitions")
+	}
+
+	return importer.ImportPodDefinition(ctx, id)
+}
+
+// UpdatePodDefinition saves the input and updates the mock pod definition. The
+// mock output can be customized. By default, it will return the result of
+// updating the pod definition in the backing ECS pod definition manager.
+func (m *ECSPodDefinitionManager) UpdatePodDefinition(ctx context.Context, id string, opts cocoa.ECSPodDefinitionUpdateOptions) (*cocoa.ECSPodDefinitionItem, error) {
+	m.UpdatePodDefinitionInput = &UpdatePodDefinitionInput{ID: id, Opts: opts}
+
+	if m.UpdatePodDefinitionOutput != nil || m.UpdatePodDefinitionError != nil {
+		return m.UpdatePodDefinitionOutput, m.UpdatePodDefinitionError
+	}
+
+	updater, ok := m.ECSPodDefinitionManager.(cocoa.ECSPodDefinitionUpdater)
+	if !ok {
+		return nil, errors.New("backing pod definition manager does not support updating pod definitions")
+	}
+
+	return updater.UpdatePodDefinition(ctx, id, opts)
+}
+
+// VerifyDefinitionIntegrity saves the input and verifies the mock pod
+// definition. The mock output can be customized. By default, it will return
+// the result of verifying the pod definition in the backing ECS pod definition
+// manager.
+func (m *ECSPodDefinitionManager) VerifyDefinitionIntegrity(ctx context.Context, id string) error {
+	m.VerifyDefinitionIntegrityInput = utility.ToStringPtr(id)
+
+	if m.VerifyDefinitionIntegrityError != nil {
+		return m.VerifyDefinitionIntegrityError
+	}
+
+	verifier, ok := m.ECSPodDefinitionManager.(cocoa.ECSPodDefinitionIntegrityVerifier)
+	if !ok {
+		return errors.New("backing pod definition manager does not support verifying pod definition integrity")
+	}
+
+	return verifier.VerifyDefinitionIntegrity(ctx, id)
+}
@@ -3,8 +3,12 @@ package mock
 import (
 	"context"
 	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsECS "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"github.com/evergreen-ci/cocoa"
 	"github.com/evergreen-ci/cocoa/ecs"
 	"github.com/evergreen-ci/cocoa/internal/testcase"
@@ -18,6 +22,10 @@ import (
 
 func TestECSPodDefinitionManager(t *testing.T) {
 	assert.Implements(t, (*cocoa.ECSPodDefinitionManager)(nil), &ECSPodDefinitionManager{})
+	assert.Implements(t, (*cocoa.ECSPodDefinitionRevisionLister)(nil), &ECSPodDefinitionManager{})
+	assert.Implements(t, (*cocoa.ECSPodDefinitionImporter)(nil), &ECSPodDefinitionManager{})
+	assert.Implements(t, (*cocoa.ECSPodDefinitionUpdater)(nil), &ECSPodDefinitionManager{})
+	assert.Implements(t, (*cocoa.ECSPodDefinitionIntegrityVerifier)(nil), &ECSPodDefinitionManager{})
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -37,10 +45,13 @@ func TestECSPodDefinitionManager(t *testing.T) {
 
 			pdc := NewECSPodDefinitionCache(&testutil.NoopECSPodDefinitionCache{Tag: "cache-tag"})
 
+			// The mock clients are not safe for concurrent use, so revisions
+			// have to be described one at a time.
 			pdm, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
 				SetClient(c).
 				SetVault(mv).
-				SetCache(pdc))
+				SetCache(pdc).
+				SetRevisionDescribeConcurrency(1))
 			require.NoError(t, err)
 
 			m := NewECSPodDefinitionManager(pdm)
@@ -56,8 +67,12 @@ func TestECSPodDefinitionManager(t *testing.T) {
 
 			resetECSAndSecretsManagerCache()
 
+			// The mock clients are not safe for concurrent use, so revisions
+			// have to be described one at a time.
 			c := &ECSClient{}
-			opts := ecs.NewBasicPodDefinitionManagerOptions().SetClient(c)
+			opts := ecs.NewBasicPodDefinitionManagerOptions().
+				SetClient(c).
+				SetRevisionDescribeConcurrency(1)
 
 			pdm, err := ecs.NewBasicPodDefinitionManager(*opts)
 			require.NoError(t, err)
@@ -93,6 +108,160 @@ func TestECSPodDefinitionManager(t *testing.T) {
 			tCase(tctx, t, mpdm)
 		})
 	}
+
+	t.Run("CreatePodDefinitionUsesCustomTagNameOverCacheTag", func(t *testing.T) {
+		tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+		defer tcancel()
+
+		resetECSAndSecretsManagerCache()
+
+		c := &ECSClient{}
+		pdc := NewECSPodDefinitionCache(&testutil.NoopECSPodDefinitionCache{Tag: "cache-tag"})
+		pdm, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
+			SetClient(c).
+			SetCache(pdc).
+			SetTagName("custom-tag"))
+		require.NoError(t, err)
+
+		containerDef := cocoa.NewECSContainerDefinition().
+			SetName("name").
+			SetImage("image").
+			SetCommand([]string{"echo", "foo"})
+		opts := cocoa.NewECSPodDefinitionOptions().
+			SetName(testutil.NewTaskDefinitionFamily(t)).
+			SetMemoryMB(128).
+			SetCPU(128).
+			AddContainerDefinitions(*containerDef)
+
+		pdi, err := pdm.CreatePodDefinition(tctx, *opts)
+		require.NoError(t, err)
+		require.NotZero(t, pdi)
+
+		require.NotZero(t, c.RegisterTaskDefinitionInput)
+		require.Len(t, c.RegisterTaskDefinitionInput.Tags, 2, "should have the cache tracking tag and the hash tag")
+		assert.Contains(t, c.RegisterTaskDefinitionInput.Tags, types.Tag{Key: aws.String("custom-tag"), Value: aws.String("false")})
+		assert.NotContains(t, c.RegisterTaskDefinitionInput.Tags, types.Tag{Key: aws.String("cache-tag"), Value: aws.String("false")})
+
+		require.NotZero(t, c.TagResourceInput)
+		require.Len(t, c.TagResourceInput.Tags, 1)
+		assert.Equal(t, "custom-tag", utility.FromStringPtr(c.TagResourceInput.Tags[0].Key))
+		assert.Equal(t, "true", utility.FromStringPtr(c.TagResourceInput.Tags[0].Value))
+	})
+	t.Run("CreatePodDefinitionPropagatesSelectedTagsToNewSecrets", func(t *testing.T) {
+		tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+		defer tcancel()
+
+		resetECSAndSecretsManagerCache()
+
+		c := &ECSClient{}
+		sm := &SecretsManagerClient{}
+		v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(sm))
+		require.NoError(t, err)
+		pdc := NewECSPodDefinitionCache(&testutil.NoopECSPodDefinitionCache{Tag: "cache-tag"})
+		pdm, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
+			SetClient(c).
+			SetVault(v).
+			SetCache(pdc).
+			SetSecretTagPropagationOptions(*ecs.NewSecretTagPropagationOptions().AddKeys("cost-center")))
+		require.NoError(t, err)
+
+		secretName := testutil.NewSecretName(t)
+		containerDef := cocoa.NewECSContainerDefinition().
+			SetName("name").
+			SetImage("image").
+			AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("env_var_name").
+				SetSecretOptions(*cocoa.NewSecretOptions().
+					SetName(secretName).
+					SetNewValue("secret_value").
+					SetTags(map[string]string{"owner": "team"})))
+		opts := cocoa.NewECSPodDefinitionOptions().
+			SetName(testutil.NewTaskDefinitionFamily(t)).
+			SetMemoryMB(128).
+			SetCPU(128).
+			SetTags(map[string]string{"cost-center": "123", "env": "prod"}).
+			AddContainerDefinitions(*containerDef)
+
+		pdi, err := pdm.CreatePodDefinition(tctx, *opts)
+		require.NoError(t, err)
+		require.NotZero(t, pdi)
+
+		require.NotZero(t, sm.CreateSecretInput)
+		assert.Equal(t, secretName, utility.FromStringPtr(sm.CreateSecretInput.Name))
+		tags := map[string]string{}
+		for _, tag := range sm.CreateSecretInput.Tags {
+			tags[utility.FromStringPtr(tag.Key)] = utility.FromStringPtr(tag.Value)
+		}
+		assert.Equal(t, "123", tags["cost-center"], "selected pod definition tag should be propagated")
+		assert.Equal(t, "team", tags["owner"], "secret's own tags should be kept")
+		assert.NotContains(t, tags, "env", "unselected pod definition tag should not be propagated")
+		assert.NotContains(t, tags, "cache-tag", "pod definition cache tag should not be propagated")
+	})
+	t.Run("VerifyDefinitionIntegrityDetectsDrift", func(t *testing.T) {
+		tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+		defer tcancel()
+
+		resetECSAndSecretsManagerCache()
+
+		c := &ECSClient{}
+		pdm, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
+			SetClient(c).
+			SetChecksumTagName("checksum-tag"))
+		require.NoError(t, err)
+
+		family := testutil.NewTaskDefinitionFamily(t)
+		containerDef := cocoa.NewECSContainerDefinition().
+			SetName("name").
+			SetImage("image").
+			SetCommand([]string{"echo", "foo"}).
+			AddEnvironmentVariables(
+				*cocoa.NewEnvironmentVariable().SetName("b").SetValue("1"),
+				*cocoa.NewEnvironmentVariable().SetName("a").SetValue("2"),
+			)
+		opts := cocoa.NewECSPodDefinitionOptions().
+			SetName(family).
+			SetMemoryMB(512).
+			SetCPU(256).
+			AddContainerDefinitions(*containerDef)
+
+		pdi, err := pdm.CreatePodDefinition(tctx, *opts)
+		require.NoError(t, err)
+		require.NotZero(t, pdi)
+
+		require.NotZero(t, c.RegisterTaskDefinitionInput)
+		var checksum string
+		for _, tag := range c.RegisterTaskDefinitionInput.Tags {
+			if utility.FromStringPtr(tag.Key) == "checksum-tag" {
+				checksum = utility.FromStringPtr(tag.Value)
+			}
+		}
+		assert.NotZero(t, checksum)
+
+		require.NoError(t, pdm.VerifyDefinitionIntegrity(tctx, pdi.ID))
+
+		GlobalECSService.TaskDefs[family][0].CPU = utility.ToStringPtr("512")
+		err = pdm.VerifyDefinitionIntegrity(tctx, pdi.ID)
+		require.Error(t, err)
+		assert.True(t, cocoa.IsDefinitionDriftError(err))
+
+		delete(GlobalECSService.TaskDefs[family][0].Tags, "checksum-tag")
+		err = pdm.VerifyDefinitionIntegrity(tctx, pdi.ID)
+		require.Error(t, err)
+		assert.True(t, cocoa.IsDefinitionDriftError(err))
+	})
+	t.Run("VerifyDefinitionIntegrityFailsWithoutChecksumTagName", func(t *testing.T) {
+		tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+		defer tcancel()
+
+		resetECSAndSecretsManagerCache()
+
+		pdm, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().SetClient(&ECSClient{}))
+		require.NoError(t, err)
+
+		err = pdm.VerifyDefinitionIntegrity(tctx, testutil.NewTaskDefinitionFamily(t)+":1")
+		assert.Error(t, err)
+		assert.False(t, cocoa.IsDefinitionDriftError(err))
+	})
 }
 
 // ecsPodDefinitionManagerTests are mock-specific tests for ECS and Secrets
@@ -119,6 +288,470 @@ func ecsPodDefinitionManagerTests() map[string]func(ctx context.Context, t *test
 		return *opts
 	}
 	return map[string]func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient){
+		"ImportPodDefinitionTranslatesSecretReferencesAndCachesPodDefinition": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			in := testutil.ValidRegisterTaskDefinitionInput(t)
+			in.TaskRoleArn = aws.String("task_role")
+			in.ExecutionRoleArn = aws.String("execution_role")
+			in.NetworkMode = types.NetworkModeAwsvpc
+			in.Tags = []types.Tag{{Key: aws.String("key"), Value: aws.String("value")}}
+			in.ContainerDefinitions[0].Secrets = []types.Secret{{Name: aws.String("secret_name"), ValueFrom: aws.String("secret_id")}}
+			in.ContainerDefinitions[0].RepositoryCredentials = &types.RepositoryCredentials{CredentialsParameter: aws.String("repo_creds_id")}
+			in.ContainerDefinitions[0].LogConfiguration = &types.LogConfiguration{
+				LogDriver: types.LogDriverAwslogs,
+				Options:   map[string]string{"awslogs-group": "group"},
+			}
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, in)
+			id := utility.FromStringPtr(registerOut.TaskDefinition.TaskDefinitionArn)
+
+			pdi, err := pdm.ImportPodDefinition(ctx, id)
+			require.NoError(t, err)
+			require.NotZero(t, pdi)
+			assert.Equal(t, id, pdi.ID)
+
+			opts := pdi.DefinitionOpts
+			assert.Equal(t, utility.FromStringPtr(in.Family), utility.FromStringPtr(opts.Name))
+			assert.Equal(t, 128, utility.FromIntPtr(opts.CPU))
+			assert.Equal(t, 256, utility.FromIntPtr(opts.MemoryMB))
+			assert.Equal(t, "task_role", utility.FromStringPtr(opts.TaskRole))
+			assert.Equal(t, "execution_role", utility.FromStringPtr(opts.ExecutionRole))
+			require.NotZero(t, opts.NetworkMode)
+			assert.Equal(t, cocoa.NetworkModeAWSVPC, *opts.NetworkMode)
+			assert.Equal(t, cocoa.Tags{"key": "value"}, opts.Tags)
+
+			require.Len(t, opts.ContainerDefinitions, 1)
+			containerDef := opts.ContainerDefinitions[0]
+			assert.Equal(t, utility.FromStringPtr(in.ContainerDefinitions[0].Name), utility.FromStringPtr(containerDef.Name))
+			assert.Equal(t, in.ContainerDefinitions[0].Command, containerDef.Command)
+			require.Len(t, containerDef.EnvVars, 1)
+			assert.Equal(t, "secret_name", utility.FromStringPtr(containerDef.EnvVars[0].Name))
+			require.NotZero(t, containerDef.EnvVars[0].SecretOpts)
+			assert.Equal(t, "secret_id", utility.FromStringPtr(containerDef.EnvVars[0].SecretOpts.ID))
+			assert.False(t, utility.FromBoolPtr(containerDef.EnvVars[0].SecretOpts.Owned))
+			require.NotZero(t, containerDef.RepoCreds)
+			assert.Equal(t, "repo_creds_id", utility.FromStringPtr(containerDef.RepoCreds.ID))
+			assert.False(t, utility.FromBoolPtr(containerDef.RepoCreds.Owned))
+			require.NotZero(t, containerDef.LogConfiguration)
+			assert.Equal(t, string(types.LogDriverAwslogs), utility.FromStringPtr(containerDef.LogConfiguration.LogDriver))
+			assert.Equal(t, map[string]string{"awslogs-group": "group"}, containerDef.LogConfiguration.Options)
+			assert.NoError(t, opts.Validate())
+
+			require.NotZero(t, pdc.PutInput, "should have cached the imported pod definition")
+			assert.Equal(t, id, pdc.PutInput.ID)
+			require.NotZero(t, c.TagResourceInput, "should have tagged the imported pod definition as tracked")
+			assert.Equal(t, id, utility.FromStringPtr(c.TagResourceInput.ResourceArn))
+			require.Len(t, c.TagResourceInput.Tags, 1)
+			assert.Equal(t, "true", utility.FromStringPtr(c.TagResourceInput.Tags[0].Value))
+			assert.Zero(t, sm.CreateSecretInput, "should not have created any secrets")
+		},
+		"SyncCacheFromECSCachesTrackedPodDefinitions": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			bpdm, ok := pdm.ECSPodDefinitionManager.(*ecs.BasicPodDefinitionManager)
+			require.True(t, ok)
+
+			opts := getValidPodDefOpts(t)
+			cached, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, cached.Hash)
+
+			pdc.PutError = errors.New("fake error")
+			uncachedOpts := getValidPodDefOpts(t)
+			_, err = pdm.CreatePodDefinition(ctx, uncachedOpts)
+			require.Error(t, err)
+			uncachedDefs := GlobalECSService.TaskDefs[utility.FromStringPtr(uncachedOpts.Name)]
+			require.Len(t, uncachedDefs, 1)
+			uncachedID := uncachedDefs[0].ARN
+			pdc.PutError = nil
+
+			untrackedOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			untrackedID := utility.FromStringPtr(untrackedOut.TaskDefinition.TaskDefinitionArn)
+
+			for _, original := range []cocoa.ECSPodDefinitionOptions{opts, uncachedOpts} {
+				family := utility.FromStringPtr(original.Name)
+				res, err := ecs.SyncCacheFromECS(ctx, bpdm, family)
+				require.NoError(t, err)
+				require.NotZero(t, res)
+				require.Len(t, res.Synced, 1)
+				assert.Empty(t, res.Skipped)
+				assert.Empty(t, res.Failed)
+
+				item := res.Synced[0]
+				assert.Equal(t, family, utility.FromStringPtr(item.DefinitionOpts.Name))
+				assert.Equal(t, "creation_val", item.DefinitionOpts.Tags["creation_tag"])
+				require.NotZero(t, pdc.PutInput)
+				assert.Equal(t, item.ID, pdc.PutInput.ID)
+				assert.NotContains(t, item.DefinitionOpts.Tags, cocoa.PodDefinitionHashTagKey)
+				hash, err := bpdm.HashPodDefinition(original)
+				require.NoError(t, err)
+				assert.Equal(t, hash, item.Hash, "synced hash should match the hash of the options the pod definition was created from")
+				assert.Equal(t, hash, pdc.PutInput.Hash)
+			}
+
+			assert.Equal(t, "true", GlobalECSService.TaskDefs[utility.FromStringPtr(opts.Name)][0].Tags[pdc.GetTag()])
+			assert.Equal(t, cached.ID, GlobalECSService.TaskDefs[utility.FromStringPtr(opts.Name)][0].ARN)
+			assert.Equal(t, "true", GlobalECSService.TaskDefs[utility.FromStringPtr(uncachedOpts.Name)][0].Tags[pdc.GetTag()], "uncached pod definition should be tagged as tracked after it is synced")
+			require.NotZero(t, c.TagResourceInput)
+			assert.Equal(t, uncachedID, utility.FromStringPtr(c.TagResourceInput.ResourceArn))
+
+			res, err := ecs.SyncCacheFromECS(ctx, bpdm, "")
+			require.NoError(t, err)
+			require.NotZero(t, res)
+			assert.Len(t, res.Synced, 2)
+			assert.Equal(t, []string{untrackedID}, res.Skipped, "pod definition without the tracking tag should not be synced")
+		},
+		"CreatePodDefinitionWithActiveWaitOptionsWaitsForActivePodDefinition": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			waitingPDM, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
+				SetClient(c).
+				SetActiveWaitOptions(utility.RetryOptions{MaxAttempts: 2}))
+			require.NoError(t, err)
+
+			pdi, err := waitingPDM.CreatePodDefinition(ctx, getValidPodDefOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, pdi)
+
+			require.NotZero(t, c.DescribeTaskDefinitionInput, "should have checked that the task definition is active")
+			assert.Equal(t, pdi.ID, utility.FromStringPtr(c.DescribeTaskDefinitionInput.TaskDefinition))
+		},
+		"CreatePodDefinitionWithActiveWaitOptionsFailsWhenPodDefinitionIsInactive": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			waitingPDM, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
+				SetClient(c).
+				SetActiveWaitOptions(utility.RetryOptions{MaxAttempts: 2}))
+			require.NoError(t, err)
+
+			c.DescribeTaskDefinitionOutput = &awsECS.DescribeTaskDefinitionOutput{
+				TaskDefinition: &types.TaskDefinition{Status: types.TaskDefinitionStatusInactive},
+			}
+
+			pdi, err := waitingPDM.CreatePodDefinition(ctx, getValidPodDefOpts(t))
+			require.Error(t, err)
+			assert.Zero(t, pdi)
+			pce, ok := cocoa.AsPartialCreationError(err)
+			require.True(t, ok, "should return a partial creation error")
+			assert.NotZero(t, pce.TaskDefinitionID)
+		},
+		"ImportPodDefinitionRecoversHashFromHashTag": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			created, err := pdm.CreatePodDefinition(ctx, getValidPodDefOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, created.Hash)
+
+			imported, err := pdm.ImportPodDefinition(ctx, created.ID)
+			require.NoError(t, err)
+			require.NotZero(t, imported)
+			assert.Equal(t, created.Hash, imported.Hash)
+			assert.NotContains(t, imported.DefinitionOpts.Tags, cocoa.PodDefinitionHashTagKey)
+			require.NotZero(t, pdc.PutInput)
+			assert.Equal(t, created.Hash, pdc.PutInput.Hash)
+		},
+		"ImportPodDefinitionHashesImportedOptionsWithoutHashTag": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			bpdm, ok := pdm.ECSPodDefinitionManager.(*ecs.BasicPodDefinitionManager)
+			require.True(t, ok)
+
+			out := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			imported, err := pdm.ImportPodDefinition(ctx, utility.FromStringPtr(out.TaskDefinition.TaskDefinitionArn))
+			require.NoError(t, err)
+			require.NotZero(t, imported)
+			require.NotZero(t, imported.Hash)
+
+			hash, err := bpdm.HashPodDefinition(imported.DefinitionOpts)
+			require.NoError(t, err)
+			assert.Equal(t, hash, imported.Hash, "hash should match the hash of the equivalent pod definition options")
+			require.NotZero(t, pdc.PutInput)
+			assert.Equal(t, hash, pdc.PutInput.Hash)
+		},
+		"CreatePodDefinitionReturnsPartialCreationErrorWhenReTaggingFails": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			c.TagResourceError = errors.New("fake error")
+
+			pdi, err := pdm.CreatePodDefinition(ctx, getValidPodDefOpts(t))
+			require.Error(t, err)
+			assert.Zero(t, pdi)
+			pce, ok := cocoa.AsPartialCreationError(err)
+			require.True(t, ok, "should return a partial creation error")
+			require.NotZero(t, pdc.PutInput)
+			assert.Equal(t, pdc.PutInput.ID, pce.TaskDefinitionID)
+		},
+		"CreatePodDefinitionWithoutActiveWaitOptionsDoesNotWait": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			_, err := pdm.CreatePodDefinition(ctx, getValidPodDefOpts(t))
+			require.NoError(t, err)
+			assert.Zero(t, c.DescribeTaskDefinitionInput)
+		},
+		"CreatePodDefinitionRegistersNormalizedPodDefinition": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			normalizingPDM, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
+				SetClient(c).
+				AddNormalizers(cocoa.SortEnvironmentVariablesNormalizer(), cocoa.LowercaseImageHostsNormalizer()))
+			require.NoError(t, err)
+
+			opts := getValidPodDefOpts(t)
+			opts.ContainerDefinitions[0].
+				SetImage("Registry.Example.com/image").
+				AddEnvironmentVariables(
+					*cocoa.NewEnvironmentVariable().SetName("b").SetValue("value"),
+					*cocoa.NewEnvironmentVariable().SetName("a").SetValue("value"),
+				)
+
+			pdi, err := normalizingPDM.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, pdi)
+
+			require.Len(t, pdi.DefinitionOpts.ContainerDefinitions, 1)
+			containerDef := pdi.DefinitionOpts.ContainerDefinitions[0]
+			assert.Equal(t, "registry.example.com/image", utility.FromStringPtr(containerDef.Image))
+			require.Len(t, containerDef.EnvVars, 2)
+			assert.Equal(t, "a", utility.FromStringPtr(containerDef.EnvVars[0].Name))
+			assert.Equal(t, "b", utility.FromStringPtr(containerDef.EnvVars[1].Name))
+			assert.Equal(t, "Registry.Example.com/image", utility.FromStringPtr(opts.ContainerDefinitions[0].Image), "original pod definition should not be modified")
+
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			assert.Equal(t, "registry.example.com/image", utility.FromStringPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Image))
+		},
+		"WaitForPodDefinitionActiveSucceedsWithActivePodDefinition": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			basicPDM, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().SetClient(c))
+			require.NoError(t, err)
+
+			pdi, err := basicPDM.CreatePodDefinition(ctx, getValidPodDefOpts(t))
+			require.NoError(t, err)
+
+			assert.NoError(t, basicPDM.WaitForPodDefinitionActive(ctx, pdi.ID))
+		},
+		"WaitForPodDefinitionActiveFailsWithNonexistentPodDefinition": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			basicPDM, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
+				SetClient(c).
+				SetActiveWaitOptions(utility.RetryOptions{MaxAttempts: 2}))
+			require.NoError(t, err)
+
+			assert.Error(t, basicPDM.WaitForPodDefinitionActive(ctx, "foo"))
+		},
+		"CreatePodDefinitionWithStrictValidationFailsWithOversizedContainerBeforeCreatingResources": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(sm))
+			require.NoError(t, err)
+			strictPDM, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
+				SetClient(c).
+				SetVault(NewVault(v)).
+				SetStrictValidation(true))
+			require.NoError(t, err)
+
+			opts := getValidPodDefOpts(t)
+			containerDef := opts.ContainerDefinitions[0]
+			containerDef.SetCommand([]string{strings.Repeat("a", ecs.MaxContainerCommandSize+1)})
+			containerDef.AddEnvironmentVariables(*cocoa.NewEnvironmentVariable().
+				SetName("secret").
+				SetSecretOptions(*cocoa.NewSecretOptions().SetName("secret").SetNewValue("value")))
+			opts.SetContainerDefinitions([]cocoa.ECSContainerDefinition{containerDef})
+
+			pdi, err := strictPDM.CreatePodDefinition(ctx, opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), utility.FromStringPtr(containerDef.Name))
+			assert.Zero(t, pdi)
+			assert.Zero(t, sm.CreateSecretInput, "should not have created secrets")
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not have registered a task definition")
+		},
+		"CreatePodDefinitionWithImageValidationFailsWithUnpinnedImageBeforeCreatingResources": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			imagePDM, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
+				SetClient(c).
+				SetImageValidationOptions(*ecs.NewImageValidationOptions()))
+			require.NoError(t, err)
+
+			opts := getValidPodDefOpts(t)
+			opts.ContainerDefinitions[0].SetImage("image:latest")
+
+			pdi, err := imagePDM.CreatePodDefinition(ctx, opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "image:latest")
+			assert.Zero(t, pdi)
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not have registered a task definition")
+		},
+		"CreatePodDefinitionWithSecretLocationValidationFailsWithCrossAccountSecretBeforeCreatingResources": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			locPDM, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
+				SetClient(c).
+				SetSecretLocationOptions(*ecs.NewSecretLocationOptions().SetAccountID("123456789012")))
+			require.NoError(t, err)
+
+			const secretARN = "arn:aws:secretsmanager:us-east-1:210987654321:secret:name-AbCdEf"
+			opts := getValidPodDefOpts(t)
+			opts.ContainerDefinitions[0].SetRepositoryCredentials(*cocoa.NewRepositoryCredentials().SetID(secretARN))
+
+			pdi, err := locPDM.CreatePodDefinition(ctx, opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), secretARN)
+			assert.Zero(t, pdi)
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not have registered a task definition")
+		},
+		"CreatePodDefinitionWithImageValidationWarningAllowsUnpinnedImage": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			imagePDM, err := ecs.NewBasicPodDefinitionManager(*ecs.NewBasicPodDefinitionManagerOptions().
+				SetClient(c).
+				SetImageValidationOptions(*ecs.NewImageValidationOptions().
+					SetRequireDigest(true).
+					SetPolicy(ecs.ImageValidationPolicyWarn)))
+			require.NoError(t, err)
+
+			opts := getValidPodDefOpts(t)
+			opts.ContainerDefinitions[0].SetImage("image:1.2.3")
+
+			pdi, err := imagePDM.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, pdi)
+			assert.NotZero(t, c.RegisterTaskDefinitionInput)
+		},
+		"CreatePodDefinitionWithoutStrictValidationAllowsOversizedContainer": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			containerDef := opts.ContainerDefinitions[0]
+			containerDef.SetCommand([]string{strings.Repeat("a", ecs.MaxContainerCommandSize+1)})
+			opts.SetContainerDefinitions([]cocoa.ECSContainerDefinition{containerDef})
+
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, pdi)
+			assert.NotZero(t, c.RegisterTaskDefinitionInput)
+		},
+		"CreatePodDefinitionExportsInteractiveAndPseudoTerminal": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			containerDef := opts.ContainerDefinitions[0]
+			containerDef.SetInteractive(true).SetPseudoTerminal(true)
+			opts.SetContainerDefinitions([]cocoa.ECSContainerDefinition{containerDef})
+
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, pdi)
+
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			assert.True(t, utility.FromBoolPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].Interactive))
+			assert.True(t, utility.FromBoolPtr(c.RegisterTaskDefinitionInput.ContainerDefinitions[0].PseudoTerminal))
+		},
+		"CreatePodDefinitionExportsHostnameUserAndExtraHosts": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			opts.SetNetworkMode(cocoa.NetworkModeBridge)
+			containerDef := opts.ContainerDefinitions[0]
+			containerDef.SetHostname("hostname").
+				SetUser("1000:1000").
+				AddExtraHosts(*cocoa.NewHostEntry().SetHostname("db").SetIPAddress("10.0.0.1"))
+			opts.SetContainerDefinitions([]cocoa.ECSContainerDefinition{containerDef})
+
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, pdi)
+
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			exported := c.RegisterTaskDefinitionInput.ContainerDefinitions[0]
+			assert.Equal(t, "hostname", utility.FromStringPtr(exported.Hostname))
+			assert.Equal(t, "1000:1000", utility.FromStringPtr(exported.User))
+			require.Len(t, exported.ExtraHosts, 1)
+			assert.Equal(t, "db", utility.FromStringPtr(exported.ExtraHosts[0].Hostname))
+			assert.Equal(t, "10.0.0.1", utility.FromStringPtr(exported.ExtraHosts[0].IpAddress))
+
+			imported, err := pdm.ImportPodDefinition(ctx, pdi.ID)
+			require.NoError(t, err)
+			require.Len(t, imported.DefinitionOpts.ContainerDefinitions, 1)
+			importedContainerDef := imported.DefinitionOpts.ContainerDefinitions[0]
+			assert.Equal(t, "hostname", utility.FromStringPtr(importedContainerDef.Hostname))
+			assert.Equal(t, "1000:1000", utility.FromStringPtr(importedContainerDef.User))
+			assert.Equal(t, containerDef.ExtraHosts, importedContainerDef.ExtraHosts)
+		},
+		"CreatePodDefinitionExportsVolumesFrom": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			sidecar := opts.ContainerDefinitions[0]
+			sidecar.AddVolumesFrom(*cocoa.NewVolumeFrom().SetSourceContainer("data").SetReadOnly(true))
+			data := cocoa.NewECSContainerDefinition().
+				SetName("data").
+				SetImage("image").
+				SetMemoryMB(128).
+				SetCPU(256)
+			opts.SetContainerDefinitions([]cocoa.ECSContainerDefinition{*data, sidecar})
+
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, pdi)
+
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 2)
+			exported := c.RegisterTaskDefinitionInput.ContainerDefinitions[1]
+			assert.Empty(t, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].VolumesFrom)
+			require.Len(t, exported.VolumesFrom, 1)
+			assert.Equal(t, "data", utility.FromStringPtr(exported.VolumesFrom[0].SourceContainer))
+			assert.True(t, utility.FromBoolPtr(exported.VolumesFrom[0].ReadOnly))
+
+			imported, err := pdm.ImportPodDefinition(ctx, pdi.ID)
+			require.NoError(t, err)
+			require.Len(t, imported.DefinitionOpts.ContainerDefinitions, 2)
+			assert.Equal(t, sidecar.VolumesFrom, imported.DefinitionOpts.ContainerDefinitions[1].VolumesFrom)
+		},
+		"CreatePodDefinitionExportsTmpfsMountsAndSharedMemorySize": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			containerDef := opts.ContainerDefinitions[0]
+			containerDef.AddTmpfsMounts(*cocoa.NewTmpfsMount().
+				SetContainerPath("/tmp").
+				SetSizeMB(64).
+				AddMountOptions("noexec", "mode=1777")).
+				SetSharedMemorySizeMB(256)
+			opts.SetContainerDefinitions([]cocoa.ECSContainerDefinition{containerDef})
+
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, pdi)
+
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			exported := c.RegisterTaskDefinitionInput.ContainerDefinitions[0]
+			require.NotZero(t, exported.LinuxParameters)
+			require.Len(t, exported.LinuxParameters.Tmpfs, 1)
+			assert.Equal(t, "/tmp", utility.FromStringPtr(exported.LinuxParameters.Tmpfs[0].ContainerPath))
+			assert.EqualValues(t, 64, exported.LinuxParameters.Tmpfs[0].Size)
+			assert.Equal(t, []string{"noexec", "mode=1777"}, exported.LinuxParameters.Tmpfs[0].MountOptions)
+			assert.EqualValues(t, 256, utility.FromInt32Ptr(exported.LinuxParameters.SharedMemorySize))
+
+			imported, err := pdm.ImportPodDefinition(ctx, pdi.ID)
+			require.NoError(t, err)
+			require.Len(t, imported.DefinitionOpts.ContainerDefinitions, 1)
+			importedContainerDef := imported.DefinitionOpts.ContainerDefinitions[0]
+			assert.Equal(t, containerDef.TmpfsMounts, importedContainerDef.TmpfsMounts)
+			assert.Equal(t, 256, utility.FromIntPtr(importedContainerDef.SharedMemorySizeMB))
+		},
+		"CreatePodDefinitionOmitsLinuxParametersByDefault": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			pdi, err := pdm.CreatePodDefinition(ctx, getValidPodDefOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, pdi)
+
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			assert.Zero(t, c.RegisterTaskDefinitionInput.ContainerDefinitions[0].LinuxParameters)
+		},
+		"CreatePodDefinitionExportsPortMappingProtocolAndName": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			tcp := cocoa.NewPortMapping().SetContainerPort(1337).SetName("http")
+			udp := cocoa.NewPortMapping().SetContainerPort(8125).SetProtocol(cocoa.PortProtocolUDP).SetName("statsd")
+			opts.ContainerDefinitions[0].AddPortMappings(*tcp, *udp)
+
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+			require.NotZero(t, pdi)
+
+			require.NotZero(t, c.RegisterTaskDefinitionInput)
+			require.Len(t, c.RegisterTaskDefinitionInput.ContainerDefinitions, 1)
+			exported := c.RegisterTaskDefinitionInput.ContainerDefinitions[0].PortMappings
+			require.Len(t, exported, 2)
+			assert.EqualValues(t, 1337, utility.FromInt32Ptr(exported[0].ContainerPort))
+			assert.Empty(t, exported[0].Protocol, "unset protocol should use the ECS default")
+			assert.Equal(t, "http", utility.FromStringPtr(exported[0].Name))
+			assert.EqualValues(t, 8125, utility.FromInt32Ptr(exported[1].ContainerPort))
+			assert.Equal(t, types.TransportProtocolUdp, exported[1].Protocol)
+			assert.Equal(t, "statsd", utility.FromStringPtr(exported[1].Name))
+
+			imported, err := pdm.ImportPodDefinition(ctx, pdi.ID)
+			require.NoError(t, err)
+			require.Len(t, imported.DefinitionOpts.ContainerDefinitions, 1)
+			importedMappings := imported.DefinitionOpts.ContainerDefinitions[0].PortMappings
+			require.Len(t, importedMappings, 2)
+			assert.True(t, tcp.Equals(importedMappings[0]))
+			assert.True(t, udp.Equals(importedMappings[1]))
+		},
+		"CreatePodDefinitionFailsWithVolumesFromNonexistentContainer": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			opts.ContainerDefinitions[0].AddVolumesFrom(*cocoa.NewVolumeFrom().SetSourceContainer("data"))
+
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			assert.Error(t, err)
+			assert.Zero(t, pdi)
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not have registered the task definition")
+		},
 		"CreatePodDefinitionRegistersTaskDefinitionAndCachesWithAllFieldsSet": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
 			envVar := cocoa.NewEnvironmentVariable().
 				SetName("env_var_name").
@@ -270,6 +903,120 @@ func ecsPodDefinitionManagerTests() map[string]func(ctx context.Context, t *test
 			assert.NotZero(t, c.DeregisterTaskDefinitionInput, "should have attempted to deregister the task definition")
 			assert.Zero(t, pdc.DeleteInput, "should not have attempted to delete the cached pod definition")
 		},
+		"UpdatePodDefinitionRegistersNewRevisionAndReplacesCachedItem": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+
+			updatedOpts := opts
+			updatedOpts.Name = nil
+			updatedOpts.SetMemoryMB(1024)
+			updated, err := pdm.UpdatePodDefinition(ctx, pdi.ID, *cocoa.NewECSPodDefinitionUpdateOptions().SetDefinitionOptions(updatedOpts))
+			require.NoError(t, err)
+			require.NotZero(t, updated)
+			assert.NotEqual(t, pdi.ID, updated.ID)
+			assert.Equal(t, utility.FromStringPtr(opts.Name), utility.FromStringPtr(updated.DefinitionOpts.Name), "new revision should be in the same family")
+			assert.Equal(t, 1024, utility.FromIntPtr(updated.DefinitionOpts.MemoryMB))
+
+			require.NotZero(t, pdc.PutInput)
+			assert.Equal(t, updated.ID, pdc.PutInput.ID, "should have cached the new revision")
+			require.NotZero(t, pdc.DeleteInput)
+			assert.Equal(t, pdi.ID, utility.FromStringPtr(pdc.DeleteInput), "should have removed the previous revision from the cache")
+			assert.Zero(t, c.DeregisterTaskDefinitionInput, "should not have deregistered the previous revision")
+
+			revisions, err := pdm.ListPodDefinitionRevisions(ctx, utility.FromStringPtr(opts.Name))
+			require.NoError(t, err)
+			require.Len(t, revisions, 2)
+			assert.Equal(t, cocoa.PodDefinitionStatusActive, revisions[0].Status)
+			assert.Equal(t, cocoa.PodDefinitionStatusActive, revisions[1].Status)
+		},
+		"UpdatePodDefinitionDeregistersPreviousRevision": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+
+			updated, err := pdm.UpdatePodDefinition(ctx, pdi.ID, *cocoa.NewECSPodDefinitionUpdateOptions().
+				SetDefinitionOptions(opts).
+				SetDeregisterPrevious(true))
+			require.NoError(t, err)
+			require.NotZero(t, updated)
+
+			require.NotZero(t, c.DeregisterTaskDefinitionInput)
+			assert.Equal(t, pdi.ID, utility.FromStringPtr(c.DeregisterTaskDefinitionInput.TaskDefinition))
+			require.NotZero(t, pdc.DeleteInput)
+			assert.Equal(t, pdi.ID, utility.FromStringPtr(pdc.DeleteInput))
+
+			revisions, err := pdm.ListPodDefinitionRevisions(ctx, utility.FromStringPtr(opts.Name))
+			require.NoError(t, err)
+			require.Len(t, revisions, 2)
+			assert.Equal(t, cocoa.PodDefinitionStatusInactive, revisions[0].Status)
+			assert.Equal(t, cocoa.PodDefinitionStatusActive, revisions[1].Status)
+		},
+		"UpdatePodDefinitionKeepsPreviousCachedItemWhenDeregisteringFails": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+
+			c.DeregisterTaskDefinitionError = errors.New("fake error")
+			updated, err := pdm.UpdatePodDefinition(ctx, pdi.ID, *cocoa.NewECSPodDefinitionUpdateOptions().
+				SetDefinitionOptions(opts).
+				SetDeregisterPrevious(true))
+			assert.Error(t, err)
+			require.NotZero(t, updated, "should return the new revision even though the previous one could not be cleaned up")
+			assert.NotEqual(t, pdi.ID, updated.ID)
+			assert.Zero(t, pdc.DeleteInput, "should not have removed the previous revision from the cache")
+		},
+		"UpdatePodDefinitionKeepsBothCachedItemsWhenRemovingPreviousFromCacheFails": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+
+			pdc.DeleteError = errors.New("fake error")
+			updated, err := pdm.UpdatePodDefinition(ctx, pdi.ID, *cocoa.NewECSPodDefinitionUpdateOptions().
+				SetDefinitionOptions(opts).
+				SetDeregisterPrevious(true))
+			assert.Error(t, err)
+			require.NotZero(t, updated, "should return the new revision even though the previous one could not be removed from the cache")
+			assert.NotEqual(t, pdi.ID, updated.ID)
+
+			require.NotZero(t, pdc.PutInput)
+			assert.Equal(t, updated.ID, pdc.PutInput.ID, "new revision should still be cached")
+			require.NotZero(t, pdc.DeleteInput)
+			assert.Equal(t, pdi.ID, utility.FromStringPtr(pdc.DeleteInput), "should have attempted to remove the previous revision from the cache")
+			require.NotZero(t, c.DeregisterTaskDefinitionInput)
+			assert.Equal(t, pdi.ID, utility.FromStringPtr(c.DeregisterTaskDefinitionInput.TaskDefinition), "previous revision should be deregistered before it is removed from the cache")
+
+			pdc.DeleteError = nil
+			require.NoError(t, pdm.DeletePodDefinition(ctx, pdi.ID), "deleting the previous revision should finish the update")
+			assert.Equal(t, pdi.ID, utility.FromStringPtr(pdc.DeleteInput))
+		},
+		"UpdatePodDefinitionFailsWithMismatchedFamily": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			opts := getValidPodDefOpts(t)
+			pdi, err := pdm.CreatePodDefinition(ctx, opts)
+			require.NoError(t, err)
+			c.RegisterTaskDefinitionInput = nil
+
+			updatedOpts := opts
+			updatedOpts.SetName("other_family")
+			updated, err := pdm.UpdatePodDefinition(ctx, pdi.ID, *cocoa.NewECSPodDefinitionUpdateOptions().SetDefinitionOptions(updatedOpts))
+			assert.Error(t, err)
+			assert.Zero(t, updated)
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not have registered a new revision")
+		},
+		"UpdatePodDefinitionFailsWithNonexistentID": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			updated, err := pdm.UpdatePodDefinition(ctx, "foo", *cocoa.NewECSPodDefinitionUpdateOptions().SetDefinitionOptions(getValidPodDefOpts(t)))
+			assert.Error(t, err)
+			assert.Zero(t, updated)
+			assert.Zero(t, c.RegisterTaskDefinitionInput, "should not have registered a new revision")
+		},
+		"UpdatePodDefinitionFailsWithoutDefinitionOptions": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
+			pdi, err := pdm.CreatePodDefinition(ctx, getValidPodDefOpts(t))
+			require.NoError(t, err)
+
+			updated, err := pdm.UpdatePodDefinition(ctx, pdi.ID, *cocoa.NewECSPodDefinitionUpdateOptions())
+			assert.Error(t, err)
+			assert.Zero(t, updated)
+		},
 		"DeletePodDefinitionIsIdempotent": func(ctx context.Context, t *testing.T, pdm *ECSPodDefinitionManager, pdc *ECSPodDefinitionCache, c *ECSClient, sm *SecretsManagerClient) {
 			opts := getValidPodDefOpts(t)
 
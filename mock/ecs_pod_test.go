@@ -2,7 +2,9 @@ package mock
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsECS "github.com/aws/aws-sdk-go-v2/service/ecs"
@@ -21,6 +23,9 @@ import (
 
 func TestECSPod(t *testing.T) {
 	assert.Implements(t, (*cocoa.ECSPod)(nil), &ECSPod{})
+	assert.Implements(t, (*cocoa.ECSPodPendingDiagnoser)(nil), &ECSPod{})
+	assert.Implements(t, (*cocoa.ECSPodExporter)(nil), &ECSPod{})
+	assert.Implements(t, (*cocoa.ECSPodProtectedDeleter)(nil), &ECSPod{})
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -152,6 +157,32 @@ func ecsPodTests() map[string]func(ctx context.Context, t *testing.T, pc cocoa.E
 	}
 
 	return map[string]func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient){
+		"DeleteSucceedsForPodRestoredFromExportedState": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t).AddEnvironmentVariables(*makeSecretEnvVar(t)))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			exporter, ok := p.(cocoa.ECSPodExporter)
+			require.True(t, ok, "pod should be exportable")
+
+			b, err := json.Marshal(exporter.Export())
+			require.NoError(t, err)
+			var state cocoa.ECSPodState
+			require.NoError(t, json.Unmarshal(b, &state))
+			assert.Equal(t, exporter.Export(), state)
+
+			v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(smc))
+			require.NoError(t, err)
+			restored, err := ecs.NewBasicPodFromState(c, v, state)
+			require.NoError(t, err)
+			assert.Equal(t, p.Resources(), restored.Resources())
+			assert.Equal(t, p.StatusInfo(), restored.StatusInfo())
+
+			require.NoError(t, restored.Delete(ctx))
+
+			checkPodDeleted(ctx, t, restored, c, smc, *opts)
+		},
 		"StopSucceedsWithoutContainers": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
 			opts := makePodCreationOpts(t)
 			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
@@ -322,6 +353,108 @@ func ecsPodTests() map[string]func(ctx context.Context, t *testing.T, pc cocoa.E
 			assert.NoError(t, withVault.Delete(ctx))
 			checkPodDeleted(ctx, t, withVault, c, smc, *opts)
 		},
+		"DeleteWithNeverDeregistrationPolicyKeepsOwnedTaskDefinition": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			res := p.Resources()
+			require.True(t, utility.FromBoolPtr(res.TaskDefinition.Owned))
+			neverDeregister, err := makePod(ecs.NewBasicPodOptions().
+				SetClient(c).
+				SetResources(res).
+				SetStatusInfo(p.StatusInfo()).
+				SetDeregistrationPolicy(ecs.DeregistrationPolicyNever))
+			require.NoError(t, err)
+
+			require.NoError(t, neverDeregister.Delete(ctx))
+			describeTaskDef, err := c.DescribeTaskDefinition(ctx, &awsECS.DescribeTaskDefinitionInput{
+				TaskDefinition: res.TaskDefinition.ID,
+			})
+			require.NoError(t, err)
+			require.NotZero(t, describeTaskDef.TaskDefinition)
+			assert.Equal(t, types.TaskDefinitionStatusActive, describeTaskDef.TaskDefinition.Status, "task definition should not be deregistered")
+		},
+		"DeleteWithAlwaysDeregistrationPolicyDeregistersUnownedTaskDefinition": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			res := p.Resources()
+			unownedTaskDef := *res.TaskDefinition
+			res.SetTaskDefinition(*unownedTaskDef.SetOwned(false))
+			alwaysDeregister, err := makePod(ecs.NewBasicPodOptions().
+				SetClient(c).
+				SetResources(res).
+				SetStatusInfo(p.StatusInfo()).
+				SetDeregistrationPolicy(ecs.DeregistrationPolicyAlways))
+			require.NoError(t, err)
+
+			require.NoError(t, alwaysDeregister.Delete(ctx))
+			describeTaskDef, err := c.DescribeTaskDefinition(ctx, &awsECS.DescribeTaskDefinitionInput{
+				TaskDefinition: res.TaskDefinition.ID,
+			})
+			require.NoError(t, err)
+			require.NotZero(t, describeTaskDef.TaskDefinition)
+			assert.Equal(t, types.TaskDefinitionStatusInactive, describeTaskDef.TaskDefinition.Status, "task definition should be deregistered")
+		},
+		"DeleteSkipsProtectedResources": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(
+				*makeContainerDef(t).AddEnvironmentVariables(
+					*makeSecretEnvVar(t),
+				),
+			)
+			opts.ExecutionOpts.SetProtectionPolicy(*cocoa.NewECSPodProtectionPolicy().
+				SetTaskDefinition(true).
+				AddSecrets(t.Name()))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			require.NoError(t, p.Delete(ctx))
+			assert.Equal(t, cocoa.StatusDeleted, p.StatusInfo().Status)
+
+			res := p.Resources()
+			describeTaskDef, err := c.DescribeTaskDefinition(ctx, &awsECS.DescribeTaskDefinitionInput{
+				TaskDefinition: res.TaskDefinition.ID,
+			})
+			require.NoError(t, err)
+			require.NotZero(t, describeTaskDef.TaskDefinition)
+			assert.Equal(t, types.TaskDefinitionStatusActive, describeTaskDef.TaskDefinition.Status, "protected task definition should not be deregistered")
+
+			require.Len(t, res.Containers, 1)
+			require.Len(t, res.Containers[0].Secrets, 1)
+			_, err = smc.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+				SecretId: res.Containers[0].Secrets[0].ID,
+			})
+			assert.NoError(t, err, "protected secret should not be deleted")
+		},
+		"DeleteWithOverrideDeletesProtectedResources": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(
+				*makeContainerDef(t).AddEnvironmentVariables(
+					*makeSecretEnvVar(t),
+				),
+			)
+			opts.ExecutionOpts.SetProtectionPolicy(*cocoa.NewECSPodProtectionPolicy().
+				SetTaskDefinition(true).
+				AddSecrets(t.Name()))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			require.NoError(t, protectedDeleter(t, p).DeleteWithOptions(ctx, *cocoa.NewECSPodDeletionOptions().SetOverrideProtection(true)))
+
+			checkPodDeleted(ctx, t, p, c, smc, *opts)
+
+			describeTaskDef, err := c.DescribeTaskDefinition(ctx, &awsECS.DescribeTaskDefinitionInput{
+				TaskDefinition: p.Resources().TaskDefinition.ID,
+			})
+			require.NoError(t, err)
+			require.NotZero(t, describeTaskDef.TaskDefinition)
+			assert.Equal(t, types.TaskDefinitionStatusInactive, describeTaskDef.TaskDefinition.Status)
+		},
 		"LatestStatusInfoSucceedsWithoutContainers": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
 			opts := makePodCreationOpts(t)
 			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
@@ -345,6 +478,80 @@ func ecsPodTests() map[string]func(ctx context.Context, t *testing.T, pc cocoa.E
 			require.NotZero(t, status)
 			assert.Len(t, status.Containers, 1, "should get container's latest status even if in-memory pod was missing its containers")
 		},
+		"LatestStatusInfoIncludesLifecycleTimestamps": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			res := p.Resources()
+			cluster := utility.FromStringPtr(res.Cluster)
+			taskID := utility.FromStringPtr(res.TaskID)
+			task, ok := GlobalECSService.Clusters[cluster][taskID]
+			require.True(t, ok)
+			pullStarted := time.Now().Add(-time.Minute).Round(time.Millisecond)
+			pullStopped := pullStarted.Add(10 * time.Second)
+			started := pullStopped.Add(time.Second)
+			task.Status = string(types.DesiredStatusRunning)
+			task.PullStarted = utility.ToTimePtr(pullStarted)
+			task.PullStopped = utility.ToTimePtr(pullStopped)
+			task.Started = utility.ToTimePtr(started)
+			GlobalECSService.Clusters[cluster][taskID] = task
+
+			ps, err := p.LatestStatusInfo(ctx)
+			require.NoError(t, err)
+			require.NotZero(t, ps)
+			require.NotZero(t, ps.PullStartedAt)
+			assert.True(t, pullStarted.Equal(*ps.PullStartedAt))
+			require.NotZero(t, ps.PullStoppedAt)
+			assert.True(t, pullStopped.Equal(*ps.PullStoppedAt))
+			require.NotZero(t, ps.StartedAt)
+			assert.True(t, started.Equal(*ps.StartedAt))
+			assert.Zero(t, ps.StoppingAt)
+			assert.Zero(t, ps.ExecutionStoppedAt)
+
+			require.NoError(t, p.Stop(ctx))
+
+			ps, err = p.LatestStatusInfo(ctx)
+			require.NoError(t, err)
+			require.NotZero(t, ps)
+			assert.NotZero(t, ps.StoppingAt)
+			assert.NotZero(t, ps.ExecutionStoppedAt)
+			require.NotZero(t, ps.StartedAt)
+			assert.True(t, started.Equal(*ps.StartedAt), "start time should be preserved after stopping")
+		},
+		"LatestStatusInfoIncludesRetirementNotice": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			ps, err := p.LatestStatusInfo(ctx)
+			require.NoError(t, err)
+			assert.False(t, ps.IsRetiring(), "running pod should not be retiring")
+			assert.Zero(t, ps.RetirementNotice)
+
+			res := p.Resources()
+			cluster := utility.FromStringPtr(res.Cluster)
+			taskID := utility.FromStringPtr(res.TaskID)
+			task, ok := GlobalECSService.Clusters[cluster][taskID]
+			require.True(t, ok)
+			stopping := time.Now().Round(time.Millisecond)
+			task.StopCode = string(types.TaskStopCodeSpotInterruption)
+			task.StopReason = utility.ToStringPtr("Spot capacity is being reclaimed")
+			task.Stopping = utility.ToTimePtr(stopping)
+			GlobalECSService.Clusters[cluster][taskID] = task
+
+			ps, err = p.LatestStatusInfo(ctx)
+			require.NoError(t, err)
+			assert.True(t, ps.IsRetiring())
+			require.NotZero(t, ps.RetirementNotice)
+			assert.Equal(t, cocoa.RetirementCauseSpotInterruption, ps.RetirementNotice.Cause)
+			assert.Equal(t, string(types.TaskStopCodeSpotInterruption), ps.RetirementNotice.StopCode)
+			assert.Equal(t, "Spot capacity is being reclaimed", ps.RetirementNotice.Message)
+			require.NotZero(t, ps.RetirementNotice.NoticeAt)
+			assert.True(t, stopping.Equal(*ps.RetirementNotice.NoticeAt))
+		},
 		"LatestStatusInfoFailsWhenRequestErrors": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
 			opts := makePodCreationOpts(t)
 			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
@@ -387,5 +594,290 @@ func ecsPodTests() map[string]func(ctx context.Context, t *testing.T, pc cocoa.E
 			assert.Error(t, err)
 			assert.Zero(t, ps)
 		},
+		"PendingDiagnosisIsUnknownForPendingPodWithoutKnownCause": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			diagnosis, err := pendingDiagnoser(t, p).PendingDiagnosis(ctx)
+			require.NoError(t, err)
+			require.NotZero(t, diagnosis)
+			assert.Equal(t, cocoa.PendingCauseUnknown, diagnosis.Cause)
+			assert.Equal(t, cocoa.StatusStarting, diagnosis.Status)
+			assert.NotEmpty(t, diagnosis.Message)
+		},
+		"PendingDiagnosisIsNoneForStoppedPod": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+			require.NoError(t, p.Stop(ctx))
+
+			diagnosis, err := pendingDiagnoser(t, p).PendingDiagnosis(ctx)
+			require.NoError(t, err)
+			require.NotZero(t, diagnosis)
+			assert.Equal(t, cocoa.PendingCauseNone, diagnosis.Cause)
+			assert.Equal(t, cocoa.StatusStopped, diagnosis.Status)
+			assert.Equal(t, string(types.TaskStopCodeUserInitiated), diagnosis.StopCode)
+		},
+		"PendingDiagnosisDetectsImagePullFailure": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			res := p.Resources()
+			require.NoError(t, GlobalECSService.SetContainerExit(utility.FromStringPtr(res.Cluster), utility.FromStringPtr(res.TaskID), "container", 1, "CannotPullContainerError: pull image manifest has been retried 5 time(s)"))
+
+			diagnosis, err := pendingDiagnoser(t, p).PendingDiagnosis(ctx)
+			require.NoError(t, err)
+			require.NotZero(t, diagnosis)
+			assert.Equal(t, cocoa.PendingCauseImagePull, diagnosis.Cause)
+			assert.Contains(t, diagnosis.Message, "CannotPullContainerError")
+		},
+		"PendingDiagnosisDetectsInsufficientCapacityFromRecentFailures": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			history := ecs.NewRunTaskFailureHistory(0)
+			hpc, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c).SetRunTaskFailureHistory(history))
+			require.NoError(t, err)
+
+			GlobalECSService.ClusterCapacities[testutil.ECSClusterName()] = ECSClusterCapacity{CPU: utility.ToIntPtr(128)}
+
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
+			p, err := hpc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			_, err = hpc.CreatePod(ctx, *opts)
+			require.Error(t, err)
+
+			diagnosis, err := pendingDiagnoser(t, p).PendingDiagnosis(ctx)
+			require.NoError(t, err)
+			require.NotZero(t, diagnosis)
+			assert.Equal(t, cocoa.PendingCauseInsufficientCapacity, diagnosis.Cause)
+			require.Len(t, diagnosis.RecentFailures, 1)
+			assert.Equal(t, ecs.ReasonResourceCPU, diagnosis.RecentFailures[0].Reason)
+		},
+		"PendingDiagnosisFailsWhenRequestReturnsFailures": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, smc *SecretsManagerClient) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.AddContainerDefinitions(*makeContainerDef(t))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+
+			c.DescribeTasksOutput = &awsECS.DescribeTasksOutput{
+				Failures: []types.Failure{{
+					Arn:    p.Resources().TaskID,
+					Reason: aws.String("fake reason"),
+				}},
+			}
+
+			diagnosis, err := pendingDiagnoser(t, p).PendingDiagnosis(ctx)
+			assert.Error(t, err)
+			assert.Zero(t, diagnosis)
+		},
 	}
 }
+
+func TestListPods(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer resetECSAndSecretsManagerCache()
+
+	makePodCreationOpts := func(t *testing.T) *cocoa.ECSPodCreationOptions {
+		envVar := cocoa.NewEnvironmentVariable().
+			SetName("envVar").
+			SetSecretOptions(*cocoa.NewSecretOptions().
+				SetName(testutil.NewSecretName(t)).
+				SetNewValue("value").
+				SetOwned(true))
+		containerDef := cocoa.NewECSContainerDefinition().
+			SetImage("image").
+			SetMemoryMB(128).
+			SetCPU(128).
+			SetName("container").
+			AddEnvironmentVariables(*envVar)
+		defOpts := cocoa.NewECSPodDefinitionOptions().
+			SetName(testutil.NewTaskDefinitionFamily(t)).
+			AddContainerDefinitions(*containerDef).
+			SetMemoryMB(128).
+			SetCPU(128).
+			SetTaskRole(testutil.ECSTaskRole()).
+			SetExecutionRole(testutil.ECSExecutionRole())
+		execOpts := cocoa.NewECSPodExecutionOptions().
+			SetCluster(testutil.ECSClusterName())
+		return cocoa.NewECSPodCreationOptions().
+			SetDefinitionOptions(*defOpts).
+			SetExecutionOptions(*execOpts)
+	}
+
+	for tName, tCase := range map[string]func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, v cocoa.Vault){
+		"ReturnsAllRunningPods": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, v cocoa.Vault) {
+			created := map[string]cocoa.ECSPod{}
+			for i := 0; i < 2; i++ {
+				p, err := pc.CreatePod(ctx, *makePodCreationOpts(t))
+				require.NoError(t, err)
+				created[utility.FromStringPtr(p.Resources().TaskID)] = p
+			}
+
+			pods, err := ecs.ListPods(ctx, c, v, testutil.ECSClusterName(), *ecs.NewListPodsFilters())
+			require.NoError(t, err)
+			require.Len(t, pods, len(created))
+			for _, p := range pods {
+				res := p.Resources()
+				createdPod, ok := created[utility.FromStringPtr(res.TaskID)]
+				require.True(t, ok, "listed pod should be one of the created pods")
+				createdRes := createdPod.Resources()
+
+				assert.Equal(t, testutil.ECSClusterName(), utility.FromStringPtr(res.Cluster))
+				require.NotZero(t, res.TaskDefinition)
+				assert.Equal(t, utility.FromStringPtr(createdRes.TaskDefinition.ID), utility.FromStringPtr(res.TaskDefinition.ID))
+				assert.False(t, utility.FromBoolPtr(res.TaskDefinition.Owned))
+
+				require.Len(t, res.Containers, 1)
+				require.Len(t, createdRes.Containers, 1)
+				assert.Equal(t, utility.FromStringPtr(createdRes.Containers[0].ContainerID), utility.FromStringPtr(res.Containers[0].ContainerID))
+				require.Len(t, res.Containers[0].Secrets, 1)
+				assert.Equal(t, utility.FromStringPtr(createdRes.Containers[0].Secrets[0].ID), utility.FromStringPtr(res.Containers[0].Secrets[0].ID))
+				assert.False(t, utility.FromBoolPtr(res.Containers[0].Secrets[0].Owned))
+
+				assert.Equal(t, createdPod.StatusInfo().Status, p.StatusInfo().Status)
+			}
+		},
+		"ReturnsOnlyPodsMatchingFamily": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, v cocoa.Vault) {
+			opts := makePodCreationOpts(t)
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+			_, err = pc.CreatePod(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+
+			filters := ecs.NewListPodsFilters().SetFamily(utility.FromStringPtr(opts.DefinitionOpts.Name))
+			pods, err := ecs.ListPods(ctx, c, v, testutil.ECSClusterName(), *filters)
+			require.NoError(t, err)
+			require.Len(t, pods, 1)
+			assert.Equal(t, utility.FromStringPtr(p.Resources().TaskID), utility.FromStringPtr(pods[0].Resources().TaskID))
+		},
+		"ReturnsOnlyPodsMatchingGroup": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, v cocoa.Vault) {
+			metadata := map[string]string{"app": "evergreen", "env": "prod"}
+			opts := makePodCreationOpts(t)
+			opts.ExecutionOpts.SetPlacementOptions(*cocoa.NewECSPodPlacementOptions().
+				SetGroupNameTemplate("{app}-{env}").
+				SetGroupMetadata(metadata))
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+			_, err = pc.CreatePod(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+
+			group, err := cocoa.ResolveGroupNameTemplate("{app}-{env}", metadata)
+			require.NoError(t, err)
+			pods, err := ecs.ListPods(ctx, c, v, testutil.ECSClusterName(), *ecs.NewListPodsFilters().SetGroup(group))
+			require.NoError(t, err)
+			require.Len(t, pods, 1)
+			assert.Equal(t, utility.FromStringPtr(p.Resources().TaskID), utility.FromStringPtr(pods[0].Resources().TaskID))
+			assert.Equal(t, "evergreen-prod", utility.FromStringPtr(pods[0].Resources().Group))
+		},
+		"ReturnsOnlyPodsMatchingPurpose": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, v cocoa.Vault) {
+			opts := makePodCreationOpts(t)
+			opts.ExecutionOpts.SetPurpose("agent")
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+			otherOpts := makePodCreationOpts(t)
+			otherOpts.ExecutionOpts.SetPurpose("build")
+			_, err = pc.CreatePod(ctx, *otherOpts)
+			require.NoError(t, err)
+			_, err = pc.CreatePod(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+
+			pods, err := ecs.FindPodsByPurpose(ctx, c, v, testutil.ECSClusterName(), "agent", *ecs.NewListPodsFilters())
+			require.NoError(t, err)
+			require.Len(t, pods, 1)
+			assert.Equal(t, utility.FromStringPtr(p.Resources().TaskID), utility.FromStringPtr(pods[0].Resources().TaskID))
+		},
+		"ReturnsPodsInheritingPurposeFromPodDefinition": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, v cocoa.Vault) {
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.SetPurpose("agent")
+			p, err := pc.CreatePod(ctx, *opts)
+			require.NoError(t, err)
+			overriddenOpts := makePodCreationOpts(t)
+			overriddenOpts.DefinitionOpts.SetPurpose("agent")
+			overriddenOpts.ExecutionOpts.SetPurpose("build")
+			_, err = pc.CreatePod(ctx, *overriddenOpts)
+			require.NoError(t, err)
+
+			pods, err := ecs.FindPodsByPurpose(ctx, c, v, testutil.ECSClusterName(), "agent", *ecs.NewListPodsFilters())
+			require.NoError(t, err)
+			require.Len(t, pods, 1)
+			assert.Equal(t, utility.FromStringPtr(p.Resources().TaskID), utility.FromStringPtr(pods[0].Resources().TaskID))
+
+			require.NotZero(t, c.RunTaskInput)
+			assert.Contains(t, c.RunTaskInput.Tags, types.Tag{Key: aws.String(cocoa.PurposeTagKey), Value: aws.String("build")})
+		},
+		"FailsWithEmptyPurpose": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, v cocoa.Vault) {
+			pods, err := ecs.FindPodsByPurpose(ctx, c, v, testutil.ECSClusterName(), "", *ecs.NewListPodsFilters())
+			assert.Error(t, err)
+			assert.Empty(t, pods)
+		},
+		"ReturnsOnlyPodsMatchingDesiredStatus": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, v cocoa.Vault) {
+			stopped, err := pc.CreatePod(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+			require.NoError(t, stopped.Stop(ctx))
+			running, err := pc.CreatePod(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+
+			pods, err := ecs.ListPods(ctx, c, v, testutil.ECSClusterName(), *ecs.NewListPodsFilters())
+			require.NoError(t, err)
+			require.Len(t, pods, 1)
+			assert.Equal(t, utility.FromStringPtr(running.Resources().TaskID), utility.FromStringPtr(pods[0].Resources().TaskID))
+
+			pods, err = ecs.ListPods(ctx, c, v, testutil.ECSClusterName(), *ecs.NewListPodsFilters().SetDesiredStatus(types.DesiredStatusStopped))
+			require.NoError(t, err)
+			require.Len(t, pods, 1)
+			assert.Equal(t, utility.FromStringPtr(stopped.Resources().TaskID), utility.FromStringPtr(pods[0].Resources().TaskID))
+			assert.Equal(t, cocoa.StatusStopped, pods[0].StatusInfo().Status)
+		},
+		"ReturnsNoPodsForEmptyCluster": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, v cocoa.Vault) {
+			pods, err := ecs.ListPods(ctx, c, v, testutil.ECSClusterName(), *ecs.NewListPodsFilters())
+			require.NoError(t, err)
+			assert.Empty(t, pods)
+		},
+		"FailsWithInvalidFilters": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient, v cocoa.Vault) {
+			pods, err := ecs.ListPods(ctx, c, v, testutil.ECSClusterName(), *ecs.NewListPodsFilters().SetFamily(""))
+			assert.Error(t, err)
+			assert.Empty(t, pods)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			resetECSAndSecretsManagerCache()
+
+			c := &ECSClient{}
+			smc := &SecretsManagerClient{}
+			v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(smc))
+			require.NoError(t, err)
+			mv := NewVault(v)
+
+			pc, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c).SetVault(mv))
+			require.NoError(t, err)
+
+			tCase(tctx, t, NewECSPodCreator(pc), c, mv)
+		})
+	}
+}
+
+// pendingDiagnoser returns the pod as a cocoa.ECSPodPendingDiagnoser, failing
+// the test if the pod cannot diagnose pending pods.
+func pendingDiagnoser(t *testing.T, p cocoa.ECSPod) cocoa.ECSPodPendingDiagnoser {
+	diagnoser, ok := p.(cocoa.ECSPodPendingDiagnoser)
+	require.True(t, ok, "pod should support pending diagnosis")
+	return diagnoser
+}
+
+// protectedDeleter returns the pod as a cocoa.ECSPodProtectedDeleter, failing
+// the test if the pod cannot be deleted with deletion options.
+func protectedDeleter(t *testing.T, p cocoa.ECSPod) cocoa.ECSPodProtectedDeleter {
+	deleter, ok := p.(cocoa.ECSPodProtectedDeleter)
+	require.True(t, ok, "pod should support deletion options")
+	return deleter
+}
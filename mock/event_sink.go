@@ -0,0 +1,47 @@
+package mock
+
+import (
+	"context"
+	"sync"
+
+	"github.com/evergreen-ci/cocoa"
+)
+
+// EventSink provides a mock implementation of a cocoa.EventSink that records
+// the events that it receives.
+type EventSink struct {
+	mu     sync.Mutex
+	events []cocoa.Event
+}
+
+// NewEventSink creates a new mock event sink.
+func NewEventSink() *EventSink {
+	return &EventSink{}
+}
+
+// SendEvent records the event.
+func (s *EventSink) SendEvent(_ context.Context, e cocoa.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e)
+}
+
+// Events returns all the events that have been received so far in the order
+// that they were received.
+func (s *EventSink) Events() []cocoa.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]cocoa.Event{}, s.events...)
+}
+
+// EventsOfType returns all the events of the given type that have been
+// received so far in the order that they were received.
+func (s *EventSink) EventsOfType(t cocoa.EventType) []cocoa.Event {
+	var events []cocoa.Event
+	for _, e := range s.Events() {
+		if e.Type == t {
+			events = append(events, e)
+		}
+	}
+	return events
+}
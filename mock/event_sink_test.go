@@ -0,0 +1,33 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventSink(t *testing.T) {
+	assert.Implements(t, (*cocoa.EventSink)(nil), &EventSink{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sink := NewEventSink()
+	assert.Empty(t, sink.Events())
+
+	sink.SendEvent(ctx, cocoa.Event{Type: cocoa.EventTypePodCreated, TaskID: "task0"})
+	sink.SendEvent(ctx, cocoa.Event{Type: cocoa.EventTypePodStopped, TaskID: "task0"})
+	sink.SendEvent(ctx, cocoa.Event{Type: cocoa.EventTypePodCreated, TaskID: "task1"})
+
+	events := sink.Events()
+	assert.Len(t, events, 3)
+	assert.Equal(t, cocoa.EventTypePodStopped, events[1].Type)
+
+	created := sink.EventsOfType(cocoa.EventTypePodCreated)
+	assert.Len(t, created, 2)
+	assert.Equal(t, "task0", created[0].TaskID)
+	assert.Equal(t, "task1", created[1].TaskID)
+	assert.Empty(t, sink.EventsOfType(cocoa.EventTypePodDeleted))
+}
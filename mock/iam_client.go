@@ -0,0 +1,64 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/evergreen-ci/utility"
+)
+
+// defaultECSTasksTrustPolicy is a trust policy that allows ECS tasks to assume
+// the role.
+const defaultECSTasksTrustPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ecs-tasks.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+
+// IAMClient provides a mock implementation of a cocoa.IAMClient. This makes it
+// possible to introspect on inputs to the client and control the client's
+// output. By default, it checks for roles in Roles.
+type IAMClient struct {
+	// Roles are the names or ARNs of the roles that exist.
+	Roles []string
+	// TrustPolicies map the names or ARNs of roles to their trust policy
+	// documents. Roles that exist but do not have a trust policy here allow
+	// ECS tasks to assume the role.
+	TrustPolicies map[string]string
+
+	RoleExistsInput  *string
+	RoleExistsOutput *bool
+	RoleExistsError  error
+
+	GetRoleTrustPolicyInput  *string
+	GetRoleTrustPolicyOutput *string
+	GetRoleTrustPolicyError  error
+}
+
+// RoleExists saves the input and checks whether the role exists. The mock
+// output can be customized. By default, it will check whether the role is one
+// of the mock roles.
+func (c *IAMClient) RoleExists(ctx context.Context, role string) (bool, error) {
+	c.RoleExistsInput = &role
+
+	if c.RoleExistsOutput != nil || c.RoleExistsError != nil {
+		return utility.FromBoolPtr(c.RoleExistsOutput), c.RoleExistsError
+	}
+
+	return utility.StringSliceContains(c.Roles, role), nil
+}
+
+// GetRoleTrustPolicy saves the input and returns the role's trust policy. The
+// mock output can be customized. By default, it will return the role's trust
+// policy if the role is one of the mock roles.
+func (c *IAMClient) GetRoleTrustPolicy(ctx context.Context, role string) (*string, error) {
+	c.GetRoleTrustPolicyInput = &role
+
+	if c.GetRoleTrustPolicyOutput != nil || c.GetRoleTrustPolicyError != nil {
+		return c.GetRoleTrustPolicyOutput, c.GetRoleTrustPolicyError
+	}
+
+	if !utility.StringSliceContains(c.Roles, role) {
+		return nil, nil
+	}
+	if policy, ok := c.TrustPolicies[role]; ok {
+		return &policy, nil
+	}
+
+	return utility.ToStringPtr(defaultECSTasksTrustPolicy), nil
+}
@@ -0,0 +1,143 @@
+package mock
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Latency is a simulated delay for a mock API call. If Max is greater than
+// Min, the delay is chosen randomly in the range [Min, Max). Otherwise, the
+// delay is always Min.
+type Latency struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// NewFixedLatency returns a latency that always delays for the given duration.
+func NewFixedLatency(d time.Duration) Latency {
+	return Latency{Min: d, Max: d}
+}
+
+// NewRandomLatency returns a latency that delays for a random duration in the
+// range [min, max).
+func NewRandomLatency(min, max time.Duration) Latency {
+	return Latency{Min: min, Max: max}
+}
+
+// duration returns how long the call should be delayed.
+func (l Latency) duration() time.Duration {
+	if l.Max <= l.Min {
+		return l.Min
+	}
+	return l.Min + time.Duration(rand.Int63n(int64(l.Max-l.Min)))
+}
+
+// LatencySimulator simulates slow API responses for a mock client. Each
+// simulated call is delayed by the latency for its operation, or by the
+// default latency if the operation has no specific latency. If the call's
+// context is done before the delay is over, the call returns the context's
+// error without doing anything else. It also tracks how many simulated calls
+// are in progress at once.
+type LatencySimulator struct {
+	// Default is the latency for all operations that do not have their own
+	// latency. If this is nil, those operations are not delayed.
+	Default *Latency
+	// Operations are the latencies for specific operations by their API
+	// operation name (e.g. "RunTask").
+	Operations map[string]Latency
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	calls       map[string]int
+}
+
+// NewLatencySimulator returns a new latency simulator that does not delay any
+// operations.
+func NewLatencySimulator() *LatencySimulator {
+	return &LatencySimulator{
+		Operations: map[string]Latency{},
+		calls:      map[string]int{},
+	}
+}
+
+// SetDefault sets the latency for all operations that do not have their own
+// latency.
+func (s *LatencySimulator) SetDefault(l Latency) *LatencySimulator {
+	s.Default = &l
+	return s
+}
+
+// SetOperation sets the latency for a specific API operation.
+func (s *LatencySimulator) SetOperation(op string, l Latency) *LatencySimulator {
+	if s.Operations == nil {
+		s.Operations = map[string]Latency{}
+	}
+	s.Operations[op] = l
+	return s
+}
+
+// MaxInFlight returns the maximum number of simulated calls that have been in
+// progress at the same time.
+func (s *LatencySimulator) MaxInFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxInFlight
+}
+
+// Calls returns the number of simulated calls made to the API operation.
+func (s *LatencySimulator) Calls(op string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[op]
+}
+
+// latency returns the latency for the API operation, if any.
+func (s *LatencySimulator) latency(op string) *Latency {
+	if l, ok := s.Operations[op]; ok {
+		return &l
+	}
+	return s.Default
+}
+
+// wait delays the API operation by its latency. It returns an error if the
+// context is done before the delay is over. A nil latency simulator does not
+// delay any operations.
+func (s *LatencySimulator) wait(ctx context.Context, op string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.calls == nil {
+		s.calls = map[string]int{}
+	}
+	s.calls[op]++
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	l := s.latency(op)
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	if l == nil {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(l.duration())
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
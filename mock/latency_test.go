@@ -0,0 +1,138 @@
+package mock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	awsECS "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatency(t *testing.T) {
+	t.Run("FixedLatencyAlwaysHasSameDuration", func(t *testing.T) {
+		l := NewFixedLatency(time.Second)
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, time.Second, l.duration())
+		}
+	})
+	t.Run("RandomLatencyIsWithinRange", func(t *testing.T) {
+		l := NewRandomLatency(time.Second, 2*time.Second)
+		for i := 0; i < 10; i++ {
+			d := l.duration()
+			assert.True(t, d >= time.Second)
+			assert.True(t, d < 2*time.Second)
+		}
+	})
+	t.Run("InvertedRangeUsesMin", func(t *testing.T) {
+		l := NewRandomLatency(2*time.Second, time.Second)
+		assert.Equal(t, 2*time.Second, l.duration())
+	})
+}
+
+func TestLatencySimulator(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("NilSimulatorDoesNotDelay", func(t *testing.T) {
+		var s *LatencySimulator
+		assert.NoError(t, s.wait(ctx, "op"))
+	})
+	t.Run("OperationLatencyOverridesDefault", func(t *testing.T) {
+		s := NewLatencySimulator().
+			SetDefault(NewFixedLatency(time.Hour)).
+			SetOperation("op", NewFixedLatency(time.Millisecond))
+		tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+		defer tcancel()
+		assert.NoError(t, s.wait(tctx, "op"))
+		assert.Equal(t, 1, s.Calls("op"))
+	})
+	t.Run("DefaultLatencyAppliesToOtherOperations", func(t *testing.T) {
+		s := NewLatencySimulator().
+			SetDefault(NewFixedLatency(time.Hour)).
+			SetOperation("op", NewFixedLatency(time.Millisecond))
+		tctx, tcancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer tcancel()
+		err := s.wait(tctx, "other_op")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+	t.Run("ReturnsErrorForCanceledContext", func(t *testing.T) {
+		s := NewLatencySimulator()
+		tctx, tcancel := context.WithCancel(ctx)
+		tcancel()
+		assert.True(t, errors.Is(s.wait(tctx, "op"), context.Canceled))
+	})
+	t.Run("TracksMaxInFlightCalls", func(t *testing.T) {
+		s := NewLatencySimulator().SetDefault(NewFixedLatency(50 * time.Millisecond))
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, s.wait(ctx, "op"))
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 3, s.MaxInFlight())
+		assert.Equal(t, 3, s.Calls("op"))
+	})
+}
+
+func TestClientLatency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer resetECSAndSecretsManagerCache()
+
+	t.Run("ECSClientTimesOutWithSlowResponse", func(t *testing.T) {
+		resetECSAndSecretsManagerCache()
+		c := &ECSClient{
+			Latency: NewLatencySimulator().SetOperation("RegisterTaskDefinition", NewFixedLatency(time.Hour)),
+		}
+		tctx, tcancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer tcancel()
+
+		in := &awsECS.RegisterTaskDefinitionInput{Family: utility.ToStringPtr("family")}
+		out, err := c.RegisterTaskDefinition(tctx, in)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Zero(t, out)
+		assert.Equal(t, in, c.RegisterTaskDefinitionInput)
+		assert.Empty(t, GlobalECSService.TaskDefs, "task definition should not be registered after the context is done")
+	})
+	t.Run("ECSClientSucceedsAfterDelay", func(t *testing.T) {
+		resetECSAndSecretsManagerCache()
+		c := &ECSClient{
+			Latency: NewLatencySimulator().SetDefault(NewFixedLatency(10 * time.Millisecond)),
+		}
+		tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+		defer tcancel()
+
+		start := time.Now()
+		out, err := c.RegisterTaskDefinition(tctx, &awsECS.RegisterTaskDefinitionInput{Family: utility.ToStringPtr("family")})
+		require.NoError(t, err)
+		require.NotZero(t, out)
+		assert.True(t, time.Since(start) >= 10*time.Millisecond)
+		assert.Equal(t, 1, c.Latency.Calls("RegisterTaskDefinition"))
+	})
+	t.Run("SecretsManagerClientTimesOutWithSlowResponse", func(t *testing.T) {
+		resetECSAndSecretsManagerCache()
+		c := &SecretsManagerClient{
+			Latency: NewLatencySimulator().SetOperation("CreateSecret", NewRandomLatency(time.Hour, 2*time.Hour)),
+		}
+		tctx, tcancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer tcancel()
+
+		out, err := c.CreateSecret(tctx, &secretsmanager.CreateSecretInput{
+			Name:         utility.ToStringPtr("name"),
+			SecretString: utility.ToStringPtr("value"),
+		})
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Zero(t, out)
+		assert.Empty(t, GlobalSecretCache, "secret should not be created after the context is done")
+	})
+}
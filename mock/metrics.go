@@ -0,0 +1,140 @@
+package mock
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+// Metrics is a snapshot of aggregate metrics about the resources in the global
+// fake ECS service and the global secret storage cache. This is useful for
+// load tests built on the mocks to check aggregate behavior (e.g. that no
+// tasks or secrets are leaked after cleaning up thousands of pods).
+type Metrics struct {
+	// TasksCreated is the total number of tasks that have been created in
+	// all clusters.
+	TasksCreated int
+	// TasksPending is the number of tasks that are pending.
+	TasksPending int
+	// TasksRunning is the number of tasks that are running.
+	TasksRunning int
+	// TasksStopped is the number of tasks that are stopped.
+	TasksStopped int
+	// TaskDefinitionsActive is the number of task definitions that are active.
+	TaskDefinitionsActive int
+	// TaskDefinitionsInactive is the number of task definitions that have been
+	// deregistered.
+	TaskDefinitionsInactive int
+	// SecretsStored is the number of secrets that are stored and not deleted.
+	SecretsStored int
+	// SecretsDeleted is the number of secrets that are scheduled for deletion
+	// but have not been permanently deleted yet.
+	SecretsDeleted int
+}
+
+// CollectMetrics returns a snapshot of the current metrics for the global fake
+// ECS service and global secret storage cache.
+func CollectMetrics() Metrics {
+	m := GlobalECSService.Metrics()
+	secretMetrics := GlobalSecretCache.Metrics()
+	m.SecretsStored = secretMetrics.SecretsStored
+	m.SecretsDeleted = secretMetrics.SecretsDeleted
+	return m
+}
+
+// Metrics returns a snapshot of the current metrics for the tasks and task
+// definitions in the fake ECS service. The secret metrics are not set.
+func (s *ECSService) Metrics() Metrics {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var m Metrics
+	for _, cluster := range s.Clusters {
+		for _, task := range cluster {
+			m.TasksCreated++
+			switch task.Status {
+			case string(types.DesiredStatusPending):
+				m.TasksPending++
+			case string(types.DesiredStatusRunning):
+				m.TasksRunning++
+			case string(types.DesiredStatusStopped):
+				m.TasksStopped++
+			}
+		}
+	}
+
+	for _, revisions := range s.TaskDefs {
+		for _, def := range revisions {
+			if utility.FromStringPtr(def.Status) == string(types.TaskDefinitionStatusActive) {
+				m.TaskDefinitionsActive++
+			} else {
+				m.TaskDefinitionsInactive++
+			}
+		}
+	}
+
+	return m
+}
+
+// Metrics returns a snapshot of the current metrics for the stored secrets.
+// The ECS metrics are not set.
+func (c StoredSecrets) Metrics() Metrics {
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	var m Metrics
+	for _, s := range c {
+		if s.IsDeleted {
+			m.SecretsDeleted++
+		} else {
+			m.SecretsStored++
+		}
+	}
+
+	return m
+}
+
+// metric is a single metric in the Prometheus text exposition format.
+type metric struct {
+	name       string
+	help       string
+	metricType string
+	value      int
+}
+
+func (m Metrics) export() []metric {
+	return []metric{
+		{name: "cocoa_mock_ecs_tasks_created_total", help: "Total number of tasks created.", metricType: "counter", value: m.TasksCreated},
+		{name: "cocoa_mock_ecs_tasks_pending", help: "Number of tasks that are pending.", metricType: "gauge", value: m.TasksPending},
+		{name: "cocoa_mock_ecs_tasks_running", help: "Number of tasks that are running.", metricType: "gauge", value: m.TasksRunning},
+		{name: "cocoa_mock_ecs_tasks_stopped", help: "Number of tasks that are stopped.", metricType: "gauge", value: m.TasksStopped},
+		{name: "cocoa_mock_ecs_task_definitions_active", help: "Number of task definitions that are active.", metricType: "gauge", value: m.TaskDefinitionsActive},
+		{name: "cocoa_mock_ecs_task_definitions_inactive", help: "Number of task definitions that are inactive.", metricType: "gauge", value: m.TaskDefinitionsInactive},
+		{name: "cocoa_mock_secrets_stored", help: "Number of secrets that are stored.", metricType: "gauge", value: m.SecretsStored},
+		{name: "cocoa_mock_secrets_deleted", help: "Number of secrets that are scheduled for deletion.", metricType: "gauge", value: m.SecretsDeleted},
+	}
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format.
+func (m Metrics) WritePrometheus(w io.Writer) error {
+	for _, mt := range m.export() {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", mt.name, mt.help, mt.name, mt.metricType, mt.name, mt.value); err != nil {
+			return errors.Wrapf(err, "writing metric '%s'", mt.name)
+		}
+	}
+	return nil
+}
+
+// MetricsHandler returns an HTTP handler that serves a snapshot of the current
+// metrics in the Prometheus text exposition format, so that load tests can
+// scrape the mock services.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_ = CollectMetrics().WritePrometheus(w)
+	})
+}
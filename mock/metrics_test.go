@@ -0,0 +1,93 @@
+package mock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	defer ResetGlobalECSService()
+	defer ResetGlobalSecretCache()
+
+	t.Run("CollectMetrics", func(t *testing.T) {
+		t.Run("ReturnsZeroWithNoResources", func(t *testing.T) {
+			ResetGlobalECSService()
+			ResetGlobalSecretCache()
+
+			assert.Zero(t, CollectMetrics())
+		})
+		t.Run("CountsResourcesByStatus", func(t *testing.T) {
+			ResetGlobalECSService()
+			ResetGlobalSecretCache()
+
+			GlobalECSService.Clusters["cluster0"] = ECSCluster{
+				"task0": ECSTask{Status: string(types.DesiredStatusPending)},
+				"task1": ECSTask{Status: string(types.DesiredStatusRunning)},
+			}
+			GlobalECSService.Clusters["cluster1"] = ECSCluster{
+				"task2": ECSTask{Status: string(types.DesiredStatusRunning)},
+				"task3": ECSTask{Status: string(types.DesiredStatusStopped)},
+			}
+			GlobalECSService.TaskDefs["family"] = []ECSTaskDefinition{
+				{Status: utility.ToStringPtr(string(types.TaskDefinitionStatusInactive))},
+				{Status: utility.ToStringPtr(string(types.TaskDefinitionStatusActive))},
+			}
+			GlobalSecretCache["secret0"] = StoredSecret{Name: "secret0"}
+			GlobalSecretCache["secret1"] = StoredSecret{Name: "secret1", IsDeleted: true}
+
+			assert.Equal(t, Metrics{
+				TasksCreated:            4,
+				TasksPending:            1,
+				TasksRunning:            2,
+				TasksStopped:            1,
+				TaskDefinitionsActive:   1,
+				TaskDefinitionsInactive: 1,
+				SecretsStored:           1,
+				SecretsDeleted:          1,
+			}, CollectMetrics())
+		})
+	})
+	t.Run("ScopedStateMetricsAreIsolated", func(t *testing.T) {
+		ResetGlobalECSService()
+		ResetGlobalSecretCache()
+
+		svc, _ := NewScopedECSService()
+		svc.SetCluster("cluster", ECSTask{Status: string(types.DesiredStatusRunning)})
+		secrets, _ := NewScopedSecretCache()
+		secrets.Set(StoredSecret{Name: "secret"})
+
+		assert.Equal(t, Metrics{TasksCreated: 1, TasksRunning: 1}, svc.Metrics())
+		assert.Equal(t, Metrics{SecretsStored: 1}, secrets.Metrics())
+		assert.Zero(t, CollectMetrics(), "scoped state should not affect the global metrics")
+	})
+	t.Run("WritePrometheus", func(t *testing.T) {
+		var sb strings.Builder
+		require.NoError(t, Metrics{TasksCreated: 5, TasksRunning: 3}.WritePrometheus(&sb))
+
+		out := sb.String()
+		assert.Contains(t, out, "# TYPE cocoa_mock_ecs_tasks_created_total counter\n")
+		assert.Contains(t, out, "\ncocoa_mock_ecs_tasks_created_total 5\n")
+		assert.Contains(t, out, "# TYPE cocoa_mock_ecs_tasks_running gauge\n")
+		assert.Contains(t, out, "\ncocoa_mock_ecs_tasks_running 3\n")
+		assert.Contains(t, out, "\ncocoa_mock_secrets_stored 0\n")
+	})
+	t.Run("MetricsHandler", func(t *testing.T) {
+		ResetGlobalECSService()
+		ResetGlobalSecretCache()
+		GlobalSecretCache["secret"] = StoredSecret{Name: "secret"}
+
+		rec := httptest.NewRecorder()
+		MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+		assert.Contains(t, rec.Body.String(), "\ncocoa_mock_secrets_stored 1\n")
+	})
+}
@@ -0,0 +1,174 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/ecs"
+	"github.com/evergreen-ci/cocoa/internal/testutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAWSVPCNetworking(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	makeClient := func() *EC2Client {
+		return &EC2Client{
+			Subnets: []cocoa.EC2Subnet{
+				{ID: "subnet-1", VPCID: "vpc-1", AvailableIPAddresses: 0},
+				{ID: "subnet-2", VPCID: "vpc-1", AvailableIPAddresses: 5},
+				{ID: "subnet-3", VPCID: "vpc-2", AvailableIPAddresses: 5},
+			},
+			SecurityGroups: []cocoa.EC2SecurityGroup{
+				{ID: "sg-1", VPCID: "vpc-1"},
+				{ID: "sg-2", VPCID: "vpc-2"},
+			},
+		}
+	}
+
+	t.Run("SucceedsWithValidNetworking", func(t *testing.T) {
+		c := makeClient()
+		awsvpcOpts := cocoa.NewAWSVPCOptions().
+			AddSubnets("subnet-1", "subnet-2").
+			AddSecurityGroups("sg-1")
+		assert.NoError(t, ecs.ValidateAWSVPCNetworking(ctx, *awsvpcOpts, *ecs.NewNetworkValidationOptions().SetClient(c)))
+		assert.ElementsMatch(t, []string{"subnet-1", "subnet-2"}, c.DescribeSubnetsInput)
+		assert.ElementsMatch(t, []string{"sg-1"}, c.DescribeSecurityGroupsInput)
+	})
+	t.Run("SucceedsWithoutSecurityGroups", func(t *testing.T) {
+		c := makeClient()
+		awsvpcOpts := cocoa.NewAWSVPCOptions().AddSubnets("subnet-2")
+		assert.NoError(t, ecs.ValidateAWSVPCNetworking(ctx, *awsvpcOpts, *ecs.NewNetworkValidationOptions().SetClient(c)))
+		assert.Zero(t, c.DescribeSecurityGroupsInput)
+	})
+	t.Run("FailsWithNonexistentSubnets", func(t *testing.T) {
+		awsvpcOpts := cocoa.NewAWSVPCOptions().AddSubnets("subnet-2", "subnet-4")
+		err := ecs.ValidateAWSVPCNetworking(ctx, *awsvpcOpts, *ecs.NewNetworkValidationOptions().SetClient(makeClient()))
+		require.Error(t, err)
+		assert.True(t, cocoa.IsNetworkMisconfigurationError(err))
+		assert.Contains(t, err.Error(), "subnet-4")
+		assert.NotContains(t, err.Error(), "subnet-2")
+	})
+	t.Run("FailsWithNonexistentSecurityGroups", func(t *testing.T) {
+		awsvpcOpts := cocoa.NewAWSVPCOptions().
+			AddSubnets("subnet-2").
+			AddSecurityGroups("sg-1", "sg-3")
+		err := ecs.ValidateAWSVPCNetworking(ctx, *awsvpcOpts, *ecs.NewNetworkValidationOptions().SetClient(makeClient()))
+		require.Error(t, err)
+		assert.True(t, cocoa.IsNetworkMisconfigurationError(err))
+		assert.Contains(t, err.Error(), "sg-3")
+	})
+	t.Run("FailsWithSubnetsInDifferentVPCs", func(t *testing.T) {
+		awsvpcOpts := cocoa.NewAWSVPCOptions().AddSubnets("subnet-2", "subnet-3")
+		err := ecs.ValidateAWSVPCNetworking(ctx, *awsvpcOpts, *ecs.NewNetworkValidationOptions().SetClient(makeClient()))
+		require.Error(t, err)
+		assert.True(t, cocoa.IsNetworkMisconfigurationError(err))
+		assert.Contains(t, err.Error(), "vpc-1")
+		assert.Contains(t, err.Error(), "vpc-2")
+	})
+	t.Run("FailsWithSecurityGroupInDifferentVPC", func(t *testing.T) {
+		awsvpcOpts := cocoa.NewAWSVPCOptions().
+			AddSubnets("subnet-2").
+			AddSecurityGroups("sg-2")
+		err := ecs.ValidateAWSVPCNetworking(ctx, *awsvpcOpts, *ecs.NewNetworkValidationOptions().SetClient(makeClient()))
+		require.Error(t, err)
+		assert.True(t, cocoa.IsNetworkMisconfigurationError(err))
+	})
+	t.Run("FailsWithoutAvailableIPAddresses", func(t *testing.T) {
+		awsvpcOpts := cocoa.NewAWSVPCOptions().AddSubnets("subnet-1")
+		err := ecs.ValidateAWSVPCNetworking(ctx, *awsvpcOpts, *ecs.NewNetworkValidationOptions().SetClient(makeClient()))
+		require.Error(t, err)
+		assert.True(t, cocoa.IsNetworkMisconfigurationError(err))
+		assert.Contains(t, err.Error(), "available IP")
+	})
+	t.Run("FailsWithFewerAvailableIPAddressesThanMinimum", func(t *testing.T) {
+		awsvpcOpts := cocoa.NewAWSVPCOptions().AddSubnets("subnet-1", "subnet-2")
+		opts := ecs.NewNetworkValidationOptions().
+			SetClient(makeClient()).
+			SetMinAvailableIPAddresses(10)
+		err := ecs.ValidateAWSVPCNetworking(ctx, *awsvpcOpts, *opts)
+		require.Error(t, err)
+		assert.True(t, cocoa.IsNetworkMisconfigurationError(err))
+	})
+	t.Run("FailsWhenClientErrors", func(t *testing.T) {
+		c := makeClient()
+		c.DescribeSubnetsError = errors.New("fake error")
+		awsvpcOpts := cocoa.NewAWSVPCOptions().AddSubnets("subnet-2")
+		err := ecs.ValidateAWSVPCNetworking(ctx, *awsvpcOpts, *ecs.NewNetworkValidationOptions().SetClient(c))
+		require.Error(t, err)
+		assert.False(t, cocoa.IsNetworkMisconfigurationError(err))
+	})
+	t.Run("FailsWithInvalidOptions", func(t *testing.T) {
+		awsvpcOpts := cocoa.NewAWSVPCOptions().AddSubnets("subnet-2")
+		err := ecs.ValidateAWSVPCNetworking(ctx, *awsvpcOpts, *ecs.NewNetworkValidationOptions())
+		assert.Error(t, err)
+	})
+}
+
+func TestBasicPodCreatorNetworkValidation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer resetECSAndSecretsManagerCache()
+
+	makeDefinition := func(ctx context.Context, t *testing.T, c *ECSClient) cocoa.ECSTaskDefinition {
+		registerIn := testutil.ValidRegisterTaskDefinitionInput(t)
+		registerOut, err := c.RegisterTaskDefinition(ctx, &registerIn)
+		require.NoError(t, err)
+		require.NotZero(t, registerOut)
+		require.NotZero(t, registerOut.TaskDefinition)
+		return *cocoa.NewECSTaskDefinition().SetID(*registerOut.TaskDefinition.TaskDefinitionArn)
+	}
+	makeCreator := func(t *testing.T, c *ECSClient) *ecs.BasicPodCreator {
+		ec2Client := &EC2Client{
+			Subnets:        []cocoa.EC2Subnet{{ID: "subnet-1", VPCID: "vpc-1", AvailableIPAddresses: 5}},
+			SecurityGroups: []cocoa.EC2SecurityGroup{{ID: "sg-1", VPCID: "vpc-2"}},
+		}
+		pc, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+			SetClient(c).
+			SetNetworkValidationOptions(*ecs.NewNetworkValidationOptions().SetClient(ec2Client)))
+		require.NoError(t, err)
+		return pc
+	}
+
+	t.Run("RunsPodWithValidNetworking", func(t *testing.T) {
+		resetECSAndSecretsManagerCache()
+		c := &ECSClient{}
+		pc := makeCreator(t, c)
+
+		execOpts := cocoa.NewECSPodExecutionOptions().
+			SetCluster(testutil.ECSClusterName()).
+			SetAWSVPCOptions(*cocoa.NewAWSVPCOptions().AddSubnets("subnet-1"))
+		p, err := pc.CreatePodFromExistingDefinition(ctx, makeDefinition(ctx, t, c), *execOpts)
+		require.NoError(t, err)
+		assert.NotZero(t, p)
+		assert.NotZero(t, c.RunTaskInput)
+	})
+	t.Run("DoesNotRunPodWithMisconfiguredNetworking", func(t *testing.T) {
+		resetECSAndSecretsManagerCache()
+		c := &ECSClient{}
+		pc := makeCreator(t, c)
+
+		execOpts := cocoa.NewECSPodExecutionOptions().
+			SetCluster(testutil.ECSClusterName()).
+			SetAWSVPCOptions(*cocoa.NewAWSVPCOptions().
+				AddSubnets("subnet-1").
+				AddSecurityGroups("sg-1"))
+		p, err := pc.CreatePodFromExistingDefinition(ctx, makeDefinition(ctx, t, c), *execOpts)
+		require.Error(t, err)
+		assert.True(t, cocoa.IsNetworkMisconfigurationError(err))
+		assert.Zero(t, p)
+		assert.Zero(t, c.RunTaskInput, "should not have run the pod")
+	})
+	t.Run("FailsWithInvalidNetworkValidationOptions", func(t *testing.T) {
+		pc, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+			SetClient(&ECSClient{}).
+			SetNetworkValidationOptions(*ecs.NewNetworkValidationOptions()))
+		assert.Error(t, err)
+		assert.Zero(t, pc)
+	})
+}
@@ -0,0 +1,226 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/ecs"
+	"github.com/evergreen-ci/cocoa/internal/testutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreflightChecker(t *testing.T) {
+	assert.Implements(t, (*cocoa.IAMClient)(nil), &IAMClient{})
+	assert.Implements(t, (*cocoa.EC2Client)(nil), &EC2Client{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer resetECSAndSecretsManagerCache()
+
+	makePodCreationOpts := func(t *testing.T) *cocoa.ECSPodCreationOptions {
+		containerDef := cocoa.NewECSContainerDefinition().
+			SetImage("image").
+			SetMemoryMB(128).
+			SetCPU(128).
+			SetName("container")
+		defOpts := cocoa.NewECSPodDefinitionOptions().
+			SetName(testutil.NewTaskDefinitionFamily(t)).
+			AddContainerDefinitions(*containerDef).
+			SetMemoryMB(128).
+			SetCPU(128).
+			SetNetworkMode(cocoa.NetworkModeAWSVPC).
+			SetTaskRole(testutil.ECSTaskRole()).
+			SetExecutionRole(testutil.ECSExecutionRole())
+		execOpts := cocoa.NewECSPodExecutionOptions().
+			SetCluster(testutil.ECSClusterName()).
+			SetAWSVPCOptions(*cocoa.NewAWSVPCOptions().
+				AddSubnets("subnet-1", "subnet-2").
+				AddSecurityGroups("sg-1"))
+		return cocoa.NewECSPodCreationOptions().
+			SetDefinitionOptions(*defOpts).
+			SetExecutionOptions(*execOpts)
+	}
+
+	getResult := func(t *testing.T, report *ecs.PreflightReport, name ecs.PreflightCheckName) ecs.PreflightCheckResult {
+		for _, res := range report.Results {
+			if res.Name == name {
+				return res
+			}
+		}
+		require.FailNow(t, "missing preflight check result", "check '%s'", name)
+		return ecs.PreflightCheckResult{}
+	}
+
+	for tName, tCase := range map[string]func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client){
+		"PassesWhenAllResourcesExist": func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client) {
+			report, err := c.Check(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, report)
+			assert.True(t, report.Passed())
+			assert.Empty(t, report.Failures())
+			assert.NoError(t, report.Error())
+			assert.Len(t, report.Results, 6)
+			for _, res := range report.Results {
+				assert.True(t, res.Passed, "check '%s' should pass", res.Name)
+				assert.False(t, res.Skipped, "check '%s' should not be skipped", res.Name)
+			}
+
+			require.NotZero(t, ecsClient.DescribeClustersInput)
+			assert.Equal(t, []string{testutil.ECSClusterName()}, ecsClient.DescribeClustersInput.Clusters)
+		},
+		"FailsWithNonexistentCluster": func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client) {
+			opts := makePodCreationOpts(t)
+			opts.ExecutionOpts.SetCluster("foo")
+
+			report, err := c.Check(ctx, *opts)
+			require.NoError(t, err)
+			require.NotZero(t, report)
+			assert.False(t, report.Passed())
+			assert.Error(t, report.Error())
+			require.Len(t, report.Failures(), 1)
+			assert.Equal(t, ecs.PreflightCheckCluster, report.Failures()[0].Name)
+		},
+		"FailsWithNonexistentRoles": func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client) {
+			iamClient.Roles = nil
+
+			report, err := c.Check(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, report)
+			assert.False(t, report.Passed())
+			assert.False(t, getResult(t, report, ecs.PreflightCheckTaskRole).Passed)
+			assert.False(t, getResult(t, report, ecs.PreflightCheckExecutionRole).Passed)
+			assert.True(t, getResult(t, report, ecs.PreflightCheckCluster).Passed)
+		},
+		"FailsWithRoleThatECSTasksCannotAssume": func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client) {
+			iamClient.Roles = append(iamClient.Roles, "ec2_role")
+			iamClient.TrustPolicies = map[string]string{
+				"ec2_role": `{"Statement":{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}}`,
+			}
+			opts := makePodCreationOpts(t)
+			opts.DefinitionOpts.SetTaskRole("ec2_role")
+
+			report, err := c.Check(ctx, *opts)
+			require.NoError(t, err)
+			require.NotZero(t, report)
+			assert.False(t, report.Passed())
+			require.Len(t, report.Failures(), 1)
+			res := report.Failures()[0]
+			assert.Equal(t, ecs.PreflightCheckTaskRole, res.Name)
+			assert.Contains(t, res.Message, "ecs-tasks.amazonaws.com")
+		},
+		"FailsWithNonexistentSubnets": func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client) {
+			ec2Client.Subnets = []cocoa.EC2Subnet{{ID: "subnet-1", VPCID: "vpc-1", AvailableIPAddresses: 10}}
+
+			report, err := c.Check(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, report)
+			assert.False(t, report.Passed())
+			require.Len(t, report.Failures(), 1)
+			res := report.Failures()[0]
+			assert.Equal(t, ecs.PreflightCheckSubnets, res.Name)
+			assert.Contains(t, res.Message, "subnet-2")
+			assert.NotContains(t, res.Message, "subnet-1")
+			assert.True(t, getResult(t, report, ecs.PreflightCheckNetworkConfiguration).Skipped)
+		},
+		"FailsWithNonexistentSecurityGroups": func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client) {
+			ec2Client.SecurityGroups = nil
+
+			report, err := c.Check(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, report)
+			assert.False(t, report.Passed())
+			require.Len(t, report.Failures(), 1)
+			assert.Equal(t, ecs.PreflightCheckSecurityGroups, report.Failures()[0].Name)
+		},
+		"FailsWithNetworkResourcesInDifferentVPCs": func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client) {
+			ec2Client.SecurityGroups = []cocoa.EC2SecurityGroup{{ID: "sg-1", VPCID: "vpc-2"}}
+
+			report, err := c.Check(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, report)
+			assert.False(t, report.Passed())
+			require.Len(t, report.Failures(), 1)
+			res := report.Failures()[0]
+			assert.Equal(t, ecs.PreflightCheckNetworkConfiguration, res.Name)
+			assert.Contains(t, res.Message, "vpc-1")
+			assert.Contains(t, res.Message, "vpc-2")
+		},
+		"FailsWithoutAvailableIPAddresses": func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client) {
+			for i := range ec2Client.Subnets {
+				ec2Client.Subnets[i].AvailableIPAddresses = 0
+			}
+
+			report, err := c.Check(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, report)
+			assert.False(t, report.Passed())
+			require.Len(t, report.Failures(), 1)
+			assert.Equal(t, ecs.PreflightCheckNetworkConfiguration, report.Failures()[0].Name)
+		},
+		"SkipsChecksWithoutOptionalClients": func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client) {
+			c, err := ecs.NewPreflightChecker(*ecs.NewPreflightCheckerOptions().SetECSClient(ecsClient))
+			require.NoError(t, err)
+
+			report, err := c.Check(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+			require.NotZero(t, report)
+			assert.True(t, report.Passed())
+			assert.True(t, getResult(t, report, ecs.PreflightCheckCluster).Passed)
+			for _, name := range []ecs.PreflightCheckName{
+				ecs.PreflightCheckTaskRole,
+				ecs.PreflightCheckExecutionRole,
+				ecs.PreflightCheckSubnets,
+				ecs.PreflightCheckSecurityGroups,
+				ecs.PreflightCheckNetworkConfiguration,
+			} {
+				assert.True(t, getResult(t, report, name).Skipped, "check '%s' should be skipped", name)
+			}
+			assert.Zero(t, iamClient.GetRoleTrustPolicyInput)
+			assert.Zero(t, ec2Client.DescribeSubnetsInput)
+		},
+		"FailsWithInvalidOptions": func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client) {
+			report, err := c.Check(ctx, *cocoa.NewECSPodCreationOptions())
+			assert.Error(t, err)
+			assert.Zero(t, report)
+			assert.Zero(t, ecsClient.DescribeClustersInput)
+		},
+		"FailsWhenClientErrors": func(ctx context.Context, t *testing.T, c *ecs.PreflightChecker, ecsClient *ECSClient, iamClient *IAMClient, ec2Client *EC2Client) {
+			iamClient.GetRoleTrustPolicyError = errors.New("fake error")
+
+			report, err := c.Check(ctx, *makePodCreationOpts(t))
+			assert.Error(t, err)
+			assert.Zero(t, report)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			resetECSAndSecretsManagerCache()
+
+			ecsClient := &ECSClient{}
+			iamClient := &IAMClient{
+				Roles: []string{testutil.ECSTaskRole(), testutil.ECSExecutionRole()},
+			}
+			ec2Client := &EC2Client{
+				Subnets: []cocoa.EC2Subnet{
+					{ID: "subnet-1", VPCID: "vpc-1", AvailableIPAddresses: 10},
+					{ID: "subnet-2", VPCID: "vpc-1", AvailableIPAddresses: 10},
+				},
+				SecurityGroups: []cocoa.EC2SecurityGroup{{ID: "sg-1", VPCID: "vpc-1"}},
+			}
+
+			c, err := ecs.NewPreflightChecker(*ecs.NewPreflightCheckerOptions().
+				SetECSClient(ecsClient).
+				SetIAMClient(iamClient).
+				SetEC2Client(ec2Client))
+			require.NoError(t, err)
+
+			tCase(tctx, t, c, ecsClient, iamClient, ec2Client)
+		})
+	}
+}
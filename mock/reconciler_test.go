@@ -0,0 +1,184 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/ecs"
+	"github.com/evergreen-ci/cocoa/internal/testutil"
+	"github.com/evergreen-ci/cocoa/secret"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconciler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer resetECSAndSecretsManagerCache()
+
+	makePodCreationOpts := func(t *testing.T) *cocoa.ECSPodCreationOptions {
+		containerDef := cocoa.NewECSContainerDefinition().
+			SetImage("image").
+			SetMemoryMB(128).
+			SetCPU(128).
+			SetName("container")
+		defOpts := cocoa.NewECSPodDefinitionOptions().
+			SetName(testutil.NewTaskDefinitionFamily(t)).
+			AddContainerDefinitions(*containerDef).
+			SetMemoryMB(128).
+			SetCPU(128).
+			SetTaskRole(testutil.ECSTaskRole()).
+			SetExecutionRole(testutil.ECSExecutionRole())
+		execOpts := cocoa.NewECSPodExecutionOptions().
+			SetCluster(testutil.ECSClusterName())
+		return cocoa.NewECSPodCreationOptions().
+			SetDefinitionOptions(*defOpts).
+			SetExecutionOptions(*execOpts)
+	}
+
+	for tName, tCase := range map[string]func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient){
+		"StopsUndesiredAndKilledPods": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			var arns []string
+			for i := 0; i < 3; i++ {
+				p, err := pc.CreatePod(ctx, *makePodCreationOpts(t))
+				require.NoError(t, err)
+				arns = append(arns, utility.FromStringPtr(p.Resources().TaskID))
+			}
+
+			r, err := ecs.NewReconciler(*ecs.NewReconcilerOptions().
+				SetClient(c).
+				SetCluster(testutil.ECSClusterName()).
+				SetStopReason("reconciled"))
+			require.NoError(t, err)
+
+			actual, err := r.Snapshot(ctx, *ecs.NewListPodsFilters())
+			require.NoError(t, err)
+			assert.ElementsMatch(t, arns, actual)
+
+			desired := []ecs.DesiredPod{
+				*ecs.NewDesiredPod().SetARN(arns[0]),
+				*ecs.NewDesiredPod().SetARN(arns[1]).SetDesiredStatus(types.DesiredStatusStopped),
+			}
+			res, err := r.Reconcile(ctx, desired, actual)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, arns[1:], res.Plan.ToStop)
+			assert.ElementsMatch(t, arns[1:], res.Stopped)
+			assert.Empty(t, res.Created)
+			require.NotZero(t, c.StopTaskInput)
+			assert.Equal(t, "reconciled", utility.FromStringPtr(c.StopTaskInput.Reason))
+
+			actual, err = r.Snapshot(ctx, *ecs.NewListPodsFilters())
+			require.NoError(t, err)
+			assert.Equal(t, arns[:1], actual)
+		},
+		"ReplacesMissingPods": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			r, err := ecs.NewReconciler(*ecs.NewReconcilerOptions().
+				SetClient(c).
+				SetCreator(pc).
+				SetCluster(testutil.ECSClusterName()))
+			require.NoError(t, err)
+
+			desired := []ecs.DesiredPod{*ecs.NewDesiredPod().SetARN("missing").SetCreationOptions(*makePodCreationOpts(t))}
+			res, err := r.Reconcile(ctx, desired, nil)
+			require.NoError(t, err)
+			require.Len(t, res.Plan.ToCreate, 1)
+			require.Len(t, res.Created, 1)
+			p, ok := res.Created["missing"]
+			require.True(t, ok)
+
+			actual, err := r.Snapshot(ctx, *ecs.NewListPodsFilters())
+			require.NoError(t, err)
+			assert.Equal(t, []string{utility.FromStringPtr(p.Resources().TaskID)}, actual)
+		},
+		"DoesNotExecuteActionsInDryRun": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			p, err := pc.CreatePod(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+			arn := utility.FromStringPtr(p.Resources().TaskID)
+
+			r, err := ecs.NewReconciler(*ecs.NewReconcilerOptions().
+				SetClient(c).
+				SetCreator(pc).
+				SetCluster(testutil.ECSClusterName()).
+				SetDryRun(true))
+			require.NoError(t, err)
+
+			desired := []ecs.DesiredPod{*ecs.NewDesiredPod().SetARN("missing").SetCreationOptions(*makePodCreationOpts(t))}
+			res, err := r.Reconcile(ctx, desired, []string{arn})
+			require.NoError(t, err)
+			assert.Equal(t, []string{arn}, res.Plan.ToStop)
+			assert.Len(t, res.Plan.ToCreate, 1)
+			assert.Empty(t, res.Stopped)
+			assert.Empty(t, res.Created)
+			assert.Zero(t, c.StopTaskInput)
+
+			actual, err := r.Snapshot(ctx, *ecs.NewListPodsFilters())
+			require.NoError(t, err)
+			assert.Equal(t, []string{arn}, actual)
+		},
+		"SucceedsStoppingPodThatIsAlreadyGone": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			r, err := ecs.NewReconciler(*ecs.NewReconcilerOptions().
+				SetClient(c).
+				SetCluster(testutil.ECSClusterName()))
+			require.NoError(t, err)
+
+			res, err := r.Reconcile(ctx, nil, []string{"nonexistent"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"nonexistent"}, res.Stopped)
+		},
+		"FailsToReplacePodsWithoutCreator": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			p, err := pc.CreatePod(ctx, *makePodCreationOpts(t))
+			require.NoError(t, err)
+			arn := utility.FromStringPtr(p.Resources().TaskID)
+
+			r, err := ecs.NewReconciler(*ecs.NewReconcilerOptions().
+				SetClient(c).
+				SetCluster(testutil.ECSClusterName()))
+			require.NoError(t, err)
+
+			desired := []ecs.DesiredPod{*ecs.NewDesiredPod().SetARN("missing").SetCreationOptions(*makePodCreationOpts(t))}
+			res, err := r.Reconcile(ctx, desired, []string{arn})
+			assert.Error(t, err)
+			require.NotZero(t, res)
+			assert.Empty(t, res.Stopped, "no actions should be executed if the plan cannot be fully executed")
+		},
+		"ReturnsPartialResultsOnFailure": func(ctx context.Context, t *testing.T, pc cocoa.ECSPodCreator, c *ECSClient) {
+			r, err := ecs.NewReconciler(*ecs.NewReconcilerOptions().
+				SetClient(c).
+				SetCreator(pc).
+				SetCluster(testutil.ECSClusterName()))
+			require.NoError(t, err)
+
+			invalidOpts := makePodCreationOpts(t)
+			invalidOpts.DefinitionOpts.ContainerDefinitions = nil
+			desired := []ecs.DesiredPod{
+				*ecs.NewDesiredPod().SetARN("valid").SetCreationOptions(*makePodCreationOpts(t)),
+				*ecs.NewDesiredPod().SetARN("invalid").SetCreationOptions(*invalidOpts),
+			}
+			res, err := r.Reconcile(ctx, desired, nil)
+			assert.Error(t, err)
+			require.NotZero(t, res)
+			assert.Len(t, res.Created, 1)
+			assert.NotZero(t, res.Created["valid"])
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			resetECSAndSecretsManagerCache()
+
+			c := &ECSClient{}
+			v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(&SecretsManagerClient{}))
+			require.NoError(t, err)
+
+			pc, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().SetClient(c).SetVault(NewVault(v)))
+			require.NoError(t, err)
+
+			tCase(tctx, t, NewECSPodCreator(pc), c)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/ecs"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePodDefinitionRoles(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	makeClient := func() *IAMClient {
+		return &IAMClient{
+			Roles: []string{"task_role", "execution_role", "ec2_role"},
+			TrustPolicies: map[string]string{
+				"ec2_role": `{"Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`,
+			},
+		}
+	}
+
+	t.Run("SucceedsWithValidRoles", func(t *testing.T) {
+		c := makeClient()
+		opts := cocoa.NewECSPodDefinitionOptions().
+			SetTaskRole("task_role").
+			SetExecutionRole("execution_role")
+		assert.NoError(t, ecs.ValidatePodDefinitionRoles(ctx, c, *opts))
+		assert.Equal(t, "execution_role", *c.GetRoleTrustPolicyInput)
+	})
+	t.Run("SucceedsWithoutRoles", func(t *testing.T) {
+		c := makeClient()
+		assert.NoError(t, ecs.ValidatePodDefinitionRoles(ctx, c, *cocoa.NewECSPodDefinitionOptions()))
+		assert.Zero(t, c.GetRoleTrustPolicyInput)
+	})
+	t.Run("FailsWithNonexistentRole", func(t *testing.T) {
+		opts := cocoa.NewECSPodDefinitionOptions().SetTaskRole("nonexistent")
+		err := ecs.ValidatePodDefinitionRoles(ctx, makeClient(), *opts)
+		require.Error(t, err)
+		assert.True(t, cocoa.IsRoleMisconfigurationError(err))
+		assert.Contains(t, err.Error(), "nonexistent")
+	})
+	t.Run("FailsWithRoleThatECSTasksCannotAssume", func(t *testing.T) {
+		opts := cocoa.NewECSPodDefinitionOptions().
+			SetTaskRole("task_role").
+			SetExecutionRole("ec2_role")
+		err := ecs.ValidatePodDefinitionRoles(ctx, makeClient(), *opts)
+		require.Error(t, err)
+		assert.True(t, cocoa.IsRoleMisconfigurationError(err))
+		assert.Contains(t, err.Error(), "ec2_role")
+	})
+	t.Run("FailsWithInvalidTrustPolicy", func(t *testing.T) {
+		c := makeClient()
+		c.TrustPolicies["task_role"] = "foo"
+		opts := cocoa.NewECSPodDefinitionOptions().SetTaskRole("task_role")
+		err := ecs.ValidatePodDefinitionRoles(ctx, c, *opts)
+		require.Error(t, err)
+		assert.True(t, cocoa.IsRoleMisconfigurationError(err))
+	})
+	t.Run("FailsWhenClientErrors", func(t *testing.T) {
+		c := makeClient()
+		c.GetRoleTrustPolicyError = errors.New("fake error")
+		opts := cocoa.NewECSPodDefinitionOptions().SetTaskRole("task_role")
+		err := ecs.ValidatePodDefinitionRoles(ctx, c, *opts)
+		require.Error(t, err)
+		assert.False(t, cocoa.IsRoleMisconfigurationError(err))
+	})
+}
@@ -0,0 +1,91 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/evergreen-ci/cocoa"
+)
+
+// SecretUsageTracker provides a mock implementation of a
+// cocoa.SecretUsageTracker backed by any secret usage tracker. This makes it
+// possible to introspect on inputs to the tracker and control the tracker's
+// output.
+type SecretUsageTracker struct {
+	cocoa.SecretUsageTracker
+
+	TrackPodSecretsInput *TrackPodSecretsInput
+	TrackPodSecretsError error
+
+	UntrackPodInput *string
+	UntrackPodError error
+
+	FindUnreferencedSecretsOutput []string
+	FindUnreferencedSecretsError  error
+
+	ForgetSecretsInput []string
+	ForgetSecretsError error
+}
+
+// TrackPodSecretsInput is the input to TrackPodSecrets.
+type TrackPodSecretsInput struct {
+	PodID     string
+	SecretIDs []string
+}
+
+// NewSecretUsageTracker creates a mock secret usage tracker backed by the
+// given secret usage tracker.
+func NewSecretUsageTracker(t cocoa.SecretUsageTracker) *SecretUsageTracker {
+	return &SecretUsageTracker{
+		SecretUsageTracker: t,
+	}
+}
+
+// TrackPodSecrets saves the input and records the pod's secrets. The mock
+// output can be customized. By default, it will call the backing secret usage
+// tracker's TrackPodSecrets.
+func (m *SecretUsageTracker) TrackPodSecrets(ctx context.Context, podID string, secretIDs []string) error {
+	m.TrackPodSecretsInput = &TrackPodSecretsInput{PodID: podID, SecretIDs: secretIDs}
+
+	if m.TrackPodSecretsError != nil {
+		return m.TrackPodSecretsError
+	}
+
+	return m.SecretUsageTracker.TrackPodSecrets(ctx, podID, secretIDs)
+}
+
+// UntrackPod saves the input and untracks the pod's secrets. The mock output
+// can be customized. By default, it will call the backing secret usage
+// tracker's UntrackPod.
+func (m *SecretUsageTracker) UntrackPod(ctx context.Context, podID string) error {
+	m.UntrackPodInput = &podID
+
+	if m.UntrackPodError != nil {
+		return m.UntrackPodError
+	}
+
+	return m.SecretUsageTracker.UntrackPod(ctx, podID)
+}
+
+// FindUnreferencedSecrets returns the mock unreferenced secrets. The mock
+// output can be customized. By default, it will call the backing secret usage
+// tracker's FindUnreferencedSecrets.
+func (m *SecretUsageTracker) FindUnreferencedSecrets(ctx context.Context) ([]string, error) {
+	if m.FindUnreferencedSecretsOutput != nil || m.FindUnreferencedSecretsError != nil {
+		return m.FindUnreferencedSecretsOutput, m.FindUnreferencedSecretsError
+	}
+
+	return m.SecretUsageTracker.FindUnreferencedSecrets(ctx)
+}
+
+// ForgetSecrets saves the input and stops tracking the secrets. The mock output
+// can be customized. By default, it will call the backing secret usage
+// tracker's ForgetSecrets.
+func (m *SecretUsageTracker) ForgetSecrets(ctx context.Context, secretIDs []string) error {
+	m.ForgetSecretsInput = secretIDs
+
+	if m.ForgetSecretsError != nil {
+		return m.ForgetSecretsError
+	}
+
+	return m.SecretUsageTracker.ForgetSecrets(ctx, secretIDs)
+}
@@ -0,0 +1,143 @@
+package mock
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/ecs"
+	"github.com/evergreen-ci/cocoa/internal/testutil"
+	"github.com/evergreen-ci/cocoa/secret"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyPodDefinitionSecrets(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer resetECSAndSecretsManagerCache()
+
+	registerWithSecret := func(ctx context.Context, t *testing.T, c *ECSClient, secretID string) string {
+		in := testutil.ValidRegisterTaskDefinitionInput(t)
+		in.ContainerDefinitions[0].Secrets = []types.Secret{{
+			Name:      aws.String("SECRET_ENV_VAR"),
+			ValueFrom: aws.String(secretID),
+		}}
+		out := testutil.RegisterTaskDefinition(ctx, t, c, in)
+		return utility.FromStringPtr(out.TaskDefinition.TaskDefinitionArn)
+	}
+	createSecret := func(ctx context.Context, t *testing.T, v cocoa.Vault) string {
+		id, err := v.CreateSecret(ctx, *cocoa.NewNamedSecret().
+			SetName(testutil.NewSecretName(t)).
+			SetValue("secret_value"))
+		require.NoError(t, err)
+		return id
+	}
+
+	for tName, tCase := range map[string]func(ctx context.Context, t *testing.T, c *ECSClient, sm *SecretsManagerClient, v *secret.BasicSecretsManager){
+		"SucceedsWithExistingSecret": func(ctx context.Context, t *testing.T, c *ECSClient, sm *SecretsManagerClient, v *secret.BasicSecretsManager) {
+			id := registerWithSecret(ctx, t, c, createSecret(ctx, t, v))
+			assert.NoError(t, ecs.VerifyPodDefinitionSecrets(ctx, c, v, id))
+		},
+		"SucceedsWithoutSecrets": func(ctx context.Context, t *testing.T, c *ECSClient, sm *SecretsManagerClient, v *secret.BasicSecretsManager) {
+			out := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			assert.NoError(t, ecs.VerifyPodDefinitionSecrets(ctx, c, v, utility.FromStringPtr(out.TaskDefinition.TaskDefinitionArn)))
+		},
+		"FailsWithNonexistentSecret": func(ctx context.Context, t *testing.T, c *ECSClient, sm *SecretsManagerClient, v *secret.BasicSecretsManager) {
+			id := registerWithSecret(ctx, t, c, "nonexistent")
+			err := ecs.VerifyPodDefinitionSecrets(ctx, c, v, id)
+			require.Error(t, err)
+			assert.True(t, cocoa.IsMissingSecretsError(err))
+			assert.Contains(t, err.Error(), "nonexistent")
+		},
+		"FailsWithUnparseableSecretARN": func(ctx context.Context, t *testing.T, c *ECSClient, sm *SecretsManagerClient, v *secret.BasicSecretsManager) {
+			const badARN = "arn:aws:secretsmanager:us-east-1:123456789012:secret:name:key"
+			id := registerWithSecret(ctx, t, c, badARN)
+			err := ecs.VerifyPodDefinitionSecrets(ctx, c, v, id)
+			require.Error(t, err)
+			assert.True(t, cocoa.IsMissingSecretsError(err))
+			assert.Contains(t, err.Error(), badARN)
+		},
+		"FailsWithDeletedSecret": func(ctx context.Context, t *testing.T, c *ECSClient, sm *SecretsManagerClient, v *secret.BasicSecretsManager) {
+			secretID := createSecret(ctx, t, v)
+			id := registerWithSecret(ctx, t, c, secretID)
+			require.NoError(t, v.DeleteSecret(ctx, secretID))
+
+			err := ecs.VerifyPodDefinitionSecrets(ctx, c, v, id)
+			require.Error(t, err)
+			assert.True(t, cocoa.IsMissingSecretsError(err))
+		},
+		"FailsWithSecretScheduledForDeletion": func(ctx context.Context, t *testing.T, c *ECSClient, sm *SecretsManagerClient, v *secret.BasicSecretsManager) {
+			secretID := createSecret(ctx, t, v)
+			id := registerWithSecret(ctx, t, c, secretID)
+			_, err := sm.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+				SecretId:             aws.String(secretID),
+				RecoveryWindowInDays: aws.Int64(7),
+			})
+			require.NoError(t, err)
+
+			err = ecs.VerifyPodDefinitionSecrets(ctx, c, v, id)
+			require.Error(t, err)
+			assert.True(t, cocoa.IsMissingSecretsError(err))
+		},
+		"FailsWithNonexistentPodDefinition": func(ctx context.Context, t *testing.T, c *ECSClient, sm *SecretsManagerClient, v *secret.BasicSecretsManager) {
+			err := ecs.VerifyPodDefinitionSecrets(ctx, c, v, "nonexistent")
+			assert.Error(t, err)
+			assert.False(t, cocoa.IsMissingSecretsError(err))
+		},
+		"PodCreatorDoesNotRunPodWithMissingSecret": func(ctx context.Context, t *testing.T, c *ECSClient, sm *SecretsManagerClient, v *secret.BasicSecretsManager) {
+			pc, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetVault(v).
+				SetVerifySecrets(true))
+			require.NoError(t, err)
+
+			def := cocoa.NewECSTaskDefinition().SetID(registerWithSecret(ctx, t, c, "nonexistent"))
+			execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+			p, err := pc.CreatePodFromExistingDefinition(ctx, *def, *execOpts)
+			require.Error(t, err)
+			assert.True(t, cocoa.IsMissingSecretsError(err))
+			assert.Zero(t, p)
+			assert.Zero(t, c.RunTaskInput)
+		},
+		"PodCreatorRunsPodWithExistingSecret": func(ctx context.Context, t *testing.T, c *ECSClient, sm *SecretsManagerClient, v *secret.BasicSecretsManager) {
+			pc, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetVault(v).
+				SetVerifySecrets(true))
+			require.NoError(t, err)
+
+			def := cocoa.NewECSTaskDefinition().SetID(registerWithSecret(ctx, t, c, createSecret(ctx, t, v)))
+			execOpts := cocoa.NewECSPodExecutionOptions().SetCluster(testutil.ECSClusterName())
+			p, err := pc.CreatePodFromExistingDefinition(ctx, *def, *execOpts)
+			require.NoError(t, err)
+			assert.NotZero(t, p)
+			assert.NotZero(t, c.RunTaskInput)
+		},
+		"PodCreatorOptionsRequireVaultToVerifySecrets": func(ctx context.Context, t *testing.T, c *ECSClient, sm *SecretsManagerClient, v *secret.BasicSecretsManager) {
+			_, err := ecs.NewBasicPodCreator(*ecs.NewBasicPodCreatorOptions().
+				SetClient(c).
+				SetVerifySecrets(true))
+			assert.Error(t, err)
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			resetECSAndSecretsManagerCache()
+
+			c := &ECSClient{}
+			sm := &SecretsManagerClient{}
+			v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(sm))
+			require.NoError(t, err)
+
+			tCase(tctx, t, c, sm, v)
+		})
+	}
+}
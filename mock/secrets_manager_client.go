@@ -3,6 +3,7 @@ package mock
 import (
 	"context"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -16,15 +17,26 @@ import (
 type StoredSecret struct {
 	// For the sake of simplicity, the secret ARN is synonymous with the secret
 	// name.
-	Name         string
-	Value        string
-	BinaryValue  []byte
-	IsDeleted    bool
-	Created      time.Time
-	LastUpdated  time.Time
+	Name        string
+	Value       string
+	BinaryValue []byte
+	IsDeleted   bool
+	Created     time.Time
+	LastUpdated time.Time
+	// LastAccessed is the last time the secret was accessed.
 	LastAccessed time.Time
-	Deleted      time.Time
-	Tags         map[string]string
+	// Deleted is the time when a secret scheduled for deletion is permanently
+	// deleted. Until then, the deleted secret can be restored. This is zero if
+	// the secret was forcibly deleted without a recovery window.
+	Deleted time.Time
+	Tags    map[string]string
+	// RotationEnabled is whether the secret is configured to rotate
+	// automatically.
+	RotationEnabled   bool
+	RotationLambdaARN string
+	RotationRules     *types.RotationRulesType
+	// LastRotated is the last time the secret was rotated.
+	LastRotated time.Time
 }
 
 func newStoredSecret(in *secretsmanager.CreateSecretInput, ts time.Time) StoredSecret {
@@ -41,16 +53,34 @@ func newStoredSecret(in *secretsmanager.CreateSecretInput, ts time.Time) StoredS
 
 func exportSecretListEntry(s StoredSecret) types.SecretListEntry {
 	return types.SecretListEntry{
-		ARN:              utility.ToStringPtr(s.Name),
-		Name:             utility.ToStringPtr(s.Name),
-		CreatedDate:      utility.ToTimePtr(s.Created),
-		LastAccessedDate: utility.ToTimePtr(s.LastAccessed),
-		LastChangedDate:  utility.ToTimePtr(s.LastUpdated),
-		DeletedDate:      utility.ToTimePtr(s.Deleted),
-		Tags:             exportSecretsManagerTags(s.Tags),
+		ARN:               utility.ToStringPtr(s.Name),
+		Name:              utility.ToStringPtr(s.Name),
+		CreatedDate:       utility.ToTimePtr(s.Created),
+		LastAccessedDate:  utility.ToTimePtr(s.LastAccessed),
+		LastChangedDate:   utility.ToTimePtr(s.LastUpdated),
+		DeletedDate:       utility.ToTimePtr(s.Deleted),
+		Tags:              exportSecretsManagerTags(s.Tags),
+		RotationEnabled:   utility.ToBoolPtr(s.RotationEnabled),
+		RotationLambdaARN: exportRotationLambdaARN(s),
+		RotationRules:     s.RotationRules,
+		LastRotatedDate:   exportLastRotated(s),
 	}
 }
 
+func exportRotationLambdaARN(s StoredSecret) *string {
+	if s.RotationLambdaARN == "" {
+		return nil
+	}
+	return utility.ToStringPtr(s.RotationLambdaARN)
+}
+
+func exportLastRotated(s StoredSecret) *time.Time {
+	if s.LastRotated.IsZero() {
+		return nil
+	}
+	return utility.ToTimePtr(s.LastRotated)
+}
+
 func newSecretsManagerTags(tags []types.Tag) map[string]string {
 	converted := map[string]string{}
 	for _, t := range tags {
@@ -70,11 +100,22 @@ func exportSecretsManagerTags(tags map[string]string) []types.Tag {
 	return exported
 }
 
+// StoredSecrets are the secrets kept in a secret storage cache, keyed by the
+// secret ID.
+type StoredSecrets map[string]StoredSecret
+
 // GlobalSecretCache is a global secret storage cache that provides a simplified
 // in-memory implementation of a secrets storage service. This can be used
 // indirectly with the SecretsManagerClient to access and modify secrets, or
-// used directly.
-var GlobalSecretCache map[string]StoredSecret
+// used directly. The SecretsManagerClient locks the secret storage caches
+// while it accesses them, so the cache's helper methods (e.g. Snapshot) can
+// safely be used while clients concurrently make API calls; however, the cache
+// is not safe to access directly while clients are in use.
+var GlobalSecretCache StoredSecrets
+
+// storedSecretsMu guards access to all the secret storage caches, including
+// GlobalSecretCache and the scoped caches.
+var storedSecretsMu sync.Mutex
 
 func init() {
 	ResetGlobalSecretCache()
@@ -83,7 +124,31 @@ func init() {
 // ResetGlobalSecretCache resets the global fake secret storage cache to an
 // initialized but clean state.
 func ResetGlobalSecretCache() {
-	GlobalSecretCache = map[string]StoredSecret{}
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	GlobalSecretCache = StoredSecrets{}
+}
+
+// NewScopedSecretCache returns a new fake secret storage cache that is isolated
+// from GlobalSecretCache, along with a SecretsManagerClient that issues its API
+// calls to that cache. This is useful for tests that run in parallel (e.g.
+// across packages), since they do not share any state and do not need to reset
+// GlobalSecretCache. The scoped cache is cleaned up once it is no longer
+// referenced.
+func NewScopedSecretCache() (StoredSecrets, *SecretsManagerClient) {
+	secrets := StoredSecrets{}
+	return secrets, &SecretsManagerClient{Secrets: secrets}
+}
+
+// purgeExpired permanently removes all secrets from the secret cache whose
+// recovery window has passed.
+func (ss StoredSecrets) purgeExpired(ts time.Time) {
+	for id, s := range ss {
+		if s.IsDeleted && !s.Deleted.IsZero() && !ts.Before(s.Deleted) {
+			delete(ss, id)
+		}
+	}
 }
 
 // SecretsManagerClient provides a mock implementation of a
@@ -92,6 +157,10 @@ func ResetGlobalSecretCache() {
 // implementations where possible. By default, it will issue the API calls to
 // the fake GlobalSecretCache.
 type SecretsManagerClient struct {
+	// Secrets is the fake secret storage cache that the client issues its API
+	// calls to. If this is not set, it uses GlobalSecretCache.
+	Secrets StoredSecrets
+
 	CreateSecretInput  *secretsmanager.CreateSecretInput
 	CreateSecretOutput *secretsmanager.CreateSecretOutput
 	CreateSecretError  error
@@ -116,21 +185,58 @@ type SecretsManagerClient struct {
 	DeleteSecretOutput *secretsmanager.DeleteSecretOutput
 	DeleteSecretError  error
 
+	RestoreSecretInput  *secretsmanager.RestoreSecretInput
+	RestoreSecretOutput *secretsmanager.RestoreSecretOutput
+	RestoreSecretError  error
+
+	RotateSecretInput  *secretsmanager.RotateSecretInput
+	RotateSecretOutput *secretsmanager.RotateSecretOutput
+	RotateSecretError  error
+
+	CancelRotateSecretInput  *secretsmanager.CancelRotateSecretInput
+	CancelRotateSecretOutput *secretsmanager.CancelRotateSecretOutput
+	CancelRotateSecretError  error
+
 	TagResourceInput  *secretsmanager.TagResourceInput
 	TagResourceOutput *secretsmanager.TagResourceOutput
 	TagResourceError  error
+
+	// Latency, if set, delays each API call to simulate slow responses. If the
+	// call's context is done before the delay is over, the call returns the
+	// context's error.
+	Latency *LatencySimulator
+}
+
+// secrets returns the fake secret storage cache that the client issues its API
+// calls to.
+func (c *SecretsManagerClient) secrets() StoredSecrets {
+	if c.Secrets != nil {
+		return c.Secrets
+	}
+	return GlobalSecretCache
 }
 
 // CreateSecret saves the input options and returns a new mock secret. The mock
 // output can be customized. By default, it will create and save a cached mock
-// secret based on the input in the global secret cache.
+// secret based on the input in the global secret cache. As in Secrets Manager,
+// the name of a secret that's scheduled for deletion cannot be reused until the
+// secret is permanently deleted.
 func (c *SecretsManagerClient) CreateSecret(ctx context.Context, in *secretsmanager.CreateSecretInput) (*secretsmanager.CreateSecretOutput, error) {
 	c.CreateSecretInput = in
 
+	if err := c.Latency.wait(ctx, "CreateSecret"); err != nil {
+		return nil, err
+	}
+
 	if c.CreateSecretOutput != nil || c.CreateSecretError != nil {
 		return c.CreateSecretOutput, c.CreateSecretError
 	}
 
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	c.secrets().purgeExpired(time.Now())
+
 	if in.Name == nil {
 		return nil, &types.InvalidParameterException{Message: aws.String("missing secret name")}
 	}
@@ -142,12 +248,14 @@ func (c *SecretsManagerClient) CreateSecret(ctx context.Context, in *secretsmana
 	}
 
 	name := utility.FromStringPtr(in.Name)
-	if s, ok := GlobalSecretCache[name]; ok && !s.IsDeleted {
+	if s, ok := c.secrets()[name]; ok && !s.IsDeleted {
 		return nil, &types.ResourceExistsException{Message: aws.String("secret already exists")}
+	} else if ok && !s.Deleted.IsZero() {
+		return nil, &types.ResourceExistsException{Message: aws.String("secret with this name is already scheduled for deletion")}
 	}
 
 	newSecret := newStoredSecret(in, time.Now())
-	GlobalSecretCache[newSecret.Name] = newSecret
+	c.secrets()[newSecret.Name] = newSecret
 
 	return &secretsmanager.CreateSecretOutput{
 		ARN:  utility.ToStringPtr(newSecret.Name),
@@ -161,10 +269,19 @@ func (c *SecretsManagerClient) CreateSecret(ctx context.Context, in *secretsmana
 func (c *SecretsManagerClient) GetSecretValue(ctx context.Context, in *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
 	c.GetSecretValueInput = in
 
+	if err := c.Latency.wait(ctx, "GetSecretValue"); err != nil {
+		return nil, err
+	}
+
 	if c.GetSecretValueOutput != nil || c.GetSecretValueError != nil {
 		return c.GetSecretValueOutput, c.GetSecretValueError
 	}
 
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	c.secrets().purgeExpired(time.Now())
+
 	if in.SecretId == nil {
 		return nil, &types.InvalidParameterException{Message: aws.String("missing secret ID")}
 	}
@@ -180,7 +297,7 @@ func (c *SecretsManagerClient) GetSecretValue(ctx context.Context, in *secretsma
 	}
 
 	s.LastAccessed = time.Now()
-	GlobalSecretCache[id] = *s
+	c.secrets()[id] = *s
 
 	return &secretsmanager.GetSecretValueOutput{
 		ARN:          utility.ToStringPtr(s.Name),
@@ -192,10 +309,10 @@ func (c *SecretsManagerClient) GetSecretValue(ctx context.Context, in *secretsma
 }
 
 func (c *SecretsManagerClient) getSecret(id string) *StoredSecret {
-	if s, ok := GlobalSecretCache[id]; ok {
+	if s, ok := c.secrets()[id]; ok {
 		return &s
 	}
-	for _, s := range GlobalSecretCache {
+	for _, s := range c.secrets() {
 		if s.Name == id {
 			return &s
 		}
@@ -210,27 +327,41 @@ func (c *SecretsManagerClient) getSecret(id string) *StoredSecret {
 func (c *SecretsManagerClient) DescribeSecret(ctx context.Context, in *secretsmanager.DescribeSecretInput) (*secretsmanager.DescribeSecretOutput, error) {
 	c.DescribeSecretInput = in
 
+	if err := c.Latency.wait(ctx, "DescribeSecret"); err != nil {
+		return nil, err
+	}
+
 	if c.DescribeSecretOutput != nil || c.DescribeSecretError != nil {
 		return c.DescribeSecretOutput, c.DescribeSecretError
 	}
 
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	c.secrets().purgeExpired(time.Now())
+
 	if in.SecretId == nil {
 		return nil, &types.InvalidParameterException{Message: aws.String("missing secret ID")}
 	}
 
-	s, ok := GlobalSecretCache[utility.FromStringPtr(in.SecretId)]
-	if !ok {
+	s, ok := c.secrets()[utility.FromStringPtr(in.SecretId)]
+	// Secrets that are deleted without a recovery window no longer exist.
+	if !ok || s.IsDeleted && s.Deleted.IsZero() {
 		return nil, &types.ResourceNotFoundException{Message: aws.String("secret not found")}
 	}
 
 	return &secretsmanager.DescribeSecretOutput{
-		ARN:              utility.ToStringPtr(s.Name),
-		Name:             utility.ToStringPtr(s.Name),
-		CreatedDate:      utility.ToTimePtr(s.Created),
-		LastAccessedDate: utility.ToTimePtr(s.LastAccessed),
-		LastChangedDate:  utility.ToTimePtr(s.LastUpdated),
-		DeletedDate:      utility.ToTimePtr(s.Deleted),
-		Tags:             exportSecretsManagerTags(s.Tags),
+		ARN:               utility.ToStringPtr(s.Name),
+		Name:              utility.ToStringPtr(s.Name),
+		CreatedDate:       utility.ToTimePtr(s.Created),
+		LastAccessedDate:  utility.ToTimePtr(s.LastAccessed),
+		LastChangedDate:   utility.ToTimePtr(s.LastUpdated),
+		DeletedDate:       utility.ToTimePtr(s.Deleted),
+		Tags:              exportSecretsManagerTags(s.Tags),
+		RotationEnabled:   utility.ToBoolPtr(s.RotationEnabled),
+		RotationLambdaARN: exportRotationLambdaARN(s),
+		RotationRules:     s.RotationRules,
+		LastRotatedDate:   exportLastRotated(s),
 	}, nil
 }
 
@@ -240,10 +371,19 @@ func (c *SecretsManagerClient) DescribeSecret(ctx context.Context, in *secretsma
 func (c *SecretsManagerClient) ListSecrets(ctx context.Context, in *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error) {
 	c.ListSecretsInput = in
 
+	if err := c.Latency.wait(ctx, "ListSecrets"); err != nil {
+		return nil, err
+	}
+
 	if c.ListSecretsOutput != nil || c.ListSecretsError != nil {
 		return c.ListSecretsOutput, c.ListSecretsError
 	}
 
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	c.secrets().purgeExpired(time.Now())
+
 	// Get the subset of secrets that match each and every one of the filters.
 	var matchingAllFilters map[string]StoredSecret
 	if len(in.Filters) != 0 {
@@ -252,6 +392,20 @@ func (c *SecretsManagerClient) ListSecrets(ctx context.Context, in *secretsmanag
 			switch f.Key {
 			case "name":
 				matchingValues = c.secretsMatchingAnyNameValue(f.Values)
+			case "tag-key":
+				matchingValues = c.secretsMatchingAnyTagValue(f.Values, func(s StoredSecret, val string) bool {
+					_, ok := s.Tags[val]
+					return ok
+				})
+			case "tag-value":
+				matchingValues = c.secretsMatchingAnyTagValue(f.Values, func(s StoredSecret, val string) bool {
+					for _, tagVal := range s.Tags {
+						if tagVal == val {
+							return true
+						}
+					}
+					return false
+				})
 				// This could support other filter keys, but it's not worth it
 				// unless the need arises.
 			default:
@@ -268,7 +422,7 @@ func (c *SecretsManagerClient) ListSecrets(ctx context.Context, in *secretsmanag
 		}
 	} else {
 		// If no filters are given, return all the secrets.
-		matchingAllFilters = GlobalSecretCache
+		matchingAllFilters = c.secrets()
 	}
 
 	var converted []types.SecretListEntry
@@ -296,7 +450,7 @@ func (c *SecretsManagerClient) getSetIntersection(a, b map[string]StoredSecret)
 // negated.
 func (c *SecretsManagerClient) secretsMatchingAnyNameValue(vals []string) map[string]StoredSecret {
 	secrets := map[string]StoredSecret{}
-	for _, s := range GlobalSecretCache {
+	for _, s := range c.secrets() {
 		if s.IsDeleted {
 			continue
 		}
@@ -313,16 +467,46 @@ func (c *SecretsManagerClient) secretsMatchingAnyNameValue(vals []string) map[st
 	return secrets
 }
 
+// secretsMatchingAnyTagValue returns the secrets whose tags match any of the
+// given values. If the value begins with a "!", the match is negated.
+func (c *SecretsManagerClient) secretsMatchingAnyTagValue(vals []string, matches func(s StoredSecret, val string) bool) map[string]StoredSecret {
+	secrets := map[string]StoredSecret{}
+	for _, s := range c.secrets() {
+		if s.IsDeleted {
+			continue
+		}
+
+		for _, val := range vals {
+			if strings.HasPrefix(val, "!") && !matches(s, val[1:]) {
+				secrets[s.Name] = s
+			}
+			if !strings.HasPrefix(val, "!") && matches(s, val) {
+				secrets[s.Name] = s
+			}
+		}
+	}
+	return secrets
+}
+
 // UpdateSecretValue saves the input options and returns an updated mock secret
 // value. The mock output can be customized. By default, it will update a cached
 // mock secret if it exists in the global secret cache.
 func (c *SecretsManagerClient) UpdateSecretValue(ctx context.Context, in *secretsmanager.UpdateSecretInput) (*secretsmanager.UpdateSecretOutput, error) {
 	c.UpdateSecretInput = in
 
+	if err := c.Latency.wait(ctx, "UpdateSecret"); err != nil {
+		return nil, err
+	}
+
 	if c.UpdateSecretOutput != nil || c.UpdateSecretError != nil {
 		return c.UpdateSecretOutput, c.UpdateSecretError
 	}
 
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	c.secrets().purgeExpired(time.Now())
+
 	if in.SecretId == nil {
 		return nil, &types.InvalidParameterException{Message: aws.String("missing secret ID")}
 	}
@@ -334,7 +518,7 @@ func (c *SecretsManagerClient) UpdateSecretValue(ctx context.Context, in *secret
 	}
 
 	id := utility.FromStringPtr(in.SecretId)
-	s, ok := GlobalSecretCache[id]
+	s, ok := c.secrets()[id]
 	if !ok {
 		return nil, &types.ResourceNotFoundException{Message: aws.String("secret not found")}
 	}
@@ -354,7 +538,7 @@ func (c *SecretsManagerClient) UpdateSecretValue(ctx context.Context, in *secret
 	s.LastAccessed = ts
 	s.LastUpdated = ts
 
-	GlobalSecretCache[id] = s
+	c.secrets()[id] = s
 
 	return &secretsmanager.UpdateSecretOutput{
 		ARN:  utility.ToStringPtr(s.Name),
@@ -368,10 +552,19 @@ func (c *SecretsManagerClient) UpdateSecretValue(ctx context.Context, in *secret
 func (c *SecretsManagerClient) DeleteSecret(ctx context.Context, in *secretsmanager.DeleteSecretInput) (*secretsmanager.DeleteSecretOutput, error) {
 	c.DeleteSecretInput = in
 
+	if err := c.Latency.wait(ctx, "DeleteSecret"); err != nil {
+		return nil, err
+	}
+
 	if c.DeleteSecretOutput != nil || c.DeleteSecretError != nil {
 		return c.DeleteSecretOutput, c.DeleteSecretError
 	}
 
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	c.secrets().purgeExpired(time.Now())
+
 	if in.SecretId == nil {
 		return nil, &types.InvalidParameterException{Message: aws.String("missing secret ID")}
 	}
@@ -389,7 +582,7 @@ func (c *SecretsManagerClient) DeleteSecret(ctx context.Context, in *secretsmana
 	}
 
 	id := utility.FromStringPtr(in.SecretId)
-	s, ok := GlobalSecretCache[id]
+	s, ok := c.secrets()[id]
 	if !utility.FromBoolPtr(in.ForceDeleteWithoutRecovery) && !ok {
 		return nil, &types.ResourceNotFoundException{Message: aws.String("secret not found")}
 	}
@@ -401,7 +594,7 @@ func (c *SecretsManagerClient) DeleteSecret(ctx context.Context, in *secretsmana
 		s.Deleted = ts.AddDate(0, 0, window)
 	}
 	s.IsDeleted = true
-	GlobalSecretCache[id] = s
+	c.secrets()[id] = s
 
 	return &secretsmanager.DeleteSecretOutput{
 		ARN:          utility.ToStringPtr(s.Name),
@@ -416,13 +609,22 @@ func (c *SecretsManagerClient) DeleteSecret(ctx context.Context, in *secretsmana
 func (c *SecretsManagerClient) TagResource(ctx context.Context, in *secretsmanager.TagResourceInput) (*secretsmanager.TagResourceOutput, error) {
 	c.TagResourceInput = in
 
+	if err := c.Latency.wait(ctx, "TagResource"); err != nil {
+		return nil, err
+	}
+
 	if c.TagResourceOutput != nil || c.TagResourceError != nil {
 		return c.TagResourceOutput, c.TagResourceError
 	}
 
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	c.secrets().purgeExpired(time.Now())
+
 	id := utility.FromStringPtr(in.SecretId)
 
-	s, ok := GlobalSecretCache[id]
+	s, ok := c.secrets()[id]
 	if !ok {
 		return nil, &types.ResourceNotFoundException{Message: aws.String("secret not found")}
 	}
@@ -436,3 +638,162 @@ func (c *SecretsManagerClient) TagResource(ctx context.Context, in *secretsmanag
 	}
 	return &secretsmanager.TagResourceOutput{}, nil
 }
+
+// RestoreSecret saves the input options and restores an existing mock secret
+// that is scheduled for deletion. The mock output can be customized. By
+// default, it will restore the cached mock secret if it is still within its
+// recovery window. Secrets whose recovery window has passed or that were
+// forcibly deleted without recovery cannot be restored.
+func (c *SecretsManagerClient) RestoreSecret(ctx context.Context, in *secretsmanager.RestoreSecretInput) (*secretsmanager.RestoreSecretOutput, error) {
+	c.RestoreSecretInput = in
+
+	if err := c.Latency.wait(ctx, "RestoreSecret"); err != nil {
+		return nil, err
+	}
+
+	if c.RestoreSecretOutput != nil || c.RestoreSecretError != nil {
+		return c.RestoreSecretOutput, c.RestoreSecretError
+	}
+
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	c.secrets().purgeExpired(time.Now())
+
+	if in.SecretId == nil {
+		return nil, &types.InvalidParameterException{Message: aws.String("missing secret ID")}
+	}
+
+	id := utility.FromStringPtr(in.SecretId)
+	s, ok := c.secrets()[id]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("secret not found")}
+	}
+
+	if s.IsDeleted && s.Deleted.IsZero() {
+		return nil, &types.InvalidRequestException{Message: aws.String("secret was deleted without recovery")}
+	}
+
+	ts := time.Now()
+	s.LastAccessed = ts
+	s.LastUpdated = ts
+	s.IsDeleted = false
+	s.Deleted = time.Time{}
+	c.secrets()[id] = s
+
+	return &secretsmanager.RestoreSecretOutput{
+		ARN:  utility.ToStringPtr(s.Name),
+		Name: utility.ToStringPtr(s.Name),
+	}, nil
+}
+
+// RotateSecret saves the input options and configures automatic rotation for
+// an existing mock secret. The mock output can be customized. By default, it
+// will enable rotation for the cached mock secret if it exists and, unless
+// told not to rotate immediately, mark it as having just been rotated. The
+// secret's value is not changed.
+func (c *SecretsManagerClient) RotateSecret(ctx context.Context, in *secretsmanager.RotateSecretInput) (*secretsmanager.RotateSecretOutput, error) {
+	c.RotateSecretInput = in
+
+	if err := c.Latency.wait(ctx, "RotateSecret"); err != nil {
+		return nil, err
+	}
+
+	if c.RotateSecretOutput != nil || c.RotateSecretError != nil {
+		return c.RotateSecretOutput, c.RotateSecretError
+	}
+
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	c.secrets().purgeExpired(time.Now())
+
+	if in.SecretId == nil {
+		return nil, &types.InvalidParameterException{Message: aws.String("missing secret ID")}
+	}
+	if rules := in.RotationRules; rules != nil && rules.AutomaticallyAfterDays != nil {
+		days := utility.FromInt64Ptr(rules.AutomaticallyAfterDays)
+		if days < 1 || days > 1000 {
+			return nil, &types.InvalidParameterException{Message: aws.String("rotation interval must be between 1 and 1000 days")}
+		}
+	}
+
+	id := utility.FromStringPtr(in.SecretId)
+	s, ok := c.secrets()[id]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("secret not found")}
+	}
+	if s.IsDeleted {
+		return nil, &types.InvalidRequestException{Message: aws.String("secret is deleted")}
+	}
+
+	if in.RotationLambdaARN != nil {
+		s.RotationLambdaARN = utility.FromStringPtr(in.RotationLambdaARN)
+	}
+	if in.RotationRules != nil {
+		s.RotationRules = in.RotationRules
+	}
+	if s.RotationLambdaARN == "" {
+		return nil, &types.InvalidRequestException{Message: aws.String("secret has no rotation Lambda function")}
+	}
+
+	ts := time.Now()
+	s.RotationEnabled = true
+	s.LastAccessed = ts
+	s.LastUpdated = ts
+	if in.RotateImmediately == nil || utility.FromBoolPtr(in.RotateImmediately) {
+		s.LastRotated = ts
+	}
+	c.secrets()[id] = s
+
+	return &secretsmanager.RotateSecretOutput{
+		ARN:  utility.ToStringPtr(s.Name),
+		Name: utility.ToStringPtr(s.Name),
+	}, nil
+}
+
+// CancelRotateSecret saves the input options and turns off automatic rotation
+// for an existing mock secret. The mock output can be customized. By default,
+// it will disable rotation for the cached mock secret if it exists. The
+// secret's rotation Lambda and schedule are kept so that rotation can be
+// enabled again later.
+func (c *SecretsManagerClient) CancelRotateSecret(ctx context.Context, in *secretsmanager.CancelRotateSecretInput) (*secretsmanager.CancelRotateSecretOutput, error) {
+	c.CancelRotateSecretInput = in
+
+	if err := c.Latency.wait(ctx, "CancelRotateSecret"); err != nil {
+		return nil, err
+	}
+
+	if c.CancelRotateSecretOutput != nil || c.CancelRotateSecretError != nil {
+		return c.CancelRotateSecretOutput, c.CancelRotateSecretError
+	}
+
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	c.secrets().purgeExpired(time.Now())
+
+	if in.SecretId == nil {
+		return nil, &types.InvalidParameterException{Message: aws.String("missing secret ID")}
+	}
+
+	id := utility.FromStringPtr(in.SecretId)
+	s, ok := c.secrets()[id]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("secret not found")}
+	}
+	if s.IsDeleted {
+		return nil, &types.InvalidRequestException{Message: aws.String("secret is deleted")}
+	}
+
+	ts := time.Now()
+	s.RotationEnabled = false
+	s.LastAccessed = ts
+	s.LastUpdated = ts
+	c.secrets()[id] = s
+
+	return &secretsmanager.CancelRotateSecretOutput{
+		ARN:  utility.ToStringPtr(s.Name),
+		Name: utility.ToStringPtr(s.Name),
+	}, nil
+}
@@ -3,10 +3,17 @@ package mock
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/evergreen-ci/cocoa"
 	"github.com/evergreen-ci/cocoa/internal/testcase"
+	"github.com/evergreen-ci/cocoa/internal/testutil"
+	"github.com/evergreen-ci/utility"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSecretsManagerClient(t *testing.T) {
@@ -26,4 +33,150 @@ func TestSecretsManagerClient(t *testing.T) {
 			tCase(tctx, t, c)
 		})
 	}
+
+	for tName, tCase := range secretsManagerClientTests() {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			resetECSAndSecretsManagerCache()
+
+			c := &SecretsManagerClient{}
+			tCase(tctx, t, c)
+		})
+	}
+}
+
+// secretsManagerClientTests are mock-specific tests for the Secrets Manager
+// client.
+func secretsManagerClientTests() map[string]func(ctx context.Context, t *testing.T, c *SecretsManagerClient) {
+	createSecret := func(ctx context.Context, t *testing.T, c *SecretsManagerClient) secretsmanager.CreateSecretOutput {
+		return testutil.CreateSecret(ctx, t, c, secretsmanager.CreateSecretInput{
+			Name:         aws.String(testutil.NewSecretName(t)),
+			SecretString: aws.String("hello"),
+		})
+	}
+	scheduleDeletion := func(ctx context.Context, t *testing.T, c *SecretsManagerClient, id *string) {
+		_, err := c.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+			RecoveryWindowInDays: aws.Int64(7),
+			SecretId:             id,
+		})
+		require.NoError(t, err)
+	}
+	expireRecoveryWindow := func(t *testing.T, id *string) {
+		s, ok := GlobalSecretCache[utility.FromStringPtr(id)]
+		require.True(t, ok)
+		require.True(t, s.IsDeleted)
+		s.Deleted = time.Now().Add(-time.Minute)
+		GlobalSecretCache[utility.FromStringPtr(id)] = s
+	}
+
+	return map[string]func(ctx context.Context, t *testing.T, c *SecretsManagerClient){
+		"DeleteSecretSchedulesDeletionAfterRecoveryWindow": func(ctx context.Context, t *testing.T, c *SecretsManagerClient) {
+			createOut := createSecret(ctx, t, c)
+			scheduleDeletion(ctx, t, c, createOut.ARN)
+
+			s, ok := GlobalSecretCache[utility.FromStringPtr(createOut.ARN)]
+			require.True(t, ok)
+			assert.True(t, s.IsDeleted)
+			assert.WithinDuration(t, time.Now().AddDate(0, 0, 7), s.Deleted, time.Minute)
+		},
+		"RestoreSecretFailsAfterRecoveryWindowExpires": func(ctx context.Context, t *testing.T, c *SecretsManagerClient) {
+			createOut := createSecret(ctx, t, c)
+			scheduleDeletion(ctx, t, c, createOut.ARN)
+			expireRecoveryWindow(t, createOut.ARN)
+
+			_, err := c.RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{
+				SecretId: createOut.ARN,
+			})
+			assert.True(t, utility.MatchesError[*types.ResourceNotFoundException](err))
+			_, ok := GlobalSecretCache[utility.FromStringPtr(createOut.ARN)]
+			assert.False(t, ok, "secret should be permanently deleted")
+		},
+		"DescribeSecretFailsAfterRecoveryWindowExpires": func(ctx context.Context, t *testing.T, c *SecretsManagerClient) {
+			createOut := createSecret(ctx, t, c)
+			scheduleDeletion(ctx, t, c, createOut.ARN)
+
+			_, err := c.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+				SecretId: createOut.ARN,
+			})
+			require.NoError(t, err, "secret should still exist within its recovery window")
+
+			expireRecoveryWindow(t, createOut.ARN)
+
+			_, err = c.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+				SecretId: createOut.ARN,
+			})
+			assert.True(t, utility.MatchesError[*types.ResourceNotFoundException](err))
+		},
+		"CreateSecretSucceedsWithNameOfExpiredSecret": func(ctx context.Context, t *testing.T, c *SecretsManagerClient) {
+			createOut := createSecret(ctx, t, c)
+			scheduleDeletion(ctx, t, c, createOut.ARN)
+			expireRecoveryWindow(t, createOut.ARN)
+
+			recreateOut, err := c.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+				Name:         createOut.Name,
+				SecretString: aws.String("world"),
+			})
+			require.NoError(t, err)
+			require.NotZero(t, recreateOut)
+
+			getOut, err := c.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+				SecretId: recreateOut.ARN,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "world", utility.FromStringPtr(getOut.SecretString))
+		},
+		"CreateSecretFailsWithNameOfSecretScheduledForDeletion": func(ctx context.Context, t *testing.T, c *SecretsManagerClient) {
+			createOut := createSecret(ctx, t, c)
+			scheduleDeletion(ctx, t, c, createOut.ARN)
+
+			_, err := c.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+				Name:         createOut.Name,
+				SecretString: aws.String("world"),
+			})
+			assert.True(t, utility.MatchesError[*types.ResourceExistsException](err))
+		},
+		"CreateSecretSucceedsWithNameOfForceDeletedSecret": func(ctx context.Context, t *testing.T, c *SecretsManagerClient) {
+			createOut := createSecret(ctx, t, c)
+			_, err := c.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+				ForceDeleteWithoutRecovery: aws.Bool(true),
+				SecretId:                   createOut.ARN,
+			})
+			require.NoError(t, err)
+
+			_, err = c.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+				Name:         createOut.Name,
+				SecretString: aws.String("world"),
+			})
+			assert.NoError(t, err)
+		},
+		"RestoreSecretFailsWithForceDeletedSecret": func(ctx context.Context, t *testing.T, c *SecretsManagerClient) {
+			createOut := createSecret(ctx, t, c)
+			_, err := c.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+				ForceDeleteWithoutRecovery: aws.Bool(true),
+				SecretId:                   createOut.ARN,
+			})
+			require.NoError(t, err)
+
+			_, err = c.RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{
+				SecretId: createOut.ARN,
+			})
+			assert.True(t, utility.MatchesError[*types.InvalidRequestException](err))
+		},
+		"RestoreSecretIsNoopWithSecretNotScheduledForDeletion": func(ctx context.Context, t *testing.T, c *SecretsManagerClient) {
+			createOut := createSecret(ctx, t, c)
+
+			_, err := c.RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{
+				SecretId: createOut.ARN,
+			})
+			require.NoError(t, err)
+
+			getOut, err := c.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+				SecretId: createOut.ARN,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "hello", utility.FromStringPtr(getOut.SecretString))
+		},
+	}
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/evergreen-ci/cocoa"
 	"github.com/evergreen-ci/cocoa/internal/testcase"
 	"github.com/evergreen-ci/cocoa/internal/testutil"
@@ -98,6 +100,29 @@ func secretsManagerVaultTests() map[string]func(ctx context.Context, t *testing.
 			assert.Equal(t, sc.GetTag(), utility.FromStringPtr(c.TagResourceInput.Tags[0].Key))
 			assert.Equal(t, "true", utility.FromStringPtr(c.TagResourceInput.Tags[0].Value), "cache tag should be marked as cached")
 		},
+		"CreateSecretAppliesSecretTagsAlongWithCacheTag": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			ns := getValidNamedSecret(t)
+			ns.SetTags(map[string]string{"owner": "team"})
+			id, err := v.CreateSecret(ctx, ns)
+			require.NoError(t, err)
+			require.NotZero(t, id)
+
+			require.NotZero(t, c.CreateSecretInput, "should have created a secret")
+			tags := map[string]string{}
+			for _, tag := range c.CreateSecretInput.Tags {
+				tags[utility.FromStringPtr(tag.Key)] = utility.FromStringPtr(tag.Value)
+			}
+			assert.Equal(t, map[string]string{"owner": "team", sc.GetTag(): "false"}, tags)
+			assert.Equal(t, map[string]string{"owner": "team"}, map[string]string(ns.Tags), "secret's own tags should not be modified")
+		},
+		"CreateSecretFailsWithInvalidTags": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			ns := getValidNamedSecret(t)
+			ns.SetTags(map[string]string{"aws:reserved": "value"})
+			id, err := v.CreateSecret(ctx, ns)
+			assert.Error(t, err)
+			assert.Zero(t, id)
+			assert.Zero(t, c.CreateSecretInput, "should not have attempted to create the secret")
+		},
 		"CreateSecretTagsStrandedSecretAsUncachedWhenCachingFails": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
 			sc.PutError = errors.New("fake error")
 
@@ -127,6 +152,205 @@ func secretsManagerVaultTests() map[string]func(ctx context.Context, t *testing.
 			assert.NotZero(t, c.CreateSecretInput, "should have attempted to create a secret")
 			assert.Zero(t, sc.PutInput, "should not have attempted to cache the secret after secret creation failed")
 		},
+		"CreateSecretReturnsIDOfExistingSecretWithoutCaching": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			ns := getValidNamedSecret(t)
+			_, err := c.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+				Name:         ns.Name,
+				SecretString: utility.ToStringPtr("existing_value"),
+			})
+			require.NoError(t, err)
+
+			id, err := v.CreateSecret(ctx, ns)
+			require.NoError(t, err)
+			assert.Equal(t, utility.FromStringPtr(ns.Name), id)
+
+			assert.Zero(t, sc.PutInput, "should not have cached the already-existing secret")
+			assert.Zero(t, c.TagResourceInput, "should not have re-tagged the already-existing secret")
+		},
+		"CreateSecretFailsWithNameOfSecretScheduledForDeletion": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			ns := getValidNamedSecret(t)
+			_, err := c.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+				Name:         ns.Name,
+				SecretString: utility.ToStringPtr("old_value"),
+			})
+			require.NoError(t, err)
+			_, err = c.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{SecretId: ns.Name})
+			require.NoError(t, err)
+			c.DeleteSecretInput = nil
+
+			id, err := v.CreateSecret(ctx, ns)
+			assert.Error(t, err)
+			assert.Zero(t, id)
+
+			assert.Zero(t, c.DeleteSecretInput, "should not have force deleted the secret scheduled for deletion")
+			assert.Zero(t, sc.PutInput, "should not have cached the secret")
+		},
+		"CreateSecretRecreatesSecretScheduledForDeletionWhenEnabled": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			recreatingVault, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().
+				SetClient(c).
+				SetCache(sc).
+				SetRecreateIfScheduledForDeletion(true))
+			require.NoError(t, err)
+
+			ns := getValidNamedSecret(t)
+			_, err = c.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+				Name:         ns.Name,
+				SecretString: utility.ToStringPtr("old_value"),
+			})
+			require.NoError(t, err)
+			_, err = c.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{SecretId: ns.Name})
+			require.NoError(t, err)
+
+			id, err := recreatingVault.CreateSecret(ctx, ns)
+			require.NoError(t, err)
+			assert.Equal(t, utility.FromStringPtr(ns.Name), id)
+
+			require.NotZero(t, c.DeleteSecretInput, "should have force deleted the secret scheduled for deletion")
+			assert.Equal(t, id, utility.FromStringPtr(c.DeleteSecretInput.SecretId))
+			assert.True(t, utility.FromBoolPtr(c.DeleteSecretInput.ForceDeleteWithoutRecovery))
+
+			val, err := recreatingVault.GetValue(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, utility.FromStringPtr(ns.Value), val, "recreated secret should have the new value")
+
+			require.NotZero(t, sc.PutInput, "should have cached the recreated secret")
+			assert.Equal(t, id, sc.PutInput.ID)
+			require.NotZero(t, c.TagResourceInput, "should have re-tagged the recreated secret to indicate that it's cached")
+		},
+		"CreateSecretAndUpdateValueEncryptValueWithDataKeyProvider": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			p, err := secret.NewAEADDataKeyProvider(make([]byte, 32))
+			require.NoError(t, err)
+			encryptingVault, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().
+				SetClient(c).
+				SetDataKeyProvider(p))
+			require.NoError(t, err)
+
+			ns := getValidNamedSecret(t)
+			id, err := encryptingVault.CreateSecret(ctx, ns)
+			require.NoError(t, err)
+
+			require.NotZero(t, c.CreateSecretInput)
+			assert.NotEqual(t, utility.FromStringPtr(ns.Value), utility.FromStringPtr(c.CreateSecretInput.SecretString), "stored value should be encrypted")
+			stored, err := v.GetValue(ctx, id)
+			require.NoError(t, err)
+			assert.NotEqual(t, utility.FromStringPtr(ns.Value), stored, "vault without the data key provider should not decrypt the value")
+
+			val, err := encryptingVault.GetValue(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, utility.FromStringPtr(ns.Value), val)
+
+			require.NoError(t, encryptingVault.UpdateValue(ctx, *cocoa.NewNamedSecret().SetName(id).SetValue("new_value")))
+			require.NotZero(t, c.UpdateSecretInput)
+			assert.NotEqual(t, "new_value", utility.FromStringPtr(c.UpdateSecretInput.SecretString), "updated value should be encrypted")
+
+			val, err = encryptingVault.GetValue(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, "new_value", val)
+		},
+		"GetValueWithDataKeyProviderFailsWithValueEncryptedForDifferentSecret": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			p, err := secret.NewAEADDataKeyProvider(make([]byte, 32))
+			require.NoError(t, err)
+			encryptingVault, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().
+				SetClient(c).
+				SetDataKeyProvider(p))
+			require.NoError(t, err)
+
+			_, err = encryptingVault.CreateSecret(ctx, getValidNamedSecret(t))
+			require.NoError(t, err)
+			require.NotZero(t, c.CreateSecretInput)
+			encrypted := utility.FromStringPtr(c.CreateSecretInput.SecretString)
+
+			copied := getValidNamedSecret(t)
+			copied.SetValue(encrypted)
+			id, err := v.CreateSecret(ctx, copied)
+			require.NoError(t, err)
+
+			val, err := encryptingVault.GetValue(ctx, id)
+			assert.Error(t, err, "encrypted value should only be decryptable for the secret it was encrypted for")
+			assert.Zero(t, val)
+		},
+		"EncryptsValuesOnlyWithDataKeyProvider": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			assert.False(t, v.EncryptsValues())
+
+			p, err := secret.NewAEADDataKeyProvider(make([]byte, 32))
+			require.NoError(t, err)
+			encryptingVault, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().
+				SetClient(c).
+				SetDataKeyProvider(p))
+			require.NoError(t, err)
+			assert.True(t, encryptingVault.EncryptsValues())
+			assert.True(t, NewVault(encryptingVault).EncryptsValues())
+		},
+		"GetValueWithDataKeyProviderFailsWithUnencryptedValue": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			p, err := secret.NewAEADDataKeyProvider(make([]byte, 32))
+			require.NoError(t, err)
+			encryptingVault, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().
+				SetClient(c).
+				SetDataKeyProvider(p))
+			require.NoError(t, err)
+
+			id, err := v.CreateSecret(ctx, getValidNamedSecret(t))
+			require.NoError(t, err)
+
+			val, err := encryptingVault.GetValue(ctx, id)
+			assert.Error(t, err, "unencrypted value should be rejected")
+			assert.Zero(t, val)
+		},
+		"GetValueWithDataKeyProviderReturnsUnencryptedValueWhenAllowed": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			p, err := secret.NewAEADDataKeyProvider(make([]byte, 32))
+			require.NoError(t, err)
+			migratingVault, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().
+				SetClient(c).
+				SetDataKeyProvider(p).
+				SetAllowUnencryptedValues(true))
+			require.NoError(t, err)
+
+			ns := getValidNamedSecret(t)
+			id, err := v.CreateSecret(ctx, ns)
+			require.NoError(t, err)
+
+			val, err := migratingVault.GetValue(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, utility.FromStringPtr(ns.Value), val)
+
+			require.NoError(t, migratingVault.UpdateValue(ctx, *cocoa.NewNamedSecret().SetName(id).SetValue("new_value")))
+			val, err = migratingVault.GetValue(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, "new_value", val, "migrated value should be encrypted and decryptable")
+		},
+		"CreateSecretFailsWhenDataKeyProviderFails": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			encryptingVault, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().
+				SetClient(c).
+				SetDataKeyProvider(&failingDataKeyProvider{}))
+			require.NoError(t, err)
+
+			id, err := encryptingVault.CreateSecret(ctx, getValidNamedSecret(t))
+			assert.Error(t, err)
+			assert.Zero(t, id)
+			assert.Zero(t, c.CreateSecretInput, "should not have stored the secret")
+		},
+		"CreateSecretFailsWhenForceDeletingSecretScheduledForDeletionFails": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			recreatingVault, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().
+				SetClient(c).
+				SetCache(sc).
+				SetRecreateIfScheduledForDeletion(true))
+			require.NoError(t, err)
+
+			ns := getValidNamedSecret(t)
+			_, err = c.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+				Name:         ns.Name,
+				SecretString: utility.ToStringPtr("old_value"),
+			})
+			require.NoError(t, err)
+			_, err = c.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{SecretId: ns.Name})
+			require.NoError(t, err)
+			c.DeleteSecretError = errors.New("fake error")
+
+			id, err := recreatingVault.CreateSecret(ctx, ns)
+			assert.Error(t, err)
+			assert.Zero(t, id)
+			assert.Zero(t, sc.PutInput, "should not have cached the secret")
+		},
 		"DeleteSecretDeletesAndUncachesWithValidID": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
 			id, err := v.CreateSecret(ctx, getValidNamedSecret(t))
 			require.NoError(t, err)
@@ -158,6 +382,193 @@ func secretsManagerVaultTests() map[string]func(ctx context.Context, t *testing.
 			assert.NotZero(t, c.DeleteSecretInput, "should have attempted to delete the secret")
 			assert.Zero(t, sc.DeleteInput, "should not have attempted to delete  the cached secret")
 		},
+		"FindSecretIDReturnsIDOfExistingSecret": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			ns := getValidNamedSecret(t)
+			id, err := v.CreateSecret(ctx, ns)
+			require.NoError(t, err)
+
+			foundID, err := v.FindSecretID(ctx, utility.FromStringPtr(ns.Name))
+			require.NoError(t, err)
+			assert.Equal(t, id, foundID)
+
+			require.NotZero(t, c.DescribeSecretInput)
+			assert.Equal(t, utility.FromStringPtr(ns.Name), utility.FromStringPtr(c.DescribeSecretInput.SecretId))
+		},
+		"FindSecretIDReturnsEmptyIDForNonexistentSecret": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			id, err := v.FindSecretID(ctx, testutil.NewSecretName(t))
+			require.NoError(t, err)
+			assert.Zero(t, id)
+		},
+		"FindSecretIDFailsWhenDescribingSecretFails": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			c.DescribeSecretError = errors.New("fake error")
+
+			id, err := v.FindSecretID(ctx, testutil.NewSecretName(t))
+			assert.Error(t, err)
+			assert.Zero(t, id)
+		},
+		"DescribeSecretReturnsMetadataOfExistingSecret": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			ns := getValidNamedSecret(t)
+			id, err := v.CreateSecret(ctx, ns)
+			require.NoError(t, err)
+
+			md, err := v.DescribeSecret(ctx, id)
+			require.NoError(t, err)
+			require.NotZero(t, md)
+			assert.Equal(t, id, md.ID)
+			assert.Equal(t, utility.FromStringPtr(ns.Name), md.Name)
+			assert.NotZero(t, md.Created)
+			assert.False(t, md.RotationEnabled)
+			assert.Zero(t, md.RotationLambdaARN)
+			assert.Zero(t, md.RotationIntervalDays)
+			assert.Zero(t, md.LastRotated)
+		},
+		"DescribeSecretFailsWithNonexistentSecret": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			md, err := v.DescribeSecret(ctx, testutil.NewSecretName(t))
+			assert.Error(t, err)
+			assert.Zero(t, md)
+		},
+		"DescribeSecretFailsWithEmptyID": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			md, err := v.DescribeSecret(ctx, "")
+			assert.Error(t, err)
+			assert.Zero(t, md)
+			assert.Zero(t, c.DescribeSecretInput)
+		},
+		"EnableRotationConfiguresRotationAndRotatesImmediately": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			id, err := v.CreateSecret(ctx, getValidNamedSecret(t))
+			require.NoError(t, err)
+
+			opts := cocoa.NewSecretRotationOptions().
+				SetLambdaARN("lambda_arn").
+				SetIntervalDays(30)
+			require.NoError(t, v.EnableRotation(ctx, id, *opts))
+
+			require.NotZero(t, c.RotateSecretInput)
+			assert.Equal(t, id, utility.FromStringPtr(c.RotateSecretInput.SecretId))
+			assert.Equal(t, "lambda_arn", utility.FromStringPtr(c.RotateSecretInput.RotationLambdaARN))
+			require.NotZero(t, c.RotateSecretInput.RotationRules)
+			assert.EqualValues(t, 30, utility.FromInt64Ptr(c.RotateSecretInput.RotationRules.AutomaticallyAfterDays))
+
+			md, err := v.DescribeSecret(ctx, id)
+			require.NoError(t, err)
+			assert.True(t, md.RotationEnabled)
+			assert.Equal(t, "lambda_arn", md.RotationLambdaARN)
+			assert.Equal(t, 30, md.RotationIntervalDays)
+			assert.NotZero(t, md.LastRotated, "secret should have been rotated immediately")
+		},
+		"EnableRotationWithoutRotatingImmediatelyDoesNotRotate": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			id, err := v.CreateSecret(ctx, getValidNamedSecret(t))
+			require.NoError(t, err)
+
+			opts := cocoa.NewSecretRotationOptions().
+				SetLambdaARN("lambda_arn").
+				SetIntervalDays(30).
+				SetRotateImmediately(false)
+			require.NoError(t, v.EnableRotation(ctx, id, *opts))
+
+			md, err := v.DescribeSecret(ctx, id)
+			require.NoError(t, err)
+			assert.True(t, md.RotationEnabled)
+			assert.Zero(t, md.LastRotated)
+		},
+		"EnableRotationFailsWithInvalidOptions": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			id, err := v.CreateSecret(ctx, getValidNamedSecret(t))
+			require.NoError(t, err)
+
+			assert.Error(t, v.EnableRotation(ctx, id, *cocoa.NewSecretRotationOptions().SetIntervalDays(30)))
+			assert.Zero(t, c.RotateSecretInput)
+		},
+		"EnableRotationFailsWithNonexistentSecret": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			opts := cocoa.NewSecretRotationOptions().
+				SetLambdaARN("lambda_arn").
+				SetIntervalDays(30)
+			assert.Error(t, v.EnableRotation(ctx, testutil.NewSecretName(t), *opts))
+		},
+		"DisableRotationStopsRotation": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			id, err := v.CreateSecret(ctx, getValidNamedSecret(t))
+			require.NoError(t, err)
+
+			opts := cocoa.NewSecretRotationOptions().
+				SetLambdaARN("lambda_arn").
+				SetIntervalDays(30)
+			require.NoError(t, v.EnableRotation(ctx, id, *opts))
+			require.NoError(t, v.DisableRotation(ctx, id))
+
+			require.NotZero(t, c.CancelRotateSecretInput)
+			assert.Equal(t, id, utility.FromStringPtr(c.CancelRotateSecretInput.SecretId))
+
+			md, err := v.DescribeSecret(ctx, id)
+			require.NoError(t, err)
+			assert.False(t, md.RotationEnabled)
+			assert.Equal(t, "lambda_arn", md.RotationLambdaARN, "rotation Lambda should be kept after disabling rotation")
+			assert.NotZero(t, md.LastRotated)
+		},
+		"DisableRotationFailsWhenCancelingRotationFails": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			c.CancelRotateSecretError = errors.New("fake error")
+
+			id, err := v.CreateSecret(ctx, getValidNamedSecret(t))
+			require.NoError(t, err)
+
+			assert.Error(t, v.DisableRotation(ctx, id))
+		},
+		"ListSecretsAppliesDefaultListFilters": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			managedFilter := types.Filter{Key: types.FilterNameStringTypeTagKey, Values: []string{"cocoa-managed"}}
+			scopedVault, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().
+				SetClient(c).
+				AddDefaultListFilters(managedFilter))
+			require.NoError(t, err)
+
+			managedName := testutil.NewSecretName(t)
+			_, err = c.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+				Name:         utility.ToStringPtr(managedName),
+				SecretString: utility.ToStringPtr("value"),
+				Tags:         secret.ExportTags(map[string]string{"cocoa-managed": "true"}),
+			})
+			require.NoError(t, err)
+			_, err = c.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+				Name:         utility.ToStringPtr(testutil.NewSecretName(t) + "-foreign"),
+				SecretString: utility.ToStringPtr("value"),
+			})
+			require.NoError(t, err)
+
+			secrets, err := scopedVault.ListSecrets(ctx)
+			require.NoError(t, err)
+			require.Len(t, secrets, 1, "should only list secrets matching the default filters")
+			assert.Equal(t, managedName, utility.FromStringPtr(secrets[0].Name))
+
+			nameFilter := types.Filter{Key: types.FilterNameStringTypeName, Values: []string{"nonexistent"}}
+			secrets, err = scopedVault.ListSecrets(ctx, nameFilter)
+			require.NoError(t, err)
+			assert.Empty(t, secrets)
+			require.NotZero(t, c.ListSecretsInput)
+			assert.Equal(t, []types.Filter{managedFilter, nameFilter}, c.ListSecretsInput.Filters, "should apply both default and given filters")
+		},
+		"ListSecretsReturnsAllPages": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			pagingClient := &pagingSecretsManagerClient{
+				SecretsManagerClient: c,
+				pages: []secretsmanager.ListSecretsOutput{
+					{SecretList: []types.SecretListEntry{{Name: utility.ToStringPtr("secret0")}}, NextToken: utility.ToStringPtr("token")},
+					{SecretList: []types.SecretListEntry{{Name: utility.ToStringPtr("secret1")}}},
+				},
+			}
+			pagingVault, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(pagingClient))
+			require.NoError(t, err)
+
+			secrets, err := pagingVault.ListSecrets(ctx)
+			require.NoError(t, err)
+			require.Len(t, secrets, 2)
+			assert.Equal(t, "secret0", utility.FromStringPtr(secrets[0].Name))
+			assert.Equal(t, "secret1", utility.FromStringPtr(secrets[1].Name))
+			assert.Equal(t, []string{"", "token"}, pagingClient.tokens)
+		},
+		"ListSecretsFailsWhenListingFails": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
+			c.ListSecretsError = errors.New("fake error")
+
+			bsm, ok := v.Vault.(*secret.BasicSecretsManager)
+			require.True(t, ok)
+			secrets, err := bsm.ListSecrets(ctx)
+			assert.Error(t, err)
+			assert.Zero(t, secrets)
+		},
 		"DeleteSecretIsIdempotent": func(ctx context.Context, t *testing.T, v *Vault, sc *SecretCache, c *SecretsManagerClient) {
 			id, err := v.CreateSecret(ctx, getValidNamedSecret(t))
 			require.NoError(t, err)
@@ -172,3 +583,29 @@ func secretsManagerVaultTests() map[string]func(ctx context.Context, t *testing.
 		},
 	}
 }
+
+// pagingSecretsManagerClient is a Secrets Manager client that returns the
+// given pages of secrets when listing secrets and records the pagination token
+// from each request.
+type pagingSecretsManagerClient struct {
+	*SecretsManagerClient
+	pages  []secretsmanager.ListSecretsOutput
+	tokens []string
+}
+
+func (c *pagingSecretsManagerClient) ListSecrets(ctx context.Context, in *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error) {
+	c.tokens = append(c.tokens, utility.FromStringPtr(in.NextToken))
+	page := c.pages[len(c.tokens)-1]
+	return &page, nil
+}
+
+// failingDataKeyProvider is a secret.DataKeyProvider that always fails.
+type failingDataKeyProvider struct{}
+
+func (p *failingDataKeyProvider) GenerateDataKey(ctx context.Context) ([]byte, []byte, error) {
+	return nil, nil, errors.New("fake error")
+}
+
+func (p *failingDataKeyProvider) DecryptDataKey(ctx context.Context, encrypted []byte) ([]byte, error) {
+	return nil, errors.New("fake error")
+}
@@ -0,0 +1,494 @@
+package mock
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	secretsManagerTypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/evergreen-ci/utility"
+)
+
+// Snapshot returns a deep copy of the entire fake ECS service state. The
+// snapshot is unaffected by any later modifications to the ECS service, so it
+// can be inspected in test assertions while mock clients continue to modify the
+// ECS service.
+func (s *ECSService) Snapshot() *ECSService {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := &ECSService{
+		Clusters:           make(map[string]ECSCluster, len(s.Clusters)),
+		TaskDefs:           make(map[string][]ECSTaskDefinition, len(s.TaskDefs)),
+		ClusterCapacities:  make(map[string]ECSClusterCapacity, len(s.ClusterCapacities)),
+		ContainerInstances: make(map[string][]ECSContainerInstance, len(s.ContainerInstances)),
+		Services:           make(map[string][]ECSClusterService, len(s.Services)),
+	}
+	for name, cluster := range s.Clusters {
+		snapshot.Clusters[name] = cluster.deepCopy()
+	}
+	for family, revisions := range s.TaskDefs {
+		snapshot.TaskDefs[family] = snapshotTaskDefinitions(revisions)
+	}
+	for name, capacity := range s.ClusterCapacities {
+		snapshot.ClusterCapacities[name] = ECSClusterCapacity{
+			CPU:      copyPtr(capacity.CPU),
+			MemoryMB: copyPtr(capacity.MemoryMB),
+		}
+	}
+	for name, instances := range s.ContainerInstances {
+		snapshot.ContainerInstances[name] = copySlice(instances)
+	}
+	for name, services := range s.Services {
+		copied := make([]ECSClusterService, 0, len(services))
+		for _, svc := range services {
+			svc.Tags = copyMap(svc.Tags)
+			copied = append(copied, svc)
+		}
+		snapshot.Services[name] = copied
+	}
+	return snapshot
+}
+
+// SnapshotCluster returns a deep copy of the tasks in the cluster with the
+// given name. It returns false if the cluster does not exist.
+func (s *ECSService) SnapshotCluster(name string) (ECSCluster, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cluster, ok := s.Clusters[name]
+	if !ok {
+		return nil, false
+	}
+	return cluster.deepCopy(), true
+}
+
+// SnapshotTaskDefinitions returns a deep copy of all the revisions of the task
+// definition family, ordered by revision.
+func (s *ECSService) SnapshotTaskDefinitions(family string) []ECSTaskDefinition {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return snapshotTaskDefinitions(s.TaskDefs[family])
+}
+
+func snapshotTaskDefinitions(revisions []ECSTaskDefinition) []ECSTaskDefinition {
+	if revisions == nil {
+		return nil
+	}
+	copied := make([]ECSTaskDefinition, 0, len(revisions))
+	for _, def := range revisions {
+		copied = append(copied, def.deepCopy())
+	}
+	return copied
+}
+
+// SetCluster seeds the cluster with the given name with copies of the tasks,
+// replacing any tasks already in the cluster. Tasks without an ARN are given a
+// unique one.
+func (s *ECSService) SetCluster(name string, tasks ...ECSTask) {
+	cluster := make(ECSCluster, len(tasks))
+	for _, task := range tasks {
+		task = task.deepCopy()
+		if task.ARN == "" {
+			task.ARN = newECSTaskARN(name, utility.RandomString())
+		}
+		if task.Cluster == nil {
+			task.Cluster = utility.ToStringPtr(name)
+		}
+		cluster[task.ARN] = task
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Clusters[name] = cluster
+}
+
+// SetTaskDefinitions seeds the task definition family with copies of the task
+// definitions, replacing any revisions already registered in the family. The
+// task definitions are assigned revisions in the given order, starting from
+// 1. Task definitions without an ARN or status are given a default ARN and are
+// active.
+func (s *ECSService) SetTaskDefinitions(family string, defs ...ECSTaskDefinition) {
+	revisions := make([]ECSTaskDefinition, 0, len(defs))
+	for i, def := range defs {
+		def = def.deepCopy()
+		rev := i + 1
+		def.Family = utility.ToStringPtr(family)
+		def.Revision = utility.ToInt64Ptr(int64(rev))
+		if def.ARN == "" {
+			def.ARN = newECSTaskDefinitionARN(family, rev)
+		}
+		if def.Status == nil {
+			def.Status = utility.ToStringPtr(string(types.TaskDefinitionStatusActive))
+		}
+		revisions = append(revisions, def)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.TaskDefs[family] = revisions
+}
+
+// Snapshot returns a deep copy of the stored secrets. The snapshot is
+// unaffected by any later modifications to the stored secrets.
+func (c StoredSecrets) Snapshot() StoredSecrets {
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	snapshot := make(StoredSecrets, len(c))
+	for id, s := range c {
+		snapshot[id] = s.deepCopy()
+	}
+	return snapshot
+}
+
+// Set seeds the stored secrets with copies of the given secrets, keyed by
+// their names. Any existing secret with the same name is replaced.
+func (c StoredSecrets) Set(secrets ...StoredSecret) {
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
+	for _, s := range secrets {
+		c[s.Name] = s.deepCopy()
+	}
+}
+
+func (c ECSCluster) deepCopy() ECSCluster {
+	if c == nil {
+		return nil
+	}
+	copied := make(ECSCluster, len(c))
+	for id, task := range c {
+		copied[id] = task.deepCopy()
+	}
+	return copied
+}
+
+func (t ECSTask) deepCopy() ECSTask {
+	t.TaskDef = t.TaskDef.deepCopy()
+	t.Cluster = copyPtr(t.Cluster)
+	t.CapacityProvider = copyPtr(t.CapacityProvider)
+	t.ContainerInstance = copyPtr(t.ContainerInstance)
+	if t.Containers != nil {
+		containers := make([]ECSContainer, 0, len(t.Containers))
+		for _, c := range t.Containers {
+			containers = append(containers, c.deepCopy())
+		}
+		t.Containers = containers
+	}
+	t.Overrides = copyTaskOverride(t.Overrides)
+	t.CPU = copyPtr(t.CPU)
+	t.MemoryMB = copyPtr(t.MemoryMB)
+	t.Group = copyPtr(t.Group)
+	t.Created = copyPtr(t.Created)
+	t.PullStarted = copyPtr(t.PullStarted)
+	t.PullStopped = copyPtr(t.PullStopped)
+	t.Started = copyPtr(t.Started)
+	t.Stopping = copyPtr(t.Stopping)
+	t.ExecutionStopped = copyPtr(t.ExecutionStopped)
+	t.StopReason = copyPtr(t.StopReason)
+	t.Stopped = copyPtr(t.Stopped)
+	t.Tags = copyMap(t.Tags)
+	return t
+}
+
+func (c ECSContainer) deepCopy() ECSContainer {
+	c.TaskARN = copyPtr(c.TaskARN)
+	c.Name = copyPtr(c.Name)
+	c.Image = copyPtr(c.Image)
+	c.Command = copySlice(c.Command)
+	c.EnvVars = copyMap(c.EnvVars)
+	c.CPU = copyPtr(c.CPU)
+	c.MemoryMB = copyPtr(c.MemoryMB)
+	c.ExitCode = copyPtr(c.ExitCode)
+	c.Reason = copyPtr(c.Reason)
+	return c
+}
+
+func (d ECSTaskDefinition) deepCopy() ECSTaskDefinition {
+	d.Family = copyPtr(d.Family)
+	d.Revision = copyPtr(d.Revision)
+	if d.ContainerDefs != nil {
+		containerDefs := make([]ECSContainerDefinition, 0, len(d.ContainerDefs))
+		for _, def := range d.ContainerDefs {
+			containerDefs = append(containerDefs, def.deepCopy())
+		}
+		d.ContainerDefs = containerDefs
+	}
+	d.MemoryMB = copyPtr(d.MemoryMB)
+	d.CPU = copyPtr(d.CPU)
+	d.TaskRole = copyPtr(d.TaskRole)
+	d.ExecutionRole = copyPtr(d.ExecutionRole)
+	d.Volumes = copySliceFunc(d.Volumes, copyVolume)
+	d.Tags = copyMap(d.Tags)
+	d.Status = copyPtr(d.Status)
+	d.Registered = copyPtr(d.Registered)
+	d.Deregistered = copyPtr(d.Deregistered)
+	return d
+}
+
+func (d ECSContainerDefinition) deepCopy() ECSContainerDefinition {
+	d.Name = copyPtr(d.Name)
+	d.Image = copyPtr(d.Image)
+	d.Command = copySlice(d.Command)
+	d.WorkingDir = copyPtr(d.WorkingDir)
+	d.MemoryMB = copyPtr(d.MemoryMB)
+	d.EnvVars = copyMap(d.EnvVars)
+	d.Secrets = copyMap(d.Secrets)
+	d.LogConfig = copyLogConfiguration(d.LogConfig)
+	d.RepoCreds = copyRepositoryCredentials(d.RepoCreds)
+	d.PortMappings = copySliceFunc(d.PortMappings, copyPortMapping)
+	d.MountPoints = copySliceFunc(d.MountPoints, copyMountPoint)
+	d.Interactive = copyPtr(d.Interactive)
+	d.PseudoTerminal = copyPtr(d.PseudoTerminal)
+	d.Hostname = copyPtr(d.Hostname)
+	d.User = copyPtr(d.User)
+	d.ExtraHosts = copySliceFunc(d.ExtraHosts, copyHostEntry)
+	d.VolumesFrom = copySliceFunc(d.VolumesFrom, copyVolumeFrom)
+	d.LinuxParams = copyLinuxParameters(d.LinuxParams)
+	return d
+}
+
+func (s StoredSecret) deepCopy() StoredSecret {
+	s.BinaryValue = copySlice(s.BinaryValue)
+	s.Tags = copyMap(s.Tags)
+	s.RotationRules = copyRotationRules(s.RotationRules)
+	return s
+}
+
+// copyLogConfiguration returns a deep copy of the log configuration, or nil if
+// it is nil.
+func copyLogConfiguration(c *types.LogConfiguration) *types.LogConfiguration {
+	if c == nil {
+		return nil
+	}
+	copied := *c
+	copied.Options = copyMap(c.Options)
+	copied.SecretOptions = copySliceFunc(c.SecretOptions, copySecret)
+	return &copied
+}
+
+// copySecret returns a deep copy of the container secret.
+func copySecret(s types.Secret) types.Secret {
+	s.Name = copyPtr(s.Name)
+	s.ValueFrom = copyPtr(s.ValueFrom)
+	return s
+}
+
+// copyRepositoryCredentials returns a deep copy of the repository credentials,
+// or nil if they are nil.
+func copyRepositoryCredentials(c *types.RepositoryCredentials) *types.RepositoryCredentials {
+	if c == nil {
+		return nil
+	}
+	copied := *c
+	copied.CredentialsParameter = copyPtr(c.CredentialsParameter)
+	return &copied
+}
+
+// copyPortMapping returns a deep copy of the port mapping.
+func copyPortMapping(pm types.PortMapping) types.PortMapping {
+	pm.ContainerPort = copyPtr(pm.ContainerPort)
+	pm.ContainerPortRange = copyPtr(pm.ContainerPortRange)
+	pm.HostPort = copyPtr(pm.HostPort)
+	pm.Name = copyPtr(pm.Name)
+	return pm
+}
+
+// copyMountPoint returns a deep copy of the mount point.
+func copyMountPoint(mp types.MountPoint) types.MountPoint {
+	mp.ContainerPath = copyPtr(mp.ContainerPath)
+	mp.ReadOnly = copyPtr(mp.ReadOnly)
+	mp.SourceVolume = copyPtr(mp.SourceVolume)
+	return mp
+}
+
+// copyHostEntry returns a deep copy of the host entry.
+func copyHostEntry(h types.HostEntry) types.HostEntry {
+	h.Hostname = copyPtr(h.Hostname)
+	h.IpAddress = copyPtr(h.IpAddress)
+	return h
+}
+
+// copyVolumeFrom returns a deep copy of the volume from another container.
+func copyVolumeFrom(v types.VolumeFrom) types.VolumeFrom {
+	v.ReadOnly = copyPtr(v.ReadOnly)
+	v.SourceContainer = copyPtr(v.SourceContainer)
+	return v
+}
+
+// copyLinuxParameters returns a deep copy of the Linux parameters, or nil if
+// they are nil.
+func copyLinuxParameters(p *types.LinuxParameters) *types.LinuxParameters {
+	if p == nil {
+		return nil
+	}
+	copied := *p
+	if p.Capabilities != nil {
+		capabilities := *p.Capabilities
+		capabilities.Add = copySlice(p.Capabilities.Add)
+		capabilities.Drop = copySlice(p.Capabilities.Drop)
+		copied.Capabilities = &capabilities
+	}
+	copied.Devices = copySliceFunc(p.Devices, func(d types.Device) types.Device {
+		d.HostPath = copyPtr(d.HostPath)
+		d.ContainerPath = copyPtr(d.ContainerPath)
+		d.Permissions = copySlice(d.Permissions)
+		return d
+	})
+	copied.InitProcessEnabled = copyPtr(p.InitProcessEnabled)
+	copied.MaxSwap = copyPtr(p.MaxSwap)
+	copied.SharedMemorySize = copyPtr(p.SharedMemorySize)
+	copied.Swappiness = copyPtr(p.Swappiness)
+	copied.Tmpfs = copySliceFunc(p.Tmpfs, func(t types.Tmpfs) types.Tmpfs {
+		t.ContainerPath = copyPtr(t.ContainerPath)
+		t.MountOptions = copySlice(t.MountOptions)
+		return t
+	})
+	return &copied
+}
+
+// copyVolume returns a deep copy of the task definition volume.
+func copyVolume(v types.Volume) types.Volume {
+	if v.DockerVolumeConfiguration != nil {
+		docker := *v.DockerVolumeConfiguration
+		docker.Autoprovision = copyPtr(docker.Autoprovision)
+		docker.Driver = copyPtr(docker.Driver)
+		docker.DriverOpts = copyMap(docker.DriverOpts)
+		docker.Labels = copyMap(docker.Labels)
+		v.DockerVolumeConfiguration = &docker
+	}
+	if v.EfsVolumeConfiguration != nil {
+		efs := *v.EfsVolumeConfiguration
+		efs.FileSystemId = copyPtr(efs.FileSystemId)
+		if efs.AuthorizationConfig != nil {
+			auth := *efs.AuthorizationConfig
+			auth.AccessPointId = copyPtr(auth.AccessPointId)
+			efs.AuthorizationConfig = &auth
+		}
+		efs.RootDirectory = copyPtr(efs.RootDirectory)
+		efs.TransitEncryptionPort = copyPtr(efs.TransitEncryptionPort)
+		v.EfsVolumeConfiguration = &efs
+	}
+	if v.FsxWindowsFileServerVolumeConfiguration != nil {
+		fsx := *v.FsxWindowsFileServerVolumeConfiguration
+		if fsx.AuthorizationConfig != nil {
+			auth := *fsx.AuthorizationConfig
+			auth.CredentialsParameter = copyPtr(auth.CredentialsParameter)
+			auth.Domain = copyPtr(auth.Domain)
+			fsx.AuthorizationConfig = &auth
+		}
+		fsx.FileSystemId = copyPtr(fsx.FileSystemId)
+		fsx.RootDirectory = copyPtr(fsx.RootDirectory)
+		v.FsxWindowsFileServerVolumeConfiguration = &fsx
+	}
+	if v.Host != nil {
+		host := *v.Host
+		host.SourcePath = copyPtr(host.SourcePath)
+		v.Host = &host
+	}
+	v.Name = copyPtr(v.Name)
+	return v
+}
+
+// copyTaskOverride returns a deep copy of the task override, or nil if it is
+// nil.
+func copyTaskOverride(o *types.TaskOverride) *types.TaskOverride {
+	if o == nil {
+		return nil
+	}
+	copied := *o
+	copied.ContainerOverrides = copySliceFunc(o.ContainerOverrides, copyContainerOverride)
+	copied.Cpu = copyPtr(o.Cpu)
+	copied.EphemeralStorage = copyPtr(o.EphemeralStorage)
+	copied.ExecutionRoleArn = copyPtr(o.ExecutionRoleArn)
+	copied.InferenceAcceleratorOverrides = copySliceFunc(o.InferenceAcceleratorOverrides, func(ia types.InferenceAcceleratorOverride) types.InferenceAcceleratorOverride {
+		ia.DeviceName = copyPtr(ia.DeviceName)
+		ia.DeviceType = copyPtr(ia.DeviceType)
+		return ia
+	})
+	copied.Memory = copyPtr(o.Memory)
+	copied.TaskRoleArn = copyPtr(o.TaskRoleArn)
+	return &copied
+}
+
+// copyContainerOverride returns a deep copy of the container override.
+func copyContainerOverride(o types.ContainerOverride) types.ContainerOverride {
+	o.Command = copySlice(o.Command)
+	o.Cpu = copyPtr(o.Cpu)
+	o.Environment = copySliceFunc(o.Environment, func(kv types.KeyValuePair) types.KeyValuePair {
+		kv.Name = copyPtr(kv.Name)
+		kv.Value = copyPtr(kv.Value)
+		return kv
+	})
+	o.EnvironmentFiles = copySliceFunc(o.EnvironmentFiles, func(f types.EnvironmentFile) types.EnvironmentFile {
+		f.Value = copyPtr(f.Value)
+		return f
+	})
+	o.Memory = copyPtr(o.Memory)
+	o.MemoryReservation = copyPtr(o.MemoryReservation)
+	o.Name = copyPtr(o.Name)
+	o.ResourceRequirements = copySliceFunc(o.ResourceRequirements, func(r types.ResourceRequirement) types.ResourceRequirement {
+		r.Value = copyPtr(r.Value)
+		return r
+	})
+	return o
+}
+
+// copyRotationRules returns a deep copy of the secret rotation rules, or nil if
+// they are nil.
+func copyRotationRules(r *secretsManagerTypes.RotationRulesType) *secretsManagerTypes.RotationRulesType {
+	if r == nil {
+		return nil
+	}
+	copied := *r
+	copied.AutomaticallyAfterDays = copyPtr(r.AutomaticallyAfterDays)
+	copied.Duration = copyPtr(r.Duration)
+	copied.ScheduleExpression = copyPtr(r.ScheduleExpression)
+	return &copied
+}
+
+// copyPtr returns a pointer to a shallow copy of the value, or nil if the
+// pointer is nil.
+func copyPtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	copied := *p
+	return &copied
+}
+
+// copySlice returns a shallow copy of the slice, preserving whether or not it
+// is nil.
+func copySlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+// copySliceFunc returns a copy of the slice in which each element is copied
+// with the given function, preserving whether or not it is nil.
+func copySliceFunc[T any](s []T, copyElem func(T) T) []T {
+	if s == nil {
+		return nil
+	}
+	copied := make([]T, 0, len(s))
+	for _, elem := range s {
+		copied = append(copied, copyElem(elem))
+	}
+	return copied
+}
+
+// copyMap returns a shallow copy of the map, preserving whether or not it is
+// nil.
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	copied := make(map[K]V, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,427 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsECS "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/evergreen-ci/cocoa/internal/testcase"
+	"github.com/evergreen-ci/cocoa/internal/testutil"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestECSServiceSnapshot(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer resetECSAndSecretsManagerCache()
+
+	for tName, tCase := range map[string]func(ctx context.Context, t *testing.T, c *ECSClient){
+		"SnapshotClusterIsUnaffectedByLaterChanges": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+			runOut, err := c.RunTask(ctx, &awsECS.RunTaskInput{
+				Cluster:        aws.String(testutil.ECSClusterName()),
+				TaskDefinition: registerOut.TaskDefinition.TaskDefinitionArn,
+			})
+			require.NoError(t, err)
+			require.Len(t, runOut.Tasks, 1)
+			taskARN := utility.FromStringPtr(runOut.Tasks[0].TaskArn)
+
+			snapshot, ok := GlobalECSService.SnapshotCluster(testutil.ECSClusterName())
+			require.True(t, ok)
+			require.Contains(t, snapshot, taskARN)
+			status := snapshot[taskARN].Status
+
+			_, err = c.StopTask(ctx, &awsECS.StopTaskInput{
+				Cluster: aws.String(testutil.ECSClusterName()),
+				Task:    aws.String(taskARN),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, status, snapshot[taskARN].Status)
+
+			task := snapshot[taskARN]
+			task.Tags = map[string]string{"key": "value"}
+			*task.Group = "modified"
+			snapshot[taskARN] = task
+			assert.NotEqual(t, "modified", utility.FromStringPtr(GlobalECSService.Clusters[testutil.ECSClusterName()][taskARN].Group))
+		},
+		"SnapshotClusterDoesNotShareNestedStateWithLiveState": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			GlobalECSService.SetCluster("cluster", ECSTask{
+				ARN: "task_arn",
+				TaskDef: ECSTaskDefinition{
+					ContainerDefs: []ECSContainerDefinition{{
+						Name: aws.String("container"),
+						LogConfig: &types.LogConfiguration{
+							Options:       map[string]string{"key": "value"},
+							SecretOptions: []types.Secret{{Name: aws.String("secret"), ValueFrom: aws.String("secret_id")}},
+						},
+						RepoCreds: &types.RepositoryCredentials{CredentialsParameter: aws.String("creds")},
+						LinuxParams: &types.LinuxParameters{
+							Tmpfs: []types.Tmpfs{{ContainerPath: aws.String("/tmp"), Size: 64, MountOptions: []string{"noexec"}}},
+						},
+					}},
+					Volumes: []types.Volume{{
+						Name: aws.String("volume"),
+						Host: &types.HostVolumeProperties{SourcePath: aws.String("/scratch")},
+					}},
+				},
+				Overrides: &types.TaskOverride{
+					ContainerOverrides: []types.ContainerOverride{{
+						Name:    aws.String("container"),
+						Command: []string{"echo"},
+					}},
+				},
+			})
+
+			snapshot, ok := GlobalECSService.SnapshotCluster("cluster")
+			require.True(t, ok)
+			require.Contains(t, snapshot, "task_arn")
+
+			task := snapshot["task_arn"]
+			containerDef := task.TaskDef.ContainerDefs[0]
+			containerDef.LogConfig.Options["key"] = "modified"
+			*containerDef.LogConfig.SecretOptions[0].ValueFrom = "modified"
+			*containerDef.RepoCreds.CredentialsParameter = "modified"
+			containerDef.LinuxParams.Tmpfs[0].MountOptions[0] = "modified"
+			*task.TaskDef.Volumes[0].Host.SourcePath = "modified"
+			task.Overrides.ContainerOverrides[0].Command[0] = "modified"
+
+			live := GlobalECSService.Clusters["cluster"]["task_arn"]
+			liveContainerDef := live.TaskDef.ContainerDefs[0]
+			assert.Equal(t, "value", liveContainerDef.LogConfig.Options["key"])
+			assert.Equal(t, "secret_id", utility.FromStringPtr(liveContainerDef.LogConfig.SecretOptions[0].ValueFrom))
+			assert.Equal(t, "creds", utility.FromStringPtr(liveContainerDef.RepoCreds.CredentialsParameter))
+			assert.Equal(t, []string{"noexec"}, liveContainerDef.LinuxParams.Tmpfs[0].MountOptions)
+			assert.Equal(t, "/scratch", utility.FromStringPtr(live.TaskDef.Volumes[0].Host.SourcePath))
+			assert.Equal(t, []string{"echo"}, live.Overrides.ContainerOverrides[0].Command)
+		},
+		"SnapshotClusterFailsWithNonexistentCluster": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			_, ok := GlobalECSService.SnapshotCluster("nonexistent")
+			assert.False(t, ok)
+		},
+		"SnapshotTaskDefinitionsIsUnaffectedByLaterChanges": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			in := testutil.ValidRegisterTaskDefinitionInput(t)
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, in)
+
+			snapshot := GlobalECSService.SnapshotTaskDefinitions(utility.FromStringPtr(in.Family))
+			require.Len(t, snapshot, 1)
+
+			_, err := c.DeregisterTaskDefinition(ctx, &awsECS.DeregisterTaskDefinitionInput{
+				TaskDefinition: registerOut.TaskDefinition.TaskDefinitionArn,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "ACTIVE", utility.FromStringPtr(snapshot[0].Status))
+
+			*snapshot[0].ContainerDefs[0].Image = "modified"
+			assert.NotEqual(t, "modified", utility.FromStringPtr(GlobalECSService.TaskDefs[utility.FromStringPtr(in.Family)][0].ContainerDefs[0].Image))
+		},
+		"SnapshotCopiesAllState": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			in := testutil.ValidRegisterTaskDefinitionInput(t)
+			testutil.RegisterTaskDefinition(ctx, t, c, in)
+			GlobalECSService.ClusterCapacities[testutil.ECSClusterName()] = ECSClusterCapacity{CPU: utility.ToIntPtr(1024)}
+
+			snapshot := GlobalECSService.Snapshot()
+			assert.Equal(t, &GlobalECSService, snapshot)
+
+			ResetGlobalECSService()
+			assert.Len(t, snapshot.TaskDefs[utility.FromStringPtr(in.Family)], 1)
+			assert.Equal(t, 1024, utility.FromIntPtr(snapshot.ClusterCapacities[testutil.ECSClusterName()].CPU))
+		},
+		"SnapshotIsSafeWhileClientsRunTasks": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			registerOut := testutil.RegisterTaskDefinition(ctx, t, c, testutil.ValidRegisterTaskDefinitionInput(t))
+
+			const numTasks = 20
+			var wg sync.WaitGroup
+			errs := make(chan error, numTasks)
+			for i := 0; i < numTasks; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					// Each goroutine uses its own client since the client
+					// records its inputs, but they all share the global ECS
+					// service.
+					_, err := (&ECSClient{}).RunTask(ctx, &awsECS.RunTaskInput{
+						Cluster:        aws.String(testutil.ECSClusterName()),
+						TaskDefinition: registerOut.TaskDefinition.TaskDefinitionArn,
+					})
+					errs <- err
+				}()
+			}
+
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+			for snapshotting := true; snapshotting; {
+				select {
+				case <-done:
+					snapshotting = false
+				default:
+				}
+				_ = GlobalECSService.Snapshot()
+				_, _ = GlobalECSService.SnapshotCluster(testutil.ECSClusterName())
+			}
+
+			close(errs)
+			for err := range errs {
+				require.NoError(t, err)
+			}
+			cluster, ok := GlobalECSService.SnapshotCluster(testutil.ECSClusterName())
+			require.True(t, ok)
+			assert.Len(t, cluster, numTasks)
+		},
+		"SetClusterSeedsTasks": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			task := ECSTask{
+				ARN:    "task_arn",
+				Status: "RUNNING",
+				Tags:   map[string]string{"key": "value"},
+			}
+			GlobalECSService.SetCluster("cluster", task, ECSTask{Status: "PENDING"})
+
+			cluster, ok := GlobalECSService.Clusters["cluster"]
+			require.True(t, ok)
+			require.Len(t, cluster, 2)
+			seeded, ok := cluster["task_arn"]
+			require.True(t, ok)
+			assert.Equal(t, "cluster", utility.FromStringPtr(seeded.Cluster))
+			for arn, task := range cluster {
+				assert.NotEmpty(t, arn)
+				assert.Equal(t, arn, task.ARN)
+			}
+
+			task.Tags["key"] = "modified"
+			assert.Equal(t, "value", cluster["task_arn"].Tags["key"], "seeded task should be a copy")
+
+			out, err := c.DescribeTasks(ctx, &awsECS.DescribeTasksInput{
+				Cluster: aws.String("cluster"),
+				Tasks:   []string{"task_arn"},
+			})
+			require.NoError(t, err)
+			require.Len(t, out.Tasks, 1)
+			assert.Equal(t, "task_arn", utility.FromStringPtr(out.Tasks[0].TaskArn))
+		},
+		"SetTaskDefinitionsSeedsRevisions": func(ctx context.Context, t *testing.T, c *ECSClient) {
+			GlobalECSService.SetTaskDefinitions("family",
+				ECSTaskDefinition{ContainerDefs: []ECSContainerDefinition{{Name: aws.String("container0")}}},
+				ECSTaskDefinition{ContainerDefs: []ECSContainerDefinition{{Name: aws.String("container1")}}},
+			)
+
+			out, err := c.DescribeTaskDefinition(ctx, &awsECS.DescribeTaskDefinitionInput{
+				TaskDefinition: aws.String("family"),
+			})
+			require.NoError(t, err)
+			require.NotZero(t, out.TaskDefinition)
+			assert.EqualValues(t, 2, out.TaskDefinition.Revision)
+			assert.Equal(t, "family", utility.FromStringPtr(out.TaskDefinition.Family))
+			assert.NotEmpty(t, utility.FromStringPtr(out.TaskDefinition.TaskDefinitionArn))
+			assert.EqualValues(t, "ACTIVE", out.TaskDefinition.Status)
+			require.Len(t, out.TaskDefinition.ContainerDefinitions, 1)
+			assert.Equal(t, "container1", utility.FromStringPtr(out.TaskDefinition.ContainerDefinitions[0].Name))
+
+			out, err = c.DescribeTaskDefinition(ctx, &awsECS.DescribeTaskDefinitionInput{
+				TaskDefinition: aws.String("family:1"),
+			})
+			require.NoError(t, err)
+			require.NotZero(t, out.TaskDefinition)
+			require.Len(t, out.TaskDefinition.ContainerDefinitions, 1)
+			assert.Equal(t, "container0", utility.FromStringPtr(out.TaskDefinition.ContainerDefinitions[0].Name))
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			resetECSAndSecretsManagerCache()
+
+			tCase(tctx, t, &ECSClient{})
+		})
+	}
+}
+
+func TestStoredSecretsSnapshot(t *testing.T) {
+	defer ResetGlobalSecretCache()
+
+	t.Run("SnapshotIsUnaffectedByLaterChanges", func(t *testing.T) {
+		ResetGlobalSecretCache()
+		GlobalSecretCache["secret"] = StoredSecret{
+			Name:        "secret",
+			Value:       "value",
+			BinaryValue: []byte("binary"),
+			Tags:        map[string]string{"key": "value"},
+		}
+
+		snapshot := GlobalSecretCache.Snapshot()
+		assert.Equal(t, GlobalSecretCache, snapshot)
+
+		s := GlobalSecretCache["secret"]
+		s.Value = "modified"
+		s.BinaryValue[0] = 'B'
+		s.Tags["key"] = "modified"
+		GlobalSecretCache["secret"] = s
+		delete(GlobalSecretCache, "secret")
+
+		require.Contains(t, snapshot, "secret")
+		assert.Equal(t, "value", snapshot["secret"].Value)
+		assert.Equal(t, []byte("binary"), snapshot["secret"].BinaryValue)
+		assert.Equal(t, "value", snapshot["secret"].Tags["key"])
+	})
+	t.Run("SetSeedsSecretsByName", func(t *testing.T) {
+		ResetGlobalSecretCache()
+		tags := map[string]string{"key": "value"}
+		GlobalSecretCache.Set(
+			StoredSecret{Name: "secret0", Value: "value0", Tags: tags},
+			StoredSecret{Name: "secret1", Value: "value1"},
+		)
+		require.Len(t, GlobalSecretCache, 2)
+		assert.Equal(t, "value0", GlobalSecretCache["secret0"].Value)
+		assert.Equal(t, "value1", GlobalSecretCache["secret1"].Value)
+
+		tags["key"] = "modified"
+		assert.Equal(t, "value", GlobalSecretCache["secret0"].Tags["key"], "seeded secret should be a copy")
+
+		GlobalSecretCache.Set(StoredSecret{Name: "secret0", Value: "updated"})
+		assert.Equal(t, "updated", GlobalSecretCache["secret0"].Value)
+	})
+	t.Run("SnapshotIsSafeWhileClientsCreateSecrets", func(t *testing.T) {
+		ResetGlobalSecretCache()
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+		defer cancel()
+
+		const numSecrets = 20
+		var wg sync.WaitGroup
+		errs := make(chan error, numSecrets)
+		for i := 0; i < numSecrets; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := (&SecretsManagerClient{}).CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+					Name:         aws.String(fmt.Sprintf("secret%d", i)),
+					SecretString: aws.String("value"),
+				})
+				errs <- err
+			}(i)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		for snapshotting := true; snapshotting; {
+			select {
+			case <-done:
+				snapshotting = false
+			default:
+			}
+			_ = GlobalSecretCache.Snapshot()
+		}
+
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		assert.Len(t, GlobalSecretCache.Snapshot(), numSecrets)
+	})
+}
+
+func TestScopedState(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer resetECSAndSecretsManagerCache()
+	resetECSAndSecretsManagerCache()
+
+	t.Run("ECSClientTests", func(t *testing.T) {
+		for tName, tCase := range testcase.ECSClientTests() {
+			tCase := tCase
+			t.Run(tName, func(t *testing.T) {
+				t.Parallel()
+
+				tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+				defer tcancel()
+
+				s, c := NewScopedECSService()
+				s.Clusters[testutil.ECSClusterName()] = ECSCluster{}
+
+				tCase(tctx, t, c)
+			})
+		}
+	})
+	t.Run("SecretsManagerClientTests", func(t *testing.T) {
+		for tName, tCase := range testcase.SecretsManagerClientTests() {
+			tCase := tCase
+			t.Run(tName, func(t *testing.T) {
+				t.Parallel()
+
+				tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+				defer tcancel()
+
+				_, c := NewScopedSecretCache()
+
+				tCase(tctx, t, c)
+			})
+		}
+	})
+	t.Run("ScopedECSServicesAreIsolated", func(t *testing.T) {
+		s0, c0 := NewScopedECSService()
+		s1, c1 := NewScopedECSService()
+
+		in := testutil.ValidRegisterTaskDefinitionInput(t)
+		testutil.RegisterTaskDefinition(ctx, t, c0, in)
+
+		assert.Len(t, s0.TaskDefs[utility.FromStringPtr(in.Family)], 1)
+		assert.Empty(t, s1.TaskDefs)
+		assert.Empty(t, GlobalECSService.TaskDefs)
+
+		_, err := c1.DescribeTaskDefinition(ctx, &awsECS.DescribeTaskDefinitionInput{
+			TaskDefinition: in.Family,
+		})
+		assert.Error(t, err)
+	})
+	t.Run("ScopedSecretCachesAreIsolated", func(t *testing.T) {
+		secrets0, c0 := NewScopedSecretCache()
+		secrets1, c1 := NewScopedSecretCache()
+
+		name := testutil.NewSecretName(t)
+		testutil.CreateSecret(ctx, t, c0, secretsmanager.CreateSecretInput{
+			Name:         aws.String(name),
+			SecretString: aws.String("value"),
+		})
+
+		assert.Contains(t, secrets0, name)
+		assert.Empty(t, secrets1)
+		assert.Empty(t, GlobalSecretCache)
+
+		_, err := c1.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+		assert.Error(t, err)
+	})
+	t.Run("TagClientSearchesScopedState", func(t *testing.T) {
+		_, ecsClient := NewScopedECSService()
+		_, smClient := NewScopedSecretCache()
+		c := &TagClient{ECSService: ecsClient.Service, Secrets: smClient.Secrets}
+
+		name := testutil.NewSecretName(t)
+		testutil.CreateSecret(ctx, t, smClient, secretsmanager.CreateSecretInput{
+			Name:         aws.String(name),
+			SecretString: aws.String("value"),
+		})
+
+		out, err := c.GetResources(ctx, &resourcegroupstaggingapi.GetResourcesInput{})
+		require.NoError(t, err)
+		require.Len(t, out.ResourceTagMappingList, 1)
+		assert.Equal(t, name, utility.FromStringPtr(out.ResourceTagMappingList[0].ResourceARN))
+
+		out, err = (&TagClient{}).GetResources(ctx, &resourcegroupstaggingapi.GetResourcesInput{})
+		require.NoError(t, err)
+		assert.Empty(t, out.ResourceTagMappingList)
+	})
+}
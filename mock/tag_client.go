@@ -41,6 +41,14 @@ func exportResourceTags(tags map[string]string) []types.Tag {
 // it will issue the API calls to either the fake GlobalECSService for ECS or
 // fake GlobalSecretCache for Secrets Manager.
 type TagClient struct {
+	// ECSService is the fake ECS service that the client searches for ECS
+	// resources. If this is not set, it uses GlobalECSService.
+	ECSService *ECSService
+	// Secrets is the fake secret storage cache that the client searches for
+	// Secrets Manager resources. If this is not set, it uses
+	// GlobalSecretCache.
+	Secrets StoredSecrets
+
 	GetResourcesInput  *resourcegroupstaggingapi.GetResourcesInput
 	GetResourcesOutput *resourcegroupstaggingapi.GetResourcesOutput
 	GetResourcesError  error
@@ -87,7 +95,7 @@ func (c *TagClient) getResourceFindersMatchingTypeFilters(resourceTypes []string
 
 	if len(resourceTypes) == 0 {
 		// If no resource types are filtered, search all resources.
-		for _, resourceFinders := range serviceToResourceFinders {
+		for _, resourceFinders := range c.serviceToResourceFinders() {
 			matchingAnyResourceType = append(matchingAnyResourceType, resourceFinders...)
 		}
 		return matchingAnyResourceType, nil
@@ -157,13 +165,23 @@ func (c *TagClient) getSetIntersection(a, b map[string]taggedResource) map[strin
 
 // serviceToResourceFinders maps the AWS service name to the taggable resources
 // that can be searched.
-var serviceToResourceFinders = map[string][]taggedResourceFinder{
-	"ecs":            {&ecsTaskDefinitionResourceFinder{}},
-	"secretsmanager": {&secretsManagerSecretResourceFinder{}},
+func (c *TagClient) serviceToResourceFinders() map[string][]taggedResourceFinder {
+	service := c.ECSService
+	if service == nil {
+		service = &GlobalECSService
+	}
+	secrets := c.Secrets
+	if secrets == nil {
+		secrets = GlobalSecretCache
+	}
+	return map[string][]taggedResourceFinder{
+		"ecs":            {&ecsTaskDefinitionResourceFinder{service: service}},
+		"secretsmanager": {&secretsManagerSecretResourceFinder{secrets: secrets}},
+	}
 }
 
 func (c *TagClient) getResourceFinders(resourceType string) []taggedResourceFinder {
-	for service, resourceFinders := range serviceToResourceFinders {
+	for service, resourceFinders := range c.serviceToResourceFinders() {
 		if service == resourceType {
 			return resourceFinders
 		}
@@ -185,15 +203,20 @@ type taggedResourceFinder interface {
 	getAllResources() map[string]taggedResource
 }
 
-type ecsTaskDefinitionResourceFinder struct{}
+type ecsTaskDefinitionResourceFinder struct {
+	service *ECSService
+}
 
 func (f *ecsTaskDefinitionResourceFinder) name() string {
 	return "ecs:task-definition"
 }
 
 func (f *ecsTaskDefinitionResourceFinder) getTaggedResources(key string, values []string) map[string]taggedResource {
+	f.service.mu.RLock()
+	defer f.service.mu.RUnlock()
+
 	res := map[string]taggedResource{}
-	for _, family := range GlobalECSService.TaskDefs {
+	for _, family := range f.service.TaskDefs {
 		for _, def := range family {
 			if utility.FromStringPtr(def.Status) == string(ecsTypes.TaskDefinitionStatusInactive) {
 				continue
@@ -215,8 +238,11 @@ func (f *ecsTaskDefinitionResourceFinder) getTaggedResources(key string, values
 }
 
 func (f *ecsTaskDefinitionResourceFinder) getAllResources() map[string]taggedResource {
+	f.service.mu.RLock()
+	defer f.service.mu.RUnlock()
+
 	res := map[string]taggedResource{}
-	for _, family := range GlobalECSService.TaskDefs {
+	for _, family := range f.service.TaskDefs {
 		for _, revision := range family {
 			res[revision.ARN] = f.exportTaskDefinitionTaggedResource(revision)
 		}
@@ -227,19 +253,24 @@ func (f *ecsTaskDefinitionResourceFinder) getAllResources() map[string]taggedRes
 func (f *ecsTaskDefinitionResourceFinder) exportTaskDefinitionTaggedResource(def ECSTaskDefinition) taggedResource {
 	return taggedResource{
 		ID:   def.ARN,
-		Tags: def.Tags,
+		Tags: copyMap(def.Tags),
 	}
 }
 
-type secretsManagerSecretResourceFinder struct{}
+type secretsManagerSecretResourceFinder struct {
+	secrets StoredSecrets
+}
 
 func (f *secretsManagerSecretResourceFinder) name() string {
 	return "secretsmanager:secret"
 }
 
 func (f *secretsManagerSecretResourceFinder) getTaggedResources(key string, values []string) map[string]taggedResource {
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
 	res := map[string]taggedResource{}
-	for _, s := range GlobalSecretCache {
+	for _, s := range f.secrets {
 		if s.IsDeleted {
 			continue
 		}
@@ -259,8 +290,11 @@ func (f *secretsManagerSecretResourceFinder) getTaggedResources(key string, valu
 }
 
 func (f *secretsManagerSecretResourceFinder) getAllResources() map[string]taggedResource {
+	storedSecretsMu.Lock()
+	defer storedSecretsMu.Unlock()
+
 	res := map[string]taggedResource{}
-	for _, s := range GlobalSecretCache {
+	for _, s := range f.secrets {
 		res[s.Name] = f.exportSecretTaggedResource(s)
 	}
 	return res
@@ -269,6 +303,6 @@ func (f *secretsManagerSecretResourceFinder) getAllResources() map[string]tagged
 func (f *secretsManagerSecretResourceFinder) exportSecretTaggedResource(s StoredSecret) taggedResource {
 	return taggedResource{
 		ID:   s.Name,
-		Tags: s.Tags,
+		Tags: copyMap(s.Tags),
 	}
 }
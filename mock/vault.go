@@ -5,12 +5,16 @@ import (
 
 	"github.com/evergreen-ci/cocoa"
 	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
 )
 
 // Vault provides a mock implementation of a cocoa.Vault backed by any vault by
 // default. This makes it possible to introspect on inputs to the vault and
 // control the vault's output. It provides some default implementations where
-// possible.
+// possible. Each method records its most recent input. If a method's mock
+// output or error is set, it is returned instead of calling the backing vault,
+// so the mock can be used without a backing vault as long as the output of
+// every method that is called is set.
 type Vault struct {
 	cocoa.Vault
 
@@ -27,15 +31,42 @@ type Vault struct {
 
 	DeleteSecretInput *string
 	DeleteSecretError error
+
+	FindSecretIDInput  *string
+	FindSecretIDOutput *string
+	FindSecretIDError  error
+
+	DescribeSecretInput  *string
+	DescribeSecretOutput *cocoa.SecretMetadata
+	DescribeSecretError  error
+
+	EnableRotationInput *EnableRotationInput
+	EnableRotationError error
+
+	DisableRotationInput *string
+	DisableRotationError error
+
+	EncryptsValuesOutput *bool
+}
+
+// EnableRotationInput is the input to EnableRotation.
+type EnableRotationInput struct {
+	ID   string
+	Opts cocoa.SecretRotationOptions
 }
 
-// NewVault creates a mock Vault backed by the given Vault.
+// NewVault creates a mock Vault backed by the given Vault. The backing Vault
+// may be nil if the mock output for every method that is called is set.
 func NewVault(v cocoa.Vault) *Vault {
 	return &Vault{
 		Vault: v,
 	}
 }
 
+// errNoBackingVault is returned when a method is called that has no mock output
+// and there is no backing vault to call instead.
+var errNoBackingVault = errors.New("mock vault has no output set and no backing vault")
+
 // CreateSecret saves the input options and returns a mock secret ID. The mock
 // output can be customized. By default, it will call the backing Vault
 // implementation's CreateSecret.
@@ -46,6 +77,10 @@ func (m *Vault) CreateSecret(ctx context.Context, s cocoa.NamedSecret) (id strin
 		return utility.FromStringPtr(m.CreateSecretOutput), m.CreateSecretError
 	}
 
+	if m.Vault == nil {
+		return "", errNoBackingVault
+	}
+
 	return m.Vault.CreateSecret(ctx, s)
 }
 
@@ -59,6 +94,10 @@ func (m *Vault) GetValue(ctx context.Context, id string) (val string, err error)
 		return utility.FromStringPtr(m.GetValueOutput), m.GetValueError
 	}
 
+	if m.Vault == nil {
+		return "", errNoBackingVault
+	}
+
 	return m.Vault.GetValue(ctx, id)
 }
 
@@ -72,6 +111,10 @@ func (m *Vault) UpdateValue(ctx context.Context, s cocoa.NamedSecret) error {
 		return m.UpdateValueError
 	}
 
+	if m.Vault == nil {
+		return errNoBackingVault
+	}
+
 	return m.Vault.UpdateValue(ctx, s)
 }
 
@@ -85,5 +128,98 @@ func (m *Vault) DeleteSecret(ctx context.Context, id string) error {
 		return m.DeleteSecretError
 	}
 
+	if m.Vault == nil {
+		return errNoBackingVault
+	}
+
 	return m.Vault.DeleteSecret(ctx, id)
 }
+
+// FindSecretID saves the input options and returns the ID of an existing mock
+// secret by name. The mock output can be customized. By default, it will call
+// the backing Vault implementation's FindSecretID if it supports finding
+// secrets.
+func (m *Vault) FindSecretID(ctx context.Context, name string) (id string, err error) {
+	m.FindSecretIDInput = &name
+
+	if m.FindSecretIDOutput != nil || m.FindSecretIDError != nil {
+		return utility.FromStringPtr(m.FindSecretIDOutput), m.FindSecretIDError
+	}
+
+	finder, ok := m.Vault.(cocoa.SecretFinder)
+	if !ok {
+		return "", errors.New("backing vault does not support finding secrets")
+	}
+
+	return finder.FindSecretID(ctx, name)
+}
+
+// DescribeSecret saves the input options and returns an existing mock secret's
+// metadata. The mock output can be customized. By default, it will call the
+// backing Vault implementation's DescribeSecret if it supports describing
+// secrets.
+func (m *Vault) DescribeSecret(ctx context.Context, id string) (*cocoa.SecretMetadata, error) {
+	m.DescribeSecretInput = &id
+
+	if m.DescribeSecretOutput != nil || m.DescribeSecretError != nil {
+		return m.DescribeSecretOutput, m.DescribeSecretError
+	}
+
+	describer, ok := m.Vault.(cocoa.SecretDescriber)
+	if !ok {
+		return nil, errors.New("backing vault does not support describing secrets")
+	}
+
+	return describer.DescribeSecret(ctx, id)
+}
+
+// EnableRotation saves the input options and enables rotation for an existing
+// mock secret. The mock output can be customized. By default, it will call the
+// backing Vault implementation's EnableRotation if it supports rotating
+// secrets.
+func (m *Vault) EnableRotation(ctx context.Context, id string, opts cocoa.SecretRotationOptions) error {
+	m.EnableRotationInput = &EnableRotationInput{ID: id, Opts: opts}
+
+	if m.EnableRotationError != nil {
+		return m.EnableRotationError
+	}
+
+	rotator, ok := m.Vault.(cocoa.SecretRotator)
+	if !ok {
+		return errors.New("backing vault does not support rotating secrets")
+	}
+
+	return rotator.EnableRotation(ctx, id, opts)
+}
+
+// DisableRotation saves the input options and disables rotation for an
+// existing mock secret. The mock output can be customized. By default, it will
+// call the backing Vault implementation's DisableRotation if it supports
+// rotating secrets.
+func (m *Vault) DisableRotation(ctx context.Context, id string) error {
+	m.DisableRotationInput = &id
+
+	if m.DisableRotationError != nil {
+		return m.DisableRotationError
+	}
+
+	rotator, ok := m.Vault.(cocoa.SecretRotator)
+	if !ok {
+		return errors.New("backing vault does not support rotating secrets")
+	}
+
+	return rotator.DisableRotation(ctx, id)
+}
+
+// EncryptsValues returns whether or not the mock vault encrypts secret values.
+// The mock output can be customized. By default, it will call the backing
+// Vault implementation's EncryptsValues if it supports encrypting values;
+// otherwise, it returns false.
+func (m *Vault) EncryptsValues() bool {
+	if m.EncryptsValuesOutput != nil {
+		return *m.EncryptsValuesOutput
+	}
+
+	encrypter, ok := m.Vault.(cocoa.ValueEncrypter)
+	return ok && encrypter.EncryptsValues()
+}
@@ -1,12 +1,101 @@
 package mock
 
 import (
+	"context"
 	"testing"
 
 	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestVault(t *testing.T) {
 	assert.Implements(t, (*cocoa.Vault)(nil), &Vault{})
+	assert.Implements(t, (*cocoa.SecretFinder)(nil), &Vault{})
+	assert.Implements(t, (*cocoa.SecretDescriber)(nil), &Vault{})
+	assert.Implements(t, (*cocoa.SecretRotator)(nil), &Vault{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+
+	for tName, tCase := range map[string]func(ctx context.Context, t *testing.T, v *Vault){
+		"CreateSecretRecordsInputAndReturnsMockOutput": func(ctx context.Context, t *testing.T, v *Vault) {
+			v.CreateSecretOutput = utility.ToStringPtr("id")
+			s := cocoa.NewNamedSecret().SetName("name").SetValue("value")
+
+			id, err := v.CreateSecret(ctx, *s)
+			require.NoError(t, err)
+			assert.Equal(t, "id", id)
+			require.NotZero(t, v.CreateSecretInput)
+			assert.Equal(t, *s, *v.CreateSecretInput)
+		},
+		"CreateSecretReturnsMockError": func(ctx context.Context, t *testing.T, v *Vault) {
+			v.CreateSecretError = errors.New("fake error")
+
+			id, err := v.CreateSecret(ctx, *cocoa.NewNamedSecret().SetName("name").SetValue("value"))
+			assert.Error(t, err)
+			assert.Zero(t, id)
+		},
+		"GetValueRecordsInputAndReturnsMockOutput": func(ctx context.Context, t *testing.T, v *Vault) {
+			v.GetValueOutput = utility.ToStringPtr("value")
+
+			val, err := v.GetValue(ctx, "id")
+			require.NoError(t, err)
+			assert.Equal(t, "value", val)
+			assert.Equal(t, "id", utility.FromStringPtr(v.GetValueInput))
+		},
+		"UpdateValueRecordsInputAndReturnsMockError": func(ctx context.Context, t *testing.T, v *Vault) {
+			v.UpdateValueError = errors.New("fake error")
+			s := cocoa.NewNamedSecret().SetName("id").SetValue("value")
+
+			assert.Error(t, v.UpdateValue(ctx, *s))
+			require.NotZero(t, v.UpdateValueInput)
+			assert.Equal(t, *s, *v.UpdateValueInput)
+		},
+		"DeleteSecretRecordsInputAndReturnsMockError": func(ctx context.Context, t *testing.T, v *Vault) {
+			v.DeleteSecretError = errors.New("fake error")
+
+			assert.Error(t, v.DeleteSecret(ctx, "id"))
+			assert.Equal(t, "id", utility.FromStringPtr(v.DeleteSecretInput))
+		},
+		"FindSecretIDReturnsMockOutput": func(ctx context.Context, t *testing.T, v *Vault) {
+			v.FindSecretIDOutput = utility.ToStringPtr("id")
+
+			id, err := v.FindSecretID(ctx, "name")
+			require.NoError(t, err)
+			assert.Equal(t, "id", id)
+			assert.Equal(t, "name", utility.FromStringPtr(v.FindSecretIDInput))
+		},
+		"DescribeSecretReturnsMockOutput": func(ctx context.Context, t *testing.T, v *Vault) {
+			v.DescribeSecretOutput = &cocoa.SecretMetadata{ID: "id"}
+
+			md, err := v.DescribeSecret(ctx, "id")
+			require.NoError(t, err)
+			require.NotZero(t, md)
+			assert.Equal(t, "id", md.ID)
+			assert.Equal(t, "id", utility.FromStringPtr(v.DescribeSecretInput))
+		},
+		"MethodsWithoutMockOutputFailWithoutBackingVault": func(ctx context.Context, t *testing.T, v *Vault) {
+			_, err := v.CreateSecret(ctx, *cocoa.NewNamedSecret().SetName("name").SetValue("value"))
+			assert.Error(t, err)
+			_, err = v.GetValue(ctx, "id")
+			assert.Error(t, err)
+			assert.Error(t, v.UpdateValue(ctx, *cocoa.NewNamedSecret().SetName("id").SetValue("value")))
+			assert.Error(t, v.DeleteSecret(ctx, "id"))
+			_, err = v.FindSecretID(ctx, "name")
+			assert.Error(t, err)
+			_, err = v.DescribeSecret(ctx, "id")
+			assert.Error(t, err)
+			assert.Error(t, v.DisableRotation(ctx, "id"))
+		},
+	} {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			tCase(tctx, t, NewVault(nil))
+		})
+	}
 }
@@ -0,0 +1,33 @@
+package cocoa
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/evergreen-ci/utility"
+)
+
+// NameGenerator generates the names of resources that are not explicitly
+// named. By default, such resources are given random names. Tests can use a
+// deterministic NameGenerator so that the generated names, along with the
+// hashes and exported AWS inputs that depend on them, are the same in every
+// run.
+type NameGenerator func() string
+
+// generate returns a new name. If the generator is nil, it returns a random
+// name.
+func (g NameGenerator) generate() string {
+	if g == nil {
+		return utility.RandomString()
+	}
+	return g()
+}
+
+// NewSequentialNameGenerator returns a NameGenerator that generates the names
+// <prefix>0, <prefix>1, <prefix>2, and so on. It is safe for concurrent use.
+func NewSequentialNameGenerator(prefix string) NameGenerator {
+	var next int64
+	return func() string {
+		return prefix + strconv.FormatInt(atomic.AddInt64(&next, 1)-1, 10)
+	}
+}
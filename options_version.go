@@ -0,0 +1,141 @@
+package cocoa
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+// OptionsVersion is the current version of the serialized format of the
+// top-level options (i.e. ECSPodCreationOptions, ECSPodDefinitionOptions, and
+// ECSPodExecutionOptions). It is incremented whenever fields are added to those
+// options so that options persisted by a newer version of cocoa can be
+// recognized when they're read back by an older one.
+const OptionsVersion = 3
+
+// OptionsExtensions are the serialized fields of options that are not
+// recognized by this version of cocoa. They are preserved when the options are
+// read from JSON and written back out so that data persisted by a newer
+// version of cocoa is not lost when round-tripping through an older one.
+type OptionsExtensions map[string]json.RawMessage
+
+// mergeOptionsExtensions merges the extensions into a single set of
+// extensions. Conflicting extensions are overwritten by the later ones.
+func mergeOptionsExtensions(merged, ext OptionsExtensions) OptionsExtensions {
+	if len(ext) == 0 {
+		return merged
+	}
+	if merged == nil {
+		merged = OptionsExtensions{}
+	}
+	for k, v := range ext {
+		merged[k] = v
+	}
+	return merged
+}
+
+// marshalOptionsJSON marshals the known options fields to JSON along with any
+// extensions that do not conflict with a known field.
+func marshalOptionsJSON(known interface{}, ext OptionsExtensions) ([]byte, error) {
+	b, err := json.Marshal(known)
+	if err != nil {
+		return nil, err
+	}
+	if len(ext) == 0 {
+		return b, nil
+	}
+
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, errors.Wrap(err, "decoding known fields")
+	}
+	knownFields := jsonFieldNames(known)
+	for k, v := range ext {
+		if _, ok := knownFields[strings.ToLower(k)]; ok {
+			continue
+		}
+		raw[k] = v
+	}
+
+	return json.Marshal(raw)
+}
+
+// unmarshalOptionsJSON unmarshals the JSON into the known options fields and
+// returns all the remaining fields that it does not recognize as extensions.
+func unmarshalOptionsJSON(b []byte, known interface{}) (OptionsExtensions, error) {
+	if err := json.Unmarshal(b, known); err != nil {
+		return nil, err
+	}
+
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, errors.Wrap(err, "decoding fields")
+	}
+	knownFields := jsonFieldNames(known)
+	var ext OptionsExtensions
+	for k, v := range raw {
+		if _, ok := knownFields[strings.ToLower(k)]; ok {
+			continue
+		}
+		if ext == nil {
+			ext = OptionsExtensions{}
+		}
+		ext[k] = v
+	}
+
+	return ext, nil
+}
+
+// jsonFieldNames returns the lowercased JSON field names of the struct. JSON
+// field names are lowercased because JSON decoding matches field names
+// case-insensitively.
+func jsonFieldNames(v interface{}) map[string]struct{} {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	names := map[string]struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		names[strings.ToLower(name)] = struct{}{}
+	}
+	return names
+}
+
+// warnOnNewerOptionsVersion logs a warning if the options were serialized by a
+// newer version of cocoa than this one, since some of the options may not be
+// understood and will only be preserved as extensions.
+func warnOnNewerOptionsVersion(kind string, version int, ext OptionsExtensions) {
+	if version <= OptionsVersion {
+		return
+	}
+	unknownFields := make([]string, 0, len(ext))
+	for k := range ext {
+		unknownFields = append(unknownFields, k)
+	}
+	grip.Warning(message.Fields{
+		"message":           "options were serialized by a newer version of cocoa, so unrecognized fields will be ignored",
+		"options":           kind,
+		"version":           version,
+		"supported_version": OptionsVersion,
+		"unknown_fields":    unknownFields,
+	})
+}
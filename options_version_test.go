@@ -0,0 +1,117 @@
+package cocoa
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsVersioning(t *testing.T) {
+	t.Run("MarshalSetsCurrentVersion", func(t *testing.T) {
+		b, err := json.Marshal(NewECSPodDefinitionOptions().SetName("name"))
+		require.NoError(t, err)
+
+		var opts ECSPodDefinitionOptions
+		require.NoError(t, json.Unmarshal(b, &opts))
+		assert.Equal(t, OptionsVersion, opts.Version)
+		assert.Equal(t, "name", utility.FromStringPtr(opts.Name))
+		assert.Empty(t, opts.Extensions)
+	})
+	t.Run("MarshalPreservesExistingVersion", func(t *testing.T) {
+		opts := NewECSPodExecutionOptions().SetCluster("cluster")
+		opts.Version = OptionsVersion + 1
+		b, err := json.Marshal(opts)
+		require.NoError(t, err)
+
+		var roundTripped ECSPodExecutionOptions
+		require.NoError(t, json.Unmarshal(b, &roundTripped))
+		assert.Equal(t, OptionsVersion+1, roundTripped.Version)
+	})
+	t.Run("UnversionedOptionsHaveZeroVersion", func(t *testing.T) {
+		var opts ECSPodDefinitionOptions
+		require.NoError(t, json.Unmarshal([]byte(`{"Name": "name"}`), &opts))
+		assert.Zero(t, opts.Version)
+		assert.Equal(t, "name", utility.FromStringPtr(opts.Name))
+	})
+	t.Run("UnknownFieldsArePreservedAsExtensions", func(t *testing.T) {
+		var opts ECSPodDefinitionOptions
+		require.NoError(t, json.Unmarshal([]byte(`{"Name": "name", "Version": 2, "NewField": {"foo": "bar"}}`), &opts))
+		assert.Equal(t, "name", utility.FromStringPtr(opts.Name))
+		assert.Equal(t, 2, opts.Version)
+		require.Len(t, opts.Extensions, 1)
+		assert.JSONEq(t, `{"foo": "bar"}`, string(opts.Extensions["NewField"]))
+	})
+	t.Run("KnownFieldsAreMatchedCaseInsensitively", func(t *testing.T) {
+		var opts ECSPodDefinitionOptions
+		require.NoError(t, json.Unmarshal([]byte(`{"name": "name"}`), &opts))
+		assert.Equal(t, "name", utility.FromStringPtr(opts.Name))
+		assert.Empty(t, opts.Extensions)
+	})
+	t.Run("RoundTripPreservesExtensions", func(t *testing.T) {
+		in := `{"Name": "name", "Version": 2, "NewField": [1, 2, 3]}`
+		var opts ECSPodDefinitionOptions
+		require.NoError(t, json.Unmarshal([]byte(in), &opts))
+
+		b, err := json.Marshal(opts)
+		require.NoError(t, err)
+
+		raw := map[string]json.RawMessage{}
+		require.NoError(t, json.Unmarshal(b, &raw))
+		assert.JSONEq(t, `[1, 2, 3]`, string(raw["NewField"]))
+		assert.JSONEq(t, `2`, string(raw["Version"]))
+		assert.JSONEq(t, `"name"`, string(raw["Name"]))
+	})
+	t.Run("ExtensionsDoNotOverwriteKnownFields", func(t *testing.T) {
+		opts := NewECSPodDefinitionOptions().SetName("name")
+		opts.Extensions = OptionsExtensions{"name": json.RawMessage(`"other"`)}
+		b, err := json.Marshal(opts)
+		require.NoError(t, err)
+
+		var roundTripped ECSPodDefinitionOptions
+		require.NoError(t, json.Unmarshal(b, &roundTripped))
+		assert.Equal(t, "name", utility.FromStringPtr(roundTripped.Name))
+	})
+	t.Run("NestedOptionsPreserveTheirOwnExtensions", func(t *testing.T) {
+		in := `{
+			"DefinitionOpts": {"Name": "name", "NewDefinitionField": true},
+			"ExecutionOpts": {"Cluster": "cluster", "NewExecutionField": "value"},
+			"NewCreationField": 5
+		}`
+		var opts ECSPodCreationOptions
+		require.NoError(t, json.Unmarshal([]byte(in), &opts))
+
+		assert.JSONEq(t, `5`, string(opts.Extensions["NewCreationField"]))
+		assert.Equal(t, "name", utility.FromStringPtr(opts.DefinitionOpts.Name))
+		assert.JSONEq(t, `true`, string(opts.DefinitionOpts.Extensions["NewDefinitionField"]))
+		require.NotZero(t, opts.ExecutionOpts)
+		assert.Equal(t, "cluster", utility.FromStringPtr(opts.ExecutionOpts.Cluster))
+		assert.JSONEq(t, `"value"`, string(opts.ExecutionOpts.Extensions["NewExecutionField"]))
+
+		b, err := json.Marshal(opts)
+		require.NoError(t, err)
+		var roundTripped ECSPodCreationOptions
+		require.NoError(t, json.Unmarshal(b, &roundTripped))
+		assert.Equal(t, opts.Extensions, roundTripped.Extensions)
+		assert.Equal(t, opts.DefinitionOpts.Extensions, roundTripped.DefinitionOpts.Extensions)
+		require.NotZero(t, roundTripped.ExecutionOpts)
+		assert.Equal(t, opts.ExecutionOpts.Extensions, roundTripped.ExecutionOpts.Extensions)
+	})
+	t.Run("MergePreservesExtensionsAndNewestVersion", func(t *testing.T) {
+		first := ECSPodDefinitionOptions{
+			Version:    1,
+			Extensions: OptionsExtensions{"a": json.RawMessage(`1`), "b": json.RawMessage(`2`)},
+		}
+		second := ECSPodDefinitionOptions{
+			Version:    2,
+			Extensions: OptionsExtensions{"b": json.RawMessage(`3`)},
+		}
+		merged := MergeECSPodDefinitionOptions(first, second)
+		assert.Equal(t, 2, merged.Version)
+		assert.Equal(t, OptionsExtensions{"a": json.RawMessage(`1`), "b": json.RawMessage(`3`)}, merged.Extensions)
+		assert.Len(t, first.Extensions, 2, "merging should not modify the original extensions")
+		assert.Equal(t, json.RawMessage(`2`), first.Extensions["b"], "merging should not modify the original extensions")
+	})
+}
@@ -0,0 +1,61 @@
+package cocoa
+
+import "github.com/pkg/errors"
+
+// PurposeTagKey is the reserved tag key that records the purpose of a pod or
+// pod definition (e.g. "agent" or "build"). Tagging resources with a purpose
+// partitions a cluster shared between different uses, so each use can find its
+// own pods without inventing its own tag scheme.
+const PurposeTagKey = "cocoa-purpose"
+
+// SetPurpose sets the purpose tag. If the tags are nil, it returns new tags
+// containing only the purpose tag.
+func (t Tags) SetPurpose(purpose string) Tags {
+	return t.Set(PurposeTagKey, purpose)
+}
+
+// GetPurpose returns the value of the purpose tag. It returns false if there is
+// no purpose tag.
+func (t Tags) GetPurpose() (string, bool) {
+	purpose, ok := t[PurposeTagKey]
+	return purpose, ok
+}
+
+// HasPurpose returns whether or not the tags have the given purpose.
+func (t Tags) HasPurpose(purpose string) bool {
+	p, ok := t.GetPurpose()
+	return ok && p == purpose
+}
+
+// validatePurpose checks that the purpose tag, if any, is not empty.
+func (t Tags) validatePurpose() error {
+	if purpose, ok := t.GetPurpose(); ok && purpose == "" {
+		return errors.Errorf("cannot specify an empty value for the purpose tag '%s'", PurposeTagKey)
+	}
+	return nil
+}
+
+// SetPurpose sets the purpose of the pod definition, which is applied as a
+// reserved tag.
+func (o *ECSPodDefinitionOptions) SetPurpose(purpose string) *ECSPodDefinitionOptions {
+	o.Tags = o.Tags.SetPurpose(purpose)
+	return o
+}
+
+// GetPurpose returns the purpose of the pod definition, if any.
+func (o *ECSPodDefinitionOptions) GetPurpose() (string, bool) {
+	return o.Tags.GetPurpose()
+}
+
+// SetPurpose sets the purpose of the pod itself when it is run, which is
+// applied as a reserved tag. If this is not set, a pod inherits the purpose of
+// its pod definition when it is created.
+func (o *ECSPodExecutionOptions) SetPurpose(purpose string) *ECSPodExecutionOptions {
+	o.Tags = o.Tags.SetPurpose(purpose)
+	return o
+}
+
+// GetPurpose returns the purpose of the pod itself when it is run, if any.
+func (o *ECSPodExecutionOptions) GetPurpose() (string, bool) {
+	return o.Tags.GetPurpose()
+}
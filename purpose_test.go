@@ -0,0 +1,51 @@
+package cocoa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurpose(t *testing.T) {
+	t.Run("SetPurposeOnNilTags", func(t *testing.T) {
+		var tags Tags
+		tags = tags.SetPurpose("agent")
+		purpose, ok := tags.GetPurpose()
+		assert.True(t, ok)
+		assert.Equal(t, "agent", purpose)
+		assert.Equal(t, "agent", tags[PurposeTagKey])
+	})
+	t.Run("GetPurposeWithoutPurposeTag", func(t *testing.T) {
+		_, ok := Tags{"key": "value"}.GetPurpose()
+		assert.False(t, ok)
+	})
+	t.Run("HasPurpose", func(t *testing.T) {
+		tags := NewTags().SetPurpose("agent")
+		assert.True(t, tags.HasPurpose("agent"))
+		assert.False(t, tags.HasPurpose("build"))
+		assert.False(t, NewTags().HasPurpose("agent"))
+	})
+	t.Run("PodDefinitionOptions", func(t *testing.T) {
+		opts := NewECSPodDefinitionOptions().AddTags(map[string]string{"key": "value"}).SetPurpose("agent")
+		purpose, ok := opts.GetPurpose()
+		assert.True(t, ok)
+		assert.Equal(t, "agent", purpose)
+		assert.Equal(t, "value", opts.Tags["key"])
+	})
+	t.Run("PodDefinitionOptionsFailValidationWithEmptyPurpose", func(t *testing.T) {
+		opts := NewECSPodDefinitionOptions().
+			AddContainerDefinitions(*NewECSContainerDefinition().SetImage("image").SetMemoryMB(128).SetCPU(128)).
+			SetPurpose("")
+		assert.Error(t, opts.Validate())
+	})
+	t.Run("PodExecutionOptions", func(t *testing.T) {
+		opts := NewECSPodExecutionOptions().SetPurpose("agent")
+		purpose, ok := opts.GetPurpose()
+		assert.True(t, ok)
+		assert.Equal(t, "agent", purpose)
+		assert.NoError(t, opts.Validate())
+	})
+	t.Run("PodExecutionOptionsFailValidationWithEmptyPurpose", func(t *testing.T) {
+		assert.Error(t, NewECSPodExecutionOptions().SetPurpose("").Validate())
+	})
+}
@@ -0,0 +1,116 @@
+package cocoa
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ResourceProfile is a named preset of the CPU and memory for a pod. Using
+// resource profiles standardizes pod sizes rather than requiring each pod to
+// specify its own CPU and memory.
+type ResourceProfile string
+
+const (
+	// ResourceProfileSmall is a pod with 0.5 vCPU and 1 GB of memory.
+	ResourceProfileSmall ResourceProfile = "small"
+	// ResourceProfileMedium is a pod with 1 vCPU and 2 GB of memory.
+	ResourceProfileMedium ResourceProfile = "medium"
+	// ResourceProfileLarge is a pod with 2 vCPUs and 4 GB of memory.
+	ResourceProfileLarge ResourceProfile = "large"
+)
+
+// resourceProfileSizes are the CPU (in CPU units) and memory (in MB) for each
+// resource profile.
+var resourceProfileSizes = map[ResourceProfile]struct {
+	cpu   int
+	memMB int
+}{
+	ResourceProfileSmall:  {cpu: 512, memMB: 1024},
+	ResourceProfileMedium: {cpu: 1024, memMB: 2048},
+	ResourceProfileLarge:  {cpu: 2048, memMB: 4096},
+}
+
+// Validate checks that the resource profile is one of the recognized profiles.
+func (p ResourceProfile) Validate() error {
+	if _, ok := resourceProfileSizes[p]; !ok {
+		return errors.Errorf("unrecognized resource profile '%s'", p)
+	}
+	return nil
+}
+
+// CPU returns the CPU (in CPU units) for the resource profile. It returns 0
+// if the profile is not recognized.
+func (p ResourceProfile) CPU() int {
+	return resourceProfileSizes[p].cpu
+}
+
+// MemoryMB returns the memory (in MB) for the resource profile. It returns 0
+// if the profile is not recognized.
+func (p ResourceProfile) MemoryMB() int {
+	return resourceProfileSizes[p].memMB
+}
+
+// fargateMemoryMBRanges are the valid amounts of memory (in MB) for each
+// amount of CPU (in CPU units) that a Fargate pod can have.
+// Docs: https://docs.aws.amazon.com/AmazonECS/latest/developerguide/task_definition_parameters.html#task_size
+var fargateMemoryMBRanges = map[int]struct {
+	min, max, step int
+}{
+	256:   {min: 512, max: 2048, step: 512},
+	512:   {min: 1024, max: 4096, step: 1024},
+	1024:  {min: 2048, max: 8192, step: 1024},
+	2048:  {min: 4096, max: 16384, step: 1024},
+	4096:  {min: 8192, max: 30720, step: 1024},
+	8192:  {min: 16384, max: 61440, step: 4096},
+	16384: {min: 32768, max: 122880, step: 8192},
+}
+
+// ValidateFargateSize checks that the pod-level CPU (in CPU units) and memory
+// (in MB) are a combination that Fargate supports.
+func ValidateFargateSize(cpu, memMB int) error {
+	r, ok := fargateMemoryMBRanges[cpu]
+	if !ok {
+		return errors.Errorf("%d CPU units is not a supported Fargate CPU size", cpu)
+	}
+	if memMB < r.min || memMB > r.max || (memMB-r.min)%r.step != 0 {
+		return errors.Errorf("%d MB of memory is not supported by Fargate for %d CPU units", memMB, cpu)
+	}
+	return nil
+}
+
+// SetResourceProfile sets the named preset of CPU and memory for the pod. If
+// the pod-level CPU or memory is also explicitly set, the explicit value takes
+// precedence over the profile.
+func (o *ECSPodDefinitionOptions) SetResourceProfile(p ResourceProfile) *ECSPodDefinitionOptions {
+	o.ResourceProfile = &p
+	return o
+}
+
+// applyResourceProfile sets the pod-level CPU and memory from the resource
+// profile if they are not explicitly set.
+func (o *ECSPodDefinitionOptions) applyResourceProfile() {
+	if o.ResourceProfile == nil {
+		return
+	}
+	o.CPU = o.effectiveCPU()
+	o.MemoryMB = o.effectiveMemoryMB()
+}
+
+// effectiveCPU returns the pod-level CPU, which is either the explicit CPU or
+// the CPU from the resource profile.
+func (o *ECSPodDefinitionOptions) effectiveCPU() *int {
+	if o.CPU != nil || o.ResourceProfile == nil || o.ResourceProfile.Validate() != nil {
+		return o.CPU
+	}
+	cpu := o.ResourceProfile.CPU()
+	return &cpu
+}
+
+// effectiveMemoryMB returns the pod-level memory, which is either the explicit
+// memory or the memory from the resource profile.
+func (o *ECSPodDefinitionOptions) effectiveMemoryMB() *int {
+	if o.MemoryMB != nil || o.ResourceProfile == nil || o.ResourceProfile.Validate() != nil {
+		return o.MemoryMB
+	}
+	memMB := o.ResourceProfile.MemoryMB()
+	return &memMB
+}
@@ -0,0 +1,79 @@
+package cocoa
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceProfile(t *testing.T) {
+	t.Run("ProfilesAreValidFargateSizes", func(t *testing.T) {
+		for _, p := range []ResourceProfile{ResourceProfileSmall, ResourceProfileMedium, ResourceProfileLarge} {
+			assert.NoError(t, p.Validate(), p)
+			assert.NoError(t, ValidateFargateSize(p.CPU(), p.MemoryMB()), p)
+		}
+	})
+	t.Run("FailsWithUnrecognizedProfile", func(t *testing.T) {
+		p := ResourceProfile("huge")
+		assert.Error(t, p.Validate())
+		assert.Zero(t, p.CPU())
+		assert.Zero(t, p.MemoryMB())
+	})
+}
+
+func TestValidateFargateSize(t *testing.T) {
+	for _, size := range [][2]int{{256, 512}, {256, 2048}, {512, 3072}, {4096, 30720}, {8192, 20480}, {16384, 122880}} {
+		assert.NoError(t, ValidateFargateSize(size[0], size[1]), size)
+	}
+	for _, size := range [][2]int{{128, 512}, {256, 4096}, {512, 1536}, {1024, 1024}, {8192, 18432}} {
+		assert.Error(t, ValidateFargateSize(size[0], size[1]), size)
+	}
+}
+
+func TestECSPodDefinitionOptionsResourceProfile(t *testing.T) {
+	newOpts := func() *ECSPodDefinitionOptions {
+		return NewECSPodDefinitionOptions().
+			SetName("name").
+			AddContainerDefinitions(*NewECSContainerDefinition().SetName("container").SetImage("image"))
+	}
+
+	t.Run("ValidateAppliesProfile", func(t *testing.T) {
+		opts := newOpts().SetResourceProfile(ResourceProfileMedium)
+		require.NoError(t, opts.Validate())
+		assert.Equal(t, 1024, utility.FromIntPtr(opts.CPU))
+		assert.Equal(t, 2048, utility.FromIntPtr(opts.MemoryMB))
+	})
+	t.Run("ExplicitValuesOverrideProfile", func(t *testing.T) {
+		opts := newOpts().SetMemoryMB(3072).SetResourceProfile(ResourceProfileSmall)
+		require.NoError(t, opts.Validate())
+		assert.Equal(t, 512, utility.FromIntPtr(opts.CPU))
+		assert.Equal(t, 3072, utility.FromIntPtr(opts.MemoryMB))
+	})
+	t.Run("ValidateFailsWithUnrecognizedProfile", func(t *testing.T) {
+		opts := newOpts().SetResourceProfile("huge")
+		assert.Error(t, opts.Validate())
+	})
+	t.Run("ValidateFailsWithoutProfileOrContainerResources", func(t *testing.T) {
+		assert.Error(t, newOpts().Validate())
+	})
+	t.Run("MergeOverridesProfile", func(t *testing.T) {
+		merged := MergeECSPodDefinitionOptions(
+			*NewECSPodDefinitionOptions().SetResourceProfile(ResourceProfileSmall),
+			*NewECSPodDefinitionOptions().SetResourceProfile(ResourceProfileLarge),
+		)
+		require.NotNil(t, merged.ResourceProfile)
+		assert.Equal(t, ResourceProfileLarge, *merged.ResourceProfile)
+	})
+	t.Run("HashAndEqualityUseEffectiveResources", func(t *testing.T) {
+		profile := newOpts().SetResourceProfile(ResourceProfileSmall)
+		explicit := newOpts().SetCPU(512).SetMemoryMB(1024)
+		other := newOpts().SetResourceProfile(ResourceProfileLarge)
+
+		assert.Equal(t, explicit.Hash(), profile.Hash())
+		assert.True(t, profile.Equals(*explicit))
+		assert.NotEqual(t, other.Hash(), profile.Hash())
+		assert.False(t, profile.Equals(*other))
+	})
+}
@@ -0,0 +1,178 @@
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DataKeyProvider provides the data keys used to encrypt secret values before
+// they are stored. Each secret value is encrypted with its own data key, and
+// the data key itself is stored alongside the value in its encrypted form
+// (i.e. envelope encryption). Implementations can wrap the data keys with a
+// key held by the caller (see AEADDataKeyProvider) or with a key management
+// service such as AWS KMS (e.g. using its GenerateDataKey and Decrypt APIs).
+type DataKeyProvider interface {
+	// GenerateDataKey returns a new 256-bit data key in both its plaintext
+	// form, which is used to encrypt a secret value, and its encrypted form,
+	// which is stored with the encrypted secret value.
+	GenerateDataKey(ctx context.Context) (plaintext, encrypted []byte, err error)
+	// DecryptDataKey returns the plaintext form of an encrypted data key that
+	// was returned from GenerateDataKey.
+	DecryptDataKey(ctx context.Context, encrypted []byte) (plaintext []byte, err error)
+}
+
+// dataKeySize is the size of a data key in bytes, which is suitable for
+// AES-256.
+const dataKeySize = 32
+
+// AEADDataKeyProvider is a DataKeyProvider that encrypts data keys with
+// AES-GCM using a key provided by the caller.
+type AEADDataKeyProvider struct {
+	aead cipher.AEAD
+}
+
+// NewAEADDataKeyProvider returns a new data key provider that encrypts data
+// keys with the given AES key, which must be 16, 24, or 32 bytes long.
+func NewAEADDataKeyProvider(key []byte) (*AEADDataKeyProvider, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "initializing AES-GCM cipher")
+	}
+	return &AEADDataKeyProvider{aead: aead}, nil
+}
+
+// GenerateDataKey returns a new random data key along with the data key
+// encrypted by the provider's key.
+func (p *AEADDataKeyProvider) GenerateDataKey(ctx context.Context) (plaintext, encrypted []byte, err error) {
+	plaintext = make([]byte, dataKeySize)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, nil, errors.Wrap(err, "generating data key")
+	}
+	encrypted, err = seal(p.aead, plaintext, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "encrypting data key")
+	}
+	return plaintext, encrypted, nil
+}
+
+// DecryptDataKey decrypts a data key that was encrypted by the provider's key.
+func (p *AEADDataKeyProvider) DecryptDataKey(ctx context.Context, encrypted []byte) ([]byte, error) {
+	plaintext, err := open(p.aead, encrypted, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting data key")
+	}
+	return plaintext, nil
+}
+
+// encryptedValuePrefix identifies a secret value that was encrypted by the
+// vault before it was stored.
+const encryptedValuePrefix = "cocoa-encrypted:v1:"
+
+// encryptedValue is the envelope-encrypted form of a secret value.
+type encryptedValue struct {
+	// DataKey is the encrypted data key.
+	DataKey []byte `json:"data_key"`
+	// Ciphertext is the secret value encrypted by the data key, prefixed by
+	// its nonce.
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// encryptValue encrypts the value of the secret with the given name with a new
+// data key from the provider and returns the encoded envelope that can be
+// stored in place of the value. The secret name is authenticated along with
+// the value, so the envelope can only be decrypted for the same secret.
+func encryptValue(ctx context.Context, p DataKeyProvider, name, val string) (string, error) {
+	plaintextKey, encryptedKey, err := p.GenerateDataKey(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "generating data key")
+	}
+	aead, err := newAESGCM(plaintextKey)
+	if err != nil {
+		return "", errors.Wrap(err, "initializing cipher from data key")
+	}
+	ciphertext, err := seal(aead, []byte(val), []byte(name))
+	if err != nil {
+		return "", errors.Wrap(err, "encrypting secret value")
+	}
+
+	b, err := json.Marshal(encryptedValue{DataKey: encryptedKey, Ciphertext: ciphertext})
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling encrypted secret value")
+	}
+
+	return encryptedValuePrefix + base64.StdEncoding.EncodeToString(b), nil
+}
+
+// decryptValue decrypts the stored value of the secret with the given name that
+// was encrypted by encryptValue. It fails if the value was encrypted for a
+// different secret. Values that were not encrypted are rejected unless
+// allowUnencrypted is set, in which case they are returned unchanged.
+func decryptValue(ctx context.Context, p DataKeyProvider, name, stored string, allowUnencrypted bool) (string, error) {
+	if !strings.HasPrefix(stored, encryptedValuePrefix) {
+		if allowUnencrypted {
+			return stored, nil
+		}
+		return "", errors.New("secret value is not encrypted")
+	}
+
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedValuePrefix))
+	if err != nil {
+		return "", errors.Wrap(err, "decoding encrypted secret value")
+	}
+	var ev encryptedValue
+	if err := json.Unmarshal(b, &ev); err != nil {
+		return "", errors.Wrap(err, "unmarshalling encrypted secret value")
+	}
+
+	plaintextKey, err := p.DecryptDataKey(ctx, ev.DataKey)
+	if err != nil {
+		return "", errors.Wrap(err, "decrypting data key")
+	}
+	aead, err := newAESGCM(plaintextKey)
+	if err != nil {
+		return "", errors.Wrap(err, "initializing cipher from data key")
+	}
+	val, err := open(aead, ev.Ciphertext, []byte(name))
+	if err != nil {
+		return "", errors.Wrap(err, "decrypting secret value")
+	}
+
+	return string(val), nil
+}
+
+// newAESGCM returns an AES-GCM cipher using the key.
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the plaintext with a random nonce, authenticating the
+// additional data along with it, and returns the nonce followed by the
+// ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "generating nonce")
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the output of seal, which must have been sealed with the same
+// additional data.
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,113 @@
+package secret
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAEADDataKeyProvider(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("FailsWithInvalidKeySize", func(t *testing.T) {
+		p, err := NewAEADDataKeyProvider([]byte("short"))
+		assert.Error(t, err)
+		assert.Zero(t, p)
+	})
+	t.Run("DecryptsGeneratedDataKey", func(t *testing.T) {
+		p, err := NewAEADDataKeyProvider(make([]byte, 32))
+		require.NoError(t, err)
+
+		plaintext, encrypted, err := p.GenerateDataKey(ctx)
+		require.NoError(t, err)
+		assert.Len(t, plaintext, dataKeySize)
+		assert.NotEqual(t, plaintext, encrypted)
+
+		decrypted, err := p.DecryptDataKey(ctx, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	})
+	t.Run("FailsToDecryptDataKeyWithDifferentKey", func(t *testing.T) {
+		p, err := NewAEADDataKeyProvider(make([]byte, 32))
+		require.NoError(t, err)
+		other, err := NewAEADDataKeyProvider([]byte(strings.Repeat("k", 32)))
+		require.NoError(t, err)
+
+		_, encrypted, err := p.GenerateDataKey(ctx)
+		require.NoError(t, err)
+		_, err = other.DecryptDataKey(ctx, encrypted)
+		assert.Error(t, err)
+	})
+}
+
+func TestEncryptValue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p, err := NewAEADDataKeyProvider(make([]byte, 32))
+	require.NoError(t, err)
+
+	t.Run("RoundTrips", func(t *testing.T) {
+		encrypted, err := encryptValue(ctx, p, "name", "value")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(encrypted, encryptedValuePrefix))
+		assert.NotContains(t, encrypted, "value")
+
+		decrypted, err := decryptValue(ctx, p, "name", encrypted, false)
+		require.NoError(t, err)
+		assert.Equal(t, "value", decrypted)
+	})
+	t.Run("UsesNewDataKeyForEachValue", func(t *testing.T) {
+		first, err := encryptValue(ctx, p, "name", "value")
+		require.NoError(t, err)
+		second, err := encryptValue(ctx, p, "name", "value")
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+	t.Run("FailsWithUnencryptedValue", func(t *testing.T) {
+		val, err := decryptValue(ctx, p, "name", "value", false)
+		assert.Error(t, err)
+		assert.Zero(t, val)
+	})
+	t.Run("ReturnsUnencryptedValueUnchangedWhenAllowed", func(t *testing.T) {
+		val, err := decryptValue(ctx, p, "name", "value", true)
+		require.NoError(t, err)
+		assert.Equal(t, "value", val)
+	})
+	t.Run("DecryptsEncryptedValueWhenUnencryptedValuesAreAllowed", func(t *testing.T) {
+		encrypted, err := encryptValue(ctx, p, "name", "value")
+		require.NoError(t, err)
+
+		decrypted, err := decryptValue(ctx, p, "name", encrypted, true)
+		require.NoError(t, err)
+		assert.Equal(t, "value", decrypted)
+	})
+	t.Run("FailsWithValueEncryptedForDifferentSecret", func(t *testing.T) {
+		encrypted, err := encryptValue(ctx, p, "name", "value")
+		require.NoError(t, err)
+
+		_, err = decryptValue(ctx, p, "other_name", encrypted, false)
+		assert.Error(t, err)
+	})
+	t.Run("FailsWithTamperedValue", func(t *testing.T) {
+		encrypted, err := encryptValue(ctx, p, "name", "value")
+		require.NoError(t, err)
+
+		_, err = decryptValue(ctx, p, "name", encryptedValuePrefix+"not-base64!", false)
+		assert.Error(t, err)
+
+		tampered := []byte(encrypted)
+		last := len(tampered) - 5
+		if tampered[last] == 'A' {
+			tampered[last] = 'B'
+		} else {
+			tampered[last] = 'A'
+		}
+		_, err = decryptValue(ctx, p, "name", string(tampered), false)
+		assert.Error(t, err)
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/aws/smithy-go"
+	"github.com/evergreen-ci/cocoa"
 	"github.com/evergreen-ci/cocoa/awsutil"
 	"github.com/evergreen-ci/utility"
 )
@@ -35,6 +36,14 @@ func NewBasicSecretsManagerClient(ctx context.Context, opts awsutil.ClientOption
 	return c, nil
 }
 
+// newLazyBasicSecretsManagerClient creates a new AWS Secrets Manager client
+// from the given options that is set up the first time it is used.
+func newLazyBasicSecretsManagerClient(opts awsutil.ClientOptions) *BasicSecretsManagerClient {
+	return &BasicSecretsManagerClient{
+		BaseClient: awsutil.NewBaseClient(opts),
+	}
+}
+
 func (c *BasicSecretsManagerClient) setup(ctx context.Context) error {
 	if c.sm != nil {
 		return nil
@@ -50,68 +59,94 @@ func (c *BasicSecretsManagerClient) setup(ctx context.Context) error {
 	return nil
 }
 
+// setupOperation sets up the client and returns the options for a single
+// operation. If the context specifies a role to assume, the operation uses the
+// credentials for that role. If the client has a circuit breaker, the
+// operation is guarded by it.
+func (c *BasicSecretsManagerClient) setupOperation(ctx context.Context) ([]func(*secretsmanager.Options), error) {
+	if err := c.setup(ctx); err != nil {
+		return nil, err
+	}
+
+	creds, err := c.GetContextCredentials(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting credentials for role to assume")
+	}
+	apiOpts := c.GetCircuitBreakerAPIOptions()
+	if creds == nil && len(apiOpts) == 0 {
+		return nil, nil
+	}
+
+	return []func(*secretsmanager.Options){func(o *secretsmanager.Options) {
+		if creds != nil {
+			o.Credentials = creds
+		}
+		o.APIOptions = append(o.APIOptions, apiOpts...)
+	}}, nil
+}
+
 // CreateSecret creates a new secret.
 func (c *BasicSecretsManagerClient) CreateSecret(ctx context.Context, in *secretsmanager.CreateSecretInput) (*secretsmanager.CreateSecretOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *secretsmanager.CreateSecretOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("CreateSecret", in)
-		out, err = c.sm.CreateSecret(ctx, in)
+		out, err = c.sm.CreateSecret(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.Name))
 	}
 	return out, nil
 }
 
 // GetSecretValue gets the decrypted value of an existing secret.
 func (c *BasicSecretsManagerClient) GetSecretValue(ctx context.Context, in *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *secretsmanager.GetSecretValueOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("GetSecretValue", in)
-		out, err = c.sm.GetSecretValue(ctx, in)
+		out, err = c.sm.GetSecretValue(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.SecretId))
 	}
 	return out, nil
 }
 
 // DescribeSecret gets the metadata information about a secret.
 func (c *BasicSecretsManagerClient) DescribeSecret(ctx context.Context, in *secretsmanager.DescribeSecretInput) (*secretsmanager.DescribeSecretOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *secretsmanager.DescribeSecretOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("DescribeSecret", in)
-		out, err = c.sm.DescribeSecret(ctx, in)
+		out, err = c.sm.DescribeSecret(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.SecretId))
 	}
 
 	return out, nil
@@ -119,22 +154,22 @@ func (c *BasicSecretsManagerClient) DescribeSecret(ctx context.Context, in *secr
 
 // ListSecrets lists the metadata information for secrets matching the filters.
 func (c *BasicSecretsManagerClient) ListSecrets(ctx context.Context, in *secretsmanager.ListSecretsInput) (*secretsmanager.ListSecretsOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *secretsmanager.ListSecretsOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("ListSecrets", in)
-		out, err = c.sm.ListSecrets(ctx, in)
+		out, err = c.sm.ListSecrets(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err)
 	}
 
 	return out, nil
@@ -142,59 +177,59 @@ func (c *BasicSecretsManagerClient) ListSecrets(ctx context.Context, in *secrets
 
 // UpdateSecretValue updates the value of an existing secret.
 func (c *BasicSecretsManagerClient) UpdateSecretValue(ctx context.Context, in *secretsmanager.UpdateSecretInput) (*secretsmanager.UpdateSecretOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *secretsmanager.UpdateSecretOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("UpdateSecret", in)
-		out, err = c.sm.UpdateSecret(ctx, in)
+		out, err = c.sm.UpdateSecret(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.SecretId))
 	}
 	return out, nil
 }
 
 // TagResource tags an existing secret.
 func (c *BasicSecretsManagerClient) TagResource(ctx context.Context, in *secretsmanager.TagResourceInput) (*secretsmanager.TagResourceOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *secretsmanager.TagResourceOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("TagResource", in)
-		out, err = c.sm.TagResource(ctx, in)
+		out, err = c.sm.TagResource(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
 		}
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.SecretId))
 	}
 	return out, nil
 }
 
 // DeleteSecret deletes an existing secret.
 func (c *BasicSecretsManagerClient) DeleteSecret(ctx context.Context, in *secretsmanager.DeleteSecretInput) (*secretsmanager.DeleteSecretOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *secretsmanager.DeleteSecretOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("DeleteSecret", in)
-		out, err = c.sm.DeleteSecret(ctx, in)
+		out, err = c.sm.DeleteSecret(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
@@ -202,7 +237,75 @@ func (c *BasicSecretsManagerClient) DeleteSecret(ctx context.Context, in *secret
 
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.SecretId))
+	}
+	return out, nil
+}
+
+// RestoreSecret cancels the scheduled deletion of a secret that is still within
+// its recovery window.
+func (c *BasicSecretsManagerClient) RestoreSecret(ctx context.Context, in *secretsmanager.RestoreSecretInput) (*secretsmanager.RestoreSecretOutput, error) {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up client")
+	}
+
+	var out *secretsmanager.RestoreSecretOutput
+	if err := utility.Retry(ctx, func() (bool, error) {
+		msg := awsutil.MakeAPILogMessage("RestoreSecret", in)
+		out, err = c.sm.RestoreSecret(ctx, in, optFns...)
+		grip.Debug(message.WrapError(err, msg))
+		if c.isNonRetryableError(err) {
+			return false, err
+		}
+		return true, err
+	}, c.GetRetryOptions()); err != nil {
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.SecretId))
+	}
+	return out, nil
+}
+
+// RotateSecret configures automatic rotation for an existing secret and
+// optionally rotates it immediately.
+func (c *BasicSecretsManagerClient) RotateSecret(ctx context.Context, in *secretsmanager.RotateSecretInput) (*secretsmanager.RotateSecretOutput, error) {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up client")
+	}
+
+	var out *secretsmanager.RotateSecretOutput
+	if err := utility.Retry(ctx, func() (bool, error) {
+		msg := awsutil.MakeAPILogMessage("RotateSecret", in)
+		out, err = c.sm.RotateSecret(ctx, in, optFns...)
+		grip.Debug(message.WrapError(err, msg))
+		if c.isNonRetryableError(err) {
+			return false, err
+		}
+		return true, err
+	}, c.GetRetryOptions()); err != nil {
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.SecretId))
+	}
+	return out, nil
+}
+
+// CancelRotateSecret turns off automatic rotation for an existing secret.
+func (c *BasicSecretsManagerClient) CancelRotateSecret(ctx context.Context, in *secretsmanager.CancelRotateSecretInput) (*secretsmanager.CancelRotateSecretOutput, error) {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up client")
+	}
+
+	var out *secretsmanager.CancelRotateSecretOutput
+	if err := utility.Retry(ctx, func() (bool, error) {
+		msg := awsutil.MakeAPILogMessage("CancelRotateSecret", in)
+		out, err = c.sm.CancelRotateSecret(ctx, in, optFns...)
+		grip.Debug(message.WrapError(err, msg))
+		if c.isNonRetryableError(err) {
+			return false, err
+		}
+		return true, err
+	}, c.GetRetryOptions()); err != nil {
+		return nil, awsutil.WrapAPIError(err, utility.FromStringPtr(in.SecretId))
 	}
 	return out, nil
 }
@@ -215,5 +318,6 @@ func (c *BasicSecretsManagerClient) isNonRetryableError(err error) bool {
 		utility.MatchesError[*types.ResourceNotFoundException](err) ||
 		utility.MatchesError[*types.ResourceExistsException](err) ||
 		utility.MatchesError[*smithy.InvalidParamsError](err) ||
-		utility.MatchesError[*smithy.ParamRequiredError](err)
+		utility.MatchesError[*smithy.ParamRequiredError](err) ||
+		cocoa.IsCircuitOpenError(err)
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/awsutil"
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
@@ -17,15 +18,76 @@ import (
 // BasicSecretsManager provides a cocoa.Vault implementation backed by AWS
 // Secrets Manager.
 type BasicSecretsManager struct {
-	client cocoa.SecretsManagerClient
-	cache  cocoa.SecretCache
+	client             cocoa.SecretsManagerClient
+	cache              cocoa.SecretCache
+	defaultListFilters []types.Filter
+	// recreateIfScheduledForDeletion is whether to force delete and recreate
+	// a secret whose name conflicts with a secret that's scheduled for
+	// deletion.
+	recreateIfScheduledForDeletion bool
+	// dataKeyProvider provides the data keys to encrypt secret values before
+	// they're stored, if any.
+	dataKeyProvider DataKeyProvider
+	// allowUnencryptedValues is whether values that were not encrypted by the
+	// data key provider are returned as-is rather than rejected.
+	allowUnencryptedValues bool
+	// ownedClient is the client that the vault constructed itself, if any.
+	// Only the owned client is closed when the vault is closed.
+	ownedClient *BasicSecretsManagerClient
 }
 
 // BasicSecretsManagerOptions are options to create a basic Secrets Manager
 // vault that's optionally backed by a cache.
 type BasicSecretsManagerOptions struct {
+	// Client is the client used to communicate with Secrets Manager. The
+	// caller retains ownership of the client, so it is not closed when the
+	// vault is closed. Exactly one of Client or ClientOptions must be
+	// specified.
 	Client cocoa.SecretsManagerClient
-	Cache  cocoa.SecretCache
+	// ClientOptions are options to construct a new client used to communicate
+	// with Secrets Manager. The vault owns the constructed client, so it is
+	// closed when the vault is closed. Exactly one of Client or ClientOptions
+	// must be specified.
+	ClientOptions *awsutil.ClientOptions
+	Cache         cocoa.SecretCache
+	// DefaultListFilters are filters that are always applied when listing
+	// secrets in addition to any filters given when listing. This can scope
+	// the vault to only the secrets that belong to it (e.g. secrets with a
+	// particular tag) so that it never operates on foreign secrets.
+	DefaultListFilters []types.Filter
+	// RecreateIfScheduledForDeletion determines what happens when creating a
+	// secret whose name belongs to an existing secret that's scheduled for
+	// deletion. Secrets Manager does not allow the name to be reused until the
+	// old secret is permanently deleted, so if this is enabled, the old secret
+	// is deleted immediately without recovery and the new secret is created in
+	// its place. By default, creating the secret fails.
+	RecreateIfScheduledForDeletion *bool
+	// DataKeyProvider, if specified, encrypts secret values on the client side
+	// before they are sent to Secrets Manager and decrypts them when they are
+	// retrieved with GetValue. This provides defense in depth when access to
+	// Secrets Manager in the account is broad, since the stored values are
+	// unusable without the data key provider's key. Values that were not
+	// encrypted by the vault are rejected when they are retrieved, unless
+	// AllowUnencryptedValues is enabled.
+	//
+	// Each encrypted value is bound to the name of its secret, so it cannot be
+	// decrypted if it is copied into a different secret.
+	//
+	// Encrypted secrets can only be read through the vault, so they cannot be
+	// used by pod definitions that ECS resolves directly from Secrets Manager
+	// (e.g. for container environment variables or repository credentials).
+	// The ECS pod creator and pod definition manager reject a vault that
+	// encrypts values. By default, secret values are not encrypted by the
+	// vault.
+	DataKeyProvider DataKeyProvider
+	// AllowUnencryptedValues determines whether GetValue returns values that
+	// were not encrypted by the vault (e.g. values stored before the
+	// DataKeyProvider was specified) as-is. This is only intended for
+	// migrating existing secrets to encrypted values, since it allows anyone
+	// who can write to Secrets Manager to supply a value that the vault
+	// trusts. It can only be specified with a DataKeyProvider. By default,
+	// unencrypted values are rejected.
+	AllowUnencryptedValues *bool
 }
 
 // NewBasicSecretsManagerOptions returns new uninitialized options to create a
@@ -41,12 +103,54 @@ func (o *BasicSecretsManagerOptions) SetClient(c cocoa.SecretsManagerClient) *Ba
 	return o
 }
 
+// SetClientOptions sets the options to construct a new client that the vault
+// owns and uses to communicate with Secrets Manager.
+func (o *BasicSecretsManagerOptions) SetClientOptions(opts awsutil.ClientOptions) *BasicSecretsManagerOptions {
+	o.ClientOptions = &opts
+	return o
+}
+
 // SetCache sets the cache used to track secrets externally.
 func (o *BasicSecretsManagerOptions) SetCache(sc cocoa.SecretCache) *BasicSecretsManagerOptions {
 	o.Cache = sc
 	return o
 }
 
+// SetDefaultListFilters sets the filters that are always applied when listing
+// secrets. This overwrites any existing default list filters.
+func (o *BasicSecretsManagerOptions) SetDefaultListFilters(filters []types.Filter) *BasicSecretsManagerOptions {
+	o.DefaultListFilters = filters
+	return o
+}
+
+// SetRecreateIfScheduledForDeletion sets whether to force delete and recreate
+// a secret whose name belongs to a secret that's scheduled for deletion.
+func (o *BasicSecretsManagerOptions) SetRecreateIfScheduledForDeletion(recreate bool) *BasicSecretsManagerOptions {
+	o.RecreateIfScheduledForDeletion = &recreate
+	return o
+}
+
+// SetDataKeyProvider sets the provider of the data keys that encrypt secret
+// values before they are stored.
+func (o *BasicSecretsManagerOptions) SetDataKeyProvider(p DataKeyProvider) *BasicSecretsManagerOptions {
+	o.DataKeyProvider = p
+	return o
+}
+
+// SetAllowUnencryptedValues sets whether values that were not encrypted by the
+// vault are returned as-is rather than rejected.
+func (o *BasicSecretsManagerOptions) SetAllowUnencryptedValues(allow bool) *BasicSecretsManagerOptions {
+	o.AllowUnencryptedValues = &allow
+	return o
+}
+
+// AddDefaultListFilters adds new filters that are always applied when listing
+// secrets to the existing ones.
+func (o *BasicSecretsManagerOptions) AddDefaultListFilters(filters ...types.Filter) *BasicSecretsManagerOptions {
+	o.DefaultListFilters = append(o.DefaultListFilters, filters...)
+	return o
+}
+
 var (
 	defaultCacheTrackingTag = "cocoa-tracked"
 )
@@ -55,7 +159,13 @@ var (
 // vault are given.
 func (o *BasicSecretsManagerOptions) Validate() error {
 	catcher := grip.NewBasicCatcher()
-	catcher.NewWhen(o.Client == nil, "must specify a client")
+	catcher.NewWhen(o.Client == nil && o.ClientOptions == nil, "must specify either a client or client options")
+	catcher.NewWhen(o.Client != nil && o.ClientOptions != nil, "cannot specify both a client and client options")
+	for i, f := range o.DefaultListFilters {
+		catcher.ErrorfWhen(f.Key == "", "default list filter at index %d must specify a key", i)
+		catcher.ErrorfWhen(len(f.Values) == 0, "default list filter at index %d must specify at least one value", i)
+	}
+	catcher.NewWhen(utility.FromBoolPtr(o.AllowUnencryptedValues) && o.DataKeyProvider == nil, "cannot allow unencrypted values without a data key provider")
 	if catcher.HasErrors() {
 		return catcher.Resolve()
 	}
@@ -68,55 +178,65 @@ func NewBasicSecretsManager(opts BasicSecretsManagerOptions) (*BasicSecretsManag
 	if err := opts.Validate(); err != nil {
 		return nil, errors.Wrap(err, "invalid options")
 	}
-	return &BasicSecretsManager{
-		client: opts.Client,
-		cache:  opts.Cache,
-	}, nil
+	m := &BasicSecretsManager{
+		client:                         opts.Client,
+		cache:                          opts.Cache,
+		defaultListFilters:             opts.DefaultListFilters,
+		recreateIfScheduledForDeletion: utility.FromBoolPtr(opts.RecreateIfScheduledForDeletion),
+		dataKeyProvider:                opts.DataKeyProvider,
+		allowUnencryptedValues:         utility.FromBoolPtr(opts.AllowUnencryptedValues),
+	}
+	if opts.ClientOptions != nil {
+		m.ownedClient = newLazyBasicSecretsManagerClient(*opts.ClientOptions)
+		m.client = m.ownedClient
+	}
+	return m, nil
+}
+
+// Close closes the client if the vault constructed it from client options.
+// Clients that were given to the vault are owned by the caller and are not
+// closed. The vault should not be used after it is closed.
+func (m *BasicSecretsManager) Close(ctx context.Context) error {
+	if m.ownedClient == nil {
+		return nil
+	}
+	return errors.Wrap(m.ownedClient.Close(ctx), "closing Secrets Manager client")
 }
 
 // CreateSecret creates a new secret and adds it to the cache if it is using
 // one. If the secret already exists, it will return the secret ID without
 // modifying the secret value. To update an existing secret, see UpdateValue.
+// If the secret already exists but is scheduled for deletion, it fails unless
+// the vault is configured to recreate it.
 func (m *BasicSecretsManager) CreateSecret(ctx context.Context, s cocoa.NamedSecret) (id string, err error) {
 	if err := s.Validate(); err != nil {
 		return "", errors.Wrap(err, "invalid secret")
 	}
+	val, err := m.storedValue(ctx, *s.Name, *s.Value)
+	if err != nil {
+		return "", err
+	}
 	in := &secretsmanager.CreateSecretInput{
 		Name:         s.Name,
-		SecretString: s.Value,
+		SecretString: &val,
 	}
+	tags := cocoa.NewTags().Add(s.Tags)
 	if m.usesCache() {
 		// If the secret needs to be cached, we could successfully create a
 		// cloud secret but fail to cache it. Adding a tag makes it possible to
 		// track whether the secret has been created but has not been
 		// successfully cached. In that case, the application can query Secrets
 		// Manager for secrets that are tagged as untracked to clean them up.
-		in.Tags = ExportTags(map[string]string{m.getCacheTag(): strconv.FormatBool(false)})
+		tags.Set(m.getCacheTag(), strconv.FormatBool(false))
 	}
+	in.Tags = ExportTags(tags)
 
-	out, err := m.client.CreateSecret(ctx, in)
+	arn, created, err := m.createOrFindSecret(ctx, in)
 	if err != nil {
-		var resourceExistsError *types.ResourceExistsException
-		if errors.As(err, &resourceExistsError) {
-			// The secret already exists, so describe it to get the ARN.
-			describeOut, err := m.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: s.Name})
-			if err != nil {
-				return "", errors.Wrap(err, "describing already-existing secret")
-			}
-			if describeOut == nil || describeOut.ARN == nil {
-				return "", errors.New("expected an ID for an already-existing secret in the response, but none was returned from Secrets Manager")
-			}
-			return *describeOut.ARN, nil
-		}
 		return "", err
 	}
-	if out == nil || out.ARN == nil {
-		return "", errors.New("expected an ID in the response, but none was returned from Secrets Manager")
-	}
-
-	arn := utility.FromStringPtr(out.ARN)
 
-	if !m.usesCache() {
+	if !created || !m.usesCache() {
 		return arn, nil
 	}
 
@@ -141,6 +261,61 @@ func (m *BasicSecretsManager) CreateSecret(ctx context.Context, s cocoa.NamedSec
 	return arn, nil
 }
 
+// createOrFindSecret creates the secret if it does not exist yet and returns
+// its ID. If a secret with the same name already exists, it returns the
+// existing secret's ID instead and indicates that it did not create it.
+func (m *BasicSecretsManager) createOrFindSecret(ctx context.Context, in *secretsmanager.CreateSecretInput) (id string, created bool, err error) {
+	out, err := m.client.CreateSecret(ctx, in)
+	if err != nil {
+		var resourceExistsError *types.ResourceExistsException
+		if !errors.As(err, &resourceExistsError) {
+			return "", false, err
+		}
+
+		// The secret already exists, so describe it to get the ARN.
+		describeOut, err := m.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: in.Name})
+		if err != nil {
+			return "", false, errors.Wrap(err, "describing already-existing secret")
+		}
+		if describeOut == nil || describeOut.ARN == nil {
+			return "", false, errors.New("expected an ID for an already-existing secret in the response, but none was returned from Secrets Manager")
+		}
+		if utility.FromTimePtr(describeOut.DeletedDate).IsZero() {
+			return *describeOut.ARN, false, nil
+		}
+
+		if !m.recreateIfScheduledForDeletion {
+			return "", false, errors.Errorf("secret '%s' already exists and is scheduled for deletion", utility.FromStringPtr(in.Name))
+		}
+		if out, err = m.recreateSecret(ctx, *describeOut.ARN, in); err != nil {
+			return "", false, errors.Wrapf(err, "recreating secret '%s' that is scheduled for deletion", utility.FromStringPtr(in.Name))
+		}
+	}
+	if out == nil || out.ARN == nil {
+		return "", false, errors.New("expected an ID in the response, but none was returned from Secrets Manager")
+	}
+
+	return *out.ARN, true, nil
+}
+
+// recreateSecret deletes the existing secret that's scheduled for deletion
+// without recovery so that its name can be reused, then creates the new secret.
+func (m *BasicSecretsManager) recreateSecret(ctx context.Context, id string, in *secretsmanager.CreateSecretInput) (*secretsmanager.CreateSecretOutput, error) {
+	if _, err := m.client.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+		ForceDeleteWithoutRecovery: aws.Bool(true),
+		SecretId:                   &id,
+	}); err != nil {
+		return nil, errors.Wrap(err, "force deleting secret")
+	}
+
+	out, err := m.client.CreateSecret(ctx, in)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating secret")
+	}
+
+	return out, nil
+}
+
 // GetValue returns an existing secret's decrypted value.
 func (m *BasicSecretsManager) GetValue(ctx context.Context, id string) (val string, err error) {
 	if id == "" {
@@ -154,7 +329,124 @@ func (m *BasicSecretsManager) GetValue(ctx context.Context, id string) (val stri
 	if out == nil || out.SecretString == nil {
 		return "", errors.New("expected a value in the response, but none was returned from Secrets Manager")
 	}
-	return *out.SecretString, nil
+	if m.dataKeyProvider == nil {
+		return *out.SecretString, nil
+	}
+
+	val, err = decryptValue(ctx, m.dataKeyProvider, utility.FromStringPtr(out.Name), *out.SecretString, m.allowUnencryptedValues)
+	if err != nil {
+		return "", errors.Wrapf(err, "decrypting value of secret '%s'", id)
+	}
+	return val, nil
+}
+
+// FindSecretID returns the ID of the existing secret with the given name. If
+// no such secret exists, it returns an empty ID.
+func (m *BasicSecretsManager) FindSecretID(ctx context.Context, name string) (id string, err error) {
+	if name == "" {
+		return "", errors.New("must specify a non-empty name")
+	}
+
+	out, err := m.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: &name})
+	if err != nil {
+		var notFoundError *types.ResourceNotFoundException
+		if errors.As(err, &notFoundError) {
+			return "", nil
+		}
+		return "", err
+	}
+	if out == nil || out.ARN == nil {
+		return "", errors.New("expected an ID in the response, but none was returned from Secrets Manager")
+	}
+	return *out.ARN, nil
+}
+
+// DescribeSecret returns the metadata for an existing secret, including its
+// rotation configuration.
+func (m *BasicSecretsManager) DescribeSecret(ctx context.Context, id string) (*cocoa.SecretMetadata, error) {
+	if id == "" {
+		return nil, errors.New("must specify a non-empty ID")
+	}
+
+	out, err := m.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: &id})
+	if err != nil {
+		return nil, err
+	}
+	if out == nil || out.ARN == nil {
+		return nil, errors.New("expected an ID in the response, but none was returned from Secrets Manager")
+	}
+
+	md := cocoa.SecretMetadata{
+		ID:                *out.ARN,
+		Name:              utility.FromStringPtr(out.Name),
+		Created:           utility.FromTimePtr(out.CreatedDate),
+		LastChanged:       utility.FromTimePtr(out.LastChangedDate),
+		RotationEnabled:   utility.FromBoolPtr(out.RotationEnabled),
+		RotationLambdaARN: utility.FromStringPtr(out.RotationLambdaARN),
+		LastRotated:       utility.FromTimePtr(out.LastRotatedDate),
+		Deleted:           utility.FromTimePtr(out.DeletedDate),
+	}
+	if out.RotationRules != nil {
+		md.RotationIntervalDays = int(utility.FromInt64Ptr(out.RotationRules.AutomaticallyAfterDays))
+	}
+
+	return &md, nil
+}
+
+// EnableRotation configures an existing secret to rotate automatically using
+// the given Lambda function and schedule.
+func (m *BasicSecretsManager) EnableRotation(ctx context.Context, id string, opts cocoa.SecretRotationOptions) error {
+	if id == "" {
+		return errors.New("must specify a non-empty ID")
+	}
+	if err := opts.Validate(); err != nil {
+		return errors.Wrap(err, "invalid rotation options")
+	}
+
+	_, err := m.client.RotateSecret(ctx, &secretsmanager.RotateSecretInput{
+		SecretId:          &id,
+		RotationLambdaARN: opts.LambdaARN,
+		RotationRules: &types.RotationRulesType{
+			AutomaticallyAfterDays: aws.Int64(int64(utility.FromIntPtr(opts.IntervalDays))),
+		},
+		RotateImmediately: opts.RotateImmediately,
+	})
+	return err
+}
+
+// DisableRotation stops an existing secret from rotating automatically.
+func (m *BasicSecretsManager) DisableRotation(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("must specify a non-empty ID")
+	}
+
+	_, err := m.client.CancelRotateSecret(ctx, &secretsmanager.CancelRotateSecretInput{SecretId: &id})
+	return err
+}
+
+// ListSecrets returns the metadata for all the secrets that match the given
+// filters as well as the vault's default list filters.
+func (m *BasicSecretsManager) ListSecrets(ctx context.Context, filters ...types.Filter) ([]types.SecretListEntry, error) {
+	in := &secretsmanager.ListSecretsInput{
+		Filters: append(append([]types.Filter{}, m.defaultListFilters...), filters...),
+	}
+
+	var secrets []types.SecretListEntry
+	for {
+		out, err := m.client.ListSecrets(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			return nil, errors.New("expected a response, but none was returned from Secrets Manager")
+		}
+		secrets = append(secrets, out.SecretList...)
+
+		if out.NextToken == nil {
+			return secrets, nil
+		}
+		in.NextToken = out.NextToken
+	}
 }
 
 // UpdateValue updates an existing secret's value.
@@ -162,9 +454,27 @@ func (m *BasicSecretsManager) UpdateValue(ctx context.Context, s cocoa.NamedSecr
 	if err := s.Validate(); err != nil {
 		return errors.Wrap(err, "invalid secret")
 	}
-	_, err := m.client.UpdateSecretValue(ctx, &secretsmanager.UpdateSecretInput{
+	name := *s.Name
+	if m.dataKeyProvider != nil && cocoa.IsSecretARN(name) {
+		// The encrypted value is bound to the secret's name, which cannot be
+		// determined from the ARN alone because of the random suffix that
+		// Secrets Manager appends to it.
+		out, err := m.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{SecretId: s.Name})
+		if err != nil {
+			return errors.Wrapf(err, "describing secret '%s' to get its name", name)
+		}
+		if out == nil || out.Name == nil {
+			return errors.New("expected a name in the response, but none was returned from Secrets Manager")
+		}
+		name = *out.Name
+	}
+	val, err := m.storedValue(ctx, name, *s.Value)
+	if err != nil {
+		return err
+	}
+	_, err = m.client.UpdateSecretValue(ctx, &secretsmanager.UpdateSecretInput{
 		SecretId:     s.Name,
-		SecretString: s.Value,
+		SecretString: &val,
 	})
 	return err
 }
@@ -194,6 +504,26 @@ func (m *BasicSecretsManager) DeleteSecret(ctx context.Context, id string) error
 	return nil
 }
 
+// storedValue returns the form of the value of the secret with the given name
+// that is stored in Secrets Manager, which is encrypted if the vault has a data
+// key provider.
+func (m *BasicSecretsManager) storedValue(ctx context.Context, name, val string) (string, error) {
+	if m.dataKeyProvider == nil {
+		return val, nil
+	}
+	encrypted, err := encryptValue(ctx, m.dataKeyProvider, name, val)
+	if err != nil {
+		return "", errors.Wrap(err, "encrypting secret value")
+	}
+	return encrypted, nil
+}
+
+// EncryptsValues returns whether or not the vault encrypts secret values before
+// it stores them, which it does if it has a data key provider.
+func (m *BasicSecretsManager) EncryptsValues() bool {
+	return m.dataKeyProvider != nil
+}
+
 func (m *BasicSecretsManager) usesCache() bool {
 	return m.cache != nil
 }
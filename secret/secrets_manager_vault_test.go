@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/evergreen-ci/cocoa"
 	"github.com/evergreen-ci/cocoa/internal/testcase"
 	"github.com/evergreen-ci/cocoa/internal/testutil"
@@ -14,6 +15,7 @@ import (
 
 func TestBasicSecretsManager(t *testing.T) {
 	assert.Implements(t, (*cocoa.Vault)(nil), &BasicSecretsManager{})
+	assert.Implements(t, (*cocoa.SecretFinder)(nil), &BasicSecretsManager{})
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -34,6 +36,30 @@ func TestBasicSecretsManager(t *testing.T) {
 			assert.NoError(t, err)
 			assert.NotZero(t, sm)
 		})
+		t.Run("SucceedsWithClientOptions", func(t *testing.T) {
+			sm, err := NewBasicSecretsManager(*NewBasicSecretsManagerOptions().SetClientOptions(testutil.ValidNonIntegrationAWSOptions()))
+			assert.NoError(t, err)
+			require.NotZero(t, sm)
+			assert.NotZero(t, sm.client)
+			assert.Equal(t, sm.ownedClient, sm.client)
+		})
+	})
+	t.Run("Close", func(t *testing.T) {
+		t.Run("ClosesOwnedClient", func(t *testing.T) {
+			sm, err := NewBasicSecretsManager(*NewBasicSecretsManagerOptions().SetClientOptions(testutil.ValidNonIntegrationAWSOptions()))
+			require.NoError(t, err)
+			require.NotZero(t, sm.ownedClient)
+			assert.NoError(t, sm.Close(ctx))
+			assert.NoError(t, sm.Close(ctx), "closing should be idempotent")
+		})
+		t.Run("DoesNotOwnGivenClient", func(t *testing.T) {
+			c, err := NewBasicSecretsManagerClient(ctx, testutil.ValidNonIntegrationAWSOptions())
+			require.NoError(t, err)
+			sm, err := NewBasicSecretsManager(*NewBasicSecretsManagerOptions().SetClient(c))
+			require.NoError(t, err)
+			assert.Zero(t, sm.ownedClient)
+			assert.NoError(t, sm.Close(ctx))
+		})
 	})
 }
 
@@ -88,17 +114,56 @@ func TestBasicSecretsManagerOptions(t *testing.T) {
 		opts := NewBasicSecretsManagerOptions().SetClient(c)
 		assert.Equal(t, c, opts.Client)
 	})
+	t.Run("SetClientOptions", func(t *testing.T) {
+		clientOpts := testutil.ValidNonIntegrationAWSOptions()
+		opts := NewBasicSecretsManagerOptions().SetClientOptions(clientOpts)
+		require.NotZero(t, opts.ClientOptions)
+		assert.Equal(t, clientOpts, *opts.ClientOptions)
+	})
 	t.Run("SetCache", func(t *testing.T) {
 		sc := &testutil.NoopSecretCache{}
 		opts := NewBasicSecretsManagerOptions().SetCache(sc)
 		require.NotZero(t, opts.Cache)
 		assert.Equal(t, sc, opts.Cache)
 	})
+	t.Run("SetDefaultListFilters", func(t *testing.T) {
+		filters := []types.Filter{{Key: types.FilterNameStringTypeTagKey, Values: []string{"key"}}}
+		opts := NewBasicSecretsManagerOptions().SetDefaultListFilters(filters)
+		assert.Equal(t, filters, opts.DefaultListFilters)
+	})
+	t.Run("AddDefaultListFilters", func(t *testing.T) {
+		filter0 := types.Filter{Key: types.FilterNameStringTypeTagKey, Values: []string{"key"}}
+		filter1 := types.Filter{Key: types.FilterNameStringTypeTagValue, Values: []string{"value"}}
+		opts := NewBasicSecretsManagerOptions().AddDefaultListFilters(filter0).AddDefaultListFilters(filter1)
+		assert.Equal(t, []types.Filter{filter0, filter1}, opts.DefaultListFilters)
+	})
+	t.Run("SetAllowUnencryptedValues", func(t *testing.T) {
+		opts := NewBasicSecretsManagerOptions().SetAllowUnencryptedValues(true)
+		assert.True(t, utility.FromBoolPtr(opts.AllowUnencryptedValues))
+	})
 	t.Run("Validate", func(t *testing.T) {
 		t.Run("FailsWithEmpty", func(t *testing.T) {
 			opts := NewBasicSecretsManagerOptions()
 			assert.Error(t, opts.Validate())
 		})
+		t.Run("SucceedsWithDefaultListFilters", func(t *testing.T) {
+			opts := NewBasicSecretsManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				AddDefaultListFilters(types.Filter{Key: types.FilterNameStringTypeTagKey, Values: []string{"key"}})
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithDefaultListFilterMissingKey", func(t *testing.T) {
+			opts := NewBasicSecretsManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				AddDefaultListFilters(types.Filter{Values: []string{"key"}})
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithDefaultListFilterMissingValues", func(t *testing.T) {
+			opts := NewBasicSecretsManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				AddDefaultListFilters(types.Filter{Key: types.FilterNameStringTypeTagKey})
+			assert.Error(t, opts.Validate())
+		})
 		t.Run("SucceedsWithAllFieldsPopulated", func(t *testing.T) {
 			smClient, err := NewBasicSecretsManagerClient(ctx, testutil.ValidNonIntegrationAWSOptions())
 			require.NoError(t, err)
@@ -112,5 +177,32 @@ func TestBasicSecretsManagerOptions(t *testing.T) {
 				SetCache(&testutil.NoopSecretCache{})
 			assert.Error(t, opts.Validate())
 		})
+		t.Run("SucceedsWithClientOptionsInsteadOfClient", func(t *testing.T) {
+			opts := NewBasicSecretsManagerOptions().SetClientOptions(testutil.ValidNonIntegrationAWSOptions())
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("SucceedsWithAllowUnencryptedValuesAndDataKeyProvider", func(t *testing.T) {
+			p, err := NewAEADDataKeyProvider(make([]byte, 32))
+			require.NoError(t, err)
+			opts := NewBasicSecretsManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetDataKeyProvider(p).
+				SetAllowUnencryptedValues(true)
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("FailsWithAllowUnencryptedValuesWithoutDataKeyProvider", func(t *testing.T) {
+			opts := NewBasicSecretsManagerOptions().
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions()).
+				SetAllowUnencryptedValues(true)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("FailsWithBothClientAndClientOptions", func(t *testing.T) {
+			smClient, err := NewBasicSecretsManagerClient(ctx, testutil.ValidNonIntegrationAWSOptions())
+			require.NoError(t, err)
+			opts := NewBasicSecretsManagerOptions().
+				SetClient(smClient).
+				SetClientOptions(testutil.ValidNonIntegrationAWSOptions())
+			assert.Error(t, opts.Validate())
+		})
 	})
 }
@@ -0,0 +1,9 @@
+package cocoa
+
+import "strings"
+
+// IsSecretARN returns whether or not the secret identifier is an ARN rather than
+// a secret name. ARNs can be parsed and validated with the identity package.
+func IsSecretARN(id string) bool {
+	return strings.HasPrefix(id, "arn:")
+}
@@ -0,0 +1,14 @@
+package cocoa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSecretARN(t *testing.T) {
+	assert.True(t, IsSecretARN("arn:aws:secretsmanager:us-east-1:123456789012:secret:name"))
+	assert.True(t, IsSecretARN("arn:malformed"))
+	assert.False(t, IsSecretARN("name"))
+	assert.False(t, IsSecretARN(""))
+}
@@ -0,0 +1,91 @@
+package cocoa
+
+import "github.com/evergreen-ci/utility"
+
+// ContainerSecrets returns the secrets that the container definition
+// references, including its log configuration secrets and repository
+// credentials. Each secret is owned by the container if its secret options
+// specify that it is owned.
+func (d *ECSContainerDefinition) ContainerSecrets() []ContainerSecret {
+	var secrets []ContainerSecret
+
+	secretEnvVars := d.EnvVars
+	if d.LogConfiguration != nil {
+		secretEnvVars = append(append([]EnvironmentVariable{}, d.EnvVars...), d.LogConfiguration.SecretOptions...)
+	}
+	for _, envVar := range secretEnvVars {
+		if envVar.SecretOpts == nil {
+			continue
+		}
+		secrets = append(secrets, newOwnedContainerSecret(envVar.SecretOpts.ID, envVar.SecretOpts.Name, envVar.SecretOpts.Owned))
+	}
+
+	if d.RepoCreds != nil {
+		secrets = append(secrets, newOwnedContainerSecret(d.RepoCreds.ID, d.RepoCreds.Name, d.RepoCreds.Owned))
+	}
+
+	return secrets
+}
+
+// ContainerSecrets returns the secrets that all of the pod definition's
+// containers reference, along with whether or not each secret is owned.
+func (o *ECSPodDefinitionOptions) ContainerSecrets() []ContainerSecret {
+	var secrets []ContainerSecret
+	for _, def := range o.ContainerDefinitions {
+		secrets = append(secrets, def.ContainerSecrets()...)
+	}
+	return secrets
+}
+
+// newOwnedContainerSecret returns a container secret with the given ID, name,
+// and ownership.
+func newOwnedContainerSecret(id, name *string, owned *bool) ContainerSecret {
+	cs := NewContainerSecret().
+		SetID(utility.FromStringPtr(id)).
+		SetOwned(utility.FromBoolPtr(owned))
+	if name := utility.FromStringPtr(name); name != "" {
+		cs.SetName(name)
+	}
+	return *cs
+}
+
+// ApplySecretOwnership recomputes whether or not each of the pod's container
+// secrets is owned according to the pod definition options that created the
+// pod. A secret is owned only if the pod definition options reference it and
+// specify that it is owned. This is useful to keep external records of which
+// secrets must be cleaned up in sync with what cocoa owns. The resources,
+// including secret ownership, can be serialized for such records with
+// NewECSPodState.
+func (r *ECSPodResources) ApplySecretOwnership(opts ECSPodDefinitionOptions) {
+	owned := map[string]bool{}
+	for _, s := range opts.ContainerSecrets() {
+		if id := utility.FromStringPtr(s.ID); id != "" {
+			owned[id] = owned[id] || utility.FromBoolPtr(s.Owned)
+		}
+	}
+
+	for i := range r.Containers {
+		for j := range r.Containers[i].Secrets {
+			s := &r.Containers[i].Secrets[j]
+			s.SetOwned(owned[utility.FromStringPtr(s.ID)])
+		}
+	}
+}
+
+// OwnedSecretIDs returns the unique IDs of all the secrets that are owned by
+// the pod's containers.
+func (r *ECSPodResources) OwnedSecretIDs() []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, c := range r.Containers {
+		for _, s := range c.Secrets {
+			id := utility.FromStringPtr(s.ID)
+			if id == "" || seen[id] || !utility.FromBoolPtr(s.Owned) {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,89 @@
+package cocoa
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretOwnership(t *testing.T) {
+	newPodDefOpts := func() ECSPodDefinitionOptions {
+		return *NewECSPodDefinitionOptions().AddContainerDefinitions(
+			*NewECSContainerDefinition().
+				AddEnvironmentVariables(
+					*NewEnvironmentVariable().SetName("owned").SetSecretOptions(*NewSecretOptions().SetID("owned_id").SetName("owned_name").SetOwned(true)),
+					*NewEnvironmentVariable().SetName("unowned").SetSecretOptions(*NewSecretOptions().SetID("unowned_id")),
+					*NewEnvironmentVariable().SetName("plaintext").SetValue("value"),
+				).
+				SetRepositoryCredentials(*NewRepositoryCredentials().SetID("creds_id").SetOwned(true)),
+		)
+	}
+
+	t.Run("ContainerSecretsIncludesAllReferencedSecrets", func(t *testing.T) {
+		opts := newPodDefOpts()
+		secrets := opts.ContainerSecrets()
+		require.Len(t, secrets, 3)
+
+		assert.Equal(t, "owned_id", utility.FromStringPtr(secrets[0].ID))
+		assert.Equal(t, "owned_name", utility.FromStringPtr(secrets[0].Name))
+		assert.True(t, utility.FromBoolPtr(secrets[0].Owned))
+
+		assert.Equal(t, "unowned_id", utility.FromStringPtr(secrets[1].ID))
+		assert.Nil(t, secrets[1].Name)
+		assert.False(t, utility.FromBoolPtr(secrets[1].Owned))
+
+		assert.Equal(t, "creds_id", utility.FromStringPtr(secrets[2].ID))
+		assert.True(t, utility.FromBoolPtr(secrets[2].Owned))
+	})
+	t.Run("ApplySecretOwnershipRecomputesOwnership", func(t *testing.T) {
+		res := NewECSPodResources().AddContainers(*NewECSContainerResources().
+			SetName("container").
+			AddSecrets(
+				*NewContainerSecret().SetID("owned_id"),
+				*NewContainerSecret().SetID("unowned_id").SetOwned(true),
+				*NewContainerSecret().SetID("unreferenced_id").SetOwned(true),
+			))
+
+		res.ApplySecretOwnership(newPodDefOpts())
+
+		secrets := res.Containers[0].Secrets
+		require.Len(t, secrets, 3)
+		assert.True(t, utility.FromBoolPtr(secrets[0].Owned))
+		assert.False(t, utility.FromBoolPtr(secrets[1].Owned))
+		assert.False(t, utility.FromBoolPtr(secrets[2].Owned))
+		assert.Equal(t, []string{"owned_id"}, res.OwnedSecretIDs())
+	})
+	t.Run("OwnedSecretIDsDeduplicatesAcrossContainers", func(t *testing.T) {
+		secret := *NewContainerSecret().SetID("owned_id").SetOwned(true)
+		res := NewECSPodResources().AddContainers(
+			*NewECSContainerResources().SetName("container0").AddSecrets(secret),
+			*NewECSContainerResources().SetName("container1").AddSecrets(secret, *NewContainerSecret().SetID("unowned_id")),
+		)
+		assert.Equal(t, []string{"owned_id"}, res.OwnedSecretIDs())
+	})
+	t.Run("StateJSONRoundTripPreservesOwnership", func(t *testing.T) {
+		res := NewECSPodResources().
+			SetTaskID("task_id").
+			SetCluster("cluster").
+			SetTaskDefinition(*NewECSTaskDefinition().SetID("task_def_id").SetOwned(true)).
+			AddContainers(*NewECSContainerResources().
+				SetContainerID("container_id").
+				SetName("container").
+				AddSecrets(
+					*NewContainerSecret().SetID("owned_id").SetName("owned_name").SetOwned(true),
+					*NewContainerSecret().SetID("unowned_id").SetOwned(false),
+				))
+
+		b, err := json.Marshal(NewECSPodState(*res, ECSPodStatusInfo{}, ECSPodProtectionPolicy{}))
+		require.NoError(t, err)
+		assert.Contains(t, string(b), `"task_id":"task_id"`)
+		assert.Contains(t, string(b), `"owned":true`)
+
+		var state ECSPodState
+		require.NoError(t, json.Unmarshal(b, &state))
+		assert.Equal(t, *res, state.Resources())
+	})
+}
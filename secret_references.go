@@ -0,0 +1,145 @@
+package cocoa
+
+import (
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+// SecretReferenceSource is the part of a container definition that refers to a
+// secret.
+type SecretReferenceSource string
+
+const (
+	// SecretReferenceSourceEnvVar is a secret that is the value of an
+	// environment variable.
+	SecretReferenceSourceEnvVar SecretReferenceSource = "env-var"
+	// SecretReferenceSourceRepoCreds is a secret that contains the
+	// credentials to pull the container's image from a private repository.
+	SecretReferenceSourceRepoCreds SecretReferenceSource = "repository-credentials"
+	// SecretReferenceSourceLogConfig is a secret that is the value of a
+	// logging driver option.
+	SecretReferenceSourceLogConfig SecretReferenceSource = "log-configuration"
+)
+
+// SecretReference describes a single secret that a pod definition refers to.
+// It never contains the value of a new secret.
+type SecretReference struct {
+	// ContainerName is the name of the container that refers to the secret.
+	ContainerName string
+	// Source is the part of the container definition that refers to the
+	// secret.
+	Source SecretReferenceSource
+	// Key is the name of the environment variable or logging driver option
+	// whose value is the secret. This is empty for repository credentials.
+	Key string
+	// ID is the unique resource identifier of an existing secret. This is
+	// empty if the secret must be created.
+	ID string
+	// Name is the friendly name of the secret, if any.
+	Name string
+	// New is whether or not the secret will be created when the pod is
+	// created.
+	New bool
+	// Deferred is whether or not the secret's value is only given when the
+	// pod is created. A deferred secret is created with a placeholder value.
+	Deferred bool
+	// CreateIfMissing is whether or not the new secret will only be created
+	// if no secret with the same name already exists. If the secret already
+	// exists, it is used instead.
+	CreateIfMissing bool
+	// Owned is whether or not the secret is owned by the pod, in which case
+	// it is cleaned up along with the pod.
+	Owned bool
+	// Tags are the tags explicitly given for the secret if it is created.
+	Tags Tags
+}
+
+// WalkSecretReferences calls fn for every secret that the pod definition
+// refers to, in the order of the container definitions. Within each container
+// definition, the secret environment variables are visited first, followed by
+// the repository credentials and the secret logging driver options.
+// Repository credentials that are skipped for the container's public image are
+// neither created nor used, so they are not visited. If fn returns an error,
+// the walk stops and returns that error.
+//
+// The pod definition options are not validated, so unnamed containers will
+// have an empty container name. It does not create or modify any resources,
+// so it can be used to audit a pod definition before creating it.
+func WalkSecretReferences(opts ECSPodDefinitionOptions, fn func(SecretReference) error) error {
+	if fn == nil {
+		return errors.New("must specify a function to call for each secret reference")
+	}
+
+	for _, def := range opts.ContainerDefinitions {
+		containerName := utility.FromStringPtr(def.Name)
+
+		for _, envVar := range def.EnvVars {
+			if envVar.SecretOpts == nil {
+				continue
+			}
+			if err := fn(newSecretReference(containerName, SecretReferenceSourceEnvVar, utility.FromStringPtr(envVar.Name), *envVar.SecretOpts)); err != nil {
+				return err
+			}
+		}
+
+		if def.UsesRepositoryCredentials() {
+			if err := fn(newRepoCredsSecretReference(containerName, *def.RepoCreds)); err != nil {
+				return err
+			}
+		}
+
+		if def.LogConfiguration != nil {
+			for _, opt := range def.LogConfiguration.SecretOptions {
+				if opt.SecretOpts == nil {
+					continue
+				}
+				if err := fn(newSecretReference(containerName, SecretReferenceSourceLogConfig, utility.FromStringPtr(opt.Name), *opt.SecretOpts)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// CollectSecretReferences returns all the secrets that the pod definition
+// refers to (see WalkSecretReferences).
+func CollectSecretReferences(opts ECSPodDefinitionOptions) []SecretReference {
+	var refs []SecretReference
+	_ = WalkSecretReferences(opts, func(ref SecretReference) error {
+		refs = append(refs, ref)
+		return nil
+	})
+	return refs
+}
+
+// newSecretReference returns a reference to the secret described by the secret
+// options.
+func newSecretReference(containerName string, source SecretReferenceSource, key string, opts SecretOptions) SecretReference {
+	return SecretReference{
+		ContainerName: containerName,
+		Source:        source,
+		Key:           key,
+		ID:            utility.FromStringPtr(opts.ID),
+		Name:          utility.FromStringPtr(opts.Name),
+		New:           opts.NewValue != nil || (utility.FromBoolPtr(opts.Deferred) && opts.ID == nil),
+		Deferred:      utility.FromBoolPtr(opts.Deferred),
+		Owned:         utility.FromBoolPtr(opts.Owned),
+		Tags:          opts.Tags,
+	}
+}
+
+// newRepoCredsSecretReference returns a reference to the secret containing the
+// repository credentials.
+func newRepoCredsSecretReference(containerName string, creds RepositoryCredentials) SecretReference {
+	return SecretReference{
+		ContainerName:   containerName,
+		Source:          SecretReferenceSourceRepoCreds,
+		ID:              utility.FromStringPtr(creds.ID),
+		Name:            utility.FromStringPtr(creds.Name),
+		New:             creds.NewCreds != nil,
+		CreateIfMissing: utility.FromBoolPtr(creds.CreateIfMissing),
+		Owned:           utility.FromBoolPtr(creds.Owned),
+	}
+}
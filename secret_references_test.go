@@ -0,0 +1,127 @@
+package cocoa
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectSecretReferences(t *testing.T) {
+	makeOpts := func() ECSPodDefinitionOptions {
+		first := NewECSContainerDefinition().
+			SetName("first").
+			SetImage("image").
+			AddEnvironmentVariables(
+				*NewEnvironmentVariable().SetName("plain").SetValue("value"),
+				*NewEnvironmentVariable().SetName("existing").SetSecretOptions(*NewSecretOptions().
+					SetID("existing_id")),
+				*NewEnvironmentVariable().SetName("new").SetSecretOptions(*NewSecretOptions().
+					SetName("new_secret").
+					SetNewValue("super_secret").
+					SetOwned(true).
+					SetTags(map[string]string{"key": "value"})),
+			).
+			SetRepositoryCredentials(*NewRepositoryCredentials().
+				SetName("repo_creds").
+				SetNewCredentials(*NewStoredRepositoryCredentials().SetUsername("username").SetPassword("password")).
+				SetCreateIfMissing(true).
+				SetOwned(true))
+		second := NewECSContainerDefinition().
+			SetName("second").
+			SetImage("public.ecr.aws/image").
+			SetRepositoryCredentials(*NewRepositoryCredentials().
+				SetID("skipped_repo_creds").
+				SetSkipForPublicImages(true)).
+			SetLogConfiguration(*NewLogConfiguration().
+				SetLogDriver("splunk").
+				AddSecretOptions(*NewEnvironmentVariable().SetName("splunk-token").SetSecretOptions(*NewSecretOptions().
+					SetID("log_secret_id"))))
+		return *NewECSPodDefinitionOptions().AddContainerDefinitions(*first, *second)
+	}
+
+	t.Run("ReturnsAllSecretsInOrder", func(t *testing.T) {
+		refs := CollectSecretReferences(makeOpts())
+		require.Len(t, refs, 4)
+
+		assert.Equal(t, SecretReference{
+			ContainerName: "first",
+			Source:        SecretReferenceSourceEnvVar,
+			Key:           "existing",
+			ID:            "existing_id",
+		}, refs[0])
+		assert.Equal(t, SecretReference{
+			ContainerName: "first",
+			Source:        SecretReferenceSourceEnvVar,
+			Key:           "new",
+			Name:          "new_secret",
+			New:           true,
+			Owned:         true,
+			Tags:          Tags{"key": "value"},
+		}, refs[1])
+		assert.Equal(t, SecretReference{
+			ContainerName:   "first",
+			Source:          SecretReferenceSourceRepoCreds,
+			Name:            "repo_creds",
+			New:             true,
+			CreateIfMissing: true,
+			Owned:           true,
+		}, refs[2])
+		assert.Equal(t, SecretReference{
+			ContainerName: "second",
+			Source:        SecretReferenceSourceLogConfig,
+			Key:           "splunk-token",
+			ID:            "log_secret_id",
+		}, refs[3])
+	})
+	t.Run("NeverIncludesNewSecretValues", func(t *testing.T) {
+		for _, ref := range CollectSecretReferences(makeOpts()) {
+			assert.NotContains(t, ref.ID, "super_secret")
+			assert.NotContains(t, ref.Name, "super_secret")
+		}
+	})
+	t.Run("ReturnsNothingWithoutSecrets", func(t *testing.T) {
+		opts := NewECSPodDefinitionOptions().AddContainerDefinitions(*NewECSContainerDefinition().
+			SetImage("image").
+			AddEnvironmentVariables(*NewEnvironmentVariable().SetName("plain").SetValue("value")))
+		assert.Empty(t, CollectSecretReferences(*opts))
+	})
+	t.Run("DoesNotModifyOptions", func(t *testing.T) {
+		opts := makeOpts()
+		_ = CollectSecretReferences(opts)
+		assert.Equal(t, makeOpts(), opts)
+	})
+}
+
+func TestWalkSecretReferences(t *testing.T) {
+	opts := NewECSPodDefinitionOptions().AddContainerDefinitions(*NewECSContainerDefinition().
+		SetName("container").
+		SetImage("image").
+		AddEnvironmentVariables(
+			*NewEnvironmentVariable().SetName("first").SetSecretOptions(*NewSecretOptions().SetID("first_id")),
+			*NewEnvironmentVariable().SetName("second").SetSecretOptions(*NewSecretOptions().SetID("second_id")),
+		))
+
+	t.Run("StopsAtFirstError", func(t *testing.T) {
+		var visited []string
+		err := WalkSecretReferences(*opts, func(ref SecretReference) error {
+			visited = append(visited, ref.ID)
+			return errors.New("denied")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, []string{"first_id"}, visited)
+	})
+	t.Run("VisitsAllSecretsWithoutError", func(t *testing.T) {
+		var visited []string
+		err := WalkSecretReferences(*opts, func(ref SecretReference) error {
+			visited = append(visited, ref.ID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first_id", "second_id"}, visited)
+	})
+	t.Run("FailsWithoutFunction", func(t *testing.T) {
+		assert.Error(t, WalkSecretReferences(*opts, nil))
+	})
+}
@@ -0,0 +1,133 @@
+package cocoa
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// SecretUsageTracker records which pods reference which secrets so that
+// secrets that are no longer referenced by any live pod can be found and
+// safely garbage collected.
+type SecretUsageTracker interface {
+	// TrackPodSecrets records that the pod references the given secrets.
+	TrackPodSecrets(ctx context.Context, podID string, secretIDs []string) error
+	// UntrackPod records that the pod no longer references any secrets (e.g.
+	// because it was deleted). Implementations should ensure that untracking
+	// is idempotent.
+	UntrackPod(ctx context.Context, podID string) error
+	// FindUnreferencedSecrets returns the IDs of the tracked secrets that are
+	// no longer referenced by any live pod.
+	FindUnreferencedSecrets(ctx context.Context) ([]string, error)
+	// ForgetSecrets stops tracking the given secrets (e.g. because they were
+	// garbage collected).
+	ForgetSecrets(ctx context.Context, secretIDs []string) error
+}
+
+// MemorySecretUsageTracker is a SecretUsageTracker that records secret usage
+// in memory. It is safe for concurrent use.
+type MemorySecretUsageTracker struct {
+	mu sync.Mutex
+	// podSecrets maps each pod ID to the IDs of the secrets that it
+	// references.
+	podSecrets map[string][]string
+	// secretPods maps each tracked secret ID to the set of IDs of the pods
+	// that reference it.
+	secretPods map[string]map[string]struct{}
+}
+
+// NewMemorySecretUsageTracker returns a new secret usage tracker that records
+// secret usage in memory.
+func NewMemorySecretUsageTracker() *MemorySecretUsageTracker {
+	return &MemorySecretUsageTracker{
+		podSecrets: map[string][]string{},
+		secretPods: map[string]map[string]struct{}{},
+	}
+}
+
+// TrackPodSecrets records that the pod references the given secrets in
+// addition to any secrets it already references.
+func (t *MemorySecretUsageTracker) TrackPodSecrets(_ context.Context, podID string, secretIDs []string) error {
+	if podID == "" {
+		return errors.New("must specify a pod ID")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, id := range secretIDs {
+		if id == "" {
+			return errors.Errorf("cannot track an empty secret ID for pod '%s'", podID)
+		}
+	}
+	for _, id := range secretIDs {
+		pods, ok := t.secretPods[id]
+		if !ok {
+			pods = map[string]struct{}{}
+			t.secretPods[id] = pods
+		}
+		if _, ok := pods[podID]; ok {
+			continue
+		}
+		pods[podID] = struct{}{}
+		t.podSecrets[podID] = append(t.podSecrets[podID], id)
+	}
+
+	return nil
+}
+
+// UntrackPod records that the pod no longer references any secrets. The
+// secrets that the pod referenced are still tracked so that they can be found
+// if they're no longer referenced by any other pod.
+func (t *MemorySecretUsageTracker) UntrackPod(_ context.Context, podID string) error {
+	if podID == "" {
+		return errors.New("must specify a pod ID")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, id := range t.podSecrets[podID] {
+		delete(t.secretPods[id], podID)
+	}
+	delete(t.podSecrets, podID)
+
+	return nil
+}
+
+// FindUnreferencedSecrets returns the IDs of the tracked secrets that are no
+// longer referenced by any pod, sorted by ID.
+func (t *MemorySecretUsageTracker) FindUnreferencedSecrets(_ context.Context) ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var unreferenced []string
+	for id, pods := range t.secretPods {
+		if len(pods) == 0 {
+			unreferenced = append(unreferenced, id)
+		}
+	}
+	sort.Strings(unreferenced)
+
+	return unreferenced, nil
+}
+
+// ForgetSecrets stops tracking the given secrets. Secrets that are still
+// referenced by a pod cannot be forgotten.
+func (t *MemorySecretUsageTracker) ForgetSecrets(_ context.Context, secretIDs []string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, id := range secretIDs {
+		if len(t.secretPods[id]) != 0 {
+			return errors.Errorf("cannot forget secret '%s' because it is still referenced by %d pod(s)", id, len(t.secretPods[id]))
+		}
+	}
+	for _, id := range secretIDs {
+		delete(t.secretPods, id)
+	}
+
+	return nil
+}
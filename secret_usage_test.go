@@ -0,0 +1,92 @@
+package cocoa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySecretUsageTracker(t *testing.T) {
+	assert.Implements(t, (*SecretUsageTracker)(nil), &MemorySecretUsageTracker{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("FindsNoUnreferencedSecretsWhileReferenced", func(t *testing.T) {
+		tracker := NewMemorySecretUsageTracker()
+		require.NoError(t, tracker.TrackPodSecrets(ctx, "pod0", []string{"secret0", "secret1"}))
+
+		unreferenced, err := tracker.FindUnreferencedSecrets(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, unreferenced)
+	})
+	t.Run("FindsSecretsAfterAllReferencingPodsAreUntracked", func(t *testing.T) {
+		tracker := NewMemorySecretUsageTracker()
+		require.NoError(t, tracker.TrackPodSecrets(ctx, "pod0", []string{"secret0", "shared"}))
+		require.NoError(t, tracker.TrackPodSecrets(ctx, "pod1", []string{"secret1", "shared"}))
+
+		require.NoError(t, tracker.UntrackPod(ctx, "pod0"))
+		unreferenced, err := tracker.FindUnreferencedSecrets(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"secret0"}, unreferenced, "shared secret should still be referenced by the other pod")
+
+		require.NoError(t, tracker.UntrackPod(ctx, "pod1"))
+		unreferenced, err = tracker.FindUnreferencedSecrets(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"secret0", "secret1", "shared"}, unreferenced)
+	})
+	t.Run("TrackingSameSecretTwiceForPodIsIdempotent", func(t *testing.T) {
+		tracker := NewMemorySecretUsageTracker()
+		require.NoError(t, tracker.TrackPodSecrets(ctx, "pod0", []string{"secret0"}))
+		require.NoError(t, tracker.TrackPodSecrets(ctx, "pod0", []string{"secret0"}))
+		assert.Equal(t, []string{"secret0"}, tracker.podSecrets["pod0"])
+
+		require.NoError(t, tracker.UntrackPod(ctx, "pod0"))
+		unreferenced, err := tracker.FindUnreferencedSecrets(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"secret0"}, unreferenced)
+	})
+	t.Run("UntrackPodIsIdempotent", func(t *testing.T) {
+		tracker := NewMemorySecretUsageTracker()
+		require.NoError(t, tracker.TrackPodSecrets(ctx, "pod0", []string{"secret0"}))
+		require.NoError(t, tracker.UntrackPod(ctx, "pod0"))
+		require.NoError(t, tracker.UntrackPod(ctx, "pod0"))
+		assert.NoError(t, tracker.UntrackPod(ctx, "nonexistent"))
+	})
+	t.Run("ForgetSecretsStopsTrackingUnreferencedSecrets", func(t *testing.T) {
+		tracker := NewMemorySecretUsageTracker()
+		require.NoError(t, tracker.TrackPodSecrets(ctx, "pod0", []string{"secret0"}))
+		require.NoError(t, tracker.UntrackPod(ctx, "pod0"))
+
+		require.NoError(t, tracker.ForgetSecrets(ctx, []string{"secret0"}))
+		unreferenced, err := tracker.FindUnreferencedSecrets(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, unreferenced)
+	})
+	t.Run("ForgetSecretsFailsForReferencedSecret", func(t *testing.T) {
+		tracker := NewMemorySecretUsageTracker()
+		require.NoError(t, tracker.TrackPodSecrets(ctx, "pod0", []string{"secret0"}))
+		require.NoError(t, tracker.TrackPodSecrets(ctx, "pod1", []string{"secret1"}))
+		require.NoError(t, tracker.UntrackPod(ctx, "pod1"))
+
+		assert.Error(t, tracker.ForgetSecrets(ctx, []string{"secret1", "secret0"}))
+		unreferenced, err := tracker.FindUnreferencedSecrets(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"secret1"}, unreferenced, "no secrets should be forgotten if any are still referenced")
+	})
+	t.Run("TrackPodSecretsFailsWithoutPodID", func(t *testing.T) {
+		tracker := NewMemorySecretUsageTracker()
+		assert.Error(t, tracker.TrackPodSecrets(ctx, "", []string{"secret0"}))
+	})
+	t.Run("TrackPodSecretsFailsWithEmptySecretID", func(t *testing.T) {
+		tracker := NewMemorySecretUsageTracker()
+		assert.Error(t, tracker.TrackPodSecrets(ctx, "pod0", []string{"secret0", ""}))
+		assert.Empty(t, tracker.podSecrets, "no secrets should be tracked if any are invalid")
+	})
+	t.Run("UntrackPodFailsWithoutPodID", func(t *testing.T) {
+		tracker := NewMemorySecretUsageTracker()
+		assert.Error(t, tracker.UntrackPod(ctx, ""))
+	})
+}
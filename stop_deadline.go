@@ -0,0 +1,64 @@
+package cocoa
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StopDeadlineTagKey is the reserved tag key that records the time by which a
+// pod should be stopped. Since the deadline is stored on the pod itself, any
+// process can find and stop pods that have outlived their deadline without
+// keeping its own record of when each pod was started.
+const StopDeadlineTagKey = "cocoa-stop-deadline"
+
+// stopDeadlineFormat is the format of the stop deadline tag's value.
+const stopDeadlineFormat = time.RFC3339
+
+// SetStopDeadline sets the stop deadline tag. If the tags are nil, it returns
+// new tags containing only the stop deadline tag.
+func (t Tags) SetStopDeadline(deadline time.Time) Tags {
+	return t.Set(StopDeadlineTagKey, deadline.UTC().Format(stopDeadlineFormat))
+}
+
+// GetStopDeadline returns the time in the stop deadline tag. It returns false
+// if there is no stop deadline tag, and an error if the tag's value is not a
+// valid time.
+func (t Tags) GetStopDeadline() (time.Time, bool, error) {
+	val, ok := t[StopDeadlineTagKey]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	deadline, err := time.Parse(stopDeadlineFormat, val)
+	if err != nil {
+		return time.Time{}, true, errors.Wrapf(err, "parsing stop deadline tag '%s'", StopDeadlineTagKey)
+	}
+	return deadline, true, nil
+}
+
+// validateStopDeadline checks that the stop deadline tag, if any, is a valid
+// time.
+func (t Tags) validateStopDeadline() error {
+	_, _, err := t.GetStopDeadline()
+	return err
+}
+
+// SetMaxLifetime sets the maximum amount of time that the pod can run. When
+// the pod is run, the deadline by which it should be stopped is recorded in a
+// reserved tag on the pod. ECS does not stop the pod by itself once the
+// deadline passes; the pod must be stopped by a process that checks the tag
+// (e.g. ecs.ReapExpiredPods).
+func (o *ECSPodExecutionOptions) SetMaxLifetime(d time.Duration) *ECSPodExecutionOptions {
+	o.MaxLifetime = &d
+	return o
+}
+
+// RunTags returns the tags to apply to the pod when it is run at the given
+// time. If the pod has a maximum lifetime, this includes the stop deadline
+// tag.
+func (o *ECSPodExecutionOptions) RunTags(now time.Time) Tags {
+	if o.MaxLifetime == nil {
+		return o.Tags
+	}
+	return NewTags().Add(o.Tags).SetStopDeadline(now.Add(*o.MaxLifetime))
+}
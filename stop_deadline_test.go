@@ -0,0 +1,97 @@
+package cocoa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopDeadline(t *testing.T) {
+	deadline := time.Date(2023, time.June, 1, 12, 30, 0, 0, time.UTC)
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		tags := NewTags().SetStopDeadline(deadline)
+		assert.Equal(t, "2023-06-01T12:30:00Z", tags[StopDeadlineTagKey])
+
+		parsed, ok, err := tags.GetStopDeadline()
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.True(t, deadline.Equal(parsed))
+	})
+	t.Run("SetOnNilTags", func(t *testing.T) {
+		var tags Tags
+		tags = tags.SetStopDeadline(deadline)
+		_, ok, err := tags.GetStopDeadline()
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+	t.Run("GetReturnsFalseWithoutTag", func(t *testing.T) {
+		parsed, ok, err := NewTags().Set("key", "value").GetStopDeadline()
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Zero(t, parsed)
+	})
+	t.Run("GetFailsWithInvalidTag", func(t *testing.T) {
+		parsed, ok, err := NewTags().Set(StopDeadlineTagKey, "tomorrow").GetStopDeadline()
+		assert.Error(t, err)
+		assert.True(t, ok)
+		assert.Zero(t, parsed)
+	})
+}
+
+func TestECSPodExecutionOptionsMaxLifetime(t *testing.T) {
+	t.Run("SetMaxLifetime", func(t *testing.T) {
+		opts := NewECSPodExecutionOptions().SetMaxLifetime(time.Hour)
+		require.NotZero(t, opts.MaxLifetime)
+		assert.Equal(t, time.Hour, *opts.MaxLifetime)
+	})
+	t.Run("RunTagsIncludeStopDeadline", func(t *testing.T) {
+		now := time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC)
+		opts := NewECSPodExecutionOptions().
+			SetTags(map[string]string{"key": "value"}).
+			SetMaxLifetime(30 * time.Minute)
+
+		tags := opts.RunTags(now)
+		assert.Equal(t, "value", tags["key"])
+		deadline, ok, err := tags.GetStopDeadline()
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.True(t, now.Add(30*time.Minute).Equal(deadline))
+
+		_, ok, err = opts.Tags.GetStopDeadline()
+		require.NoError(t, err)
+		assert.False(t, ok, "execution options' own tags should not be modified")
+	})
+	t.Run("RunTagsWithoutMaxLifetime", func(t *testing.T) {
+		opts := NewECSPodExecutionOptions().SetTags(map[string]string{"key": "value"})
+		assert.Equal(t, opts.Tags, opts.RunTags(time.Now()))
+	})
+	t.Run("ValidateSucceedsWithMaxLifetime", func(t *testing.T) {
+		assert.NoError(t, NewECSPodExecutionOptions().SetMaxLifetime(time.Hour).Validate())
+	})
+	t.Run("ValidateFailsWithNonPositiveMaxLifetime", func(t *testing.T) {
+		assert.Error(t, NewECSPodExecutionOptions().SetMaxLifetime(0).Validate())
+		assert.Error(t, NewECSPodExecutionOptions().SetMaxLifetime(-time.Hour).Validate())
+	})
+	t.Run("ValidateFailsWithInvalidStopDeadlineTag", func(t *testing.T) {
+		assert.Error(t, NewECSPodExecutionOptions().SetTags(map[string]string{StopDeadlineTagKey: "tomorrow"}).Validate())
+	})
+	t.Run("MergeOverwritesMaxLifetime", func(t *testing.T) {
+		merged := MergeECSPodExecutionOptions(
+			*NewECSPodExecutionOptions().SetMaxLifetime(time.Hour),
+			*NewECSPodExecutionOptions().SetMaxLifetime(time.Minute),
+			*NewECSPodExecutionOptions(),
+		)
+		require.NotZero(t, merged.MaxLifetime)
+		assert.Equal(t, time.Minute, *merged.MaxLifetime)
+	})
+	t.Run("HashChangesWithMaxLifetime", func(t *testing.T) {
+		base := NewECSPodExecutionOptions().SetCluster("cluster")
+		hour := NewECSPodExecutionOptions().SetCluster("cluster").SetMaxLifetime(time.Hour)
+		minute := NewECSPodExecutionOptions().SetCluster("cluster").SetMaxLifetime(time.Minute)
+		assert.NotEqual(t, base.Hash(), hour.Hash())
+		assert.NotEqual(t, hour.Hash(), minute.Hash())
+	})
+}
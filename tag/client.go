@@ -50,17 +50,38 @@ func (c *BasicTagClient) setup(ctx context.Context) error {
 	return nil
 }
 
+// setupOperation sets up the client and returns the options for a single
+// operation. If the context specifies a role to assume, the operation uses the
+// credentials for that role.
+func (c *BasicTagClient) setupOperation(ctx context.Context) ([]func(*resourcegroupstaggingapi.Options), error) {
+	if err := c.setup(ctx); err != nil {
+		return nil, err
+	}
+
+	creds, err := c.GetContextCredentials(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting credentials for role to assume")
+	}
+	if creds == nil {
+		return nil, nil
+	}
+
+	return []func(*resourcegroupstaggingapi.Options){func(o *resourcegroupstaggingapi.Options) {
+		o.Credentials = creds
+	}}, nil
+}
+
 // GetResources finds arbitrary AWS resources that match the input filters.
 func (c *BasicTagClient) GetResources(ctx context.Context, in *resourcegroupstaggingapi.GetResourcesInput) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
-	if err := c.setup(ctx); err != nil {
+	optFns, err := c.setupOperation(ctx)
+	if err != nil {
 		return nil, errors.Wrap(err, "setting up client")
 	}
 
 	var out *resourcegroupstaggingapi.GetResourcesOutput
-	var err error
 	if err := utility.Retry(ctx, func() (bool, error) {
 		msg := awsutil.MakeAPILogMessage("GetResources", in)
-		out, err = c.rgt.GetResources(ctx, in)
+		out, err = c.rgt.GetResources(ctx, in, optFns...)
 		grip.Debug(message.WrapError(err, msg))
 		if c.isNonRetryableError(err) {
 			return false, err
@@ -68,7 +89,7 @@ func (c *BasicTagClient) GetResources(ctx context.Context, in *resourcegroupstag
 
 		return true, err
 	}, c.GetRetryOptions()); err != nil {
-		return nil, err
+		return nil, awsutil.WrapAPIError(err)
 	}
 	return out, nil
 }
@@ -0,0 +1,131 @@
+package cocoa
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mongodb/grip"
+)
+
+// Tags are resource tags, which map each tag key to its value.
+type Tags map[string]string
+
+// NewTags returns new empty tags.
+func NewTags() Tags {
+	return Tags{}
+}
+
+// Set sets the value for the tag key. If the tags are nil, it returns new tags
+// containing only the given tag.
+func (t Tags) Set(key, value string) Tags {
+	if t == nil {
+		t = Tags{}
+	}
+	t[key] = value
+	return t
+}
+
+// Add adds the given tags to the existing ones. Existing tags with the same key
+// are overwritten. If the tags are nil, it returns new tags containing only the
+// given tags.
+func (t Tags) Add(tags map[string]string) Tags {
+	if t == nil {
+		t = Tags{}
+	}
+	for k, v := range tags {
+		t[k] = v
+	}
+	return t
+}
+
+// Remove removes the tags with the given keys.
+func (t Tags) Remove(keys ...string) Tags {
+	for _, k := range keys {
+		delete(t, k)
+	}
+	return t
+}
+
+// Keys returns the sorted tag keys.
+func (t Tags) Keys() []string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Tagable represents a resource that can be tagged.
+type Tagable interface {
+	// GetTags returns the resource's tags.
+	GetTags() Tags
+}
+
+// TagConstraints are the restrictions that a service places on resource tags.
+type TagConstraints struct {
+	// Service is the name of the service that imposes the constraints.
+	Service string
+	// MaxTags is the maximum number of tags per resource.
+	MaxTags int
+	// MaxKeyLength is the maximum number of characters in a tag key.
+	MaxKeyLength int
+	// MaxValueLength is the maximum number of characters in a tag value.
+	MaxValueLength int
+}
+
+var (
+	// ECSTagConstraints are the tag constraints for ECS resources.
+	ECSTagConstraints = TagConstraints{
+		Service:        "ECS",
+		MaxTags:        50,
+		MaxKeyLength:   128,
+		MaxValueLength: 256,
+	}
+	// SecretsManagerTagConstraints are the tag constraints for Secrets Manager
+	// secrets.
+	SecretsManagerTagConstraints = TagConstraints{
+		Service:        "Secrets Manager",
+		MaxTags:        50,
+		MaxKeyLength:   127,
+		MaxValueLength: 255,
+	}
+
+	// tagCharsRegexp matches tag keys and values that only contain the
+	// characters that are allowed across AWS services, which are letters,
+	// numbers, spaces, and the characters + - = . _ : / @.
+	tagCharsRegexp = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+)
+
+// reservedTagPrefix is the tag prefix that AWS reserves for its own use.
+const reservedTagPrefix = "aws:"
+
+// Validate checks that the tags satisfy the constraints.
+func (c TagConstraints) Validate(tags Tags) error {
+	catcher := grip.NewBasicCatcher()
+	catcher.ErrorfWhen(len(tags) > c.MaxTags, "cannot specify more than %d tags for %s, but got %d", c.MaxTags, c.Service, len(tags))
+	for _, k := range tags.Keys() {
+		v := tags[k]
+		catcher.NewWhen(k == "", "cannot specify an empty tag key")
+		catcher.ErrorfWhen(utf8.RuneCountInString(k) > c.MaxKeyLength, "tag key '%s' cannot be longer than %d characters for %s", k, c.MaxKeyLength, c.Service)
+		catcher.ErrorfWhen(utf8.RuneCountInString(v) > c.MaxValueLength, "value for tag '%s' cannot be longer than %d characters for %s", k, c.MaxValueLength, c.Service)
+		catcher.ErrorfWhen(!tagCharsRegexp.MatchString(k), "tag key '%s' contains invalid characters", k)
+		catcher.ErrorfWhen(!tagCharsRegexp.MatchString(v), "value for tag '%s' contains invalid characters", k)
+		catcher.ErrorfWhen(strings.HasPrefix(strings.ToLower(k), reservedTagPrefix), "tag key '%s' cannot use the reserved prefix '%s'", k, reservedTagPrefix)
+		catcher.ErrorfWhen(strings.HasPrefix(strings.ToLower(v), reservedTagPrefix), "value for tag '%s' cannot use the reserved prefix '%s'", k, reservedTagPrefix)
+	}
+	return catcher.Resolve()
+}
+
+// ValidateECSTags checks that the tags satisfy the ECS tag constraints.
+func ValidateECSTags(tags Tags) error {
+	return ECSTagConstraints.Validate(tags)
+}
+
+// ValidateSecretsManagerTags checks that the tags satisfy the Secrets Manager
+// tag constraints.
+func ValidateSecretsManagerTags(tags Tags) error {
+	return SecretsManagerTagConstraints.Validate(tags)
+}
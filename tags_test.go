@@ -0,0 +1,118 @@
+package cocoa
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTags(t *testing.T) {
+	t.Run("NewTags", func(t *testing.T) {
+		tags := NewTags()
+		require.NotNil(t, tags)
+		assert.Empty(t, tags)
+	})
+	t.Run("Set", func(t *testing.T) {
+		tags := NewTags().Set("key", "value")
+		assert.Equal(t, Tags{"key": "value"}, tags)
+	})
+	t.Run("SetInitializesNilTags", func(t *testing.T) {
+		var tags Tags
+		tags = tags.Set("key", "value")
+		assert.Equal(t, Tags{"key": "value"}, tags)
+	})
+	t.Run("Add", func(t *testing.T) {
+		tags := NewTags().Set("key0", "val0").Add(map[string]string{"key0": "new_val0", "key1": "val1"})
+		assert.Equal(t, Tags{"key0": "new_val0", "key1": "val1"}, tags)
+	})
+	t.Run("AddInitializesNilTags", func(t *testing.T) {
+		var tags Tags
+		tags = tags.Add(map[string]string{"key": "value"})
+		assert.Equal(t, Tags{"key": "value"}, tags)
+	})
+	t.Run("Remove", func(t *testing.T) {
+		tags := Tags{"key0": "val0", "key1": "val1", "key2": "val2"}.Remove("key0", "key2", "nonexistent")
+		assert.Equal(t, Tags{"key1": "val1"}, tags)
+	})
+	t.Run("Keys", func(t *testing.T) {
+		tags := Tags{"b": "", "c": "", "a": ""}
+		assert.Equal(t, []string{"a", "b", "c"}, tags.Keys())
+	})
+}
+
+func TestTagConstraints(t *testing.T) {
+	for service, validate := range map[string]func(Tags) error{
+		"ECS":            ValidateECSTags,
+		"SecretsManager": ValidateSecretsManagerTags,
+	} {
+		t.Run(service, func(t *testing.T) {
+			t.Run("SucceedsWithNoTags", func(t *testing.T) {
+				assert.NoError(t, validate(nil))
+			})
+			t.Run("SucceedsWithValidTags", func(t *testing.T) {
+				assert.NoError(t, validate(Tags{
+					"key":             "value",
+					"with spaces":     "and_symbols+-=.:/@",
+					"empty-value":     "",
+					"unicode-ключ":    "значение",
+					"owner@team:role": "service/name",
+				}))
+			})
+			t.Run("FailsWithEmptyKey", func(t *testing.T) {
+				assert.Error(t, validate(Tags{"": "value"}))
+			})
+			t.Run("FailsWithReservedKeyPrefix", func(t *testing.T) {
+				assert.Error(t, validate(Tags{"aws:key": "value"}))
+				assert.Error(t, validate(Tags{"AWS:key": "value"}))
+			})
+			t.Run("FailsWithReservedValuePrefix", func(t *testing.T) {
+				assert.Error(t, validate(Tags{"key": "aWs:value"}))
+			})
+			t.Run("FailsWithInvalidCharacters", func(t *testing.T) {
+				assert.Error(t, validate(Tags{"key!": "value"}))
+				assert.Error(t, validate(Tags{"key": "value#"}))
+			})
+			t.Run("FailsWithTooManyTags", func(t *testing.T) {
+				tags := NewTags()
+				for i := 0; i < 51; i++ {
+					tags.Set(strings.Repeat("k", i+1), "value")
+				}
+				assert.Error(t, validate(tags))
+			})
+			t.Run("FailsWithTooLongKey", func(t *testing.T) {
+				assert.Error(t, validate(Tags{strings.Repeat("k", 129): "value"}))
+			})
+			t.Run("FailsWithTooLongValue", func(t *testing.T) {
+				assert.Error(t, validate(Tags{"key": strings.Repeat("v", 257)}))
+			})
+		})
+	}
+	t.Run("ECSAllowsLongerKeysAndValuesThanSecretsManager", func(t *testing.T) {
+		longKey := Tags{strings.Repeat("k", 128): "value"}
+		assert.NoError(t, ValidateECSTags(longKey))
+		assert.Error(t, ValidateSecretsManagerTags(longKey))
+
+		longValue := Tags{"key": strings.Repeat("v", 256)}
+		assert.NoError(t, ValidateECSTags(longValue))
+		assert.Error(t, ValidateSecretsManagerTags(longValue))
+	})
+	t.Run("CountsCharactersRatherThanBytes", func(t *testing.T) {
+		assert.NoError(t, ValidateSecretsManagerTags(Tags{strings.Repeat("é", 127): "value"}))
+	})
+}
+
+func TestTagable(t *testing.T) {
+	tags := Tags{"key": "value"}
+	for name, tagable := range map[string]Tagable{
+		"ECSPodDefinitionOptions": NewECSPodDefinitionOptions().SetTags(tags),
+		"ECSPodExecutionOptions":  NewECSPodExecutionOptions().SetTags(tags),
+		"SecretOptions":           NewSecretOptions().SetTags(tags),
+		"NamedSecret":             NewNamedSecret().SetTags(tags),
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tags, tagable.GetTags())
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package testsupport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	awsECS "github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/ecs"
+	"github.com/evergreen-ci/cocoa/internal/testutil"
+	"github.com/evergreen-ci/cocoa/mock"
+	"github.com/evergreen-ci/cocoa/secret"
+	"github.com/evergreen-ci/cocoa/tag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cleanupTimeout is the maximum amount of time to spend cleaning up AWS
+// resources after a test.
+const cleanupTimeout = time.Minute
+
+// Clients are the clients to use in a test. Depending on the environment, they
+// are either backed by mocks or make actual requests to AWS.
+type Clients struct {
+	// Env is the environment that the clients were created from.
+	Env Environment
+	// ECS is the client to interact with ECS.
+	ECS cocoa.ECSClient
+	// SecretsManager is the client to interact with Secrets Manager.
+	SecretsManager cocoa.SecretsManagerClient
+	// Tag is the client to interact with the Resource Groups Tagging API.
+	Tag cocoa.TagClient
+}
+
+// NewClients returns new clients for the test based on the current
+// environment. In mock mode, this resets the global mock service state, so
+// tests using mock clients must not run in parallel. In AWS mode, this checks
+// that the required AWS environment variables are set and cleans up the test's
+// leftover AWS resources when the test finishes.
+func NewClients(ctx context.Context, t *testing.T) *Clients {
+	env, err := CurrentEnvironment()
+	require.NoError(t, err)
+
+	switch env.Mode {
+	case ModeAWS:
+		return newAWSClients(ctx, t, *env)
+	default:
+		return newMockClients(t, *env)
+	}
+}
+
+func newMockClients(t *testing.T, env Environment) *Clients {
+	resetMockServices(env)
+	t.Cleanup(func() {
+		resetMockServices(env)
+	})
+
+	return &Clients{
+		Env:            env,
+		ECS:            &mock.ECSClient{},
+		SecretsManager: &mock.SecretsManagerClient{},
+		Tag:            &mock.TagClient{},
+	}
+}
+
+// resetMockServices resets the global mock service state so that it only
+// contains the test cluster.
+func resetMockServices(env Environment) {
+	mock.ResetGlobalECSService()
+	mock.GlobalECSService.Clusters[env.ClusterName] = mock.ECSCluster{}
+	mock.ResetGlobalSecretCache()
+}
+
+func newAWSClients(ctx context.Context, t *testing.T, env Environment) *Clients {
+	testutil.CheckAWSEnvVarsForECSAndSecretsManager(t)
+
+	awsOpts := testutil.ValidIntegrationAWSOptions()
+
+	ecsClient, err := ecs.NewBasicClient(ctx, awsOpts)
+	require.NoError(t, err)
+	smClient, err := secret.NewBasicSecretsManagerClient(ctx, awsOpts)
+	require.NoError(t, err)
+	tagClient, err := tag.NewBasicTagClient(ctx, awsOpts)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		// The test's context is usually done by the time the cleanup runs, so
+		// the cleanup needs its own context.
+		cctx, ccancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer ccancel()
+
+		testutil.CleanupTasks(cctx, t, ecsClient)
+		testutil.CleanupTaskDefinitions(cctx, t, ecsClient)
+		testutil.CleanupSecrets(cctx, t, smClient)
+	})
+
+	return &Clients{
+		Env:            env,
+		ECS:            ecsClient,
+		SecretsManager: smClient,
+		Tag:            tagClient,
+	}
+}
+
+// NewVault returns a new vault backed by the Secrets Manager client.
+func (c *Clients) NewVault(t *testing.T) cocoa.Vault {
+	v, err := secret.NewBasicSecretsManager(*secret.NewBasicSecretsManagerOptions().SetClient(c.SecretsManager))
+	require.NoError(t, err)
+	return v
+}
+
+// NewPodCreator returns a new pod creator backed by the ECS client. If a vault
+// is given, the pod creator can also manage secrets.
+func (c *Clients) NewPodCreator(t *testing.T, v cocoa.Vault) cocoa.ECSPodCreator {
+	opts := ecs.NewBasicPodCreatorOptions().SetClient(c.ECS)
+	if v != nil {
+		opts.SetVault(v)
+	}
+	pc, err := ecs.NewBasicPodCreator(*opts)
+	require.NoError(t, err)
+	return pc
+}
+
+// NewPodDefinitionManager returns a new pod definition manager backed by the
+// ECS client. If a vault is given, the pod definition manager can also manage
+// secrets.
+func (c *Clients) NewPodDefinitionManager(t *testing.T, v cocoa.Vault) cocoa.ECSPodDefinitionManager {
+	opts := ecs.NewBasicPodDefinitionManagerOptions().SetClient(c.ECS)
+	if v != nil {
+		opts.SetVault(v)
+	}
+	pdm, err := ecs.NewBasicPodDefinitionManager(*opts)
+	require.NoError(t, err)
+	return pdm
+}
+
+// RegisterTaskDefinition registers a valid task definition for the test and
+// deregisters it when the test finishes.
+func (c *Clients) RegisterTaskDefinition(ctx context.Context, t *testing.T) types.TaskDefinition {
+	out := testutil.RegisterTaskDefinition(ctx, t, c.ECS, testutil.ValidRegisterTaskDefinitionInput(t))
+	t.Cleanup(func() {
+		cctx, ccancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer ccancel()
+
+		_, err := c.ECS.DeregisterTaskDefinition(cctx, &awsECS.DeregisterTaskDefinitionInput{
+			TaskDefinition: out.TaskDefinition.TaskDefinitionArn,
+		})
+		assert.NoError(t, err)
+	})
+	return *out.TaskDefinition
+}
@@ -0,0 +1,8 @@
+/*
+Package testsupport provides helpers for running cocoa's integration test
+suites either against mocks or against real AWS services. The backing services
+are selected by the COCOA_TEST_MODE environment variable, so downstream
+projects can reuse the same test suites against their own AWS accounts without
+modifying the tests.
+*/
+package testsupport
@@ -0,0 +1,86 @@
+package testsupport
+
+import (
+	"os"
+
+	"github.com/evergreen-ci/cocoa/internal/testutil"
+	"github.com/pkg/errors"
+)
+
+// ModeEnvVar is the environment variable that determines whether the test
+// clients are backed by mocks or by real AWS services.
+const ModeEnvVar = "COCOA_TEST_MODE"
+
+// Mode represents the kind of services that back the test clients.
+type Mode string
+
+const (
+	// ModeMock indicates that the test clients are backed by in-memory mocks.
+	// This is the default mode.
+	ModeMock Mode = "mock"
+	// ModeAWS indicates that the test clients make actual requests to AWS.
+	ModeAWS Mode = "aws"
+)
+
+// Validate checks that the mode is recognized.
+func (m Mode) Validate() error {
+	switch m {
+	case ModeMock, ModeAWS:
+		return nil
+	default:
+		return errors.Errorf("unrecognized test mode '%s'", m)
+	}
+}
+
+// CurrentMode returns the test mode from the environment variable. If it is
+// not set, it defaults to mock mode.
+func CurrentMode() (Mode, error) {
+	m := Mode(os.Getenv(ModeEnvVar))
+	if m == "" {
+		return ModeMock, nil
+	}
+	if err := m.Validate(); err != nil {
+		return "", errors.Wrapf(err, "invalid value for environment variable '%s'", ModeEnvVar)
+	}
+	return m, nil
+}
+
+// Environment represents the test settings that are read from the
+// environment.
+type Environment struct {
+	// Mode is the kind of services that back the test clients.
+	Mode Mode
+	// Role is the IAM role to assume when making requests to AWS.
+	Role string
+	// ClusterName is the name of the ECS cluster to run pods in.
+	ClusterName string
+	// CapacityProvider is the ECS capacity provider to run pods with.
+	CapacityProvider string
+	// TaskRole is the ECS task role to give to pods.
+	TaskRole string
+	// ExecutionRole is the ECS execution role to give to pods.
+	ExecutionRole string
+	// TaskDefinitionPrefix is the prefix for the names of test task
+	// definitions.
+	TaskDefinitionPrefix string
+	// SecretPrefix is the prefix for the names of test secrets.
+	SecretPrefix string
+}
+
+// CurrentEnvironment returns the test settings from the environment variables.
+func CurrentEnvironment() (*Environment, error) {
+	m, err := CurrentMode()
+	if err != nil {
+		return nil, err
+	}
+	return &Environment{
+		Mode:                 m,
+		Role:                 testutil.AWSRole(),
+		ClusterName:          testutil.ECSClusterName(),
+		CapacityProvider:     testutil.ECSCapacityProvider(),
+		TaskRole:             testutil.ECSTaskRole(),
+		ExecutionRole:        testutil.ECSExecutionRole(),
+		TaskDefinitionPrefix: testutil.TaskDefinitionPrefix(),
+		SecretPrefix:         testutil.SecretPrefix(),
+	}, nil
+}
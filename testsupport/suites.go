@@ -0,0 +1,121 @@
+package testsupport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/evergreen-ci/cocoa"
+	"github.com/evergreen-ci/cocoa/internal/testcase"
+)
+
+// ECSClientTestCase represents a test case for a cocoa.ECSClient.
+type ECSClientTestCase = testcase.ECSClientTestCase
+
+// ECSClientTests returns common test cases that a cocoa.ECSClient should
+// support.
+func ECSClientTests() map[string]ECSClientTestCase {
+	return testcase.ECSClientTests()
+}
+
+// ECSClientRegisteredTaskDefinitionTestCase represents a test case for a
+// cocoa.ECSClient with a task definition already registered.
+type ECSClientRegisteredTaskDefinitionTestCase = testcase.ECSClientRegisteredTaskDefinitionTestCase
+
+// ECSClientRegisteredTaskDefinitionTests returns common test cases that a
+// cocoa.ECSClient should support with a task definition already registered.
+func ECSClientRegisteredTaskDefinitionTests() map[string]ECSClientRegisteredTaskDefinitionTestCase {
+	return testcase.ECSClientRegisteredTaskDefinitionTests()
+}
+
+// ECSPodCreatorTestCase represents a test case for a cocoa.ECSPodCreator.
+type ECSPodCreatorTestCase = testcase.ECSPodCreatorTestCase
+
+// ECSPodCreatorTests returns common test cases that a cocoa.ECSPodCreator
+// should support.
+func ECSPodCreatorTests() map[string]ECSPodCreatorTestCase {
+	return testcase.ECSPodCreatorTests()
+}
+
+// ECSPodCreatorVaultTests returns common test cases that a cocoa.ECSPodCreator
+// should support with a cocoa.Vault.
+func ECSPodCreatorVaultTests() map[string]ECSPodCreatorTestCase {
+	return testcase.ECSPodCreatorVaultTests()
+}
+
+// ECSPodCreatorRegisteredTaskDefinitionTestCase represents a test case for a
+// cocoa.ECSPodCreator with a task definition already registered.
+type ECSPodCreatorRegisteredTaskDefinitionTestCase func(ctx context.Context, t *testing.T, c cocoa.ECSPodCreator, def types.TaskDefinition)
+
+// ECSPodCreatorRegisteredTaskDefinitionTests returns common test cases that a
+// cocoa.ECSPodCreator should support with a task definition already
+// registered.
+func ECSPodCreatorRegisteredTaskDefinitionTests() map[string]ECSPodCreatorRegisteredTaskDefinitionTestCase {
+	tests := map[string]ECSPodCreatorRegisteredTaskDefinitionTestCase{}
+	for tName, tCase := range testcase.ECSPodCreatorRegisteredTaskDefinitionTests() {
+		tests[tName] = tCase
+	}
+	return tests
+}
+
+// ECSPodTestCase represents a test case for a cocoa.ECSPod.
+type ECSPodTestCase = testcase.ECSPodTestCase
+
+// ECSPodTests returns common test cases that a cocoa.ECSPod should support.
+func ECSPodTests() map[string]ECSPodTestCase {
+	return testcase.ECSPodTests()
+}
+
+// ECSPodDefinitionManagerTestCase represents a test case for a
+// cocoa.ECSPodDefinitionManager.
+type ECSPodDefinitionManagerTestCase = testcase.ECSPodDefinitionManagerTestCase
+
+// ECSPodDefinitionManagerTests returns common test cases that a
+// cocoa.ECSPodDefinitionManager should support.
+func ECSPodDefinitionManagerTests() map[string]ECSPodDefinitionManagerTestCase {
+	return testcase.ECSPodDefinitionManagerTests()
+}
+
+// ECSPodDefinitionManagerVaultTests returns common test cases that a
+// cocoa.ECSPodDefinitionManager should support with a cocoa.Vault.
+func ECSPodDefinitionManagerVaultTests() map[string]ECSPodDefinitionManagerTestCase {
+	return testcase.ECSPodDefinitionManagerVaultTests()
+}
+
+// SecretsManagerClientTestCase represents a test case for a
+// cocoa.SecretsManagerClient.
+type SecretsManagerClientTestCase = testcase.SecretsManagerClientTestCase
+
+// SecretsManagerClientTests returns common test cases that a
+// cocoa.SecretsManagerClient should support.
+func SecretsManagerClientTests() map[string]SecretsManagerClientTestCase {
+	return testcase.SecretsManagerClientTests()
+}
+
+// TagClientTestCase represents a test case for a cocoa.TagClient.
+type TagClientTestCase = testcase.TagClientTestCase
+
+// TagClientTests returns common test cases that a cocoa.TagClient should
+// support.
+func TagClientTests() map[string]TagClientTestCase {
+	return testcase.TagClientTests()
+}
+
+// TagClientSecretTestCase represents a test case for a cocoa.TagClient that
+// tags secrets.
+type TagClientSecretTestCase = testcase.TagClientSecretTestCase
+
+// TagClientSecretTests returns common test cases that a cocoa.TagClient should
+// support for secrets.
+func TagClientSecretTests() map[string]TagClientSecretTestCase {
+	return testcase.TagClientSecretTests()
+}
+
+// VaultTestCase represents a test case for a cocoa.Vault.
+type VaultTestCase = testcase.VaultTestCase
+
+// VaultTests returns common test cases that a cocoa.Vault should support. The
+// cleanupSecret function is called to clean up secrets created by the tests.
+func VaultTests(cleanupSecret func(ctx context.Context, t *testing.T, v cocoa.Vault, id string)) map[string]VaultTestCase {
+	return testcase.VaultTests(cleanupSecret)
+}
@@ -0,0 +1,95 @@
+package testsupport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cocoa/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const defaultTestTimeout = time.Second
+
+func TestCurrentMode(t *testing.T) {
+	t.Run("DefaultsToMock", func(t *testing.T) {
+		t.Setenv(ModeEnvVar, "")
+		m, err := CurrentMode()
+		require.NoError(t, err)
+		assert.Equal(t, ModeMock, m)
+	})
+	t.Run("ReturnsAWS", func(t *testing.T) {
+		t.Setenv(ModeEnvVar, string(ModeAWS))
+		m, err := CurrentMode()
+		require.NoError(t, err)
+		assert.Equal(t, ModeAWS, m)
+	})
+	t.Run("FailsWithUnrecognizedMode", func(t *testing.T) {
+		t.Setenv(ModeEnvVar, "foo")
+		m, err := CurrentMode()
+		assert.Error(t, err)
+		assert.Zero(t, m)
+	})
+}
+
+func TestCurrentEnvironment(t *testing.T) {
+	t.Setenv(ModeEnvVar, string(ModeMock))
+	t.Setenv("AWS_ECS_CLUSTER", "cluster")
+	t.Setenv("AWS_ECS_TASK_ROLE", "task_role")
+	t.Setenv("AWS_ECS_EXECUTION_ROLE", "execution_role")
+
+	env, err := CurrentEnvironment()
+	require.NoError(t, err)
+	require.NotZero(t, env)
+	assert.Equal(t, ModeMock, env.Mode)
+	assert.Equal(t, "cluster", env.ClusterName)
+	assert.Equal(t, "task_role", env.TaskRole)
+	assert.Equal(t, "execution_role", env.ExecutionRole)
+}
+
+func TestNewClientsWithMocks(t *testing.T) {
+	t.Setenv(ModeEnvVar, string(ModeMock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("ReturnsMockClients", func(t *testing.T) {
+		c := NewClients(ctx, t)
+		assert.IsType(t, &mock.ECSClient{}, c.ECS)
+		assert.IsType(t, &mock.SecretsManagerClient{}, c.SecretsManager)
+		assert.IsType(t, &mock.TagClient{}, c.Tag)
+		assert.Contains(t, mock.GlobalECSService.Clusters, c.Env.ClusterName)
+	})
+
+	for tName, tCase := range ECSPodCreatorTests() {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			c := NewClients(tctx, t)
+			tCase(tctx, t, c.NewPodCreator(t, nil))
+		})
+	}
+
+	for tName, tCase := range ECSPodCreatorVaultTests() {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			c := NewClients(tctx, t)
+			tCase(tctx, t, c.NewPodCreator(t, c.NewVault(t)))
+		})
+	}
+
+	for tName, tCase := range ECSPodCreatorRegisteredTaskDefinitionTests() {
+		t.Run(tName, func(t *testing.T) {
+			tctx, tcancel := context.WithTimeout(ctx, defaultTestTimeout)
+			defer tcancel()
+
+			c := NewClients(tctx, t)
+			def := c.RegisterTaskDefinition(tctx, t)
+			tCase(tctx, t, c.NewPodCreator(t, nil), def)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package cocoa
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CPUUnitsPerVCPU is the number of CPU units equivalent to 1 vCPU.
+	CPUUnitsPerVCPU = 1024
+	// MBPerGiB is the number of MB of memory in 1 GiB.
+	MBPerGiB = 1024
+
+	// MinPodCPU is the minimum pod-level CPU (in CPU units) that ECS allows.
+	MinPodCPU CPUUnits = 128
+	// MaxCPU is the maximum pod-level or container-level CPU (in CPU units)
+	// that ECS allows.
+	MaxCPU CPUUnits = 192 * CPUUnitsPerVCPU
+	// MinMemoryMB is the minimum pod-level or container-level memory (in MB)
+	// that ECS allows.
+	MinMemoryMB MemoryMB = 6
+)
+
+// CPUUnits is an amount of CPU in CPU units, which is the unit that ECS uses
+// for CPU. 1024 CPU units is equivalent to 1 vCPU.
+type CPUUnits int
+
+// VCPUs returns the number of CPU units equivalent to the given number of
+// vCPUs (e.g. VCPUs(0.5) is 512 CPU units). The result is rounded to the
+// nearest whole CPU unit.
+func VCPUs(vcpus float64) CPUUnits {
+	return CPUUnits(math.Round(vcpus * CPUUnitsPerVCPU))
+}
+
+// VCPUs returns the number of vCPUs equivalent to the CPU units.
+func (u CPUUnits) VCPUs() float64 {
+	return float64(u) / CPUUnitsPerVCPU
+}
+
+// ValidatePod checks that the CPU units are within the range that ECS allows
+// for an entire pod.
+func (u CPUUnits) ValidatePod() error {
+	if u < MinPodCPU || u > MaxCPU {
+		return errors.Errorf("pod CPU must be between %d and %d CPU units (%g to %g vCPUs), but got %d CPU units", MinPodCPU, MaxCPU, MinPodCPU.VCPUs(), MaxCPU.VCPUs(), u)
+	}
+	return nil
+}
+
+// ValidateContainer checks that the CPU units are within the range that ECS
+// allows for a single container.
+func (u CPUUnits) ValidateContainer() error {
+	if u <= 0 || u > MaxCPU {
+		return errors.Errorf("container CPU must be positive and at most %d CPU units (%g vCPUs), but got %d CPU units", MaxCPU, MaxCPU.VCPUs(), u)
+	}
+	return nil
+}
+
+// MemoryMB is an amount of memory in MB, which is the unit that ECS uses for
+// memory.
+type MemoryMB int
+
+// GiB returns the amount of memory in MB equivalent to the given amount of
+// memory in GiB (e.g. GiB(2) is 2048 MB). The result is rounded to the nearest
+// whole MB.
+func GiB(gib float64) MemoryMB {
+	return MemoryMB(math.Round(gib * MBPerGiB))
+}
+
+// GiB returns the amount of memory in GiB equivalent to the memory in MB.
+func (m MemoryMB) GiB() float64 {
+	return float64(m) / MBPerGiB
+}
+
+// Validate checks that the memory is at least the minimum amount that ECS
+// allows for a pod or container.
+func (m MemoryMB) Validate() error {
+	if m < MinMemoryMB {
+		return errors.Errorf("memory must be at least %d MB, but got %d MB", MinMemoryMB, m)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cocoa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCPUUnits(t *testing.T) {
+	t.Run("VCPUs", func(t *testing.T) {
+		assert.Equal(t, CPUUnits(128), VCPUs(0.125))
+		assert.Equal(t, CPUUnits(512), VCPUs(0.5))
+		assert.Equal(t, CPUUnits(1024), VCPUs(1))
+		assert.Equal(t, CPUUnits(4096), VCPUs(4))
+		assert.Equal(t, CPUUnits(1), VCPUs(0.001), "should round to the nearest CPU unit")
+	})
+	t.Run("ConvertsToVCPUs", func(t *testing.T) {
+		assert.Equal(t, 0.25, CPUUnits(256).VCPUs())
+		assert.Equal(t, 2.0, VCPUs(2).VCPUs())
+	})
+	t.Run("ValidatePod", func(t *testing.T) {
+		for _, u := range []CPUUnits{MinPodCPU, VCPUs(0.25), VCPUs(16), MaxCPU} {
+			assert.NoError(t, u.ValidatePod(), u)
+		}
+		for _, u := range []CPUUnits{-1, 0, 2, MinPodCPU - 1, MaxCPU + 1} {
+			assert.Error(t, u.ValidatePod(), u)
+		}
+	})
+	t.Run("ValidateContainer", func(t *testing.T) {
+		for _, u := range []CPUUnits{1, 2, VCPUs(0.5), MaxCPU} {
+			assert.NoError(t, u.ValidateContainer(), u)
+		}
+		for _, u := range []CPUUnits{-1, 0, MaxCPU + 1} {
+			assert.Error(t, u.ValidateContainer(), u)
+		}
+	})
+}
+
+func TestMemoryMB(t *testing.T) {
+	t.Run("GiB", func(t *testing.T) {
+		assert.Equal(t, MemoryMB(512), GiB(0.5))
+		assert.Equal(t, MemoryMB(2048), GiB(2))
+		assert.Equal(t, MemoryMB(30720), GiB(30))
+	})
+	t.Run("ConvertsToGiB", func(t *testing.T) {
+		assert.Equal(t, 0.5, MemoryMB(512).GiB())
+		assert.Equal(t, 8.0, GiB(8).GiB())
+	})
+	t.Run("Validate", func(t *testing.T) {
+		for _, m := range []MemoryMB{MinMemoryMB, 128, GiB(4)} {
+			assert.NoError(t, m.Validate(), m)
+		}
+		for _, m := range []MemoryMB{-1, 0, 2, MinMemoryMB - 1} {
+			assert.Error(t, m.Validate(), m)
+		}
+	})
+}
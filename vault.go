@@ -2,6 +2,7 @@ package cocoa
 
 import (
 	"context"
+	"time"
 
 	"github.com/mongodb/grip"
 )
@@ -28,6 +29,8 @@ type NamedSecret struct {
 	Name *string
 	// Value is the stored value of the secret.
 	Value *string
+	// Tags are resource tags to apply to the secret when it is created.
+	Tags Tags
 }
 
 // NewNamedSecret returns a new uninitialized named secret.
@@ -47,11 +50,156 @@ func (s *NamedSecret) SetValue(value string) *NamedSecret {
 	return s
 }
 
+// SetTags sets the tags to apply to the secret when it is created. This
+// overwrites any existing tags.
+func (s *NamedSecret) SetTags(tags map[string]string) *NamedSecret {
+	s.Tags = tags
+	return s
+}
+
+// AddTags adds new tags to the existing ones to apply to the secret when it is
+// created.
+func (s *NamedSecret) AddTags(tags map[string]string) *NamedSecret {
+	s.Tags = s.Tags.Add(tags)
+	return s
+}
+
+// GetTags returns the tags to apply to the secret when it is created.
+func (s *NamedSecret) GetTags() Tags {
+	return s.Tags
+}
+
 // Validate checks that both the name and value for the secret are set.
 func (s *NamedSecret) Validate() error {
 	catcher := grip.NewBasicCatcher()
 	catcher.NewWhen(s.Name == nil, "must specify a name")
 	catcher.NewWhen(s.Name != nil && *s.Name == "", "cannot specify an empty name")
 	catcher.NewWhen(s.Value == nil, "must specify a value")
+	catcher.Wrap(ValidateSecretsManagerTags(s.Tags), "invalid tags")
+	return catcher.Resolve()
+}
+
+// SecretFinder represents a vault that can look up existing secrets by name.
+// Vaults that implement it allow secrets to be created only if they do not
+// already exist.
+type SecretFinder interface {
+	Vault
+	// FindSecretID returns the unique identifier of the existing secret with
+	// the given name. If no such secret exists, it returns an empty ID.
+	FindSecretID(ctx context.Context, name string) (id string, err error)
+}
+
+// SecretMetadata contains information about a stored secret other than its
+// value.
+type SecretMetadata struct {
+	// ID is the unique identifier for the secret.
+	ID string
+	// Name is the friendly human-readable name of the secret.
+	Name string
+	// Created is when the secret was created.
+	Created time.Time
+	// LastChanged is when the secret was last modified.
+	LastChanged time.Time
+	// RotationEnabled indicates whether the secret is configured to rotate
+	// automatically.
+	RotationEnabled bool
+	// RotationLambdaARN is the ARN of the Lambda function that rotates the
+	// secret.
+	RotationLambdaARN string
+	// RotationIntervalDays is the number of days between automatic rotations
+	// of the secret. This is zero if the secret has no rotation schedule.
+	RotationIntervalDays int
+	// LastRotated is when the secret was last rotated. This is zero if the
+	// secret has never been rotated.
+	LastRotated time.Time
+	// Deleted is when the secret is scheduled to be permanently deleted. This
+	// is zero if the secret is not scheduled for deletion.
+	Deleted time.Time
+}
+
+// SecretRotationOptions represent options to rotate a secret automatically.
+type SecretRotationOptions struct {
+	// LambdaARN is the ARN of the Lambda function that rotates the secret.
+	LambdaARN *string
+	// IntervalDays is the number of days between automatic rotations of the
+	// secret. It must be between 1 and 1000 days.
+	IntervalDays *int
+	// RotateImmediately indicates whether the secret should be rotated as soon
+	// as rotation is enabled rather than waiting for the next scheduled
+	// rotation. By default, it is rotated immediately.
+	RotateImmediately *bool
+}
+
+// NewSecretRotationOptions returns new uninitialized options to rotate a
+// secret.
+func NewSecretRotationOptions() *SecretRotationOptions {
+	return &SecretRotationOptions{}
+}
+
+// SetLambdaARN sets the ARN of the Lambda function that rotates the secret.
+func (o *SecretRotationOptions) SetLambdaARN(arn string) *SecretRotationOptions {
+	o.LambdaARN = &arn
+	return o
+}
+
+// SetIntervalDays sets the number of days between automatic rotations of the
+// secret.
+func (o *SecretRotationOptions) SetIntervalDays(days int) *SecretRotationOptions {
+	o.IntervalDays = &days
+	return o
+}
+
+// SetRotateImmediately sets whether the secret should be rotated as soon as
+// rotation is enabled.
+func (o *SecretRotationOptions) SetRotateImmediately(rotate bool) *SecretRotationOptions {
+	o.RotateImmediately = &rotate
+	return o
+}
+
+const (
+	minSecretRotationIntervalDays = 1
+	maxSecretRotationIntervalDays = 1000
+)
+
+// Validate checks that the Lambda function and the rotation interval are
+// given and that the rotation interval is within the allowed range.
+func (o *SecretRotationOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.LambdaARN == nil, "must specify a rotation Lambda ARN")
+	catcher.NewWhen(o.LambdaARN != nil && *o.LambdaARN == "", "cannot specify an empty rotation Lambda ARN")
+	catcher.NewWhen(o.IntervalDays == nil, "must specify a rotation interval")
+	catcher.ErrorfWhen(o.IntervalDays != nil && (*o.IntervalDays < minSecretRotationIntervalDays || *o.IntervalDays > maxSecretRotationIntervalDays), "rotation interval must be between %d and %d days", minSecretRotationIntervalDays, maxSecretRotationIntervalDays)
 	return catcher.Resolve()
 }
+
+// SecretDescriber represents a vault that can return metadata about existing
+// secrets.
+type SecretDescriber interface {
+	Vault
+	// DescribeSecret returns the metadata for the existing secret identified
+	// by ID.
+	DescribeSecret(ctx context.Context, id string) (*SecretMetadata, error)
+}
+
+// ValueEncrypter represents a vault that can encrypt secret values before it
+// stores them. ECS resolves the secrets that pods reference directly from the
+// secret store rather than through the vault, so a vault that encrypts values
+// cannot be used to create the secrets for pods.
+type ValueEncrypter interface {
+	Vault
+	// EncryptsValues returns whether or not the vault encrypts secret values
+	// before it stores them.
+	EncryptsValues() bool
+}
+
+// SecretRotator represents a vault that can manage the automatic rotation
+// schedule of existing secrets.
+type SecretRotator interface {
+	Vault
+	// EnableRotation configures the secret identified by ID to rotate
+	// automatically on the given schedule.
+	EnableRotation(ctx context.Context, id string, opts SecretRotationOptions) error
+	// DisableRotation stops the secret identified by ID from rotating
+	// automatically.
+	DisableRotation(ctx context.Context, id string) error
+}
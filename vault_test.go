@@ -24,6 +24,16 @@ func TestNamedSecret(t *testing.T) {
 		s := NewNamedSecret().SetValue(val)
 		assert.Equal(t, val, utility.FromStringPtr(s.Value))
 	})
+	t.Run("SetTags", func(t *testing.T) {
+		tags := Tags{"key": "value"}
+		s := NewNamedSecret().SetTags(tags)
+		assert.Equal(t, tags, s.Tags)
+		assert.Equal(t, tags, s.GetTags())
+	})
+	t.Run("AddTags", func(t *testing.T) {
+		s := NewNamedSecret().AddTags(map[string]string{"key0": "val0"}).AddTags(map[string]string{"key1": "val1"})
+		assert.Equal(t, Tags{"key0": "val0", "key1": "val1"}, s.Tags)
+	})
 	t.Run("Validate", func(t *testing.T) {
 		t.Run("EmptyIsInvalid", func(t *testing.T) {
 			s := NewNamedSecret()
@@ -45,5 +55,64 @@ func TestNamedSecret(t *testing.T) {
 			s := NewNamedSecret().SetName("name")
 			assert.Error(t, s.Validate())
 		})
+		t.Run("ValidTagsAreValid", func(t *testing.T) {
+			s := NewNamedSecret().SetName("name").SetValue("value").SetTags(map[string]string{"key": "value"})
+			assert.NoError(t, s.Validate())
+		})
+		t.Run("InvalidTagsAreInvalid", func(t *testing.T) {
+			s := NewNamedSecret().SetName("name").SetValue("value").SetTags(map[string]string{"": "value"})
+			assert.Error(t, s.Validate())
+		})
+	})
+}
+
+func TestSecretRotationOptions(t *testing.T) {
+	t.Run("NewSecretRotationOptions", func(t *testing.T) {
+		opts := NewSecretRotationOptions()
+		require.NotZero(t, opts)
+		assert.Zero(t, *opts)
+	})
+	t.Run("SetLambdaARN", func(t *testing.T) {
+		arn := "lambda_arn"
+		opts := NewSecretRotationOptions().SetLambdaARN(arn)
+		assert.Equal(t, arn, utility.FromStringPtr(opts.LambdaARN))
+	})
+	t.Run("SetIntervalDays", func(t *testing.T) {
+		opts := NewSecretRotationOptions().SetIntervalDays(30)
+		assert.Equal(t, 30, utility.FromIntPtr(opts.IntervalDays))
+	})
+	t.Run("SetRotateImmediately", func(t *testing.T) {
+		opts := NewSecretRotationOptions().SetRotateImmediately(false)
+		require.NotZero(t, opts.RotateImmediately)
+		assert.False(t, *opts.RotateImmediately)
+	})
+	t.Run("Validate", func(t *testing.T) {
+		t.Run("EmptyIsInvalid", func(t *testing.T) {
+			assert.Error(t, NewSecretRotationOptions().Validate())
+		})
+		t.Run("LambdaARNAndIntervalIsValid", func(t *testing.T) {
+			opts := NewSecretRotationOptions().SetLambdaARN("lambda_arn").SetIntervalDays(30)
+			assert.NoError(t, opts.Validate())
+		})
+		t.Run("MissingLambdaARNIsInvalid", func(t *testing.T) {
+			opts := NewSecretRotationOptions().SetIntervalDays(30)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("EmptyLambdaARNIsInvalid", func(t *testing.T) {
+			opts := NewSecretRotationOptions().SetLambdaARN("").SetIntervalDays(30)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("MissingIntervalIsInvalid", func(t *testing.T) {
+			opts := NewSecretRotationOptions().SetLambdaARN("lambda_arn")
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("ZeroIntervalIsInvalid", func(t *testing.T) {
+			opts := NewSecretRotationOptions().SetLambdaARN("lambda_arn").SetIntervalDays(0)
+			assert.Error(t, opts.Validate())
+		})
+		t.Run("IntervalAboveMaxIsInvalid", func(t *testing.T) {
+			opts := NewSecretRotationOptions().SetLambdaARN("lambda_arn").SetIntervalDays(maxSecretRotationIntervalDays + 1)
+			assert.Error(t, opts.Validate())
+		})
 	})
 }